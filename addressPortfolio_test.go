@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/RiemaLabs/modular-indexer-committee/apis"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/getter"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+func Test_AddressPortfolio(t *testing.T) {
+	pkscriptA := "0014" + strings.Repeat("a3", 20)
+	pkscriptB := "0014" + strings.Repeat("b3", 20)
+	transfer := strings.Repeat("c", 64) + "i3"
+	move := inscribe(transfer, pkscriptB, "", `{"p":"brc-20","op":"transfer","tick":"pflb","amt":"10"}`)
+	move.OldSatpoint = transfer + ":0:0"
+	g := &blocksGetter{
+		blocks: map[uint][]getter.OrdTransfer{
+			800001: {
+				inscribe(strings.Repeat("4", 64)+"i0", pkscriptA, "", `{"p":"brc-20","op":"deploy","tick":"pfla","max":"100","dec":"1"}`),
+				inscribe(strings.Repeat("4", 64)+"i1", pkscriptA, "", `{"p":"brc-20","op":"deploy","tick":"pflb","max":"100"}`),
+			},
+			800002: {
+				inscribe(strings.Repeat("5", 64)+"i0", pkscriptA, "", `{"p":"brc-20","op":"mint","tick":"pfla","amt":"5.5"}`),
+				inscribe(strings.Repeat("5", 64)+"i1", pkscriptA, "", `{"p":"brc-20","op":"mint","tick":"pflb","amt":"10"}`),
+				inscribe(strings.Repeat("5", 64)+"i2", pkscriptA, "", `{"p":"brc-20","op":"transfer","tick":"pfla","amt":"2"}`),
+				inscribe(transfer, pkscriptA, "", `{"p":"brc-20","op":"transfer","tick":"pflb","amt":"10"}`),
+			},
+			// Moving all of pflb away drops it from the portfolio.
+			800003: {move},
+		},
+		hashes: make(map[uint]string),
+	}
+	header := stateless.LoadHeader(false, 800000)
+	queue, err := stateless.NewQueues(g, header, true, 800001)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := apis.NewRouter(queue, "brc-20", false, false)
+	portfolio := func(query string) (int, apis.Brc20PortfolioResponse) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/brc20_portfolio?"+query, nil))
+		var resp apis.Brc20PortfolioResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Unexpected response %d: %s", w.Code, w.Body.String())
+		}
+		return w.Code, resp
+	}
+
+	code, resp := portfolio("pkscript=" + pkscriptA)
+	if code != http.StatusOK || resp.Result.Total != 1 || len(resp.Result.Balances) != 1 || resp.Result.Height != queue.Header.Height {
+		t.Fatalf("Unexpected portfolio %d: %+v", code, resp.Result)
+	}
+	expected := apis.Brc20PortfolioBalance{
+		Brc20VerifiableTickBalance: apis.Brc20VerifiableTickBalance{Tick: "pfla", AvailableBalance: "3500000000000000000", OverallBalance: "5500000000000000000"},
+		Decimals:                   1,
+		Available:                  "3.5",
+		Overall:                    "5.5",
+	}
+	if resp.Result.Balances[0] != expected {
+		t.Fatalf("Unexpected balance %+v", resp.Result.Balances[0])
+	}
+	rootC := queue.Header.Root.Commit()
+	if _, err := apis.VerifyPortfolio(rootC, &resp); err != nil {
+		t.Fatal(err)
+	}
+	resp.Result.Balances[0].OverallBalance = "1"
+	if _, err := apis.VerifyPortfolio(rootC, &resp); err == nil {
+		t.Fatal("Expected the tampered portfolio to be rejected")
+	}
+
+	code, resp = portfolio("pkscript=" + pkscriptB)
+	if code != http.StatusOK || len(resp.Result.Balances) != 1 || resp.Result.Balances[0].Tick != "pflb" || resp.Result.Balances[0].Overall != "10" {
+		t.Fatalf("Unexpected portfolio %d: %+v", code, resp.Result)
+	}
+	code, resp = portfolio("pkscript=0014" + strings.Repeat("d3", 20))
+	if code != http.StatusOK || resp.Result.Total != 0 || resp.Proof != nil {
+		t.Fatalf("Unexpected empty portfolio %d: %+v", code, resp.Result)
+	}
+	if code, _ := portfolio("pkscript=" + pkscriptA + "&limit=1000"); code != http.StatusBadRequest {
+		t.Fatalf("Expected too many ticks to be rejected, got %d", code)
+	}
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/holiman/uint256"
+
+	"github.com/RiemaLabs/modular-indexer-committee/apis"
+	"github.com/RiemaLabs/modular-indexer-committee/checkpoint"
+	"github.com/RiemaLabs/modular-indexer-committee/ord"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/brc20"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/getter"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+func Test_AdminControl(t *testing.T) {
+	pkscript := "0014" + strings.Repeat("ad", 20)
+	g := &blocksGetter{
+		blocks: map[uint][]getter.OrdTransfer{
+			800001: {inscribe(strings.Repeat("7", 64)+"i0", pkscript, "", `{"p":"brc-20","op":"deploy","tick":"ctrl","max":"100"}`)},
+			800003: {inscribe(strings.Repeat("7", 64)+"i1", pkscript, "", `{"p":"brc-20","op":"mint","tick":"ctrl","amt":"10"}`)},
+		},
+		hashes: make(map[uint]string),
+	}
+	header := stateless.LoadHeader(false, 800000)
+	queue, err := stateless.NewQueues(g, header, true, 800001)
+	if err != nil {
+		t.Fatal(err)
+	}
+	latestHeight := queue.LatestHeight()
+
+	apis.Admin = &apis.AdminService{Tokens: func() []string { return []string{"operator"} }}
+	defer func() { apis.Admin = nil }()
+	ts := httptest.NewServer(apis.NewRouter(queue, "brc-20", false, false))
+	defer ts.Close()
+	call := func(method, path string) (int, apis.ControlResponse) {
+		req, err := http.NewRequest(method, ts.URL+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "Bearer operator")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var res apis.ControlResponse
+		if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode, res
+	}
+
+	if status, res := call(http.MethodPost, "/v1/admin/pause"); status != http.StatusOK || !res.Result.Paused || !apis.Admin.Paused() {
+		t.Fatalf("Unexpected pause %d %+v", status, res.Result)
+	}
+	if status, res := call(http.MethodPost, "/v1/admin/resume"); status != http.StatusOK || res.Result.Paused {
+		t.Fatalf("Unexpected resume %d %+v", status, res.Result)
+	}
+	select {
+	case <-apis.Admin.Wake():
+	default:
+		t.Fatal("Expected the service loop to be woken up")
+	}
+
+	// Only the kept blocks are resynced.
+	if status, _ := call(http.MethodPost, "/v1/admin/resync?height=800000"); status != http.StatusBadRequest {
+		t.Fatalf("Unexpected status %d of a resync before the kept blocks", status)
+	}
+	status, res := call(http.MethodPost, "/v1/admin/resync?height=800003")
+	if status != http.StatusAccepted || res.Result.PendingResync != 800003 {
+		t.Fatalf("Unexpected resync %d %+v", status, res.Result)
+	}
+	if status, res := call(http.MethodPost, "/v1/admin/republish"); status != http.StatusAccepted || len(res.Result.PendingRepublish) != 1 || res.Result.PendingRepublish[0] != latestHeight {
+		t.Fatalf("Unexpected republish %d %+v", status, res.Result)
+	}
+	resync, republish := apis.Admin.TakeRequests()
+	if resync != 800003 || len(republish) != 1 {
+		t.Fatalf("Unexpected requests %d %v", resync, republish)
+	}
+
+	// The getter served the mint by mistake, which is fixed upstream and dropped by the resync.
+	balance := func() *uint256.Int {
+		_, _, available, _, err := brc20.GetBalances(queue.Header, "ctrl", ord.Pkscript(pkscript))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return available
+	}
+	if balance().IsZero() {
+		t.Fatal("Expected the mint to be executed")
+	}
+	delete(g.blocks, 800003)
+	if err := queue.Recovery(g, resync); err != nil {
+		t.Fatal(err)
+	}
+	if !balance().IsZero() || queue.LatestHeight() != latestHeight {
+		t.Fatalf("Expected the mint to be dropped by the resync, got %s at height %d", balance(), queue.LatestHeight())
+	}
+
+	// The republished checkpoint is uploaded regardless of the schedule.
+	report := GlobalConfig.Report
+	defer func() {
+		GlobalConfig.Report = report
+		CheckpointQueue = nil
+	}()
+	GlobalConfig.Report.Targets = []string{"Local"}
+	GlobalConfig.Report.Local.Dir = t.TempDir()
+	if CheckpointQueue, err = checkpoint.NewQueue(checkpoint.QueueConfig{}); err != nil {
+		t.Fatal(err)
+	}
+	arguments := RuntimeArguments{EnableCommittee: true}
+	if err := republishCheckpoints(&arguments, queue, republish); err != nil {
+		t.Fatal(err)
+	}
+	files, err := os.ReadDir(GlobalConfig.Report.Local.Dir)
+	if err != nil || len(files) != 1 {
+		t.Fatalf("Expected the republished checkpoint, got %d files: %v", len(files), err)
+	}
+}
+
+func Test_AdminJournal(t *testing.T) {
+	g := &blocksGetter{blocks: map[uint][]getter.OrdTransfer{}, hashes: make(map[uint]string)}
+	header := stateless.LoadHeader(false, 800000)
+	queue, err := stateless.NewQueues(g, header, true, 800001)
+	if err != nil {
+		t.Fatal(err)
+	}
+	latestHeight := queue.LatestHeight()
+
+	journal := filepath.Join(t.TempDir(), "journal.json")
+	tokens := func() []string { return []string{"operator", "another"} }
+	apis.Admin = &apis.AdminService{Tokens: tokens, Journal: journal}
+	defer func() { apis.Admin = nil }()
+	if err := apis.Admin.LoadJournal(); err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(apis.NewRouter(queue, "brc-20", false, false))
+	defer ts.Close()
+	call := func(url, method, path, token, key string, res any) int {
+		req, err := http.NewRequest(method, url+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		if key != "" {
+			req.Header.Set(apis.HeaderIdempotencyKey, key)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if err := json.NewDecoder(resp.Body).Decode(res); err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode
+	}
+
+	resync := "/v1/admin/resync?height=" + strconv.FormatUint(uint64(latestHeight), 10)
+	var accepted apis.ControlResponse
+	if status := call(ts.URL, http.MethodPost, resync, "operator", "rollback-1", &accepted); status != http.StatusAccepted {
+		t.Fatalf("Unexpected status %d of the resync", status)
+	}
+	op := accepted.Operation
+	if op == nil || op.Kind != apis.OperationResync || op.Status != apis.OperationPending || op.Height != latestHeight || op.Operator == "" {
+		t.Fatalf("Unexpected operation %+v", op)
+	}
+
+	// The retry returns the journaled operation, which isn't requested again.
+	var replayed apis.OperationResponse
+	if status := call(ts.URL, http.MethodPost, resync, "operator", "rollback-1", &replayed); status != http.StatusOK || replayed.Result.ID != op.ID {
+		t.Fatalf("Unexpected retry %d %+v", status, replayed.Result)
+	}
+	// The key can't be reused by another request or another operator.
+	var conflict apis.OperationResponse
+	if status := call(ts.URL, http.MethodPost, "/v1/admin/republish", "operator", "rollback-1", &conflict); status != http.StatusConflict {
+		t.Fatalf("Unexpected status %d of another request under the key", status)
+	}
+	if status := call(ts.URL, http.MethodPost, resync, "another", "rollback-1", &conflict); status != http.StatusConflict {
+		t.Fatalf("Unexpected status %d of another operator under the key", status)
+	}
+	var republished apis.ControlResponse
+	if status := call(ts.URL, http.MethodPost, "/v1/admin/republish", "another", "", &republished); status != http.StatusAccepted || republished.Operation.ID != op.ID+1 {
+		t.Fatalf("Unexpected republish %d %+v", status, republished.Operation)
+	}
+
+	height, republish := apis.Admin.TakeRequests()
+	if height != latestHeight || len(republish) != 1 {
+		t.Fatalf("Unexpected requests %d %v", height, republish)
+	}
+	apis.Admin.Finish(apis.OperationResync, nil)
+	var polled apis.OperationResponse
+	if status := call(ts.URL, http.MethodGet, "/v1/admin/operations/"+strconv.FormatUint(op.ID, 10), "operator", "", &polled); status != http.StatusOK || polled.Result.Status != apis.OperationDone || polled.Result.FinishedAt == nil {
+		t.Fatalf("Unexpected polled operation %d %+v", status, polled.Result)
+	}
+	if status := call(ts.URL, http.MethodGet, "/v1/admin/operations/42", "operator", "", &polled); status != http.StatusNotFound {
+		t.Fatalf("Unexpected status %d of an unknown operation", status)
+	}
+
+	// The journal survives the restart, which fails the unfinished republish.
+	apis.Admin = &apis.AdminService{Tokens: tokens, Journal: journal}
+	if err := apis.Admin.LoadJournal(); err != nil {
+		t.Fatal(err)
+	}
+	restarted := httptest.NewServer(apis.NewRouter(queue, "brc-20", false, false))
+	defer restarted.Close()
+	var listed apis.OperationsResponse
+	if status := call(restarted.URL, http.MethodGet, "/v1/admin/operations", "operator", "", &listed); status != http.StatusOK || len(listed.Result) != 2 {
+		t.Fatalf("Unexpected operations %d %+v", status, listed.Result)
+	}
+	if latest := listed.Result[0]; latest.Kind != apis.OperationRepublish || latest.Status != apis.OperationFailed || latest.Operator == op.Operator {
+		t.Fatalf("Unexpected interrupted operation %+v", latest)
+	}
+	if status := call(restarted.URL, http.MethodPost, resync, "operator", "rollback-1", &replayed); status != http.StatusOK || replayed.Result.Status != apis.OperationDone {
+		t.Fatalf("Unexpected retry after the restart %d %+v", status, replayed.Result)
+	}
+	if height, _ := apis.Admin.TakeRequests(); height != 0 {
+		t.Fatalf("Expected the retry not to resync again, got %d", height)
+	}
+}
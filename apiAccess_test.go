@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/RiemaLabs/modular-indexer-committee/apis"
+	"github.com/RiemaLabs/modular-indexer-committee/apis/pb"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/getter"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+func Test_APIAccess(t *testing.T) {
+	cfg := apis.AccessConfig{
+		Keys:     []string{"marketplace"},
+		Rate:     0.01,
+		Burst:    20,
+		KeyRate:  100,
+		KeyBurst: 100,
+		Costs:    map[string]int{"/v1/state/schema": 8},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if (apis.AccessConfig{Rate: 1, Burst: 5}).Validate() == nil {
+		t.Fatal("Expected a burst below the cost of the proofs to be rejected")
+	}
+	if (apis.AccessConfig{TrustedProxies: []string{"proxy"}}).Validate() == nil {
+		t.Fatal("Expected an invalid trusted proxy to be rejected")
+	}
+	// The proofs of the module namespaces cost as much as the verifiable ones.
+	for _, route := range []string{"/v1/brc20/current_portfolio", "/v1/brc20/batch_proof"} {
+		if apis.DefaultRouteCosts[route] != apis.DefaultRouteCosts[strings.Replace(route, "brc20", "brc20_verifiable", 1)] {
+			t.Fatalf("Unexpected cost of %s", route)
+		}
+	}
+	if apis.DefaultRouteCosts["/v1/brc20_balance"] != apis.DefaultRouteCosts["/v1/brc20_verifiable/current_balance_of_pkscript"] {
+		t.Fatal("Unexpected cost of the historical balances")
+	}
+	apis.Access = apis.NewAccessControl(cfg, func() []string { return cfg.Keys })
+	apis.Admin = &apis.AdminService{Tokens: func() []string { return []string{"operator"} }}
+	defer func() { apis.Access, apis.Admin = nil, nil }()
+
+	g := &blocksGetter{blocks: map[uint][]getter.OrdTransfer{}, hashes: make(map[uint]string)}
+	header := stateless.LoadHeader(false, 800000)
+	queue, err := stateless.NewQueues(g, header, true, 800001)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ts := httptest.NewServer(apis.NewRouter(queue, "brc-20", false, false))
+	defer ts.Close()
+	getAs := func(path, key, token string) *http.Response {
+		req, err := http.NewRequest(http.MethodGet, ts.URL+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if key != "" {
+			req.Header.Set(apis.HeaderAPIKey, key)
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+	get := func(path, key string) *http.Response {
+		return getAs(path, key, "")
+	}
+
+	// The weighted route drains the bucket of the IP after two requests.
+	for i := 0; i < 2; i++ {
+		if resp := get("/v1/state/schema", ""); resp.StatusCode != http.StatusOK {
+			t.Fatalf("Unexpected status %d of the request %d", resp.StatusCode, i)
+		}
+	}
+	resp := get("/v1/state/schema", "")
+	if resp.StatusCode != http.StatusTooManyRequests || resp.Header.Get("Retry-After") == "" {
+		t.Fatalf("Expected the IP to be limited, got %d", resp.StatusCode)
+	}
+	// The clients can't get fresh buckets by forging the forwarded IPs, as no proxy is trusted by default.
+	forged, err := http.NewRequest(http.MethodGet, ts.URL+"/v1/state/schema", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged.Header.Set("X-Forwarded-For", "203.0.113.7")
+	if resp, err := http.DefaultClient.Do(forged); err != nil || resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("Expected the forged IP to be limited, got %v: %v", resp, err)
+	} else {
+		resp.Body.Close()
+	}
+	// The key has its own bucket, while the probes of the load balancers are never limited.
+	if resp := get("/v1/state/schema", "marketplace"); resp.StatusCode != http.StatusOK {
+		t.Fatalf("Unexpected status %d of the API key", resp.StatusCode)
+	}
+	if resp := get("/v1/state/schema", "scraper"); resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("Unexpected status %d of an invalid API key", resp.StatusCode)
+	}
+	if resp := get("/healthcheck", ""); resp.StatusCode != http.StatusOK {
+		t.Fatalf("Unexpected status %d of the health check", resp.StatusCode)
+	}
+	// The admin routes aren't limited by the bucket of the IP, but its failed checks of the token are.
+	if resp := getAs("/v1/admin/control", "", "operator"); resp.StatusCode != http.StatusOK {
+		t.Fatalf("Unexpected status %d of the admin token", resp.StatusCode)
+	}
+	for i := 0; i < 10; i++ {
+		if resp := getAs("/v1/admin/control", "", "guess"); resp.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("Unexpected status %d of the guess %d", resp.StatusCode, i)
+		}
+	}
+	if resp := getAs("/v1/admin/control", "", "operator"); resp.StatusCode != http.StatusTooManyRequests || resp.Header.Get("Retry-After") == "" {
+		t.Fatalf("Expected the IP guessing the token to be limited, got %d", resp.StatusCode)
+	}
+
+	lis := bufconn.Listen(1 << 20)
+	srv := apis.NewGRPCServer(queue, "brc-20")
+	go func() {
+		_ = srv.Serve(lis)
+	}()
+	defer srv.Stop()
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	client := pb.NewCommitteeClient(conn)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// A proof costs 5 requests, so the fifth one exceeds the burst of the IP.
+	req := &pb.GetBalanceOfPkscriptRequest{Tick: "none", Pkscript: "0014" + strings.Repeat("aa", 20)}
+	for i := 0; i < 4; i++ {
+		if _, err := client.GetBalanceOfPkscript(ctx, req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := client.GetBalanceOfPkscript(ctx, req); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("Expected the proofs to be limited, got %v", err)
+	}
+	keyed := metadata.AppendToOutgoingContext(ctx, strings.ToLower(apis.HeaderAPIKey), "marketplace")
+	if _, err := client.GetBalanceOfPkscript(keyed, req); err != nil {
+		t.Fatal(err)
+	}
+	invalid := metadata.AppendToOutgoingContext(ctx, strings.ToLower(apis.HeaderAPIKey), "scraper")
+	if _, err := client.GetBlockHeight(invalid, &pb.GetBlockHeightRequest{}); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("Expected an invalid API key to be rejected, got %v", err)
+	}
+}
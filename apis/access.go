@@ -0,0 +1,322 @@
+package apis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	grpcpeer "google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/RiemaLabs/modular-indexer-committee/apis/pb"
+	"github.com/RiemaLabs/modular-indexer-committee/internal/metrics"
+	"github.com/RiemaLabs/modular-indexer-committee/peer"
+)
+
+// HeaderAPIKey carries the API key of a client, as does the metadata of the same name over gRPC.
+const HeaderAPIKey = "X-API-Key"
+
+// DefaultRouteCosts weighs the routes generating proofs, in requests. The routes not listed cost 1.
+var DefaultRouteCosts = map[string]int{
+	"/v1/brc20_verifiable/current_balance_of_wallet":   5,
+	"/v1/brc20_verifiable/current_balance_of_pkscript": 5,
+	"/v1/brc20_verifiable/current_portfolio":           10,
+	"/v1/brc20_portfolio":                              10,
+	"/v1/brc20_balance":                                5,
+	"/v1/brc20_verifiable/batch_proof":                 20,
+	"/v1/brc20_verifiable/latest_state_proof":          20,
+	"/v1/brc20_verifiable/tick_counters":               5,
+	"/v1/brc20/current_balance_of_wallet":              5,
+	"/v1/brc20/current_balance_of_pkscript":            5,
+	"/v1/brc20/current_portfolio":                      10,
+	"/v1/brc20/batch_proof":                            20,
+	"/v1/sns/name/:name":                               5,
+	"/v1/bitmap/district/:district":                    5,
+	pb.Committee_GetBalanceOfPkscript_FullMethodName:   5,
+	pb.Committee_GetBalanceOfWallet_FullMethodName:     5,
+}
+
+// The routes authorized by their own tokens, or probed by the load balancers, which aren't limited by the buckets of
+// the clients. The failed checks of the tokens are limited instead, see admitToken.
+var unlimitedRoutes = []string{"/healthcheck", "/v1/peer/", "/v1/admin/"}
+
+// Every IP may fail failedTokenBurst checks of the peer and the admin tokens, refilled by one every
+// failedTokenInterval, so that the tokens can't be guessed.
+const (
+	failedTokenBurst    = 10
+	failedTokenInterval = time.Minute
+)
+
+// How often the buckets refilled to their bursts are dropped, so that the scrapers rotating their IPs don't pile up.
+const bucketSweepInterval = time.Minute
+
+// AccessConfig guards the public APIs by the API keys and limits the rate of every client by a token bucket.
+type AccessConfig struct {
+	// The API keys of the clients, which may refer to secrets.
+	Keys []string `json:"keys"`
+	// Reject the requests without a valid key. Otherwise the requests without a key are limited by their IPs.
+	RequireKey bool `json:"requireKey"`
+	// The requests per second and the burst of every IP without a key. 0 disables the limit.
+	Rate  float64 `json:"rate"`
+	Burst int     `json:"burst"`
+	// The requests per second and the burst of every key, the same as the IPs if 0.
+	KeyRate  float64 `json:"keyRate"`
+	KeyBurst int     `json:"keyBurst"`
+	// The costs of the routes in requests by their paths or gRPC methods, overriding DefaultRouteCosts.
+	Costs map[string]int `json:"costs"`
+	// The IPs or CIDRs of the reverse proxies whose X-Forwarded-For names the client IP. None by default, so the
+	// clients can't pick their IPs by the header.
+	TrustedProxies []string `json:"trustedProxies"`
+}
+
+func (cfg AccessConfig) Validate() error {
+	if cfg.RequireKey && len(cfg.Keys) == 0 {
+		return errors.New("at least one key is required if the key is required")
+	}
+	if cfg.Rate < 0 || cfg.KeyRate < 0 {
+		return errors.New("the rate must not be negative")
+	}
+	for _, proxy := range cfg.TrustedProxies {
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				return fmt.Errorf("invalid trusted proxy %s", proxy)
+			}
+		}
+	}
+	maxCost := 1
+	for route, cost := range cfg.Costs {
+		if cost < 0 {
+			return fmt.Errorf("the cost of the route %s must not be negative", route)
+		}
+		maxCost = max(maxCost, cost)
+	}
+	for route, cost := range DefaultRouteCosts {
+		if _, found := cfg.Costs[route]; !found {
+			maxCost = max(maxCost, cost)
+		}
+	}
+	// A route costing more than the burst could never be served.
+	if cfg.Rate > 0 && cfg.Burst < maxCost {
+		return fmt.Errorf("the burst %d is less than the max cost %d of the routes", cfg.Burst, maxCost)
+	}
+	if cfg.KeyRate > 0 && cfg.KeyBurst < maxCost {
+		return fmt.Errorf("the key burst %d is less than the max cost %d of the routes", cfg.KeyBurst, maxCost)
+	}
+	return nil
+}
+
+// AccessControl admits the requests of the clients by their keys and their token buckets.
+type AccessControl struct {
+	cfg AccessConfig
+	// Keys returns the latest API keys, which may be rotated.
+	keys  func() []string
+	costs map[string]int
+
+	mu      sync.Mutex
+	buckets map[string]*rate.Limiter
+	swept   time.Time
+}
+
+// Access is nil unless the access control of the APIs is enabled.
+var Access *AccessControl
+
+// TrustedProxies are the proxies whose X-Forwarded-For is trusted by the routers, none by default.
+var TrustedProxies []string
+
+func NewAccessControl(cfg AccessConfig, keys func() []string) *AccessControl {
+	if cfg.KeyRate == 0 {
+		cfg.KeyRate, cfg.KeyBurst = cfg.Rate, cfg.Burst
+	}
+	costs := make(map[string]int, len(DefaultRouteCosts)+len(cfg.Costs))
+	for route, cost := range DefaultRouteCosts {
+		costs[route] = cost
+	}
+	for route, cost := range cfg.Costs {
+		costs[route] = cost
+	}
+	return &AccessControl{
+		cfg:     cfg,
+		keys:    keys,
+		costs:   costs,
+		buckets: make(map[string]*rate.Limiter),
+		swept:   time.Now(),
+	}
+}
+
+func (a *AccessControl) cost(route string) int {
+	if cost, found := a.costs[route]; found {
+		return cost
+	}
+	return 1
+}
+
+// take consumes the cost of the route from the bucket of the client, and returns the wait before it may be retried
+// if the bucket runs out.
+func (a *AccessControl) take(client string, limit float64, burst int, cost int) (bool, time.Duration) {
+	if limit == 0 || cost == 0 {
+		return true, 0
+	}
+	now := time.Now()
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if now.Sub(a.swept) > bucketSweepInterval {
+		for c, b := range a.buckets {
+			if b.TokensAt(now) >= float64(b.Burst()) {
+				delete(a.buckets, c)
+			}
+		}
+		a.swept = now
+	}
+	b, found := a.buckets[client]
+	if !found {
+		b = rate.NewLimiter(rate.Limit(limit), burst)
+		a.buckets[client] = b
+	}
+	if b.AllowN(now, cost) {
+		return true, 0
+	}
+	missing := float64(cost) - b.TokensAt(now)
+	return false, time.Duration(math.Ceil(missing/limit)) * time.Second
+}
+
+// tokenLocked tells whether the IP failed too many checks of the tokens, and the wait before it may try again.
+func (a *AccessControl) tokenLocked(ip string) (bool, time.Duration) {
+	now := time.Now()
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	b, found := a.buckets["token:"+ip]
+	if !found {
+		return false, 0
+	}
+	if tokens := b.TokensAt(now); tokens < 1 {
+		return true, time.Duration(math.Ceil((1-tokens)*failedTokenInterval.Seconds())) * time.Second
+	}
+	return false, 0
+}
+
+// admit tells whether the request of the route is served, with the status code and the reason if it's rejected.
+func (a *AccessControl) admit(key, ip, route string) (bool, int, time.Duration, string) {
+	if key != "" {
+		if !peer.Authorized(key, a.keys()) {
+			metrics.APIRejections.WithLabelValues("unauthorized").Inc()
+			return false, http.StatusUnauthorized, 0, "Invalid API key"
+		}
+		if ok, retry := a.take("key:"+key, a.cfg.KeyRate, a.cfg.KeyBurst, a.cost(route)); !ok {
+			metrics.APIRejections.WithLabelValues("rate_limited").Inc()
+			return false, http.StatusTooManyRequests, retry, "Rate limit of the API key exceeded"
+		}
+		return true, 0, 0, ""
+	}
+	if a.cfg.RequireKey {
+		metrics.APIRejections.WithLabelValues("unauthorized").Inc()
+		return false, http.StatusUnauthorized, 0, fmt.Sprintf("An API key is required in the header %s", HeaderAPIKey)
+	}
+	if ok, retry := a.take("ip:"+ip, a.cfg.Rate, a.cfg.Burst, a.cost(route)); !ok {
+		metrics.APIRejections.WithLabelValues("rate_limited").Inc()
+		return false, http.StatusTooManyRequests, retry, "Rate limit exceeded"
+	}
+	return true, 0, 0, ""
+}
+
+// Limit admits the requests by Access, unless the route is authorized by its own tokens.
+func Limit(c *gin.Context) {
+	for _, route := range unlimitedRoutes {
+		if strings.HasPrefix(c.Request.URL.Path, route) {
+			c.Next()
+			return
+		}
+	}
+	route := c.FullPath()
+	if route == "" {
+		route = c.Request.URL.Path
+	}
+	ok, code, retry, reason := Access.admit(c.GetHeader(HeaderAPIKey), c.ClientIP(), route)
+	if !ok {
+		if retry > 0 {
+			c.Header("Retry-After", strconv.Itoa(int(retry.Seconds())))
+		}
+		c.AbortWithStatusJSON(code, ErrorResponse{Error: &reason})
+		return
+	}
+	c.Next()
+}
+
+// admitToken rejects the request of an IP which failed too many checks of the peer or the admin tokens, before its
+// token is checked, unless Access is disabled.
+func admitToken(c *gin.Context) bool {
+	if Access == nil {
+		return true
+	}
+	locked, retry := Access.tokenLocked(c.ClientIP())
+	if !locked {
+		return true
+	}
+	metrics.APIRejections.WithLabelValues("rate_limited").Inc()
+	c.Header("Retry-After", strconv.Itoa(int(retry.Seconds())))
+	reason := "Too many failed token checks"
+	c.AbortWithStatusJSON(http.StatusTooManyRequests, ErrorResponse{Error: &reason})
+	return false
+}
+
+// failToken counts a failed check of the peer or the admin token of the IP.
+func failToken(c *gin.Context) {
+	if Access == nil {
+		return
+	}
+	Access.take("token:"+c.ClientIP(), 1/failedTokenInterval.Seconds(), failedTokenBurst, 1)
+}
+
+// grpcClient returns the API key and the IP of the gRPC call.
+func grpcClient(ctx context.Context) (string, string) {
+	var key, ip string
+	if values := metadata.ValueFromIncomingContext(ctx, strings.ToLower(HeaderAPIKey)); len(values) != 0 {
+		key = values[0]
+	}
+	if p, ok := grpcpeer.FromContext(ctx); ok {
+		ip = p.Addr.String()
+		if host, _, err := net.SplitHostPort(ip); err == nil {
+			ip = host
+		}
+	}
+	return key, ip
+}
+
+func grpcAdmit(ctx context.Context, method string) error {
+	key, ip := grpcClient(ctx)
+	ok, code, _, reason := Access.admit(key, ip, method)
+	if ok {
+		return nil
+	}
+	if code == http.StatusUnauthorized {
+		return status.Error(codes.Unauthenticated, reason)
+	}
+	return status.Error(codes.ResourceExhausted, reason)
+}
+
+// limitUnary admits the unary calls by Access as Limit does.
+func limitUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if err := grpcAdmit(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// limitStream admits the streams by Access once when they're opened.
+func limitStream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := grpcAdmit(ss.Context(), info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
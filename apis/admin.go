@@ -0,0 +1,485 @@
+package apis
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+	"github.com/RiemaLabs/modular-indexer-committee/peer"
+)
+
+// HeaderIdempotencyKey carries the key of an operation, under which the retries of the request return the operation
+// journaled by the first one rather than requesting it again.
+const HeaderIdempotencyKey = "Idempotency-Key"
+
+// The number of the latest operations kept by the journal.
+const journalLimit = 1024
+
+// The kinds of the operations journaled.
+const (
+	OperationPrune     = "prune"
+	OperationResync    = "resync"
+	OperationRepublish = "republish"
+)
+
+// The statuses of the operations: pending until taken by the service loop, running until finished.
+const (
+	OperationPending = "pending"
+	OperationRunning = "running"
+	OperationDone    = "done"
+	OperationFailed  = "failed"
+)
+
+// Operation is an action requested by an operator, journaled so that the operators can poll it and audit who
+// requested it.
+type Operation struct {
+	ID   uint64 `json:"id"`
+	Kind string `json:"kind"`
+	// The method and the path of the request, e.g. POST /v1/admin/resync?height=800003, and the height it resolved.
+	Request        string `json:"request"`
+	Height         uint   `json:"height,omitempty"`
+	IdempotencyKey string `json:"idempotencyKey,omitempty"`
+	// The fingerprint of the token of the operator, which is never journaled itself, and the IP of the request.
+	Operator    string     `json:"operator"`
+	IP          string     `json:"ip"`
+	Status      string     `json:"status"`
+	Error       string     `json:"error,omitempty"`
+	RequestedAt time.Time  `json:"requestedAt"`
+	FinishedAt  *time.Time `json:"finishedAt,omitempty"`
+}
+
+// AdminService lets the operators maintain the committee indexer.
+type AdminService struct {
+	// Tokens returns the latest tokens of the operators, which may be rotated.
+	Tokens func() []string
+	// The file journaling the operations across restarts, empty keeps them in memory only.
+	Journal string
+
+	mu     sync.Mutex
+	paused bool
+	// The pending requests, taken by the service loop: the first block to resync, 0 if none,
+	// and the heights of the checkpoints to republish.
+	resync    uint
+	republish []uint
+	wake      chan struct{}
+	// The latest operations, in the order of their IDs.
+	operations []*Operation
+}
+
+// Admin is nil unless the admin APIs are enabled.
+var Admin *AdminService
+
+// ControlStatus is the state of the block processing controlled by the operators.
+type ControlStatus struct {
+	Paused bool `json:"paused"`
+	// The height of the latest executed block.
+	Height           uint   `json:"height"`
+	PendingResync    uint   `json:"pendingResync,omitempty"`
+	PendingRepublish []uint `json:"pendingRepublish,omitempty"`
+}
+
+// Paused tells whether the operators paused the processing of the new blocks.
+func (a *AdminService) Paused() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.paused
+}
+
+// Wake returns the channel signaled by every request of the operators, so that the service loop handles it
+// without waiting for the next round.
+func (a *AdminService) Wake() <-chan struct{} {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.wake == nil {
+		a.wake = make(chan struct{}, 1)
+	}
+	return a.wake
+}
+
+// notify wakes up the service loop, which shall be called with the lock held.
+func (a *AdminService) notify() {
+	if a.wake == nil {
+		a.wake = make(chan struct{}, 1)
+	}
+	select {
+	case a.wake <- struct{}{}:
+	default:
+	}
+}
+
+// TakeRequests returns and clears the pending requests: the first block to resync, 0 if none,
+// and the heights of the checkpoints to republish. Their operations are running until finished by Finish.
+func (a *AdminService) TakeRequests() (uint, []uint) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	resync, republish := a.resync, a.republish
+	a.resync, a.republish = 0, nil
+	for _, op := range a.operations {
+		if op.Status == OperationPending {
+			op.Status = OperationRunning
+		}
+	}
+	if err := a.save(); err != nil {
+		log.Printf("Unable to journal the admin operations due to: %v", err)
+	}
+	return resync, republish
+}
+
+// Finish journals the outcome of the running operations of the kind, failed if err isn't nil.
+func (a *AdminService) Finish(kind string, err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	now := time.Now()
+	for _, op := range a.operations {
+		if op.Kind != kind || op.Status != OperationRunning {
+			continue
+		}
+		a.finish(op, now, err)
+	}
+	if err := a.save(); err != nil {
+		log.Printf("Unable to journal the admin operations due to: %v", err)
+	}
+}
+
+// finish sets the outcome of the operation, which shall be called with the lock held.
+func (a *AdminService) finish(op *Operation, now time.Time, err error) {
+	op.Status, op.FinishedAt = OperationDone, &now
+	if err != nil {
+		op.Status, op.Error = OperationFailed, err.Error()
+	}
+}
+
+// LoadJournal loads the operations journaled before the restart. Those unfinished are failed, since their requests
+// were lost with the process.
+func (a *AdminService) LoadJournal() error {
+	if a.Journal == "" {
+		return nil
+	}
+	bytes, err := os.ReadFile(a.Journal)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err := json.Unmarshal(bytes, &a.operations); err != nil {
+		return fmt.Errorf("invalid admin journal %s: %v", a.Journal, err)
+	}
+	now := time.Now()
+	for _, op := range a.operations {
+		if op.Status == OperationPending || op.Status == OperationRunning {
+			a.finish(op, now, errors.New("interrupted by a restart"))
+		}
+	}
+	return a.save()
+}
+
+// save persists the latest operations, unless they're kept in memory only, which shall be called with the lock held.
+// The file is renamed into place, so that a crash never leaves it truncated.
+func (a *AdminService) save() error {
+	if len(a.operations) > journalLimit {
+		a.operations = a.operations[len(a.operations)-journalLimit:]
+	}
+	if a.Journal == "" {
+		return nil
+	}
+	bytes, err := json.Marshal(a.operations)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(a.Journal+".tmp", bytes, 0644); err != nil {
+		return err
+	}
+	return os.Rename(a.Journal+".tmp", a.Journal)
+}
+
+// operator identifies the operator of the request by the fingerprint of its token.
+func operator(c *gin.Context) string {
+	token, _ := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:8])
+}
+
+func request(c *gin.Context) string {
+	return c.Request.Method + " " + c.Request.URL.RequestURI()
+}
+
+// keyed returns the operation of the idempotency key, nil if none, which shall be called with the lock held.
+func (a *AdminService) keyed(key string) *Operation {
+	if key == "" {
+		return nil
+	}
+	for _, op := range a.operations {
+		if op.IdempotencyKey == key {
+			return op
+		}
+	}
+	return nil
+}
+
+// respondKeyed returns the operation to the retry of the request which journaled it. The key can't be reused by
+// another request or another operator.
+func respondKeyed(c *gin.Context, op Operation) {
+	if op.Request != request(c) || op.Operator != operator(c) {
+		errStr := fmt.Sprintf("The idempotency key is taken by the operation %d: %s", op.ID, op.Request)
+		c.JSON(http.StatusConflict, OperationResponse{Error: &errStr, Result: nil})
+		return
+	}
+	c.JSON(http.StatusOK, OperationResponse{Error: nil, Result: &op})
+}
+
+// replay answers the retry of a journaled operation, and tells whether it did. It's checked ahead of the request,
+// which may no longer be valid, e.g. the block of a resync has been pruned since.
+func (a *AdminService) replay(c *gin.Context) bool {
+	a.mu.Lock()
+	op := a.keyed(c.GetHeader(HeaderIdempotencyKey))
+	var copied Operation
+	if op != nil {
+		copied = *op
+	}
+	a.mu.Unlock()
+	if op == nil {
+		return false
+	}
+	respondKeyed(c, copied)
+	return true
+}
+
+// begin journals the operation of the request, and then calls enqueue with the lock held. It answers the request
+// and returns false if the operation has been journaled by a concurrent retry or can't be journaled, since an
+// unjournaled operation would escape the audit.
+func (a *AdminService) begin(c *gin.Context, kind string, height uint, status string, enqueue func()) (Operation, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	key := c.GetHeader(HeaderIdempotencyKey)
+	if op := a.keyed(key); op != nil {
+		respondKeyed(c, *op)
+		return Operation{}, false
+	}
+	op := Operation{
+		ID:             1,
+		Kind:           kind,
+		Request:        request(c),
+		Height:         height,
+		IdempotencyKey: key,
+		Operator:       operator(c),
+		IP:             c.ClientIP(),
+		Status:         status,
+		RequestedAt:    time.Now(),
+	}
+	if len(a.operations) != 0 {
+		op.ID = a.operations[len(a.operations)-1].ID + 1
+	}
+	a.operations = append(a.operations, &op)
+	if err := a.save(); err != nil {
+		a.operations = a.operations[:len(a.operations)-1]
+		errStr := fmt.Sprintf("Unable to journal the operation: %v", err)
+		c.JSON(http.StatusInternalServerError, OperationResponse{Error: &errStr, Result: nil})
+		return Operation{}, false
+	}
+	log.Printf("The operator %s requested the %s operation %d: %s", op.Operator, kind, op.ID, op.Request)
+	if enqueue != nil {
+		enqueue()
+	}
+	return op, true
+}
+
+// end journals the outcome of the operation run by the request itself.
+func (a *AdminService) end(id uint64, err error) Operation {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var finished Operation
+	for _, op := range a.operations {
+		if op.ID == id {
+			a.finish(op, time.Now(), err)
+			finished = *op
+		}
+	}
+	if err := a.save(); err != nil {
+		log.Printf("Unable to journal the admin operations due to: %v", err)
+	}
+	return finished
+}
+
+func (a *AdminService) status(queue *stateless.Queue) ControlStatus {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return ControlStatus{
+		Paused:           a.paused,
+		Height:           queue.CommittedHeight(),
+		PendingResync:    a.resync,
+		PendingRepublish: slices.Clone(a.republish),
+	}
+}
+
+func authorizeAdmin(c *gin.Context) {
+	if !admitToken(c) {
+		return
+	}
+	token, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !found || !peer.Authorized(token, Admin.Tokens()) {
+		failToken(c)
+		errStr := "Unauthorized operator"
+		c.AbortWithStatusJSON(http.StatusUnauthorized, ErrorResponse{Error: &errStr})
+		return
+	}
+	c.Next()
+}
+
+// PostPrune prunes the history on the disk older than the retention right away, instead of waiting for the
+// periodic pruning.
+func PostPrune(c *gin.Context, queue *stateless.Queue) {
+	if Admin.replay(c) {
+		return
+	}
+	latestHeight := queue.LatestHeight()
+	op, ok := Admin.begin(c, OperationPrune, latestHeight, OperationRunning, nil)
+	if !ok {
+		return
+	}
+	result, err := stateless.Prune(latestHeight)
+	op = Admin.end(op.ID, err)
+	if err != nil {
+		errStr := err.Error()
+		c.JSON(http.StatusInternalServerError, PruneResponse{Error: &errStr, Result: &result, Operation: &op})
+		return
+	}
+	c.JSON(http.StatusOK, PruneResponse{
+		Error:     nil,
+		Result:    &result,
+		Operation: &op,
+	})
+}
+
+// GetControl returns the state of the block processing.
+func GetControl(c *gin.Context, queue *stateless.Queue) {
+	status := Admin.status(queue)
+	c.JSON(http.StatusOK, ControlResponse{Result: &status})
+}
+
+// PostPause stops processing the new blocks, while the APIs keep serving the latest executed state.
+func PostPause(c *gin.Context, queue *stateless.Queue) {
+	Admin.mu.Lock()
+	Admin.paused = true
+	Admin.mu.Unlock()
+	GetControl(c, queue)
+}
+
+// PostResume resumes processing the new blocks.
+func PostResume(c *gin.Context, queue *stateless.Queue) {
+	Admin.mu.Lock()
+	Admin.paused = false
+	Admin.notify()
+	Admin.mu.Unlock()
+	GetControl(c, queue)
+}
+
+// keptHeight parses the height of the query, which shall be of a block kept by the queue, from the first one
+// whose diff is kept on. The latest height is the default if optional.
+func keptHeight(c *gin.Context, queue *stateless.Queue, optional bool) (uint, bool) {
+	queue.RLock()
+	startHeight, latestHeight := queue.StartHeight(), queue.LatestHeight()
+	queue.RUnlock()
+	s := c.Query("height")
+	if s == "" && optional {
+		return latestHeight, true
+	}
+	height, err := strconv.ParseUint(s, 10, 64)
+	if err != nil || uint(height) <= startHeight || uint(height) > latestHeight {
+		errStr := fmt.Sprintf("Invalid height %s, expected one of the kept blocks from %d to %d", s, startHeight+1, latestHeight)
+		c.JSON(http.StatusBadRequest, ControlResponse{Error: &errStr})
+		return 0, false
+	}
+	return uint(height), true
+}
+
+// PostResync rolls the state back before the block at ?height and executes the blocks from it again, e.g. after
+// the getter served wrong transfers. The block shall be one of the kept ones, see --reorg-depth.
+func PostResync(c *gin.Context, queue *stateless.Queue) {
+	if Admin.replay(c) {
+		return
+	}
+	height, ok := keptHeight(c, queue, false)
+	if !ok {
+		return
+	}
+	op, ok := Admin.begin(c, OperationResync, height, OperationPending, func() {
+		if Admin.resync == 0 || height < Admin.resync {
+			Admin.resync = height
+		}
+		Admin.notify()
+	})
+	if !ok {
+		return
+	}
+	status := Admin.status(queue)
+	c.JSON(http.StatusAccepted, ControlResponse{Result: &status, Operation: &op})
+}
+
+// PostRepublish publishes the checkpoint of the block at ?height, the latest one by default, to every target again,
+// regardless of the schedules and the previous uploads.
+func PostRepublish(c *gin.Context, queue *stateless.Queue) {
+	if Admin.replay(c) {
+		return
+	}
+	height, ok := keptHeight(c, queue, true)
+	if !ok {
+		return
+	}
+	op, ok := Admin.begin(c, OperationRepublish, height, OperationPending, func() {
+		if !slices.Contains(Admin.republish, height) {
+			Admin.republish = append(Admin.republish, height)
+		}
+		Admin.notify()
+	})
+	if !ok {
+		return
+	}
+	status := Admin.status(queue)
+	c.JSON(http.StatusAccepted, ControlResponse{Result: &status, Operation: &op})
+}
+
+// GetOperations returns the journaled operations, the latest first.
+func GetOperations(c *gin.Context) {
+	Admin.mu.Lock()
+	operations := make([]Operation, len(Admin.operations))
+	for i, op := range Admin.operations {
+		operations[len(operations)-1-i] = *op
+	}
+	Admin.mu.Unlock()
+	c.JSON(http.StatusOK, OperationsResponse{Error: nil, Result: operations})
+}
+
+// GetOperation returns the journaled operation of the ID, which is polled until done or failed.
+func GetOperation(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	Admin.mu.Lock()
+	var found *Operation
+	for _, op := range Admin.operations {
+		if err == nil && op.ID == id {
+			copied := *op
+			found = &copied
+		}
+	}
+	Admin.mu.Unlock()
+	if found == nil {
+		errStr := fmt.Sprintf("Unknown operation %s", c.Param("id"))
+		c.JSON(http.StatusNotFound, OperationResponse{Error: &errStr, Result: nil})
+		return
+	}
+	c.JSON(http.StatusOK, OperationResponse{Error: nil, Result: found})
+}
@@ -15,47 +15,42 @@ import (
 
 	"github.com/RiemaLabs/modular-indexer-committee/internal/metrics"
 	"github.com/RiemaLabs/modular-indexer-committee/ord"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/brc20"
 	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
 )
 
-func GetAllBalances(queue *stateless.Queue, tick string, pkScript string) ([]byte, []byte, Brc20VerifiableCurrentBalanceOfPkscriptResult) {
+func GetAllBalances(queue *stateless.Queue, tick string, pkScript string) ([]byte, []byte, Brc20VerifiableCurrentBalanceOfPkscriptResult, error) {
 	var ordPkscript ord.Pkscript = ord.Pkscript(pkScript)
-	availKey, overKey, availableBalance, overallBalance := stateless.GetBalances(queue.Header, tick, ordPkscript)
+	availKey, overKey, availableBalance, overallBalance, err := brc20.GetBalances(queue.Header, tick, ordPkscript)
+	if err != nil {
+		return nil, nil, Brc20VerifiableCurrentBalanceOfPkscriptResult{}, err
+	}
 	availableBalanceStr := availableBalance.String()
 	overallBalanceStr := overallBalance.String()
 
+	commitment := queue.Header.Root.Commit().Bytes()
 	result := Brc20VerifiableCurrentBalanceOfPkscriptResult{
 		AvailableBalance: availableBalanceStr,
 		OverallBalance:   overallBalanceStr,
+		Height:           queue.Header.Height,
+		StateRoot:        base64.StdEncoding.EncodeToString(commitment[:]),
 	}
 
-	return availKey, overKey, result
+	return availKey, overKey, result, nil
 }
 
 func GetCurrentBalanceOfWallet(c *gin.Context, queue *stateless.Queue) {
 	tick := c.DefaultQuery("tick", "")
 	wallet := c.DefaultQuery("wallet", "")
 
-	_, pkScript := stateless.GetLatestPkscript(queue.Header, wallet)
-
-	availKey, overKey, result := GetAllBalances(queue, tick, pkScript)
-
-	keys := [][]byte{availKey, overKey}
-
-	proof, _, _, _, err := verkle.MakeVerkleMultiProof(queue.Header.Root, nil, keys, stateless.NodeResolveFn)
-	if err != nil {
-		errStr := fmt.Sprintf("Failed to generate proof due to %v", err)
-		c.JSON(http.StatusInternalServerError, Brc20VerifiableCurrentBalanceOfWalletResponse{
-			Error:  &errStr,
-			Result: nil,
-			Proof:  nil,
-		})
-		return
+	_, pkScript, err := brc20.GetLatestPkscript(queue.Header, wallet)
+	var availKey, overKey []byte
+	var result Brc20VerifiableCurrentBalanceOfPkscriptResult
+	if err == nil {
+		availKey, overKey, result, err = GetAllBalances(queue, tick, pkScript)
 	}
-
-	vProof, _, err := verkle.SerializeProof(proof)
 	if err != nil {
-		errStr := fmt.Sprintf("Failed to serialize proof due to %v", err)
+		errStr := fmt.Sprintf("Failed to read the balance due to %v", err)
 		c.JSON(http.StatusInternalServerError, Brc20VerifiableCurrentBalanceOfWalletResponse{
 			Error:  &errStr,
 			Result: nil,
@@ -63,9 +58,12 @@ func GetCurrentBalanceOfWallet(c *gin.Context, queue *stateless.Queue) {
 		})
 		return
 	}
-	vProofBytes, err := vProof.MarshalJSON()
+
+	keys := [][]byte{availKey, overKey}
+
+	proof, err := makeProof(queue.Header, keys)
 	if err != nil {
-		errStr := fmt.Sprintf("Failed to marshal the proof to JSON due to %v", err)
+		errStr := fmt.Sprintf("Failed to generate proof due to %v", err)
 		c.JSON(http.StatusInternalServerError, Brc20VerifiableCurrentBalanceOfWalletResponse{
 			Error:  &errStr,
 			Result: nil,
@@ -73,12 +71,14 @@ func GetCurrentBalanceOfWallet(c *gin.Context, queue *stateless.Queue) {
 		})
 		return
 	}
-	finalproof := base64.StdEncoding.EncodeToString(vProofBytes[:])
+	finalproof := proof.proof
 
 	resultWallet := Brc20VerifiableCurrentBalanceOfWalletResult{
 		AvailableBalance: result.AvailableBalance,
 		OverallBalance:   result.OverallBalance,
 		Pkscript:         pkScript,
+		Height:           result.Height,
+		StateRoot:        result.StateRoot,
 	}
 
 	c.JSON(http.StatusOK, Brc20VerifiableCurrentBalanceOfWalletResponse{
@@ -91,23 +91,9 @@ func GetCurrentBalanceOfWallet(c *gin.Context, queue *stateless.Queue) {
 func GetCurrentBalanceOfPkscript(c *gin.Context, queue *stateless.Queue) {
 	tick := c.DefaultQuery("tick", "")
 	pkScript := c.DefaultQuery("pkscript", "")
-	availKey, overKey, result := GetAllBalances(queue, tick, pkScript)
-
-	keys := [][]byte{availKey, overKey}
-	// Generate proof
-	proofOfKeys, _, _, _, err := verkle.MakeVerkleMultiProof(queue.Header.Root, nil, keys, stateless.NodeResolveFn)
-	if err != nil {
-		errStr := fmt.Sprintf("Failed to generate proof due to %v", err)
-		c.JSON(http.StatusInternalServerError, Brc20VerifiableCurrentBalanceOfPkscriptResponse{
-			Error:  &errStr,
-			Result: nil,
-			Proof:  nil,
-		})
-		return
-	}
-	vProof, _, err := verkle.SerializeProof(proofOfKeys)
+	availKey, overKey, result, err := GetAllBalances(queue, tick, pkScript)
 	if err != nil {
-		errStr := fmt.Sprintf("Failed to serialize proof due to %v", err)
+		errStr := fmt.Sprintf("Failed to read the balance due to %v", err)
 		c.JSON(http.StatusInternalServerError, Brc20VerifiableCurrentBalanceOfPkscriptResponse{
 			Error:  &errStr,
 			Result: nil,
@@ -116,9 +102,11 @@ func GetCurrentBalanceOfPkscript(c *gin.Context, queue *stateless.Queue) {
 		return
 	}
 
-	vProofBytes, err := vProof.MarshalJSON()
+	keys := [][]byte{availKey, overKey}
+	// Generate proof
+	proof, err := makeProof(queue.Header, keys)
 	if err != nil {
-		errStr := fmt.Sprintf("Failed to marshal the proof to JSON due to %v", err)
+		errStr := fmt.Sprintf("Failed to generate proof due to %v", err)
 		c.JSON(http.StatusInternalServerError, Brc20VerifiableCurrentBalanceOfPkscriptResponse{
 			Error:  &errStr,
 			Result: nil,
@@ -126,7 +114,7 @@ func GetCurrentBalanceOfPkscript(c *gin.Context, queue *stateless.Queue) {
 		})
 		return
 	}
-	finalproof := base64.StdEncoding.EncodeToString(vProofBytes[:])
+	finalproof := proof.proof
 
 	c.JSON(http.StatusOK, Brc20VerifiableCurrentBalanceOfPkscriptResponse{
 		Error:  nil,
@@ -217,49 +205,212 @@ func GetLatestStateProof(c *gin.Context, queue *stateless.Queue) {
 	})
 }
 
-func StartService(queue *stateless.Queue, enableCommittee, enableDebug, enablePprof bool) {
-	if !enableDebug {
-		gin.SetMode(gin.ReleaseMode)
-	}
+// NewRouter registers the APIs of the committee indexer serving the given queue.
+func NewRouter(queue *stateless.Queue, metaProtocol string, enableCommittee, enablePprof bool) *gin.Engine {
 	r := gin.Default()
+	if err := r.SetTrustedProxies(TrustedProxies); err != nil {
+		log.Printf("Ignoring the invalid trusted proxies %v: %v", TrustedProxies, err)
+		_ = r.SetTrustedProxies(nil)
+	}
 
 	r.Use(gin.Recovery(), gin.Logger(), cors.New(cors.Config{
 		AllowOrigins:     []string{"*"},
 		AllowMethods:     []string{"POST", "GET"},
 		AllowHeaders:     []string{"*"},
+		ExposeHeaders:    []string{HeaderBlockHeight, HeaderBlockHash, HeaderStateRoot},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
 	r.Use(metrics.HTTP)
+	if Access != nil {
+		r.Use(Limit)
+	}
 
 	if enablePprof {
 		pprof.Register(r)
 	}
 
-	r.GET("/v1/brc20_verifiable/current_balance_of_wallet", func(c *gin.Context) {
+	// The state APIs are served from a committed block boundary, see Consistent.
+	state := r.Group("/v1", Consistent(queue))
+
+	state.GET("/brc20_verifiable/current_balance_of_wallet", func(c *gin.Context) {
 		GetCurrentBalanceOfWallet(c, queue)
 	})
 
-	r.GET("/v1/brc20_verifiable/current_balance_of_pkscript", func(c *gin.Context) {
+	state.GET("/brc20_verifiable/current_balance_of_pkscript", func(c *gin.Context) {
 		GetCurrentBalanceOfPkscript(c, queue)
 	})
 
-	r.GET("/v1/brc20_verifiable/block_height", func(c *gin.Context) {
+	state.GET("/brc20_verifiable/current_portfolio", func(c *gin.Context) {
+		GetCurrentPortfolio(c, queue)
+	})
+
+	state.POST("/brc20_verifiable/batch_proof", func(c *gin.Context) {
+		PostBatchProof(c, queue)
+	})
+
+	state.GET("/brc20_portfolio", func(c *gin.Context) {
+		GetPortfolio(c, queue)
+	})
+
+	state.GET("/brc20_balance", func(c *gin.Context) {
+		GetBalanceAtHeight(c, queue)
+	})
+
+	state.GET("/brc20_ticks", func(c *gin.Context) {
+		GetTicks(c, queue)
+	})
+
+	state.GET("/brc20_tick/:tick", func(c *gin.Context) {
+		GetTick(c, queue)
+	})
+
+	state.GET("/brc20_verifiable/tick_counters", func(c *gin.Context) {
+		GetTickCounters(c, queue)
+	})
+
+	state.GET("/brc20_verifiable/block_height", func(c *gin.Context) {
 		GetBlockHeight(c, queue)
 	})
 
+	if stateless.EventsPath != "" {
+		state.GET("/brc20_inscription/:id/history", func(c *gin.Context) {
+			GetInscriptionHistory(c, queue)
+		})
+	}
+
+	for _, m := range Modules {
+		m.Register(state.Group("/"+m.Namespace), queue)
+	}
+
+	state.GET("/checkpoint", func(c *gin.Context) {
+		GetCheckpoint(c, queue, metaProtocol)
+	})
+
+	state.GET("/state/digest", func(c *gin.Context) {
+		GetStateDigest(c, queue)
+	})
+
+	r.GET("/v1/state/schema", GetStateSchema)
+
+	if stateless.PreImagesPath != "" {
+		r.GET("/v1/state/preimages", GetKeyPreImages)
+	}
+
+	r.POST("/v1/checkpoint/verify", PostVerifyCheckpoint)
+
+	r.GET("/v1/status", GetStatus)
+
 	r.GET("/healthcheck", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{
 			"status": "healthy",
 		})
 	})
 
+	r.GET("/healthz", func(c *gin.Context) {
+		GetHealthz(c, queue)
+	})
+
+	r.GET("/readyz", func(c *gin.Context) {
+		GetReadyz(c, queue)
+	})
+
+	if stateless.Watchlist != nil {
+		r.GET("/v1/watchlist/events", func(c *gin.Context) {
+			GetWatchlistEvents(c, stateless.Watchlist)
+		})
+		r.GET("/v1/watchlist/stream", func(c *gin.Context) {
+			StreamWatchlistEvents(c, stateless.Watchlist)
+		})
+	}
+
+	if stateless.Subscriptions != nil {
+		r.GET("/ws", func(c *gin.Context) {
+			ServeSubscriptions(c, stateless.Subscriptions)
+		})
+	}
+
+	if stateless.WitnessPath != "" || stateless.KeepWitnesses != 0 {
+		r.GET("/v1/witness/:height", GetWitness)
+	}
+
+	if CrossCheck != nil {
+		r.GET("/v1/committee/consensus", GetConsensus)
+	}
+
+	if stateless.OPICheck != nil {
+		r.GET("/v1/opicheck", GetOPICheck)
+	}
+
+	if Publications != nil {
+		r.GET("/v1/checkpoints", GetCheckpoints)
+	}
+
+	if SelfAudit != nil {
+		r.GET("/v1/selfaudit", GetSelfAudit)
+	}
+
+	if Peers != nil {
+		r.GET("/v1/peer/key", GetPeerKey)
+		peers := r.Group("/v1/peer", authorizePeer)
+		peers.GET("/diffs", func(c *gin.Context) {
+			StreamPeerDiffs(c, queue)
+		})
+		peers.GET("/snapshot", func(c *gin.Context) {
+			GetPeerSnapshot(c, queue)
+		})
+		peers.GET("/audit", func(c *gin.Context) {
+			GetPeerAudit(c, queue)
+		})
+	}
+
+	if Admin != nil {
+		admin := r.Group("/v1/admin", authorizeAdmin)
+		admin.POST("/prune", func(c *gin.Context) {
+			PostPrune(c, queue)
+		})
+		admin.GET("/control", func(c *gin.Context) {
+			GetControl(c, queue)
+		})
+		admin.POST("/pause", func(c *gin.Context) {
+			PostPause(c, queue)
+		})
+		admin.POST("/resume", func(c *gin.Context) {
+			PostResume(c, queue)
+		})
+		admin.POST("/resync", func(c *gin.Context) {
+			PostResync(c, queue)
+		})
+		admin.POST("/republish", func(c *gin.Context) {
+			PostRepublish(c, queue)
+		})
+		admin.GET("/operations", GetOperations)
+		admin.GET("/operations/:id", GetOperation)
+		if SelfAudit != nil {
+			admin.POST("/selfaudit", PostSelfAudit)
+		}
+	}
+
 	if enableCommittee {
-		r.GET("/v1/brc20_verifiable/latest_state_proof", func(c *gin.Context) {
+		state.GET("/brc20_verifiable/latest_state_proof", func(c *gin.Context) {
 			GetLatestStateProof(c, queue)
 		})
 	}
 
+	// Register the capabilities last so that they list every route above.
+	capabilities := NewCapabilities(r, metaProtocol)
+	r.GET("/v1/capabilities", func(c *gin.Context) {
+		GetCapabilities(c, capabilities)
+	})
+	return r
+}
+
+func StartService(queue *stateless.Queue, metaProtocol string, enableCommittee, enableDebug, enablePprof bool) {
+	if !enableDebug {
+		gin.SetMode(gin.ReleaseMode)
+	}
+	r := NewRouter(queue, metaProtocol, enableCommittee, enablePprof)
+
 	// TODO: Medium. Allow user to setup port.
 	if err := r.Run(":8080"); !errors.Is(err, http.ErrServerClosed) {
 		log.Fatal(err)
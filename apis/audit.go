@@ -0,0 +1,196 @@
+package apis
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/RiemaLabs/modular-indexer-committee/internal/metrics"
+	"github.com/RiemaLabs/modular-indexer-committee/lightclient"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+	"github.com/RiemaLabs/modular-indexer-committee/peer"
+)
+
+// The members may execute a block a little later, so the audit of a block is retried for about a minute.
+const (
+	auditRetryInterval = 10 * time.Second
+	auditAttempts      = 6
+)
+
+var errNotExecuted = errors.New("the block isn't executed yet")
+
+// LocalAudit samples the state keys accessed by the block at the height, which must be held by the queue.
+// The multiproof of the samples is included only if the block is the latest one.
+func LocalAudit(queue *stateless.Queue, height uint, samples int) (*peer.Audit, error) {
+	queue.RLock()
+	defer queue.RUnlock()
+	if height > queue.Header.Height {
+		return nil, errNotExecuted
+	}
+	for i, state := range queue.History {
+		if state.Height+1 != height {
+			continue
+		}
+		latest := i+1 == len(queue.History)
+		hash, post := queue.Header.Hash, queue.Header.Root.Commit().Bytes()
+		if !latest {
+			hash, post = queue.History[i+1].Hash, queue.History[i+1].VerkleCommit
+		}
+		keys := make([][]byte, len(state.Access.Elements))
+		elements := make(map[string]stateless.TripleElement, len(state.Access.Elements))
+		for j, elem := range state.Access.Elements {
+			keys[j] = elem.Key[:]
+			elements[string(elem.Key[:])] = elem
+		}
+		keys = peer.SampleKeys(hash, keys, samples)
+
+		audit := peer.Audit{
+			Height:     height,
+			Hash:       hash,
+			Commitment: base64.StdEncoding.EncodeToString(post[:]),
+			Samples:    make([]peer.Sample, len(keys)),
+		}
+		for j, key := range keys {
+			elem := elements[string(key)]
+			exists := elem.OldValueExists || elem.NewValue != elem.OldValue
+			if latest {
+				_, exists = queue.Header.KV.Get(elem.Key)
+			}
+			audit.Samples[j] = peer.Sample{
+				Key:    hex.EncodeToString(key),
+				Value:  hex.EncodeToString(elem.NewValue[:]),
+				Exists: exists,
+			}
+		}
+		if latest && len(keys) != 0 {
+			proof, err := makeProof(queue.Header, keys)
+			if err != nil {
+				return nil, err
+			}
+			audit.Proof = proof.proof
+		}
+		return &audit, nil
+	}
+	return nil, fmt.Errorf("the block %d isn't held by the queue", height)
+}
+
+// VerifyAuditProof checks the samples of the audit against its commitment, if the audit has a proof.
+func VerifyAuditProof(a *peer.Audit) error {
+	if a.Proof == "" {
+		return nil
+	}
+	rootC, err := ParseCommitment(a.Commitment)
+	if err != nil {
+		return err
+	}
+	keys := make([][]byte, len(a.Samples))
+	values := make([][]byte, len(a.Samples))
+	for i, s := range a.Samples {
+		if keys[i], err = hex.DecodeString(s.Key); err != nil {
+			return err
+		}
+		values[i] = []byte{}
+		if s.Exists {
+			if values[i], err = hex.DecodeString(s.Value); err != nil {
+				return err
+			}
+		}
+	}
+	return lightclient.VerifyValues(rootC, a.Proof, keys, values)
+}
+
+func auditSamples() int {
+	if Peers.Audit.Samples == 0 {
+		return peer.DefaultAuditSamples
+	}
+	return Peers.Audit.Samples
+}
+
+func GetPeerAudit(c *gin.Context, queue *stateless.Queue) {
+	height, err := strconv.ParseUint(c.Query("height"), 10, 64)
+	if err != nil {
+		errStr := fmt.Sprintf("Invalid height due to %v", err)
+		c.JSON(http.StatusBadRequest, PeerAuditResponse{Error: &errStr})
+		return
+	}
+	audit, err := LocalAudit(queue, uint(height), auditSamples())
+	if err != nil {
+		errStr := err.Error()
+		status := http.StatusNotFound
+		if errors.Is(err, errNotExecuted) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, PeerAuditResponse{Error: &errStr})
+		return
+	}
+	Peers.Signer.SignAudit(audit)
+	c.JSON(http.StatusOK, PeerAuditResponse{
+		Error:  nil,
+		Result: audit,
+	})
+}
+
+// auditMember compares the sample of the block with the member, and retries while the member lags behind.
+func auditMember(ctx context.Context, local *peer.Audit, m peer.Member) (string, error) {
+	client := &http.Client{Timeout: auditRetryInterval}
+	var err error
+	for attempt := 0; attempt < auditAttempts; attempt++ {
+		if attempt != 0 {
+			select {
+			case <-ctx.Done():
+				return "failed", ctx.Err()
+			case <-time.After(auditRetryInterval):
+			}
+		}
+		var remote *peer.Audit
+		remote, err = peer.FetchAudit(ctx, client, m, Peers.MemberToken(m), local.Height)
+		if err != nil {
+			continue
+		}
+		if err := VerifyAuditProof(remote); err != nil {
+			return "failed", fmt.Errorf("the proof of the member %s: %v", m.Name, err)
+		}
+		mismatches, err := peer.Compare(local, remote)
+		if errors.Is(err, peer.ErrNotComparable) {
+			return "skipped", fmt.Errorf("the member %s is at the block %s", m.Name, remote.Hash)
+		}
+		if len(mismatches) != 0 {
+			for _, mismatch := range mismatches {
+				log.Printf("The audit of the block %d with the member %s mismatches: %s", local.Height, m.Name, mismatch)
+			}
+			return "mismatch", fmt.Errorf("%d of %d samples mismatch the member %s", len(mismatches), len(local.Samples), m.Name)
+		}
+		return "match", nil
+	}
+	return "failed", err
+}
+
+// AuditMembers compares the sample of the block at the height with every audited member.
+// A mismatch means that the state of one side has diverged, which is logged and counted in the metrics.
+func AuditMembers(ctx context.Context, queue *stateless.Queue, height uint) {
+	if Peers == nil || len(Peers.Audit.Members) == 0 {
+		return
+	}
+	local, err := LocalAudit(queue, height, auditSamples())
+	if err != nil {
+		log.Printf("Failed to sample the block %d for the audits: %v", height, err)
+		return
+	}
+	for _, m := range Peers.Audit.Members {
+		go func(m peer.Member) {
+			result, err := auditMember(ctx, local, m)
+			metrics.PeerAudits.WithLabelValues(m.Name, result).Inc()
+			if err != nil {
+				log.Printf("The audit of the block %d is %s: %v", height, result, err)
+			}
+		}(m)
+	}
+}
@@ -0,0 +1,163 @@
+package apis
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net/http"
+
+	"github.com/ethereum/go-verkle"
+	"github.com/gin-gonic/gin"
+
+	"github.com/RiemaLabs/modular-indexer-committee/lightclient"
+	"github.com/RiemaLabs/modular-indexer-committee/ord"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/brc20"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+// The max number of keys proven by a batch, two per balance, which bounds the size of the proof.
+const MaxBatchProofKeys = 1024
+
+func batchProofError(c *gin.Context, code int, errStr string) {
+	c.JSON(code, Brc20VerifiableBatchProofResponse{
+		Error:  &errStr,
+		Result: nil,
+		Proof:  nil,
+	})
+}
+
+// batchProofKeys returns the keys of the balances followed by the raw keys of the request.
+func batchProofKeys(req *Brc20VerifiableBatchProofRequest) ([][]byte, error) {
+	if len(req.Balances) == 0 && len(req.Keys) == 0 {
+		return nil, fmt.Errorf("at least one balance or key is required")
+	}
+	if n := 2*len(req.Balances) + len(req.Keys); n > MaxBatchProofKeys {
+		return nil, fmt.Errorf("at most %d keys are allowed, two per balance, current is: %d", MaxBatchProofKeys, n)
+	}
+	keys := make([][]byte, 0, 2*len(req.Balances)+len(req.Keys))
+	for _, b := range req.Balances {
+		if b.Tick == "" || b.Pkscript == "" {
+			return nil, fmt.Errorf("the tick and the pkscript of every balance are required")
+		}
+		if _, err := hex.DecodeString(b.Pkscript); err != nil {
+			return nil, fmt.Errorf("invalid pkscript %s: %v", b.Pkscript, err)
+		}
+		keys = append(keys,
+			brc20.GetTickPkscriptHash(b.Tick, ord.Pkscript(b.Pkscript), brc20.AvailableBalancePkscript),
+			brc20.GetTickPkscriptHash(b.Tick, ord.Pkscript(b.Pkscript), brc20.OverallBalancePkscript))
+	}
+	for _, k := range req.Keys {
+		key, err := hex.DecodeString(k)
+		if err == nil && len(key) != verkle.KeySize {
+			err = fmt.Errorf("expected %d bytes", verkle.KeySize)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid key %s: %v", k, err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// PostBatchProof returns the balances of many (tick, pkscript) pairs and the values of many raw keys of the state,
+// with a single multiproof of all the keys instead of one proof per balance. The ticks aren't folded, as the ones of
+// the current balances.
+func PostBatchProof(c *gin.Context, queue *stateless.Queue) {
+	var req Brc20VerifiableBatchProofRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		batchProofError(c, http.StatusBadRequest, fmt.Sprintf("Invalid request due to %v", err))
+		return
+	}
+	keys, err := batchProofKeys(&req)
+	if err != nil {
+		batchProofError(c, http.StatusBadRequest, fmt.Sprintf("Invalid batch due to %v", err))
+		return
+	}
+
+	height, hash, commitment := censusAttestation(queue)
+	result := Brc20VerifiableBatchProofResult{
+		Height:     height,
+		Hash:       hash,
+		Commitment: commitment,
+		Balances:   make([]Brc20BatchBalance, 0, len(req.Balances)),
+		Values:     make([]Brc20BatchValue, 0, len(req.Keys)),
+	}
+	for _, b := range req.Balances {
+		_, _, availableBalance, overallBalance, err := brc20.GetBalances(queue.Header, b.Tick, ord.Pkscript(b.Pkscript))
+		if err != nil {
+			batchProofError(c, http.StatusInternalServerError, fmt.Sprintf("Failed to read the balance due to %v", err))
+			return
+		}
+		result.Balances = append(result.Balances, Brc20BatchBalance{
+			Tick:             b.Tick,
+			Pkscript:         b.Pkscript,
+			AvailableBalance: availableBalance.String(),
+			OverallBalance:   overallBalance.String(),
+		})
+	}
+	for i, key := range keys[2*len(req.Balances):] {
+		value, err := queue.Header.GetUInt256(key)
+		if err != nil {
+			batchProofError(c, http.StatusInternalServerError, fmt.Sprintf("Failed to read the key %s due to %v", req.Keys[i], err))
+			return
+		}
+		raw := value.Bytes32()
+		result.Values = append(result.Values, Brc20BatchValue{Key: req.Keys[i], Value: hex.EncodeToString(raw[:])})
+	}
+
+	// The keys repeated by the batch are proven once.
+	unique := make([][]byte, 0, len(keys))
+	seen := make(map[[verkle.KeySize]byte]bool, len(keys))
+	for _, key := range keys {
+		if !seen[[verkle.KeySize]byte(key)] {
+			seen[[verkle.KeySize]byte(key)] = true
+			unique = append(unique, key)
+		}
+	}
+	proof, err := makeProof(queue.Header, unique)
+	if err != nil {
+		batchProofError(c, http.StatusInternalServerError, fmt.Sprintf("Failed to generate proof due to %v", err))
+		return
+	}
+	result.StateDiff = proof.stateDiff
+	c.JSON(http.StatusOK, Brc20VerifiableBatchProofResponse{
+		Error:  nil,
+		Result: &result,
+		Proof:  &proof.proof,
+	})
+}
+
+// VerifyBatchProof verifies the aggregated proof against the state root, and checks every balance and every value of
+// the batch against the proven values, the absent keys being zero.
+func VerifyBatchProof(rootC *verkle.Point, resp *Brc20VerifiableBatchProofResponse) (bool, error) {
+	if resp.Error != nil {
+		return false, fmt.Errorf("failed to obtain the proof from committee indexer, error: %s", *resp.Error)
+	}
+	if resp.Result == nil || resp.Proof == nil {
+		return false, fmt.Errorf("the batch or its proof is missing")
+	}
+	result := resp.Result
+	proven, err := lightclient.ProvenValues(rootC, *resp.Proof, result.StateDiff)
+	if err != nil {
+		return false, err
+	}
+	for _, b := range result.Balances {
+		balance := Brc20VerifiableTickBalance{Tick: b.Tick, AvailableBalance: b.AvailableBalance, OverallBalance: b.OverallBalance}
+		if err := checkProvenBalance(proven, balance, b.Pkscript); err != nil {
+			return false, err
+		}
+	}
+	for _, v := range result.Values {
+		key, err := hex.DecodeString(v.Key)
+		if err != nil || len(key) != verkle.KeySize {
+			return false, fmt.Errorf("invalid key %s", v.Key)
+		}
+		value, err := hex.DecodeString(v.Value)
+		if err != nil || len(value) != stateless.ValueSize {
+			return false, fmt.Errorf("invalid value %s of the key %s", v.Value, v.Key)
+		}
+		if err := checkProven(proven, key, value); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
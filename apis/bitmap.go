@@ -0,0 +1,64 @@
+package apis
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord/bitmap"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/protocol"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+// BitmapModule serves the Bitmap districts under /v1/bitmap, appended to the Modules once the protocol is registered.
+var BitmapModule = Module{Namespace: "bitmap", MetaProtocol: bitmap.Name, Register: registerBitmap}
+
+func registerBitmap(g *gin.RouterGroup, queue *stateless.Queue) {
+	g.GET("/district/:district", func(c *gin.Context) {
+		GetBitmapDistrict(c, queue)
+	})
+}
+
+// GetBitmapDistrict returns the owner of the district, with the proof of the keys of the district against the state root.
+func GetBitmapDistrict(c *gin.Context, queue *stateless.Queue) {
+	district, err := strconv.ParseUint(c.Param("district"), 10, 64)
+	if err != nil {
+		errStr := fmt.Sprintf("Invalid district %s", c.Param("district"))
+		c.JSON(http.StatusBadRequest, BitmapDistrictResponse{Error: &errStr})
+		return
+	}
+	record, found, err := bitmap.Resolve(protocol.Namespace(queue.Header, bitmap.Name), district)
+	if err != nil {
+		errStr := fmt.Sprintf("Failed to resolve the district due to %v", err)
+		c.JSON(http.StatusInternalServerError, BitmapDistrictResponse{Error: &errStr})
+		return
+	}
+	if !found {
+		errStr := fmt.Sprintf("The district %d isn't claimed", district)
+		c.JSON(http.StatusNotFound, BitmapDistrictResponse{Error: &errStr})
+		return
+	}
+	keys := bitmap.RecordKeys(record)
+	for i, key := range keys {
+		keys[i] = protocol.NamespaceKey(bitmap.Name, key)
+	}
+	proof, err := makeProof(queue.Header, keys)
+	if err != nil {
+		errStr := fmt.Sprintf("Failed to generate proof due to %v", err)
+		c.JSON(http.StatusInternalServerError, BitmapDistrictResponse{Error: &errStr})
+		return
+	}
+	commitment := queue.Header.Root.Commit().Bytes()
+	c.JSON(http.StatusOK, BitmapDistrictResponse{
+		Error: nil,
+		Result: &BitmapDistrictResult{
+			Record:    record,
+			Height:    queue.Header.Height,
+			StateRoot: base64.StdEncoding.EncodeToString(commitment[:]),
+		},
+		Proof: &proof.proof,
+	})
+}
@@ -0,0 +1,51 @@
+package apis
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/RiemaLabs/modular-indexer-committee/checkpoint"
+	"github.com/RiemaLabs/modular-indexer-committee/ord"
+)
+
+// The proof types attached to the verifiable responses.
+var ProofTypes = []string{"verkle-multiproof"}
+
+// NewCapabilities describes the deployment to light clients, so they can select compatible committee indexers.
+// The features are the API routes registered on the engine.
+func NewCapabilities(r *gin.Engine, metaProtocol string) *CapabilitiesResult {
+	features := make([]string, 0)
+	for _, route := range r.Routes() {
+		if strings.HasPrefix(route.Path, "/debug/") {
+			continue
+		}
+		features = append(features, route.Path)
+	}
+	sort.Strings(features)
+	namespaces := make([]string, len(Modules))
+	metaProtocols := []string{metaProtocol}
+	for i, m := range Modules {
+		namespaces[i] = "/v1/" + m.Namespace
+		if m.MetaProtocol != "" {
+			metaProtocols = append(metaProtocols, m.MetaProtocol)
+		}
+	}
+	return &CapabilitiesResult{
+		Network:            string(ord.IndexedNetwork),
+		MetaProtocols:      metaProtocols,
+		CheckpointVersions: checkpoint.SupportedFormatVersions,
+		ProofTypes:         ProofTypes,
+		Namespaces:         namespaces,
+		Features:           features,
+	}
+}
+
+func GetCapabilities(c *gin.Context, capabilities *CapabilitiesResult) {
+	c.JSON(http.StatusOK, CapabilitiesResponse{
+		Error:  nil,
+		Result: capabilities,
+	})
+}
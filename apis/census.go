@@ -0,0 +1,76 @@
+package apis
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+// The max number of the days or the ticks returned by a census request.
+const (
+	MaxCensusDays  = 365
+	MaxCensusTicks = 1000
+)
+
+// censusAttestation returns the latest block and its commitment, which the census is derived from.
+// The census itself isn't committed, while every tick of it can be verified by the proofs of its state.
+func censusAttestation(queue *stateless.Queue) (uint, string, string) {
+	commitment := queue.Header.Root.Commit().Bytes()
+	return queue.Header.Height, queue.Header.Hash, base64.StdEncoding.EncodeToString(commitment[:])
+}
+
+func GetCensus(c *gin.Context, queue *stateless.Queue) {
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil || days < 0 || days > MaxCensusDays {
+		errStr := fmt.Sprintf("The days must be between 0 and %d", MaxCensusDays)
+		c.JSON(http.StatusBadRequest, CensusResponse{Error: &errStr})
+		return
+	}
+	height, hash, commitment := censusAttestation(queue)
+	census := stateless.CurrentCensus(days)
+	if census.Height != height {
+		errStr := fmt.Sprintf("The census is at the height %d instead of %d, please retry later", census.Height, height)
+		c.JSON(http.StatusServiceUnavailable, CensusResponse{Error: &errStr})
+		return
+	}
+	c.JSON(http.StatusOK, CensusResponse{
+		Error: nil,
+		Result: &CensusResult{
+			Census:     census,
+			Hash:       hash,
+			Commitment: commitment,
+		},
+	})
+}
+
+func GetCensusTicks(c *gin.Context, queue *stateless.Queue) {
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		errStr := "The offset must not be negative"
+		c.JSON(http.StatusBadRequest, CensusTicksResponse{Error: &errStr})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(MaxCensusTicks)))
+	if err != nil || limit <= 0 || limit > MaxCensusTicks {
+		errStr := fmt.Sprintf("The limit must be between 1 and %d", MaxCensusTicks)
+		c.JSON(http.StatusBadRequest, CensusTicksResponse{Error: &errStr})
+		return
+	}
+	height, hash, commitment := censusAttestation(queue)
+	ticks, total := stateless.CensusTicks(offset, limit)
+	c.JSON(http.StatusOK, CensusTicksResponse{
+		Error: nil,
+		Result: &CensusTicksResult{
+			Height:     height,
+			Hash:       hash,
+			Commitment: commitment,
+			Total:      total,
+			Ticks:      ticks,
+		},
+	})
+}
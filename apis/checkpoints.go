@@ -0,0 +1,74 @@
+package apis
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/RiemaLabs/modular-indexer-committee/checkpoint"
+)
+
+// Publications is nil unless the checkpoints published by the member are recorded.
+var Publications *checkpoint.Ledger
+
+// MaxCheckpointHeights is the max number of the heights of the checkpoints queried at once.
+const MaxCheckpointHeights = 1000
+
+// GetCheckpoints returns the checkpoints published by the member from the height from to the height to, along with
+// where each of them is published, e.g. the namespace and the transaction of the DA layer. The last 100 heights up to
+// the latest published checkpoint are returned by default.
+func GetCheckpoints(c *gin.Context) {
+	parse := func(name string) (uint, bool, error) {
+		value := c.Query(name)
+		if value == "" {
+			return 0, false, nil
+		}
+		height, err := strconv.ParseUint(value, 10, 64)
+		return uint(height), true, err
+	}
+	from, hasFrom, err := parse("from")
+	if err != nil {
+		errStr := fmt.Sprintf("Invalid from due to %v", err)
+		c.JSON(http.StatusBadRequest, CheckpointsResponse{Error: &errStr})
+		return
+	}
+	to, hasTo, err := parse("to")
+	if err != nil {
+		errStr := fmt.Sprintf("Invalid to due to %v", err)
+		c.JSON(http.StatusBadRequest, CheckpointsResponse{Error: &errStr})
+		return
+	}
+	if !hasTo {
+		if hasFrom {
+			to = from + MaxCheckpointHeights - 1
+		} else if to, _, err = Publications.Latest(); err != nil {
+			errStr := fmt.Sprintf("Failed to read the published checkpoints due to %v", err)
+			c.JSON(http.StatusInternalServerError, CheckpointsResponse{Error: &errStr})
+			return
+		}
+	}
+	if !hasFrom && to >= 100 {
+		from = to - 99
+	}
+	if to < from || to-from >= MaxCheckpointHeights {
+		errStr := fmt.Sprintf("The heights must be from %d to %d, at most %d heights", from, to, MaxCheckpointHeights)
+		c.JSON(http.StatusBadRequest, CheckpointsResponse{Error: &errStr})
+		return
+	}
+	publications, err := Publications.Publications(from, to)
+	if err != nil {
+		errStr := fmt.Sprintf("Failed to read the published checkpoints due to %v", err)
+		c.JSON(http.StatusInternalServerError, CheckpointsResponse{Error: &errStr})
+		return
+	}
+	c.JSON(http.StatusOK, CheckpointsResponse{
+		Error: nil,
+		Result: &CheckpointsResult{
+			From:         from,
+			To:           to,
+			Publications: publications,
+		},
+	})
+}
@@ -0,0 +1,91 @@
+package apis
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+// The headers of the block boundary a state API is served from.
+const (
+	HeaderBlockHeight = "X-Block-Height"
+	HeaderBlockHash   = "X-Block-Hash"
+	HeaderStateRoot   = "X-State-Root"
+)
+
+// MaxMinHeightWait is the longest a request waits for its min_height, and the default of its timeout.
+var MaxMinHeightWait = 30 * time.Second
+
+// Consistent serves the state APIs from a committed block boundary: the queue is read locked for the whole request,
+// so a response never mixes the states before and after a block, and the height, the hash and the root
+// of the served state are set in the headers. With ?min_height=<height>, the request waits until the block
+// at the height is committed, up to ?timeout=<duration> capped by MaxMinHeightWait, or fails with 503.
+func Consistent(queue *stateless.Queue) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var minHeight uint
+		if s := c.Query("min_height"); s != "" {
+			height, err := strconv.ParseUint(s, 10, 64)
+			if err != nil {
+				errStr := fmt.Sprintf("Invalid min_height %s", s)
+				c.AbortWithStatusJSON(http.StatusBadRequest, ErrorResponse{Error: &errStr})
+				return
+			}
+			minHeight = uint(height)
+		}
+		timeout := MaxMinHeightWait
+		if s := c.Query("timeout"); s != "" {
+			d, err := time.ParseDuration(s)
+			if err != nil || d < 0 {
+				errStr := fmt.Sprintf("Invalid timeout %s, expected a duration such as 5s", s)
+				c.AbortWithStatusJSON(http.StatusBadRequest, ErrorResponse{Error: &errStr})
+				return
+			}
+			timeout = min(d, MaxMinHeightWait)
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		if !rLockAt(ctx, queue, minHeight) {
+			errStr := fmt.Sprintf("The state is at height %d, below the min_height %d", queue.CommittedHeight(), minHeight)
+			c.Header("Retry-After", "1")
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, ErrorResponse{Error: &errStr})
+			return
+		}
+		defer queue.RUnlock()
+
+		commitment := queue.Header.Root.Commit().Bytes()
+		c.Header(HeaderBlockHeight, strconv.FormatUint(uint64(queue.Header.Height), 10))
+		c.Header(HeaderBlockHash, queue.Header.Hash)
+		c.Header(HeaderStateRoot, base64.StdEncoding.EncodeToString(commitment[:]))
+		c.Next()
+	}
+}
+
+// rLockAt read locks the queue once the block at the min height is committed, false if the context is done before.
+func rLockAt(ctx context.Context, queue *stateless.Queue, minHeight uint) bool {
+	for {
+		// The lock is held by the update of the queue for as long as the blocks take,
+		// so the request waits for the min height without it, to honor the timeout.
+		if !queue.TryRLock() {
+			if queue.WaitHeight(ctx, minHeight) != nil {
+				return false
+			}
+			queue.RLock()
+		}
+		// A reorg may roll the state back after the wait.
+		if queue.Header.Height >= minHeight {
+			return true
+		}
+		queue.RUnlock()
+		if queue.WaitHeight(ctx, minHeight) != nil {
+			return false
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package apis
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/RiemaLabs/modular-indexer-committee/crosscheck"
+)
+
+// CrossCheck is nil unless the checkpoints of the other members are cross-checked.
+var CrossCheck *crosscheck.Checker
+
+// GetConsensus returns whether the checkpoints of the members agree with the local one,
+// at the height of the query or at the latest cross-checked height.
+func GetConsensus(c *gin.Context) {
+	status := CrossCheck.Latest()
+	if h := c.Query("height"); h != "" {
+		height, err := strconv.ParseUint(h, 10, 64)
+		if err != nil {
+			errStr := fmt.Sprintf("Invalid height due to %v", err)
+			c.JSON(http.StatusBadRequest, ConsensusResponse{Error: &errStr})
+			return
+		}
+		status = CrossCheck.At(uint(height))
+	}
+	if status == nil {
+		errStr := "The height isn't cross-checked"
+		c.JSON(http.StatusNotFound, ConsensusResponse{Error: &errStr})
+		return
+	}
+	c.JSON(http.StatusOK, ConsensusResponse{
+		Error:  nil,
+		Result: status,
+	})
+}
@@ -0,0 +1,36 @@
+package apis
+
+import (
+	"encoding/hex"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord/brc20"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+// GetStateDigest returns the digest of the full state, so that two committee indexers can compare their states
+// at the same height cheaply. Unlike the verkle commitment, it doesn't require to rebuild any tree.
+func GetStateDigest(c *gin.Context, queue *stateless.Queue) {
+	digest := queue.Header.Digest()
+	c.JSON(http.StatusOK, StateDigestResponse{
+		Error: nil,
+		Result: &StateDigestResult{
+			Height: queue.Header.Height,
+			Hash:   queue.Header.Hash,
+			Digest: hex.EncodeToString(digest[:]),
+			Size:   queue.Header.KV.Len(),
+		},
+	})
+}
+
+// GetStateSchema returns the registry of the state keys and the value encodings,
+// so that the verifiers derive the keys of the proofs without reading the source.
+func GetStateSchema(c *gin.Context) {
+	schema := brc20.StateSchema()
+	c.JSON(http.StatusOK, StateSchemaResponse{
+		Error:  nil,
+		Result: &schema,
+	})
+}
@@ -0,0 +1,126 @@
+package apis
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/brc20"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/getter"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/protocol"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+	"github.com/RiemaLabs/modular-indexer-committee/peer"
+)
+
+// DryRunService lets the wallets pre-validate their inscriptions against a fork of the latest state.
+type DryRunService struct {
+	// Tokens returns the latest tokens of the wallets, which may be rotated.
+	Tokens func() []string
+}
+
+// DryRuns is nil unless the dry runs are enabled.
+var DryRuns *DryRunService
+
+// The max size of the body of a dry run.
+const maxDryRunBody = 64 << 10
+
+// The inscription ID of the candidate inscriptions, which never collides with an inscribed one.
+var dryRunInscriptionID = strings.Repeat("0", 64) + "i0"
+
+func authorizeDryRun(c *gin.Context) {
+	token, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !found || !peer.Authorized(token, DryRuns.Tokens()) {
+		errStr := "Unauthorized wallet"
+		c.AbortWithStatusJSON(http.StatusUnauthorized, DryRunResponse{Error: &errStr})
+		return
+	}
+	c.Next()
+}
+
+// dryRun executes the inscription as the only one of the next block on a fork of the latest state.
+// The queue is read locked by Consistent.
+func dryRun(queue *stateless.Queue, req DryRunRequest) (result *DryRunResult, err error) {
+	fork := queue.Header.Fork()
+	pkscript := ord.Pkscript(req.Pkscript)
+	_, _, availableBefore, overallBefore, err := brc20.GetBalances(fork, req.Tick, pkscript)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("the inscription can't be executed: %v", r)
+		}
+	}()
+	protocol.Exec(fork, []getter.OrdTransfer{{
+		InscriptionID: dryRunInscriptionID,
+		BlockHeight:   fork.Height + 1,
+		NewPkscript:   pkscript,
+		NewWallet:     ord.Wallet(req.Wallet),
+		Content:       []byte(req.Content),
+		ContentType:   "text/plain",
+		ParentID:      req.ParentID,
+	}}, fork.Height+1)
+
+	_, _, availableAfter, overallAfter, err := brc20.GetBalances(fork, req.Tick, pkscript)
+	if err != nil {
+		return nil, err
+	}
+	return &DryRunResult{
+		Height: fork.Height + 1,
+		Hash:   fork.Hash,
+		// An invalid inscription never writes the state.
+		Valid:    len(fork.IntermediateKV) != 0,
+		Tick:     req.Tick,
+		Pkscript: req.Pkscript,
+		AvailableBalance: BalanceChange{
+			Before: availableBefore.String(),
+			After:  availableAfter.String(),
+		},
+		OverallBalance: BalanceChange{
+			Before: overallBefore.String(),
+			After:  overallAfter.String(),
+		},
+		Writes: len(fork.IntermediateKV),
+	}, nil
+}
+
+// PostDryRun tells whether the candidate inscription would be valid if inscribed in the next block,
+// and how it would change the balances of the pkscript. The latest state is never changed.
+func PostDryRun(c *gin.Context, queue *stateless.Queue) {
+	fail := func(status int, errStr string) {
+		c.JSON(status, DryRunResponse{Error: &errStr})
+	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxDryRunBody)
+	var req DryRunRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		fail(http.StatusBadRequest, fmt.Sprintf("Invalid dry run due to %v", err))
+		return
+	}
+	if _, err := hex.DecodeString(req.Pkscript); err != nil || req.Pkscript == "" {
+		fail(http.StatusBadRequest, "The pkscript must be hex")
+		return
+	}
+	if reason := brc20.Limits.SkipContent([]byte(req.Content)); reason != "" {
+		fail(http.StatusBadRequest, fmt.Sprintf("The content is %s", reason))
+		return
+	}
+	if req.Tick == "" {
+		req.Tick = brc20.ParseContent([]byte(req.Content), queue.LatestHeight()+1)["tick"]
+	}
+	req.Tick = brc20.NormalizeTick(req.Tick)
+
+	result, err := dryRun(queue, req)
+	if err != nil {
+		fail(http.StatusBadRequest, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, DryRunResponse{
+		Error:  nil,
+		Result: result,
+	})
+}
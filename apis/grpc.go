@@ -0,0 +1,329 @@
+package apis
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"log"
+	"net"
+	"strconv"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/RiemaLabs/modular-indexer-committee/apis/pb"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/brc20"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/subscription"
+)
+
+// The metadata of a unary call waiting until the block at the height is committed, as ?min_height of the REST APIs.
+const MetadataMinHeight = "min-height"
+
+// consistentUnary serves the unary calls from a committed block boundary as Consistent does. The call waits for the
+// block of its min-height metadata up to its deadline capped by MaxMinHeightWait, and the served block is sent in
+// the header metadata.
+func consistentUnary(queue *stateless.Queue) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		var minHeight uint
+		if values := metadata.ValueFromIncomingContext(ctx, MetadataMinHeight); len(values) != 0 {
+			height, err := strconv.ParseUint(values[0], 10, 64)
+			if err != nil {
+				return nil, status.Errorf(codes.InvalidArgument, "Invalid min-height %s", values[0])
+			}
+			minHeight = uint(height)
+		}
+		ctx, cancel := context.WithTimeout(ctx, MaxMinHeightWait)
+		defer cancel()
+		if !rLockAt(ctx, queue, minHeight) {
+			return nil, status.Errorf(codes.Unavailable, "The state is at height %d, below the min-height %d", queue.CommittedHeight(), minHeight)
+		}
+		defer queue.RUnlock()
+
+		commitment := queue.Header.Root.Commit().Bytes()
+		_ = grpc.SetHeader(ctx, metadata.Pairs(
+			HeaderBlockHeight, strconv.FormatUint(uint64(queue.Header.Height), 10),
+			HeaderBlockHash, queue.Header.Hash,
+			HeaderStateRoot, base64.StdEncoding.EncodeToString(commitment[:]),
+		))
+		return handler(ctx, req)
+	}
+}
+
+// committeeServer serves the state APIs over gRPC, mirroring the REST service.
+type committeeServer struct {
+	pb.UnimplementedCommitteeServer
+	queue        *stateless.Queue
+	metaProtocol string
+}
+
+// NewGRPCServer registers the gRPC service of the committee indexer serving the given queue.
+func NewGRPCServer(queue *stateless.Queue, metaProtocol string) *grpc.Server {
+	unary := []grpc.UnaryServerInterceptor{consistentUnary(queue)}
+	var stream []grpc.StreamServerInterceptor
+	if Access != nil {
+		// The calls over the limit are rejected before waiting for the state.
+		unary = append([]grpc.UnaryServerInterceptor{limitUnary}, unary...)
+		stream = append(stream, limitStream)
+	}
+	s := grpc.NewServer(grpc.ChainUnaryInterceptor(unary...), grpc.ChainStreamInterceptor(stream...))
+	pb.RegisterCommitteeServer(s, &committeeServer{queue: queue, metaProtocol: metaProtocol})
+	return s
+}
+
+func StartGRPCService(queue *stateless.Queue, metaProtocol string, addr string) {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		log.Fatalf("Failed to listen the gRPC service at %s: %v", addr, err)
+	}
+	if err := NewGRPCServer(queue, metaProtocol).Serve(lis); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func (s *committeeServer) GetBlockHeight(context.Context, *pb.GetBlockHeightRequest) (*pb.GetBlockHeightResponse, error) {
+	return &pb.GetBlockHeightResponse{Height: uint64(s.queue.LatestHeight())}, nil
+}
+
+func (s *committeeServer) balance(tick string, pkscript string) (*pb.BalanceResponse, error) {
+	availKey, overKey, result, err := GetAllBalances(s.queue, tick, pkscript)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to read the balance due to %v", err)
+	}
+	proof, err := makeProof(s.queue.Header, [][]byte{availKey, overKey})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to generate proof due to %v", err)
+	}
+	return &pb.BalanceResponse{
+		AvailableBalance: result.AvailableBalance,
+		OverallBalance:   result.OverallBalance,
+		Pkscript:         pkscript,
+		Height:           uint64(result.Height),
+		StateRoot:        result.StateRoot,
+		Proof:            proof.proof,
+	}, nil
+}
+
+func (s *committeeServer) GetBalanceOfPkscript(_ context.Context, req *pb.GetBalanceOfPkscriptRequest) (*pb.BalanceResponse, error) {
+	return s.balance(req.Tick, req.Pkscript)
+}
+
+func (s *committeeServer) GetBalanceOfWallet(_ context.Context, req *pb.GetBalanceOfWalletRequest) (*pb.BalanceResponse, error) {
+	_, pkscript, err := brc20.GetLatestPkscript(s.queue.Header, req.Wallet)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to read the pkscript of the wallet due to %v", err)
+	}
+	return s.balance(req.Tick, pkscript)
+}
+
+func (s *committeeServer) GetBalanceAtHeight(_ context.Context, req *pb.GetBalanceAtHeightRequest) (*pb.GetBalanceAtHeightResponse, error) {
+	result, err := balanceAtHeight(s.queue, req.Tick, req.Pkscript, uint(req.Height))
+	if err != nil {
+		code := codes.Internal
+		switch {
+		case errors.Is(err, stateless.ErrHeightNotReached):
+			code = codes.InvalidArgument
+		case errors.Is(err, stateless.ErrHistoryUnavailable):
+			code = codes.NotFound
+		}
+		return nil, status.Errorf(code, "Failed to get the balance at height %d due to %v", req.Height, err)
+	}
+	return &pb.GetBalanceAtHeightResponse{
+		Tick:             result.Tick,
+		Pkscript:         result.Pkscript,
+		Height:           uint64(result.Height),
+		AvailableBalance: result.AvailableBalance,
+		OverallBalance:   result.OverallBalance,
+	}, nil
+}
+
+func toPBTick(info stateless.TickInfo) *pb.Tick {
+	return &pb.Tick{
+		Tick:              info.Tick,
+		InscriptionId:     info.InscriptionID,
+		DeployerPkscript:  info.DeployerPkscript,
+		Deployer:          info.Deployer,
+		DeployHeight:      uint64(info.DeployHeight),
+		MaxSupply:         info.MaxSupply,
+		LimitPerMint:      info.LimitPerMint,
+		Decimals:          info.Decimals,
+		SelfMint:          info.SelfMint,
+		Minted:            info.Minted,
+		RemainingSupply:   info.RemainingSupply,
+		LastMintHeight:    uint64(info.LastMintHeight),
+		Completed:         info.Completed,
+		BurnedSupply:      info.BurnedSupply,
+		CirculatingSupply: info.CirculatingSupply,
+		HolderCount:       info.HolderCount,
+		MintCount:         info.MintCount,
+		MintedAmount:      info.MintedAmount,
+	}
+}
+
+func (s *committeeServer) GetTick(_ context.Context, req *pb.GetTickRequest) (*pb.GetTickResponse, error) {
+	tick := brc20.NormalizeTick(req.Tick)
+	height, hash, commitment := censusAttestation(s.queue)
+	info, found, censusHeight := s.queue.Header.TickInfo(tick)
+	if !found {
+		return nil, status.Errorf(codes.NotFound, "The tick %s isn't deployed", tick)
+	}
+	if censusHeight != height {
+		return nil, status.Errorf(codes.Unavailable, "The census is at the height %d instead of %d, please retry later", censusHeight, height)
+	}
+	return &pb.GetTickResponse{
+		Height:     uint64(height),
+		Hash:       hash,
+		Commitment: commitment,
+		Tick:       toPBTick(info),
+	}, nil
+}
+
+func (s *committeeServer) ListTicks(_ context.Context, req *pb.ListTicksRequest) (*pb.ListTicksResponse, error) {
+	limit := int(req.Limit)
+	if limit == 0 {
+		limit = 100
+	}
+	if limit > MaxTicks {
+		return nil, status.Errorf(codes.InvalidArgument, "The limit must be between 1 and %d", MaxTicks)
+	}
+	height, hash, commitment := censusAttestation(s.queue)
+	ticks, total, censusHeight := s.queue.Header.TickInfos(int(req.Offset), limit)
+	if censusHeight != height {
+		return nil, status.Errorf(codes.Unavailable, "The census is at the height %d instead of %d, please retry later", censusHeight, height)
+	}
+	resp := &pb.ListTicksResponse{
+		Height:     uint64(height),
+		Hash:       hash,
+		Commitment: commitment,
+		Total:      uint64(total),
+	}
+	for _, info := range ticks {
+		resp.Ticks = append(resp.Ticks, toPBTick(info))
+	}
+	return resp, nil
+}
+
+func (s *committeeServer) GetCheckpoint(context.Context, *pb.GetCheckpointRequest) (*pb.GetCheckpointResponse, error) {
+	result := checkpointResult(s.queue, s.metaProtocol)
+	resp := &pb.GetCheckpointResponse{
+		Height:              uint64(result.Height),
+		Hash:                result.Hash,
+		SecondaryCommitment: result.SecondaryCommitment,
+		RulesVersion:        result.RulesVersion,
+	}
+	for _, m := range result.Modules {
+		resp.Modules = append(resp.Modules, &pb.CheckpointModule{
+			MetaProtocol: m.MetaProtocol,
+			Namespace:    m.Namespace,
+			Commitment:   m.Commitment,
+		})
+	}
+	return resp, nil
+}
+
+func toPBEvent(event brc20.Event) *pb.Event {
+	switch e := event.(type) {
+	case brc20.DeployEvent:
+		return &pb.Event{Event: &pb.Event_Deploy{Deploy: &pb.DeployEvent{
+			InscriptionId: e.InscriptionID,
+			Tick:          e.Tick,
+			Pkscript:      string(e.Pkscript),
+			Wallet:        string(e.Wallet),
+			MaxSupply:     e.MaxSupply,
+			LimitPerMint:  e.LimitPerMint,
+			Decimals:      e.Decimals,
+			SelfMint:      e.SelfMint,
+		}}}
+	case brc20.MintEvent:
+		return &pb.Event{Event: &pb.Event_Mint{Mint: &pb.MintEvent{
+			InscriptionId: e.InscriptionID,
+			Tick:          e.Tick,
+			Pkscript:      string(e.Pkscript),
+			Wallet:        string(e.Wallet),
+			Amount:        e.Amount,
+			ParentId:      e.ParentID,
+		}}}
+	case brc20.TransferInscribeEvent:
+		return &pb.Event{Event: &pb.Event_TransferInscribe{TransferInscribe: &pb.TransferInscribeEvent{
+			InscriptionId: e.InscriptionID,
+			Tick:          e.Tick,
+			Pkscript:      string(e.Pkscript),
+			Wallet:        string(e.Wallet),
+			Amount:        e.Amount,
+		}}}
+	case brc20.TransferTransferEvent:
+		return &pb.Event{Event: &pb.Event_TransferTransfer{TransferTransfer: &pb.TransferTransferEvent{
+			InscriptionId:  e.InscriptionID,
+			Tick:           e.Tick,
+			SourcePkscript: string(e.SourcePkscript),
+			SourceWallet:   string(e.SourceWallet),
+			SpentPkscript:  string(e.SpentPkscript),
+			SpentWallet:    string(e.SpentWallet),
+			Amount:         e.Amount,
+			SentAsFee:      e.SentAsFee,
+		}}}
+	}
+	return nil
+}
+
+// eventTick returns the tick of the event.
+func eventTick(event brc20.Event) string {
+	switch e := event.(type) {
+	case brc20.DeployEvent:
+		return e.Tick
+	case brc20.MintEvent:
+		return e.Tick
+	case brc20.TransferInscribeEvent:
+		return e.Tick
+	case brc20.TransferTransferEvent:
+		return e.Tick
+	}
+	return ""
+}
+
+// StreamBlocks follows the events pushed by the subscriptions, so it shares their limit of the subscribers.
+func (s *committeeServer) StreamBlocks(req *pb.StreamBlocksRequest, stream pb.Committee_StreamBlocksServer) error {
+	hub := stateless.Subscriptions
+	if hub == nil {
+		return status.Error(codes.Unimplemented, "The subscriptions are disabled")
+	}
+	sub, ok := hub.Subscribe()
+	if !ok {
+		return status.Error(codes.Unavailable, "Too many subscribers, please retry later")
+	}
+	defer hub.Unsubscribe(sub)
+	hub.Update(sub, subscription.Filter{Events: true}, false)
+	ticks := make(map[string]bool)
+	for _, tick := range req.Ticks {
+		ticks[brc20.NormalizeTick(tick)] = true
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case m, ok := <-sub.Messages():
+			if !ok {
+				return status.Error(codes.ResourceExhausted, "Too slow to receive the blocks")
+			}
+			if m.Type != subscription.TypeEvents {
+				continue
+			}
+			events, err := brc20.UnmarshalEvents(m.Events)
+			if err != nil {
+				return status.Errorf(codes.Internal, "Failed to decode the events of the block %d due to %v", m.Height, err)
+			}
+			block := &pb.Block{Height: uint64(m.Height), Hash: m.Hash, Commitment: m.Commitment, Events: make([]*pb.Event, 0, len(events))}
+			for _, event := range events {
+				if len(ticks) == 0 || ticks[eventTick(event)] {
+					block.Events = append(block.Events, toPBEvent(event))
+				}
+			}
+			if err := stream.Send(block); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package apis
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+// MaxLag is the number of the blocks the indexed height may lag behind the chain tip before the probes fail.
+var MaxLag uint = 3
+
+// The upstreams of the blocks, i.e. the getter of an indexer and the member followed by a replica, besides the
+// checkpoint publication methods recorded by their names.
+const (
+	UpstreamGetter = "getter"
+	UpstreamMember = "member"
+)
+
+// UpstreamStatus is the result of the latest call to an upstream of the indexer.
+type UpstreamStatus struct {
+	Connected     bool       `json:"connected"`
+	CheckedAt     time.Time  `json:"checkedAt"`
+	LastSuccessAt *time.Time `json:"lastSuccessAt"`
+	Error         string     `json:"error,omitempty"`
+}
+
+// The sync status reported by the probes, recorded by the service loop and the publications.
+var health struct {
+	sync.RWMutex
+	tip              uint
+	lastCheckpointAt *time.Time
+	upstreams        map[string]UpstreamStatus
+}
+
+// RecordTip records the height of the chain tip reported by the getter.
+func RecordTip(height uint) {
+	health.Lock()
+	defer health.Unlock()
+	health.tip = height
+}
+
+// RecordUpstream records the result of a call to the upstream, i.e. the getter or a checkpoint publication method.
+func RecordUpstream(name string, err error) {
+	health.Lock()
+	defer health.Unlock()
+	if health.upstreams == nil {
+		health.upstreams = make(map[string]UpstreamStatus)
+	}
+	status := health.upstreams[name]
+	status.Connected, status.CheckedAt, status.Error = err == nil, time.Now(), ""
+	if err != nil {
+		status.Error = err.Error()
+	} else {
+		status.LastSuccessAt = &status.CheckedAt
+	}
+	health.upstreams[name] = status
+}
+
+// RecordCheckpoint records the publication of a checkpoint.
+func RecordCheckpoint() {
+	health.Lock()
+	defer health.Unlock()
+	now := time.Now()
+	health.lastCheckpointAt = &now
+}
+
+func currentHealth(queue *stateless.Queue) HealthResult {
+	health.RLock()
+	defer health.RUnlock()
+	indexed := queue.CommittedHeight()
+	result := HealthResult{
+		IndexedHeight:    indexed,
+		TipHeight:        health.tip,
+		MaxLag:           MaxLag,
+		LastCheckpointAt: health.lastCheckpointAt,
+		Upstreams:        maps.Clone(health.upstreams),
+	}
+	if health.tip > indexed {
+		result.Lag = health.tip - indexed
+	}
+	if result.Upstreams == nil {
+		result.Upstreams = make(map[string]UpstreamStatus)
+	}
+	return result
+}
+
+func replyHealth(c *gin.Context, result HealthResult, problems []string) {
+	if len(problems) != 0 {
+		errStr := strings.Join(problems, "; ")
+		c.JSON(http.StatusServiceUnavailable, HealthResponse{Error: &errStr, Result: &result})
+		return
+	}
+	c.JSON(http.StatusOK, HealthResponse{Error: nil, Result: &result})
+}
+
+func lagProblems(result HealthResult) []string {
+	if result.Lag <= result.MaxLag {
+		return nil
+	}
+	return []string{fmt.Sprintf("The indexed height %d lags %d blocks behind the tip %d, more than %d", result.IndexedHeight, result.Lag, result.TipHeight, result.MaxLag)}
+}
+
+// GetHealthz is the liveness probe, which fails once the indexed height lags behind the chain tip by more than
+// MaxLag blocks. The upstreams are reported but don't fail the probe, since restarting the indexer doesn't fix them.
+func GetHealthz(c *gin.Context, queue *stateless.Queue) {
+	result := currentHealth(queue)
+	replyHealth(c, result, lagProblems(result))
+}
+
+// GetReadyz is the readiness probe, which fails as the liveness probe, or once the latest call to an upstream
+// failed, e.g. the getter or the DA layer.
+func GetReadyz(c *gin.Context, queue *stateless.Queue) {
+	result := currentHealth(queue)
+	problems := lagProblems(result)
+	names := make([]string, 0, len(result.Upstreams))
+	for name := range result.Upstreams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if status := result.Upstreams[name]; !status.Connected {
+			problems = append(problems, fmt.Sprintf("The %s is unreachable: %s", name, status.Error))
+		}
+	}
+	replyHealth(c, result, problems)
+}
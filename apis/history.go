@@ -0,0 +1,67 @@
+package apis
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/ethereum/go-verkle"
+	"github.com/gin-gonic/gin"
+	"github.com/holiman/uint256"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/brc20"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+// GetBalanceAtHeight returns the balance of the pkscript once the block at the height was executed, reverted from the
+// kept writes of the later blocks. Unlike the latest balances, the past ones come without a proof.
+func GetBalanceAtHeight(c *gin.Context, queue *stateless.Queue) {
+	tick := c.DefaultQuery("tick", "")
+	pkscript := c.DefaultQuery("pkscript", "")
+	height, err := strconv.ParseUint(c.Query("height"), 10, 64)
+	if err != nil {
+		errStr := "The height must be a non-negative integer"
+		c.JSON(http.StatusBadRequest, Brc20BalanceResponse{Error: &errStr})
+		return
+	}
+
+	result, err := balanceAtHeight(queue, tick, pkscript, uint(height))
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, stateless.ErrHeightNotReached):
+			status = http.StatusBadRequest
+		case errors.Is(err, stateless.ErrHistoryUnavailable):
+			status = http.StatusNotFound
+		}
+		errStr := fmt.Sprintf("Failed to get the balance at height %d due to %v", height, err)
+		c.JSON(status, Brc20BalanceResponse{Error: &errStr})
+		return
+	}
+
+	c.JSON(http.StatusOK, Brc20BalanceResponse{
+		Error:  nil,
+		Result: &result,
+	})
+}
+
+func balanceAtHeight(queue *stateless.Queue, tick string, pkscript string, height uint) (Brc20BalanceResult, error) {
+	balances := make([]string, 0, 2)
+	for _, location := range []brc20.LocationID{brc20.AvailableBalancePkscript, brc20.OverallBalancePkscript} {
+		key := brc20.GetTickPkscriptHash(tick, ord.Pkscript(pkscript), location)
+		value, err := queue.ValueAt([verkle.KeySize]byte(key), height)
+		if err != nil {
+			return Brc20BalanceResult{}, err
+		}
+		balances = append(balances, new(uint256.Int).SetBytes(value[:]).String())
+	}
+	return Brc20BalanceResult{
+		Tick:             tick,
+		Pkscript:         pkscript,
+		Height:           height,
+		AvailableBalance: balances[0],
+		OverallBalance:   balances[1],
+	}, nil
+}
@@ -0,0 +1,55 @@
+package apis
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord/brc20"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+// The max number of the holders returned by a holders request.
+const MaxHolders = 1000
+
+func GetHolders(c *gin.Context, queue *stateless.Queue) {
+	tick := brc20.NormalizeTick(c.DefaultQuery("tick", ""))
+	if tick == "" {
+		errStr := "The tick is required"
+		c.JSON(http.StatusBadRequest, HoldersResponse{Error: &errStr})
+		return
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		errStr := "The offset must not be negative"
+		c.JSON(http.StatusBadRequest, HoldersResponse{Error: &errStr})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit <= 0 || limit > MaxHolders {
+		errStr := fmt.Sprintf("The limit must be between 1 and %d", MaxHolders)
+		c.JSON(http.StatusBadRequest, HoldersResponse{Error: &errStr})
+		return
+	}
+	height, hash, commitment := censusAttestation(queue)
+	holders, total, indexHeight, fromHeight := stateless.TickHolders(tick, offset, limit)
+	if indexHeight != height {
+		errStr := fmt.Sprintf("The holders are at the height %d instead of %d, please retry later", indexHeight, height)
+		c.JSON(http.StatusServiceUnavailable, HoldersResponse{Error: &errStr})
+		return
+	}
+	c.JSON(http.StatusOK, HoldersResponse{
+		Error: nil,
+		Result: &HoldersResult{
+			Tick:       tick,
+			Height:     height,
+			Hash:       hash,
+			Commitment: commitment,
+			FromHeight: fromHeight,
+			Total:      total,
+			Holders:    holders,
+		},
+	})
+}
@@ -0,0 +1,88 @@
+package apis
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord/brc20"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+// The statuses of a transfer inscription.
+const (
+	// The inscribed amount is still transferable by sending the inscription.
+	InscriptionTransferable = "transferable"
+	// The inscription has been sent and the amount moved to the receiver.
+	InscriptionSpent = "spent"
+	// The inscription has been sent as fee and the amount returned to the source.
+	InscriptionSentAsFee = "sentAsFee"
+)
+
+// GetInscriptionHistory returns the lifecycle of a transfer inscription recorded by the events database, so that
+// a marketplace can tell whether a listed transfer inscription is still valid.
+func GetInscriptionHistory(c *gin.Context, queue *stateless.Queue) {
+	inscriptionID := c.Param("id")
+	height, hash, commitment := censusAttestation(queue)
+	events, fromHeight, err := stateless.InscriptionEvents(inscriptionID)
+	if errors.Is(err, stateless.ErrEventsUnavailable) {
+		errStr := fmt.Sprintf("The history of the inscription is unavailable due to %v", err)
+		c.JSON(http.StatusNotFound, InscriptionHistoryResponse{Error: &errStr})
+		return
+	}
+	if err != nil {
+		errStr := fmt.Sprintf("Failed to read the history of the inscription due to %v", err)
+		c.JSON(http.StatusInternalServerError, InscriptionHistoryResponse{Error: &errStr})
+		return
+	}
+
+	result := InscriptionHistoryResult{
+		InscriptionID: inscriptionID,
+		Height:        height,
+		Hash:          hash,
+		Commitment:    commitment,
+		FromHeight:    fromHeight,
+		History:       make([]InscriptionHistoryEntry, 0),
+	}
+	for _, event := range events {
+		// The events database may be ahead of the state being served by the block being paged.
+		if event.Height > height {
+			continue
+		}
+		switch e := event.Event.(type) {
+		case brc20.TransferInscribeEvent:
+			result.Tick, result.Amount, result.Status = e.Tick, e.Amount, InscriptionTransferable
+			result.History = append(result.History, InscriptionHistoryEntry{
+				Height:   event.Height,
+				Type:     e.Type(),
+				Pkscript: string(e.Pkscript),
+				Wallet:   string(e.Wallet),
+			})
+		case brc20.TransferTransferEvent:
+			result.Status = InscriptionSpent
+			if e.SentAsFee {
+				result.Status = InscriptionSentAsFee
+			}
+			result.History = append(result.History, InscriptionHistoryEntry{
+				Height:        event.Height,
+				Type:          e.Type(),
+				Pkscript:      string(e.SourcePkscript),
+				Wallet:        string(e.SourceWallet),
+				SpentPkscript: string(e.SpentPkscript),
+				SpentWallet:   string(e.SpentWallet),
+				SentAsFee:     e.SentAsFee,
+			})
+		}
+	}
+	if len(result.History) == 0 {
+		errStr := fmt.Sprintf("The inscription %s isn't a valid transfer inscription since the height %d", inscriptionID, fromHeight)
+		c.JSON(http.StatusNotFound, InscriptionHistoryResponse{Error: &errStr})
+		return
+	}
+	c.JSON(http.StatusOK, InscriptionHistoryResponse{
+		Error:  nil,
+		Result: &result,
+	})
+}
@@ -0,0 +1,94 @@
+package apis
+
+import (
+	"encoding/base64"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord/brc20"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+// Module is a protocol module of the committee indexer, whose APIs are served under /v1/<namespace>.
+type Module struct {
+	Namespace string
+	// The meta protocol of the module, the one of the committee indexer if empty.
+	MetaProtocol string
+	// Register adds the routes of the module to its namespace.
+	Register func(g *gin.RouterGroup, queue *stateless.Queue)
+}
+
+// Modules are the enabled protocol modules, BRC-20 and the registered ones such as the sats names. The namespaces of
+// the other modules, such as runes or arc20, are reserved for when their executions are added.
+var Modules = []Module{
+	{Namespace: "brc20", Register: registerBRC20},
+}
+
+func registerBRC20(g *gin.RouterGroup, queue *stateless.Queue) {
+	g.GET("/current_balance_of_wallet", func(c *gin.Context) {
+		GetCurrentBalanceOfWallet(c, queue)
+	})
+	g.GET("/current_balance_of_pkscript", func(c *gin.Context) {
+		GetCurrentBalanceOfPkscript(c, queue)
+	})
+	g.GET("/current_portfolio", func(c *gin.Context) {
+		GetCurrentPortfolio(c, queue)
+	})
+	g.POST("/batch_proof", func(c *gin.Context) {
+		PostBatchProof(c, queue)
+	})
+	g.GET("/block_height", func(c *gin.Context) {
+		GetBlockHeight(c, queue)
+	})
+	g.GET("/census", func(c *gin.Context) {
+		GetCensus(c, queue)
+	})
+	g.GET("/census/ticks", func(c *gin.Context) {
+		GetCensusTicks(c, queue)
+	})
+	g.GET("/holders", func(c *gin.Context) {
+		GetHolders(c, queue)
+	})
+	if DryRuns != nil {
+		g.POST("/dry_run", authorizeDryRun, func(c *gin.Context) {
+			PostDryRun(c, queue)
+		})
+	}
+}
+
+// checkpointResult describes the latest state attested by the committee indexer: the root of each module it includes,
+// so that a client can tell which protocols are covered and query them in their namespaces.
+func checkpointResult(queue *stateless.Queue, metaProtocol string) CheckpointResult {
+	commitment := queue.Header.Root.Commit().Bytes()
+	result := CheckpointResult{
+		Height:       queue.Header.Height,
+		Hash:         queue.Header.Hash,
+		RulesVersion: brc20.RulesVersion(),
+	}
+	// All modules share the state tree for now, so they are committed by the same root.
+	for _, m := range Modules {
+		moduleProtocol := metaProtocol
+		if m.MetaProtocol != "" {
+			moduleProtocol = m.MetaProtocol
+		}
+		result.Modules = append(result.Modules, CheckpointModule{
+			MetaProtocol: moduleProtocol,
+			Namespace:    "/v1/" + m.Namespace,
+			Commitment:   base64.StdEncoding.EncodeToString(commitment[:]),
+		})
+	}
+	if stateless.SecondaryCommitment {
+		secondary := queue.Header.SecondaryRoot()
+		result.SecondaryCommitment = base64.StdEncoding.EncodeToString(secondary[:])
+	}
+	return result
+}
+
+func GetCheckpoint(c *gin.Context, queue *stateless.Queue, metaProtocol string) {
+	result := checkpointResult(queue, metaProtocol)
+	c.JSON(http.StatusOK, CheckpointResponse{
+		Error:  nil,
+		Result: &result,
+	})
+}
@@ -0,0 +1,38 @@
+package apis
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+// GetOPICheck returns whether the event hashes agree with the ones of OPI, at the height of the query or at the
+// latest cross-checked height, along with the first divergence since the start.
+func GetOPICheck(c *gin.Context) {
+	status := stateless.OPICheck.Latest()
+	if h := c.Query("height"); h != "" {
+		height, err := strconv.ParseUint(h, 10, 64)
+		if err != nil {
+			errStr := fmt.Sprintf("Invalid height due to %v", err)
+			c.JSON(http.StatusBadRequest, OPICheckResponse{Error: &errStr})
+			return
+		}
+		status = stateless.OPICheck.At(uint(height))
+	}
+	if status == nil {
+		errStr := "The height isn't cross-checked with OPI"
+		c.JSON(http.StatusNotFound, OPICheckResponse{Error: &errStr})
+		return
+	}
+	c.JSON(http.StatusOK, OPICheckResponse{
+		Error: nil,
+		Result: &OPICheckResult{
+			Status:     status,
+			Divergence: stateless.OPICheck.Divergence(),
+		},
+	})
+}
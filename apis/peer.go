@@ -0,0 +1,176 @@
+package apis
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-verkle"
+	"github.com/gin-gonic/gin"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+	"github.com/RiemaLabs/modular-indexer-committee/peer"
+)
+
+// PeerService serves the signed state updates to the other committee members and standbys.
+type PeerService struct {
+	Signer *peer.Signer
+	// Tokens returns the latest tokens of the peers, which may be rotated.
+	Tokens func() []string
+	// The sampling audits of the other members, and the tokens presented to them.
+	Audit       peer.AuditConfig
+	MemberToken func(peer.Member) string
+}
+
+// Peers is nil unless the peer service is enabled.
+var Peers *PeerService
+
+// The interval of polling the queue for new diffs.
+const peerPollInterval = time.Second
+
+func authorizePeer(c *gin.Context) {
+	if !admitToken(c) {
+		return
+	}
+	token, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !found || !peer.Authorized(token, Peers.Tokens()) {
+		failToken(c)
+		errStr := "Unauthorized peer"
+		c.AbortWithStatusJSON(http.StatusUnauthorized, PeerErrorResponse{Error: &errStr})
+		return
+	}
+	c.Next()
+}
+
+func GetPeerKey(c *gin.Context) {
+	publicKey := Peers.Signer.PublicKey()
+	c.JSON(http.StatusOK, PeerKeyResponse{
+		Error:  nil,
+		Result: &publicKey,
+	})
+}
+
+// peerDiffs returns the signed diffs of the blocks held by the queue, from the oldest one.
+func peerDiffs(queue *stateless.Queue) []peer.Diff {
+	queue.RLock()
+	defer queue.RUnlock()
+	diffs := make([]peer.Diff, len(queue.History))
+	for i, state := range queue.History {
+		hash, post := queue.Header.Hash, queue.Header.Root.Commit().Bytes()
+		if i+1 < len(queue.History) {
+			hash, post = queue.History[i+1].Hash, queue.History[i+1].VerkleCommit
+		}
+		elements := make([]peer.Element, len(state.Access.Elements))
+		for j, elem := range state.Access.Elements {
+			elements[j] = peer.Element{
+				Key:            hex.EncodeToString(elem.Key[:]),
+				OldValue:       hex.EncodeToString(elem.OldValue[:]),
+				NewValue:       hex.EncodeToString(elem.NewValue[:]),
+				OldValueExists: elem.OldValueExists,
+				Written:        elem.Written,
+				Deleted:        elem.Deleted,
+			}
+		}
+		diffs[i] = peer.Diff{
+			Height:         state.Height + 1,
+			Hash:           hash,
+			ParentHash:     state.Hash,
+			PreCommitment:  base64.StdEncoding.EncodeToString(state.VerkleCommit[:]),
+			PostCommitment: base64.StdEncoding.EncodeToString(post[:]),
+			Elements:       elements,
+		}
+		Peers.Signer.SignDiff(&diffs[i])
+	}
+	return diffs
+}
+
+// StreamPeerDiffs streams the diffs of the blocks from the height by server-sent events, and follows the new blocks.
+// If a reorg replaces the blocks already sent, the stream restarts from the first replaced block,
+// whose earlier diffs the peer reverts by their old values.
+func StreamPeerDiffs(c *gin.Context, queue *stateless.Queue) {
+	diffs := peerDiffs(queue)
+	oldest := diffs[0].Height
+	next := oldest
+	if from := c.Query("from"); from != "" {
+		height, err := strconv.ParseUint(from, 10, 64)
+		if err != nil {
+			errStr := fmt.Sprintf("Invalid from due to %v", err)
+			c.JSON(http.StatusBadRequest, PeerErrorResponse{Error: &errStr})
+			return
+		}
+		next = uint(height)
+	}
+	if next < oldest {
+		errStr := fmt.Sprintf("The diffs before the block %d are pruned, resume from a snapshot", oldest)
+		c.JSON(http.StatusGone, PeerErrorResponse{Error: &errStr})
+		return
+	}
+
+	sent := make(map[uint]string)
+	c.Stream(func(_ io.Writer) bool {
+		for _, d := range diffs {
+			if d.Height < next && sent[d.Height] != "" && sent[d.Height] != d.PostCommitment {
+				next = d.Height
+				break
+			}
+		}
+		for _, d := range diffs {
+			if d.Height >= next {
+				c.SSEvent("diff", d)
+				sent[d.Height] = d.PostCommitment
+				next = d.Height + 1
+			}
+		}
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case <-time.After(peerPollInterval):
+			diffs = peerDiffs(queue)
+			for height := range sent {
+				if height < diffs[0].Height {
+					delete(sent, height)
+				}
+			}
+			return true
+		}
+	})
+}
+
+// GetPeerSnapshot returns the signed manifest of the latest state followed by its key-values, one JSON per line.
+func GetPeerSnapshot(c *gin.Context, queue *stateless.Queue) {
+	queue.RLock()
+	commitment := queue.Header.Root.Commit().Bytes()
+	digest := queue.Header.Digest()
+	manifest := peer.Manifest{
+		Height:     queue.Header.Height,
+		Hash:       queue.Header.Hash,
+		Commitment: base64.StdEncoding.EncodeToString(commitment[:]),
+		Digest:     hex.EncodeToString(digest[:]),
+		Size:       queue.Header.KV.Len(),
+	}
+	kvs := make([]peer.KeyValue, 0, queue.Header.KV.Len())
+	queue.Header.KV.Range(func(k [verkle.KeySize]byte, v [stateless.ValueSize]byte) bool {
+		kvs = append(kvs, peer.KeyValue{Key: hex.EncodeToString(k[:]), Value: hex.EncodeToString(v[:])})
+		return true
+	})
+	queue.RUnlock()
+	Peers.Signer.SignManifest(&manifest)
+
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Status(http.StatusOK)
+	encoder := json.NewEncoder(c.Writer)
+	if err := encoder.Encode(manifest); err != nil {
+		return
+	}
+	for _, kv := range kvs {
+		if err := encoder.Encode(kv); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,337 @@
+package apis
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-verkle"
+	"github.com/gin-gonic/gin"
+	"github.com/holiman/uint256"
+
+	"github.com/RiemaLabs/modular-indexer-committee/lightclient"
+	"github.com/RiemaLabs/modular-indexer-committee/ord"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/brc20"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+// The max number of ticks of a portfolio request, which bounds the size of the proof.
+const MaxPortfolioTicks = 256
+
+// walletPkscriptKeys returns the keys storing the latest pkscript of the wallet, following the layout of Header.InsertBytes:
+// the first slot stores the length and the following slots store the padded bytes.
+func walletPkscriptKeys(wallet string, pkscript []byte) [][]byte {
+	lenKey := brc20.GetWalletHash(wallet, brc20.WalletLatestPkscript)
+	keys := [][]byte{lenKey}
+	requiredSlots := (len(pkscript) + stateless.ValueSize - 1) / stateless.ValueSize
+	for i := 0; i < requiredSlots; i++ {
+		key := make([]byte, verkle.KeySize)
+		copy(key, lenKey)
+		key[verkle.StemSize] = lenKey[verkle.StemSize] + byte(i+1)
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func portfolioKeys(wallet string, pkscript string, ticks []string) ([][]byte, error) {
+	keys := make([][]byte, 0, 2*len(ticks)+1)
+	if wallet != "" {
+		pkscriptBytes, err := hex.DecodeString(pkscript)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pkscript %s: %v", pkscript, err)
+		}
+		keys = append(keys, walletPkscriptKeys(wallet, pkscriptBytes)...)
+	}
+	for _, tick := range ticks {
+		keys = append(keys,
+			brc20.GetTickPkscriptHash(tick, ord.Pkscript(pkscript), brc20.AvailableBalancePkscript),
+			brc20.GetTickPkscriptHash(tick, ord.Pkscript(pkscript), brc20.OverallBalancePkscript))
+	}
+	return keys, nil
+}
+
+func parsePortfolioTicks(ticks string) ([]string, error) {
+	res := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, tick := range strings.Split(ticks, ",") {
+		if tick == "" || seen[tick] {
+			continue
+		}
+		seen[tick] = true
+		res = append(res, tick)
+	}
+	if len(res) == 0 {
+		return nil, fmt.Errorf("at least one tick is required")
+	}
+	if len(res) > MaxPortfolioTicks {
+		return nil, fmt.Errorf("at most %d ticks are allowed, current is: %d", MaxPortfolioTicks, len(res))
+	}
+	return res, nil
+}
+
+func portfolioError(c *gin.Context, code int, errStr string) {
+	c.JSON(code, Brc20VerifiableCurrentPortfolioResponse{
+		Error:  &errStr,
+		Result: nil,
+		Proof:  nil,
+	})
+}
+
+// GetCurrentPortfolio returns the balances of a wallet or a pkscript over the given ticks,
+// with a single multiproof of all the keys instead of one proof per tick.
+func GetCurrentPortfolio(c *gin.Context, queue *stateless.Queue) {
+	wallet := c.DefaultQuery("wallet", "")
+	pkScript := c.DefaultQuery("pkscript", "")
+	if (wallet == "") == (pkScript == "") {
+		portfolioError(c, http.StatusBadRequest, "Exactly one of wallet and pkscript is required")
+		return
+	}
+	ticks, err := parsePortfolioTicks(c.DefaultQuery("ticks", ""))
+	if err != nil {
+		portfolioError(c, http.StatusBadRequest, fmt.Sprintf("Invalid ticks due to %v", err))
+		return
+	}
+
+	if wallet != "" {
+		_, pkScript, err = brc20.GetLatestPkscript(queue.Header, wallet)
+		if err != nil {
+			portfolioError(c, http.StatusInternalServerError, fmt.Sprintf("Failed to read the pkscript of the wallet due to %v", err))
+			return
+		}
+	}
+	keys, err := portfolioKeys(wallet, pkScript, ticks)
+	if err != nil {
+		portfolioError(c, http.StatusBadRequest, fmt.Sprintf("Invalid portfolio due to %v", err))
+		return
+	}
+
+	balances := make([]Brc20VerifiableTickBalance, 0, len(ticks))
+	for _, tick := range ticks {
+		_, _, availableBalance, overallBalance, err := brc20.GetBalances(queue.Header, tick, ord.Pkscript(pkScript))
+		if err != nil {
+			portfolioError(c, http.StatusInternalServerError, fmt.Sprintf("Failed to read the balance due to %v", err))
+			return
+		}
+		balances = append(balances, Brc20VerifiableTickBalance{
+			Tick:             tick,
+			AvailableBalance: availableBalance.String(),
+			OverallBalance:   overallBalance.String(),
+		})
+	}
+
+	proof, err := makeProof(queue.Header, keys)
+	if err != nil {
+		portfolioError(c, http.StatusInternalServerError, fmt.Sprintf("Failed to generate proof due to %v", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, Brc20VerifiableCurrentPortfolioResponse{
+		Error: nil,
+		Result: &Brc20VerifiableCurrentPortfolioResult{
+			Wallet:    wallet,
+			Pkscript:  pkScript,
+			Balances:  balances,
+			StateDiff: proof.stateDiff,
+		},
+		Proof: &proof.proof,
+	})
+}
+
+// VerifyCurrentPortfolio verifies the aggregated proof against the state root,
+// and checks every balance and the latest pkscript of the wallet against the proven values.
+func VerifyCurrentPortfolio(rootC *verkle.Point, resp *Brc20VerifiableCurrentPortfolioResponse) (bool, error) {
+	if resp.Error != nil {
+		return false, fmt.Errorf("failed to obtain the proof from committee indexer, error: %s", *resp.Error)
+	}
+	if resp.Result == nil || resp.Proof == nil {
+		return false, fmt.Errorf("the portfolio or its proof is missing")
+	}
+	result := resp.Result
+
+	proven, err := lightclient.ProvenValues(rootC, *resp.Proof, result.StateDiff)
+	if err != nil {
+		return false, err
+	}
+	check := func(key []byte, expected []byte) error {
+		return checkProven(proven, key, expected)
+	}
+
+	if result.Wallet != "" {
+		pkscriptBytes, err := hex.DecodeString(result.Pkscript)
+		if err != nil {
+			return false, fmt.Errorf("invalid pkscript %s: %v", result.Pkscript, err)
+		}
+		keys := walletPkscriptKeys(result.Wallet, pkscriptBytes)
+		length := uint256.NewInt(uint64(len(pkscriptBytes))).Bytes32()
+		if err := check(keys[0], length[:]); err != nil {
+			return false, err
+		}
+		padded := make([]byte, (len(keys)-1)*stateless.ValueSize)
+		copy(padded, pkscriptBytes)
+		for i, key := range keys[1:] {
+			if err := check(key, padded[i*stateless.ValueSize:(i+1)*stateless.ValueSize]); err != nil {
+				return false, err
+			}
+		}
+	}
+	for _, balance := range result.Balances {
+		if err := checkProvenBalance(proven, balance, result.Pkscript); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// checkProven checks the claimed value of the key against the proven values, the absent keys being zero.
+func checkProven(proven map[[verkle.KeySize]byte]*[32]byte, key []byte, expected []byte) error {
+	value, found := proven[[verkle.KeySize]byte(key)]
+	if !found {
+		return fmt.Errorf("the key %x is not proven", key)
+	}
+	if value == nil {
+		if !bytes.Equal(expected, make([]byte, len(expected))) {
+			return fmt.Errorf("the key %x is absent but the claimed value is %x", key, expected)
+		}
+		return nil
+	}
+	if !bytes.Equal(value[:], expected) {
+		return fmt.Errorf("the key %x has the proven value %x but the claimed value is %x", key, value[:], expected)
+	}
+	return nil
+}
+
+// checkProvenBalance checks the claimed available and overall balances of the pkscript against the proven values.
+func checkProvenBalance(proven map[[verkle.KeySize]byte]*[32]byte, balance Brc20VerifiableTickBalance, pkscript string) error {
+	availKey := brc20.GetTickPkscriptHash(balance.Tick, ord.Pkscript(pkscript), brc20.AvailableBalancePkscript)
+	overallKey := brc20.GetTickPkscriptHash(balance.Tick, ord.Pkscript(pkscript), brc20.OverallBalancePkscript)
+	availValue, err := ParseBalance(balance.AvailableBalance)
+	if err != nil {
+		return err
+	}
+	overallValue, err := ParseBalance(balance.OverallBalance)
+	if err != nil {
+		return err
+	}
+	if err := checkProven(proven, availKey, availValue); err != nil {
+		return err
+	}
+	return checkProven(proven, overallKey, overallValue)
+}
+
+// humanAmount formats the amount extended to 18 decimals as the decimal of the tick, e.g. "5.5" of "5500000000000000000".
+func humanAmount(amount *uint256.Int, decimals uint64) string {
+	digits := amount.Dec()
+	if len(digits) <= 18 {
+		digits = strings.Repeat("0", 19-len(digits)) + digits
+	}
+	integer, fraction := digits[:len(digits)-18], digits[len(digits)-18:][:min(decimals, 18)]
+	fraction = strings.TrimRight(fraction, "0")
+	if fraction == "" {
+		return integer
+	}
+	return integer + "." + fraction
+}
+
+func brc20PortfolioError(c *gin.Context, code int, errStr string) {
+	c.JSON(code, Brc20PortfolioResponse{
+		Error:  &errStr,
+		Result: nil,
+		Proof:  nil,
+	})
+}
+
+// GetPortfolio returns every tick held by the pkscript by the holders index, with the available and overall balances
+// along with their human-readable amounts by the decimals of the ticks. The balances are proven by a single
+// multiproof as the ones of the current portfolio, the decimals aren't.
+func GetPortfolio(c *gin.Context, queue *stateless.Queue) {
+	pkScript := c.DefaultQuery("pkscript", "")
+	if pkScript == "" {
+		brc20PortfolioError(c, http.StatusBadRequest, "The pkscript is required")
+		return
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		brc20PortfolioError(c, http.StatusBadRequest, "The offset must not be negative")
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit <= 0 || limit > MaxPortfolioTicks {
+		brc20PortfolioError(c, http.StatusBadRequest, fmt.Sprintf("The limit must be between 1 and %d", MaxPortfolioTicks))
+		return
+	}
+	height, hash, commitment := censusAttestation(queue)
+	ticks, total, indexHeight, fromHeight := stateless.PkscriptTicks(pkScript, offset, limit)
+	if indexHeight != height {
+		brc20PortfolioError(c, http.StatusServiceUnavailable, fmt.Sprintf("The holders are at the height %d instead of %d, please retry later", indexHeight, height))
+		return
+	}
+
+	balances := make([]Brc20PortfolioBalance, 0, len(ticks))
+	for _, tick := range ticks {
+		_, _, availableBalance, overallBalance, err := brc20.GetBalances(queue.Header, tick, ord.Pkscript(pkScript))
+		if err != nil {
+			brc20PortfolioError(c, http.StatusInternalServerError, fmt.Sprintf("Failed to read the balance due to %v", err))
+			return
+		}
+		decimals, err := queue.Header.GetUInt256(brc20.GetTickHash(tick, brc20.Decimals))
+		if err != nil {
+			brc20PortfolioError(c, http.StatusInternalServerError, fmt.Sprintf("Failed to read the decimals due to %v", err))
+			return
+		}
+		balances = append(balances, Brc20PortfolioBalance{
+			Brc20VerifiableTickBalance: Brc20VerifiableTickBalance{
+				Tick:             tick,
+				AvailableBalance: availableBalance.String(),
+				OverallBalance:   overallBalance.String(),
+			},
+			Decimals:  decimals.Uint64(),
+			Available: humanAmount(availableBalance, decimals.Uint64()),
+			Overall:   humanAmount(overallBalance, decimals.Uint64()),
+		})
+	}
+
+	keys, err := portfolioKeys("", pkScript, ticks)
+	if err != nil {
+		brc20PortfolioError(c, http.StatusBadRequest, fmt.Sprintf("Invalid portfolio due to %v", err))
+		return
+	}
+	result := Brc20PortfolioResult{
+		Pkscript:   pkScript,
+		Height:     height,
+		Hash:       hash,
+		Commitment: commitment,
+		FromHeight: fromHeight,
+		Total:      total,
+		Balances:   balances,
+		StateDiff:  make([]string, 0),
+	}
+	var finalproof *string
+	if len(keys) != 0 {
+		proof, err := makeProof(queue.Header, keys)
+		if err != nil {
+			brc20PortfolioError(c, http.StatusInternalServerError, fmt.Sprintf("Failed to generate proof due to %v", err))
+			return
+		}
+		result.StateDiff, finalproof = proof.stateDiff, &proof.proof
+	}
+	c.JSON(http.StatusOK, Brc20PortfolioResponse{
+		Error:  nil,
+		Result: &result,
+		Proof:  finalproof,
+	})
+}
+
+// VerifyPortfolio verifies the balances of the portfolio against the state root as the ones of the current portfolio.
+func VerifyPortfolio(rootC *verkle.Point, resp *Brc20PortfolioResponse) (bool, error) {
+	current := Brc20VerifiableCurrentPortfolioResponse{Error: resp.Error, Proof: resp.Proof}
+	if resp.Result != nil {
+		current.Result = &Brc20VerifiableCurrentPortfolioResult{Pkscript: resp.Result.Pkscript, StateDiff: resp.Result.StateDiff}
+		for _, balance := range resp.Result.Balances {
+			current.Result.Balances = append(current.Result.Balances, balance.Brc20VerifiableTickBalance)
+		}
+	}
+	return VerifyCurrentPortfolio(rootC, &current)
+}
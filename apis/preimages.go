@@ -0,0 +1,46 @@
+package apis
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net/http"
+
+	"github.com/ethereum/go-verkle"
+	"github.com/gin-gonic/gin"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+// The max number of the keys decoded by a request.
+const MaxPreImageKeys = 1000
+
+// GetKeyPreImages decodes the keys of the query, the 32-byte hex keys of a KV dump or of a proof, by the preimages
+// of their stems. The preimage of a key which isn't kept is null.
+func GetKeyPreImages(c *gin.Context) {
+	keys := c.QueryArray("key")
+	if len(keys) == 0 || len(keys) > MaxPreImageKeys {
+		errStr := fmt.Sprintf("Expected 1 to %d keys", MaxPreImageKeys)
+		c.JSON(http.StatusBadRequest, KeyPreImagesResponse{Error: &errStr})
+		return
+	}
+	decoded := make([]stateless.DecodedKey, 0, len(keys))
+	for _, k := range keys {
+		key, err := hex.DecodeString(k)
+		if err == nil && len(key) != verkle.KeySize {
+			err = fmt.Errorf("expected %d bytes", verkle.KeySize)
+		}
+		if err != nil {
+			errStr := fmt.Sprintf("Invalid key %s due to %v", k, err)
+			c.JSON(http.StatusBadRequest, KeyPreImagesResponse{Error: &errStr})
+			return
+		}
+		d, err := stateless.DecodeKey(key)
+		if err != nil {
+			errStr := fmt.Sprintf("Failed to read the preimages due to %v", err)
+			c.JSON(http.StatusInternalServerError, KeyPreImagesResponse{Error: &errStr})
+			return
+		}
+		decoded = append(decoded, d)
+	}
+	c.JSON(http.StatusOK, KeyPreImagesResponse{Error: nil, Result: decoded})
+}
@@ -0,0 +1,221 @@
+package apis
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-verkle"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+type keySetID = [sha256.Size]byte
+
+type cachedProof struct {
+	proof     string
+	stateDiff []string
+}
+
+type popularKeySet struct {
+	keys [][]byte
+	hits uint
+}
+
+// ProofCache caches the proofs by the key set and the state root, and drops all of them once the root changes.
+// It also counts the queries of every key set to precompute the proofs of the most queried ones after each block.
+type ProofCache struct {
+	sync.Mutex
+	capacity int
+	root     [32]byte
+	proofs   map[keySetID]*cachedProof
+	popular  map[keySetID]*popularKeySet
+	hits     uint64
+	misses   uint64
+
+	// Generating proofs may commit the tree lazily, which is not safe to run concurrently.
+	generation sync.Mutex
+}
+
+// The number of the most queried key sets whose proofs are precomputed after each block.
+const PrecomputedProofs = 64
+
+// The proof cache of the APIs. Nil disables the cache.
+var Proofs *ProofCache = nil
+
+func NewProofCache(capacity int) *ProofCache {
+	return &ProofCache{
+		capacity: capacity,
+		proofs:   make(map[keySetID]*cachedProof),
+		popular:  make(map[keySetID]*popularKeySet),
+	}
+}
+
+func sortedKeys(keys [][]byte) [][]byte {
+	sorted := make([][]byte, len(keys))
+	copy(sorted, keys)
+	sort.Slice(sorted, func(i, j int) bool { return bytes.Compare(sorted[i], sorted[j]) < 0 })
+	return sorted
+}
+
+// The proof doesn't depend on the order of the keys.
+func keySetDigest(sorted [][]byte) keySetID {
+	hasher := sha256.New()
+	for _, key := range sorted {
+		hasher.Write(key)
+	}
+	return keySetID(hasher.Sum(nil))
+}
+
+// generateProof builds the multiproof of the keys along with the pre-values of the proven keys.
+func generateProof(root verkle.VerkleNode, keys [][]byte) (*cachedProof, error) {
+	unlock := stateless.LockTree()
+	proof, _, _, _, err := verkle.MakeVerkleMultiProof(root, nil, keys, stateless.NodeResolveFn)
+	unlock()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate proof: %v", err)
+	}
+	vProof, stateDiff, err := verkle.SerializeProof(proof)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize proof: %v", err)
+	}
+	vProofBytes, err := vProof.MarshalJSON()
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal the proof to JSON: %v", err)
+	}
+	stateDiffExport := make([]string, 0, len(stateDiff))
+	for _, sd := range stateDiff {
+		sdBytes, err := sd.MarshalJSON()
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode stateDiff: %v", err)
+		}
+		stateDiffExport = append(stateDiffExport, base64.StdEncoding.EncodeToString(sdBytes))
+	}
+	return &cachedProof{
+		proof:     base64.StdEncoding.EncodeToString(vProofBytes),
+		stateDiff: stateDiffExport,
+	}, nil
+}
+
+// invalidate drops the cached proofs if the root changes. The lock must be held.
+func (pc *ProofCache) invalidate(root [32]byte) {
+	if pc.root != root {
+		pc.root = root
+		pc.proofs = make(map[keySetID]*cachedProof)
+	}
+}
+
+func (pc *ProofCache) lookup(id keySetID, sorted [][]byte, root [32]byte) (*cachedProof, bool) {
+	pc.Lock()
+	defer pc.Unlock()
+	pc.invalidate(root)
+	if p, found := pc.popular[id]; found {
+		p.hits++
+	} else if len(pc.popular) < 16*pc.capacity {
+		pc.popular[id] = &popularKeySet{keys: sorted, hits: 1}
+	}
+	cached, found := pc.proofs[id]
+	if found {
+		pc.hits++
+	} else {
+		pc.misses++
+	}
+	return cached, found
+}
+
+func (pc *ProofCache) store(id keySetID, root [32]byte, cached *cachedProof) {
+	pc.Lock()
+	defer pc.Unlock()
+	if pc.root != root {
+		// The root changed during the generation.
+		return
+	}
+	if len(pc.proofs) >= pc.capacity {
+		for k := range pc.proofs {
+			delete(pc.proofs, k)
+			break
+		}
+	}
+	pc.proofs[id] = cached
+}
+
+func (pc *ProofCache) proof(header *stateless.Header, keys [][]byte) (*cachedProof, error) {
+	pc.generation.Lock()
+	root := header.Root.Commit().Bytes()
+	pc.generation.Unlock()
+
+	sorted := sortedKeys(keys)
+	id := keySetDigest(sorted)
+	if cached, found := pc.lookup(id, sorted, root); found {
+		return cached, nil
+	}
+
+	pc.generation.Lock()
+	cached, err := generateProof(header.Root, sorted)
+	pc.generation.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	pc.store(id, root, cached)
+	return cached, nil
+}
+
+// Precompute generates the missing proofs of the most queried key sets against the current root,
+// and then halves the query counts so that the popularity follows the recent queries.
+func (pc *ProofCache) Precompute(header *stateless.Header, n int) {
+	pc.generation.Lock()
+	root := header.Root.Commit().Bytes()
+	pc.generation.Unlock()
+
+	pc.Lock()
+	pc.invalidate(root)
+	candidates := make([]keySetID, 0, len(pc.popular))
+	for id := range pc.popular {
+		if _, cached := pc.proofs[id]; !cached {
+			candidates = append(candidates, id)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool { return pc.popular[candidates[i]].hits > pc.popular[candidates[j]].hits })
+	if len(candidates) > n {
+		candidates = candidates[:n]
+	}
+	keySets := make([][][]byte, len(candidates))
+	for i, id := range candidates {
+		keySets[i] = pc.popular[id].keys
+	}
+	for id, p := range pc.popular {
+		p.hits /= 2
+		if p.hits == 0 {
+			delete(pc.popular, id)
+		}
+	}
+	pc.Unlock()
+
+	for i, keys := range keySets {
+		pc.generation.Lock()
+		cached, err := generateProof(header.Root, keys)
+		pc.generation.Unlock()
+		if err != nil {
+			continue
+		}
+		pc.store(candidates[i], root, cached)
+	}
+}
+
+// Stats returns the number of the cache hits and misses.
+func (pc *ProofCache) Stats() (uint64, uint64) {
+	pc.Lock()
+	defer pc.Unlock()
+	return pc.hits, pc.misses
+}
+
+// makeProof returns the proof of the keys against the current root of the header, from the cache if enabled.
+func makeProof(header *stateless.Header, keys [][]byte) (*cachedProof, error) {
+	if Proofs == nil {
+		return generateProof(header.Root, keys)
+	}
+	return Proofs.proof(header, keys)
+}
@@ -0,0 +1,72 @@
+package apis
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/RiemaLabs/modular-indexer-committee/internal/metrics"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/brc20"
+	"github.com/RiemaLabs/modular-indexer-committee/peer"
+)
+
+const rulesTimeout = 10 * time.Second
+
+// The safe mode of a member whose rules disagree with the majority of the audited members.
+// The member keeps indexing but withholds its checkpoints, so that it never attests a divergent state.
+var safeMode atomic.Bool
+
+// SafeMode tells whether the checkpoints are withheld for the disagreement of the rules.
+func SafeMode() bool {
+	return safeMode.Load()
+}
+
+// CheckRules compares the local rules version with the ones of the audited members, entering the safe mode if
+// the majority of the members run other rules, and leaving it once the majority runs the local rules again.
+// Without a majority, e.g. if too many members are unreachable, the mode is kept.
+func CheckRules(ctx context.Context) {
+	if Peers == nil || len(Peers.Audit.Members) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, rulesTimeout)
+	defer cancel()
+	client := &http.Client{Timeout: rulesTimeout}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	versions := make(map[string]string)
+	for _, m := range Peers.Audit.Members {
+		wg.Add(1)
+		go func(m peer.Member) {
+			defer wg.Done()
+			version, err := peer.FetchRulesVersion(ctx, client, m)
+			if err != nil {
+				log.Printf("Failed to fetch the rules version of the member %s: %v", m.Name, err)
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			versions[m.Name] = version
+		}(m)
+	}
+	wg.Wait()
+
+	majority, found := peer.MajorityRules(versions, len(Peers.Audit.Members))
+	if !found {
+		return
+	}
+	local := brc20.RulesVersion()
+	if majority != local {
+		if !safeMode.Swap(true) {
+			log.Printf("The rules version %s disagrees with the version %s of the majority of the members, withholding the checkpoints", local, majority)
+		}
+		metrics.RulesDisagreement.Set(1)
+	} else {
+		if safeMode.Swap(false) {
+			log.Printf("The rules version %s agrees with the majority of the members again, publishing the checkpoints", local)
+		}
+		metrics.RulesDisagreement.Set(0)
+	}
+}
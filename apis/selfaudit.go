@@ -0,0 +1,62 @@
+package apis
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/RiemaLabs/modular-indexer-committee/selfaudit"
+)
+
+// SelfAudit is nil unless the checkpoints published by the member are audited.
+var SelfAudit *selfaudit.Auditor
+
+// GetSelfAudit returns the report of the latest audit of the published checkpoints.
+func GetSelfAudit(c *gin.Context) {
+	report := SelfAudit.Latest()
+	if report == nil {
+		errStr := "The published checkpoints aren't audited yet"
+		c.JSON(http.StatusNotFound, SelfAuditResponse{Error: &errStr})
+		return
+	}
+	c.JSON(http.StatusOK, SelfAuditResponse{
+		Error:  nil,
+		Result: report,
+	})
+}
+
+// PostSelfAudit audits the published checkpoints from ?from to ?to right away, the configured number of the latest
+// published heights by default, instead of waiting for the periodic audit.
+func PostSelfAudit(c *gin.Context) {
+	if c.Query("from") == "" && c.Query("to") == "" {
+		report, err := SelfAudit.AuditLatest(c.Request.Context())
+		if err != nil {
+			errStr := err.Error()
+			c.JSON(http.StatusInternalServerError, SelfAuditResponse{Error: &errStr})
+			return
+		}
+		c.JSON(http.StatusOK, SelfAuditResponse{Result: report})
+		return
+	}
+	from, err := strconv.ParseUint(c.Query("from"), 10, 64)
+	if err != nil {
+		errStr := fmt.Sprintf("Invalid from due to %v", err)
+		c.JSON(http.StatusBadRequest, SelfAuditResponse{Error: &errStr})
+		return
+	}
+	to, err := strconv.ParseUint(c.Query("to"), 10, 64)
+	if err != nil || to < from || to-from >= MaxCheckpointHeights {
+		errStr := fmt.Sprintf("Invalid to %s, expected from %d to at most %d heights after it", c.Query("to"), from, MaxCheckpointHeights)
+		c.JSON(http.StatusBadRequest, SelfAuditResponse{Error: &errStr})
+		return
+	}
+	report, err := SelfAudit.Audit(c.Request.Context(), uint(from), uint(to))
+	if err != nil {
+		errStr := err.Error()
+		c.JSON(http.StatusInternalServerError, SelfAuditResponse{Error: &errStr})
+		return
+	}
+	c.JSON(http.StatusOK, SelfAuditResponse{Result: report})
+}
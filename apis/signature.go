@@ -0,0 +1,47 @@
+package apis
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/RiemaLabs/modular-indexer-committee/checkpoint"
+	"github.com/RiemaLabs/modular-indexer-committee/ord"
+)
+
+// The max size of the body of a checkpoint to verify.
+const maxCheckpointBody = 16 << 10
+
+// PostVerifyCheckpoint verifies the signature of the checkpoint in the body, as published by any member of the network.
+// The signature only proves the key signed the checkpoint, so the publicKey query pins the key the caller trusts,
+// e.g. from the committee registry.
+func PostVerifyCheckpoint(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxCheckpointBody)
+	var cp checkpoint.Checkpoint
+	if err := c.ShouldBindJSON(&cp); err != nil {
+		errStr := fmt.Sprintf("Invalid checkpoint due to %v", err)
+		c.JSON(http.StatusBadRequest, VerifyCheckpointResponse{Error: &errStr})
+		return
+	}
+	result := VerifyCheckpointResult{
+		Valid:           true,
+		SignatureScheme: cp.SignatureScheme,
+		PublicKey:       cp.PublicKey,
+	}
+	if !slices.Contains(checkpoint.SupportedFormatVersions, cp.Format()) {
+		result.Valid, result.Reason = false, fmt.Sprintf("unsupported checkpoint format %s", cp.FormatVersion)
+	} else if err := cp.VerifySignature(); err != nil {
+		result.Valid, result.Reason = false, err.Error()
+	} else if err := cp.CheckNetwork(ord.IndexedNetwork.Tag()); err != nil {
+		result.Valid, result.Reason = false, err.Error()
+	} else if trusted := c.Query("publicKey"); trusted != "" && !strings.EqualFold(trusted, cp.PublicKey) {
+		result.Valid, result.Reason = false, "the checkpoint is signed by another key"
+	}
+	c.JSON(http.StatusOK, VerifyCheckpointResponse{
+		Error:  nil,
+		Result: &result,
+	})
+}
@@ -0,0 +1,101 @@
+package apis
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord/protocol"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/sns"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+// The max number of the names returned by a names request.
+const MaxNames = 1000
+
+// SNSModule serves the sats names under /v1/sns, appended to the Modules once the protocol is registered.
+var SNSModule = Module{Namespace: "sns", MetaProtocol: sns.Name, Register: registerSNS}
+
+func registerSNS(g *gin.RouterGroup, queue *stateless.Queue) {
+	g.GET("/name/:name", func(c *gin.Context) {
+		GetSNSName(c, queue)
+	})
+	g.GET("/names", func(c *gin.Context) {
+		GetSNSNames(c, queue)
+	})
+}
+
+// GetSNSName resolves the name to its owner, with the proof of the keys of the name against the state root.
+func GetSNSName(c *gin.Context, queue *stateless.Queue) {
+	record, found, err := sns.Resolve(protocol.Namespace(queue.Header, sns.Name), c.Param("name"))
+	if err != nil {
+		errStr := fmt.Sprintf("Failed to resolve the name due to %v", err)
+		c.JSON(http.StatusInternalServerError, SNSNameResponse{Error: &errStr})
+		return
+	}
+	if !found {
+		errStr := fmt.Sprintf("The name %s isn't claimed", c.Param("name"))
+		c.JSON(http.StatusNotFound, SNSNameResponse{Error: &errStr})
+		return
+	}
+	keys := sns.RecordKeys(record)
+	for i, key := range keys {
+		keys[i] = protocol.NamespaceKey(sns.Name, key)
+	}
+	proof, err := makeProof(queue.Header, keys)
+	if err != nil {
+		errStr := fmt.Sprintf("Failed to generate proof due to %v", err)
+		c.JSON(http.StatusInternalServerError, SNSNameResponse{Error: &errStr})
+		return
+	}
+	commitment := queue.Header.Root.Commit().Bytes()
+	c.JSON(http.StatusOK, SNSNameResponse{
+		Error: nil,
+		Result: &SNSNameResult{
+			Record:    record,
+			Height:    queue.Header.Height,
+			StateRoot: base64.StdEncoding.EncodeToString(commitment[:]),
+		},
+		Proof: &proof.proof,
+	})
+}
+
+// GetSNSNames lists the names owned by the address, i.e. the wallet or the pkscript if the wallet is unknown.
+func GetSNSNames(c *gin.Context, queue *stateless.Queue) {
+	address := c.DefaultQuery("address", "")
+	if address == "" {
+		errStr := "The address is required"
+		c.JSON(http.StatusBadRequest, SNSNamesResponse{Error: &errStr})
+		return
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		errStr := "The offset must not be negative"
+		c.JSON(http.StatusBadRequest, SNSNamesResponse{Error: &errStr})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit <= 0 || limit > MaxNames {
+		errStr := fmt.Sprintf("The limit must be between 1 and %d", MaxNames)
+		c.JSON(http.StatusBadRequest, SNSNamesResponse{Error: &errStr})
+		return
+	}
+	names, total, err := sns.Names(protocol.Namespace(queue.Header, sns.Name), address, offset, limit)
+	if err != nil {
+		errStr := fmt.Sprintf("Failed to list the names due to %v", err)
+		c.JSON(http.StatusInternalServerError, SNSNamesResponse{Error: &errStr})
+		return
+	}
+	c.JSON(http.StatusOK, SNSNamesResponse{
+		Error: nil,
+		Result: &SNSNamesResult{
+			Address: address,
+			Height:  queue.Header.Height,
+			Total:   total,
+			Names:   names,
+		},
+	})
+}
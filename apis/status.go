@@ -0,0 +1,29 @@
+package apis
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord/brc20"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+// GetStatus returns the progress of the block being executed, or of the last executed block,
+// and the forecast of the state size. It doesn't lock the queue, which is held by the update during the execution.
+func GetStatus(c *gin.Context) {
+	p := stateless.CurrentProgress()
+	c.JSON(http.StatusOK, StatusResponse{
+		Error: nil,
+		Result: &StatusResult{
+			Executing:    p.Executing,
+			BlockHeight:  p.Height,
+			Processed:    p.Processed,
+			Total:        p.Total,
+			ElapsedMs:    p.Elapsed.Milliseconds(),
+			Forecast:     stateless.CurrentForecast(),
+			SafeMode:     SafeMode(),
+			RulesVersion: brc20.RulesVersion(),
+		},
+	})
+}
@@ -0,0 +1,109 @@
+package apis
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord/subscription"
+)
+
+// The max number of the ticks and the pkscripts a websocket client may subscribe to.
+const MaxSubscriptionFilters = 1000
+
+const (
+	subscriptionWriteTimeout = 10 * time.Second
+	subscriptionPingInterval = 30 * time.Second
+	// The max size of a request of the client.
+	subscriptionReadLimit = 1 << 16
+)
+
+// The subscriptions serve the same public data as the other APIs, which allow any origin as well.
+var upgrader = websocket.Upgrader{
+	CheckOrigin: func(*http.Request) bool { return true },
+}
+
+// ServeSubscriptions pushes the checkpoints and the balance changes of every block over a websocket, following the
+// requests of the client to subscribe or unsubscribe them. A client falling behind is disconnected.
+func ServeSubscriptions(c *gin.Context, hub *subscription.Hub) {
+	sub, ok := hub.Subscribe()
+	if !ok {
+		errStr := "Too many subscribers, please retry later"
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: &errStr})
+		return
+	}
+	defer hub.Unsubscribe(sub)
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// The upgrader has replied the error.
+		return
+	}
+	defer conn.Close()
+
+	requests := make(chan SubscriptionRequest)
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		defer close(requests)
+		conn.SetReadLimit(subscriptionReadLimit)
+		for {
+			var req SubscriptionRequest
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+			select {
+			case requests <- req:
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	write := func(v any) bool {
+		_ = conn.SetWriteDeadline(time.Now().Add(subscriptionWriteTimeout))
+		return conn.WriteJSON(v) == nil
+	}
+	ping := time.NewTicker(subscriptionPingInterval)
+	defer ping.Stop()
+	var current subscription.Filter
+	for {
+		select {
+		case req, ok := <-requests:
+			if !ok {
+				return
+			}
+			reply := SubscriptionReply{Type: req.Type}
+			switch {
+			case req.Type != SubscriptionSubscribe && req.Type != SubscriptionUnsubscribe:
+				errStr := fmt.Sprintf("Unknown request type %s, expected %s or %s", req.Type, SubscriptionSubscribe, SubscriptionUnsubscribe)
+				reply.Error = &errStr
+			case req.Type == SubscriptionSubscribe && len(current.Ticks)+len(current.Pkscripts)+len(req.Ticks)+len(req.Pkscripts) > MaxSubscriptionFilters:
+				errStr := fmt.Sprintf("At most %d ticks and pkscripts can be subscribed", MaxSubscriptionFilters)
+				reply.Error = &errStr
+			default:
+				current = hub.Update(sub, req.Filter, req.Type == SubscriptionUnsubscribe)
+				reply.Result = &current
+			}
+			if !write(reply) {
+				return
+			}
+		case m, ok := <-sub.Messages():
+			if !ok {
+				_ = conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "too slow to receive the messages"),
+					time.Now().Add(subscriptionWriteTimeout))
+				return
+			}
+			if !write(m) {
+				return
+			}
+		case <-ping.C:
+			if conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(subscriptionWriteTimeout)) != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package apis
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord/brc20"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+// The max number of the ticks returned by a ticks request.
+const MaxTicks = 1000
+
+func GetTicks(c *gin.Context, queue *stateless.Queue) {
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		errStr := "The offset must not be negative"
+		c.JSON(http.StatusBadRequest, Brc20TicksResponse{Error: &errStr})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit <= 0 || limit > MaxTicks {
+		errStr := fmt.Sprintf("The limit must be between 1 and %d", MaxTicks)
+		c.JSON(http.StatusBadRequest, Brc20TicksResponse{Error: &errStr})
+		return
+	}
+	height, hash, commitment := censusAttestation(queue)
+	ticks, total, censusHeight := queue.Header.TickInfos(offset, limit)
+	if censusHeight != height {
+		errStr := fmt.Sprintf("The census is at the height %d instead of %d, please retry later", censusHeight, height)
+		c.JSON(http.StatusServiceUnavailable, Brc20TicksResponse{Error: &errStr})
+		return
+	}
+	c.JSON(http.StatusOK, Brc20TicksResponse{
+		Error: nil,
+		Result: &Brc20TicksResult{
+			Height:     height,
+			Hash:       hash,
+			Commitment: commitment,
+			Total:      total,
+			Ticks:      ticks,
+		},
+	})
+}
+
+func GetTick(c *gin.Context, queue *stateless.Queue) {
+	tick := brc20.NormalizeTick(c.Param("tick"))
+	height, hash, commitment := censusAttestation(queue)
+	info, found, censusHeight := queue.Header.TickInfo(tick)
+	if !found {
+		errStr := fmt.Sprintf("The tick %s isn't deployed", tick)
+		c.JSON(http.StatusNotFound, Brc20TickResponse{Error: &errStr})
+		return
+	}
+	if censusHeight != height {
+		errStr := fmt.Sprintf("The census is at the height %d instead of %d, please retry later", censusHeight, height)
+		c.JSON(http.StatusServiceUnavailable, Brc20TickResponse{Error: &errStr})
+		return
+	}
+	c.JSON(http.StatusOK, Brc20TickResponse{
+		Error: nil,
+		Result: &Brc20TickResult{
+			Height:     height,
+			Hash:       hash,
+			Commitment: commitment,
+			TickInfo:   info,
+		},
+	})
+}
+
+// GetTickCounters returns the counters of the tick kept in the state, with the proof of their keys, so that a light
+// client verifies them against the state root as the balances.
+func GetTickCounters(c *gin.Context, queue *stateless.Queue) {
+	tick := brc20.NormalizeTick(c.DefaultQuery("tick", ""))
+	keys, holders, mints, minted, err := brc20.GetTickCounters(queue.Header, tick)
+	if err != nil {
+		errStr := fmt.Sprintf("Failed to read the counters due to %v", err)
+		c.JSON(http.StatusInternalServerError, Brc20VerifiableTickCountersResponse{Error: &errStr})
+		return
+	}
+	proof, err := makeProof(queue.Header, keys)
+	if err != nil {
+		errStr := fmt.Sprintf("Failed to generate proof due to %v", err)
+		c.JSON(http.StatusInternalServerError, Brc20VerifiableTickCountersResponse{Error: &errStr})
+		return
+	}
+	commitment := queue.Header.Root.Commit().Bytes()
+	c.JSON(http.StatusOK, Brc20VerifiableTickCountersResponse{
+		Error: nil,
+		Result: &Brc20VerifiableTickCountersResult{
+			Tick:         tick,
+			HolderCount:  holders.Dec(),
+			MintCount:    mints.Dec(),
+			MintedAmount: minted.Dec(),
+			Height:       queue.Header.Height,
+			StateRoot:    base64.StdEncoding.EncodeToString(commitment[:]),
+		},
+		Proof: &proof.proof,
+	})
+}
@@ -1,9 +1,28 @@
 package apis
 
 import (
+	"time"
+
+	"github.com/RiemaLabs/modular-indexer-committee/checkpoint"
+	"github.com/RiemaLabs/modular-indexer-committee/crosscheck"
 	"github.com/RiemaLabs/modular-indexer-committee/ord"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/bitmap"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/brc20"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/reexec"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/sns"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/subscription"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/watchlist"
+	"github.com/RiemaLabs/modular-indexer-committee/peer"
+	"github.com/RiemaLabs/modular-indexer-committee/selfaudit"
 )
 
+// ErrorResponse is returned when a request is rejected before reaching its API.
+type ErrorResponse struct {
+	Error  *string   `json:"error"`
+	Result *struct{} `json:"result"`
+}
+
 type OrdTransferJSON struct {
 	ID            uint         `json:"ID"`
 	InscriptionID string       `json:"inscriptionID"`
@@ -32,6 +51,9 @@ type Brc20VerifiableCurrentBalanceOfWalletResult struct {
 	AvailableBalance string `json:"availableBalance"`
 	OverallBalance   string `json:"overallBalance"`
 	Pkscript         string `json:"pkscript"`
+	// The height and the base64 commitment of the state root the proof is made against.
+	Height    uint   `json:"height"`
+	StateRoot string `json:"stateRoot"`
 }
 
 type Brc20VerifiableCurrentBalanceOfWalletResponse struct {
@@ -50,6 +72,9 @@ type Brc20VerifiableCurrentBalanceOfPkscriptRequest struct {
 type Brc20VerifiableCurrentBalanceOfPkscriptResult struct {
 	AvailableBalance string `json:"availableBalance"`
 	OverallBalance   string `json:"overallBalance"`
+	// The height and the base64 commitment of the state root the proof is made against.
+	Height    uint   `json:"height"`
+	StateRoot string `json:"stateRoot"`
 }
 
 type Brc20VerifiableCurrentBalanceOfPkscriptResponse struct {
@@ -58,6 +83,21 @@ type Brc20VerifiableCurrentBalanceOfPkscriptResponse struct {
 	Proof  *string                                        `json:"proof"`
 }
 
+// Brc20Balance
+
+type Brc20BalanceResult struct {
+	Tick             string `json:"tick"`
+	Pkscript         string `json:"pkscript"`
+	Height           uint   `json:"height"`
+	AvailableBalance string `json:"availableBalance"`
+	OverallBalance   string `json:"overallBalance"`
+}
+
+type Brc20BalanceResponse struct {
+	Error  *string             `json:"error"`
+	Result *Brc20BalanceResult `json:"result"`
+}
+
 // Brc20VerifiableLatestStateProof
 
 type Brc20VerifiableLatestStateProofRequest struct {
@@ -68,3 +108,552 @@ type Brc20VerifiableLatestStateProofResponse struct {
 	Result *Brc20VerifiableLatestStateProofResult `json:"result"`
 	Proof  *string                                `json:"proof"`
 }
+
+// Capabilities
+
+type CapabilitiesResult struct {
+	// The Bitcoin network indexed by the member, see ord.Networks.
+	Network            string   `json:"network"`
+	MetaProtocols      []string `json:"metaProtocols"`
+	CheckpointVersions []string `json:"checkpointVersions"`
+	ProofTypes         []string `json:"proofTypes"`
+	// The path prefixes of the APIs of the protocol modules, e.g. /v1/brc20.
+	Namespaces []string `json:"namespaces"`
+	Features   []string `json:"features"`
+}
+
+type CapabilitiesResponse struct {
+	Error  *string             `json:"error"`
+	Result *CapabilitiesResult `json:"result"`
+}
+
+// Checkpoint
+
+type CheckpointModule struct {
+	MetaProtocol string `json:"metaProtocol"`
+	// The path prefix of the APIs of the module.
+	Namespace string `json:"namespace"`
+	// Base64 of the root committing the state of the module.
+	Commitment string `json:"commitment"`
+}
+
+type CheckpointResult struct {
+	Height  uint               `json:"height"`
+	Hash    string             `json:"hash"`
+	Modules []CheckpointModule `json:"modules"`
+	// Base64 of the sparse Merkle root, only set in the dual-commitment mode.
+	SecondaryCommitment string `json:"secondaryCommitment,omitempty"`
+	// The version of the executed rules, which the members compare to detect a disagreement.
+	RulesVersion string `json:"rulesVersion"`
+}
+
+type CheckpointResponse struct {
+	Error  *string           `json:"error"`
+	Result *CheckpointResult `json:"result"`
+}
+
+// VerifyCheckpoint
+
+type VerifyCheckpointResult struct {
+	// Whether the checkpoint is signed by its public key, and by the expected one if given.
+	Valid           bool   `json:"valid"`
+	SignatureScheme string `json:"signatureScheme"`
+	PublicKey       string `json:"publicKey"`
+	// Why the signature is invalid, empty if it's valid.
+	Reason string `json:"reason,omitempty"`
+}
+
+type VerifyCheckpointResponse struct {
+	Error  *string                 `json:"error"`
+	Result *VerifyCheckpointResult `json:"result"`
+}
+
+// Consensus
+
+type ConsensusResponse struct {
+	Error  *string            `json:"error"`
+	Result *crosscheck.Status `json:"result"`
+}
+
+// OPI cross-check
+
+type OPICheckResult struct {
+	Status *crosscheck.OPIStatus `json:"status"`
+	// The first height diverging from OPI since the start, null if none.
+	Divergence *crosscheck.OPIStatus `json:"divergence"`
+}
+
+type OPICheckResponse struct {
+	Error  *string         `json:"error"`
+	Result *OPICheckResult `json:"result"`
+}
+
+// Key preimages
+
+type KeyPreImagesResponse struct {
+	Error  *string                `json:"error"`
+	Result []stateless.DecodedKey `json:"result"`
+}
+
+// Checkpoints
+
+type CheckpointsResult struct {
+	From         uint                     `json:"from"`
+	To           uint                     `json:"to"`
+	Publications []checkpoint.Publication `json:"publications"`
+}
+
+type CheckpointsResponse struct {
+	Error  *string            `json:"error"`
+	Result *CheckpointsResult `json:"result"`
+}
+
+// Self-audit
+
+type SelfAuditResponse struct {
+	Error  *string           `json:"error"`
+	Result *selfaudit.Report `json:"result"`
+}
+
+// Prune
+
+type PruneResponse struct {
+	Error     *string                `json:"error"`
+	Result    *stateless.PruneResult `json:"result"`
+	Operation *Operation             `json:"operation,omitempty"`
+}
+
+// Control
+
+type ControlResponse struct {
+	Error     *string        `json:"error"`
+	Result    *ControlStatus `json:"result"`
+	Operation *Operation     `json:"operation,omitempty"`
+}
+
+type OperationResponse struct {
+	Error  *string    `json:"error"`
+	Result *Operation `json:"result"`
+}
+
+type OperationsResponse struct {
+	Error  *string     `json:"error"`
+	Result []Operation `json:"result"`
+}
+
+// Witness
+
+type WitnessResponse struct {
+	Error  *string         `json:"error"`
+	Result *reexec.Witness `json:"result"`
+}
+
+// Brc20VerifiableCurrentPortfolio
+
+type Brc20VerifiableCurrentPortfolioRequest struct {
+	Wallet   string   `json:"wallet"`
+	Pkscript string   `json:"pkscript"`
+	Ticks    []string `json:"ticks"`
+}
+
+type Brc20VerifiableTickBalance struct {
+	Tick             string `json:"tick"`
+	AvailableBalance string `json:"availableBalance"`
+	OverallBalance   string `json:"overallBalance"`
+}
+
+type Brc20VerifiableCurrentPortfolioResult struct {
+	Wallet   string                       `json:"wallet"`
+	Pkscript string                       `json:"pkscript"`
+	Balances []Brc20VerifiableTickBalance `json:"balances"`
+	// The pre-values of all proven keys, encoded as the ones of the latest state proof.
+	StateDiff []string `json:"stateDiff"`
+}
+
+type Brc20VerifiableCurrentPortfolioResponse struct {
+	Error  *string                                `json:"error"`
+	Result *Brc20VerifiableCurrentPortfolioResult `json:"result"`
+	Proof  *string                                `json:"proof"`
+}
+
+// Brc20VerifiableBatchProof
+
+type Brc20BatchBalanceQuery struct {
+	Tick     string `json:"tick"`
+	Pkscript string `json:"pkscript"`
+}
+
+type Brc20VerifiableBatchProofRequest struct {
+	Balances []Brc20BatchBalanceQuery `json:"balances"`
+	// The hex keys of the state, e.g. of a KV dump or decoded by /v1/state/preimages.
+	Keys []string `json:"keys"`
+}
+
+type Brc20BatchBalance struct {
+	Tick             string `json:"tick"`
+	Pkscript         string `json:"pkscript"`
+	AvailableBalance string `json:"availableBalance"`
+	OverallBalance   string `json:"overallBalance"`
+}
+
+type Brc20BatchValue struct {
+	Key string `json:"key"`
+	// The hex 32-byte value, zero if the key is absent.
+	Value string `json:"value"`
+}
+
+type Brc20VerifiableBatchProofResult struct {
+	Height     uint                `json:"height"`
+	Hash       string              `json:"hash"`
+	Commitment string              `json:"commitment"`
+	Balances   []Brc20BatchBalance `json:"balances"`
+	Values     []Brc20BatchValue   `json:"values"`
+	// The pre-values of all proven keys, encoded as the ones of the latest state proof.
+	StateDiff []string `json:"stateDiff"`
+}
+
+type Brc20VerifiableBatchProofResponse struct {
+	Error  *string                          `json:"error"`
+	Result *Brc20VerifiableBatchProofResult `json:"result"`
+	Proof  *string                          `json:"proof"`
+}
+
+type Brc20PortfolioBalance struct {
+	Brc20VerifiableTickBalance
+	Decimals uint64 `json:"decimals"`
+	// The balances in the decimals of the tick.
+	Available string `json:"available"`
+	Overall   string `json:"overall"`
+}
+
+type Brc20PortfolioResult struct {
+	Pkscript   string `json:"pkscript"`
+	Height     uint   `json:"height"`
+	Hash       string `json:"hash"`
+	Commitment string `json:"commitment"`
+	// The first height observed by the holders index, whose ticks not changed since are missing if it isn't the start.
+	FromHeight uint                    `json:"fromHeight"`
+	Total      int                     `json:"total"`
+	Balances   []Brc20PortfolioBalance `json:"balances"`
+	// The pre-values of all proven keys, encoded as the ones of the latest state proof.
+	StateDiff []string `json:"stateDiff"`
+}
+
+type Brc20PortfolioResponse struct {
+	Error  *string               `json:"error"`
+	Result *Brc20PortfolioResult `json:"result"`
+	// Empty if the pkscript holds no tick.
+	Proof *string `json:"proof"`
+}
+
+// Watchlist
+
+type WatchlistEventsResponse struct {
+	Error  *string           `json:"error"`
+	Result []watchlist.Event `json:"result"`
+}
+
+// StateDigest
+
+type StateDigestResult struct {
+	Height uint   `json:"height"`
+	Hash   string `json:"hash"`
+	// Hex of the order-independent digest of all key-values of the state.
+	Digest string `json:"digest"`
+	// The number of the key-values of the state.
+	Size int `json:"size"`
+}
+
+type StateDigestResponse struct {
+	Error  *string            `json:"error"`
+	Result *StateDigestResult `json:"result"`
+}
+
+// DryRun
+
+type DryRunRequest struct {
+	// The content of the inscription, such as {"p":"brc-20","op":"mint","tick":"ordi","amt":"1000"}.
+	Content string `json:"content"`
+	// The hex of the pkscript receiving the inscription, and its wallet if any.
+	Pkscript string `json:"pkscript"`
+	Wallet   string `json:"wallet"`
+	// The tick whose balances are reported, the tick of the content if empty.
+	Tick string `json:"tick"`
+	// The parent of the inscription, which the mints of the self-mint ticks require.
+	ParentID string `json:"parentID"`
+}
+
+type BalanceChange struct {
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+type DryRunResult struct {
+	// The inscription is executed as if inscribed in the block at the height, on top of the block of the hash.
+	Height           uint          `json:"height"`
+	Hash             string        `json:"hash"`
+	Valid            bool          `json:"valid"`
+	Tick             string        `json:"tick"`
+	Pkscript         string        `json:"pkscript"`
+	AvailableBalance BalanceChange `json:"availableBalance"`
+	OverallBalance   BalanceChange `json:"overallBalance"`
+	// The number of the key-values the inscription would write.
+	Writes int `json:"writes"`
+}
+
+type DryRunResponse struct {
+	Error  *string       `json:"error"`
+	Result *DryRunResult `json:"result"`
+}
+
+// Census
+
+type CensusResult struct {
+	stateless.Census
+	// The latest block and its commitment, which the census is derived from.
+	Hash       string `json:"hash"`
+	Commitment string `json:"commitment"`
+}
+
+type CensusResponse struct {
+	Error  *string       `json:"error"`
+	Result *CensusResult `json:"result"`
+}
+
+type CensusTicksResult struct {
+	Height     uint                   `json:"height"`
+	Hash       string                 `json:"hash"`
+	Commitment string                 `json:"commitment"`
+	Total      int                    `json:"total"`
+	Ticks      []stateless.TickCensus `json:"ticks"`
+}
+
+type CensusTicksResponse struct {
+	Error  *string            `json:"error"`
+	Result *CensusTicksResult `json:"result"`
+}
+
+// Holders
+
+type HoldersResult struct {
+	Tick       string `json:"tick"`
+	Height     uint   `json:"height"`
+	Hash       string `json:"hash"`
+	Commitment string `json:"commitment"`
+	// The first height observed by the index, whose holders not changed since are missing if it isn't the start.
+	FromHeight uint               `json:"fromHeight"`
+	Total      int                `json:"total"`
+	Holders    []stateless.Holder `json:"holders"`
+}
+
+type HoldersResponse struct {
+	Error  *string        `json:"error"`
+	Result *HoldersResult `json:"result"`
+}
+
+// Brc20Ticks
+
+type Brc20TicksResult struct {
+	Height     uint                 `json:"height"`
+	Hash       string               `json:"hash"`
+	Commitment string               `json:"commitment"`
+	Total      int                  `json:"total"`
+	Ticks      []stateless.TickInfo `json:"ticks"`
+}
+
+type Brc20TicksResponse struct {
+	Error  *string           `json:"error"`
+	Result *Brc20TicksResult `json:"result"`
+}
+
+// Brc20Tick
+
+type Brc20TickResult struct {
+	Height     uint   `json:"height"`
+	Hash       string `json:"hash"`
+	Commitment string `json:"commitment"`
+	stateless.TickInfo
+}
+
+type Brc20TickResponse struct {
+	Error  *string          `json:"error"`
+	Result *Brc20TickResult `json:"result"`
+}
+
+// Bitmap
+
+type BitmapDistrictResult struct {
+	bitmap.Record
+	// The height and the base64 commitment of the state root the proof is made against.
+	Height    uint   `json:"height"`
+	StateRoot string `json:"stateRoot"`
+}
+
+type BitmapDistrictResponse struct {
+	Error  *string               `json:"error"`
+	Result *BitmapDistrictResult `json:"result"`
+	Proof  *string               `json:"proof"`
+}
+
+// SNS
+
+type SNSNameResult struct {
+	sns.Record
+	// The height and the base64 commitment of the state root the proof is made against.
+	Height    uint   `json:"height"`
+	StateRoot string `json:"stateRoot"`
+}
+
+type SNSNameResponse struct {
+	Error  *string        `json:"error"`
+	Result *SNSNameResult `json:"result"`
+	Proof  *string        `json:"proof"`
+}
+
+type SNSNamesResult struct {
+	Address string   `json:"address"`
+	Height  uint     `json:"height"`
+	Total   int      `json:"total"`
+	Names   []string `json:"names"`
+}
+
+type SNSNamesResponse struct {
+	Error  *string         `json:"error"`
+	Result *SNSNamesResult `json:"result"`
+}
+
+// Brc20VerifiableTickCounters
+
+type Brc20VerifiableTickCountersResult struct {
+	Tick         string `json:"tick"`
+	HolderCount  string `json:"holderCount"`
+	MintCount    string `json:"mintCount"`
+	MintedAmount string `json:"mintedAmount"`
+	// The height and the base64 commitment of the state root the proof is made against.
+	Height    uint   `json:"height"`
+	StateRoot string `json:"stateRoot"`
+}
+
+type Brc20VerifiableTickCountersResponse struct {
+	Error  *string                            `json:"error"`
+	Result *Brc20VerifiableTickCountersResult `json:"result"`
+	Proof  *string                            `json:"proof"`
+}
+
+// InscriptionHistory
+
+type InscriptionHistoryEntry struct {
+	Height uint            `json:"height"`
+	Type   brc20.EventType `json:"type"`
+	// The owner of the inscribed amount, i.e. the inscriber of the transfer-inscribe and the source of the transfer-transfer.
+	Pkscript string `json:"pkscript"`
+	Wallet   string `json:"wallet"`
+	// The receiver of the transfer-transfer, empty if the inscription is sent as fee.
+	SpentPkscript string `json:"spentPkscript,omitempty"`
+	SpentWallet   string `json:"spentWallet,omitempty"`
+	SentAsFee     bool   `json:"sentAsFee,omitempty"`
+}
+
+type InscriptionHistoryResult struct {
+	InscriptionID string `json:"inscriptionID"`
+	Height        uint   `json:"height"`
+	Hash          string `json:"hash"`
+	Commitment    string `json:"commitment"`
+	// The first height kept by the events database, before which the history is missing.
+	FromHeight uint                      `json:"fromHeight"`
+	Tick       string                    `json:"tick"`
+	Amount     string                    `json:"amount"`
+	Status     string                    `json:"status"`
+	History    []InscriptionHistoryEntry `json:"history"`
+}
+
+type InscriptionHistoryResponse struct {
+	Error  *string                   `json:"error"`
+	Result *InscriptionHistoryResult `json:"result"`
+}
+
+// Subscriptions
+
+const (
+	SubscriptionSubscribe   = "subscribe"
+	SubscriptionUnsubscribe = "unsubscribe"
+)
+
+// SubscriptionRequest is sent by a websocket client to subscribe or unsubscribe the checkpoints, the ticks or the pkscripts.
+type SubscriptionRequest struct {
+	Type string `json:"type"`
+	subscription.Filter
+}
+
+// SubscriptionReply acknowledges a request with the resulting subscriptions of the client.
+type SubscriptionReply struct {
+	Type   string               `json:"type"`
+	Error  *string              `json:"error"`
+	Result *subscription.Filter `json:"result"`
+}
+
+// StateSchema
+
+type StateSchemaResponse struct {
+	Error  *string       `json:"error"`
+	Result *brc20.Schema `json:"result"`
+}
+
+// Status
+
+type StatusResult struct {
+	// Whether a block is being executed, otherwise the fields describe the last executed block.
+	Executing   bool  `json:"executing"`
+	BlockHeight uint  `json:"blockHeight"`
+	Processed   int   `json:"processed"`
+	Total       int   `json:"total"`
+	ElapsedMs   int64 `json:"elapsedMs"`
+	// The projection of the state size from the growth of the latest blocks.
+	Forecast stateless.Forecast `json:"forecast"`
+	// Whether the checkpoints are withheld, since the rules disagree with the majority of the audited members.
+	SafeMode     bool   `json:"safeMode"`
+	RulesVersion string `json:"rulesVersion"`
+}
+
+type StatusResponse struct {
+	Error  *string       `json:"error"`
+	Result *StatusResult `json:"result"`
+}
+
+// Health
+
+type HealthResult struct {
+	// The height of the latest committed block, and of the chain tip reported by the getter.
+	IndexedHeight uint `json:"indexedHeight"`
+	TipHeight     uint `json:"tipHeight"`
+	Lag           uint `json:"lag"`
+	MaxLag        uint `json:"maxLag"`
+	// The time of the latest checkpoint published, null if none since the start.
+	LastCheckpointAt *time.Time `json:"lastCheckpointAt"`
+	// The getter and the checkpoint publication methods by their names, e.g. DA.
+	Upstreams map[string]UpstreamStatus `json:"upstreams"`
+}
+
+type HealthResponse struct {
+	// The reasons of the failing probe, if any.
+	Error  *string       `json:"error"`
+	Result *HealthResult `json:"result"`
+}
+
+// Peer
+
+type PeerKeyResponse struct {
+	Error *string `json:"error"`
+	// The hex of the ed25519 public key verifying the diffs and the snapshots.
+	Result *string `json:"result"`
+}
+
+type PeerAuditResponse struct {
+	Error  *string     `json:"error"`
+	Result *peer.Audit `json:"result"`
+}
+
+// PeerErrorResponse is returned if a diff stream or a snapshot can't be served.
+type PeerErrorResponse struct {
+	Error *string `json:"error"`
+}
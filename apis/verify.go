@@ -1,137 +1,48 @@
 package apis
 
 import (
-	"bytes"
 	"encoding/base64"
 	"fmt"
-	"unsafe"
 
+	"github.com/RiemaLabs/modular-indexer-committee/lightclient"
 	"github.com/RiemaLabs/modular-indexer-committee/ord"
-	"github.com/RiemaLabs/modular-indexer-committee/ord/getter"
-	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/protocol"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/reexec"
 	"github.com/ethereum/go-verkle"
-	"github.com/holiman/uint256"
 )
 
+// The parsers and the verifiers of the proofs live in the lightclient package, imported by the wallets without
+// the committee indexer, and are kept here for the existing callers.
+
 func ParseBalance(balance string) ([]byte, error) {
-	value, err := uint256.FromDecimal(balance)
-	if err != nil {
-		return []byte{}, err
-	}
-	var dest [stateless.ValueSize]byte
-	value.WriteToArray32(&dest)
-	return dest[:], err
+	return lightclient.ParseBalance(balance)
 }
 
 func ParseProof(proof string) (*verkle.VerkleProof, error) {
-	vProofBytes, err := base64.StdEncoding.DecodeString(proof)
-	if err != nil {
-		return nil, err
-	}
-	var vProof verkle.VerkleProof
-	err = vProof.UnmarshalJSON(vProofBytes)
-	if err != nil {
-		return nil, err
-	}
-	return &vProof, nil
+	return lightclient.ParseProof(proof)
 }
 
 func ParseCommitment(commitment string) (*verkle.Point, error) {
-	bytes, err := base64.StdEncoding.DecodeString(commitment)
-	if err != nil {
-		return nil, err
-	}
-	var p verkle.Point
-	err = p.SetBytes(bytes)
-	if err != nil {
-		return nil, err
-	}
-	return &p, nil
+	return lightclient.ParseCommitment(commitment)
 }
 
 func ParseStateDiff(Keys, PreValues, PostValues [][]byte) *verkle.StateDiff {
-	var stemdiff *verkle.StemStateDiff
-	var statediff verkle.StateDiff
-	for i, key := range Keys {
-		stem := verkle.KeyToStem(key)
-		if stemdiff == nil || !bytes.Equal(stemdiff.Stem[:], stem) {
-			statediff = append(statediff, verkle.StemStateDiff{})
-			stemdiff = &statediff[len(statediff)-1]
-			copy(stemdiff.Stem[:], stem)
-		}
-		stemdiff.SuffixDiffs = append(stemdiff.SuffixDiffs, verkle.SuffixStateDiff{Suffix: key[verkle.StemSize]})
-		newsd := &stemdiff.SuffixDiffs[len(stemdiff.SuffixDiffs)-1]
-
-		var valueLen = len(PreValues[i])
-		switch valueLen {
-		case 0:
-			// null value
-		case 32:
-			newsd.CurrentValue = (*[32]byte)(PreValues[i])
-		default:
-			var aligned [32]byte
-			copy(aligned[:valueLen], PreValues[i])
-			newsd.CurrentValue = (*[32]byte)(unsafe.Pointer(&aligned[0]))
-		}
-
-		valueLen = len(PostValues[i])
-		switch valueLen {
-		case 0:
-			// null value
-		case 32:
-			newsd.NewValue = (*[32]byte)(PostValues[i])
-		default:
-			// TODO remove usage of unsafe
-			var aligned [32]byte
-			copy(aligned[:valueLen], PostValues[i])
-			newsd.NewValue = (*[32]byte)(unsafe.Pointer(&aligned[0]))
-		}
-	}
-	return &statediff
+	return lightclient.ParseStateDiff(Keys, PreValues, PostValues)
 }
 
 func VerifyCurrentBalanceOfPkscript(rootC *verkle.Point, tick, pkscript string, resp *Brc20VerifiableCurrentBalanceOfPkscriptResponse) (bool, error) {
 	if resp.Error != nil {
 		return false, fmt.Errorf("failed to obtain the proof from committee indexer, error: %s", *resp.Error)
 	}
-	availKey := stateless.GetTickPkscriptHash(tick, ord.Pkscript(pkscript), stateless.AvailableBalancePkscript)
-	overallKey := stateless.GetTickPkscriptHash(tick, ord.Pkscript(pkscript), stateless.OverallBalancePkscript)
-
-	keys := [][]byte{availKey, overallKey}
-
-	availValue, err := ParseBalance(resp.Result.AvailableBalance)
-	if err != nil {
-		return false, err
+	balance := lightclient.Balance{
+		Tick:             tick,
+		Pkscript:         pkscript,
+		AvailableBalance: resp.Result.AvailableBalance,
+		OverallBalance:   resp.Result.OverallBalance,
 	}
-	overallValue, err := ParseBalance(resp.Result.OverallBalance)
-	if err != nil {
+	if err := lightclient.VerifyBalance(rootC, balance, *resp.Proof); err != nil {
 		return false, err
 	}
-
-	values := [][]byte{availValue, overallValue}
-
-	vProof, err := ParseProof(*resp.Proof)
-	if err != nil {
-		return false, err
-	}
-
-	stateDiff := ParseStateDiff(keys, values, [][]byte{{}, {}})
-
-	preProof, err := verkle.DeserializeProof(vProof, *stateDiff)
-	if err != nil {
-		return false, err
-	}
-
-	preRoot, err := verkle.PreStateTreeFromProof(preProof, rootC)
-	if err != nil {
-		return false, err
-	}
-
-	err = verkle.VerifyVerkleProofWithPreState(preProof, preRoot)
-	if err != nil {
-		return false, err
-	}
-
 	return true, nil
 }
 
@@ -142,6 +53,8 @@ func VerifyCurrentBalanceOfWallet(rootC *verkle.Point, tick, wallet string, resp
 		Result: &Brc20VerifiableCurrentBalanceOfPkscriptResult{
 			AvailableBalance: resp.Result.AvailableBalance,
 			OverallBalance:   resp.Result.OverallBalance,
+			Height:           resp.Result.Height,
+			StateRoot:        resp.Result.StateRoot,
 		},
 		Proof: resp.Proof,
 	}
@@ -190,7 +103,7 @@ func GeneratePostRoot(rootC *verkle.Point, blockHeight uint, resp *Brc20Verifiab
 
 	preRoot.Commit()
 
-	preHeader := &stateless.LightHeader{
+	preHeader := &reexec.LightHeader{
 		Root:   preRoot,
 		Height: blockHeight - 1,
 		Hash:   "",
@@ -200,10 +113,10 @@ func GeneratePostRoot(rootC *verkle.Point, blockHeight uint, resp *Brc20Verifiab
 		return preHeader.Root, nil
 	}
 
-	var ordTransfers []getter.OrdTransfer
+	var ordTransfers []ord.OrdTransfer
 	for _, tran := range resp.Result.OrdTransfers {
 		contentBytes, _ := base64.StdEncoding.DecodeString(tran.Content)
-		ordTransfers = append(ordTransfers, getter.OrdTransfer{
+		ordTransfers = append(ordTransfers, ord.OrdTransfer{
 			ID:            tran.ID,
 			InscriptionID: tran.InscriptionID,
 			OldSatpoint:   tran.OldSatpoint,
@@ -216,6 +129,6 @@ func GeneratePostRoot(rootC *verkle.Point, blockHeight uint, resp *Brc20Verifiab
 		})
 	}
 
-	stateless.Exec(preHeader, ordTransfers, blockHeight)
+	protocol.Exec(preHeader, ordTransfers, blockHeight)
 	return preHeader.Root, nil
 }
@@ -0,0 +1,90 @@
+package apis
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord/watchlist"
+)
+
+// The max number of events returned by a request.
+const MaxWatchlistEvents = 1000
+
+// sinceSeq returns the sequence number after which the events are returned, by since_seq or its former name since.
+func sinceSeq(c *gin.Context) (uint64, error) {
+	since := c.Query("since_seq")
+	if since == "" {
+		since = c.DefaultQuery("since", "0")
+	}
+	return strconv.ParseUint(since, 10, 64)
+}
+
+func GetWatchlistEvents(c *gin.Context, w *watchlist.Watchlist) {
+	address := c.DefaultQuery("address", "")
+	since, err := sinceSeq(c)
+	if err != nil {
+		errStr := fmt.Sprintf("Invalid since_seq due to %v", err)
+		c.JSON(http.StatusBadRequest, WatchlistEventsResponse{Error: &errStr})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(MaxWatchlistEvents)))
+	if err != nil || limit <= 0 || limit > MaxWatchlistEvents {
+		errStr := fmt.Sprintf("The limit must be between 1 and %d", MaxWatchlistEvents)
+		c.JSON(http.StatusBadRequest, WatchlistEventsResponse{Error: &errStr})
+		return
+	}
+	c.JSON(http.StatusOK, WatchlistEventsResponse{
+		Error:  nil,
+		Result: w.Events(address, since, limit),
+	})
+}
+
+// StreamWatchlistEvents notifies the new events of the address instantly by server-sent events.
+// With since_seq, the stream resumes by sending the kept events after the sequence number first.
+func StreamWatchlistEvents(c *gin.Context, w *watchlist.Watchlist) {
+	address := c.DefaultQuery("address", "")
+	var last uint64
+	resume := c.Query("since_seq") != ""
+	if resume {
+		since, err := sinceSeq(c)
+		if err != nil {
+			errStr := fmt.Sprintf("Invalid since_seq due to %v", err)
+			c.JSON(http.StatusBadRequest, WatchlistEventsResponse{Error: &errStr})
+			return
+		}
+		last = since
+	}
+	// Subscribe before reading the kept events, so that no event is missed in between.
+	events, cancel := w.Subscribe()
+	defer cancel()
+	if resume {
+		for {
+			backlog := w.Events(address, last, MaxWatchlistEvents)
+			for _, e := range backlog {
+				c.SSEvent("event", e)
+				last = e.Seq
+			}
+			if len(backlog) < MaxWatchlistEvents {
+				break
+			}
+		}
+	}
+	c.Stream(func(_ io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case e, ok := <-events:
+			if !ok {
+				return false
+			}
+			if e.Seq > last && (address == "" || e.Address == address) {
+				c.SSEvent("event", e)
+			}
+			return true
+		}
+	})
+}
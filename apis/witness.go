@@ -0,0 +1,32 @@
+package apis
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+// GetWitness returns the execution witness of the block at the height, with which anyone can re-execute the block
+// statelessly and confirm its post-state root, see reexec.Verify.
+func GetWitness(c *gin.Context) {
+	height, err := strconv.ParseUint(c.Param("height"), 10, 64)
+	if err != nil {
+		errStr := fmt.Sprintf("Invalid height due to %v", err)
+		c.JSON(http.StatusBadRequest, WitnessResponse{Error: &errStr})
+		return
+	}
+	w, err := stateless.RecentWitness(uint(height))
+	if err != nil {
+		errStr := fmt.Sprintf("The witness at height %d isn't available: %v", height, err)
+		c.JSON(http.StatusNotFound, WitnessResponse{Error: &errStr})
+		return
+	}
+	c.JSON(http.StatusOK, WitnessResponse{
+		Error:  nil,
+		Result: w,
+	})
+}
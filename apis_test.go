@@ -190,3 +190,44 @@ func loadVerifyCurrentBalanceOfWallet(tick string, wallet string, catchupHeight
 		log.Fatal("With error: ", err)
 	}
 }
+
+func TestAPI_VerifyCurrentPortfolio(t *testing.T) {
+	wallet := "bc1prvqdfjku8359hk9uc2tdgg0xlwvsel2fjr9ysydmaas9x3kyzuvskuwmlq"
+	ordGetterTest, arguments := loadMain(782000)
+	queue, _ := CatchupStage(ordGetterTest, &arguments, stateless.BRC20StartHeight-1, uint(779980))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.Default()
+	r.GET("/v1/brc20_verifiable/current_portfolio", func(c *gin.Context) {
+		apis.GetCurrentPortfolio(c, queue)
+	})
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	// The wallet holds meme but never held ordi, whose keys are proven absent.
+	resp, err := http.Get(ts.URL + "/v1/brc20_verifiable/current_portfolio?ticks=meme,ordi&wallet=" + wallet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+	var res apis.Brc20VerifiableCurrentPortfolioResponse
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Result.Balances) != 2 || res.Result.Balances[0].OverallBalance == "0" || res.Result.Balances[1].OverallBalance != "0" {
+		t.Fatalf("Unexpected portfolio %+v", res.Result.Balances)
+	}
+
+	rootC := queue.Header.Root.Commit()
+	if _, err := apis.VerifyCurrentPortfolio(rootC, &res); err != nil {
+		t.Fatal(err)
+	}
+
+	res.Result.Balances[0].AvailableBalance = "1"
+	if _, err := apis.VerifyCurrentPortfolio(rootC, &res); err == nil {
+		t.Fatal("Expected the tampered portfolio to be rejected")
+	}
+}
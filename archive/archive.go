@@ -0,0 +1,199 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/RiemaLabs/modular-indexer-committee/internal/metrics"
+)
+
+// The classes of the archive data, which are the directories of the object keys.
+const (
+	ClassSnapshots  = "snapshots"
+	ClassWitnesses  = "witnesses"
+	ClassQuarantine = "quarantine"
+	ClassWatchlist  = "watchlist"
+)
+
+// The default number of the latest blocks whose files are kept on the local disk.
+const DefaultKeepBlocks = 1000
+
+type Config struct {
+	Enabled bool `json:"enabled"`
+	// The object store of the tier: s3, or gcs through its S3-compatible XML API with HMAC keys.
+	Provider string `json:"provider"`
+	Bucket   string `json:"bucket"`
+	Region   string `json:"region"`
+	// The endpoint of another S3-compatible object store, optional.
+	Endpoint  string `json:"endpoint"`
+	AccessKey string `json:"accessKey"`
+	SecretKey string `json:"secretKey"`
+	// The prefix of the object keys, e.g. the name of the indexer sharing the bucket.
+	Prefix string `json:"prefix"`
+	// The number of the latest blocks whose files are kept on the local disk, the older ones are moved to the tier.
+	KeepBlocks uint `json:"keepBlocks"`
+	// The interval in seconds to move the old files to the tier (default 60).
+	Interval int `json:"interval"`
+}
+
+// ErrNotFound is returned if the object isn't in the tier.
+var ErrNotFound = errors.New("the object is not archived")
+
+// Tier is the object store holding the archive data.
+type Tier interface {
+	Put(ctx context.Context, key string, data []byte) error
+	// Get returns ErrNotFound if the object doesn't exist.
+	Get(ctx context.Context, key string) ([]byte, error)
+	// List returns the keys of the objects with the prefix, in the lexicographic order.
+	List(ctx context.Context, prefix string) ([]string, error)
+}
+
+type class struct {
+	name     string
+	dir      string
+	suffixes []string
+}
+
+// Archiver moves the files of the old blocks, named <height><suffix>, from the local disk to the tier,
+// and retrieves them on demand.
+type Archiver struct {
+	tier       Tier
+	prefix     string
+	keepBlocks uint
+
+	mu      sync.Mutex
+	classes []class
+}
+
+func New(cfg Config) (*Archiver, error) {
+	tier, err := newS3Tier(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return NewArchiver(tier, cfg.Prefix, cfg.KeepBlocks), nil
+}
+
+func NewArchiver(tier Tier, prefix string, keepBlocks uint) *Archiver {
+	if keepBlocks == 0 {
+		keepBlocks = DefaultKeepBlocks
+	}
+	return &Archiver{tier: tier, prefix: prefix, keepBlocks: keepBlocks}
+}
+
+func (a *Archiver) key(class, name string) string {
+	return path.Join(a.prefix, class, name)
+}
+
+// Register sweeps the files of the directory with the suffixes as the class.
+func (a *Archiver) Register(name, dir string, suffixes ...string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.classes = append(a.classes, class{name: name, dir: dir, suffixes: suffixes})
+}
+
+// fileHeight returns the height of the file named <height><suffix>.
+func fileHeight(name string, suffixes []string) (uint, bool) {
+	for _, suffix := range suffixes {
+		if !strings.HasSuffix(name, suffix) {
+			continue
+		}
+		height, err := strconv.ParseUint(strings.TrimSuffix(name, suffix), 10, 64)
+		if err == nil {
+			return uint(height), true
+		}
+	}
+	return 0, false
+}
+
+// Move uploads the local file to the class of the tier, then removes it.
+func (a *Archiver) Move(ctx context.Context, class, file string) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	if err := a.tier.Put(ctx, a.key(class, filepath.Base(file)), data); err != nil {
+		return fmt.Errorf("failed to archive %s: %v", file, err)
+	}
+	metrics.ArchivedFiles.WithLabelValues(class).Inc()
+	return os.Remove(file)
+}
+
+// Sweep moves the files of the registered classes older than the kept blocks to the tier.
+func (a *Archiver) Sweep(ctx context.Context, latestHeight uint) error {
+	a.mu.Lock()
+	classes := append([]class{}, a.classes...)
+	a.mu.Unlock()
+
+	var errs []error
+	for _, c := range classes {
+		files, err := os.ReadDir(c.dir)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, file := range files {
+			height, ok := fileHeight(file.Name(), c.suffixes)
+			if file.IsDir() || !ok || height+a.keepBlocks >= latestHeight {
+				continue
+			}
+			if err := a.Move(ctx, c.name, filepath.Join(c.dir, file.Name())); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Run sweeps at the interval until the context is done.
+func (a *Archiver) Run(ctx context.Context, interval time.Duration, latestHeight func() uint) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := a.Sweep(ctx, latestHeight()); err != nil {
+				log.Printf("Failed to move the old files to the archive: %v", err)
+			}
+		}
+	}
+}
+
+// Fetch retrieves the archived file of the class, or ErrNotFound.
+func (a *Archiver) Fetch(ctx context.Context, class, name string) ([]byte, error) {
+	data, err := a.tier.Get(ctx, a.key(class, name))
+	if err == nil {
+		metrics.ArchiveRetrievals.WithLabelValues(class).Inc()
+	}
+	return data, err
+}
+
+// Heights returns the heights of the archived files of the class with the suffix, in the ascending order.
+func (a *Archiver) Heights(ctx context.Context, class, suffix string) ([]uint, error) {
+	keys, err := a.tier.List(ctx, a.key(class, "")+"/")
+	if err != nil {
+		return nil, err
+	}
+	var heights []uint
+	for _, key := range keys {
+		if height, ok := fileHeight(path.Base(key), []string{suffix}); ok {
+			heights = append(heights, height)
+		}
+	}
+	sort.Slice(heights, func(i, j int) bool { return heights[i] < heights[j] })
+	return heights, nil
+}
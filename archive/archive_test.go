@@ -0,0 +1,125 @@
+package archive
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/watchlist"
+)
+
+type memoryTier struct {
+	sync.Mutex
+	objects map[string][]byte
+}
+
+func (t *memoryTier) Put(_ context.Context, key string, data []byte) error {
+	t.Lock()
+	defer t.Unlock()
+	t.objects[key] = data
+	return nil
+}
+
+func (t *memoryTier) Get(_ context.Context, key string) ([]byte, error) {
+	t.Lock()
+	defer t.Unlock()
+	data, found := t.objects[key]
+	if !found {
+		return nil, ErrNotFound
+	}
+	return data, nil
+}
+
+func (t *memoryTier) List(_ context.Context, prefix string) ([]string, error) {
+	t.Lock()
+	defer t.Unlock()
+	var keys []string
+	for key := range t.objects {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func TestArchiver_Sweep(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	for _, name := range []string{"90.json", "100.json", "101.json", "pending.json"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tier := &memoryTier{objects: make(map[string][]byte)}
+	a := NewArchiver(tier, "committee", 10)
+	a.Register(ClassWitnesses, dir, ".json")
+	a.Register(ClassQuarantine, filepath.Join(dir, "missing"), ".json")
+
+	if err := a.Sweep(ctx, 111); err != nil {
+		t.Fatal(err)
+	}
+	for name, local := range map[string]bool{"90.json": false, "100.json": false, "101.json": true, "pending.json": true} {
+		if _, err := os.Stat(filepath.Join(dir, name)); (err == nil) != local {
+			t.Fatalf("Expected %s to be kept locally: %v", name, local)
+		}
+	}
+	if data, err := a.Fetch(ctx, ClassWitnesses, "100.json"); err != nil || string(data) != "100.json" {
+		t.Fatalf("Unexpected archived file %s: %v", data, err)
+	}
+	if _, err := a.Fetch(ctx, ClassWitnesses, "101.json"); err != ErrNotFound {
+		t.Fatalf("Expected the kept file not to be archived, got %v", err)
+	}
+	if heights, err := a.Heights(ctx, ClassWitnesses, ".json"); err != nil || fmt.Sprint(heights) != "[90 100]" {
+		t.Fatalf("Unexpected archived heights %v: %v", heights, err)
+	}
+}
+
+func TestEventArchive(t *testing.T) {
+	tier := &memoryTier{objects: make(map[string][]byte)}
+	w := watchlist.New(watchlist.Config{Wallets: []string{"alice", "bob"}, MaxEvents: 10})
+	w.SetArchive(NewEventArchive(NewArchiver(tier, "", 0)))
+	balance := func(string, ord.Pkscript) (string, string) { return "0", "0" }
+
+	total := 2*watchlist.ArchiveSegment + 30
+	for i := 0; i < total; i++ {
+		wallet := ord.Wallet("alice")
+		if i%2 == 1 {
+			wallet = "bob"
+		}
+		w.Observe([]ord.OrdTransfer{{InscriptionID: fmt.Sprintf("i%d", i), NewWallet: wallet, NewSatpoint: "tx:0:0"}}, uint(i+1), "", balance)
+	}
+	if len(tier.objects) != 2 {
+		t.Fatalf("Expected 2 archived segments, got %d", len(tier.objects))
+	}
+
+	// The events are read through the archive and the memory without a gap.
+	var seqs []uint64
+	for since := uint64(0); ; {
+		events := w.Events("", since, 333)
+		if len(events) == 0 {
+			break
+		}
+		for _, e := range events {
+			seqs = append(seqs, e.Seq)
+		}
+		since = events[len(events)-1].Seq
+	}
+	if len(seqs) != total || seqs[0] != 1 || seqs[total-1] != uint64(total) {
+		t.Fatalf("Unexpected events from %v to %v, %d of %d", seqs[0], seqs[len(seqs)-1], len(seqs), total)
+	}
+	for i := range seqs {
+		if seqs[i] != uint64(i+1) {
+			t.Fatalf("Missing event %d", i+1)
+		}
+	}
+	if events := w.Events("bob", 0, 5000); len(events) != total/2 || events[0].Seq != 2 {
+		t.Fatalf("Unexpected events of bob: %d", len(events))
+	}
+}
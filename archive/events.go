@@ -0,0 +1,90 @@
+package archive
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/RiemaLabs/modular-indexer-committee/internal/metrics"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/watchlist"
+)
+
+const eventsTimeout = 30 * time.Second
+
+type segment struct {
+	first, last uint64
+}
+
+// EventArchive keeps the events trimmed by the watchlist in the tier, one object per segment of events.
+// The sequence numbers restart along with the indexer, so the segments of each run are kept apart.
+type EventArchive struct {
+	archiver *Archiver
+	run      string
+
+	mu       sync.Mutex
+	segments []segment
+}
+
+func NewEventArchive(a *Archiver) *EventArchive {
+	return &EventArchive{archiver: a, run: time.Now().UTC().Format("20060102T150405Z")}
+}
+
+func (e *EventArchive) segmentName(s segment) string {
+	return fmt.Sprintf("%s/%020d-%020d.json", e.run, s.first, s.last)
+}
+
+func (e *EventArchive) StoreEvents(events []watchlist.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(events)
+	if err != nil {
+		return err
+	}
+	s := segment{first: events[0].Seq, last: events[len(events)-1].Seq}
+	ctx, cancel := context.WithTimeout(context.Background(), eventsTimeout)
+	defer cancel()
+	if err := e.archiver.tier.Put(ctx, e.archiver.key(ClassWatchlist, e.segmentName(s)), data); err != nil {
+		return err
+	}
+	metrics.ArchivedFiles.WithLabelValues(ClassWatchlist).Inc()
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.segments = append(e.segments, s)
+	return nil
+}
+
+func (e *EventArchive) LoadEvents(since, before uint64, match func(watchlist.Event) bool, limit int) ([]watchlist.Event, error) {
+	e.mu.Lock()
+	segments := append([]segment{}, e.segments...)
+	e.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), eventsTimeout)
+	defer cancel()
+	res := make([]watchlist.Event, 0)
+	for _, s := range segments {
+		if s.last <= since || s.first >= before {
+			continue
+		}
+		data, err := e.archiver.Fetch(ctx, ClassWatchlist, e.segmentName(s))
+		if err != nil {
+			return nil, err
+		}
+		var events []watchlist.Event
+		if err := json.Unmarshal(data, &events); err != nil {
+			return nil, fmt.Errorf("invalid archived events %s: %v", e.segmentName(s), err)
+		}
+		for _, event := range events {
+			if event.Seq <= since || event.Seq >= before || !match(event) {
+				continue
+			}
+			res = append(res, event)
+			if len(res) == limit {
+				return res, nil
+			}
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,100 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+const gcsEndpoint = "https://storage.googleapis.com"
+
+type s3Tier struct {
+	client *s3.Client
+	bucket string
+}
+
+func newS3Tier(cfg Config) (*s3Tier, error) {
+	if cfg.Bucket == "" {
+		return nil, errors.New("the bucket of the archive is required")
+	}
+	region, endpoint := cfg.Region, cfg.Endpoint
+	switch cfg.Provider {
+	case "", "s3":
+	case "gcs":
+		if endpoint == "" {
+			endpoint = gcsEndpoint
+		}
+		if region == "" {
+			region = "auto"
+		}
+	default:
+		return nil, fmt.Errorf("unknown archive provider %s", cfg.Provider)
+	}
+	options := []func(*config.LoadOptions) error{config.WithRegion(region)}
+	// Without the keys, the credentials are loaded from the default chain.
+	if cfg.AccessKey != "" {
+		options = append(options, config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(cfg.AccessKey, cfg.SecretKey, "")))
+	}
+	awsCfg, err := config.LoadDefaultConfig(context.Background(), options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create aws config, error: %v", err)
+	}
+	client := s3.NewFromConfig(awsCfg, func(o *s3.Options) {
+		if endpoint != "" {
+			o.BaseEndpoint = aws.String(endpoint)
+			o.UsePathStyle = true
+		}
+	})
+	return &s3Tier{client: client, bucket: cfg.Bucket}, nil
+}
+
+func (t *s3Tier) Put(ctx context.Context, key string, data []byte) error {
+	_, err := t.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(t.bucket),
+		Key:    aws.String(key),
+		Body:   bytes.NewReader(data),
+	})
+	return err
+}
+
+func (t *s3Tier) Get(ctx context.Context, key string) ([]byte, error) {
+	out, err := t.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(t.bucket),
+		Key:    aws.String(key),
+	})
+	var noSuchKey *types.NoSuchKey
+	if errors.As(err, &noSuchKey) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer out.Body.Close()
+	return io.ReadAll(out.Body)
+}
+
+func (t *s3Tier) List(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	paginator := s3.NewListObjectsV2Paginator(t.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(t.bucket),
+		Prefix: aws.String(prefix),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, object := range page.Contents {
+			keys = append(keys, aws.ToString(object.Key))
+		}
+	}
+	return keys, nil
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/brc20"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/getter"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+var (
+	deployID          = strings.Repeat("a", 64) + "i0"
+	authorityID       = strings.Repeat("b", 64) + "i0"
+	deployerPkscript  = "0014" + strings.Repeat("11", 20)
+	successorPkscript = "0014" + strings.Repeat("22", 20)
+)
+
+func inscribe(inscriptionID string, pkscript string, parentID string, content string) getter.OrdTransfer {
+	return getter.OrdTransfer{
+		InscriptionID: inscriptionID,
+		NewPkscript:   ord.Pkscript(pkscript),
+		NewWallet:     ord.Wallet(pkscript),
+		Content:       []byte(content),
+		ContentType:   "text/plain",
+		ParentID:      parentID,
+	}
+}
+
+// execAuthorityTransfer deploys a self-mint tick, transfers its authority from the deploy inscription, and mints
+// by both the deploy inscription and the new authority, returning the balances of their holders.
+func execAuthorityTransfer(t *testing.T, activationHeight uint) (uint64, uint64) {
+	selfMintEnableHeight := brc20.SelfMintEnableHeight
+	brc20.SelfMintEnableHeight = 0
+	brc20.AuthorityTransferHeight = activationHeight
+	defer func() {
+		brc20.SelfMintEnableHeight = selfMintEnableHeight
+		brc20.AuthorityTransferHeight = 0
+	}()
+
+	header := stateless.LoadHeader(false, 800000)
+	blocks := [][]getter.OrdTransfer{
+		{inscribe(deployID, deployerPkscript, "", `{"p":"brc-20","op":"deploy","tick":"selfm","max":"100","lim":"10","self_mint":"true"}`)},
+		{inscribe(authorityID, successorPkscript, deployID, `{"p":"brc-20","op":"authority","tick":"selfm"}`)},
+		{
+			inscribe(strings.Repeat("c", 64)+"i0", deployerPkscript, deployID, `{"p":"brc-20","op":"mint","tick":"selfm","amt":"10"}`),
+			inscribe(strings.Repeat("c", 64)+"i1", successorPkscript, authorityID, `{"p":"brc-20","op":"mint","tick":"selfm","amt":"10"}`),
+		},
+	}
+	for _, ots := range blocks {
+		stateless.Exec(header, ots, header.Height+1)
+		if err := header.Paging(nil, false, stateless.NodeResolveFn); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, _, _, deployer, err := brc20.GetBalances(header, "selfm", ord.Pkscript(deployerPkscript))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, _, successor, err := brc20.GetBalances(header, "selfm", ord.Pkscript(successorPkscript))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return deployer.Uint64() / 1e18, successor.Uint64() / 1e18
+}
+
+func Test_AuthorityTransfer(t *testing.T) {
+	deployer, successor := execAuthorityTransfer(t, 0)
+	if deployer != 10 || successor != 0 {
+		t.Fatalf("Expected the deploy inscription to keep the authority when disabled, got %d and %d", deployer, successor)
+	}
+	deployer, successor = execAuthorityTransfer(t, 800003)
+	if deployer != 10 || successor != 0 {
+		t.Fatalf("Expected the authority transfer before the activation to be ignored, got %d and %d", deployer, successor)
+	}
+	deployer, successor = execAuthorityTransfer(t, 800002)
+	if deployer != 0 || successor != 10 {
+		t.Fatalf("Expected the new authority to take over the mints, got %d and %d", deployer, successor)
+	}
+}
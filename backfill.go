@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/RiemaLabs/modular-indexer-committee/committee"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/protocol"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+// BackfillArguments is what the backfill command executes: the blocks from FromHeight up to the height of the stored
+// state, through the protocol Module alone.
+type BackfillArguments struct {
+	ConfigFilePath string
+	Network        string
+	StateDBPath    string
+	PreImagesPath  string
+	Module         string
+	FromHeight     uint
+}
+
+// Backfill runs the backfill command on the stored state, which the protocol enabled by the config joins without
+// a reindex, and stores the backfilled state.
+func Backfill(arguments *BackfillArguments) error {
+	loadConfig(arguments.ConfigFilePath, arguments.Network)
+	configureRules()
+	stateless.StateDBPath = arguments.StateDBPath
+	stateless.PreImagesPath = arguments.PreImagesPath
+	protocol.RecordPreImages(arguments.PreImagesPath != "")
+	defer stateless.ClosePreImages()
+	// The blocks logged after the stored state are recovered before the backfill, which would miss them otherwise.
+	stateless.WriteAheadLog = true
+	found, err := stateless.HasState()
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("no state is stored to backfill")
+	}
+	header := stateless.LoadHeader(true, 0)
+	defer func() {
+		if err := stateless.CloseStateDB(); err != nil {
+			log.Printf("Failed to close the state database: %v", err)
+		}
+	}()
+	module := strings.ToLower(arguments.Module)
+	if err := committee.Backfill(newOrdGetter(&RuntimeArguments{}), header, module, arguments.FromHeight, true); err != nil {
+		return err
+	}
+	log.Printf("Backfilled the protocol %s on the state from the block %d to %d", module, arguments.FromHeight, header.Height)
+	return nil
+}
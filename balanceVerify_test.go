@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/RiemaLabs/modular-indexer-committee/apis"
+	"github.com/RiemaLabs/modular-indexer-committee/checkpoint"
+	"github.com/RiemaLabs/modular-indexer-committee/client"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/getter"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+func Test_BalanceVerify(t *testing.T) {
+	pkscript := "0014" + strings.Repeat("7e", 20)
+	g := &blocksGetter{
+		blocks: map[uint][]getter.OrdTransfer{
+			800001: {inscribe(strings.Repeat("7", 64)+"i0", pkscript, "", `{"p":"brc-20","op":"deploy","tick":"vrfy","max":"100","lim":"10"}`)},
+			800002: {inscribe(strings.Repeat("7", 64)+"i1", pkscript, "", `{"p":"brc-20","op":"mint","tick":"vrfy","amt":"10"}`)},
+		},
+		hashes: make(map[uint]string),
+	}
+	queue, err := stateless.NewQueues(g, stateless.LoadHeader(false, 800000), true, 800001)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gin.SetMode(gin.TestMode)
+	ts := httptest.NewServer(apis.NewRouter(queue, "brc-20", false, false))
+	defer ts.Close()
+	c, err := client.New(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Retries = 0
+
+	signer, err := checkpoint.NewSigner(checkpoint.SchemeEd25519, strings.Repeat("7e", 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	publish := func(commitment string) {
+		indexerID := checkpoint.IndexerIdentification{Name: "alice", MetaProtocol: "brc-20"}
+		cp := checkpoint.NewCheckpoint(&indexerID, queue.Header.Height, queue.Header.Hash, commitment)
+		if err := cp.Sign(signer); err != nil {
+			t.Fatal(err)
+		}
+		data, _ := json.Marshal(cp)
+		if err := os.WriteFile(filepath.Join(dir, checkpoint.ObjectKey(&cp)), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	arguments := &VerifyArguments{Publisher: "alice", PublicKey: hex.EncodeToString(signer.PublicKey()), MetaProtocol: "brc-20", Tick: "vrfy", Pkscript: pkscript}
+	source := &checkpoint.DirSource{Dir: dir}
+	ctx := context.Background()
+
+	// The balance is rejected without a checkpoint, or against another commitment.
+	if _, err := VerifyBalance(ctx, c, source, arguments); err == nil {
+		t.Fatal("Expected the balance without a checkpoint to be rejected")
+	}
+	publish(base64.StdEncoding.EncodeToString(make([]byte, 32)))
+	if _, err := VerifyBalance(ctx, c, source, arguments); err == nil {
+		t.Fatal("Expected the balance proven against another commitment to be rejected")
+	}
+
+	commitment := queue.Header.Root.Commit().Bytes()
+	publish(base64.StdEncoding.EncodeToString(commitment[:]))
+	b, err := VerifyBalance(ctx, c, source, arguments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Height != queue.Header.Height || b.OverallBalance != "10000000000000000000" || b.AvailableBalance != b.OverallBalance {
+		t.Fatalf("Unexpected verified balance %+v", b)
+	}
+
+	// Only the latest block is proven, and only the checkpoints signed by the trusted key are trusted.
+	arguments.Height = 800002
+	if _, err := VerifyBalance(ctx, c, source, arguments); err == nil {
+		t.Fatal("Expected the past height to be rejected")
+	}
+	arguments.Height, arguments.PublicKey = queue.Header.Height, strings.Repeat("00", 32)
+	if _, err := VerifyBalance(ctx, c, source, arguments); err == nil {
+		t.Fatal("Expected the checkpoint signed by another key to be rejected")
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/RiemaLabs/modular-indexer-committee/apis"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/brc20"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/getter"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+func Test_BatchProof(t *testing.T) {
+	pkscriptA := "0014" + strings.Repeat("a8", 20)
+	pkscriptB := "0014" + strings.Repeat("b8", 20)
+	g := &blocksGetter{
+		blocks: map[uint][]getter.OrdTransfer{
+			800001: {
+				inscribe(strings.Repeat("8", 64)+"i0", pkscriptA, "", `{"p":"brc-20","op":"deploy","tick":"bpfa","max":"100"}`),
+				inscribe(strings.Repeat("8", 64)+"i1", pkscriptA, "", `{"p":"brc-20","op":"deploy","tick":"bpfb","max":"100"}`),
+			},
+			800002: {
+				inscribe(strings.Repeat("9", 64)+"i0", pkscriptA, "", `{"p":"brc-20","op":"mint","tick":"bpfa","amt":"5"}`),
+				inscribe(strings.Repeat("9", 64)+"i1", pkscriptB, "", `{"p":"brc-20","op":"mint","tick":"bpfb","amt":"7"}`),
+			},
+		},
+		hashes: make(map[uint]string),
+	}
+	header := stateless.LoadHeader(false, 800000)
+	queue, err := stateless.NewQueues(g, header, true, 800001)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := apis.NewRouter(queue, "brc-20", false, false)
+	batch := func(req apis.Brc20VerifiableBatchProofRequest) (int, apis.Brc20VerifiableBatchProofResponse) {
+		body, _ := json.Marshal(req)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/brc20_verifiable/batch_proof", bytes.NewReader(body)))
+		var resp apis.Brc20VerifiableBatchProofResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Unexpected response %d: %s", w.Code, w.Body.String())
+		}
+		return w.Code, resp
+	}
+
+	exists := hex.EncodeToString(brc20.GetTickHash("bpfb", brc20.Exists))
+	absent := strings.Repeat("00", 32)
+	code, resp := batch(apis.Brc20VerifiableBatchProofRequest{
+		Balances: []apis.Brc20BatchBalanceQuery{
+			{Tick: "bpfa", Pkscript: pkscriptA},
+			{Tick: "bpfb", Pkscript: pkscriptB},
+			// Neither holds the tick, which is proven absent.
+			{Tick: "bpfb", Pkscript: pkscriptA},
+		},
+		Keys: []string{exists, absent, exists},
+	})
+	if code != http.StatusOK || len(resp.Result.Balances) != 3 || len(resp.Result.Values) != 3 || resp.Result.Height != queue.Header.Height {
+		t.Fatalf("Unexpected batch %d: %+v", code, resp.Result)
+	}
+	if resp.Result.Balances[1].OverallBalance != "7000000000000000000" || resp.Result.Balances[2].OverallBalance != "0" {
+		t.Fatalf("Unexpected balances %+v", resp.Result.Balances)
+	}
+	if resp.Result.Values[0].Value != strings.Repeat("00", 31)+"01" || resp.Result.Values[1].Value != absent {
+		t.Fatalf("Unexpected values %+v", resp.Result.Values)
+	}
+	rootC := queue.Header.Root.Commit()
+	if _, err := apis.VerifyBatchProof(rootC, &resp); err != nil {
+		t.Fatal(err)
+	}
+	resp.Result.Values[1].Value = strings.Repeat("00", 31) + "01"
+	if _, err := apis.VerifyBatchProof(rootC, &resp); err == nil {
+		t.Fatal("Expected the tampered value to be rejected")
+	}
+	resp.Result.Values[1].Value = absent
+	resp.Result.Balances[0].AvailableBalance = "1"
+	if _, err := apis.VerifyBatchProof(rootC, &resp); err == nil {
+		t.Fatal("Expected the tampered balance to be rejected")
+	}
+
+	for _, req := range []apis.Brc20VerifiableBatchProofRequest{
+		{},
+		{Keys: []string{"00"}},
+		{Balances: []apis.Brc20BatchBalanceQuery{{Tick: "bpfa"}}},
+		{Balances: make([]apis.Brc20BatchBalanceQuery, apis.MaxBatchProofKeys/2+1)},
+	} {
+		if code, _ := batch(req); code != http.StatusBadRequest {
+			t.Fatalf("Expected the batch %+v to be rejected, got %d", req, code)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/RiemaLabs/modular-indexer-committee/checkpoint"
+	"github.com/RiemaLabs/modular-indexer-committee/crosscheck"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/getter"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/reexec"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+// BlockTrace is the local execution of a block between the agreed checkpoint and the divergent one.
+type BlockTrace struct {
+	Height         uint   `json:"height"`
+	Hash           string `json:"hash"`
+	Transfers      int    `json:"transfers"`
+	Reads          int    `json:"reads"`
+	Writes         int    `json:"writes"`
+	PreCommitment  string `json:"preCommitment"`
+	PostCommitment string `json:"postCommitment"`
+	// The error of re-executing the witness statelessly, empty if the witness is sound.
+	Reexec  string `json:"reexec,omitempty"`
+	Witness string `json:"witness"`
+}
+
+// BisectReport combines the divergence located between two members with the local trace of the blocks leading to it.
+type BisectReport struct {
+	A          string                 `json:"a"`
+	B          string                 `json:"b"`
+	Divergence *checkpoint.Divergence `json:"divergence"`
+	// Whether the divergent block directly follows the agreed checkpoint, otherwise any traced block may diverge.
+	Exact bool         `json:"exact"`
+	Trace []BlockTrace `json:"trace"`
+	// The member committing the same state as the local execution at the divergent height: a, b or none.
+	Matches string `json:"matches"`
+}
+
+// OpenHistory opens the checkpoint history of a member, either a directory of checkpoint files
+// or s3://<bucket>/<name> listed with the credentials of the S3 report config.
+func OpenHistory(ctx context.Context, source string, metaProtocol string) (checkpoint.History, error) {
+	location, found := strings.CutPrefix(source, "s3://")
+	if !found {
+		return checkpoint.NewDirHistory(source)
+	}
+	bucket, name, found := strings.Cut(location, "/")
+	if !found || bucket == "" || name == "" {
+		return nil, fmt.Errorf("invalid checkpoint history %s, expected s3://<bucket>/<name>", source)
+	}
+	s3cfg := GlobalConfig.Report.S3
+	return checkpoint.NewS3History(ctx, Secrets.Get(s3cfg.AccessKey), Secrets.Get(s3cfg.SecretKey), s3cfg.Region, s3cfg.Endpoint, bucket, name, metaProtocol)
+}
+
+// BisectStage locates the first divergent checkpoint between the members, then executes the blocks from the last
+// agreed checkpoint to the divergent one locally, exporting their execution witnesses to traceDir.
+func BisectStage(ctx context.Context, ordGetter getter.OrdGetter, arguments *RuntimeArguments, initHeight uint, a, b checkpoint.History, traceDir string) (*BisectReport, error) {
+	d, err := checkpoint.Bisect(ctx, a, b)
+	if err != nil {
+		return nil, err
+	}
+	report := BisectReport{A: arguments.BisectA, B: arguments.BisectB, Divergence: d, Trace: make([]BlockTrace, 0)}
+	if d == nil {
+		log.Printf("The members agree on all the common checkpoints")
+		return &report, nil
+	}
+	log.Printf("The members diverge at height %d after agreeing at height %d, located by %d probes", d.DivergedHeight, d.AgreedHeight, d.Probes)
+
+	// Without an agreed checkpoint only the divergent block itself is traced.
+	from := d.AgreedHeight
+	if from == 0 {
+		from = d.DivergedHeight - 1
+	}
+	report.Exact = d.DivergedHeight == from+1
+
+	header := stateless.LoadHeader(arguments.EnableStateRootCache, initHeight)
+	if header.Height > from {
+		return nil, fmt.Errorf("the cached state at height %d is past the height %d to trace from, rerun with --cache=false", header.Height, from)
+	}
+	log.Printf("Catch up from %d to %d before tracing", header.Height, from)
+	header.Pipeline(true)
+	fetcher := ordGetter
+	if arguments.Prefetch > 0 && header.Height < from {
+		prefetcher := getter.NewPrefetcher(ordGetter, header.Height+1, from, arguments.Prefetch, arguments.PrefetchWorkers)
+		defer prefetcher.Close()
+		fetcher = prefetcher
+	}
+	for i := header.Height + 1; i <= from; i++ {
+		ots, err := fetcher.GetOrdTransfers(i)
+		if err != nil {
+			return nil, err
+		}
+		stateless.Exec(header, ots, i)
+		if err := header.Paging(ordGetter, false, stateless.NodeResolveFn); err != nil {
+			return nil, err
+		}
+		if i%1000 == 0 {
+			log.Printf("Blocks: %d / %d \n", i, from)
+		}
+	}
+
+	if prefetcher, ok := fetcher.(*getter.Prefetcher); ok {
+		prefetcher.Close()
+	}
+	header.Pipeline(false)
+
+	for i := from + 1; i <= d.DivergedHeight; i++ {
+		ots, err := ordGetter.GetOrdTransfers(i)
+		if err != nil {
+			return nil, err
+		}
+		stateless.Exec(header, ots, i)
+		w, err := stateless.NewWitness(header, ots, i)
+		if err != nil {
+			return nil, fmt.Errorf("failed to record the witness at height %d: %v", i, err)
+		}
+		if err := header.Paging(ordGetter, true, stateless.NodeResolveFn); err != nil {
+			return nil, err
+		}
+		stateless.SealWitness(w, header)
+		if err := stateless.StoreWitness(traceDir, w); err != nil {
+			return nil, fmt.Errorf("failed to store the witness at height %d: %v", i, err)
+		}
+		trace := BlockTrace{
+			Height:         i,
+			Hash:           header.Hash,
+			Transfers:      len(ots),
+			Reads:          len(w.Reads),
+			Writes:         len(w.Writes),
+			PreCommitment:  w.PreCommitment,
+			PostCommitment: w.PostCommitment,
+			Witness:        fmt.Sprintf("%s/%d.json", traceDir, i),
+		}
+		if err := reexec.Verify(w); err != nil {
+			trace.Reexec = err.Error()
+		}
+		report.Trace = append(report.Trace, trace)
+	}
+
+	commitment := header.Root.Commit().Bytes()
+	local := base64.StdEncoding.EncodeToString(commitment[:])
+	switch local {
+	case d.A.Commitment:
+		report.Matches = "a"
+	case d.B.Commitment:
+		report.Matches = "b"
+	default:
+		report.Matches = "none"
+	}
+	if header.Hash != d.A.Hash {
+		log.Printf("The local block hash %s at height %d differs from the hash %s of the members", header.Hash, d.DivergedHeight, d.A.Hash)
+	}
+	return &report, nil
+}
+
+// Bisect runs the bisect stage on the histories of the arguments and writes the combined report.
+func Bisect(ordGetter getter.OrdGetter, arguments *RuntimeArguments, initHeight uint, metaProtocol string) error {
+	ctx := context.Background()
+	a, err := OpenHistory(ctx, arguments.BisectA, metaProtocol)
+	if err != nil {
+		return err
+	}
+	b, err := OpenHistory(ctx, arguments.BisectB, metaProtocol)
+	if err != nil {
+		return err
+	}
+	traceDir := arguments.WitnessPath
+	if traceDir == "" {
+		traceDir = "bisect-trace"
+		if err := os.MkdirAll(traceDir, 0755); err != nil {
+			return err
+		}
+	}
+	report, err := BisectStage(ctx, ordGetter, arguments, initHeight, a, b, traceDir)
+	if err != nil {
+		return err
+	}
+	bytes, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(arguments.BisectReport, bytes, 0644); err != nil {
+		return err
+	}
+	log.Printf("The bisect report is written to %s, the local execution matches: %s", arguments.BisectReport, report.Matches)
+	return nil
+}
+
+// VerifyFraudProof verifies the fraud proof saved by the cross-check, exiting with an error if it's invalid.
+func VerifyFraudProof(path string) {
+	proof, err := crosscheck.LoadFraudProof(path)
+	if err != nil {
+		log.Fatalf("Failed to load the fraud proof: %v", err)
+	}
+	if err := proof.Verify(); err != nil {
+		log.Fatalf("Invalid fraud proof of the member %s at height %d: %v", proof.Member, proof.Height, err)
+	}
+	attested := "not attested by the member"
+	if proof.Previous != nil {
+		attested = "attested by the member at the previous height"
+	}
+	log.Printf("Valid fraud proof: the checkpoint of the member %s at height %d commits to %s, but the block changing %d keys from the pre-state %s (%s) leads to %s",
+		proof.Member, proof.Height, proof.Checkpoint.Commitment, len(proof.Keys), proof.Witness.PreCommitment, attested, proof.Witness.PostCommitment)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/RiemaLabs/modular-indexer-committee/checkpoint"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+func Test_Bisect(t *testing.T) {
+	stateless.ReorgDepth = 12
+	defer func() { stateless.ReorgDepth = 6 }()
+
+	ordGetterTest, arguments := loadMain(782000)
+	queue, err := CatchupStage(ordGetterTest, &arguments, stateless.BRC20StartHeight-1, 780000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The member b publishes forged commitments from the height 779995 on.
+	var forkHeight uint = 779995
+	dirA, dirB := t.TempDir(), t.TempDir()
+	indexerID := checkpoint.IndexerIdentification{Name: "committee", MetaProtocol: "brc-20"}
+	for _, state := range queue.History {
+		commitment := base64.StdEncoding.EncodeToString(state.VerkleCommit[:])
+		forged := commitment
+		if state.Height >= forkHeight {
+			forged = base64.StdEncoding.EncodeToString([]byte(fmt.Sprint("forged", state.Height)))
+		}
+		for dir, commitment := range map[string]string{dirA: commitment, dirB: forged} {
+			c := checkpoint.NewCheckpoint(&indexerID, state.Height, state.Hash, commitment)
+			bytes, err := json.Marshal(c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("%d.json", state.Height)), bytes, 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	a, err := checkpoint.NewDirHistory(dirA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := checkpoint.NewDirHistory(dirB)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	traceDir := t.TempDir()
+	report, err := BisectStage(context.Background(), ordGetterTest, &arguments, stateless.BRC20StartHeight-1, a, b, traceDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := report.Divergence
+	if d == nil || d.DivergedHeight != forkHeight || d.AgreedHeight != forkHeight-1 || !report.Exact {
+		t.Fatalf("Unexpected divergence %+v", d)
+	}
+	if report.Matches != "a" || len(report.Trace) != 1 {
+		t.Fatalf("Expected the local execution to match the member a with 1 traced block, got %s with %d", report.Matches, len(report.Trace))
+	}
+	trace := report.Trace[0]
+	if trace.Height != forkHeight || trace.Reexec != "" || trace.PreCommitment != d.Agreed.Commitment || trace.PostCommitment != d.A.Commitment {
+		t.Fatalf("Unexpected trace %+v", trace)
+	}
+	if _, err := stateless.LoadWitness(traceDir, forkHeight); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/RiemaLabs/modular-indexer-committee/apis"
+	"github.com/RiemaLabs/modular-indexer-committee/ord"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/bitmap"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/getter"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+// The districts stay registered for the rest of the tests, as the sats names.
+var registerBitmap sync.Once
+
+func Test_BitmapDistricts(t *testing.T) {
+	registerBitmap.Do(bitmap.Register)
+	apis.Modules = append(apis.Modules, apis.BitmapModule)
+	defer func() {
+		apis.Modules = apis.Modules[:len(apis.Modules)-1]
+	}()
+
+	claim := inscribe(strings.Repeat("a", 63)+"1i0", deployerPkscript, "", "800000.bitmap")
+	claim.NewWallet = "bc1qalice"
+	transfer := claim
+	transfer.OldSatpoint = strings.Repeat("a", 63) + "1:0:0"
+	transfer.NewPkscript, transfer.NewWallet = ord.Pkscript(successorPkscript), "bc1qbob"
+	g := &blocksGetter{
+		blocks: map[uint][]getter.OrdTransfer{
+			800001: {
+				claim,
+				inscribe(strings.Repeat("a", 63)+"2i0", successorPkscript, "", "800000.bitmap"),
+				// The block to come can't be claimed yet.
+				inscribe(strings.Repeat("a", 63)+"3i0", successorPkscript, "", "800002.bitmap"),
+			},
+			800002: {transfer},
+		},
+		hashes: make(map[uint]string),
+	}
+	header := stateless.LoadHeader(false, 800000)
+	queue, err := stateless.NewQueues(g, header, true, 800001)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := apis.NewRouter(queue, "brc-20", false, false)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/bitmap/district/800000", nil))
+	var resp apis.BitmapDistrictResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || w.Code != http.StatusOK || resp.Proof == nil {
+		t.Fatalf("Unexpected response %d: %s", w.Code, w.Body.String())
+	}
+	if resp.Result.Owner != "bc1qbob" || resp.Result.InscriptionID != claim.InscriptionID {
+		t.Fatalf("Unexpected record %+v", resp.Result)
+	}
+	for path, status := range map[string]int{
+		"/v1/bitmap/district/800002": http.StatusNotFound,
+		"/v1/bitmap/district/x":      http.StatusBadRequest,
+	} {
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != status {
+			t.Fatalf("Expected %d from %s, got %d", status, path, w.Code)
+		}
+	}
+}
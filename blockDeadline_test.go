@@ -0,0 +1,52 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+func Test_BlockDeadline(t *testing.T) {
+	ordGetterTest, arguments := loadMain(782000)
+	queue, err := CatchupStage(ordGetterTest, &arguments, stateless.BRC20StartHeight-1, 780000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	next := queue.Header.Height + 1
+	for ; next < 780100; next++ {
+		if ots, _ := ordGetterTest.GetOrdTransfers(next); len(ots) != 0 {
+			break
+		}
+	}
+	if err := queue.Update(ordGetterTest, next-1); err != nil {
+		t.Fatal(err)
+	}
+	commit := queue.Header.Root.Commit().Bytes()
+
+	// The non-empty block can't be executed within a nanosecond.
+	stateless.BlockDeadline = time.Nanosecond
+	defer func() { stateless.BlockDeadline = 0 }()
+	err = queue.Update(ordGetterTest, next)
+	if !errors.Is(err, stateless.ErrDeadlineExceeded) {
+		t.Fatalf("Expected the block %d to exceed the deadline, got %v", next, err)
+	}
+	if queue.Header.Height != next-1 || queue.Header.Root.Commit().Bytes() != commit {
+		t.Fatalf("Expected the queue to stay before the exceeded block, got height %d", queue.Header.Height)
+	}
+	if len(queue.Header.Access.Elements) != 0 || len(queue.Header.IntermediateKV) != 0 {
+		t.Fatal("Expected the partial block to be rolled back")
+	}
+
+	// The block is executed once retried without the deadline.
+	stateless.BlockDeadline = 0
+	if err := queue.Update(ordGetterTest, next); err != nil {
+		t.Fatal(err)
+	}
+	progress := stateless.CurrentProgress()
+	if queue.Header.Height != next || progress.Executing || progress.Height != next || progress.Processed != progress.Total || progress.Total == 0 {
+		t.Fatalf("Unexpected progress %+v at height %d", progress, queue.Header.Height)
+	}
+}
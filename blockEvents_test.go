@@ -0,0 +1,104 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/brc20"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/getter"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+func Test_BlockEvents(t *testing.T) {
+	pkscriptA := "0014" + strings.Repeat("a1", 20)
+	pkscriptB := "0014" + strings.Repeat("b1", 20)
+	// The sources of the transfers are kept as the base58 decoded wallets, which the events read back.
+	wallets := map[string]ord.Wallet{
+		pkscriptA: "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2",
+		pkscriptB: "3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy",
+	}
+	transferA := strings.Repeat("7", 64) + "i0"
+	transferB := strings.Repeat("8", 64) + "i0"
+	move := func(inscriptionID string, pkscript string, sentAsFee bool) getter.OrdTransfer {
+		ot := inscribe(inscriptionID, pkscript, "", `{"p":"brc-20","op":"transfer","tick":"evta","amt":"4"}`)
+		ot.OldSatpoint = inscriptionID + ":0:0"
+		ot.SentAsFee = sentAsFee
+		return ot
+	}
+	blocks := [][]getter.OrdTransfer{
+		{
+			inscribe(strings.Repeat("1", 64)+"i0", pkscriptA, "", `{"p":"brc-20","op":"deploy","tick":"EVTA","max":"100","lim":"10"}`),
+			inscribe(strings.Repeat("1", 64)+"i1", pkscriptA, "", `{"p":"brc-20","op":"deploy","tick":"evtb","max":"100","dec":"1"}`),
+		},
+		{
+			inscribe(strings.Repeat("2", 64)+"i0", pkscriptA, "", `{"p":"brc-20","op":"mint","tick":"evta","amt":"10"}`),
+			inscribe(strings.Repeat("2", 64)+"i1", pkscriptB, "", `{"p":"brc-20","op":"mint","tick":"evtb","amt":"5.5"}`),
+			inscribe(transferA, pkscriptA, "", `{"p":"brc-20","op":"transfer","tick":"evta","amt":"4"}`),
+			inscribe(transferB, pkscriptA, "", `{"p":"brc-20","op":"transfer","tick":"evta","amt":"4"}`),
+			// Exceeding the limit per mint emits nothing.
+			inscribe(strings.Repeat("2", 64)+"i2", pkscriptB, "", `{"p":"brc-20","op":"mint","tick":"evta","amt":"11"}`),
+		},
+		{move(transferA, pkscriptB, false), move(transferB, pkscriptB, true)},
+	}
+	for _, ots := range blocks {
+		for i := range ots {
+			ots[i].NewWallet = wallets[string(ots[i].NewPkscript)]
+		}
+	}
+	expected := [][]brc20.Event{
+		{
+			brc20.DeployEvent{InscriptionID: strings.Repeat("1", 64) + "i0", Tick: "evta", OriginalTick: "EVTA", Pkscript: ord.Pkscript(pkscriptA), Wallet: wallets[pkscriptA], MaxSupply: "100000000000000000000", LimitPerMint: "10000000000000000000", Decimals: 18},
+			brc20.DeployEvent{InscriptionID: strings.Repeat("1", 64) + "i1", Tick: "evtb", Pkscript: ord.Pkscript(pkscriptA), Wallet: wallets[pkscriptA], MaxSupply: "100000000000000000000", LimitPerMint: "100000000000000000000", Decimals: 1},
+		},
+		{
+			brc20.MintEvent{InscriptionID: strings.Repeat("2", 64) + "i0", Tick: "evta", Pkscript: ord.Pkscript(pkscriptA), Wallet: wallets[pkscriptA], Amount: "10000000000000000000"},
+			brc20.MintEvent{InscriptionID: strings.Repeat("2", 64) + "i1", Tick: "evtb", Pkscript: ord.Pkscript(pkscriptB), Wallet: wallets[pkscriptB], Amount: "5500000000000000000"},
+			brc20.TransferInscribeEvent{InscriptionID: transferA, Tick: "evta", Pkscript: ord.Pkscript(pkscriptA), Wallet: wallets[pkscriptA], Amount: "4000000000000000000"},
+			brc20.TransferInscribeEvent{InscriptionID: transferB, Tick: "evta", Pkscript: ord.Pkscript(pkscriptA), Wallet: wallets[pkscriptA], Amount: "4000000000000000000"},
+		},
+		{
+			brc20.TransferTransferEvent{InscriptionID: transferA, Tick: "evta", SourcePkscript: ord.Pkscript(pkscriptA), SourceWallet: wallets[pkscriptA], SpentPkscript: ord.Pkscript(pkscriptB), SpentWallet: wallets[pkscriptB], Amount: "4000000000000000000"},
+			brc20.TransferTransferEvent{InscriptionID: transferB, Tick: "evta", SourcePkscript: ord.Pkscript(pkscriptA), SourceWallet: wallets[pkscriptA], Amount: "4000000000000000000", SentAsFee: true},
+		},
+	}
+
+	stateless.EventsPath = t.TempDir()
+	defer func() {
+		_ = stateless.CloseEvents()
+		stateless.EventsPath = ""
+		stateless.ExecShards = 1
+	}()
+	// The sharded execution emits the events in the order of the block as well.
+	for _, shards := range []uint{1, 4} {
+		stateless.ExecShards = shards
+		header := stateless.LoadHeader(false, 800000)
+		for i, ots := range blocks {
+			events := stateless.Exec(header, ots, header.Height+1)
+			if !reflect.DeepEqual(events, expected[i]) {
+				t.Fatalf("Unexpected events of the block %d with %d shards: %+v", i, shards, events)
+			}
+			if err := header.Paging(nil, false, stateless.NodeResolveFn); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for i := range blocks {
+			events, err := stateless.BlockEvents(800001 + uint(i))
+			if err != nil || !reflect.DeepEqual(events, expected[i]) {
+				t.Fatalf("Unexpected kept events of the block %d: %+v, %v", i, events, err)
+			}
+		}
+	}
+
+	// The block executed again drops the events of the blocks following it.
+	header := stateless.LoadHeader(false, 800000)
+	stateless.Exec(header, blocks[0], 800001)
+	if err := header.Paging(nil, false, stateless.NodeResolveFn); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stateless.BlockEvents(800002); !errors.Is(err, stateless.ErrEventsUnavailable) {
+		t.Fatalf("Expected the events of the block 800002 to be dropped, got %v", err)
+	}
+}
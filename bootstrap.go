@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-verkle"
+
+	"github.com/RiemaLabs/modular-indexer-committee/checkpoint"
+	"github.com/RiemaLabs/modular-indexer-committee/ord"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/getter"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+	"github.com/RiemaLabs/modular-indexer-committee/peer"
+)
+
+// snapshotBootstrapEnabled tells whether a snapshot is configured to bootstrap the state from.
+func snapshotBootstrapEnabled() bool {
+	cfg := GlobalConfig.SnapshotBootstrap
+	return cfg.URL != "" || cfg.Member.URL != "" || cfg.Member.Name != ""
+}
+
+// snapshotMember returns the member serving the snapshot, filled in by the audited member of the same name.
+func snapshotMember() (peer.Member, error) {
+	m := GlobalConfig.SnapshotBootstrap.Member
+	if m.URL == "" && m.Name != "" {
+		for _, audited := range GlobalConfig.Peers.Audit.Members {
+			if audited.Name == m.Name {
+				m = audited
+				break
+			}
+		}
+	}
+	if key, err := hex.DecodeString(m.PublicKey); err != nil || len(key) == 0 {
+		return m, errors.New("the public key of the member serving the snapshot is required")
+	}
+	return m, nil
+}
+
+// BootstrapSnapshot imports the snapshot of a trusted member as the state cache, unless a state is already stored,
+// so that the indexing starts from the block of the snapshot rather than from the genesis. The snapshot is only
+// imported if its block is on the chain and its commitment is the one published by the checkpoints of the member
+// configured, in addition to the signature of the member serving it.
+func BootstrapSnapshot(ctx context.Context, ordGetter getter.OrdGetter, metaProtocol string) error {
+	cfg := GlobalConfig.SnapshotBootstrap
+	stored, err := stateless.HasState()
+	if err != nil {
+		return err
+	}
+	if stored {
+		log.Printf("The snapshot isn't imported onto the stored state")
+		return nil
+	}
+	if cfg.Checkpoints.Name == "" || cfg.Checkpoints.Source == "" {
+		return errors.New("the name and the source of the member publishing the checkpoints are required")
+	}
+	source, err := OpenSource(cfg.Checkpoints.Source)
+	if err != nil {
+		return err
+	}
+	m, err := snapshotMember()
+	if err != nil {
+		return err
+	}
+	url := cfg.URL
+	if url == "" {
+		url = strings.TrimRight(m.URL, "/") + "/v1/peer/snapshot"
+	}
+
+	header, manifest, err := downloadSnapshot(ctx, url, m)
+	if err != nil {
+		return err
+	}
+	hash, err := ordGetter.GetBlockHash(manifest.Height)
+	if err != nil {
+		return err
+	}
+	if hash != manifest.Hash {
+		return fmt.Errorf("the snapshot is of the block %s rather than %s at height %d", manifest.Hash, hash, manifest.Height)
+	}
+	key := checkpoint.ObjectKey(&checkpoint.Checkpoint{
+		Name:         cfg.Checkpoints.Name,
+		MetaProtocol: metaProtocol,
+		Height:       strconv.FormatUint(uint64(manifest.Height), 10),
+		Hash:         manifest.Hash,
+	})
+	c, err := source.Get(ctx, key)
+	if errors.Is(err, checkpoint.ErrNotPublished) {
+		return fmt.Errorf("the member %s published no checkpoint at height %d to verify the snapshot, retry after its next checkpoint", cfg.Checkpoints.Name, manifest.Height)
+	}
+	if err != nil {
+		return err
+	}
+	if err := c.CheckNetwork(ord.IndexedNetwork.Tag()); err != nil {
+		return err
+	}
+	if cfg.Checkpoints.PublicKey != "" {
+		if !strings.EqualFold(c.PublicKey, cfg.Checkpoints.PublicKey) {
+			return fmt.Errorf("the checkpoint %s isn't signed by the key of the member %s", key, cfg.Checkpoints.Name)
+		}
+		if err := c.VerifySignature(); err != nil {
+			return err
+		}
+	}
+	if c.Commitment != manifest.Commitment {
+		return fmt.Errorf("the commitment %s of the snapshot differs from %s of the checkpoint at height %d", manifest.Commitment, c.Commitment, manifest.Height)
+	}
+
+	if err := stateless.StoreHeader(header, 0); err != nil {
+		return err
+	}
+	log.Printf("Imported the snapshot of %d key-values at height %d, verified by the checkpoint of %s", manifest.Size, manifest.Height, cfg.Checkpoints.Name)
+	return nil
+}
+
+// downloadSnapshot downloads the snapshot at the URL served by the member, and imports it once its manifest is signed
+// by the member and its key-values match the digest and the commitment of the manifest. The block of the snapshot is
+// left to the caller to verify.
+func downloadSnapshot(ctx context.Context, url string, m peer.Member) (*stateless.Header, *peer.Manifest, error) {
+	log.Printf("Downloading the snapshot from %s", url)
+	kv := make(stateless.MemoryKV)
+	manifest, err := peer.FetchSnapshot(ctx, &http.Client{}, url, Secrets.Get(m.Token), m.PublicKey, func(key, value []byte) error {
+		if len(key) != verkle.KeySize || len(value) != stateless.ValueSize {
+			return fmt.Errorf("invalid key-value %x of the snapshot", key)
+		}
+		kv[[verkle.KeySize]byte(key)] = [stateless.ValueSize]byte(value)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to download the snapshot: %v", err)
+	}
+	header, err := stateless.ImportSnapshot(manifest.Height, manifest.Hash, kv)
+	if err != nil {
+		return nil, nil, err
+	}
+	digest := header.Digest()
+	bytes := header.Root.Commit().Bytes()
+	commitment := base64.StdEncoding.EncodeToString(bytes[:])
+	if hex.EncodeToString(digest[:]) != manifest.Digest || commitment != manifest.Commitment {
+		return nil, nil, fmt.Errorf("the key-values of the snapshot at height %d don't match its manifest", manifest.Height)
+	}
+	return header, manifest, nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/brc20"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/getter"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+// execBurn mints a tick and transfers a part of it to an OP_RETURN output, returning the header after the transfer.
+func execBurn(t *testing.T, activationHeight uint) *stateless.Header {
+	brc20.BurnHeight = activationHeight
+	defer func() {
+		brc20.BurnHeight = 0
+	}()
+
+	transferID := strings.Repeat("e", 64) + "i0"
+	transferContent := `{"p":"brc-20","op":"transfer","tick":"burn","amt":"4"}`
+	spent := inscribe(transferID, "6a"+strings.Repeat("00", 4), "", transferContent)
+	spent.OldSatpoint = strings.Repeat("e", 64) + ":0:0"
+
+	header := stateless.LoadHeader(false, 800000)
+	blocks := [][]getter.OrdTransfer{
+		{inscribe(strings.Repeat("c", 64)+"i0", deployerPkscript, "", `{"p":"brc-20","op":"deploy","tick":"burn","max":"100","lim":"10"}`)},
+		{inscribe(strings.Repeat("d", 64)+"i0", deployerPkscript, "", `{"p":"brc-20","op":"mint","tick":"burn","amt":"10"}`)},
+		{inscribe(transferID, deployerPkscript, "", transferContent)},
+		{spent},
+	}
+	for _, ots := range blocks {
+		stateless.Exec(header, ots, header.Height+1)
+		if err := header.Paging(nil, false, stateless.NodeResolveFn); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return header
+}
+
+func Test_BRC20Burn(t *testing.T) {
+	header := execBurn(t, 800004)
+	_, _, _, deployer, err := brc20.GetBalances(header, "burn", ord.Pkscript(deployerPkscript))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, _, unspendable, err := brc20.GetBalances(header, "burn", ord.Pkscript("6a"+strings.Repeat("00", 4)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deployer.Uint64()/1e18 != 6 || !unspendable.IsZero() {
+		t.Fatalf("Expected the transfer to be burned, got %s and %s", deployer.Dec(), unspendable.Dec())
+	}
+	info, found, _ := header.TickInfo("burn")
+	if !found || info.Minted != "10000000000000000000" || info.BurnedSupply != "4000000000000000000" || info.CirculatingSupply != "6000000000000000000" {
+		t.Fatalf("Unexpected tick %+v", info)
+	}
+
+	header = execBurn(t, 0)
+	_, _, _, unspendable, err = brc20.GetBalances(header, "burn", ord.Pkscript("6a"+strings.Repeat("00", 4)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unspendable.Uint64()/1e18 != 4 {
+		t.Fatalf("Expected the unspendable output to be credited when disabled, got %s", unspendable.Dec())
+	}
+	info, _, _ = header.TickInfo("burn")
+	if info.BurnedSupply != "0" || info.CirculatingSupply != "10000000000000000000" {
+		t.Fatalf("Unexpected tick %+v", info)
+	}
+}
@@ -29,3 +29,13 @@ func Test_CatchupStage(t *testing.T) {
 	commitment := base64.StdEncoding.EncodeToString(bytes[:])
 	log.Printf("Header's Commitment Is %s", commitment)
 }
+
+func Test_CatchupStageShortChain(t *testing.T) {
+	ordGetterTest, arguments := loadMain(782000)
+	// A chain shorter than the blocks kept for the reorgs, e.g. a new regtest chain, must not wrap the catch-up height.
+	for _, latestHeight := range []uint{stateless.ReorgDepth - 1, stateless.BRC20StartHeight - 2 + stateless.ReorgDepth} {
+		if _, err := CatchupStage(ordGetterTest, &arguments, stateless.BRC20StartHeight-1, latestHeight); err == nil {
+			t.Errorf("expected the catch-up to the latest height %d to fail", latestHeight)
+		}
+	}
+}
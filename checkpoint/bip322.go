@@ -0,0 +1,111 @@
+package checkpoint
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// The tag of the BIP-322 message hash.
+var bip322Tag = []byte("BIP0322-signed-message")
+
+// The max number of the witness items of a BIP-322 signature, far beyond a key-path spend.
+const maxWitnessItems = 16
+
+// bip322Transactions returns the virtual to_sign transaction spending the to_spend transaction of BIP-322,
+// which commits to the message and the scriptPubKey, along with the fetcher of the spent output.
+func bip322Transactions(pkScript, message []byte) (*wire.MsgTx, txscript.PrevOutputFetcher) {
+	messageHash := chainhash.TaggedHash(bip322Tag, message)
+	scriptSig, _ := txscript.NewScriptBuilder().AddOp(txscript.OP_0).AddData(messageHash[:]).Script()
+
+	toSpend := wire.NewMsgTx(0)
+	toSpend.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: wire.OutPoint{Index: wire.MaxPrevOutIndex},
+		SignatureScript:  scriptSig,
+		Sequence:         0,
+	})
+	toSpend.AddTxOut(wire.NewTxOut(0, pkScript))
+
+	toSign := wire.NewMsgTx(0)
+	toSign.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: wire.OutPoint{Hash: toSpend.TxHash(), Index: 0},
+		Sequence:         0,
+	})
+	toSign.AddTxOut(wire.NewTxOut(0, []byte{txscript.OP_RETURN}))
+	return toSign, txscript.NewCannedPrevOutputFetcher(pkScript, 0)
+}
+
+// taprootScript is the scriptPubKey of the P2TR address of the internal key without a script path, as of BIP-86.
+func taprootScript(internalKey *btcec.PublicKey) ([]byte, error) {
+	return txscript.PayToTaprootScript(txscript.ComputeTaprootKeyNoScript(internalKey))
+}
+
+// signBIP322 returns the consensus encoding of the witness of the BIP-322 simple signature by the P2TR address.
+func signBIP322(key *btcec.PrivateKey, message []byte) ([]byte, error) {
+	pkScript, err := taprootScript(key.PubKey())
+	if err != nil {
+		return nil, err
+	}
+	toSign, fetcher := bip322Transactions(pkScript, message)
+	witness, err := txscript.TaprootWitnessSignature(toSign, txscript.NewTxSigHashes(toSign, fetcher), 0, 0, pkScript,
+		txscript.SigHashDefault, key)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := wire.WriteVarInt(&buf, 0, uint64(len(witness))); err != nil {
+		return nil, err
+	}
+	for _, item := range witness {
+		if err := wire.WriteVarBytes(&buf, 0, item); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// verifyBIP322 checks the BIP-322 simple signature by executing the virtual transaction, as a wallet would.
+func verifyBIP322(publicKey, message, signature []byte) error {
+	internalKey, err := schnorr.ParsePubKey(publicKey)
+	if err != nil {
+		return fmt.Errorf("invalid public key: %v", err)
+	}
+	pkScript, err := taprootScript(internalKey)
+	if err != nil {
+		return err
+	}
+
+	r := bytes.NewReader(signature)
+	count, err := wire.ReadVarInt(r, 0)
+	if err != nil || count == 0 || count > maxWitnessItems {
+		return errors.New("invalid witness of the signature")
+	}
+	witness := make(wire.TxWitness, count)
+	for i := range witness {
+		witness[i], err = wire.ReadVarBytes(r, 0, txscript.MaxScriptSize, "witness item")
+		if err != nil {
+			return fmt.Errorf("invalid witness of the signature: %v", err)
+		}
+	}
+	if r.Len() != 0 {
+		return errors.New("invalid witness of the signature: trailing bytes")
+	}
+
+	toSign, fetcher := bip322Transactions(pkScript, message)
+	toSign.TxIn[0].Witness = witness
+	vm, err := txscript.NewEngine(pkScript, toSign, 0, txscript.StandardVerifyFlags, nil,
+		txscript.NewTxSigHashes(toSign, fetcher), 0, fetcher)
+	if err != nil {
+		return err
+	}
+	if err := vm.Execute(); err != nil {
+		return fmt.Errorf("invalid signature: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package checkpoint
+
+import (
+	"context"
+	"fmt"
+)
+
+// Divergence is the first divergent checkpoint between two members.
+type Divergence struct {
+	// The last common height at which the members commit the same state, 0 if they diverge at the first one.
+	AgreedHeight uint        `json:"agreedHeight"`
+	Agreed       *Checkpoint `json:"agreed,omitempty"`
+
+	// The first common height at which the members commit different states of the same block.
+	DivergedHeight uint       `json:"divergedHeight"`
+	A              Checkpoint `json:"a"`
+	B              Checkpoint `json:"b"`
+
+	// The number of heights compared to locate the divergence.
+	Probes int `json:"probes"`
+}
+
+// compare returns the checkpoints of both members for the same block at the height,
+// false if the members attest different blocks at the height, which can't be compared.
+func compare(ctx context.Context, a, b History, height uint) (*Checkpoint, *Checkpoint, bool, error) {
+	as, err := a.Checkpoints(ctx, height)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	bs, err := b.Checkpoints(ctx, height)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	for i := range as {
+		for j := range bs {
+			if as[i].Hash == bs[j].Hash {
+				return &as[i], &bs[j], true, nil
+			}
+		}
+	}
+	return nil, nil, false, nil
+}
+
+// Bisect binary searches the heights at which both members published checkpoints for the first divergent one.
+// Once diverged, the states never agree again, so the heights split into agreeing ones and diverged ones.
+// It returns nil if the members agree on all the comparable heights.
+func Bisect(ctx context.Context, a, b History) (*Divergence, error) {
+	ah, err := a.Heights(ctx)
+	if err != nil {
+		return nil, err
+	}
+	bh, err := b.Heights(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var heights []uint
+	for i, j := 0, 0; i < len(ah) && j < len(bh); {
+		switch {
+		case ah[i] < bh[j]:
+			i++
+		case ah[i] > bh[j]:
+			j++
+		default:
+			heights = append(heights, ah[i])
+			i++
+			j++
+		}
+	}
+
+	var res Divergence
+	// probe compares the first comparable height in heights[from:to].
+	probe := func(from, to int) (int, *Checkpoint, *Checkpoint, error) {
+		for k := from; k < to; k++ {
+			res.Probes++
+			ca, cb, ok, err := compare(ctx, a, b, heights[k])
+			if err != nil {
+				return 0, nil, nil, fmt.Errorf("failed to compare the checkpoints at %d: %v", heights[k], err)
+			}
+			if ok {
+				return k, ca, cb, nil
+			}
+		}
+		return -1, nil, nil, nil
+	}
+
+	// Invariant: heights[:lo] agree or can't be compared, the first divergent one is within heights[lo:hi] or
+	// is the recorded one at hi.
+	lo, hi := 0, len(heights)
+	var diverged *Checkpoint
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		k, ca, cb, err := probe(mid, hi)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case k < 0:
+			// Nothing within heights[mid:hi] can be compared.
+			hi = mid
+		case ca.Commitment == cb.Commitment:
+			lo = k + 1
+			res.AgreedHeight, res.Agreed = heights[k], ca
+		default:
+			hi = k
+			diverged = ca
+			res.DivergedHeight, res.A, res.B = heights[k], *ca, *cb
+		}
+	}
+	if diverged == nil {
+		return nil, nil
+	}
+	return &res, nil
+}
@@ -0,0 +1,68 @@
+package checkpoint
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+type memoryHistory map[uint][]Checkpoint
+
+func (h memoryHistory) Heights(context.Context) ([]uint, error) {
+	return sortedHeights(h), nil
+}
+
+func (h memoryHistory) Checkpoints(_ context.Context, height uint) ([]Checkpoint, error) {
+	return h[height], nil
+}
+
+func (h memoryHistory) add(height uint, hash, commitment string) {
+	h[height] = append(h[height], Checkpoint{Height: fmt.Sprint(height), Hash: hash, Commitment: commitment})
+}
+
+func TestBisect(t *testing.T) {
+	ctx := context.Background()
+	a, b := memoryHistory{}, memoryHistory{}
+	for height := uint(100); height < 1100; height++ {
+		a.add(height, fmt.Sprint("hash", height), fmt.Sprint("root", height))
+		if height%7 == 0 {
+			// The member b skips some heights.
+			continue
+		}
+		commitment := fmt.Sprint("root", height)
+		if height >= 733 {
+			commitment = fmt.Sprint("forged", height)
+		}
+		b.add(height, fmt.Sprint("hash", height), commitment)
+	}
+	// The members attest different blocks at 731 and 732, which can't be compared.
+	b[731][0].Hash, b[732][0].Hash = "fork731", "fork732"
+	// A reorg leaves two checkpoints at 800, one of which is for the same block.
+	b.add(800, "fork800", "forged800")
+
+	d, err := Bisect(ctx, a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d == nil || d.DivergedHeight != 733 || d.AgreedHeight != 730 || d.A.Commitment != "root733" || d.B.Commitment != "forged733" {
+		t.Fatalf("Unexpected divergence %+v", d)
+	}
+	if d.Probes > 40 {
+		t.Fatalf("Expected a binary search, got %d probes", d.Probes)
+	}
+
+	if d, err := Bisect(ctx, a, a); err != nil || d != nil {
+		t.Fatalf("Expected no divergence, got %+v: %v", d, err)
+	}
+
+	// Diverged from the first common height.
+	b[100][0].Commitment = "forged100"
+	for height := uint(101); height < 733; height++ {
+		if len(b[height]) != 0 {
+			b[height][0].Commitment = fmt.Sprint("forged", height)
+		}
+	}
+	if d, err := Bisect(ctx, a, b); err != nil || d == nil || d.DivergedHeight != 100 || d.Agreed != nil {
+		t.Fatalf("Unexpected divergence %+v: %v", d, err)
+	}
+}
@@ -0,0 +1,153 @@
+package checkpoint
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/RiemaLabs/modular-indexer-committee/internal/metrics"
+)
+
+// BudgetConfig caps the storage fee spent on the publications to the DA layer.
+type BudgetConfig struct {
+	// The max storage fee spent in a calendar month (UTC), 0 disables the cap.
+	Monthly uint64 `json:"monthly"`
+	// The estimated fee of a publication above which the fees are spiking, 0 never considers them spiking.
+	SpikeFee uint64 `json:"spikeFee"`
+	// The max number of blocks the non-final checkpoints are delayed while the fees spike.
+	MaxDelay uint `json:"maxDelay"`
+	// The file keeping the spend of the month across restarts, empty keeps it in memory only.
+	LedgerPath string `json:"ledgerPath"`
+}
+
+func (cfg BudgetConfig) Enabled() bool {
+	return cfg.Monthly != 0 || cfg.SpikeFee != 0
+}
+
+type Decision string
+
+const (
+	DecisionPublish Decision = "published"
+	// Held back until the fees drop or the delay expires.
+	DecisionDelay Decision = "delayed"
+	// Superseded by a later checkpoint published in its place, so it's never published.
+	DecisionCoalesce Decision = "coalesced"
+	// Held back until the next month.
+	DecisionOverBudget Decision = "over_budget"
+)
+
+type ledger struct {
+	Month string `json:"month"`
+	Spent uint64 `json:"spent"`
+}
+
+// Budget decides which due checkpoints are published with the estimated fee.
+// The final checkpoints are published as long as the budget allows, while the non-final ones are delayed
+// when the fees spike; once delayed for too long, only the latest of them is published, standing for the others.
+type Budget struct {
+	cfg BudgetConfig
+	now func() time.Time
+
+	mu     sync.Mutex
+	ledger ledger
+	// The height since which the non-final checkpoints are delayed, 0 if none is delayed.
+	delayedSince uint
+}
+
+func NewBudget(cfg BudgetConfig) (*Budget, error) {
+	b := Budget{cfg: cfg, now: time.Now}
+	if cfg.LedgerPath != "" {
+		bytes, err := os.ReadFile(cfg.LedgerPath)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		if err == nil {
+			if err := json.Unmarshal(bytes, &b.ledger); err != nil {
+				return nil, fmt.Errorf("invalid DA ledger %s: %v", cfg.LedgerPath, err)
+			}
+		}
+	}
+	metrics.DABudget.Set(float64(cfg.Monthly))
+	return &b, nil
+}
+
+// rollover resets the spend at the start of a month.
+func (b *Budget) rollover() {
+	month := b.now().UTC().Format("2006-01")
+	if b.ledger.Month != month {
+		b.ledger = ledger{Month: month}
+	}
+	metrics.DASpent.Set(float64(b.ledger.Spent))
+}
+
+// Spent returns the storage fee spent in the current month.
+func (b *Budget) Spent() uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.rollover()
+	return b.ledger.Spent
+}
+
+// Plan decides the checkpoints at the heights in the ascending order, each published at the estimated fee.
+// A checkpoint is final once it's at least finalDepth blocks deep.
+func (b *Budget) Plan(heights []uint, latestHeight, finalDepth uint, fee uint64) []Decision {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.rollover()
+	metrics.DAFeeEstimate.Set(float64(fee))
+
+	spiking := b.cfg.SpikeFee != 0 && fee > b.cfg.SpikeFee
+	latestNonFinal := -1
+	for i, height := range heights {
+		if height+finalDepth > latestHeight {
+			latestNonFinal = i
+		}
+	}
+	if !spiking {
+		b.delayedSince = 0
+	} else if latestNonFinal >= 0 && b.delayedSince == 0 {
+		b.delayedSince = latestHeight
+	}
+
+	spent := b.ledger.Spent
+	decisions := make([]Decision, len(heights))
+	for i, height := range heights {
+		final := height+finalDepth <= latestHeight
+		switch {
+		case spiking && !final && latestHeight < b.delayedSince+b.cfg.MaxDelay:
+			decisions[i] = DecisionDelay
+		case spiking && !final && i != latestNonFinal:
+			decisions[i] = DecisionCoalesce
+		case b.cfg.Monthly != 0 && spent+fee > b.cfg.Monthly:
+			decisions[i] = DecisionOverBudget
+		default:
+			decisions[i] = DecisionPublish
+			spent += fee
+			if !final {
+				b.delayedSince = 0
+			}
+		}
+		metrics.DAPublications.WithLabelValues(string(decisions[i])).Inc()
+	}
+	return decisions
+}
+
+// Spend records the fee of a publication.
+func (b *Budget) Spend(fee uint64) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.rollover()
+	b.ledger.Spent += fee
+	metrics.DASpent.Set(float64(b.ledger.Spent))
+	if b.cfg.LedgerPath == "" {
+		return nil
+	}
+	bytes, err := json.Marshal(b.ledger)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(b.cfg.LedgerPath, bytes, 0644)
+}
@@ -0,0 +1,57 @@
+package checkpoint
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBudget(t *testing.T) {
+	ledgerPath := filepath.Join(t.TempDir(), "ledger.json")
+	cfg := BudgetConfig{Monthly: 100, SpikeFee: 20, MaxDelay: 3, LedgerPath: ledgerPath}
+	b, err := NewBudget(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	month := time.Date(2024, 5, 31, 12, 0, 0, 0, time.UTC)
+	b.now = func() time.Time { return month }
+
+	// 100 is final, 105 and 106 aren't.
+	heights := []uint{100, 105, 106}
+	if got := fmt.Sprint(b.Plan(heights, 106, 6, 10)); got != "[published published published]" {
+		t.Fatalf("Expected all published at a normal fee, got %s", got)
+	}
+	// While the fees spike, the non-final checkpoints are delayed, then coalesced into the latest one.
+	if got := fmt.Sprint(b.Plan(heights, 106, 6, 30)); got != "[published delayed delayed]" {
+		t.Fatalf("Unexpected decisions at a spiking fee: %s", got)
+	}
+	if got := fmt.Sprint(b.Plan(heights, 108, 6, 30)); got != "[published delayed delayed]" {
+		t.Fatalf("Unexpected decisions within the delay: %s", got)
+	}
+	if got := fmt.Sprint(b.Plan(heights, 109, 6, 30)); got != "[published coalesced published]" {
+		t.Fatalf("Unexpected decisions after the delay: %s", got)
+	}
+
+	// The spend caps the publications of the month and survives restarts.
+	for range 3 {
+		if err := b.Spend(30); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := fmt.Sprint(b.Plan(heights, 106, 6, 10)); got != "[published over_budget over_budget]" {
+		t.Fatalf("Unexpected decisions near the cap: %s", got)
+	}
+	restarted, err := NewBudget(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restarted.now = b.now
+	if restarted.Spent() != 90 {
+		t.Fatalf("Expected the spend to be restored, got %d", restarted.Spent())
+	}
+	month = month.Add(24 * time.Hour)
+	if restarted.Spent() != 0 {
+		t.Fatalf("Expected the spend to be reset in the next month, got %d", restarted.Spent())
+	}
+}
@@ -0,0 +1,142 @@
+package checkpoint
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// History is the checkpoints published by a member.
+type History interface {
+	// Heights returns the heights of the published checkpoints in the ascending order.
+	Heights(ctx context.Context) ([]uint, error)
+	// Checkpoints returns the checkpoints published at the height, one per block hash.
+	Checkpoints(ctx context.Context, height uint) ([]Checkpoint, error)
+}
+
+func sortedHeights[T any](m map[uint]T) []uint {
+	heights := make([]uint, 0, len(m))
+	for height := range m {
+		heights = append(heights, height)
+	}
+	sort.Slice(heights, func(i, j int) bool { return heights[i] < heights[j] })
+	return heights
+}
+
+// DirHistory is the checkpoints of a member saved as JSON files in a directory, e.g. downloaded from S3 or DA.
+type DirHistory struct {
+	checkpoints map[uint][]Checkpoint
+}
+
+func NewDirHistory(dir string) (*DirHistory, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	h := DirHistory{checkpoints: make(map[uint][]Checkpoint)}
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != ".json" {
+			continue
+		}
+		bytes, err := os.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		c, err := ParseCheckpoint(bytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid checkpoint %s: %v", file.Name(), err)
+		}
+		height, err := strconv.ParseUint(c.Height, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid height of the checkpoint %s: %v", file.Name(), err)
+		}
+		h.checkpoints[uint(height)] = append(h.checkpoints[uint(height)], *c)
+	}
+	return &h, nil
+}
+
+func (h *DirHistory) Heights(context.Context) ([]uint, error) {
+	return sortedHeights(h.checkpoints), nil
+}
+
+func (h *DirHistory) Checkpoints(_ context.Context, height uint) ([]Checkpoint, error) {
+	return h.checkpoints[height], nil
+}
+
+// S3History is the checkpoints of a member uploaded to an S3 bucket, which are only fetched when compared.
+type S3History struct {
+	client *s3.Client
+	bucket string
+	keys   map[uint][]string
+}
+
+// NewS3History lists the checkpoints uploaded by the indexer of the name for the meta protocol.
+// The endpoint is of another S3-compatible object store, empty for AWS S3.
+func NewS3History(ctx context.Context, accessKey, secretKey, region, endpoint, bucket, name, metaProtocol string) (*S3History, error) {
+	client, err := newS3Client(ctx, accessKey, secretKey, region, endpoint)
+	if err != nil {
+		return nil, err
+	}
+	h := S3History{client: client, bucket: bucket, keys: make(map[uint][]string)}
+	// The object keys are checkpoint-<name>-<meta protocol>-<height>-<hash>.json, see ObjectKey.
+	prefix := fmt.Sprintf("checkpoint-%s-%s-", name, metaProtocol)
+	paginator := s3.NewListObjectsV2Paginator(h.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(bucket),
+		Prefix: aws.String(prefix),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, object := range page.Contents {
+			key := aws.ToString(object.Key)
+			parts := strings.Split(strings.TrimSuffix(strings.TrimPrefix(key, prefix), ".json"), "-")
+			if len(parts) != 2 {
+				continue
+			}
+			height, err := strconv.ParseUint(parts[0], 10, 64)
+			if err != nil {
+				continue
+			}
+			h.keys[uint(height)] = append(h.keys[uint(height)], key)
+		}
+	}
+	return &h, nil
+}
+
+func (h *S3History) Heights(context.Context) ([]uint, error) {
+	return sortedHeights(h.keys), nil
+}
+
+func (h *S3History) Checkpoints(ctx context.Context, height uint) ([]Checkpoint, error) {
+	var res []Checkpoint
+	for _, key := range h.keys[height] {
+		out, err := h.client.GetObject(ctx, &s3.GetObjectInput{
+			Bucket: aws.String(h.bucket),
+			Key:    aws.String(key),
+		})
+		if err != nil {
+			return nil, err
+		}
+		bytes, err := io.ReadAll(out.Body)
+		out.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		c, err := ParseCheckpoint(bytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid checkpoint %s: %v", key, err)
+		}
+		res = append(res, *c)
+	}
+	return res, nil
+}
@@ -0,0 +1,105 @@
+package checkpoint
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// Publication is a checkpoint published by the member, along with where it's found.
+type Publication struct {
+	Height     uint   `json:"height"`
+	Hash       string `json:"hash"`
+	Commitment string `json:"commitment"`
+	Method     string `json:"method"`
+	Receipt
+	PublishedAt time.Time `json:"publishedAt"`
+}
+
+// Key layout of the ledger, where the heights and the times are big-endian uint64
+// Publication: "p" + height + the unix nanoseconds of the publication + method, Value: the JSON of the Publication
+var publicationPrefix = []byte("p")
+
+// Ledger keeps every checkpoint published by the member in a LevelDB database, so that the auditors read the
+// publication record of the member instead of crawling the DA layer. The republished checkpoints and the checkpoints
+// of the reorganized blocks are kept as well.
+type Ledger struct {
+	db *leveldb.DB
+}
+
+func OpenLedger(path string) (*Ledger, error) {
+	db, err := leveldb.OpenFile(path, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &Ledger{db: db}, nil
+}
+
+func (l *Ledger) Close() error {
+	return l.db.Close()
+}
+
+func publicationKey(height uint, at time.Time, method string) []byte {
+	key := append([]byte(nil), publicationPrefix...)
+	key = binary.BigEndian.AppendUint64(key, uint64(height))
+	key = binary.BigEndian.AppendUint64(key, uint64(at.UnixNano()))
+	return append(key, method...)
+}
+
+// Record keeps the publication of the checkpoint by the method.
+func (l *Ledger) Record(c *Checkpoint, method string, receipt Receipt, at time.Time) error {
+	height, err := strconv.ParseUint(c.Height, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid height of the checkpoint %s: %v", c.Height, err)
+	}
+	p := Publication{
+		Height:      uint(height),
+		Hash:        c.Hash,
+		Commitment:  c.Commitment,
+		Method:      method,
+		Receipt:     receipt,
+		PublishedAt: at.UTC(),
+	}
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	return l.db.Put(publicationKey(p.Height, at, method), data, nil)
+}
+
+// Latest returns the height of the latest published checkpoint, false if none is published.
+func (l *Ledger) Latest() (uint, bool, error) {
+	iter := l.db.NewIterator(util.BytesPrefix(publicationPrefix), nil)
+	defer iter.Release()
+	if !iter.Last() {
+		return 0, false, iter.Error()
+	}
+	return uint(binary.BigEndian.Uint64(iter.Key()[len(publicationPrefix):])), true, nil
+}
+
+// Publications returns the publications of the checkpoints from the height from to the height to, in the order of
+// the heights and of the times of the publications.
+func (l *Ledger) Publications(from, to uint) ([]Publication, error) {
+	start := binary.BigEndian.AppendUint64(append([]byte(nil), publicationPrefix...), uint64(from))
+	limit := util.BytesPrefix(publicationPrefix).Limit
+	if uint64(to) != math.MaxUint64 {
+		limit = binary.BigEndian.AppendUint64(append([]byte(nil), publicationPrefix...), uint64(to)+1)
+	}
+	iter := l.db.NewIterator(&util.Range{Start: start, Limit: limit}, nil)
+	defer iter.Release()
+	publications := make([]Publication, 0)
+	for iter.Next() {
+		var p Publication
+		if err := json.Unmarshal(iter.Value(), &p); err != nil {
+			return nil, fmt.Errorf("invalid publication %x: %v", iter.Key(), err)
+		}
+		publications = append(publications, p)
+	}
+	return publications, iter.Error()
+}
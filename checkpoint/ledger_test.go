@@ -0,0 +1,59 @@
+package checkpoint
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLedger(t *testing.T) {
+	l, err := OpenLedger(filepath.Join(t.TempDir(), "ledger"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	if _, found, err := l.Latest(); err != nil || found {
+		t.Fatalf("Expected no publication, got %v", err)
+	}
+
+	indexerID := IndexerIdentification{URL: "https://committee.example", Name: "committee", Version: "v1", MetaProtocol: "brc-20"}
+	at := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	c1 := NewCheckpoint(&indexerID, 780000, "00000000000000000001", "commitment1")
+	c2 := NewCheckpoint(&indexerID, 780001, "00000000000000000002", "commitment2")
+	records := []struct {
+		c       Checkpoint
+		method  string
+		receipt Receipt
+	}{
+		{c2, "DA", Receipt{Namespace: "0x00000001", TxID: "tx2"}},
+		{c1, "DA", Receipt{Namespace: "0x00000001", TxID: "tx1"}},
+		{c1, "S3", Receipt{Location: "s3://bucket/" + ObjectKey(&c1)}},
+		// The republished checkpoint is kept along with the previous publication.
+		{c1, "DA", Receipt{Namespace: "0x00000001", TxID: "tx1b"}},
+	}
+	for i, r := range records {
+		if err := l.Record(&r.c, r.method, r.receipt, at.Add(time.Duration(i)*time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if latest, found, err := l.Latest(); err != nil || !found || latest != 780001 {
+		t.Fatalf("Unexpected latest height %d, %v", latest, err)
+	}
+	ps, err := l.Publications(780000, 780000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ps) != 3 || ps[0].TxID != "tx1" || ps[1].Method != "S3" || ps[2].TxID != "tx1b" {
+		t.Fatalf("Unexpected publications %+v", ps)
+	}
+	if ps[0].Height != 780000 || ps[0].Hash != c1.Hash || ps[0].Commitment != "commitment1" || !ps[0].PublishedAt.Equal(at.Add(time.Minute)) {
+		t.Fatalf("Unexpected publication %+v", ps[0])
+	}
+	if ps, err := l.Publications(780000, ^uint(0)); err != nil || len(ps) != 4 || ps[3].TxID != "tx2" {
+		t.Fatalf("Unexpected publications %+v, %v", ps, err)
+	}
+	if ps, err := l.Publications(780002, 780010); err != nil || len(ps) != 0 {
+		t.Fatalf("Expected no publication, got %+v, %v", ps, err)
+	}
+}
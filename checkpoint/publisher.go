@@ -0,0 +1,292 @@
+package checkpoint
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/RiemaLabs/nubit-da-sdk/constant"
+	"github.com/RiemaLabs/nubit-da-sdk/types"
+	"github.com/RiemaLabs/nubit-da-sdk/utils"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"github.com/RiemaLabs/modular-indexer-committee/internal/retry"
+)
+
+// Publisher publishes the checkpoints of the indexer to where the other members and the clients read them.
+type Publisher interface {
+	// Method is the name of the publication method, e.g. DA, which labels the logs and the metrics.
+	Method() string
+	Publish(ctx context.Context, c *Checkpoint) error
+}
+
+// FeePublisher is implemented by the Publisher charging a storage fee per checkpoint, which the budget is spent on.
+type FeePublisher interface {
+	Publisher
+	EstimateFee(ctx context.Context, c *Checkpoint) (uint64, error)
+	// PublishAtFee publishes the checkpoint at the storage fee, 0 pays the estimated fee.
+	PublishAtFee(ctx context.Context, c *Checkpoint, fee uint64) error
+}
+
+// Receipt tells where a checkpoint is published, which the Ledger records.
+type Receipt struct {
+	// The namespace and the transaction of the DA layer.
+	Namespace string `json:"namespace,omitempty"`
+	TxID      string `json:"txID,omitempty"`
+	// Where the checkpoint is found otherwise, e.g. the object of S3, the file or the URL of the collector.
+	Location string `json:"location,omitempty"`
+}
+
+// ReceiptPublisher is implemented by the Publisher telling where it published a checkpoint.
+type ReceiptPublisher interface {
+	Publisher
+	Receipt(c *Checkpoint) Receipt
+}
+
+// ObjectKey is the name of the checkpoint as an object or a file: checkpoint-<name>-<meta protocol>-<height>-<hash>.json.
+func ObjectKey(c *Checkpoint) string {
+	return fmt.Sprintf("checkpoint-%s-%s-%s-%s.json", c.Name, c.MetaProtocol, c.Height, c.Hash)
+}
+
+// DAPublisher uploads the checkpoints to a namespace of the Nubit DA layer.
+type DAPublisher struct {
+	Network     string
+	NamespaceID string
+	GasCoupon   string
+	PrivateKey  string
+	// The retries of the failed calls to the DA layer, nil calls it once.
+	Breaker *retry.Breaker
+
+	mu sync.Mutex
+	// The transactions of the published checkpoints by their object keys.
+	txIDs map[string]string
+}
+
+func (p *DAPublisher) Method() string {
+	return "DA"
+}
+
+func (p *DAPublisher) Publish(ctx context.Context, c *Checkpoint) error {
+	return p.PublishAtFee(ctx, c, 0)
+}
+
+func (p *DAPublisher) EstimateFee(ctx context.Context, c *Checkpoint) (uint64, error) {
+	var fee uint64
+	err := p.Breaker.Do(ctx, func() (err error) {
+		fee, err = p.estimateFee(ctx, c)
+		return err
+	})
+	return fee, err
+}
+
+func (p *DAPublisher) estimateFee(ctx context.Context, c *Checkpoint) (uint64, error) {
+	clientDA, err := newDAClient(ctx, p.PrivateKey, p.GasCoupon, p.Network)
+	if err != nil {
+		return 0, err
+	}
+
+	checkpointJSON, err := json.Marshal(c)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal checkpoint to JSON: %v", err)
+	}
+
+	req := &types.DataUploadReq{
+		NID:        p.NamespaceID,
+		From:       utils.PrivateStrToBtcAddress(p.PrivateKey),
+		RawData:    base64.StdEncoding.EncodeToString(checkpointJSON),
+		Labels:     checkpointLabels,
+		MethodName: constant.DataUpload,
+	}
+	fee, err := clientDA.GetEstimateFee(req, constant.DataUpload, p.NamespaceID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to estimate the fee: %v", err)
+	}
+	return uint64(fee.StorageFee), nil
+}
+
+func (p *DAPublisher) PublishAtFee(ctx context.Context, c *Checkpoint, fee uint64) error {
+	return p.Breaker.Do(ctx, func() error {
+		return p.publishAtFee(ctx, c, fee)
+	})
+}
+
+func (p *DAPublisher) publishAtFee(ctx context.Context, c *Checkpoint, fee uint64) error {
+	clientDA, err := newDAClient(ctx, p.PrivateKey, p.GasCoupon, p.Network)
+	if err != nil {
+		return err
+	}
+
+	checkpointJSON, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("failed to marshal checkpoint to JSON: %v", err)
+	}
+
+	rsp, err := clientDA.UploadBytes(checkpointJSON, p.NamespaceID, fee, checkpointLabels)
+	if err != nil {
+		return fmt.Errorf("failed to upload checkpoint: %v", err)
+	}
+	if rsp != nil {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if p.txIDs == nil {
+			p.txIDs = make(map[string]string)
+		}
+		p.txIDs[ObjectKey(c)] = rsp.TxID
+	}
+	return nil
+}
+
+func (p *DAPublisher) Receipt(c *Checkpoint) Receipt {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return Receipt{Namespace: p.NamespaceID, TxID: p.txIDs[ObjectKey(c)]}
+}
+
+// S3Publisher uploads the checkpoints to a bucket of S3 or of another S3-compatible object store.
+type S3Publisher struct {
+	Bucket string
+	Region string
+	// The endpoint of another S3-compatible object store, empty for AWS S3.
+	Endpoint  string
+	AccessKey string
+	SecretKey string
+}
+
+func (p *S3Publisher) Method() string {
+	return "S3"
+}
+
+func (p *S3Publisher) Publish(ctx context.Context, c *Checkpoint) error {
+	client, err := newS3Client(ctx, p.AccessKey, p.SecretKey, p.Region, p.Endpoint)
+	if err != nil {
+		return err
+	}
+	checkpointJSON, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	// The uploader may not return as soon as the context is done, so it isn't waited for beyond the deadline.
+	done := make(chan error, 1)
+	go func() {
+		_, err := manager.NewUploader(client).Upload(ctx, &s3.PutObjectInput{
+			Bucket: aws.String(p.Bucket),
+			Key:    aws.String(ObjectKey(c)),
+			Body:   bytes.NewReader(checkpointJSON),
+		})
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *S3Publisher) Receipt(c *Checkpoint) Receipt {
+	return Receipt{Location: fmt.Sprintf("s3://%s/%s", p.Bucket, ObjectKey(c))}
+}
+
+func newS3Client(ctx context.Context, accessKey, secretKey, region, endpoint string) (*s3.Client, error) {
+	cfg, err := config.LoadDefaultConfig(ctx,
+		config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(accessKey, secretKey, "")),
+		config.WithRegion(region),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create aws config, error: %v", err)
+	}
+	return s3.NewFromConfig(cfg, func(o *s3.Options) {
+		if endpoint != "" {
+			o.BaseEndpoint = aws.String(endpoint)
+			o.UsePathStyle = true
+		}
+	}), nil
+}
+
+// LocalPublisher saves the checkpoints as JSON files in a directory, which NewDirHistory reads back.
+// It needs neither a wallet nor a bucket, e.g. for running a member during testing.
+type LocalPublisher struct {
+	Dir string
+}
+
+func (p *LocalPublisher) Method() string {
+	return "Local"
+}
+
+func (p *LocalPublisher) Publish(_ context.Context, c *Checkpoint) error {
+	checkpointJSON, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(p.Dir, 0755); err != nil {
+		return err
+	}
+	// The file is renamed into place, so that a reader never sees a partial checkpoint.
+	path := filepath.Join(p.Dir, ObjectKey(c))
+	if err := os.WriteFile(path+".tmp", checkpointJSON, 0644); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+func (p *LocalPublisher) Receipt(c *Checkpoint) Receipt {
+	return Receipt{Location: filepath.Join(p.Dir, ObjectKey(c))}
+}
+
+// HTTPPublisher posts the checkpoints as JSON to the URL of a collector, which replies a 2xx status if accepted.
+type HTTPPublisher struct {
+	URL string
+	// The headers of the requests, e.g. the authorization of the collector.
+	Headers map[string]string
+	Client  *http.Client
+}
+
+func (p *HTTPPublisher) Method() string {
+	return "HTTP"
+}
+
+func (p *HTTPPublisher) Publish(ctx context.Context, c *Checkpoint) error {
+	checkpointJSON, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.URL, bytes.NewReader(checkpointJSON))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range p.Headers {
+		req.Header.Set(name, value)
+	}
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("the collector replied %s", resp.Status)
+	}
+	return nil
+}
+
+func (p *HTTPPublisher) Receipt(*Checkpoint) Receipt {
+	return Receipt{Location: p.URL}
+}
@@ -0,0 +1,138 @@
+package checkpoint
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"testing"
+
+	datypes "github.com/RiemaLabs/nubit-da-sdk/types"
+)
+
+func TestLocalPublisher(t *testing.T) {
+	indexerID := IndexerIdentification{URL: "https://committee.example", Name: "committee", Version: "v1", MetaProtocol: "brc-20"}
+	dir := t.TempDir()
+	p := LocalPublisher{Dir: dir}
+	for _, height := range []uint{780000, 780001} {
+		c := NewCheckpoint(&indexerID, height, "00000000000000000002", "commitment")
+		if err := p.Publish(context.Background(), &c); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.Stat(p.Receipt(&c).Location); err != nil {
+			t.Fatalf("Expected the receipt to locate the checkpoint: %v", err)
+		}
+	}
+
+	h, err := NewDirHistory(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	heights, _ := h.Heights(context.Background())
+	if len(heights) != 2 || heights[0] != 780000 || heights[1] != 780001 {
+		t.Fatalf("Unexpected heights of the published checkpoints %v", heights)
+	}
+	cs, _ := h.Checkpoints(context.Background(), 780001)
+	if len(cs) != 1 || cs[0].Commitment != "commitment" {
+		t.Fatalf("Unexpected checkpoints %v", cs)
+	}
+}
+
+func TestHTTPPublisher(t *testing.T) {
+	var received Checkpoint
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	indexerID := IndexerIdentification{URL: "https://committee.example", Name: "committee", Version: "v1", MetaProtocol: "brc-20"}
+	c := NewCheckpoint(&indexerID, 780000, "00000000000000000002", "commitment")
+	p := HTTPPublisher{URL: srv.URL, Headers: map[string]string{"Authorization": "Bearer token"}}
+	if err := p.Publish(context.Background(), &c); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(received, c) {
+		t.Fatalf("Unexpected checkpoint received by the collector %v", received)
+	}
+
+	p.Headers = nil
+	if err := p.Publish(context.Background(), &c); err == nil {
+		t.Fatal("Expected the rejected checkpoint to fail")
+	}
+}
+
+// namespaceReader serves the data uploaded to a namespace in the order of the uploads.
+type namespaceReader struct {
+	data  []string
+	reads int
+}
+
+func (r *namespaceReader) GetDataInNamespace(_ context.Context, req *datypes.GetDataInNamespaceReq) (*datypes.GetDataInNamespaceRsp, error) {
+	rsp := &datypes.GetDataInNamespaceRsp{}
+	for i := req.Offset; i < len(r.data) && i < req.Offset+req.Limit; i++ {
+		rsp.DataIDs = append(rsp.DataIDs, fmt.Sprint(i))
+	}
+	return rsp, nil
+}
+
+func (r *namespaceReader) GetData(_ context.Context, req *datypes.GetDataReq) (*datypes.GetDataRsp, error) {
+	var i int
+	fmt.Sscan(req.DAID, &i)
+	r.reads++
+	return &datypes.GetDataRsp{DataID: req.DAID, RawData: r.data[i]}, nil
+}
+
+func TestDASource(t *testing.T) {
+	indexerID := IndexerIdentification{URL: "https://committee.example", Name: "committee", Version: "v1", MetaProtocol: "brc-20"}
+	reader := &namespaceReader{data: []string{base64.StdEncoding.EncodeToString([]byte("not a checkpoint"))}}
+	upload := func(height uint) Checkpoint {
+		c := NewCheckpoint(&indexerID, height, "00000000000000000002", fmt.Sprintf("commitment%d", height))
+		data, _ := json.Marshal(c)
+		reader.data = append(reader.data, base64.StdEncoding.EncodeToString(data))
+		return c
+	}
+	for height := uint(780000); height < 780000+daPageSize; height++ {
+		upload(height)
+	}
+	s := &DASource{NamespaceID: "0x1", reader: reader, checkpoints: make(map[string]*Checkpoint), copies: make(map[string]int)}
+	ctx := context.Background()
+
+	// The checkpoints are found across the pages of the namespace, which are only scanned once.
+	last := upload(780000 + daPageSize)
+	c, err := s.Get(ctx, ObjectKey(&last))
+	if err != nil || !reflect.DeepEqual(*c, last) {
+		t.Fatalf("Unexpected checkpoint %v: %v", c, err)
+	}
+	first := NewCheckpoint(&indexerID, 780000, "00000000000000000002", "commitment780000")
+	if c, err := s.Get(ctx, ObjectKey(&first)); err != nil || c.Commitment != "commitment780000" {
+		t.Fatalf("Unexpected checkpoint %v: %v", c, err)
+	}
+	if reader.reads != len(reader.data) {
+		t.Fatalf("Read %d data of the %d in the namespace", reader.reads, len(reader.data))
+	}
+
+	// A miss scans the data uploaded since.
+	missing := NewCheckpoint(&indexerID, 790000, "00000000000000000002", "commitment790000")
+	if _, err := s.Get(ctx, ObjectKey(&missing)); err != ErrNotPublished {
+		t.Fatalf("Expected the missing checkpoint not to be published: %v", err)
+	}
+	// The checkpoint published twice is counted.
+	next := upload(790000)
+	upload(790000)
+	if c, err := s.Get(ctx, ObjectKey(&next)); err != nil || !reflect.DeepEqual(*c, next) || reader.reads != len(reader.data) {
+		t.Fatalf("Unexpected checkpoint %v after %d reads: %v", c, reader.reads, err)
+	}
+	if s.Copies(ObjectKey(&next)) != 2 || s.Copies(ObjectKey(&first)) != 1 {
+		t.Fatalf("Unexpected copies %d and %d", s.Copies(ObjectKey(&next)), s.Copies(ObjectKey(&first)))
+	}
+}
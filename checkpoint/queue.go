@@ -0,0 +1,197 @@
+package checkpoint
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/RiemaLabs/modular-indexer-committee/internal/metrics"
+)
+
+// The default delays before retrying a target, doubled by every consecutive failure up to the max.
+const (
+	DefaultBackoff    = 5 * time.Second
+	DefaultMaxBackoff = 10 * time.Minute
+)
+
+// The number of the latest checkpoints remembered as queued by each target, so that a restart doesn't queue them again.
+const queuedLimit = 1024
+
+// QueueConfig keeps the checkpoints not published yet, so that a failing target retries them later.
+type QueueConfig struct {
+	// The file keeping the pending checkpoints across restarts, empty keeps them in memory only.
+	Path string `json:"path"`
+	// The delay in seconds before the first retry of a failing target (default 5).
+	Backoff int `json:"backoff"`
+	// The max delay in seconds between the retries of a failing target (default 600).
+	MaxBackoff int `json:"maxBackoff"`
+}
+
+// Pending is a checkpoint waiting to be published to a target.
+type Pending struct {
+	Checkpoint Checkpoint `json:"checkpoint"`
+	// The storage fee planned by the budget, 0 pays the estimated fee.
+	Fee uint64 `json:"fee,omitempty"`
+}
+
+type target struct {
+	Pending []Pending `json:"pending"`
+	// The number of the consecutive failures, and the time before which the target isn't retried.
+	Failures  int       `json:"failures"`
+	NextRetry time.Time `json:"nextRetry"`
+	LastError string    `json:"lastError,omitempty"`
+	// The latest checkpoints ever queued, by their heights and hashes.
+	Queued []string `json:"queued,omitempty"`
+}
+
+func queuedKey(height, hash string) string {
+	return height + ":" + hash
+}
+
+// Queue holds the checkpoints to publish by each target, with the retry state of each target independent of the
+// others, so that an outage of one destination neither blocks the others nor loses its checkpoints.
+type Queue struct {
+	cfg QueueConfig
+	now func() time.Time
+
+	mu      sync.Mutex
+	targets map[string]*target
+}
+
+func NewQueue(cfg QueueConfig) (*Queue, error) {
+	q := Queue{cfg: cfg, now: time.Now, targets: make(map[string]*target)}
+	if cfg.Path != "" {
+		bytes, err := os.ReadFile(cfg.Path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		if err == nil {
+			if err := json.Unmarshal(bytes, &q.targets); err != nil {
+				return nil, fmt.Errorf("invalid checkpoint queue %s: %v", cfg.Path, err)
+			}
+		}
+	}
+	for method, t := range q.targets {
+		metrics.CheckpointQueue.WithLabelValues(method).Set(float64(len(t.Pending)))
+	}
+	return &q, nil
+}
+
+func (q *Queue) target(method string) *target {
+	t, found := q.targets[method]
+	if !found {
+		t = &target{}
+		q.targets[method] = t
+	}
+	return t
+}
+
+// save persists the queue, unless it's kept in memory only. The file is renamed into place, so that a crash never
+// leaves it truncated.
+func (q *Queue) save(method string) error {
+	metrics.CheckpointQueue.WithLabelValues(method).Set(float64(len(q.target(method).Pending)))
+	if q.cfg.Path == "" {
+		return nil
+	}
+	bytes, err := json.Marshal(q.targets)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(q.cfg.Path+".tmp", bytes, 0644); err != nil {
+		return err
+	}
+	return os.Rename(q.cfg.Path+".tmp", q.cfg.Path)
+}
+
+// Persisted tells whether the pending checkpoints are kept across restarts.
+func (q *Queue) Persisted() bool {
+	return q.cfg.Path != ""
+}
+
+// Push queues the checkpoint for the target, unless it's already queued.
+func (q *Queue) Push(method string, c Checkpoint, fee uint64) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	t := q.target(method)
+	for _, p := range t.Pending {
+		if p.Checkpoint.Height == c.Height && p.Checkpoint.Hash == c.Hash {
+			return nil
+		}
+	}
+	t.Pending = append(t.Pending, Pending{Checkpoint: c, Fee: fee})
+	if key := queuedKey(c.Height, c.Hash); !slices.Contains(t.Queued, key) {
+		t.Queued = append(t.Queued, key)
+		if len(t.Queued) > queuedLimit {
+			t.Queued = t.Queued[len(t.Queued)-queuedLimit:]
+		}
+	}
+	return q.save(method)
+}
+
+// Queued tells whether the checkpoint at the height and the hash has been queued for the target, even if it has been
+// published since. Only the latest checkpoints are remembered, which survive the restarts if the queue is persisted.
+func (q *Queue) Queued(method string, height string, hash string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return slices.Contains(q.target(method).Queued, queuedKey(height, hash))
+}
+
+// Due returns the queued checkpoints of the target in the order they were pushed,
+// or none if the target is waiting to be retried.
+func (q *Queue) Due(method string) []Pending {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	t := q.target(method)
+	if q.now().Before(t.NextRetry) {
+		return nil
+	}
+	return append([]Pending(nil), t.Pending...)
+}
+
+// Len returns the number of the queued checkpoints of the target.
+func (q *Queue) Len(method string) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.target(method).Pending)
+}
+
+// Done removes the published checkpoint and resets the retry state of the target.
+func (q *Queue) Done(method string, c *Checkpoint) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	t := q.target(method)
+	for i, p := range t.Pending {
+		if p.Checkpoint.Height == c.Height && p.Checkpoint.Hash == c.Hash {
+			t.Pending = append(t.Pending[:i], t.Pending[i+1:]...)
+			break
+		}
+	}
+	t.Failures, t.NextRetry, t.LastError = 0, time.Time{}, ""
+	return q.save(method)
+}
+
+// Fail delays the next retry of the target by the backoff, doubled by every consecutive failure.
+// It returns the time of the next retry.
+func (q *Queue) Fail(method string, err error) (time.Time, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	backoff, maxBackoff := DefaultBackoff, DefaultMaxBackoff
+	if q.cfg.Backoff > 0 {
+		backoff = time.Duration(q.cfg.Backoff) * time.Second
+	}
+	if q.cfg.MaxBackoff > 0 {
+		maxBackoff = time.Duration(q.cfg.MaxBackoff) * time.Second
+	}
+	t := q.target(method)
+	t.Failures++
+	for i := 1; i < t.Failures && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	backoff = min(backoff, maxBackoff)
+	t.NextRetry, t.LastError = q.now().Add(backoff), err.Error()
+	return t.NextRetry, q.save(method)
+}
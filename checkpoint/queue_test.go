@@ -0,0 +1,71 @@
+package checkpoint
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestQueueRetry(t *testing.T) {
+	indexerID := IndexerIdentification{URL: "https://committee.example", Name: "committee", Version: "v1", MetaProtocol: "brc-20"}
+	cfg := QueueConfig{Path: filepath.Join(t.TempDir(), "queue.json"), Backoff: 10, MaxBackoff: 30}
+	q, err := NewQueue(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	q.now = func() time.Time { return now }
+
+	c1 := NewCheckpoint(&indexerID, 780000, "00000000000000000001", "commitment")
+	c2 := NewCheckpoint(&indexerID, 780001, "00000000000000000002", "commitment")
+	for _, c := range []Checkpoint{c1, c2, c1} {
+		for _, method := range []string{"DA", "S3"} {
+			if err := q.Push(method, c, 0); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if q.Len("DA") != 2 || q.Len("S3") != 2 {
+		t.Fatalf("Unexpected queued checkpoints %d and %d", q.Len("DA"), q.Len("S3"))
+	}
+
+	// The failures of DA delay its retries by 10s, 20s, then at most 30s, without delaying S3.
+	for _, backoff := range []time.Duration{10 * time.Second, 20 * time.Second, 30 * time.Second, 30 * time.Second} {
+		retry, err := q.Fail("DA", errors.New("unavailable"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if retry != now.Add(backoff) {
+			t.Fatalf("Unexpected retry at %s, expected after %s", retry, backoff)
+		}
+	}
+	if len(q.Due("DA")) != 0 || len(q.Due("S3")) != 2 {
+		t.Fatal("Expected only the failing target to wait")
+	}
+	if err := q.Done("S3", &c1); err != nil {
+		t.Fatal(err)
+	}
+
+	// The restarted queue resumes the pending checkpoints and the retry state.
+	restarted, err := NewQueue(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restarted.now = func() time.Time { return now.Add(29 * time.Second) }
+	if len(restarted.Due("DA")) != 0 {
+		t.Fatal("Expected the failing target to wait after the restart")
+	}
+	restarted.now = func() time.Time { return now.Add(30 * time.Second) }
+	due := restarted.Due("DA")
+	if len(due) != 2 || due[0].Checkpoint.Height != "780000" || due[1].Checkpoint.Height != "780001" {
+		t.Fatalf("Unexpected checkpoints to retry %v", due)
+	}
+	if due := restarted.Due("S3"); len(due) != 1 || due[0].Checkpoint.Height != "780001" {
+		t.Fatalf("Unexpected checkpoints of S3 %v", due)
+	}
+	// The published checkpoint is still remembered as queued, so that it isn't queued again after the restart.
+	if !restarted.Queued("S3", c1.Height, c1.Hash) || restarted.Queued("S3", c1.Height, c2.Hash) {
+		t.Fatal("Unexpected queued checkpoints of S3 after the restart")
+	}
+}
@@ -0,0 +1,66 @@
+package checkpoint
+
+import (
+	"fmt"
+)
+
+type Policy string
+
+const (
+	// Publish the checkpoint of every block.
+	PolicyEveryBlock Policy = "every"
+	// Publish the checkpoints of the heights divisible by the interval.
+	PolicyInterval Policy = "interval"
+	// Publish the checkpoints once they are deeper than the finality depth.
+	PolicyFinality Policy = "finality"
+	// Publish the checkpoint of the latest height only on demand.
+	PolicyOnDemand Policy = "demand"
+)
+
+// Schedule decides which checkpoints are published, since publishing every block to a DA layer is expensive.
+type Schedule struct {
+	Policy   Policy `json:"policy"`
+	Interval uint   `json:"interval"`
+	Depth    uint   `json:"depth"`
+	// Publish only the latest due checkpoint while the indexer is catching up with the chain.
+	SuppressCatchup bool `json:"suppressCatchup"`
+}
+
+// Override returns the schedule of the provider if it is set, otherwise the default one.
+func (s Schedule) Override(provider Schedule) Schedule {
+	if provider.Policy != "" {
+		return provider
+	}
+	return s
+}
+
+func (s Schedule) Validate(maxDepth uint) error {
+	switch s.Policy {
+	case "", PolicyEveryBlock, PolicyOnDemand:
+	case PolicyInterval:
+		if s.Interval == 0 {
+			return fmt.Errorf("the interval of the policy %s must be positive", s.Policy)
+		}
+	case PolicyFinality:
+		if s.Depth > maxDepth {
+			return fmt.Errorf("the depth %d of the policy %s exceeds the kept history %d", s.Depth, s.Policy, maxDepth)
+		}
+	default:
+		return fmt.Errorf("unknown publication policy: %s", s.Policy)
+	}
+	return nil
+}
+
+// Due reports whether the checkpoint at the height shall be published when the indexer reaches the latest height.
+func (s Schedule) Due(height, latestHeight uint, demanded bool) bool {
+	switch s.Policy {
+	case PolicyInterval:
+		return height%s.Interval == 0
+	case PolicyFinality:
+		return height+s.Depth <= latestHeight
+	case PolicyOnDemand:
+		return demanded && height == latestHeight
+	default:
+		return true
+	}
+}
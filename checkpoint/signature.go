@@ -0,0 +1,281 @@
+package checkpoint
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+)
+
+// The signature schemes attesting the checkpoints.
+const (
+	// The compact signature of the Bitcoin signed message, verified against the compressed public key.
+	SchemeSecp256k1 = "secp256k1"
+	SchemeEd25519   = "ed25519"
+	// The basic BLS signature with the public key in G1 and the signature in G2, which aggregates across members.
+	SchemeBLS = "bls12-381"
+	// The BIP-340 signature of the tagged hash of the message, verified against the x-only public key.
+	SchemeSchnorr = "schnorr"
+	// The BIP-322 simple signature of the P2TR address of the x-only public key, as signed by the Bitcoin wallets.
+	// The signature is the consensus encoding of the witness.
+	SchemeBIP322 = "bip322"
+)
+
+var SignatureSchemes = []string{SchemeSecp256k1, SchemeEd25519, SchemeBLS, SchemeSchnorr, SchemeBIP322}
+
+// The tag of the hash signed by the schnorr scheme.
+var schnorrTag = []byte("modular-indexer-committee/checkpoint")
+
+// The domain separation tag of the BLS signatures, as the ciphersuite of the IETF BLS signature draft.
+var blsDST = []byte("BLS_SIG_BLS12381G2_XMD:SHA-256_SSWU_RO_NUL_")
+
+type SignatureConfig struct {
+	// One of SignatureSchemes, empty disables the signature.
+	Scheme string `json:"scheme"`
+	// The hex of the 32-byte private key, or the seed for ed25519, which may refer to a secret.
+	PrivateKey string `json:"privateKey"`
+}
+
+// Signer attests the checkpoints under a signature scheme.
+type Signer interface {
+	Scheme() string
+	PublicKey() []byte
+	Sign(message []byte) ([]byte, error)
+}
+
+func NewSigner(scheme, privateKeyHex string) (Signer, error) {
+	key, err := hex.DecodeString(privateKeyHex)
+	if err != nil {
+		return nil, fmt.Errorf("the private key isn't hex: %v", err)
+	}
+	if len(key) != 32 {
+		return nil, errors.New("the private key must be 32 bytes")
+	}
+	switch scheme {
+	case SchemeSecp256k1:
+		priv, _ := btcec.PrivKeyFromBytes(key)
+		return &secp256k1Signer{key: priv}, nil
+	case SchemeEd25519:
+		return &ed25519Signer{key: ed25519.NewKeyFromSeed(key)}, nil
+	case SchemeBLS:
+		sk := new(big.Int).SetBytes(key)
+		sk.Mod(sk, fr.Modulus())
+		if sk.Sign() == 0 {
+			return nil, errors.New("invalid BLS private key")
+		}
+		return &blsSigner{key: sk}, nil
+	case SchemeSchnorr, SchemeBIP322:
+		priv, _ := btcec.PrivKeyFromBytes(key)
+		return &schnorrSigner{key: priv, bip322: scheme == SchemeBIP322}, nil
+	}
+	return nil, fmt.Errorf("unknown signature scheme: %s", scheme)
+}
+
+// Verify checks the signature of the message under the scheme.
+func Verify(scheme string, publicKey, message, signature []byte) error {
+	switch scheme {
+	case SchemeSecp256k1:
+		recovered, _, err := ecdsa.RecoverCompact(signature, bitcoinMessageHash(message))
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(recovered.SerializeCompressed(), publicKey) {
+			return errors.New("invalid signature")
+		}
+		return nil
+	case SchemeEd25519:
+		if len(publicKey) != ed25519.PublicKeySize || !ed25519.Verify(publicKey, message, signature) {
+			return errors.New("invalid signature")
+		}
+		return nil
+	case SchemeBLS:
+		var pk bls12381.G1Affine
+		if _, err := pk.SetBytes(publicKey); err != nil {
+			return fmt.Errorf("invalid public key: %v", err)
+		}
+		var sig bls12381.G2Affine
+		if _, err := sig.SetBytes(signature); err != nil {
+			return fmt.Errorf("invalid signature: %v", err)
+		}
+		h, err := bls12381.HashToG2(message, blsDST)
+		if err != nil {
+			return err
+		}
+		// e(g1, sig) == e(pk, H(m))
+		_, _, g1, _ := bls12381.Generators()
+		var negG1 bls12381.G1Affine
+		negG1.Neg(&g1)
+		ok, err := bls12381.PairingCheck([]bls12381.G1Affine{negG1, pk}, []bls12381.G2Affine{sig, h})
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errors.New("invalid signature")
+		}
+		return nil
+	case SchemeSchnorr:
+		pk, err := schnorr.ParsePubKey(publicKey)
+		if err != nil {
+			return fmt.Errorf("invalid public key: %v", err)
+		}
+		sig, err := schnorr.ParseSignature(signature)
+		if err != nil {
+			return fmt.Errorf("invalid signature: %v", err)
+		}
+		if !sig.Verify(chainhash.TaggedHash(schnorrTag, message)[:], pk) {
+			return errors.New("invalid signature")
+		}
+		return nil
+	case SchemeBIP322:
+		return verifyBIP322(publicKey, message, signature)
+	}
+	return fmt.Errorf("unknown signature scheme: %s", scheme)
+}
+
+// bitcoinMessageHash is the hash of the message signed by the Bitcoin wallets.
+func bitcoinMessageHash(message []byte) []byte {
+	var buf bytes.Buffer
+	_ = wire.WriteVarString(&buf, 0, "Bitcoin Signed Message:\n")
+	_ = wire.WriteVarBytes(&buf, 0, message)
+	return chainhash.DoubleHashB(buf.Bytes())
+}
+
+type secp256k1Signer struct {
+	key *btcec.PrivateKey
+}
+
+func (s *secp256k1Signer) Scheme() string {
+	return SchemeSecp256k1
+}
+
+func (s *secp256k1Signer) PublicKey() []byte {
+	return s.key.PubKey().SerializeCompressed()
+}
+
+func (s *secp256k1Signer) Sign(message []byte) ([]byte, error) {
+	return ecdsa.SignCompact(s.key, bitcoinMessageHash(message), true)
+}
+
+type ed25519Signer struct {
+	key ed25519.PrivateKey
+}
+
+func (s *ed25519Signer) Scheme() string {
+	return SchemeEd25519
+}
+
+func (s *ed25519Signer) PublicKey() []byte {
+	return s.key.Public().(ed25519.PublicKey)
+}
+
+func (s *ed25519Signer) Sign(message []byte) ([]byte, error) {
+	return ed25519.Sign(s.key, message), nil
+}
+
+type blsSigner struct {
+	key *big.Int
+}
+
+func (s *blsSigner) Scheme() string {
+	return SchemeBLS
+}
+
+func (s *blsSigner) PublicKey() []byte {
+	var pk bls12381.G1Affine
+	pk.ScalarMultiplicationBase(s.key)
+	b := pk.Bytes()
+	return b[:]
+}
+
+func (s *blsSigner) Sign(message []byte) ([]byte, error) {
+	h, err := bls12381.HashToG2(message, blsDST)
+	if err != nil {
+		return nil, err
+	}
+	var sig bls12381.G2Affine
+	sig.ScalarMultiplication(&h, s.key)
+	b := sig.Bytes()
+	return b[:], nil
+}
+
+type schnorrSigner struct {
+	key    *btcec.PrivateKey
+	bip322 bool
+}
+
+func (s *schnorrSigner) Scheme() string {
+	if s.bip322 {
+		return SchemeBIP322
+	}
+	return SchemeSchnorr
+}
+
+func (s *schnorrSigner) PublicKey() []byte {
+	return schnorr.SerializePubKey(s.key.PubKey())
+}
+
+func (s *schnorrSigner) Sign(message []byte) ([]byte, error) {
+	if s.bip322 {
+		return signBIP322(s.key, message)
+	}
+	sig, err := schnorr.Sign(s.key, chainhash.TaggedHash(schnorrTag, message)[:])
+	if err != nil {
+		return nil, err
+	}
+	return sig.Serialize(), nil
+}
+
+// signingMessage is the JSON of the checkpoint without the signature, which binds the scheme and the public key.
+func (c *Checkpoint) signingMessage() ([]byte, error) {
+	unsigned := *c
+	unsigned.Signature = ""
+	return json.Marshal(unsigned)
+}
+
+// Sign attests the checkpoint, which must not be changed afterwards.
+func (c *Checkpoint) Sign(s Signer) error {
+	c.SignatureScheme = s.Scheme()
+	c.PublicKey = hex.EncodeToString(s.PublicKey())
+	message, err := c.signingMessage()
+	if err != nil {
+		return err
+	}
+	signature, err := s.Sign(message)
+	if err != nil {
+		return err
+	}
+	c.Signature = hex.EncodeToString(signature)
+	return nil
+}
+
+// VerifySignature checks the attestation of the checkpoint by its own public key,
+// which the verifier has to trust separately, e.g. from the committee registry.
+func (c *Checkpoint) VerifySignature() error {
+	if c.SignatureScheme == "" {
+		return errors.New("the checkpoint isn't signed")
+	}
+	publicKey, err := hex.DecodeString(c.PublicKey)
+	if err != nil {
+		return fmt.Errorf("invalid public key: %v", err)
+	}
+	signature, err := hex.DecodeString(c.Signature)
+	if err != nil {
+		return fmt.Errorf("invalid signature: %v", err)
+	}
+	message, err := c.signingMessage()
+	if err != nil {
+		return err
+	}
+	return Verify(c.SignatureScheme, publicKey, message, signature)
+}
@@ -0,0 +1,75 @@
+package checkpoint
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+func TestCheckpointSignature(t *testing.T) {
+	indexerID := IndexerIdentification{URL: "https://committee.example", Name: "committee", Version: "v1", MetaProtocol: "brc-20"}
+	for _, scheme := range SignatureSchemes {
+		signer, err := NewSigner(scheme, strings.Repeat("2a", 32))
+		if err != nil {
+			t.Fatal(err)
+		}
+		c := NewCheckpoint(&indexerID, 780000, "00000000000000000002", "commitment")
+		if c.VerifySignature() == nil {
+			t.Fatalf("%s: expected the unsigned checkpoint to be rejected", scheme)
+		}
+		if err := c.Sign(signer); err != nil {
+			t.Fatal(err)
+		}
+		if err := c.VerifySignature(); err != nil {
+			t.Fatalf("%s: %v", scheme, err)
+		}
+
+		tampered := c
+		tampered.Commitment = "forged"
+		if tampered.VerifySignature() == nil {
+			t.Fatalf("%s: expected the tampered checkpoint to be rejected", scheme)
+		}
+		other, _ := NewSigner(scheme, strings.Repeat("2b", 32))
+		tampered = c
+		if err := tampered.Sign(other); err != nil || tampered.Signature == c.Signature {
+			t.Fatalf("%s: expected another key to sign differently", scheme)
+		}
+		tampered.Signature = c.Signature
+		if tampered.VerifySignature() == nil {
+			t.Fatalf("%s: expected the signature of another key to be rejected", scheme)
+		}
+	}
+
+	if _, err := NewSigner("rsa", strings.Repeat("2a", 32)); err == nil {
+		t.Fatal("Expected the unknown scheme to be rejected")
+	}
+}
+
+// The P2TR test vector of BIP-322, signed by a wallet with SIGHASH_ALL.
+func TestBIP322Vector(t *testing.T) {
+	wif, err := btcutil.DecodeWIF("L3VFeEujGtevx9w18HD1fhRbCH67Az2dpCymeRE1SoPK6XQtaN2k")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkScript, err := taprootScript(wif.PrivKey.PubKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	address, err := btcutil.NewAddressTaproot(pkScript[2:], &chaincfg.MainNetParams)
+	if err != nil || address.EncodeAddress() != "bc1ppv609nr0vr25u07u95waq5lucwfm6tde4nydujnu8npg4q75mr5sxq8lt3" {
+		t.Fatalf("Unexpected address %v", address)
+	}
+
+	signature, _ := base64.StdEncoding.DecodeString("AUHd69PrJQEv+oKTfZ8l+WROBHuy9HKrbFCJu7U1iK2iiEy1vMU5EfMtjc+VSHM7aU0SDbak5IUZRVno2P5mjSafAQ==")
+	publicKey := schnorr.SerializePubKey(wif.PrivKey.PubKey())
+	if err := Verify(SchemeBIP322, publicKey, []byte("Hello World"), signature); err != nil {
+		t.Fatal(err)
+	}
+	if Verify(SchemeBIP322, publicKey, []byte(""), signature) == nil {
+		t.Fatal("Expected the signature of another message to be rejected")
+	}
+}
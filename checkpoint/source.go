@@ -0,0 +1,213 @@
+package checkpoint
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	datypes "github.com/RiemaLabs/nubit-da-sdk/types"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// ErrNotPublished is returned if the checkpoint isn't published, or not yet.
+var ErrNotPublished = errors.New("the checkpoint is not published")
+
+// Source reads the checkpoints published by a member, named by ObjectKey.
+type Source interface {
+	// Get returns ErrNotPublished if the checkpoint of the key doesn't exist.
+	Get(ctx context.Context, key string) (*Checkpoint, error)
+}
+
+func decodeCheckpoint(key string, bytes []byte) (*Checkpoint, error) {
+	c, err := ParseCheckpoint(bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid checkpoint %s: %v", key, err)
+	}
+	return c, nil
+}
+
+// DirSource reads the checkpoints saved in a directory, e.g. by the LocalPublisher of the member on a shared volume.
+type DirSource struct {
+	Dir string
+}
+
+func (s *DirSource) Get(_ context.Context, key string) (*Checkpoint, error) {
+	bytes, err := os.ReadFile(filepath.Join(s.Dir, key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotPublished
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeCheckpoint(key, bytes)
+}
+
+// S3Source reads the checkpoints uploaded to a bucket by the S3Publisher of the member.
+type S3Source struct {
+	client *s3.Client
+	bucket string
+}
+
+// NewS3Source reads the bucket, whose endpoint is of another S3-compatible object store, empty for AWS S3.
+func NewS3Source(ctx context.Context, accessKey, secretKey, region, endpoint, bucket string) (*S3Source, error) {
+	client, err := newS3Client(ctx, accessKey, secretKey, region, endpoint)
+	if err != nil {
+		return nil, err
+	}
+	return &S3Source{client: client, bucket: bucket}, nil
+}
+
+func (s *S3Source) Get(ctx context.Context, key string) (*Checkpoint, error) {
+	out, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	var notFound *types.NoSuchKey
+	if errors.As(err, &notFound) {
+		return nil, ErrNotPublished
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer out.Body.Close()
+	bytes, err := io.ReadAll(out.Body)
+	if err != nil {
+		return nil, err
+	}
+	return decodeCheckpoint(key, bytes)
+}
+
+// HTTPSource reads the checkpoints served as files under a base URL, e.g. the public URL of the bucket of the member.
+type HTTPSource struct {
+	URL    string
+	Client *http.Client
+}
+
+func (s *HTTPSource) Get(ctx context.Context, key string) (*Checkpoint, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(s.URL, "/")+"/"+key, nil)
+	if err != nil {
+		return nil, err
+	}
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotPublished
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("the source replied %s", resp.Status)
+	}
+	bytes, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	return decodeCheckpoint(key, bytes)
+}
+
+// The number of the data IDs of a namespace listed per request.
+const daPageSize = 100
+
+// daReader reads the data of the namespaces, implemented by the client of the Nubit SDK.
+type daReader interface {
+	GetDataInNamespace(ctx context.Context, req *datypes.GetDataInNamespaceReq) (*datypes.GetDataInNamespaceRsp, error)
+	GetData(ctx context.Context, req *datypes.GetDataReq) (*datypes.GetDataRsp, error)
+}
+
+// DASource reads the checkpoints uploaded to a namespace of the Nubit DA layer by the DAPublisher of the member.
+// The data of the namespace are only listed, not indexed by the key, so the new data are scanned at every miss
+// and the checkpoints read so far are kept by their keys.
+type DASource struct {
+	NamespaceID string
+
+	reader daReader
+	mu     sync.Mutex
+	// The number of the data of the namespace scanned so far.
+	offset      int
+	checkpoints map[string]*Checkpoint
+	// The number of the copies of each checkpoint found in the namespace.
+	copies map[string]int
+}
+
+// NewDASource reads the namespace on the network of the DA layer, Pre-Alpha Testnet or Testnet.
+func NewDASource(ctx context.Context, network, namespaceID string) (*DASource, error) {
+	if !IsValidNamespaceID(namespaceID) {
+		return nil, fmt.Errorf("invalid namespace ID %s", namespaceID)
+	}
+	clientDA, err := newDAClient(ctx, "", "", network)
+	if err != nil {
+		return nil, err
+	}
+	if clientDA.Client == nil {
+		return nil, fmt.Errorf("failed to connect to the DA network %s", network)
+	}
+	return &DASource{NamespaceID: namespaceID, reader: clientDA.Client, checkpoints: make(map[string]*Checkpoint), copies: make(map[string]int)}, nil
+}
+
+func (s *DASource) Get(ctx context.Context, key string) (*Checkpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if c, found := s.checkpoints[key]; found {
+		return c, nil
+	}
+	for {
+		page, err := s.reader.GetDataInNamespace(ctx, &datypes.GetDataInNamespaceReq{NID: s.NamespaceID, Limit: daPageSize, Offset: s.offset})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list the data of the namespace %s: %v", s.NamespaceID, err)
+		}
+		for _, id := range page.DataIDs {
+			data, err := s.reader.GetData(ctx, &datypes.GetDataReq{DAID: id})
+			if err != nil {
+				return nil, fmt.Errorf("failed to read the data %s: %v", id, err)
+			}
+			s.offset++
+			// The namespace may hold other data, which are skipped.
+			if c := decodeDAData(data.RawData); c != nil {
+				s.checkpoints[ObjectKey(c)] = c
+				s.copies[ObjectKey(c)]++
+			}
+		}
+		if len(page.DataIDs) < daPageSize {
+			break
+		}
+	}
+	if c, found := s.checkpoints[key]; found {
+		return c, nil
+	}
+	return nil, ErrNotPublished
+}
+
+// Copies returns the number of the copies of the checkpoint of the key found in the namespace so far, which are
+// more than one if the checkpoint is published more than once.
+func (s *DASource) Copies(key string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.copies[key]
+}
+
+// decodeDAData decodes the checkpoint uploaded as the base64 of its JSON, nil if the data isn't a checkpoint.
+func decodeDAData(raw string) *Checkpoint {
+	bytes, err := base64.StdEncoding.DecodeString(raw)
+	if err != nil {
+		bytes = []byte(raw)
+	}
+	c, err := ParseCheckpoint(bytes)
+	if err != nil || c.Height == "" || c.Commitment == "" {
+		return nil
+	}
+	return c
+}
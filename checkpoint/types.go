@@ -1,28 +1,124 @@
 package checkpoint
 
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+)
+
 type IndexerIdentification struct {
 	URL          string
 	Name         string
 	Version      string
 	MetaProtocol string
+	RulesVersion string
+	// The tag of the Bitcoin network, empty on the mainnet.
+	Network string
+	// The source of the inscriptions executed by the indexer, e.g. opi, ord or bitcoind.
+	GetterSource string
 }
 
+// The checkpoint format versions understood by the committee indexer, which produces FormatVersion.
+// The checkpoints of v1 carry no formatVersion, nor the counts and the getter source added by v2.
+const (
+	FormatV1      = "v1"
+	FormatV2      = "v2"
+	FormatVersion = FormatV2
+)
+
+var SupportedFormatVersions = []string{FormatV1, FormatV2}
+
 // CheckpointFromCommitteeIndexer
 type Checkpoint struct {
+	// The format of the checkpoint, one of SupportedFormatVersions, omitted by v1
+	FormatVersion string `json:"formatVersion,omitempty"`
 	// Hex of the Commitment of the Verkle Tree Root
 	Commitment string `json:"commitment"`
+	// Base64 of the root of the sparse Merkle tree over the same state, only set in the dual-commitment mode
+	SecondaryCommitment string `json:"secondaryCommitment,omitempty"`
 	// Hex of the BlockHash of the checkpoint
 	Hash string `json:"hash"`
 	// BlockHeight of the checkpoint
 	Height string `json:"height"`
 	// Protocol name used by the indexer, fixed as "BRC-20" now
 	MetaProtocol string `json:"metaProtocol"`
+	// The Bitcoin network of the block: testnet, signet or regtest, omitted on the mainnet
+	Network string `json:"network,omitempty"`
 	// Name of the indexer
 	Name string `json:"name"`
 	// URL of the indexer service
 	URL string `json:"url"`
 	// Version number of the Modular Indexer
 	Version string `json:"version"`
+	// The version of the rules executed by the indexer, see brc20.RulesVersion
+	RulesVersion string `json:"rulesVersion,omitempty"`
+	// Hex of the SHA-256 of the execution witness of the block, only set if the witness hash is published
+	WitnessHash string `json:"witnessHash,omitempty"`
+	// The number of the events indexed by the block and of the ticks indexed up to it, since v2
+	EventCount uint64 `json:"eventCount,omitempty"`
+	TickCount  uint64 `json:"tickCount,omitempty"`
+	// The source of the inscriptions executed by the indexer, since v2
+	GetterSource string `json:"getterSource,omitempty"`
+	// The key layout of the state, see brc20.SchemaVersion, and the migrations producing it, since v2
+	SchemaVersion int         `json:"schemaVersion,omitempty"`
+	Migrations    []Migration `json:"migrations,omitempty"`
+	// The scheme deriving the keys of the state, omitted for the legacy keccak256, see protocol.KeySchemeFlag
+	KeyScheme string `json:"keyScheme,omitempty"`
+	// The committee signature of the checkpoint, only set if a signature scheme is configured:
+	// one of SignatureSchemes, the hex of the public key and the hex of the signature.
+	// The fields of v2 are omitted when empty, so the signatures of the v1 checkpoints still verify.
+	SignatureScheme string `json:"signatureScheme,omitempty"`
+	PublicKey       string `json:"publicKey,omitempty"`
+	Signature       string `json:"signature,omitempty"`
+}
+
+// Migration is a rewrite of the state from a key layout to the next one at the height, so that the verifiers can tell
+// a commitment changed by a migration from a divergent one.
+type Migration struct {
+	Name   string `json:"name"`
+	From   int    `json:"from"`
+	To     int    `json:"to"`
+	Height uint   `json:"height"`
+	// Base64 of the commitment of the migrated state
+	Commitment string `json:"commitment"`
+	// The key scheme the state was rewritten to, only set by the migrations of the key scheme
+	KeyScheme string `json:"keyScheme,omitempty"`
+}
+
+// Format returns the format version of the checkpoint, v1 if it carries none.
+func (c *Checkpoint) Format() string {
+	if c.FormatVersion == "" {
+		return FormatV1
+	}
+	return c.FormatVersion
+}
+
+// ParseCheckpoint decodes the JSON of a checkpoint of any supported format.
+func ParseCheckpoint(bytes []byte) (*Checkpoint, error) {
+	var c Checkpoint
+	if err := json.Unmarshal(bytes, &c); err != nil {
+		return nil, err
+	}
+	if !slices.Contains(SupportedFormatVersions, c.Format()) {
+		return nil, fmt.Errorf("unsupported checkpoint format %s, expected one of %v", c.FormatVersion, SupportedFormatVersions)
+	}
+	return &c, nil
+}
+
+// CheckNetwork returns an error unless the checkpoint is of the network of the tag, empty for the mainnet,
+// so that a checkpoint of another network is never taken for one of the network.
+func (c *Checkpoint) CheckNetwork(tag string) error {
+	if c.Network != tag {
+		return fmt.Errorf("the checkpoint is of the network %s rather than %s", networkName(c.Network), networkName(tag))
+	}
+	return nil
+}
+
+func networkName(tag string) string {
+	if tag == "" {
+		return "mainnet"
+	}
+	return tag
 }
 
 type UploadRecord struct {
@@ -0,0 +1,64 @@
+package checkpoint
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestParseCheckpoint(t *testing.T) {
+	v1 := `{"commitment":"commitment","hash":"00000000000000000002","height":"780000","metaProtocol":"brc-20","name":"committee","url":"https://committee.example","version":"v1"}`
+	c, err := ParseCheckpoint([]byte(v1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Format() != FormatV1 || c.Height != "780000" || c.EventCount != 0 || c.GetterSource != "" {
+		t.Fatalf("Unexpected v1 checkpoint %+v", c)
+	}
+
+	// A v1 checkpoint signed before v2 still verifies, since the fields of v2 are omitted.
+	signer, err := NewSigner(SchemeEd25519, strings.Repeat("2a", 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Sign(signer); err != nil {
+		t.Fatal(err)
+	}
+	signed, _ := json.Marshal(c)
+	if strings.Contains(string(signed), "formatVersion") || strings.Contains(string(signed), "eventCount") {
+		t.Fatalf("Unexpected fields of v2 in the v1 checkpoint %s", signed)
+	}
+	c, err = ParseCheckpoint(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.VerifySignature(); err != nil {
+		t.Fatal(err)
+	}
+
+	indexerID := IndexerIdentification{Name: "committee", MetaProtocol: "brc-20", RulesVersion: "rules", GetterSource: "opi"}
+	v2 := NewCheckpoint(&indexerID, 780000, "00000000000000000002", "commitment")
+	v2.EventCount, v2.TickCount = 3, 42
+	if err := v2.Sign(signer); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := json.Marshal(v2)
+	c, err = ParseCheckpoint(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Format() != FormatV2 || c.RulesVersion != "rules" || c.EventCount != 3 || c.TickCount != 42 || c.GetterSource != "opi" {
+		t.Fatalf("Unexpected v2 checkpoint %+v", c)
+	}
+	if err := c.VerifySignature(); err != nil {
+		t.Fatal(err)
+	}
+	c.TickCount++
+	if c.VerifySignature() == nil {
+		t.Fatal("Expected the signature to cover the counts of v2")
+	}
+
+	if _, err := ParseCheckpoint([]byte(`{"formatVersion":"v9","height":"780000"}`)); err == nil {
+		t.Fatal("Expected the unknown format to be rejected")
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/RiemaLabs/modular-indexer-committee/apis"
+	"github.com/RiemaLabs/modular-indexer-committee/checkpoint"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/getter"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+func Test_CheckpointHistory(t *testing.T) {
+	g := &blocksGetter{blocks: map[uint][]getter.OrdTransfer{}, hashes: make(map[uint]string)}
+	header := stateless.LoadHeader(false, 800000)
+	queue, err := stateless.NewQueues(g, header, true, 800001)
+	if err != nil {
+		t.Fatal(err)
+	}
+	latestHeight := queue.LatestHeight()
+
+	report := GlobalConfig.Report
+	defer func() {
+		GlobalConfig.Report = report
+		CheckpointQueue = nil
+	}()
+	GlobalConfig.Report.Targets = []string{"Local"}
+	GlobalConfig.Report.Local.Dir = t.TempDir()
+	if CheckpointQueue, err = checkpoint.NewQueue(checkpoint.QueueConfig{}); err != nil {
+		t.Fatal(err)
+	}
+	if apis.Publications, err = checkpoint.OpenLedger(filepath.Join(t.TempDir(), "publications")); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = apis.Publications.Close()
+		apis.Publications = nil
+	}()
+
+	// Every publication is recorded, including the republished checkpoint.
+	arguments := RuntimeArguments{EnableCommittee: true}
+	republishCheckpoints(&arguments, queue, []uint{latestHeight - 1, latestHeight})
+	republishCheckpoints(&arguments, queue, []uint{latestHeight})
+
+	ts := httptest.NewServer(apis.NewRouter(queue, "brc-20", false, false))
+	defer ts.Close()
+	query := func(params string) (int, apis.CheckpointsResponse) {
+		resp, err := http.Get(ts.URL + "/v1/checkpoints" + params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var res apis.CheckpointsResponse
+		if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode, res
+	}
+
+	status, res := query("")
+	if status != http.StatusOK || res.Result.To != latestHeight || len(res.Result.Publications) != 3 {
+		t.Fatalf("Unexpected publications %d %+v", status, res.Result)
+	}
+	commitment := queue.Header.Root.Commit().Bytes()
+	for i, p := range res.Result.Publications {
+		height := latestHeight
+		if i == 0 {
+			height = latestHeight - 1
+		}
+		if p.Height != height || p.Method != "Local" || p.PublishedAt.IsZero() {
+			t.Fatalf("Unexpected publication %+v", p)
+		}
+		if _, err := os.Stat(p.Location); err != nil {
+			t.Fatalf("Expected the publication to locate the checkpoint: %v", err)
+		}
+	}
+	if latest := res.Result.Publications[2]; latest.Hash != queue.Header.Hash || latest.Commitment != base64.StdEncoding.EncodeToString(commitment[:]) {
+		t.Fatalf("Unexpected publication of the latest block %+v", latest)
+	}
+
+	if status, res := query(fmt.Sprintf("?from=%d&to=%d", latestHeight-1, latestHeight-1)); status != http.StatusOK || len(res.Result.Publications) != 1 {
+		t.Fatalf("Unexpected publications %d %+v", status, res.Result)
+	}
+	if status, res := query(fmt.Sprintf("?from=%d", latestHeight+1)); status != http.StatusOK || len(res.Result.Publications) != 0 {
+		t.Fatalf("Expected no publication, got %d %+v", status, res.Result)
+	}
+	for _, params := range []string{"?from=2&to=1", fmt.Sprintf("?from=0&to=%d", apis.MaxCheckpointHeights), "?from=x"} {
+		if status, _ := query(params); status != http.StatusBadRequest {
+			t.Fatalf("Expected %s to be rejected, got %d", params, status)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/RiemaLabs/modular-indexer-committee/checkpoint"
+	"github.com/RiemaLabs/modular-indexer-committee/committee"
+	"github.com/RiemaLabs/modular-indexer-committee/ord"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+func dueHeights(schedule checkpoint.Schedule, demanded bool, catchingUp bool) []uint {
+	var latestHeight uint = 780006
+	hs := make([]*stateless.DiffState, 0)
+	for h := latestHeight - ord.BitcoinConfirmations; h <= latestHeight; h++ {
+		hs = append(hs, &stateless.DiffState{Height: h})
+	}
+	heights := make([]uint, 0)
+	for _, h := range committee.DueCheckpoints(hs, schedule, latestHeight, demanded, catchingUp) {
+		heights = append(heights, h.Height)
+	}
+	return heights
+}
+
+func Test_CheckpointSchedule(t *testing.T) {
+	cases := []struct {
+		name       string
+		schedule   checkpoint.Schedule
+		demanded   bool
+		catchingUp bool
+		expected   []uint
+	}{
+		{"every block", checkpoint.Schedule{}, false, false, []uint{780000, 780001, 780002, 780003, 780004, 780005, 780006}},
+		{"interval", checkpoint.Schedule{Policy: checkpoint.PolicyInterval, Interval: 3}, false, false, []uint{780000, 780003, 780006}},
+		{"finality", checkpoint.Schedule{Policy: checkpoint.PolicyFinality, Depth: 5}, false, false, []uint{780000, 780001}},
+		{"not demanded", checkpoint.Schedule{Policy: checkpoint.PolicyOnDemand}, false, false, []uint{}},
+		{"demanded", checkpoint.Schedule{Policy: checkpoint.PolicyOnDemand}, true, false, []uint{780006}},
+		{"catch-up suppression", checkpoint.Schedule{Policy: checkpoint.PolicyInterval, Interval: 3, SuppressCatchup: true}, false, true, []uint{780006}},
+		{"synced", checkpoint.Schedule{Policy: checkpoint.PolicyInterval, Interval: 3, SuppressCatchup: true}, false, false, []uint{780000, 780003, 780006}},
+	}
+	for _, c := range cases {
+		heights := dueHeights(c.schedule, c.demanded, c.catchingUp)
+		if len(heights) != len(c.expected) {
+			t.Fatalf("%s: expected the heights %v, got %v", c.name, c.expected, heights)
+		}
+		for i := range heights {
+			if heights[i] != c.expected[i] {
+				t.Fatalf("%s: expected the heights %v, got %v", c.name, c.expected, heights)
+			}
+		}
+	}
+
+	if err := (checkpoint.Schedule{Policy: checkpoint.PolicyInterval}).Validate(ord.BitcoinConfirmations); err == nil {
+		t.Fatal("Expected the zero interval to be rejected")
+	}
+	if err := (checkpoint.Schedule{Policy: checkpoint.PolicyFinality, Depth: 7}).Validate(ord.BitcoinConfirmations); err == nil {
+		t.Fatal("Expected the depth beyond the history to be rejected")
+	}
+	da := checkpoint.Schedule{Policy: checkpoint.PolicyOnDemand}
+	if (checkpoint.Schedule{}).Override(da) != da {
+		t.Fatal("Expected the schedule of the provider to override the default one")
+	}
+}
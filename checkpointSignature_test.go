@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/RiemaLabs/modular-indexer-committee/apis"
+	"github.com/RiemaLabs/modular-indexer-committee/checkpoint"
+)
+
+func Test_VerifyCheckpoint(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	// The verification never reads the state.
+	ts := httptest.NewServer(apis.NewRouter(nil, "brc-20", false, false))
+	defer ts.Close()
+
+	verify := func(c checkpoint.Checkpoint, trusted string) apis.VerifyCheckpointResult {
+		body, _ := json.Marshal(c)
+		resp, err := http.Post(ts.URL+"/v1/checkpoint/verify?publicKey="+trusted, "application/json", bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var res apis.VerifyCheckpointResponse
+		if err := json.NewDecoder(resp.Body).Decode(&res); err != nil || res.Result == nil {
+			t.Fatalf("Unexpected response %d %v", resp.StatusCode, err)
+		}
+		return *res.Result
+	}
+
+	indexerID := checkpoint.IndexerIdentification{URL: "https://committee.example", Name: "committee", Version: "v1", MetaProtocol: "brc-20"}
+	c := checkpoint.NewCheckpoint(&indexerID, 780000, "00000000000000000002", "commitment")
+	signer, err := checkpoint.NewSigner(checkpoint.SchemeBIP322, strings.Repeat("2a", 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Sign(signer); err != nil {
+		t.Fatal(err)
+	}
+
+	if res := verify(c, ""); !res.Valid || res.SignatureScheme != checkpoint.SchemeBIP322 {
+		t.Fatalf("Expected the signed checkpoint to be valid, got %+v", res)
+	}
+	if res := verify(c, strings.ToUpper(c.PublicKey)); !res.Valid {
+		t.Fatalf("Expected the trusted key to match, got %+v", res)
+	}
+	if res := verify(c, strings.Repeat("00", 32)); res.Valid {
+		t.Fatal("Expected the checkpoint signed by another key to be rejected")
+	}
+	tampered := c
+	tampered.Height = "780001"
+	if res := verify(tampered, ""); res.Valid || res.Reason == "" {
+		t.Fatalf("Expected the tampered checkpoint to be rejected, got %+v", res)
+	}
+}
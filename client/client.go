@@ -0,0 +1,391 @@
+// Package client is a Go SDK for the APIs of committee indexers.
+// It fails over across multiple committee indexers and verifies the returned proofs against trusted state roots,
+// such as the commitments of the checkpoints published on DA.
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/RiemaLabs/modular-indexer-committee/apis"
+	"github.com/RiemaLabs/modular-indexer-committee/checkpoint"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/brc20"
+)
+
+// Client sends the requests to a set of committee indexers serving the same meta protocol.
+// A request is sent to the last healthy endpoint first, and fails over to the others on network or server errors.
+type Client struct {
+	HTTPClient *http.Client
+	// Rounds over all endpoints before a request fails.
+	Retries int
+	// Waiting time between two rounds.
+	Backoff time.Duration
+
+	endpoints []string
+	mu        sync.Mutex
+	preferred int
+}
+
+func New(endpoints ...string) (*Client, error) {
+	if len(endpoints) == 0 {
+		return nil, errors.New("at least one committee indexer endpoint is required")
+	}
+	trimmed := make([]string, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		if _, err := url.Parse(endpoint); err != nil {
+			return nil, fmt.Errorf("invalid committee indexer endpoint %s: %v", endpoint, err)
+		}
+		trimmed = append(trimmed, strings.TrimRight(endpoint, "/"))
+	}
+	return &Client{
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+		Retries:    3,
+		Backoff:    time.Second,
+		endpoints:  trimmed,
+	}, nil
+}
+
+// Endpoints returns the committee indexers of the client.
+func (c *Client) Endpoints() []string {
+	return append([]string{}, c.endpoints...)
+}
+
+func (c *Client) fetch(ctx context.Context, rawURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d from %s: %s", resp.StatusCode, rawURL, body)
+	}
+	return body, nil
+}
+
+// get requests the path from the endpoints in turn until one of them succeeds.
+func (c *Client) get(ctx context.Context, path string, query url.Values) ([]byte, error) {
+	if len(query) != 0 {
+		path = path + "?" + query.Encode()
+	}
+	var lastErr error
+	for round := 0; round <= c.Retries; round++ {
+		if round != 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(c.Backoff):
+			}
+		}
+		c.mu.Lock()
+		start := c.preferred
+		c.mu.Unlock()
+		for i := 0; i < len(c.endpoints); i++ {
+			index := (start + i) % len(c.endpoints)
+			body, err := c.fetch(ctx, c.endpoints[index]+path)
+			if err == nil {
+				c.mu.Lock()
+				c.preferred = index
+				c.mu.Unlock()
+				return body, nil
+			}
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			lastErr = err
+		}
+	}
+	return nil, fmt.Errorf("all committee indexers failed to serve %s, the last error: %v", path, lastErr)
+}
+
+func (c *Client) getJSON(ctx context.Context, path string, query url.Values, v any) error {
+	body, err := c.get(ctx, path, query)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to parse the response of %s: %v", path, err)
+	}
+	return nil
+}
+
+// BlockHeight returns the latest block height indexed by the committee indexer.
+func (c *Client) BlockHeight(ctx context.Context) (uint, error) {
+	body, err := c.get(ctx, "/v1/brc20_verifiable/block_height", nil)
+	if err != nil {
+		return 0, err
+	}
+	height, err := strconv.ParseUint(strings.TrimSpace(string(body)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid block height %s: %v", body, err)
+	}
+	return uint(height), nil
+}
+
+// Capabilities returns the meta protocols, checkpoint versions, proof types and API routes of the committee indexer.
+func (c *Client) Capabilities(ctx context.Context) (*apis.CapabilitiesResult, error) {
+	var resp apis.CapabilitiesResponse
+	if err := c.getJSON(ctx, "/v1/capabilities", nil, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Error != nil {
+		return nil, errors.New(*resp.Error)
+	}
+	return resp.Result, nil
+}
+
+// StateDigest returns the order-independent digest of the full state of the committee indexer.
+func (c *Client) StateDigest(ctx context.Context) (*apis.StateDigestResult, error) {
+	var resp apis.StateDigestResponse
+	if err := c.getJSON(ctx, "/v1/state/digest", nil, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Error != nil {
+		return nil, errors.New(*resp.Error)
+	}
+	return resp.Result, nil
+}
+
+// StateSchema returns the registry of the state keys and the value encodings of the committee indexer.
+func (c *Client) StateSchema(ctx context.Context) (*brc20.Schema, error) {
+	var resp apis.StateSchemaResponse
+	if err := c.getJSON(ctx, "/v1/state/schema", nil, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Error != nil {
+		return nil, errors.New(*resp.Error)
+	}
+	return resp.Result, nil
+}
+
+// Checkpoint returns the latest state attested by the committee indexer, with the roots of its protocol modules.
+func (c *Client) Checkpoint(ctx context.Context) (*apis.CheckpointResult, error) {
+	var resp apis.CheckpointResponse
+	if err := c.getJSON(ctx, "/v1/checkpoint", nil, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Error != nil {
+		return nil, errors.New(*resp.Error)
+	}
+	return resp.Result, nil
+}
+
+// Status returns the execution progress of the committee indexer.
+func (c *Client) Status(ctx context.Context) (*apis.StatusResult, error) {
+	var resp apis.StatusResponse
+	if err := c.getJSON(ctx, "/v1/status", nil, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Error != nil {
+		return nil, errors.New(*resp.Error)
+	}
+	return resp.Result, nil
+}
+
+func (c *Client) CurrentBalanceOfPkscript(ctx context.Context, tick, pkscript string) (*apis.Brc20VerifiableCurrentBalanceOfPkscriptResponse, error) {
+	var resp apis.Brc20VerifiableCurrentBalanceOfPkscriptResponse
+	query := url.Values{"tick": {tick}, "pkscript": {pkscript}}
+	if err := c.getJSON(ctx, "/v1/brc20_verifiable/current_balance_of_pkscript", query, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Error != nil {
+		return nil, errors.New(*resp.Error)
+	}
+	if resp.Result == nil || resp.Proof == nil {
+		return nil, fmt.Errorf("incomplete balance of the pkscript %s of the tick %s", pkscript, tick)
+	}
+	return &resp, nil
+}
+
+func (c *Client) CurrentBalanceOfWallet(ctx context.Context, tick, wallet string) (*apis.Brc20VerifiableCurrentBalanceOfWalletResponse, error) {
+	var resp apis.Brc20VerifiableCurrentBalanceOfWalletResponse
+	query := url.Values{"tick": {tick}, "wallet": {wallet}}
+	if err := c.getJSON(ctx, "/v1/brc20_verifiable/current_balance_of_wallet", query, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Error != nil {
+		return nil, errors.New(*resp.Error)
+	}
+	if resp.Result == nil || resp.Proof == nil {
+		return nil, fmt.Errorf("incomplete balance of the wallet %s of the tick %s", wallet, tick)
+	}
+	return &resp, nil
+}
+
+// VerifiedBalanceOfPkscript returns the balance only if its proof is valid against the commitment,
+// which is the base64 encoded state root, e.g. the commitment of a checkpoint at the latest height.
+func (c *Client) VerifiedBalanceOfPkscript(ctx context.Context, commitment, tick, pkscript string) (*apis.Brc20VerifiableCurrentBalanceOfPkscriptResult, error) {
+	rootC, err := apis.ParseCommitment(commitment)
+	if err != nil {
+		return nil, fmt.Errorf("invalid commitment %s: %v", commitment, err)
+	}
+	resp, err := c.CurrentBalanceOfPkscript(ctx, tick, pkscript)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Result.StateRoot != "" && resp.Result.StateRoot != commitment {
+		return nil, fmt.Errorf("the balance of the pkscript %s is proven against the state root %s at height %d instead", pkscript, resp.Result.StateRoot, resp.Result.Height)
+	}
+	if _, err := apis.VerifyCurrentBalanceOfPkscript(rootC, tick, pkscript, resp); err != nil {
+		return nil, fmt.Errorf("invalid proof of the balance of the pkscript %s of the tick %s: %v", pkscript, tick, err)
+	}
+	return resp.Result, nil
+}
+
+// VerifiedBalanceOfWallet returns the balance only if its proof is valid against the commitment.
+func (c *Client) VerifiedBalanceOfWallet(ctx context.Context, commitment, tick, wallet string) (*apis.Brc20VerifiableCurrentBalanceOfWalletResult, error) {
+	rootC, err := apis.ParseCommitment(commitment)
+	if err != nil {
+		return nil, fmt.Errorf("invalid commitment %s: %v", commitment, err)
+	}
+	resp, err := c.CurrentBalanceOfWallet(ctx, tick, wallet)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Result.StateRoot != "" && resp.Result.StateRoot != commitment {
+		return nil, fmt.Errorf("the balance of the wallet %s is proven against the state root %s at height %d instead", wallet, resp.Result.StateRoot, resp.Result.Height)
+	}
+	if _, err := apis.VerifyCurrentBalanceOfWallet(rootC, tick, wallet, resp); err != nil {
+		return nil, fmt.Errorf("invalid proof of the balance of the wallet %s of the tick %s: %v", wallet, tick, err)
+	}
+	return resp.Result, nil
+}
+
+// CurrentPortfolio returns the balances of the wallet over the ticks with a single aggregated proof.
+func (c *Client) CurrentPortfolio(ctx context.Context, wallet string, ticks []string) (*apis.Brc20VerifiableCurrentPortfolioResponse, error) {
+	var resp apis.Brc20VerifiableCurrentPortfolioResponse
+	query := url.Values{"wallet": {wallet}, "ticks": {strings.Join(ticks, ",")}}
+	if err := c.getJSON(ctx, "/v1/brc20_verifiable/current_portfolio", query, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Error != nil {
+		return nil, errors.New(*resp.Error)
+	}
+	if resp.Result == nil || resp.Proof == nil {
+		return nil, fmt.Errorf("incomplete portfolio of the wallet %s", wallet)
+	}
+	return &resp, nil
+}
+
+// VerifiedPortfolio returns the portfolio only if the aggregated proof is valid against the commitment
+// and covers every requested tick of the wallet.
+func (c *Client) VerifiedPortfolio(ctx context.Context, commitment, wallet string, ticks []string) (*apis.Brc20VerifiableCurrentPortfolioResult, error) {
+	rootC, err := apis.ParseCommitment(commitment)
+	if err != nil {
+		return nil, fmt.Errorf("invalid commitment %s: %v", commitment, err)
+	}
+	resp, err := c.CurrentPortfolio(ctx, wallet, ticks)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Result.Wallet != wallet {
+		return nil, fmt.Errorf("the portfolio of the wallet %s is returned instead of %s", resp.Result.Wallet, wallet)
+	}
+	covered := make(map[string]bool)
+	for _, balance := range resp.Result.Balances {
+		covered[balance.Tick] = true
+	}
+	for _, tick := range ticks {
+		if !covered[tick] {
+			return nil, fmt.Errorf("the portfolio of the wallet %s misses the tick %s", wallet, tick)
+		}
+	}
+	if _, err := apis.VerifyCurrentPortfolio(rootC, resp); err != nil {
+		return nil, fmt.Errorf("invalid proof of the portfolio of the wallet %s: %v", wallet, err)
+	}
+	return resp.Result, nil
+}
+
+// LatestStateProof returns the ord transfers of the latest block and the proof of the keys they accessed.
+// The response is nil if the committee indexer is not started with the committee mode or has not executed any block.
+func (c *Client) LatestStateProof(ctx context.Context) (*apis.Brc20VerifiableLatestStateProofResponse, error) {
+	var resp apis.Brc20VerifiableLatestStateProofResponse
+	if err := c.getJSON(ctx, "/v1/brc20_verifiable/latest_state_proof", nil, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Error != nil {
+		return nil, errors.New(*resp.Error)
+	}
+	return &resp, nil
+}
+
+// VerifiedLatestCommitment re-executes the latest block at the given height on the proven pre-state,
+// and returns the post-state commitment derived from the trusted commitment of the previous block.
+func (c *Client) VerifiedLatestCommitment(ctx context.Context, preCommitment string, height uint) (string, error) {
+	rootC, err := apis.ParseCommitment(preCommitment)
+	if err != nil {
+		return "", fmt.Errorf("invalid commitment %s: %v", preCommitment, err)
+	}
+	resp, err := c.LatestStateProof(ctx)
+	if err != nil {
+		return "", err
+	}
+	postRoot, err := apis.GeneratePostRoot(rootC, height, resp)
+	if err != nil {
+		return "", err
+	}
+	postBytes := postRoot.Commit().Bytes()
+	return base64.StdEncoding.EncodeToString(postBytes[:]), nil
+}
+
+// FetchCheckpoint downloads a published checkpoint, e.g. from a public S3 object.
+func (c *Client) FetchCheckpoint(ctx context.Context, rawURL string) (*checkpoint.Checkpoint, error) {
+	body, err := c.fetch(ctx, rawURL)
+	if err != nil {
+		return nil, err
+	}
+	ckpt, err := checkpoint.ParseCheckpoint(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the checkpoint %s: %v", rawURL, err)
+	}
+	if _, err := apis.ParseCommitment(ckpt.Commitment); err != nil {
+		return nil, fmt.Errorf("invalid commitment of the checkpoint %s: %v", rawURL, err)
+	}
+	return ckpt, nil
+}
+
+// SubscribeBlockHeight polls the committee indexers every interval and emits the block height whenever it changes.
+// The channel is closed once the context is done.
+func (c *Client) SubscribeBlockHeight(ctx context.Context, interval time.Duration) <-chan uint {
+	heights := make(chan uint)
+	go func() {
+		defer close(heights)
+		var last uint
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			height, err := c.BlockHeight(ctx)
+			if err == nil && height != last {
+				last = height
+				select {
+				case heights <- height:
+				case <-ctx.Done():
+					return
+				}
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return heights
+}
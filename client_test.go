@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/RiemaLabs/modular-indexer-committee/apis"
+	"github.com/RiemaLabs/modular-indexer-committee/client"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/sha3"
+)
+
+func Test_Client(t *testing.T) {
+	var catchupHeight uint = 779980
+	ordGetterTest, arguments := loadMain(782000)
+	queue, err := CatchupStage(ordGetterTest, &arguments, stateless.BRC20StartHeight-1, catchupHeight)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	ts := httptest.NewServer(apis.NewRouter(queue, "brc-20", true, false))
+	defer ts.Close()
+	// The first committee indexer is down, so the client has to fail over.
+	down := httptest.NewServer(nil)
+	down.Close()
+
+	c, err := client.New(down.URL, ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Backoff = 0
+	ctx := context.Background()
+
+	height, err := c.BlockHeight(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if height != catchupHeight {
+		t.Fatalf("Expected block height %d, got %d", catchupHeight, height)
+	}
+
+	capabilities, err := c.Capabilities(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(capabilities.MetaProtocols) != 1 || capabilities.MetaProtocols[0] != "brc-20" {
+		t.Fatalf("Unexpected meta protocols %v", capabilities.MetaProtocols)
+	}
+
+	rootBytes := queue.Header.Root.Commit().Bytes()
+	commitment := base64.StdEncoding.EncodeToString(rootBytes[:])
+	cp, err := c.Checkpoint(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cp.Height != catchupHeight || len(cp.Modules) != 1 || cp.Modules[0].Namespace != "/v1/brc20" || cp.Modules[0].Commitment != commitment {
+		t.Fatalf("Unexpected checkpoint %+v", cp)
+	}
+	if len(capabilities.Namespaces) != 1 || capabilities.Namespaces[0] != cp.Modules[0].Namespace {
+		t.Fatalf("Unexpected namespaces %v", capabilities.Namespaces)
+	}
+	resp, err := http.Get(ts.URL + cp.Modules[0].Namespace + "/block_height")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != fmt.Sprint(catchupHeight) {
+		t.Fatalf("Unexpected block height %s in the namespace", body)
+	}
+
+	// The keys are derived from the schema alone.
+	schema, err := c.StateSchema(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, space := range schema.KeySpaces {
+		hasher := sha3.NewLegacyKeccak256()
+		hasher.Write([]byte(strings.Join(space.Example.Inputs, "") + space.Suffix))
+		key := append(hasher.Sum(nil)[:schema.StemSize], space.Locations[0].LocationID)
+		if hex.EncodeToString(key) != space.Example.Key {
+			t.Fatalf("The key of %s isn't derived from the schema", space.Name)
+		}
+	}
+	balance, err := c.VerifiedBalanceOfWallet(ctx, commitment, "meme", "bc1prvqdfjku8359hk9uc2tdgg0xlwvsel2fjr9ysydmaas9x3kyzuvskuwmlq")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance.OverallBalance == "0" {
+		t.Fatal("Expected a non-zero balance")
+	}
+	if balance.StateRoot != commitment || balance.Height != queue.Header.Height {
+		t.Fatalf("Expected the balance proven against %s at height %d, got %s at height %d", commitment, queue.Header.Height, balance.StateRoot, balance.Height)
+	}
+
+	portfolio, err := c.VerifiedPortfolio(ctx, commitment, "bc1prvqdfjku8359hk9uc2tdgg0xlwvsel2fjr9ysydmaas9x3kyzuvskuwmlq", []string{"meme", "ordi"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if portfolio.Balances[0].OverallBalance != balance.OverallBalance {
+		t.Fatalf("Expected the portfolio balance %s, got %s", balance.OverallBalance, portfolio.Balances[0].OverallBalance)
+	}
+
+	// The proof must not verify against the state root of another block.
+	preBytes := queue.History[len(queue.History)-1].VerkleCommit
+	preCommitment := base64.StdEncoding.EncodeToString(preBytes[:])
+	if preCommitment != commitment {
+		if _, err := c.VerifiedBalanceOfWallet(ctx, preCommitment, "meme", "bc1prvqdfjku8359hk9uc2tdgg0xlwvsel2fjr9ysydmaas9x3kyzuvskuwmlq"); err == nil {
+			t.Fatal("Expected the proof to be invalid against the previous state root")
+		}
+	}
+
+	post, err := c.VerifiedLatestCommitment(ctx, preCommitment, catchupHeight)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if post != commitment {
+		t.Fatalf("Expected the post-state commitment %s, got %s", commitment, post)
+	}
+}
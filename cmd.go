@@ -3,8 +3,13 @@ package main
 import (
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/spf13/cobra"
+
+	"github.com/RiemaLabs/modular-indexer-committee/apis"
+	"github.com/RiemaLabs/modular-indexer-committee/ord"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
 )
 
 type RuntimeArguments struct {
@@ -15,10 +20,41 @@ type RuntimeArguments struct {
 	TestBlockHeightLimit uint
 	EnablePprof          bool
 	ConfigFilePath       string
+	Network              string
 	CommitteeIndexerName string
 	CommitteeIndexerURL  string
 	ProtocolName         string
 	MetricAddr           string
+	ExecShards           uint
+	Prefetch             uint
+	PrefetchWorkers      uint
+	WitnessPath          string
+	StateDBPath          string
+	EvictInterval        uint
+	EvictDepth           uint8
+	HistoryPath          string
+	EventsPath           string
+	PreImagesPath        string
+	PublicationsPath     string
+	SnapshotBaseline     uint
+	WriteAheadLog        bool
+	SatpointRPC          string
+	ProofCacheSize       int
+	GetterCacheSize      int
+	GetterCacheTTL       time.Duration
+	LatestHeightTTL      time.Duration
+	WSMaxClients         int
+	GRPCAddr             string
+	SecondaryCommitment  bool
+	WitnessHash          bool
+	BlockDeadline        time.Duration
+	ReorgDepth           uint
+	BisectA              string
+	BisectB              string
+	BisectReport         string
+	VerifyFraudProof     string
+	Replica              bool
+	MaxLag               uint
 }
 
 func NewRuntimeArguments() *RuntimeArguments {
@@ -36,6 +72,11 @@ leveraging Bitcoin's immutable and decentralized nature to provide a Turing-comp
 		`,
 		Version: fmt.Sprintf("%v (%v)", version, gitHash),
 		Run: func(cmd *cobra.Command, args []string) {
+			// The fraud proof is verified offline, without the config, the state or the chain.
+			if arguments.VerifyFraudProof != "" {
+				VerifyFraudProof(arguments.VerifyFraudProof)
+				return
+			}
 			if arguments.EnableService {
 				log.Println("Service mode is enabled")
 			} else {
@@ -60,6 +101,68 @@ leveraging Bitcoin's immutable and decentralized nature to provide a Turing-comp
 			log.Printf("The url of the committee indexer service is %s\n", arguments.CommitteeIndexerURL)
 			log.Printf("The meta protocol chosen is %s\n", arguments.ProtocolName)
 			log.Println("Metrics listen at:", arguments.MetricAddr)
+			if arguments.StateDBPath != "" {
+				log.Printf("Keep the state in the database %s\n", arguments.StateDBPath)
+				if arguments.EvictInterval != 0 {
+					log.Printf("Evict the verkle tree below the depth %d into the database every %d blocks\n", arguments.EvictDepth, arguments.EvictInterval)
+				}
+			} else if arguments.EnableStateRootCache {
+				log.Printf("Store a full baseline of the state cache every %d blocks\n", arguments.SnapshotBaseline)
+			}
+			if arguments.HistoryPath != "" {
+				log.Printf("Index the writes of every block in the database %s for the historical queries\n", arguments.HistoryPath)
+			}
+			if arguments.EventsPath != "" {
+				log.Printf("Keep the BRC-20 events of every block in the database %s\n", arguments.EventsPath)
+			}
+			if arguments.PreImagesPath != "" {
+				log.Printf("Keep the preimages of the keys written by every block in the database %s\n", arguments.PreImagesPath)
+			}
+			if arguments.EnableCommittee && arguments.PublicationsPath != "" {
+				log.Printf("Record the published checkpoints in the database %s\n", arguments.PublicationsPath)
+			}
+			if arguments.WitnessPath != "" {
+				log.Printf("Export the execution witness of every block to %s\n", arguments.WitnessPath)
+			}
+			if arguments.SatpointRPC != "" {
+				log.Println("Validate the moves of the transfer inscriptions with the raw transactions")
+			}
+			if arguments.ProofCacheSize > 0 {
+				log.Printf("Cache at most %d proofs of the current state root\n", arguments.ProofCacheSize)
+			}
+			if arguments.GetterCacheSize > 0 {
+				log.Printf("Cache at most %d results of the getter for %v\n", arguments.GetterCacheSize, arguments.GetterCacheTTL)
+			}
+			if arguments.EnableService && arguments.WSMaxClients > 0 {
+				log.Printf("Push the checkpoints, the events and the balance changes to at most %d subscribers\n", arguments.WSMaxClients)
+			}
+			if arguments.EnableService && arguments.GRPCAddr != "" {
+				log.Printf("Providing gRPC service at: %s\n", arguments.GRPCAddr)
+			}
+			if arguments.SecondaryCommitment {
+				log.Println("Publish the sparse Merkle root along with the verkle commitment")
+			}
+			if arguments.WitnessHash {
+				log.Println("Publish the hash of the execution witness of the block along with the checkpoint")
+			}
+			if arguments.BlockDeadline > 0 {
+				log.Printf("Roll back the new blocks not executed within %v\n", arguments.BlockDeadline)
+			}
+			if arguments.ReorgDepth > ord.BitcoinConfirmations {
+				log.Printf("Recover the reorgs of at most %d blocks automatically\n", arguments.ReorgDepth)
+			}
+			if arguments.ExecShards > 1 {
+				log.Printf("Execute the ticks of a block with %d shards\n", arguments.ExecShards)
+			}
+			if arguments.Prefetch > 0 {
+				log.Printf("Prefetch at most %d blocks ahead during the catch-up with %d workers\n", arguments.Prefetch, arguments.PrefetchWorkers)
+			}
+			if arguments.Replica {
+				log.Println("Serve the stored state as a read-only replica, without indexing the blocks or publishing the checkpoints")
+			}
+			if arguments.BisectA != "" || arguments.BisectB != "" {
+				log.Printf("Bisect the checkpoints of %s and %s, then trace the divergent blocks\n", arguments.BisectA, arguments.BisectB)
+			}
 
 			Execution(arguments)
 		},
@@ -72,9 +175,183 @@ leveraging Bitcoin's immutable and decentralized nature to provide a Turing-comp
 	rootCmd.Flags().UintVar(&arguments.TestBlockHeightLimit, "blockheight", 0, "When -test enabled, you can set TestBlockHeightLimit as a fixed value you want")
 	rootCmd.Flags().BoolVar(&arguments.EnablePprof, "pprof", false, "Enable the pprof HTTP handler (at `/debug/pprof/`)")
 	rootCmd.Flags().StringVar(&arguments.ConfigFilePath, "cfg", "config.json", "Indicate the path of config file")
+	rootCmd.Flags().StringVar(&arguments.Network, "network", "", "Indicate the Bitcoin network to index: mainnet, testnet, signet or regtest, which replaces the network of the config file")
 	rootCmd.Flags().StringVarP(&arguments.CommitteeIndexerName, "name", "n", "", "Indicate the name of the committee indexer service")
 	rootCmd.Flags().StringVarP(&arguments.CommitteeIndexerURL, "url", "u", "", "Indicate the url of the committee indexer service")
 	rootCmd.Flags().StringVar(&arguments.ProtocolName, "protocol", "brc-20", "Indicate the meta protocol supported by the committee indexer")
 	rootCmd.Flags().StringVar(&arguments.MetricAddr, "metrics", "0.0.0.0:8081", "Metrics listening address")
+	rootCmd.Flags().StringVar(&arguments.StateDBPath, "state-db", "", "Indicate the directory of the database keeping the state on the disk instead of the state root cache files")
+	rootCmd.Flags().UintVar(&arguments.EvictInterval, "evict-interval", 0, "With --state-db, indicate the number of blocks between the evictions of the verkle tree into the database, whose nodes are then resolved on demand, 0 keeps the whole tree in memory")
+	rootCmd.Flags().Uint8Var(&arguments.EvictDepth, "evict-depth", stateless.EvictDepth, "Indicate the depth of the subtrees evicted by --evict-interval, above which the nodes always stay in memory")
+	rootCmd.Flags().StringVar(&arguments.HistoryPath, "history", "", "Indicate the directory of the database indexing the writes of every block to query the balances at the past heights")
+	rootCmd.Flags().StringVar(&arguments.EventsPath, "events", "", "Indicate the directory of the database keeping the BRC-20 events of every block")
+	rootCmd.Flags().StringVar(&arguments.PreImagesPath, "preimages", "", "Indicate the directory of the database keeping the preimages of the keys written by every block, served at /v1/state/preimages")
+	rootCmd.Flags().StringVar(&arguments.PublicationsPath, "publications", "", "With --committee, indicate the directory of the database recording every published checkpoint along with where it's published, served at /v1/checkpoints")
+	rootCmd.Flags().UintVar(&arguments.SnapshotBaseline, "snapshot-baseline", stateless.SnapshotBaselineInterval, "Indicate the number of blocks between the full baselines of the state cache, in between which only the diffs of the blocks are stored, 0 stores a full baseline every time")
+	rootCmd.Flags().BoolVar(&arguments.WriteAheadLog, "wal", true, "Enable this flag to log the writes of every block ahead of applying them during the catch-up, so a crash recovers the state of the latest block instead of the latest state cache")
+	rootCmd.Flags().StringVar(&arguments.WitnessPath, "witness", "", "Indicate the directory to export the execution witness of every block")
+	rootCmd.Flags().UintVar(&arguments.ExecShards, "shards", 1, "Indicate the number of workers executing the ticks of a block concurrently")
+	rootCmd.Flags().UintVar(&arguments.Prefetch, "prefetch", 32, "Indicate the max number of blocks whose transfers are fetched ahead of the execution during the catch-up, 0 disables the prefetch")
+	rootCmd.Flags().UintVar(&arguments.PrefetchWorkers, "prefetch-workers", 4, "Indicate the number of blocks prefetched concurrently from the OPI database, other getters prefetch one block at once")
+	rootCmd.Flags().StringVar(&arguments.SatpointRPC, "satpoint", "", "Indicate the JSON-RPC url of bitcoind to validate the moves of the transfer inscriptions")
+	rootCmd.Flags().IntVar(&arguments.WSMaxClients, "ws-max-clients", 1000, "Indicate the max number of the websocket clients and the gRPC block streams subscribing to the checkpoints, the events and the balance changes, 0 disables them")
+	rootCmd.Flags().StringVar(&arguments.GRPCAddr, "grpc", "", "Indicate the listening address of the gRPC service, e.g. 0.0.0.0:9090, empty disables it")
+	rootCmd.Flags().IntVar(&arguments.ProofCacheSize, "proof-cache", 1024, "Indicate the max number of cached proofs of the current state root, 0 disables the cache")
+	rootCmd.Flags().IntVar(&arguments.GetterCacheSize, "getter-cache", 256, "Indicate the max number of the block hashes and the block transfers cached in front of the getter, 0 disables the cache")
+	rootCmd.Flags().DurationVar(&arguments.GetterCacheTTL, "getter-cache-ttl", 10*time.Minute, "Indicate the time the block hashes and the block transfers are cached, 0 keeps them until evicted")
+	rootCmd.Flags().DurationVar(&arguments.LatestHeightTTL, "latest-height-ttl", 5*time.Second, "Indicate the time the latest block height is cached when the getter cache is enabled, 0 always queries it")
+	rootCmd.Flags().BoolVar(&arguments.SecondaryCommitment, "secondary-commitment", false, "Enable this flag to compute a sparse Merkle root of the state and include it in checkpoints")
+	rootCmd.Flags().BoolVar(&arguments.WitnessHash, "witness-hash", false, "Enable this flag to include the hash of the execution witness of the block in checkpoints")
+	rootCmd.Flags().DurationVar(&arguments.BlockDeadline, "block-deadline", 0, "Indicate the deadline of executing a new block, e.g. 30s, after which the block is rolled back and retried, 0 disables the deadline")
+	rootCmd.Flags().UintVar(&arguments.ReorgDepth, "reorg-depth", ord.BitcoinConfirmations, "Indicate the number of the latest blocks whose diffs are kept to recover the reorgs, at least 6")
+	rootCmd.Flags().StringVar(&arguments.BisectA, "bisect-a", "", "Indicate the checkpoint history of a member to bisect, a directory of checkpoint files or s3://<bucket>/<name>")
+	rootCmd.Flags().StringVar(&arguments.BisectB, "bisect-b", "", "Indicate the checkpoint history of the other member to bisect, in the same form as --bisect-a")
+	rootCmd.Flags().StringVar(&arguments.BisectReport, "bisect-report", "bisect-report.json", "Indicate the path of the report of the bisect")
+	rootCmd.Flags().UintVar(&arguments.MaxLag, "max-lag", apis.MaxLag, "Indicate the number of blocks the indexed height may lag behind the chain tip before /healthz and /readyz fail")
+	rootCmd.Flags().BoolVar(&arguments.Replica, "replica", false, "Enable this flag to serve the APIs from the stored state or the snapshot of the replica member, following its diffs, without the getter or the checkpoints")
+	rootCmd.Flags().StringVar(&arguments.VerifyFraudProof, "verify-fraud-proof", "", "Indicate the path of a fraud proof to verify offline, then exit")
+	rootCmd.AddCommand(makeVerifyCmd())
+	rootCmd.AddCommand(makeExportCmd())
+	rootCmd.AddCommand(makeExportPreImagesCmd())
+	rootCmd.AddCommand(makeReplayCmd())
+	rootCmd.AddCommand(makeBackfillCmd())
 	return rootCmd
 }
+
+func makeBackfillCmd() *cobra.Command {
+	arguments := &BackfillArguments{}
+	backfillCmd := &cobra.Command{
+		Use:   "backfill",
+		Short: "Executes the past blocks through a protocol newly enabled on the stored state, without a reindex.",
+		Long: `Backfill loads the state stored by the committee indexer, either the state root cache or the state database,
+fetches the blocks from the given height up to the height of the state from the getter of the config, and executes
+them through the given protocol alone, into its namespace. The keys of the other protocols, BRC-20 included, are left
+as they are. The protocol must be enabled by the config and never executed by the state before, and the height must
+not be after its activation height. The backfilled state is stored and records the protocol, so the committee
+indexer starts with it enabled. The state must not be opened by a running committee indexer.
+		`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := Backfill(arguments); err != nil {
+				log.Fatalf("Failed to backfill the protocol: %v", err)
+			}
+		},
+	}
+	backfillCmd.Flags().StringVar(&arguments.ConfigFilePath, "cfg", "config.json", "Indicate the path of config file")
+	backfillCmd.Flags().StringVar(&arguments.Network, "network", "", "Indicate the Bitcoin network of the state: mainnet, testnet, signet or regtest, which replaces the network of the config file")
+	backfillCmd.Flags().StringVar(&arguments.StateDBPath, "state-db", "", "Indicate the directory of the state database, if the state is kept there instead of the state root cache files")
+	backfillCmd.Flags().StringVar(&arguments.PreImagesPath, "preimages", "", "Indicate the directory of the preimages database kept by --preimages, which records the keys backfilled as well")
+	backfillCmd.Flags().StringVar(&arguments.Module, "module", "", "Indicate the protocol to backfill, e.g. sns or bitmap")
+	backfillCmd.Flags().UintVar(&arguments.FromHeight, "from-height", 0, "Indicate the first block to backfill, at most the activation height of the protocol")
+	_ = backfillCmd.MarkFlagRequired("module")
+	_ = backfillCmd.MarkFlagRequired("from-height")
+	return backfillCmd
+}
+
+func makeReplayCmd() *cobra.Command {
+	arguments := &ReplayArguments{}
+	replayCmd := &cobra.Command{
+		Use:   "replay",
+		Short: "Re-executes the stored witnesses of a range of blocks and reports the keys diverging from the recorded execution.",
+		Long: `Replay re-executes every block of the range on the pre-state proven by its stored witness, with the rules of this
+binary, and compares the written values and the post-state commitment with those recorded by the witness, and with
+the checkpoints of a member if given. The divergent keys are printed along with their decoded meaning, i.e. the key
+space, the tick, the pkscript, the wallet or the inscription, and the location, as far as the transfers of the block
+name their preimages. It exits with an error if any block diverges.
+		`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := Replay(arguments); err != nil {
+				log.Fatalf("Failed to replay the blocks: %v", err)
+			}
+		},
+	}
+	replayCmd.Flags().StringVar(&arguments.WitnessPath, "witness", "", "Indicate the directory of the execution witnesses exported by --witness")
+	replayCmd.Flags().UintVar(&arguments.From, "from", 0, "Indicate the first block to replay")
+	replayCmd.Flags().UintVar(&arguments.To, "to", 0, "Indicate the last block to replay")
+	replayCmd.Flags().StringVar(&arguments.Checkpoints, "checkpoints", "", "Indicate the directory of the checkpoint files of a member to compare with")
+	replayCmd.Flags().StringVar(&arguments.MetaProtocol, "protocol", "brc-20", "Indicate the meta protocol of the checkpoints")
+	replayCmd.Flags().StringVar(&arguments.Report, "report", "", "Indicate the path of the replay report, empty only prints the divergent blocks")
+	_ = replayCmd.MarkFlagRequired("witness")
+	_ = replayCmd.MarkFlagRequired("from")
+	_ = replayCmd.MarkFlagRequired("to")
+	return replayCmd
+}
+
+func makeExportCmd() *cobra.Command {
+	arguments := &ExportArguments{}
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Exports the stored BRC-20 state: the ticks, their supplies and all the balances.",
+		Long: `Export loads the state stored by the committee indexer, either the state root cache or the state database,
+and writes the metadata and the supplies of every tick and the balances of every holder, along with the commitment
+of the state, as CSV or JSON. The ticks and the holders are decoded by the census and the holders index stored along
+with the state. Only the latest stored height may be exported, and the state database must not be opened by
+a running committee indexer.
+		`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := Export(arguments); err != nil {
+				log.Fatalf("Failed to export the state: %v", err)
+			}
+		},
+	}
+	exportCmd.Flags().UintVar(&arguments.Height, "height", 0, "Indicate the height of the state to export, which must be the height of the stored state, 0 for the stored state")
+	exportCmd.Flags().StringVar(&arguments.Format, "format", "csv", "Indicate the format of the export: csv or json")
+	exportCmd.Flags().StringVarP(&arguments.Output, "output", "o", "", "Indicate the path of the export, empty writes to the standard output")
+	exportCmd.Flags().StringVar(&arguments.StateDBPath, "state-db", "", "Indicate the directory of the state database, if the state is kept there instead of the state root cache files")
+	return exportCmd
+}
+
+func makeExportPreImagesCmd() *cobra.Command {
+	arguments := &PreImagesArguments{}
+	exportCmd := &cobra.Command{
+		Use:   "export-preimages",
+		Short: "Exports the preimages of the keys of the state kept by --preimages.",
+		Long: `Export-preimages writes every preimage kept by --preimages as a JSON line: the stem, the domain of the protocol,
+the key space, the inputs of the key such as the tick and the pkscript, and the namespace if the protocol is
+namespaced. The key of a value in the state is its stem followed by the location ID, so the preimages tell the keys of
+a KV dump or of a proof without deriving them. The database must not be opened by a running committee indexer.
+		`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := ExportPreImages(arguments); err != nil {
+				log.Fatalf("Failed to export the preimages: %v", err)
+			}
+		},
+	}
+	exportCmd.Flags().StringVar(&arguments.PreImagesPath, "preimages", "", "Indicate the directory of the preimages database kept by --preimages")
+	exportCmd.Flags().StringVarP(&arguments.Output, "output", "o", "", "Indicate the path of the export, empty writes to the standard output")
+	_ = exportCmd.MarkFlagRequired("preimages")
+	return exportCmd
+}
+
+func makeVerifyCmd() *cobra.Command {
+	arguments := &VerifyArguments{}
+	verifyCmd := &cobra.Command{
+		Use:   "verify",
+		Short: "Verifies the balance served by the committee indexers against a published checkpoint.",
+		Long: `Verify fetches the checkpoint published by a committee member at the latest block of the committee indexers,
+requests the balance of the pkscript with its proof from the committee indexers, and prints the balance only if the proof
+is valid against the commitment of the checkpoint. Nothing but the checkpoint and the public key is trusted.
+		`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := Verify(arguments); err != nil {
+				log.Fatalf("Failed to verify the balance: %v", err)
+			}
+		},
+	}
+	verifyCmd.Flags().StringSliceVar(&arguments.Committees, "committee", nil, "Indicate the URLs of the committee indexers serving the proofs, tried in turn")
+	verifyCmd.Flags().StringVar(&arguments.Source, "checkpoints", "", "Indicate where the member publishes its checkpoints: a directory, s3://<bucket>, an http(s) URL or da://<namespaceID>")
+	verifyCmd.Flags().StringVar(&arguments.Publisher, "publisher", "", "Indicate the name of the member in its checkpoints")
+	verifyCmd.Flags().StringVar(&arguments.PublicKey, "public-key", "", "Indicate the hex of the public key signing the checkpoints of the member, without which the checkpoints are trusted unsigned")
+	verifyCmd.Flags().StringVar(&arguments.MetaProtocol, "protocol", "brc-20", "Indicate the meta protocol of the checkpoints")
+	verifyCmd.Flags().StringVar(&arguments.Network, "network", "", "Indicate the Bitcoin network of the checkpoints: mainnet, testnet, signet or regtest")
+	verifyCmd.Flags().StringVar(&arguments.DANetwork, "da-network", "Pre-Alpha Testnet", "Indicate the network of the DA layer read by da://<namespaceID>: Pre-Alpha Testnet or Testnet")
+	verifyCmd.Flags().UintVar(&arguments.Height, "height", 0, "Indicate the height of the balance, which must be the latest block of the committee indexers, 0 for the latest block")
+	verifyCmd.Flags().StringVar(&arguments.Tick, "tick", "", "Indicate the tick of the balance")
+	verifyCmd.Flags().StringVar(&arguments.Pkscript, "pkscript", "", "Indicate the pkscript of the balance")
+	_ = verifyCmd.MarkFlagRequired("committee")
+	return verifyCmd
+}
@@ -0,0 +1,309 @@
+// Package committee embeds the indexing pipeline of the committee indexer into another service: the inscriptions read
+// by the getter are executed on the state, block after block, and the checkpoints of the states are published. It
+// parses no flags and reads no config file, and the failures of the getter, of the execution and of the publications
+// are returned to the caller. The binary runs the same pipeline, along with the APIs and the operations of the members.
+//
+// The state and the rules are still configured by the process-wide settings of the stateless and the brc20 packages,
+// e.g. stateless.ReorgDepth, so a process runs a single Committee at once. The storage of the state still panics on
+// its failures, such as a corrupt state root cache, state layout or state database, and so do the optional indexes of
+// the history, the events and the preimages. The APIs of the queue are served by apis.NewRouter and
+// apis.NewGRPCServer, since apis.StartService and apis.StartGRPCService exit the process once the server fails.
+package committee
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/holiman/uint256"
+
+	"github.com/RiemaLabs/modular-indexer-committee/checkpoint"
+	"github.com/RiemaLabs/modular-indexer-committee/internal/retry"
+	"github.com/RiemaLabs/modular-indexer-committee/ord"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/brc20"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/getter"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/sanity"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+// The interval between the rounds following the new blocks by default.
+const DefaultInterval = time.Minute
+
+// ErrRunning is returned by New while another Committee of the process isn't stopped.
+var ErrRunning = errors.New("a committee is already running in the process")
+
+// ErrNotStarted is returned by the queries before Start has caught up.
+var ErrNotStarted = errors.New("the committee hasn't caught up yet")
+
+type Config struct {
+	// The first block executed without a state root cache, see stateless.BRC20StartHeight.
+	GenesisHeight uint
+	// Whether the state is stored into the state root cache, so that a restart resumes from it.
+	StateRootCache bool
+	// The prefetch of the catch-up, see CatchupOptions.
+	Prefetch        uint
+	PrefetchWorkers uint
+	// The interval between the rounds following the new blocks, DefaultInterval if 0.
+	Interval time.Duration
+	// The identity of the indexer in the published checkpoints.
+	Indexer checkpoint.IndexerIdentification
+	// Signs the published checkpoints, nil publishes them unsigned.
+	Signer checkpoint.Signer
+	// Which checkpoints are published, every block by default.
+	Schedule checkpoint.Schedule
+	// The checkpoints not published yet, kept in memory unless the path is set.
+	Queue checkpoint.QueueConfig
+	// The timeout of a publication, 15 seconds by default.
+	PublishTimeout time.Duration
+	// Whether the checkpoints carry the witness hash of their blocks, see stateless.WitnessHash.
+	WitnessHash bool
+}
+
+// Committee follows the blocks of the getter and publishes the checkpoints of the states by the publisher.
+type Committee struct {
+	cfg       Config
+	getter    getter.OrdGetter
+	publisher checkpoint.Publisher
+	pending   *checkpoint.Queue
+
+	mu        sync.Mutex
+	queue     *stateless.Queue
+	published *checkpoint.Checkpoint
+	cancel    context.CancelFunc
+	done      chan struct{}
+	stopped   bool
+	err       error
+}
+
+var running atomic.Bool
+
+// New returns the committee following the getter. The publisher may be nil, which publishes no checkpoint.
+func New(cfg Config, ordGetter getter.OrdGetter, publisher checkpoint.Publisher) (*Committee, error) {
+	if ordGetter == nil {
+		return nil, errors.New("the getter is required")
+	}
+	if cfg.GenesisHeight == 0 {
+		return nil, errors.New("the genesis height is required")
+	}
+	if cfg.Schedule.Policy == "" {
+		cfg.Schedule.Policy = checkpoint.PolicyEveryBlock
+	}
+	if err := cfg.Schedule.Validate(stateless.ReorgDepth); err != nil {
+		return nil, err
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultInterval
+	}
+	if cfg.PublishTimeout <= 0 {
+		cfg.PublishTimeout = 15 * time.Second
+	}
+	pending, err := checkpoint.NewQueue(cfg.Queue)
+	if err != nil {
+		return nil, err
+	}
+	if !running.CompareAndSwap(false, true) {
+		return nil, ErrRunning
+	}
+	return &Committee{cfg: cfg, getter: ordGetter, publisher: publisher, pending: pending}, nil
+}
+
+// Start catches up with the latest block of the getter, then follows the new blocks in the background until Stop is
+// called or a block fails. The catch-up stops once the context is done.
+func (c *Committee) Start(ctx context.Context) error {
+	c.mu.Lock()
+	started, stopped := c.done != nil, c.stopped
+	c.mu.Unlock()
+	if started || stopped {
+		return errors.New("the committee is already started")
+	}
+	latestHeight, err := c.getter.GetLatestBlockHeight()
+	if err != nil {
+		return fmt.Errorf("failed to get the latest block height: %w", err)
+	}
+	header := stateless.LoadHeader(c.cfg.StateRootCache, c.cfg.GenesisHeight-1)
+	if err := MigrateHeader(header, c.cfg.StateRootCache); err != nil {
+		return err
+	}
+	queue, err := Catchup(ctx, c.getter, header, latestHeight, CatchupOptions{
+		StoreCache:      c.cfg.StateRootCache,
+		Prefetch:        c.cfg.Prefetch,
+		PrefetchWorkers: c.cfg.PrefetchWorkers,
+	})
+	if err != nil {
+		return err
+	}
+
+	followCtx, cancel := context.WithCancel(context.Background())
+	c.mu.Lock()
+	c.queue, c.cancel, c.done = queue, cancel, make(chan struct{})
+	c.mu.Unlock()
+	c.publish(false)
+	go c.follow(followCtx)
+	return nil
+}
+
+func (c *Committee) follow(ctx context.Context) {
+	defer close(c.done)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(c.cfg.Interval):
+		}
+		round, err := Follow(ctx, c.queue, c.getter)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil && !transient(err) {
+			c.mu.Lock()
+			c.err = err
+			c.mu.Unlock()
+			log.Printf("Stop following the blocks: %v", err)
+			return
+		}
+		if err != nil {
+			log.Printf("Retry following the blocks at the next round: %v", err)
+			continue
+		}
+		c.publish(round.CatchingUp())
+	}
+}
+
+// transient tells whether the round failed by an error which the next round may not hit.
+func transient(err error) bool {
+	return errors.Is(err, retry.ErrUnavailable) || errors.Is(err, sanity.ErrQuarantined) || errors.Is(err, stateless.ErrDeadlineExceeded)
+}
+
+// publish queues the checkpoints of the kept states due by the schedule, then publishes the queued ones in order
+// until one fails, which is retried at the next round.
+func (c *Committee) publish(catchingUp bool) {
+	if c.publisher == nil {
+		return
+	}
+	method := c.publisher.Method()
+	c.queue.RLock()
+	states := make([]*stateless.DiffState, 0, len(c.queue.History)+1)
+	for _, h := range c.queue.History {
+		states = append(states, &h)
+	}
+	latest := LatestState(c.queue)
+	states = append(states, &latest)
+	c.queue.RUnlock()
+
+	for _, s := range DueCheckpoints(states, c.cfg.Schedule, latest.Height, false, catchingUp) {
+		if c.pending.Queued(method, strconv.FormatUint(uint64(s.Height), 10), s.Hash) {
+			continue
+		}
+		cp := NewCheckpoint(c.cfg.Indexer, s, c.cfg.WitnessHash)
+		if c.cfg.Signer != nil {
+			if err := cp.Sign(c.cfg.Signer); err != nil {
+				log.Printf("Unable to sign the checkpoint at height %s due to: %v", cp.Height, err)
+				continue
+			}
+		}
+		if err := c.pending.Push(method, cp, 0); err != nil {
+			log.Printf("Unable to queue the checkpoint at height %s by %s due to: %v", cp.Height, method, err)
+		}
+	}
+
+	for _, p := range c.pending.Due(method) {
+		cp := p.Checkpoint
+		ctx, cancel := context.WithTimeout(context.Background(), c.cfg.PublishTimeout)
+		err := c.publisher.Publish(ctx, &cp)
+		cancel()
+		if err != nil {
+			if _, qerr := c.pending.Fail(method, err); qerr != nil {
+				log.Printf("Unable to save the checkpoint queue due to: %v", qerr)
+			}
+			log.Printf("Unable to publish the checkpoint by %s at height %s due to: %v", method, cp.Height, err)
+			return
+		}
+		if err := c.pending.Done(method, &cp); err != nil {
+			log.Printf("Unable to save the checkpoint queue due to: %v", err)
+		}
+		c.mu.Lock()
+		c.published = &cp
+		c.mu.Unlock()
+	}
+}
+
+// Stop stops following the blocks after the block in progress, and stores the finalized state into the state root
+// cache, if enabled. It returns the error which stopped the committee before, if any. The committee can't be
+// started again, but another one can be created.
+func (c *Committee) Stop() error {
+	c.mu.Lock()
+	if c.stopped {
+		c.mu.Unlock()
+		return c.Err()
+	}
+	c.stopped = true
+	cancel, done := c.cancel, c.done
+	c.mu.Unlock()
+	defer running.Store(false)
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+	<-done
+	if c.cfg.StateRootCache {
+		if err := c.queue.StoreFinalized(); err != nil {
+			return fmt.Errorf("failed to store the finalized state: %w", err)
+		}
+	}
+	return c.Err()
+}
+
+// Err returns the error which stopped following the blocks, nil while following them.
+func (c *Committee) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// Queue returns the queue of the latest states, e.g. to serve the APIs by apis.NewRouter, nil before Start has caught
+// up.
+func (c *Committee) Queue() *stateless.Queue {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.queue
+}
+
+// Latest returns the unsigned checkpoint of the latest executed block.
+func (c *Committee) Latest() (checkpoint.Checkpoint, error) {
+	queue := c.Queue()
+	if queue == nil {
+		return checkpoint.Checkpoint{}, ErrNotStarted
+	}
+	queue.RLock()
+	latest := LatestState(queue)
+	queue.RUnlock()
+	return NewCheckpoint(c.cfg.Indexer, &latest, c.cfg.WitnessHash), nil
+}
+
+// Published returns the latest checkpoint published by the committee, nil if none.
+func (c *Committee) Published() *checkpoint.Checkpoint {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.published
+}
+
+// Balance returns the available and the overall balances of the pkscript on the tick at the latest executed block,
+// along with its height.
+func (c *Committee) Balance(tick string, pkscript ord.Pkscript) (*uint256.Int, *uint256.Int, uint, error) {
+	queue := c.Queue()
+	if queue == nil {
+		return nil, nil, 0, ErrNotStarted
+	}
+	queue.RLock()
+	defer queue.RUnlock()
+	_, _, available, overall, err := brc20.GetBalances(queue.Header, brc20.NormalizeTick(tick), pkscript)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	return available, overall, queue.Header.Height, nil
+}
@@ -0,0 +1,302 @@
+package committee
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/RiemaLabs/modular-indexer-committee/checkpoint"
+	"github.com/RiemaLabs/modular-indexer-committee/internal/metrics"
+	"github.com/RiemaLabs/modular-indexer-committee/internal/retry"
+	"github.com/RiemaLabs/modular-indexer-committee/internal/tracing"
+	"github.com/RiemaLabs/modular-indexer-committee/ord"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/brc20"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/getter"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/protocol"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+// CatchupOptions tunes Catchup.
+type CatchupOptions struct {
+	// Whether the state is stored into the state root cache every 1000 blocks, at the end and once the context is done.
+	StoreCache bool
+	// The number of the upcoming blocks fetched while the current block is executed, and the concurrent fetches of
+	// them, 0 disables the prefetch.
+	Prefetch        uint
+	PrefetchWorkers uint
+	// How long to wait before fetching a block again once the getter is unavailable, retry.DefaultBackoff if nil.
+	Pause func() time.Duration
+}
+
+// MigrateHeader checks the protocols enabled on the loaded state, see Header.CheckModules, migrates it to the key
+// layout of the rules, and stores the migrated state right away into the state root cache if storeCache is set.
+func MigrateHeader(header *stateless.Header, storeCache bool) error {
+	if err := header.CheckModules(); err != nil {
+		return err
+	}
+	migrated, err := migrateLayout(header)
+	if err != nil || !migrated || !storeCache {
+		return err
+	}
+	return stateless.StoreHeader(header, stateless.SnapshotEvictHeight(header.Height))
+}
+
+// Backfill migrates the loaded state to the key layout of the rules, executes the blocks of the getter from the height
+// from up to the height of the state through the protocol name alone, see Header.Backfill, checks the other protocols
+// enabled on the state, and stores the state into the state root cache if storeCache is set.
+func Backfill(ordGetter getter.OrdGetter, header *stateless.Header, name string, from uint, storeCache bool) error {
+	if _, err := migrateLayout(header); err != nil {
+		return err
+	}
+	if err := header.Backfill(ordGetter, name, from); err != nil {
+		return fmt.Errorf("failed to backfill the protocol %s on the state at height %d: %w", name, header.Height, err)
+	}
+	if err := header.CheckModules(); err != nil {
+		return err
+	}
+	if !storeCache {
+		return nil
+	}
+	return stateless.StoreHeader(header, stateless.SnapshotEvictHeight(header.Height))
+}
+
+// migrateLayout migrates the loaded state to the key scheme and the key layout of the rules, and tells whether it did.
+func migrateLayout(header *stateless.Header) (bool, error) {
+	// A state of another key scheme is only migrated given the preimages of its keys, see Header.MigrateKeyScheme.
+	migrated := false
+	if scheme := stateless.CurrentLayout().KeyScheme; scheme != protocol.KeySchemeFlag(protocol.Keys) {
+		if scheme == "" {
+			scheme = protocol.KeySchemeKeccak256
+		}
+		if stateless.PreImagesPath == "" {
+			return false, fmt.Errorf("the state at height %d is of the key scheme %s rather than %s, reindex it or migrate it with the preimages of its keys",
+				header.Height, scheme, protocol.Keys.Name())
+		}
+		if _, err := header.MigratePreImages(protocol.Keys); err != nil {
+			return false, fmt.Errorf("failed to migrate the state at height %d from the key scheme %s by the kept preimages: %v", header.Height, scheme, err)
+		}
+		migrated = true
+	}
+	if stateless.CurrentLayout().Version != brc20.SchemaVersion {
+		migrations, err := header.Migrate(brc20.SchemaVersion)
+		if err != nil {
+			return migrated, fmt.Errorf("failed to migrate the state at height %d: %v", header.Height, err)
+		}
+		log.Printf("Migrated the state at height %d by %d migrations", header.Height, len(migrations))
+		migrated = true
+	}
+	return migrated, nil
+}
+
+// Catchup executes the blocks on the header up to the latest height, except the ones kept for the reorgs which are
+// executed by the returned queue. Once the context is done, the catch-up stops after the block in progress and
+// returns the error of the context.
+func Catchup(ctx context.Context, ordGetter getter.OrdGetter, header *stateless.Header, latestHeight uint, opts CatchupOptions) (*stateless.Queue, error) {
+	pause := opts.Pause
+	if pause == nil {
+		pause = func() time.Duration { return retry.DefaultBackoff }
+	}
+	storeHeader := func() {
+		if !opts.StoreCache {
+			return
+		}
+		if err := stateless.StoreHeader(header, stateless.SnapshotEvictHeight(header.Height)); err != nil {
+			log.Printf("Failed to store the cache at height: %d", header.Height)
+		}
+	}
+	curHeight := header.Height
+	log.Printf("Fast catchup to the lateset block height! From %d to %d \n", curHeight, latestHeight)
+
+	// The queue keeps the ReorgDepth latest blocks after the state, which a chain shorter than the state height plus
+	// ReorgDepth, e.g. a new regtest chain, does not have yet.
+	if latestHeight < curHeight+stateless.ReorgDepth {
+		return nil, fmt.Errorf("the stored stateRoot at height %d is too advanced to handle reorg situations: the queue needs %d blocks up to the latest height %d", curHeight, stateless.ReorgDepth, latestHeight)
+	}
+	catchupHeight := latestHeight - stateless.ReorgDepth
+
+	// Nothing reads the tree until the catch-up ends, so the tree of each block is built along with the next block.
+	header.Pipeline(true)
+	if catchupHeight > curHeight {
+		// The transfers of the upcoming blocks are fetched while the current block is executed.
+		fetcher := ordGetter
+		if opts.Prefetch > 0 {
+			prefetcher := getter.NewPrefetcher(ordGetter, curHeight+1, catchupHeight, opts.Prefetch, opts.PrefetchWorkers)
+			defer prefetcher.Close()
+			fetcher = prefetcher
+		}
+		for i := curHeight + 1; i <= catchupHeight; i++ {
+			if ctx.Err() != nil {
+				log.Printf("Saving cache file. Please don't force exit.")
+				storeHeader()
+				return nil, ctx.Err()
+			}
+			blockCtx, span := tracing.Start(context.Background(), "block", tracing.Height(i))
+			_, fetchSpan := tracing.Start(blockCtx, "fetch", tracing.Height(i))
+			ordTransfer, err := fetcher.GetOrdTransfers(i)
+			tracing.End(fetchSpan, err)
+			if errors.Is(err, retry.ErrUnavailable) {
+				// Retry the block once the upstream is expected back, unless the context is done meanwhile.
+				tracing.End(span, err)
+				d := pause()
+				log.Printf("Pause the catch-up at block %d for %v: %v", i, d, err)
+				metrics.Stage.Set(metrics.StagePaused)
+				select {
+				case <-ctx.Done():
+				case <-time.After(d):
+				}
+				metrics.Stage.Set(metrics.StageCatchup)
+				i--
+				continue
+			}
+			if err != nil {
+				tracing.End(span, err)
+				return nil, err
+			}
+			header.Lock()
+			stateless.ExecContext(blockCtx, header, ordTransfer, i)
+			err = header.PagingContext(blockCtx, ordGetter, false, stateless.NodeResolveFn)
+			header.Unlock()
+			tracing.End(span, err)
+			if err != nil {
+				return nil, err
+			}
+			if i%1000 == 0 {
+				log.Printf("Blocks: %d / %d \n", i, catchupHeight)
+				storeHeader()
+			}
+		}
+		if prefetcher, ok := fetcher.(*getter.Prefetcher); ok {
+			prefetcher.Close()
+		}
+	}
+
+	header.Pipeline(false)
+	// Currently, header.Height equals to catchupHeight.
+
+	ots, err := ordGetter.GetOrdTransfers(catchupHeight)
+	if err != nil {
+		return nil, err
+	}
+	header.OrdTrans = ots
+
+	storeHeader()
+	// The nodes left on the disk are resolved before the tree is served to concurrent readers, unless the tree is
+	// evicted while serving.
+	header.Hydrate()
+
+	queue, err := stateless.NewQueues(ordGetter, header, true, catchupHeight+1)
+	if err != nil {
+		return nil, err
+	}
+	if queue.LatestHeight() != latestHeight {
+		return nil, fmt.Errorf("mismatched state height: %d and catchup height: %d", queue.LatestHeight(), latestHeight)
+	}
+	return queue, nil
+}
+
+// Round is a round of Follow.
+type Round struct {
+	// The latest height executed before the round, and the latest height of the getter, 0 if it failed.
+	Previous uint
+	Tip      uint
+	// The first height executed again after a reorg, 0 if none.
+	Reorg uint
+}
+
+// CatchingUp tells whether the round is one of many blocks to execute, whose checkpoints and audits may be skipped.
+func (r Round) CatchingUp() bool {
+	return r.Tip > r.Previous+ord.BitcoinConfirmations
+}
+
+// Follow executes the new blocks of the getter on the queue, then rolls the queue back and executes the blocks again
+// if the getter reorganized them. The errors are wrapped, so that the caller tells the transient ones, e.g.
+// retry.ErrUnavailable, sanity.ErrQuarantined or stateless.ErrDeadlineExceeded, from the fatal ones.
+func Follow(ctx context.Context, queue *stateless.Queue, ordGetter getter.OrdGetter) (Round, error) {
+	round := Round{Previous: queue.LatestHeight()}
+	latestHeight, err := ordGetter.GetLatestBlockHeight()
+	if err != nil {
+		return round, fmt.Errorf("failed to get the latest block height: %w", err)
+	}
+	round.Tip = latestHeight
+	metrics.LatestHeight.Set(float64(latestHeight))
+
+	if round.Previous < latestHeight {
+		metrics.Stage.Set(metrics.StageUpdating)
+		err := queue.UpdateContext(ctx, ordGetter, latestHeight)
+		metrics.Stage.Set(metrics.StageServing)
+		if err != nil {
+			return round, fmt.Errorf("failed to update the queue: %w", err)
+		}
+		if ctx.Err() != nil {
+			return round, ctx.Err()
+		}
+	}
+
+	reorgHeight, err := queue.CheckForReorg(ordGetter)
+	if err != nil {
+		return round, fmt.Errorf("failed to check the reorganization: %w", err)
+	}
+	if reorgHeight != 0 {
+		metrics.Stage.Set(metrics.StageReorg)
+		err := queue.Recovery(ordGetter, reorgHeight)
+		metrics.Stage.Set(metrics.StageServing)
+		if err != nil {
+			return round, fmt.Errorf("failed to recover the reorganization from %d: %w", reorgHeight, err)
+		}
+		round.Reorg = reorgHeight
+	}
+	return round, nil
+}
+
+// LatestState describes the latest block executed by the queue.
+func LatestState(queue *stateless.Queue) stateless.DiffState {
+	return stateless.DiffState{
+		Height:          queue.Header.Height,
+		Hash:            queue.Header.Hash,
+		VerkleCommit:    queue.Header.Root.Commit().Bytes(),
+		SecondaryCommit: queue.Header.SecondaryRoot(),
+		EventCount:      queue.Header.PagedEvents(),
+		TickCount:       stateless.CurrentCensus(0).TotalTicks,
+		Access:          stateless.AccessList{},
+	}
+}
+
+// DueCheckpoints returns the states whose checkpoints are due by the schedule. While catching up, only the latest due
+// checkpoint is returned if the schedule suppresses the others.
+func DueCheckpoints(hs []*stateless.DiffState, schedule checkpoint.Schedule, latestHeight uint, demanded bool, catchingUp bool) []*stateless.DiffState {
+	due := make([]*stateless.DiffState, 0, len(hs))
+	for _, h := range hs {
+		if schedule.Due(h.Height, latestHeight, demanded) {
+			due = append(due, h)
+		}
+	}
+	if schedule.SuppressCatchup && catchingUp && len(due) > 1 {
+		due = due[len(due)-1:]
+	}
+	return due
+}
+
+// NewCheckpoint returns the checkpoint of the state by the indexer, along with the layout of the state and the
+// witness hash of the block if witnessHash is set. The rules version and the network are the ones indexed.
+func NewCheckpoint(indexer checkpoint.IndexerIdentification, state *stateless.DiffState, witnessHash bool) checkpoint.Checkpoint {
+	indexer.RulesVersion = brc20.RulesVersion()
+	indexer.Network = ord.IndexedNetwork.Tag()
+	commitment := base64.StdEncoding.EncodeToString(state.VerkleCommit[:])
+	c := checkpoint.NewCheckpoint(&indexer, state.Height, state.Hash, commitment)
+	c.EventCount, c.TickCount = uint64(state.EventCount), uint64(state.TickCount)
+	layout := stateless.CurrentLayout()
+	c.SchemaVersion, c.KeyScheme = layout.Version, layout.KeyScheme
+	for _, m := range layout.Migrations {
+		c.Migrations = append(c.Migrations, checkpoint.Migration(m))
+	}
+	if stateless.SecondaryCommitment {
+		c.SecondaryCommitment = base64.StdEncoding.EncodeToString(state.SecondaryCommit[:])
+	}
+	if witnessHash {
+		c.WitnessHash = stateless.WitnessHash(state.Height, state.VerkleCommit)
+	}
+	return c
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord/brc20"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+func loadDeployedTickWithLimits(t *testing.T, tick string, limits brc20.ContentLimits) bool {
+	brc20.Limits = limits
+	defer func() { brc20.Limits = brc20.ContentLimits{} }()
+
+	ordGetterTest, arguments := loadMain(782000)
+	queue, err := CatchupStage(ordGetterTest, &arguments, stateless.BRC20StartHeight-1, 779850)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exists, err := queue.Header.GetUInt256(brc20.GetTickHash(tick, brc20.Exists))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return !exists.IsZero()
+}
+
+func Test_ContentLimits(t *testing.T) {
+	skipped := brc20.SkippedContents(brc20.SkipOversized)
+	// ordi is deployed at the block 779832.
+	if loadDeployedTickWithLimits(t, "ordi", brc20.ContentLimits{MaxContentSize: 16}) {
+		t.Fatal("Expected the oversized deploy to be skipped")
+	}
+	if brc20.SkippedContents(brc20.SkipOversized) == skipped {
+		t.Fatal("Expected the oversized contents to be counted")
+	}
+	if !loadDeployedTickWithLimits(t, "ordi", brc20.ContentLimits{MaxContentSize: 1024, MaxJSONDepth: 1}) {
+		t.Fatal("Expected the flat deploy to be accepted")
+	}
+
+	limits := brc20.ContentLimits{MaxJSONDepth: 2}
+	if reason := limits.SkipContent([]byte(`{"tick":"[[[{{{","op":["}}}\"]"]}`)); reason != "" {
+		t.Fatalf("Expected the brackets in strings to be ignored, got %s", reason)
+	}
+	if reason := limits.SkipContent([]byte(`{"op":[[["mint"]]]}`)); reason != brc20.SkipTooDeep {
+		t.Fatalf("Expected the deep content to be skipped, got %s", reason)
+	}
+}
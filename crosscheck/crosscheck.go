@@ -0,0 +1,337 @@
+// Package crosscheck compares the checkpoints published by the other committee members with the local ones, so that
+// a divergence is noticed by the members themselves rather than by the light indexers relying on them.
+package crosscheck
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/RiemaLabs/modular-indexer-committee/checkpoint"
+	"github.com/RiemaLabs/modular-indexer-committee/internal/metrics"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/reexec"
+)
+
+// The defaults of the config.
+const (
+	DefaultWait = 60
+	DefaultKeep = 100
+)
+
+type Config struct {
+	Enabled bool     `json:"enabled"`
+	Members []Member `json:"members"`
+	// The max number of seconds to wait for the checkpoints of the members after a block (default 60).
+	Wait int `json:"wait"`
+	// The number of the latest checked heights kept (default 100).
+	Keep int `json:"keep"`
+	// The directory to save the fraud proofs of the diverging checkpoints to. Empty disables the fraud proofs.
+	ProofDir string `json:"proofDir"`
+}
+
+type Member struct {
+	// The name of the member in its checkpoints.
+	Name string `json:"name"`
+	// Where the member publishes its checkpoints: a directory, s3://<bucket>, or the http(s) URL serving the files.
+	Source string `json:"source"`
+	// The hex of the public key signing the checkpoints of the member, optional. If set, the checkpoints
+	// not signed by the key are invalid.
+	PublicKey string `json:"publicKey"`
+}
+
+func (cfg Config) Validate() error {
+	names := make(map[string]bool)
+	for _, m := range cfg.Members {
+		if m.Name == "" || m.Source == "" {
+			return errors.New("the name and the source of the cross-checked member are required")
+		}
+		if names[m.Name] {
+			return fmt.Errorf("the member %s is cross-checked twice", m.Name)
+		}
+		names[m.Name] = true
+		if _, err := hex.DecodeString(m.PublicKey); err != nil {
+			return fmt.Errorf("invalid public key of the member %s", m.Name)
+		}
+	}
+	if cfg.Wait < 0 || cfg.Keep < 0 {
+		return errors.New("the wait and the number of the kept heights must not be negative")
+	}
+	return nil
+}
+
+// The results of comparing the checkpoint of a member.
+const (
+	ResultAgree   = "agree"
+	ResultDiverge = "diverge"
+	// The member published no checkpoint of the block in time, e.g. it publishes every few blocks or it's on a fork.
+	ResultMissing = "missing"
+	// The checkpoint of the member isn't signed by its key, or doesn't describe the block.
+	ResultInvalid = "invalid"
+	ResultFailed  = "failed"
+)
+
+// The consensus of the committee at a height.
+const (
+	ConsensusAgree   = "agree"
+	ConsensusDiverge = "diverge"
+	// No member published a comparable checkpoint.
+	ConsensusUnknown = "unknown"
+)
+
+type MemberStatus struct {
+	Member string `json:"member"`
+	Result string `json:"result"`
+	// The commitment of the checkpoint of the member, if published.
+	Commitment string `json:"commitment,omitempty"`
+	Error      string `json:"error,omitempty"`
+	// The file name of the fraud proof of the diverging checkpoint under the ProofDir, if saved.
+	Proof string `json:"proof,omitempty"`
+}
+
+// Status is the comparison of the local checkpoint at a height with the ones of the members.
+type Status struct {
+	Height     uint   `json:"height"`
+	Hash       string `json:"hash"`
+	Commitment string `json:"commitment"`
+	Consensus  string `json:"consensus"`
+	// The members whose checkpoints diverge from the local one.
+	Diverging []string       `json:"diverging"`
+	Members   []MemberStatus `json:"members"`
+	CheckedAt time.Time      `json:"checkedAt"`
+}
+
+// The interval of polling the members for their checkpoints.
+var pollInterval = 5 * time.Second
+
+// Checker compares the checkpoints of the members at each height.
+type Checker struct {
+	cfg     Config
+	sources map[string]checkpoint.Source
+
+	// Witnesses returns the witness of the local execution of the block at the height, which the fraud proofs of
+	// the diverging checkpoints are made of. Nil disables the fraud proofs.
+	Witnesses func(height uint) (*reexec.Witness, error)
+
+	mu       sync.RWMutex
+	statuses []*Status
+}
+
+// New cross-checks the members, whose sources are opened by open.
+func New(cfg Config, open func(source string) (checkpoint.Source, error)) (*Checker, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if cfg.Wait == 0 {
+		cfg.Wait = DefaultWait
+	}
+	if cfg.Keep == 0 {
+		cfg.Keep = DefaultKeep
+	}
+	c := Checker{cfg: cfg, sources: make(map[string]checkpoint.Source)}
+	for _, m := range cfg.Members {
+		source, err := open(m.Source)
+		if err != nil {
+			return nil, fmt.Errorf("the source of the member %s: %v", m.Name, err)
+		}
+		c.sources[m.Name] = source
+	}
+	return &c, nil
+}
+
+// compare checks the checkpoint of the member against the local one.
+func compare(m Member, local, remote *checkpoint.Checkpoint) MemberStatus {
+	status := MemberStatus{Member: m.Name, Commitment: remote.Commitment}
+	if remote.Height != local.Height || remote.Hash != local.Hash {
+		status.Result, status.Error = ResultInvalid, fmt.Sprintf("the checkpoint is of the block %s at height %s", remote.Hash, remote.Height)
+		return status
+	}
+	if err := remote.CheckNetwork(local.Network); err != nil {
+		status.Result, status.Error = ResultInvalid, err.Error()
+		return status
+	}
+	if m.PublicKey != "" {
+		if !strings.EqualFold(remote.PublicKey, m.PublicKey) {
+			status.Result, status.Error = ResultInvalid, "the checkpoint isn't signed by the key of the member"
+			return status
+		}
+		if err := remote.VerifySignature(); err != nil {
+			status.Result, status.Error = ResultInvalid, err.Error()
+			return status
+		}
+	}
+	// The secondary commitments are only compared if both sides run the dual-commitment mode.
+	if remote.Commitment != local.Commitment ||
+		(remote.SecondaryCommitment != "" && local.SecondaryCommitment != "" && remote.SecondaryCommitment != local.SecondaryCommitment) {
+		status.Result = ResultDiverge
+		return status
+	}
+	status.Result = ResultAgree
+	return status
+}
+
+// Check waits for the checkpoints of the members of the block described by the local checkpoint, compares them,
+// and records the status, which it returns. The members are polled until they all published or the wait expires.
+func (c *Checker) Check(ctx context.Context, local checkpoint.Checkpoint) *Status {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(c.cfg.Wait)*time.Second)
+	defer cancel()
+	height, _ := strconv.ParseUint(local.Height, 10, 64)
+
+	var wg sync.WaitGroup
+	members := make([]MemberStatus, len(c.cfg.Members))
+	remotes := make([]*checkpoint.Checkpoint, len(c.cfg.Members))
+	for i, m := range c.cfg.Members {
+		wg.Add(1)
+		go func(i int, m Member) {
+			defer wg.Done()
+			local := local
+			local.Name = m.Name
+			key := checkpoint.ObjectKey(&local)
+			for {
+				remote, err := c.sources[m.Name].Get(ctx, key)
+				if err == nil {
+					members[i], remotes[i] = compare(m, &local, remote), remote
+					return
+				}
+				// The request cut by the end of the wait keeps the result of the previous attempt.
+				if ctx.Err() != nil && members[i].Result != "" {
+					return
+				}
+				members[i] = MemberStatus{Member: m.Name, Result: ResultFailed, Error: err.Error()}
+				if errors.Is(err, checkpoint.ErrNotPublished) {
+					members[i] = MemberStatus{Member: m.Name, Result: ResultMissing}
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(pollInterval):
+				}
+			}
+		}(i, m)
+	}
+	wg.Wait()
+
+	status := Status{
+		Height:     uint(height),
+		Hash:       local.Hash,
+		Commitment: local.Commitment,
+		Consensus:  ConsensusUnknown,
+		Diverging:  make([]string, 0),
+		Members:    members,
+		CheckedAt:  time.Now(),
+	}
+	for i, m := range members {
+		if m.Result == ResultDiverge && remotes[i].Commitment != local.Commitment {
+			members[i].Proof = c.prove(context.Background(), c.cfg.Members[i], uint(height), remotes[i])
+		}
+		metrics.CrossChecks.WithLabelValues(m.Member, m.Result).Inc()
+		switch m.Result {
+		case ResultDiverge:
+			status.Consensus = ConsensusDiverge
+			status.Diverging = append(status.Diverging, m.Member)
+		case ResultAgree:
+			if status.Consensus == ConsensusUnknown {
+				status.Consensus = ConsensusAgree
+			}
+		}
+	}
+	if len(status.Diverging) != 0 {
+		log.Printf("The checkpoints of the members %s diverge at height %d", strings.Join(status.Diverging, ", "), status.Height)
+	}
+	c.record(&status)
+	return &status
+}
+
+// prove saves the fraud proof of the diverging checkpoint of the member, and returns its file name, empty if the
+// fraud proofs are disabled or it fails.
+func (c *Checker) prove(ctx context.Context, m Member, height uint, remote *checkpoint.Checkpoint) string {
+	if c.cfg.ProofDir == "" || c.Witnesses == nil {
+		return ""
+	}
+	w, err := c.Witnesses(height)
+	if err == nil {
+		var proof *FraudProof
+		proof, err = NewFraudProof(m.Name, w, remote, c.previous(ctx, m, height, remote))
+		if err == nil {
+			_, err = StoreFraudProof(c.cfg.ProofDir, proof)
+		}
+	}
+	if err != nil {
+		log.Printf("Failed to prove the checkpoint of the member %s wrong at height %d: %v", m.Name, height, err)
+		metrics.FraudProofs.WithLabelValues(m.Name, "failed").Inc()
+		return ""
+	}
+	log.Printf("Saved the fraud proof of the checkpoint of the member %s at height %d", m.Name, height)
+	metrics.FraudProofs.WithLabelValues(m.Name, "saved").Inc()
+	return fraudProofName(height, m.Name)
+}
+
+// previous returns the checkpoint of the member at the previous height if it agreed with the local one, nil otherwise.
+func (c *Checker) previous(ctx context.Context, m Member, height uint, remote *checkpoint.Checkpoint) *checkpoint.Checkpoint {
+	status := c.At(height - 1)
+	if status == nil {
+		return nil
+	}
+	for _, s := range status.Members {
+		if s.Member == m.Name && s.Result == ResultAgree {
+			key := checkpoint.ObjectKey(&checkpoint.Checkpoint{
+				Name:         m.Name,
+				MetaProtocol: remote.MetaProtocol,
+				Height:       strconv.FormatUint(uint64(height-1), 10),
+				Hash:         status.Hash,
+			})
+			previous, err := c.sources[m.Name].Get(ctx, key)
+			if err != nil {
+				return nil
+			}
+			return previous
+		}
+	}
+	return nil
+}
+
+// record keeps the status in the order of the heights, replacing the one of the same height after a reorg.
+func (c *Checker) record(status *Status) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	i := sort.Search(len(c.statuses), func(i int) bool { return c.statuses[i].Height >= status.Height })
+	if i < len(c.statuses) && c.statuses[i].Height == status.Height {
+		c.statuses[i] = status
+	} else {
+		c.statuses = append(c.statuses, nil)
+		copy(c.statuses[i+1:], c.statuses[i:])
+		c.statuses[i] = status
+	}
+	if len(c.statuses) > c.cfg.Keep {
+		c.statuses = c.statuses[len(c.statuses)-c.cfg.Keep:]
+	}
+	latest := c.statuses[len(c.statuses)-1]
+	metrics.DivergingMembers.Set(float64(len(latest.Diverging)))
+}
+
+// Latest returns the status of the latest checked height, nil if none is checked yet.
+func (c *Checker) Latest() *Status {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if len(c.statuses) == 0 {
+		return nil
+	}
+	return c.statuses[len(c.statuses)-1]
+}
+
+// At returns the status of the height, nil if it isn't checked or no longer kept.
+func (c *Checker) At(height uint) *Status {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	i := sort.Search(len(c.statuses), func(i int) bool { return c.statuses[i].Height >= height })
+	if i < len(c.statuses) && c.statuses[i].Height == height {
+		return c.statuses[i]
+	}
+	return nil
+}
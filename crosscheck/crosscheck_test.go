@@ -0,0 +1,86 @@
+package crosscheck
+
+import (
+	"context"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RiemaLabs/modular-indexer-committee/checkpoint"
+)
+
+func TestCheck(t *testing.T) {
+	pollInterval = 50 * time.Millisecond
+	dir := t.TempDir()
+	signer, err := checkpoint.NewSigner(checkpoint.SchemeSchnorr, strings.Repeat("2a", 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	publish := func(name string, height uint, commitment string, sign bool) {
+		indexerID := checkpoint.IndexerIdentification{URL: "https://" + name + ".example", Name: name, Version: "v1", MetaProtocol: "brc-20"}
+		c := checkpoint.NewCheckpoint(&indexerID, height, "00000000000000000002", commitment)
+		if sign {
+			if err := c.Sign(signer); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := (&checkpoint.LocalPublisher{Dir: dir}).Publish(context.Background(), &c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	publish("alice", 780000, "root", true)
+	publish("bob", 780000, "forged", false)
+	publish("carol", 780000, "root", false)
+	// The checkpoint of erin is of the signet, however equal its root is.
+	signet := checkpoint.NewCheckpoint(&checkpoint.IndexerIdentification{Name: "erin", MetaProtocol: "brc-20", Network: "signet"}, 780000, "00000000000000000002", "root")
+	if err := (&checkpoint.LocalPublisher{Dir: dir}).Publish(context.Background(), &signet); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := Config{
+		Enabled: true,
+		Members: []Member{
+			{Name: "alice", Source: dir, PublicKey: hex.EncodeToString(signer.PublicKey())},
+			{Name: "bob", Source: dir},
+			// The checkpoint of carol isn't signed by the key of the member.
+			{Name: "carol", Source: dir, PublicKey: hex.EncodeToString(signer.PublicKey())},
+			{Name: "dave", Source: dir},
+			{Name: "erin", Source: dir},
+		},
+		Wait: 1,
+		Keep: 2,
+	}
+	checker, err := New(cfg, func(source string) (checkpoint.Source, error) {
+		return &checkpoint.DirSource{Dir: source}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	local := checkpoint.NewCheckpoint(&checkpoint.IndexerIdentification{Name: "local", MetaProtocol: "brc-20"}, 780000, "00000000000000000002", "root")
+	status := checker.Check(context.Background(), local)
+	results := make(map[string]string)
+	for _, m := range status.Members {
+		results[m.Member] = m.Result
+	}
+	if results["alice"] != ResultAgree || results["bob"] != ResultDiverge || results["carol"] != ResultInvalid || results["dave"] != ResultMissing || results["erin"] != ResultInvalid {
+		t.Fatalf("Unexpected results %v", results)
+	}
+	if status.Consensus != ConsensusDiverge || len(status.Diverging) != 1 || status.Diverging[0] != "bob" {
+		t.Fatalf("Unexpected consensus %s of %v", status.Consensus, status.Diverging)
+	}
+
+	// The later heights are kept up to the limit, and a check after a reorg replaces the one of the height.
+	for _, height := range []uint{780001, 780002, 780001} {
+		publish("alice", height, "root", true)
+		local := checkpoint.NewCheckpoint(&checkpoint.IndexerIdentification{Name: "local", MetaProtocol: "brc-20"}, height, "00000000000000000002", "root")
+		checker.cfg.Members = cfg.Members[:1]
+		if status := checker.Check(context.Background(), local); status.Consensus != ConsensusAgree {
+			t.Fatalf("Unexpected consensus %s at height %d", status.Consensus, height)
+		}
+	}
+	if checker.At(780000) != nil || checker.At(780001) == nil || checker.Latest().Height != 780002 {
+		t.Fatal("Unexpected kept heights")
+	}
+}
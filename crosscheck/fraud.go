@@ -0,0 +1,140 @@
+package crosscheck
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/RiemaLabs/modular-indexer-committee/checkpoint"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/reexec"
+)
+
+// FraudProof shows that the checkpoint of a member is wrong: replaying the ord transfers of the block on the
+// pre-state proven by the witness yields another commitment. It's verified offline, without the state or the chain.
+type FraudProof struct {
+	Height uint   `json:"height"`
+	Hash   string `json:"hash"`
+	Member string `json:"member"`
+	// The diverging checkpoint of the member.
+	Checkpoint checkpoint.Checkpoint `json:"checkpoint"`
+	// The checkpoint of the member at the previous height if published, which shows the member agreed on the
+	// pre-state, so the divergence is of the block.
+	Previous *checkpoint.Checkpoint `json:"previous,omitempty"`
+	// The keys whose values the block changes, as replayed from the witness.
+	Keys []reexec.KeyChange `json:"keys"`
+	// The pre-state proof of the keys accessed by the block, along with its ord transfers.
+	Witness   *reexec.Witness `json:"witness"`
+	CreatedAt time.Time       `json:"createdAt"`
+}
+
+// NewFraudProof replays the witness of the block to prove the diverging checkpoint of the member wrong.
+func NewFraudProof(member string, w *reexec.Witness, remote, previous *checkpoint.Checkpoint) (*FraudProof, error) {
+	post, keys, err := reexec.Replay(w)
+	if err != nil {
+		return nil, err
+	}
+	if post != w.PostCommitment {
+		return nil, fmt.Errorf("the replayed commitment %s mismatches the witness %s", post, w.PostCommitment)
+	}
+	proof := FraudProof{
+		Height:     w.Height,
+		Hash:       remote.Hash,
+		Member:     member,
+		Checkpoint: *remote,
+		Previous:   previous,
+		Keys:       keys,
+		Witness:    w,
+		CreatedAt:  time.Now(),
+	}
+	if err := proof.Verify(); err != nil {
+		return nil, err
+	}
+	return &proof, nil
+}
+
+// Verify checks that the replay of the block contradicts the checkpoint of the member.
+func (p *FraudProof) Verify() error {
+	if p.Witness == nil {
+		return errors.New("the witness is required")
+	}
+	height := strconv.FormatUint(uint64(p.Height), 10)
+	if p.Witness.Height != p.Height || p.Checkpoint.Height != height || p.Checkpoint.Hash != p.Hash {
+		return fmt.Errorf("the checkpoint of the block %s at height %s isn't of the witness at height %d",
+			p.Checkpoint.Hash, p.Checkpoint.Height, p.Witness.Height)
+	}
+	if p.Checkpoint.SignatureScheme != "" {
+		if err := p.Checkpoint.VerifySignature(); err != nil {
+			return fmt.Errorf("the checkpoint of the member: %v", err)
+		}
+	}
+	if p.Previous != nil {
+		if p.Previous.Height != strconv.FormatUint(uint64(p.Height-1), 10) {
+			return fmt.Errorf("the previous checkpoint is at height %s", p.Previous.Height)
+		}
+		if p.Previous.Commitment != p.Witness.PreCommitment {
+			return fmt.Errorf("the previous checkpoint commits to %s rather than the pre-state %s",
+				p.Previous.Commitment, p.Witness.PreCommitment)
+		}
+		if p.Previous.SignatureScheme != "" {
+			if err := p.Previous.VerifySignature(); err != nil {
+				return fmt.Errorf("the previous checkpoint of the member: %v", err)
+			}
+		}
+	}
+
+	post, keys, err := reexec.Replay(p.Witness)
+	if err != nil {
+		return err
+	}
+	if post == p.Checkpoint.Commitment {
+		return fmt.Errorf("the replayed commitment %s agrees with the checkpoint", post)
+	}
+	if post != p.Witness.PostCommitment {
+		return fmt.Errorf("the replayed commitment %s mismatches the witness %s", post, p.Witness.PostCommitment)
+	}
+	if len(keys) != len(p.Keys) {
+		return fmt.Errorf("the block changes %d keys rather than %d", len(keys), len(p.Keys))
+	}
+	for i := range keys {
+		if keys[i] != p.Keys[i] {
+			return fmt.Errorf("the block changes the key %s to %s", keys[i].Key, keys[i].NewValue)
+		}
+	}
+	return nil
+}
+
+// fraudProofName is the file name of the fraud proof of the member at the height.
+func fraudProofName(height uint, member string) string {
+	return fmt.Sprintf("fraud-%d-%s.json", height, member)
+}
+
+// StoreFraudProof saves the fraud proof into the directory, and returns its path.
+func StoreFraudProof(dir string, p *FraudProof) (string, error) {
+	bytes, err := json.Marshal(p)
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, fraudProofName(p.Height, p.Member))
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, bytes, 0644); err != nil {
+		return "", err
+	}
+	return path, os.Rename(tmp, path)
+}
+
+// LoadFraudProof reads the fraud proof saved by StoreFraudProof.
+func LoadFraudProof(path string) (*FraudProof, error) {
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var p FraudProof
+	if err := json.Unmarshal(bytes, &p); err != nil {
+		return nil, fmt.Errorf("invalid fraud proof %s: %v", path, err)
+	}
+	return &p, nil
+}
@@ -0,0 +1,244 @@
+package crosscheck
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/RiemaLabs/modular-indexer-committee/internal/metrics"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/brc20"
+)
+
+// The defaults of the config of the OPI cross-check.
+const (
+	DefaultOPIWait = 600
+)
+
+// OPIConfig cross-checks the event hashes of the blocks with an OPI indexer, independently of the members.
+type OPIConfig struct {
+	Enabled bool `json:"enabled"`
+	// The URL of the API of the OPI indexer serving /v1/brc20/get_hash_of_all_activity.
+	URL string `json:"url"`
+	// The max number of seconds to wait for OPI to index a block (default 600).
+	Wait int `json:"wait"`
+	// The number of the latest checked heights kept (default 100).
+	Keep int `json:"keep"`
+}
+
+func (cfg OPIConfig) Validate() error {
+	if cfg.Enabled && cfg.URL == "" {
+		return errors.New("the URL of the OPI indexer is required")
+	}
+	if cfg.Wait < 0 || cfg.Keep < 0 {
+		return errors.New("the wait and the number of the kept heights must not be negative")
+	}
+	return nil
+}
+
+// OPIHashes are the event hashes of a block, the cumulative one chaining the hashes of the blocks before it.
+type OPIHashes struct {
+	BlockEventHash      string `json:"blockEventHash"`
+	CumulativeEventHash string `json:"cumulativeEventHash"`
+}
+
+// OPIStatus is the comparison of the event hashes of a block with the ones of OPI.
+type OPIStatus struct {
+	Height uint      `json:"height"`
+	Local  OPIHashes `json:"local"`
+	// The hashes of OPI, if fetched.
+	OPI       *OPIHashes `json:"opi,omitempty"`
+	Result    string     `json:"result"`
+	Error     string     `json:"error,omitempty"`
+	CheckedAt time.Time  `json:"checkedAt"`
+}
+
+type opiBlock struct {
+	height         uint
+	blockEventHash string
+}
+
+// OPIChecker compares the event hashes of the executed blocks with the ones of OPI, in the order of the blocks.
+type OPIChecker struct {
+	cfg    OPIConfig
+	client *http.Client
+	blocks chan opiBlock
+	// The cumulative hash of the latest compared block, chained from the one of OPI before the first block.
+	cumulative       string
+	cumulativeHeight uint
+
+	mu         sync.RWMutex
+	statuses   []*OPIStatus
+	divergence *OPIStatus
+}
+
+// NewOPI cross-checks the blocks with the OPI indexer of the config, once it's Run.
+func NewOPI(cfg OPIConfig) (*OPIChecker, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if cfg.Wait == 0 {
+		cfg.Wait = DefaultOPIWait
+	}
+	if cfg.Keep == 0 {
+		cfg.Keep = DefaultKeep
+	}
+	return &OPIChecker{cfg: cfg, client: &http.Client{}, blocks: make(chan opiBlock, cfg.Keep)}, nil
+}
+
+// Observe queues the event hash of the block executed at the height. The block queued while Keep blocks are already
+// waiting is dropped, so the blocks after it are chained to the cumulative hash of OPI again.
+func (c *OPIChecker) Observe(height uint, blockEventHash string) {
+	select {
+	case c.blocks <- opiBlock{height: height, blockEventHash: blockEventHash}:
+	default:
+		log.Printf("Skipped the OPI cross-check of the block %d, the cross-checks are behind", height)
+	}
+}
+
+// Run compares the queued blocks until the context is done.
+func (c *OPIChecker) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case b := <-c.blocks:
+			c.check(ctx, b)
+		}
+	}
+}
+
+// check compares the block with OPI, and records the status, which it returns. The first block, or the one following
+// a gap or a reorg, is chained to the cumulative hash of OPI at the previous height, which is trusted as is.
+func (c *OPIChecker) check(ctx context.Context, b opiBlock) *OPIStatus {
+	status := OPIStatus{Height: b.height, Local: OPIHashes{BlockEventHash: b.blockEventHash}, Result: ResultFailed}
+	defer func() {
+		status.CheckedAt = time.Now()
+		c.record(&status)
+	}()
+	if c.cumulative == "" || c.cumulativeHeight+1 != b.height {
+		previous, err := FetchOPIHashes(ctx, c.client, c.cfg.URL, b.height-1)
+		if err != nil {
+			c.cumulative = ""
+			status.Error = fmt.Sprintf("failed to fetch the hashes of the previous block: %v", err)
+			return &status
+		}
+		c.cumulative = previous.CumulativeEventHash
+	}
+	status.Local.CumulativeEventHash = brc20.OPICumulativeEventHash(c.cumulative, b.blockEventHash)
+	c.cumulative, c.cumulativeHeight = status.Local.CumulativeEventHash, b.height
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(c.cfg.Wait)*time.Second)
+	defer cancel()
+	for {
+		remote, err := FetchOPIHashes(ctx, c.client, c.cfg.URL, b.height)
+		if err == nil {
+			status.OPI, status.Error = remote, ""
+			status.Result = ResultAgree
+			if *remote != status.Local {
+				status.Result = ResultDiverge
+			}
+			return &status
+		}
+		status.Error = err.Error()
+		select {
+		case <-ctx.Done():
+			return &status
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// record keeps the status in the order of the heights, replacing the one of the same height after a reorg, and alerts
+// on the first divergence.
+func (c *OPIChecker) record(status *OPIStatus) {
+	metrics.OPIChecks.WithLabelValues(status.Result).Inc()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	i := sort.Search(len(c.statuses), func(i int) bool { return c.statuses[i].Height >= status.Height })
+	if i < len(c.statuses) && c.statuses[i].Height == status.Height {
+		c.statuses[i] = status
+	} else {
+		c.statuses = append(c.statuses, nil)
+		copy(c.statuses[i+1:], c.statuses[i:])
+		c.statuses[i] = status
+	}
+	if len(c.statuses) > c.cfg.Keep {
+		c.statuses = c.statuses[len(c.statuses)-c.cfg.Keep:]
+	}
+	if status.Result == ResultDiverge && c.divergence == nil {
+		c.divergence = status
+		metrics.OPIDivergenceHeight.Set(float64(status.Height))
+		log.Printf("ALERT: the event hashes diverge from OPI at height %d: local block %s cumulative %s, OPI block %s cumulative %s",
+			status.Height, status.Local.BlockEventHash, status.Local.CumulativeEventHash, status.OPI.BlockEventHash, status.OPI.CumulativeEventHash)
+	}
+}
+
+// Latest returns the status of the latest checked height, nil if none is checked yet.
+func (c *OPIChecker) Latest() *OPIStatus {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if len(c.statuses) == 0 {
+		return nil
+	}
+	return c.statuses[len(c.statuses)-1]
+}
+
+// At returns the status of the height, nil if it isn't checked or no longer kept.
+func (c *OPIChecker) At(height uint) *OPIStatus {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	i := sort.Search(len(c.statuses), func(i int) bool { return c.statuses[i].Height >= height })
+	if i < len(c.statuses) && c.statuses[i].Height == height {
+		return c.statuses[i]
+	}
+	return nil
+}
+
+// Divergence returns the status of the first diverging height since the start, nil if none diverged.
+func (c *OPIChecker) Divergence() *OPIStatus {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.divergence
+}
+
+// FetchOPIHashes requests the event hashes of the block at the height from the OPI indexer.
+func FetchOPIHashes(ctx context.Context, client *http.Client, url string, height uint) (*OPIHashes, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/v1/brc20/get_hash_of_all_activity?block_height=%d", strings.TrimRight(url, "/"), height), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	var response struct {
+		Error  *string `json:"error"`
+		Result *struct {
+			BlockEventHash      string `json:"block_event_hash"`
+			CumulativeEventHash string `json:"cumulative_event_hash"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("invalid response of OPI with the status %d: %v", resp.StatusCode, err)
+	}
+	if response.Error != nil {
+		return nil, fmt.Errorf("OPI: %s", *response.Error)
+	}
+	if response.Result == nil {
+		return nil, fmt.Errorf("OPI returned no hashes of the block %d", height)
+	}
+	return &OPIHashes{BlockEventHash: response.Result.BlockEventHash, CumulativeEventHash: response.Result.CumulativeEventHash}, nil
+}
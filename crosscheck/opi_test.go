@@ -0,0 +1,83 @@
+package crosscheck
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord/brc20"
+)
+
+func TestOPICheck(t *testing.T) {
+	pollInterval = 50 * time.Millisecond
+	// The hashes indexed by OPI, where the block 780003 diverges.
+	var mu sync.Mutex
+	indexed := map[uint]OPIHashes{779999: {BlockEventHash: "a", CumulativeEventHash: "seed"}}
+	cumulative := "seed"
+	for height, block := range []string{"b0", "b1", "b2", "opi"} {
+		cumulative = brc20.OPICumulativeEventHash(cumulative, block)
+		indexed[780000+uint(height)] = OPIHashes{BlockEventHash: block, CumulativeEventHash: cumulative}
+	}
+	opi := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		height, _ := strconv.ParseUint(r.URL.Query().Get("block_height"), 10, 64)
+		mu.Lock()
+		hashes, ok := indexed[uint(height)]
+		mu.Unlock()
+		if r.URL.Path != "/v1/brc20/get_hash_of_all_activity" || !ok {
+			json.NewEncoder(w).Encode(map[string]any{"error": "block not indexed", "result": nil})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"error": nil, "result": map[string]any{
+			"block_height": height, "block_event_hash": hashes.BlockEventHash, "cumulative_event_hash": hashes.CumulativeEventHash,
+		}})
+	}))
+	defer opi.Close()
+
+	checker, err := NewOPI(OPIConfig{Enabled: true, URL: opi.URL + "/", Wait: 1, Keep: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	for height, block := range []string{"b0", "b1", "b2", "local"} {
+		status := checker.check(ctx, opiBlock{height: 780000 + uint(height), blockEventHash: block})
+		expected := ResultAgree
+		if block == "local" {
+			expected = ResultDiverge
+		}
+		if status.Result != expected || status.OPI == nil {
+			t.Fatalf("Expected %s at height %d, got %+v", expected, status.Height, status)
+		}
+	}
+	if d := checker.Divergence(); d == nil || d.Height != 780003 {
+		t.Fatalf("Unexpected divergence %+v", d)
+	}
+	if checker.At(780000) != nil || checker.Latest().Height != 780003 {
+		t.Fatal("Expected the latest 3 heights kept")
+	}
+
+	// OPI catching up within the wait is polled for.
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		mu.Lock()
+		indexed[780004] = OPIHashes{BlockEventHash: "b4", CumulativeEventHash: brc20.OPICumulativeEventHash(indexed[780003].CumulativeEventHash, "b4")}
+		mu.Unlock()
+	}()
+	// The block re-executed after a reorg is chained to the cumulative hash of OPI again, however the local one diverged.
+	if status := checker.check(ctx, opiBlock{height: 780003, blockEventHash: "opi"}); status.Result != ResultAgree {
+		t.Fatalf("Expected the block to agree after the reorg, got %+v", status)
+	}
+	if status := checker.check(ctx, opiBlock{height: 780004, blockEventHash: "b4"}); status.Result != ResultAgree {
+		t.Fatalf("Expected the block to agree once indexed by OPI, got %+v", status)
+	}
+	if d := checker.Divergence(); d == nil || d.Height != 780003 {
+		t.Fatalf("Expected the first divergence kept, got %+v", d)
+	}
+	if status := checker.check(ctx, opiBlock{height: 780005, blockEventHash: "b5"}); status.Result != ResultFailed || status.Error == "" {
+		t.Fatalf("Expected the block missed by OPI to fail, got %+v", status)
+	}
+}
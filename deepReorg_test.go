@@ -0,0 +1,66 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+func Test_DeepReorg(t *testing.T) {
+	stateless.ReorgDepth = 12
+	defer func() { stateless.ReorgDepth = 6 }()
+
+	ordGetterTest, arguments := loadMain(782000)
+	queue, err := CatchupStage(ordGetterTest, &arguments, stateless.BRC20StartHeight-1, 780000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(queue.History) != 12 {
+		t.Fatalf("Expected 12 kept blocks, got %d", len(queue.History))
+	}
+	if reorgHeight, err := queue.CheckForReorg(ordGetterTest); err != nil || reorgHeight != 0 {
+		t.Fatalf("Expected no reorg, got %d: %v", reorgHeight, err)
+	}
+
+	// Fork the latest 10 blocks, deeper than the confirmations.
+	curHeight := queue.Header.Height
+	commitments := make([][32]byte, len(queue.History))
+	for i, state := range queue.History {
+		commitments[i] = state.VerkleCommit
+	}
+	latestCommitment := queue.Header.Root.Commit().Bytes()
+	for h := curHeight - 9; h <= curHeight; h++ {
+		ordGetterTest.BlockHash[h] = fmt.Sprintf("fork%d", h)
+	}
+	reorgHeight, err := queue.CheckForReorg(ordGetterTest)
+	if err != nil || reorgHeight != curHeight-9 {
+		t.Fatalf("Expected the reorg from %d, got %d: %v", curHeight-9, reorgHeight, err)
+	}
+	if err := queue.Recovery(ordGetterTest, reorgHeight); err != nil {
+		t.Fatal(err)
+	}
+	if queue.Header.Height != curHeight || queue.Header.Hash != fmt.Sprintf("fork%d", curHeight) || queue.Header.Root.Commit().Bytes() != latestCommitment {
+		t.Fatalf("Unexpected header at height %d with the hash %s", queue.Header.Height, queue.Header.Hash)
+	}
+	for i, state := range queue.History {
+		if state.VerkleCommit != commitments[i] {
+			t.Fatalf("The commitment at height %d changed after the recovery", state.Height)
+		}
+	}
+	if reorgHeight, err := queue.CheckForReorg(ordGetterTest); err != nil || reorgHeight != 0 {
+		t.Fatalf("Expected no reorg after the recovery, got %d: %v", reorgHeight, err)
+	}
+
+	// A fork of every kept block can't be recovered.
+	for h := queue.StartHeight(); h <= curHeight; h++ {
+		ordGetterTest.BlockHash[h] = fmt.Sprintf("deep%d", h)
+	}
+	if _, err := queue.CheckForReorg(ordGetterTest); !errors.Is(err, stateless.ErrReorgTooDeep) {
+		t.Fatalf("Expected the reorg to be too deep, got %v", err)
+	}
+	if err := queue.Recovery(ordGetterTest, queue.StartHeight()); !errors.Is(err, stateless.ErrReorgTooDeep) {
+		t.Fatalf("Expected the recovery to be refused, got %v", err)
+	}
+}
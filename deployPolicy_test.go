@@ -0,0 +1,37 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord/brc20"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+func loadDeployedTick(t *testing.T, tick string, rules brc20.DeployRules) bool {
+	brc20.DeployPolicies = []brc20.DeployPolicy{rules}
+	defer func() { brc20.DeployPolicies = nil }()
+
+	ordGetterTest, arguments := loadMain(782000)
+	queue, err := CatchupStage(ordGetterTest, &arguments, stateless.BRC20StartHeight-1, 779850)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exists, err := queue.Header.GetUInt256(brc20.GetTickHash(tick, brc20.Exists))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return !exists.IsZero()
+}
+
+func Test_DeployPolicy(t *testing.T) {
+	// ordi is deployed at the block 779832.
+	if loadDeployedTick(t, "ordi", brc20.DeployRules{{ActivationHeight: 779800, ReservedTicks: []string{"ORDI"}}}) {
+		t.Fatal("Expected the reserved tick not to be deployed")
+	}
+	if !loadDeployedTick(t, "ordi", brc20.DeployRules{{ActivationHeight: 779900, ReservedTicks: []string{"ordi"}}}) {
+		t.Fatal("Expected the tick deployed before the activation of the rule to be deployed")
+	}
+	if err := (brc20.DeployRules{{ClaimStartHeight: 10, ClaimEndHeight: 5}}).Validate(); err == nil {
+		t.Fatal("Expected the empty claim window to be rejected")
+	}
+}
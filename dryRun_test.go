@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/RiemaLabs/modular-indexer-committee/apis"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+func Test_DryRun(t *testing.T) {
+	ordGetterTest, arguments := loadMain(782000)
+	queue, err := CatchupStage(ordGetterTest, &arguments, stateless.BRC20StartHeight-1, 780000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	apis.DryRuns = &apis.DryRunService{Tokens: func() []string { return []string{"wallet"} }}
+	defer func() { apis.DryRuns = nil }()
+	gin.SetMode(gin.TestMode)
+	ts := httptest.NewServer(apis.NewRouter(queue, "brc-20", false, false))
+	defer ts.Close()
+
+	dryRun := func(token string, req apis.DryRunRequest) (int, apis.DryRunResponse) {
+		body, _ := json.Marshal(req)
+		httpReq, _ := http.NewRequest(http.MethodPost, ts.URL+"/v1/brc20/dry_run", bytes.NewReader(body))
+		httpReq.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(httpReq)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var res apis.DryRunResponse
+		if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode, res
+	}
+
+	root := queue.Header.Root.Commit().Bytes()
+	digest, size := queue.Header.Digest(), queue.Header.KV.Len()
+	pkscript := "0014" + strings.Repeat("ab", 20)
+	status, res := dryRun("wallet", apis.DryRunRequest{Content: `{"p":"brc-20","op":"mint","tick":"ordi","amt":"1000"}`, Pkscript: pkscript})
+	if status != http.StatusOK || !res.Result.Valid || res.Result.OverallBalance.Before != "0" || res.Result.OverallBalance.After != "1000000000000000000000" {
+		t.Fatalf("Unexpected dry run of the mint %d %+v", status, res.Result)
+	}
+	status, res = dryRun("wallet", apis.DryRunRequest{Content: `{"p":"brc-20","op":"mint","tick":"ordi","amt":"1001"}`, Pkscript: pkscript})
+	if status != http.StatusOK || res.Result.Valid || res.Result.OverallBalance.After != "0" {
+		t.Fatalf("Expected the mint beyond the limit to be invalid, got %d %+v", status, res.Result)
+	}
+	if status, _ := dryRun("wallet", apis.DryRunRequest{Content: `{}`, Pkscript: "wallet"}); status != http.StatusBadRequest {
+		t.Fatalf("Expected the pkscript not in hex to be rejected, got %d", status)
+	}
+	if status, _ := dryRun("intruder", apis.DryRunRequest{Content: `{}`, Pkscript: pkscript}); status != http.StatusUnauthorized {
+		t.Fatalf("Expected the unknown token to be rejected, got %d", status)
+	}
+
+	if queue.Header.Root.Commit().Bytes() != root || queue.Header.Digest() != digest || queue.Header.KV.Len() != size {
+		t.Fatal("Expected the dry runs to leave the state unchanged")
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/ethereum/go-verkle"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord/protocol"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+// ExportArguments is what the export command dumps: the stored state, as CSV or JSON.
+type ExportArguments struct {
+	// The height of the state, which must be the stored one, 0 for whichever is stored.
+	Height      uint
+	Format      string
+	Output      string
+	StateDBPath string
+}
+
+// The columns of the CSV export, whose record is either a tick or a balance.
+var exportColumns = []string{
+	"height", "record", "tick", "pkscript", "availableBalance", "overallBalance",
+	"maxSupply", "limitPerMint", "decimals", "minted", "remainingSupply", "selfMint", "inscriptionID", "deployHeight",
+}
+
+// WriteExport writes the export in the format, csv or json.
+func WriteExport(w io.Writer, export *stateless.StateExport, format string) error {
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(export)
+	case "csv":
+		records := csv.NewWriter(w)
+		height := strconv.FormatUint(uint64(export.Height), 10)
+		if err := records.Write(exportColumns); err != nil {
+			return err
+		}
+		for _, t := range export.Ticks {
+			err := records.Write([]string{
+				height, "tick", t.Tick, "", "", "",
+				t.MaxSupply, t.LimitPerMint, strconv.FormatUint(t.Decimals, 10), t.Minted, t.RemainingSupply,
+				strconv.FormatBool(t.SelfMint), t.InscriptionID, strconv.FormatUint(uint64(t.DeployHeight), 10),
+			})
+			if err != nil {
+				return err
+			}
+		}
+		for _, b := range export.Balances {
+			err := records.Write([]string{
+				height, "balance", b.Tick, b.Pkscript, b.AvailableBalance, b.OverallBalance,
+				"", "", "", "", "", "", "", "",
+			})
+			if err != nil {
+				return err
+			}
+		}
+		records.Flush()
+		return records.Error()
+	}
+	return fmt.Errorf("unknown export format %s, expected csv or json", format)
+}
+
+// Export runs the export command, writing the stored state to the output, or to the standard output if empty.
+func Export(arguments *ExportArguments) error {
+	if arguments.Format != "csv" && arguments.Format != "json" {
+		return fmt.Errorf("unknown export format %s, expected csv or json", arguments.Format)
+	}
+	stateless.StateDBPath = arguments.StateDBPath
+	found, err := stateless.HasState()
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("no state is stored to export")
+	}
+	header := stateless.LoadHeader(true, 0)
+	if arguments.Height != 0 && header.Height != arguments.Height {
+		return fmt.Errorf("the stored state is at height %d instead of %d", header.Height, arguments.Height)
+	}
+	export, err := header.Export()
+	if err != nil {
+		return err
+	}
+
+	if arguments.Output == "" {
+		err = WriteExport(os.Stdout, export, arguments.Format)
+	} else {
+		var file *os.File
+		file, err = os.Create(arguments.Output)
+		if err != nil {
+			return err
+		}
+		err = WriteExport(file, export, arguments.Format)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		return err
+	}
+	log.Printf("Exported %d ticks and %d balances at height %d with the commitment %s", len(export.Ticks), len(export.Balances), export.Height, export.Commitment)
+	if export.UndecodedKeys != 0 {
+		log.Printf("%d of %d keys aren't ticks or balances known to the census since %d and the holders since %d", export.UndecodedKeys, export.Keys, export.CensusFromHeight, export.HoldersFromHeight)
+	}
+	return nil
+}
+
+// PreImagesArguments is what the export-preimages command dumps: the preimages kept by --preimages.
+type PreImagesArguments struct {
+	PreImagesPath string
+	Output        string
+}
+
+// ExportPreImages runs the export-preimages command, writing a JSON line of every kept preimage along with its stem to
+// the output, or to the standard output if empty.
+func ExportPreImages(arguments *PreImagesArguments) error {
+	stateless.PreImagesPath = arguments.PreImagesPath
+	defer stateless.ClosePreImages()
+	w := io.Writer(os.Stdout)
+	if arguments.Output != "" {
+		file, err := os.Create(arguments.Output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+	buffered := bufio.NewWriter(w)
+	encoder := json.NewEncoder(buffered)
+	var count int
+	var err error
+	rangeErr := stateless.RangePreImages(func(stem [verkle.StemSize]byte, p protocol.KeyPreImage) bool {
+		err = encoder.Encode(struct {
+			Stem string `json:"stem"`
+			protocol.KeyPreImage
+		}{Stem: hex.EncodeToString(stem[:]), KeyPreImage: p})
+		count++
+		return err == nil
+	})
+	if rangeErr != nil {
+		return rangeErr
+	}
+	if err != nil {
+		return err
+	}
+	if err := buffered.Flush(); err != nil {
+		return err
+	}
+	log.Printf("Exported the preimages of %d stems", count)
+	return nil
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/RiemaLabs/modular-indexer-committee/checkpoint"
+	"github.com/RiemaLabs/modular-indexer-committee/crosscheck"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/reexec"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+func Test_FraudProof(t *testing.T) {
+	var latestHeight uint = 779960
+	stateless.KeepWitnesses = 8
+	defer func() { stateless.KeepWitnesses = 0 }()
+
+	ordGetterTest, arguments := loadMain(782000)
+	_, err := CatchupStage(ordGetterTest, &arguments, stateless.BRC20StartHeight-1, latestHeight)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Find the latest kept block changing the state.
+	var w *reexec.Witness
+	for height := latestHeight; height > latestHeight-stateless.KeepWitnesses+1; height-- {
+		candidate, err := stateless.RecentWitness(height)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, keys, err := reexec.Replay(candidate); err == nil && len(keys) != 0 {
+			w = candidate
+			break
+		}
+	}
+	if w == nil {
+		t.Fatal("Expected a kept block changing the state")
+	}
+	if _, err := stateless.RecentWitness(latestHeight - stateless.KeepWitnesses); err == nil {
+		t.Fatal("Expected the witnesses beyond the window to be pruned")
+	}
+
+	// The member agrees on the previous block, then publishes a forged commitment of the block.
+	signer, err := checkpoint.NewSigner(checkpoint.SchemeSchnorr, strings.Repeat("2a", 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, proofDir := t.TempDir(), t.TempDir()
+	member := checkpoint.IndexerIdentification{URL: "https://bob.example", Name: "bob", Version: "v1", MetaProtocol: "brc-20"}
+	local := checkpoint.IndexerIdentification{Name: "local", MetaProtocol: "brc-20"}
+	checker, err := crosscheck.New(crosscheck.Config{
+		Enabled:  true,
+		Members:  []crosscheck.Member{{Name: "bob", Source: dir, PublicKey: hex.EncodeToString(signer.PublicKey())}},
+		Wait:     1,
+		ProofDir: proofDir,
+	}, func(source string) (checkpoint.Source, error) {
+		return &checkpoint.DirSource{Dir: source}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checker.Witnesses = stateless.RecentWitness
+	for _, c := range []struct {
+		height     uint
+		hash       string
+		commitment string
+		forged     string
+	}{
+		{w.Height - 1, "00000000000000000001", w.PreCommitment, w.PreCommitment},
+		{w.Height, "00000000000000000002", w.PostCommitment, w.PreCommitment},
+	} {
+		published := checkpoint.NewCheckpoint(&member, c.height, c.hash, c.forged)
+		if err := published.Sign(signer); err != nil {
+			t.Fatal(err)
+		}
+		if err := (&checkpoint.LocalPublisher{Dir: dir}).Publish(context.Background(), &published); err != nil {
+			t.Fatal(err)
+		}
+		checker.Check(context.Background(), checkpoint.NewCheckpoint(&local, c.height, c.hash, c.commitment))
+	}
+
+	status := checker.Latest()
+	if status.Consensus != crosscheck.ConsensusDiverge || status.Members[0].Proof == "" {
+		t.Fatalf("Expected the fraud proof of the diverging member, got %v", status.Members)
+	}
+	path := filepath.Join(proofDir, status.Members[0].Proof)
+	proof, err := crosscheck.LoadFraudProof(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := proof.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	if proof.Previous == nil || len(proof.Keys) == 0 {
+		t.Fatal("Expected the previous checkpoint of the member and the changed keys")
+	}
+
+	// The tampered proofs don't verify.
+	tampered, _ := crosscheck.LoadFraudProof(path)
+	tampered.Keys[0].NewValue = strings.Repeat("00", 32)
+	if tampered.Verify() == nil {
+		t.Fatal("Expected the tampered keys to fail the verification")
+	}
+	tampered, _ = crosscheck.LoadFraudProof(path)
+	tampered.Checkpoint.Commitment = w.PostCommitment
+	if tampered.Verify() == nil {
+		t.Fatal("Expected the tampered checkpoint to fail the verification")
+	}
+	tampered, _ = crosscheck.LoadFraudProof(path)
+	tampered.Witness.OrdTransfers = nil
+	if tampered.Verify() == nil {
+		t.Fatal("Expected the proof without the transfers to fail the verification")
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/brc20"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+func Test_Genesis(t *testing.T) {
+	pkscript := "5120409943cab2dee3c71940969a612c6ee65c57cad1f064ca8db4508dab49260ca3"
+	genesis := &brc20.Genesis{
+		Ticks: []brc20.GenesisTick{{
+			Tick:            "SEED",
+			InscriptionID:   "b61b0172d95e266c18aea0c624db987e971a5d6d4ebc2aaed85da4642d635735i0",
+			MaxSupply:       "21000000000000000000000000",
+			RemainingSupply: "20000000000000000000000000",
+			LimitPerMint:    "1000000000000000000000",
+			Decimals:        18,
+		}},
+		Balances: []brc20.GenesisBalance{{
+			Tick:             "seed",
+			Pkscript:         pkscript,
+			AvailableBalance: "400000000000000000000000",
+			OverallBalance:   "1000000000000000000000000",
+		}},
+	}
+	if err := brc20.ValidateGenesis(genesis); err != nil {
+		t.Fatal(err)
+	}
+	invalid := *genesis
+	invalid.Balances = []brc20.GenesisBalance{{Tick: "none", Pkscript: pkscript, AvailableBalance: "0", OverallBalance: "0"}}
+	if err := brc20.ValidateGenesis(&invalid); err == nil {
+		t.Fatal("Expected the balance of an undeployed tick to be rejected")
+	}
+
+	stateless.Genesis = genesis
+	defer func() { stateless.Genesis = nil }()
+
+	var latestHeight uint = 779860
+	ordGetterTest, arguments := loadMain(782000)
+	queue, err := CatchupStage(ordGetterTest, &arguments, stateless.BRC20StartHeight-1, latestHeight)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, available, overall, err := brc20.GetBalances(queue.Header, "seed", ord.Pkscript(pkscript))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if available.Dec() != "400000000000000000000000" || overall.Dec() != "1000000000000000000000000" {
+		t.Fatalf("Unexpected balances of the bootstrap state: %s, %s", available.Dec(), overall.Dec())
+	}
+	remaining, err := queue.Header.GetUInt256(brc20.GetTickHash("seed", brc20.RemainingSupply))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if remaining.Dec() != "20000000000000000000000000" {
+		t.Fatalf("Unexpected remaining supply of the bootstrap state: %s", remaining.Dec())
+	}
+}
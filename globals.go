@@ -1,6 +1,37 @@
 package main
 
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/RiemaLabs/modular-indexer-committee/apis"
+	"github.com/RiemaLabs/modular-indexer-committee/archive"
+	"github.com/RiemaLabs/modular-indexer-committee/checkpoint"
+	"github.com/RiemaLabs/modular-indexer-committee/crosscheck"
+	"github.com/RiemaLabs/modular-indexer-committee/internal/retry"
+	"github.com/RiemaLabs/modular-indexer-committee/internal/tracing"
+	"github.com/RiemaLabs/modular-indexer-committee/ord"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/bitmap"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/brc20"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/getter"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/sanity"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/sns"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/watchlist"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/webhook"
+	"github.com/RiemaLabs/modular-indexer-committee/peer"
+	"github.com/RiemaLabs/modular-indexer-committee/secrets"
+	"github.com/RiemaLabs/modular-indexer-committee/selfaudit"
+)
+
 type Config struct {
+	// The Bitcoin network indexed: mainnet, testnet, signet or regtest (default mainnet).
+	Network  string `json:"network"`
 	Database struct {
 		Host     string `json:"host"`
 		User     string `json:"user"`
@@ -8,27 +39,349 @@ type Config struct {
 		DBname   string `json:"dbname"`
 		Port     string `json:"port"`
 	} `json:"database"`
+	// The ord server or bitcoind read instead of the OPI database if enabled.
+	Ord      getter.OrdServerConfig `json:"ord"`
+	Bitcoind getter.BitcoindConfig  `json:"bitcoind"`
+	// The retries and the circuit breakers of the calls to the getter and to the DA layer.
+	Retry struct {
+		Getter retry.Config `json:"getter"`
+		Da     retry.Config `json:"da"`
+	} `json:"retry"`
 	Report struct {
-		Method  string `json:"method"`
-		Timeout int    `json:"timeout"`
-		S3      struct {
-			Bucket    string `json:"bucket"`
-			Region    string `json:"region"`
-			AccessKey string `json:"accessKey"`
-			SecretKey string `json:"secretKey"`
+		// The publication method of the checkpoints: DA, S3, Local or HTTP.
+		Method string `json:"method"`
+		// The methods publishing every checkpoint redundantly, which replace the method if set.
+		Targets []string `json:"targets"`
+		// The checkpoints not published yet by each target.
+		Queue    checkpoint.QueueConfig `json:"queue"`
+		Timeout  int                    `json:"timeout"`
+		Schedule checkpoint.Schedule    `json:"schedule"`
+		// The signature attesting the checkpoints, optional.
+		Signature checkpoint.SignatureConfig `json:"signature"`
+		S3        struct {
+			Bucket string `json:"bucket"`
+			Region string `json:"region"`
+			// The endpoint of another S3-compatible object store, optional.
+			Endpoint  string              `json:"endpoint"`
+			AccessKey string              `json:"accessKey"`
+			SecretKey string              `json:"secretKey"`
+			Schedule  checkpoint.Schedule `json:"schedule"`
 		} `json:"s3"`
 		Da struct {
-			Network     string `json:"network"`
-			NamespaceID string `json:"namespaceID"`
-			GasCoupon   string `json:"gasCoupon"`
-			PrivateKey  string `json:"privateKey"`
+			Network     string              `json:"network"`
+			NamespaceID string              `json:"namespaceID"`
+			GasCoupon   string              `json:"gasCoupon"`
+			PrivateKey  string              `json:"privateKey"`
+			Schedule    checkpoint.Schedule `json:"schedule"`
+			// The namespace IDs by the indexed networks, which replace the namespaceID on the network.
+			Namespaces map[string]string `json:"namespaces"`
+			// The cap of the storage fee spent on the publications, optional.
+			Budget checkpoint.BudgetConfig `json:"budget"`
 		} `json:"da"`
+		Local struct {
+			Dir      string              `json:"dir"`
+			Schedule checkpoint.Schedule `json:"schedule"`
+		} `json:"local"`
+		HTTP struct {
+			URL string `json:"url"`
+			// The headers of the requests, whose values may refer to secrets.
+			Headers  map[string]string   `json:"headers"`
+			Schedule checkpoint.Schedule `json:"schedule"`
+		} `json:"http"`
+		// The other committee members which the checkpoints are also published on behalf of, optional.
+		Identities []IdentityConfig `json:"identities"`
 	} `json:"report"`
 	Service struct {
 		Name         string `json:"name"`
 		URL          string `json:"url"`
 		MetaProtocol string `json:"metaProtocol"`
+		// The dry runs of the inscriptions for the wallets holding the tokens, which may refer to secrets.
+		DryRun struct {
+			Enabled bool     `json:"enabled"`
+			Tokens  []string `json:"tokens"`
+		} `json:"dryRun"`
+		// The admin APIs for the operators holding the tokens, which may refer to secrets. Empty disables them.
+		Admin struct {
+			Tokens []string `json:"tokens"`
+			// The file journaling the operations of the operators across restarts, empty keeps them in memory only.
+			Journal string `json:"journal"`
+		} `json:"admin"`
+		// The API keys and the rate limits of the public APIs, whose keys may refer to secrets.
+		Access apis.AccessConfig `json:"access"`
 	} `json:"service"`
+	Genesis struct {
+		Height    uint   `json:"height"`
+		Bootstrap string `json:"bootstrap"`
+	} `json:"genesis"`
+	Watchlist watchlist.Config `json:"watchlist"`
+	// The webhooks POSTed the matching events of every block, optional.
+	Webhooks webhook.Config `json:"webhooks"`
+	Archive  archive.Config `json:"archive"`
+	// The history kept on the disk, which is pruned periodically or by the admin API.
+	Retention  stateless.RetentionConfig `json:"retention"`
+	Validation sanity.Config             `json:"validation"`
+	Secrets    secrets.Config            `json:"secrets"`
+	Peers      peer.Config               `json:"peers"`
+	// The comparison of the checkpoints published by the other members, optional.
+	CrossCheck crosscheck.Config `json:"crossCheck"`
+	// The comparison of the event hashes of the blocks with the ones of an OPI indexer, optional.
+	OPICheck crosscheck.OPIConfig `json:"opiCheck"`
+	// The audit of the checkpoints published by the member, read back from the DA layer, optional.
+	SelfAudit selfaudit.Config `json:"selfAudit"`
+	// The import of the state snapshot of a trusted member onto an empty disk, optional.
+	SnapshotBootstrap struct {
+		// The member serving its snapshot at /v1/peer/snapshot, or only the name of one of the audited members.
+		Member peer.Member `json:"member"`
+		// The URL of the snapshot saved elsewhere, which replaces the one of the member if set.
+		URL string `json:"url"`
+		// The member publishing the checkpoints which the commitment of the snapshot must match.
+		Checkpoints crosscheck.Member `json:"checkpoints"`
+	} `json:"snapshotBootstrap"`
+	// The member followed by the read-only replica, see --replica.
+	Replica struct {
+		// The member streaming its diffs at /v1/peer/diffs and serving its snapshot at /v1/peer/snapshot.
+		Member peer.Member `json:"member"`
+	} `json:"replica"`
+	// The OTLP export of the spans of the indexing, optional.
+	Tracing tracing.Config `json:"tracing"`
+	// The sats names module executed next to BRC-20, optional.
+	SNS sns.Config `json:"sns"`
+	// The Bitmap districts module executed next to BRC-20, optional.
+	Bitmap bitmap.Config `json:"bitmap"`
+	Rules  struct {
+		Deploy  brc20.DeployRules   `json:"deploy"`
+		Content brc20.ContentLimits `json:"content"`
+		Numbers brc20.NumberRules   `json:"numbers"`
+		Ticks   brc20.TickRules     `json:"ticks"`
+		// The activation height of transferring the mint authority of the self-mint ticks, 0 disables it.
+		AuthorityTransfer struct {
+			ActivationHeight uint `json:"activationHeight"`
+		} `json:"authorityTransfer"`
+		// The activation height of burning the transfers to the unspendable outputs, 0 disables it.
+		Burn struct {
+			ActivationHeight uint `json:"activationHeight"`
+		} `json:"burn"`
+		// The activation height of the per-tick counters kept in the state, 0 disables them.
+		Counters struct {
+			ActivationHeight uint `json:"activationHeight"`
+		} `json:"counters"`
+		// The activation height of rejecting the contents that aren't valid UTF-8, 0 disables it.
+		StrictUTF8 struct {
+			ActivationHeight uint `json:"activationHeight"`
+		} `json:"strictUTF8"`
+		// The scheme deriving the keys of the state, keccak256 if empty.
+		KeyScheme string `json:"keyScheme"`
+	} `json:"rules"`
 }
 
 var GlobalConfig Config
+
+// Secrets resolves the credentials of GlobalConfig, which may refer to external secrets.
+var Secrets *secrets.Manager
+
+// The sources of the inscriptions executed by the indexer, published by the checkpoints.
+const (
+	GetterSourceOPI      = "opi"
+	GetterSourceOrd      = "ord"
+	GetterSourceBitcoind = "bitcoind"
+	GetterSourceTest     = "opi-test"
+)
+
+// GetterSource is where the indexer reads the inscriptions from, the OPI database unless another getter is enabled.
+var GetterSource = GetterSourceOPI
+
+// CheckpointSigner attests the uploaded checkpoints, nil if no signature scheme is configured.
+var CheckpointSigner checkpoint.Signer
+
+// CheckpointQueue holds the checkpoints to publish by each report target, nil unless the committee is enabled.
+var CheckpointQueue *checkpoint.Queue
+
+// DABudget decides the publications to the DA layer by the estimated fee, nil if no budget is configured.
+var DABudget *checkpoint.Budget
+
+// GetterBreaker and DABreaker guard the calls to the getter and to the DA layer, nil until the indexing starts.
+var GetterBreaker, DABreaker *retry.Breaker
+
+// ShutdownTracing flushes the pending spans, nil if the tracing is disabled.
+var ShutdownTracing func(context.Context) error
+
+// flushTraces flushes the pending spans before the exit.
+func flushTraces() {
+	if ShutdownTracing == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := ShutdownTracing(ctx); err != nil {
+		log.Printf("Unable to flush the spans due to: %v", err)
+	}
+}
+
+// DatabaseConfig returns the database config with the latest credentials.
+func DatabaseConfig() getter.DatabaseConfig {
+	db := GlobalConfig.Database
+	return getter.DatabaseConfig{
+		Host:     Secrets.Get(db.Host),
+		User:     Secrets.Get(db.User),
+		Password: Secrets.Get(db.Password),
+		DBname:   Secrets.Get(db.DBname),
+		Port:     Secrets.Get(db.Port),
+	}
+}
+
+// LoadSecrets resolves every credential of GlobalConfig referring to a secret.
+func LoadSecrets(ctx context.Context) error {
+	Secrets = secrets.New(GlobalConfig.Secrets)
+	db, s3, da := GlobalConfig.Database, GlobalConfig.Report.S3, GlobalConfig.Report.Da
+	values := []string{
+		db.Host, db.User, db.Password, db.DBname, db.Port,
+		s3.AccessKey, s3.SecretKey,
+		da.PrivateKey, da.GasCoupon,
+		GlobalConfig.Report.Signature.PrivateKey,
+		GlobalConfig.Peers.SigningKey,
+		GlobalConfig.Bitcoind.URL,
+		GlobalConfig.Archive.AccessKey, GlobalConfig.Archive.SecretKey,
+	}
+	for _, value := range GlobalConfig.Report.HTTP.Headers {
+		values = append(values, value)
+	}
+	for _, id := range GlobalConfig.Report.Identities {
+		values = append(values, id.Signature.PrivateKey, id.Da.PrivateKey, id.Da.GasCoupon)
+	}
+	values = append(values, GlobalConfig.Peers.Tokens...)
+	values = append(values, GlobalConfig.Service.DryRun.Tokens...)
+	values = append(values, GlobalConfig.Service.Admin.Tokens...)
+	values = append(values, GlobalConfig.Service.Access.Keys...)
+	for _, m := range GlobalConfig.Peers.Audit.Members {
+		values = append(values, m.Token)
+	}
+	values = append(values, GlobalConfig.SnapshotBootstrap.Member.Token, GlobalConfig.Replica.Member.Token)
+	return Secrets.Load(ctx, values...)
+}
+
+// ReportTargets returns the methods publishing the checkpoints.
+func ReportTargets() []string {
+	if len(GlobalConfig.Report.Targets) != 0 {
+		return GlobalConfig.Report.Targets
+	}
+	return []string{GlobalConfig.Report.Method}
+}
+
+// reportsTo tells whether the checkpoints are published by the method.
+func reportsTo(method string) bool {
+	return slices.Contains(ReportTargets(), method)
+}
+
+// ReportSchedule returns the publication schedule of the report method.
+func ReportSchedule(method string) checkpoint.Schedule {
+	switch method {
+	case "S3":
+		return GlobalConfig.Report.Schedule.Override(GlobalConfig.Report.S3.Schedule)
+	case "DA":
+		return GlobalConfig.Report.Schedule.Override(GlobalConfig.Report.Da.Schedule)
+	case "Local":
+		return GlobalConfig.Report.Schedule.Override(GlobalConfig.Report.Local.Schedule)
+	case "HTTP":
+		return GlobalConfig.Report.Schedule.Override(GlobalConfig.Report.HTTP.Schedule)
+	}
+	return GlobalConfig.Report.Schedule
+}
+
+// DANamespaceID returns the namespace of the DA layer publishing the checkpoints of the indexed network.
+func DANamespaceID() string {
+	if nid, found := GlobalConfig.Report.Da.Namespaces[string(ord.IndexedNetwork)]; found {
+		return nid
+	}
+	return GlobalConfig.Report.Da.NamespaceID
+}
+
+// SelectNetwork sets the indexed network, by the flag or else by the config, along with the networks of the getters,
+// which are the indexed network unless set.
+func SelectNetwork(flag string) error {
+	name := GlobalConfig.Network
+	if flag != "" {
+		name = flag
+	}
+	network, err := ord.ParseNetwork(name)
+	if err != nil {
+		return err
+	}
+	getters := []struct {
+		name    string
+		enabled bool
+		network *string
+	}{
+		{"ord", GlobalConfig.Ord.Enabled, &GlobalConfig.Ord.Network},
+		{"bitcoind", GlobalConfig.Bitcoind.Enabled, &GlobalConfig.Bitcoind.Network},
+	}
+	for _, g := range getters {
+		switch {
+		case *g.network == "":
+			*g.network = string(network)
+		case g.enabled && *g.network != string(network):
+			return fmt.Errorf("the %s getter reads the network %s rather than %s", g.name, *g.network, network)
+		}
+	}
+	ord.IndexedNetwork = network
+	brc20.SelfMintEnableHeight = network.SelfMintHeight()
+	return nil
+}
+
+// ReportPublisher returns the publisher of the report method with the latest credentials.
+func ReportPublisher(method string) (checkpoint.Publisher, error) {
+	report := GlobalConfig.Report
+	switch method {
+	case "DA":
+		return &checkpoint.DAPublisher{
+			Network:     report.Da.Network,
+			NamespaceID: DANamespaceID(),
+			GasCoupon:   Secrets.Get(report.Da.GasCoupon),
+			PrivateKey:  Secrets.Get(report.Da.PrivateKey),
+			Breaker:     DABreaker,
+		}, nil
+	case "S3":
+		return &checkpoint.S3Publisher{
+			Bucket:    report.S3.Bucket,
+			Region:    report.S3.Region,
+			Endpoint:  report.S3.Endpoint,
+			AccessKey: Secrets.Get(report.S3.AccessKey),
+			SecretKey: Secrets.Get(report.S3.SecretKey),
+		}, nil
+	case "Local":
+		if report.Local.Dir == "" {
+			return nil, fmt.Errorf("the directory of the local report is missing")
+		}
+		return &checkpoint.LocalPublisher{Dir: report.Local.Dir}, nil
+	case "HTTP":
+		if report.HTTP.URL == "" {
+			return nil, fmt.Errorf("the URL of the HTTP report is missing")
+		}
+		headers := make(map[string]string, len(report.HTTP.Headers))
+		for name, value := range report.HTTP.Headers {
+			headers[name] = Secrets.Get(value)
+		}
+		return &checkpoint.HTTPPublisher{URL: report.HTTP.URL, Headers: headers}, nil
+	}
+	return nil, fmt.Errorf("unknown report method %s", method)
+}
+
+// OpenSource opens where a member publishes its checkpoints: a directory, s3://<bucket> read with the credentials
+// of the S3 report config, the http(s) URL serving the checkpoint files, or da://<namespaceID> on the network
+// of the DA report config.
+func OpenSource(source string) (checkpoint.Source, error) {
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		return &checkpoint.HTTPSource{URL: source, Client: &http.Client{Timeout: 10 * time.Second}}, nil
+	}
+	if namespaceID, found := strings.CutPrefix(source, "da://"); found {
+		return checkpoint.NewDASource(context.Background(), GlobalConfig.Report.Da.Network, namespaceID)
+	}
+	bucket, found := strings.CutPrefix(source, "s3://")
+	if !found {
+		return &checkpoint.DirSource{Dir: source}, nil
+	}
+	if bucket == "" || strings.Contains(bucket, "/") {
+		return nil, fmt.Errorf("invalid checkpoint source %s, expected s3://<bucket>", source)
+	}
+	s3cfg := GlobalConfig.Report.S3
+	return checkpoint.NewS3Source(context.Background(), Secrets.Get(s3cfg.AccessKey), Secrets.Get(s3cfg.SecretKey), s3cfg.Region, s3cfg.Endpoint, bucket)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord/getter"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+func Test_GracefulShutdown(t *testing.T) {
+	cleanup := func() {
+		for _, pattern := range []string{"*.dat", "*.diff", "*.census", "*.holders", "state.layout"} {
+			files, _ := filepath.Glob(filepath.Join(".cache", pattern))
+			for _, file := range files {
+				_ = os.Remove(file)
+			}
+		}
+	}
+	cleanup()
+	t.Cleanup(cleanup)
+
+	pkscript := "0014" + strings.Repeat("5f", 20)
+	mint := func(i string) getter.OrdTransfer {
+		return inscribe(strings.Repeat("5", 64)+"i"+i, pkscript, "", `{"p":"brc-20","op":"mint","tick":"halt","amt":"10"}`)
+	}
+	g := &blocksGetter{
+		blocks: map[uint][]getter.OrdTransfer{
+			800001: {inscribe(strings.Repeat("5", 64)+"i0", pkscript, "", `{"p":"brc-20","op":"deploy","tick":"halt","max":"100","lim":"10"}`)},
+			800002: {mint("1")},
+			800003: {mint("2")},
+			800007: {mint("3")},
+			800009: {mint("4")},
+		},
+		hashes: make(map[uint]string),
+	}
+	header := stateless.LoadHeader(false, 800000)
+	queue, err := stateless.NewQueues(g, header, true, 800001)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The shutdown stops before the next block.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := queue.UpdateContext(ctx, g, 800009); !errors.Is(err, context.Canceled) || queue.LatestHeight() != 800006 {
+		t.Fatalf("Expected the update to stop at height 800006, got %d: %v", queue.LatestHeight(), err)
+	}
+	if err := queue.UpdateContext(context.Background(), g, 800009); err != nil {
+		t.Fatal(err)
+	}
+
+	// The oldest kept block is stored, which the blocks executed again after the restart never revert.
+	finalized := queue.History[0]
+	if err := queue.StoreFinalized(); err != nil {
+		t.Fatal(err)
+	}
+	loaded := stateless.LoadHeader(true, 800000)
+	if loaded.Height != finalized.Height || loaded.Height != 800003 {
+		t.Fatalf("Loaded the state cache at height %d, expected %d", loaded.Height, finalized.Height)
+	}
+	if loaded.Root.Commit().Bytes() != finalized.VerkleCommit {
+		t.Fatalf("The loaded commitment differs at height %d", loaded.Height)
+	}
+	holders, total, height, _ := stateless.TickHolders("halt", 0, 10)
+	if total != 1 || height != 800003 || holders[0].OverallBalance != "20000000000000000000" {
+		t.Fatalf("Unexpected holders %+v at height %d", holders, height)
+	}
+
+	// The restarted queue catches up to the same state.
+	restarted, err := stateless.NewQueues(g, loaded, true, 800004)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := restarted.Update(g, 800009); err != nil {
+		t.Fatal(err)
+	}
+	holders, _, height, _ = stateless.TickHolders("halt", 0, 10)
+	if height != 800009 || holders[0].OverallBalance != "40000000000000000000" {
+		t.Fatalf("Unexpected holders %+v at height %d after the restart", holders, height)
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/RiemaLabs/modular-indexer-committee/apis"
+	"github.com/RiemaLabs/modular-indexer-committee/apis/pb"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/getter"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/subscription"
+)
+
+func Test_GRPC(t *testing.T) {
+	pkscriptA := "0014" + strings.Repeat("a3", 20)
+	pkscriptB := "0014" + strings.Repeat("b3", 20)
+	g := &blocksGetter{
+		blocks: map[uint][]getter.OrdTransfer{
+			800001: {inscribe(strings.Repeat("6", 64)+"i0", pkscriptA, "", `{"p":"brc-20","op":"deploy","tick":"GRPC","max":"100"}`)},
+			800002: {inscribe(strings.Repeat("6", 64)+"i1", pkscriptA, "", `{"p":"brc-20","op":"mint","tick":"grpc","amt":"10"}`)},
+		},
+		hashes: make(map[uint]string),
+	}
+	stateless.Subscriptions = subscription.NewHub(1)
+	defer func() {
+		stateless.Subscriptions = nil
+	}()
+	header := stateless.LoadHeader(false, 800000)
+	queue, err := stateless.NewQueues(g, header, true, 800001)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lis := bufconn.Listen(1 << 20)
+	srv := apis.NewGRPCServer(queue, "brc-20")
+	go func() {
+		_ = srv.Serve(lis)
+	}()
+	defer srv.Stop()
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	client := pb.NewCommitteeClient(conn)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// The balances are served with the block in the header and a proof against its state root.
+	var md metadata.MD
+	balance, err := client.GetBalanceOfPkscript(ctx, &pb.GetBalanceOfPkscriptRequest{Tick: "grpc", Pkscript: pkscriptA}, grpc.Header(&md))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance.AvailableBalance != "10000000000000000000" || balance.Height != uint64(queue.Header.Height) ||
+		md.Get(apis.HeaderBlockHeight)[0] != strconv.FormatUint(balance.Height, 10) || md.Get(apis.HeaderStateRoot)[0] != balance.StateRoot {
+		t.Fatalf("Unexpected balance %+v with the header %v", balance, md)
+	}
+	rootC, err := apis.ParseCommitment(balance.StateRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verified, err := apis.VerifyCurrentBalanceOfPkscript(rootC, "grpc", pkscriptA, &apis.Brc20VerifiableCurrentBalanceOfPkscriptResponse{
+		Result: &apis.Brc20VerifiableCurrentBalanceOfPkscriptResult{AvailableBalance: balance.AvailableBalance, OverallBalance: balance.OverallBalance},
+		Proof:  &balance.Proof,
+	})
+	if !verified || err != nil {
+		t.Fatalf("Failed to verify the balance: %v", err)
+	}
+
+	tick, err := client.GetTick(ctx, &pb.GetTickRequest{Tick: "GRPC"})
+	if err != nil || tick.Tick.Minted != "10000000000000000000" || tick.Tick.DeployHeight != 800001 || tick.Tick.CirculatingSupply != "10000000000000000000" {
+		t.Fatalf("Unexpected tick %+v, %v", tick, err)
+	}
+	if _, err := client.GetTick(ctx, &pb.GetTickRequest{Tick: "none"}); status.Code(err) != codes.NotFound {
+		t.Fatalf("Expected an undeployed tick to be not found, got %v", err)
+	}
+	ticks, err := client.ListTicks(ctx, &pb.ListTicksRequest{})
+	if err != nil || ticks.Total != 1 || len(ticks.Ticks) != 1 || ticks.Ticks[0].Tick != "grpc" {
+		t.Fatalf("Unexpected ticks %+v, %v", ticks, err)
+	}
+	checkpoint, err := client.GetCheckpoint(ctx, &pb.GetCheckpointRequest{})
+	if err != nil || len(checkpoint.Modules) == 0 || checkpoint.Modules[0].Commitment != balance.StateRoot {
+		t.Fatalf("Unexpected checkpoint %+v, %v", checkpoint, err)
+	}
+
+	// A block not reached in time is unavailable.
+	waitCtx := metadata.AppendToOutgoingContext(ctx, apis.MetadataMinHeight, "900000")
+	waitCtx, waitCancel := context.WithTimeout(waitCtx, 100*time.Millisecond)
+	defer waitCancel()
+	if _, err := client.GetBlockHeight(waitCtx, &pb.GetBlockHeightRequest{}); status.Code(err) != codes.Unavailable && status.Code(err) != codes.DeadlineExceeded {
+		t.Fatalf("Expected the min height to be waited for, got %v", err)
+	}
+
+	// The events of the following blocks are streamed, only of the requested ticks.
+	stream, err := client.StreamBlocks(ctx, &pb.StreamBlocksRequest{Ticks: []string{"GRPC"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for !stateless.Subscriptions.WatchesEvents() {
+		time.Sleep(10 * time.Millisecond)
+	}
+	g.blocks[800007] = []getter.OrdTransfer{
+		inscribe(strings.Repeat("7", 64)+"i0", pkscriptB, "", `{"p":"brc-20","op":"mint","tick":"grpc","amt":"5"}`),
+		inscribe(strings.Repeat("7", 64)+"i1", pkscriptB, "", `{"p":"brc-20","op":"deploy","tick":"othr","max":"100"}`),
+	}
+	if err := queue.Update(g, 800008); err != nil {
+		t.Fatal(err)
+	}
+	block, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if block.Height != 800007 || block.Hash != "hash800007" || block.Commitment == "" || len(block.Events) != 1 {
+		t.Fatalf("Unexpected block %+v", block)
+	}
+	if mint := block.Events[0].GetMint(); mint == nil || mint.Pkscript != pkscriptB || mint.Amount != "5000000000000000000" {
+		t.Fatalf("Unexpected block %+v", block)
+	}
+	// A block without any event is streamed as well.
+	block, err = stream.Recv()
+	if err != nil || block.Height != 800008 || len(block.Events) != 0 {
+		t.Fatalf("Unexpected block %+v, %v", block, err)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/RiemaLabs/modular-indexer-committee/apis"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/getter"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+func Test_HealthProbes(t *testing.T) {
+	g := &blocksGetter{blocks: map[uint][]getter.OrdTransfer{}, hashes: make(map[uint]string)}
+	header := stateless.LoadHeader(false, 800000)
+	queue, err := stateless.NewQueues(g, header, true, 800001)
+	if err != nil {
+		t.Fatal(err)
+	}
+	latestHeight := queue.LatestHeight()
+	defer func() {
+		apis.RecordTip(0)
+		apis.RecordUpstream("DA", nil)
+	}()
+
+	ts := httptest.NewServer(apis.NewRouter(queue, "brc-20", false, false))
+	defer ts.Close()
+	probe := func(path string) (int, apis.HealthResponse) {
+		resp, err := http.Get(ts.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var res apis.HealthResponse
+		if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode, res
+	}
+
+	apis.RecordTip(latestHeight + 1)
+	apis.RecordUpstream(apis.UpstreamGetter, nil)
+	for _, path := range []string{"/healthz", "/readyz"} {
+		if status, res := probe(path); status != http.StatusOK || res.Result.IndexedHeight != latestHeight || res.Result.Lag != 1 || !res.Result.Upstreams[apis.UpstreamGetter].Connected {
+			t.Fatalf("Unexpected %s %d %+v", path, status, res.Result)
+		}
+	}
+
+	// The lag beyond the threshold fails both probes.
+	apis.RecordTip(latestHeight + apis.MaxLag + 1)
+	for _, path := range []string{"/healthz", "/readyz"} {
+		if status, res := probe(path); status != http.StatusServiceUnavailable || res.Error == nil || res.Result.Lag != apis.MaxLag+1 {
+			t.Fatalf("Expected %s to fail with the lag, got %d %+v", path, status, res.Result)
+		}
+	}
+
+	// An unreachable upstream only fails the readiness, until it's reached again.
+	apis.RecordTip(latestHeight)
+	apis.RecordUpstream("DA", errors.New("timeout"))
+	if status, _ := probe("/healthz"); status != http.StatusOK {
+		t.Fatalf("Expected the liveness to ignore the upstreams, got %d", status)
+	}
+	if status, res := probe("/readyz"); status != http.StatusServiceUnavailable || res.Result.Upstreams["DA"].Error != "timeout" || res.Result.Upstreams["DA"].LastSuccessAt != nil {
+		t.Fatalf("Expected the readiness to fail with the DA, got %d %+v", status, res.Result)
+	}
+	apis.RecordUpstream("DA", nil)
+	apis.RecordCheckpoint()
+	if status, res := probe("/readyz"); status != http.StatusOK || res.Result.LastCheckpointAt == nil || res.Result.Upstreams["DA"].LastSuccessAt == nil {
+		t.Fatalf("Expected the readiness once the DA is reached again, got %d %+v", status, res.Result)
+	}
+}
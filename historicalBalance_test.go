@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-verkle"
+	"github.com/gin-gonic/gin"
+
+	"github.com/RiemaLabs/modular-indexer-committee/apis"
+	"github.com/RiemaLabs/modular-indexer-committee/ord"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/brc20"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+func Test_HistoricalBalance(t *testing.T) {
+	var latestHeight uint = 780000
+	heights := []uint{stateless.BRC20StartHeight - 1, 779900, 779960, 779990, 779996, latestHeight}
+	ordGetterTest, arguments := loadMain(782000)
+
+	// The key-values at the sampled heights, executed block by block.
+	expected := make(map[uint]stateless.MemoryKV)
+	header := stateless.LoadHeader(false, stateless.BRC20StartHeight-1)
+	snapshot := func() {
+		kv := make(stateless.MemoryKV)
+		header.KV.Range(func(key [verkle.KeySize]byte, value [stateless.ValueSize]byte) bool {
+			kv[key] = value
+			return true
+		})
+		expected[header.Height] = kv
+	}
+	snapshot()
+	for i := header.Height + 1; i <= latestHeight; i++ {
+		ots, err := ordGetterTest.GetOrdTransfers(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stateless.Exec(header, ots, i)
+		if err := header.Paging(ordGetterTest, false, stateless.NodeResolveFn); err != nil {
+			t.Fatal(err)
+		}
+		for _, height := range heights {
+			if height == header.Height {
+				snapshot()
+			}
+		}
+	}
+
+	stateless.HistoryPath = t.TempDir()
+	defer func() {
+		_ = stateless.CloseHistory()
+		stateless.HistoryPath = ""
+	}()
+	queue, err := CatchupStage(ordGetterTest, &arguments, stateless.BRC20StartHeight-1, latestHeight)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Every key ever written is compared at every sampled height.
+	final := expected[latestHeight]
+	if len(final) == 0 {
+		t.Fatal("Expected the blocks to write the state")
+	}
+	for _, height := range heights {
+		kv := expected[height]
+		for key := range final {
+			value, err := queue.ValueAt(key, height)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, found := kv[key]
+			if !found {
+				want = [stateless.ValueSize]byte{}
+			}
+			if value != want {
+				t.Fatalf("Unexpected value of the key %x at height %d", key, height)
+			}
+		}
+	}
+
+	// Without the history database only the latest blocks kept by the queue are served.
+	stateless.HistoryPath = ""
+	key := [verkle.KeySize]byte(brc20.GetTickPkscriptHash("ordi", ord.Pkscript("5120409943cab2dee3c71940969a612c6ee65c57cad1f064ca8db4508dab49260ca3"), brc20.OverallBalancePkscript))
+	if _, err := queue.ValueAt(key, latestHeight-1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := queue.ValueAt(key, 779900); !errors.Is(err, stateless.ErrHistoryUnavailable) {
+		t.Fatalf("Expected the height 779900 to be unavailable, got %v", err)
+	}
+	if _, err := queue.ValueAt(key, latestHeight+1); !errors.Is(err, stateless.ErrHeightNotReached) {
+		t.Fatalf("Expected the height %d to be unreached, got %v", latestHeight+1, err)
+	}
+	stateless.HistoryPath = t.TempDir()
+	_ = stateless.CloseHistory()
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/v1/brc20_balance", func(c *gin.Context) {
+		apis.GetBalanceAtHeight(c, queue)
+	})
+	query := func(height string) (int, apis.Brc20BalanceResponse) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/v1/brc20_balance?tick=ordi&pkscript=5120409943cab2dee3c71940969a612c6ee65c57cad1f064ca8db4508dab49260ca3&height="+height, nil)
+		r.ServeHTTP(w, req)
+		var resp apis.Brc20BalanceResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		return w.Code, resp
+	}
+	if code, resp := query("779960"); code != http.StatusNotFound || resp.Error == nil {
+		t.Fatalf("Expected the height 779960 to be unavailable from an empty history, got %d", code)
+	}
+	code, resp := query("779999")
+	if code != http.StatusOK || resp.Result == nil || resp.Result.Height != 779999 {
+		t.Fatalf("Unexpected response at height 779999: %d", code)
+	}
+	if code, _ := query("abc"); code != http.StatusBadRequest {
+		t.Fatalf("Expected an invalid height to be rejected, got %d", code)
+	}
+}
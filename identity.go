@@ -0,0 +1,213 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"slices"
+
+	"github.com/RiemaLabs/modular-indexer-committee/checkpoint"
+	"github.com/RiemaLabs/modular-indexer-committee/ord"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+// IdentityConfig is another committee member which the checkpoints are published on behalf of, e.g. the member of
+// a canary namespace, with its own signing key and queue.
+type IdentityConfig struct {
+	// The name of the member in its checkpoints, and the URL of its service, the one of the service if empty.
+	Name string `json:"name"`
+	URL  string `json:"url"`
+	// The publication method of the checkpoints: DA (default) or Local.
+	Method string `json:"method"`
+	// The signature attesting the checkpoints, optional.
+	Signature checkpoint.SignatureConfig `json:"signature"`
+	// The checkpoints not published yet, which must not share the file of another queue.
+	Queue checkpoint.QueueConfig `json:"queue"`
+	// The namespace and the account of the member on the DA layer of the report config.
+	Da struct {
+		NamespaceID string `json:"namespaceID"`
+		GasCoupon   string `json:"gasCoupon"`
+		PrivateKey  string `json:"privateKey"`
+		// The namespace IDs by the indexed networks, which replace the namespaceID on the network.
+		Namespaces map[string]string `json:"namespaces"`
+	} `json:"da"`
+	Local struct {
+		Dir string `json:"dir"`
+	} `json:"local"`
+}
+
+func (cfg *IdentityConfig) method() string {
+	if cfg.Method == "" {
+		return "DA"
+	}
+	return cfg.Method
+}
+
+func (cfg *IdentityConfig) namespaceID() string {
+	if nid, found := cfg.Da.Namespaces[string(ord.IndexedNetwork)]; found {
+		return nid
+	}
+	return cfg.Da.NamespaceID
+}
+
+// Identity is a committee member which the checkpoints are published on behalf of.
+type Identity struct {
+	// The name and the URL of the member in its checkpoints, the ones of the service if empty.
+	Name string
+	URL  string
+	// Attests the checkpoints, nil if unsigned.
+	Signer checkpoint.Signer
+	// The checkpoints to publish by each target of the member.
+	Queue *checkpoint.Queue
+	// The config of another member, nil for the member of the service.
+	cfg *IdentityConfig
+}
+
+// Identities are the other members which the checkpoints are published on behalf of, nil unless the committee is
+// enabled.
+var Identities []*Identity
+
+// PublishingIdentities returns the member of the service, publishing by the report targets, followed by the other
+// members.
+func PublishingIdentities() []*Identity {
+	primary := &Identity{Signer: CheckpointSigner, Queue: CheckpointQueue}
+	return append([]*Identity{primary}, Identities...)
+}
+
+// NewIdentity checks the config of another member, and opens its queue.
+func NewIdentity(cfg IdentityConfig) (*Identity, error) {
+	if cfg.Name == "" {
+		return nil, fmt.Errorf("the name of the identity is missing")
+	}
+	switch cfg.method() {
+	case "DA":
+		if !checkpoint.IsValidNamespaceID(cfg.namespaceID()) {
+			return nil, fmt.Errorf("invalid namespace ID %q of the identity %s, create the namespace ahead", cfg.namespaceID(), cfg.Name)
+		}
+		if cfg.Da.PrivateKey == "" {
+			return nil, fmt.Errorf("the DA private key of the identity %s is missing", cfg.Name)
+		}
+	case "Local":
+		if cfg.Local.Dir == "" {
+			return nil, fmt.Errorf("the directory of the local report of the identity %s is missing", cfg.Name)
+		}
+	default:
+		return nil, fmt.Errorf("unknown report method %s of the identity %s", cfg.Method, cfg.Name)
+	}
+	id := Identity{Name: cfg.Name, URL: cfg.URL, cfg: &cfg}
+	var err error
+	if cfg.Signature.Scheme != "" {
+		id.Signer, err = checkpoint.NewSigner(cfg.Signature.Scheme, Secrets.Get(cfg.Signature.PrivateKey))
+		if err != nil {
+			return nil, fmt.Errorf("invalid checkpoint signature of the identity %s: %v", cfg.Name, err)
+		}
+	}
+	id.Queue, err = checkpoint.NewQueue(cfg.Queue)
+	if err != nil {
+		return nil, fmt.Errorf("invalid checkpoint queue of the identity %s: %v", cfg.Name, err)
+	}
+	return &id, nil
+}
+
+// LoadIdentities opens the other members of the report config, whose names and queue files must be distinct from
+// the ones of the service.
+func LoadIdentities(arguments *RuntimeArguments) ([]*Identity, error) {
+	name := GlobalConfig.Service.Name
+	if arguments.CommitteeIndexerName != "" {
+		name = arguments.CommitteeIndexerName
+	}
+	names := map[string]bool{name: true}
+	paths := map[string]bool{GlobalConfig.Report.Queue.Path: true}
+	identities := make([]*Identity, 0, len(GlobalConfig.Report.Identities))
+	for _, cfg := range GlobalConfig.Report.Identities {
+		id, err := NewIdentity(cfg)
+		if err != nil {
+			return nil, err
+		}
+		if names[cfg.Name] {
+			return nil, fmt.Errorf("the name of the identity %s is already taken", cfg.Name)
+		}
+		names[cfg.Name] = true
+		if cfg.Queue.Path != "" && paths[cfg.Queue.Path] {
+			return nil, fmt.Errorf("the queue %s of the identity %s is shared", cfg.Queue.Path, cfg.Name)
+		}
+		paths[cfg.Queue.Path] = true
+		if err := ReportSchedule(cfg.method()).Validate(ord.BitcoinConfirmations); err != nil {
+			return nil, fmt.Errorf("invalid publication schedule of %s: %v", id.Target(cfg.method()), err)
+		}
+		if id.Signer != nil {
+			log.Printf("The checkpoints of %s are published by %s, signed by the %s public key %x", id.Name, cfg.method(), id.Signer.Scheme(), id.Signer.PublicKey())
+		} else {
+			log.Printf("The checkpoints of %s are published by %s", id.Name, cfg.method())
+		}
+		identities = append(identities, id)
+	}
+	return identities, nil
+}
+
+// identitiesReportTo tells whether the checkpoints of another member are published by the method.
+func identitiesReportTo(method string) bool {
+	for _, id := range Identities {
+		if slices.Contains(id.Targets(), method) {
+			return true
+		}
+	}
+	return false
+}
+
+// Primary tells whether the identity is the member of the service.
+func (id *Identity) Primary() bool {
+	return id.cfg == nil
+}
+
+// Targets returns the methods publishing the checkpoints of the member.
+func (id *Identity) Targets() []string {
+	if id.Primary() {
+		return ReportTargets()
+	}
+	return []string{id.cfg.method()}
+}
+
+// Target names the method of the member in the queue, the metrics and the logs, e.g. DA/canary.
+func (id *Identity) Target(method string) string {
+	if id.Primary() {
+		return method
+	}
+	return method + "/" + id.Name
+}
+
+// Publisher returns the publisher of the method with the latest credentials of the member.
+func (id *Identity) Publisher(method string) (checkpoint.Publisher, error) {
+	if id.Primary() {
+		return ReportPublisher(method)
+	}
+	switch method {
+	case "DA":
+		return &checkpoint.DAPublisher{
+			Network:     GlobalConfig.Report.Da.Network,
+			NamespaceID: id.cfg.namespaceID(),
+			GasCoupon:   Secrets.Get(id.cfg.Da.GasCoupon),
+			PrivateKey:  Secrets.Get(id.cfg.Da.PrivateKey),
+			Breaker:     DABreaker,
+		}, nil
+	case "Local":
+		return &checkpoint.LocalPublisher{Dir: id.cfg.Local.Dir}, nil
+	}
+	return nil, fmt.Errorf("unknown report method %s of the identity %s", method, id.Name)
+}
+
+// Checkpoint returns the checkpoint of the state by the member, signed by its key if any.
+func (id *Identity) Checkpoint(arguments *RuntimeArguments, state *stateless.DiffState) (checkpoint.Checkpoint, error) {
+	c := newCheckpoint(arguments, state)
+	if id.Name != "" {
+		c.Name = id.Name
+	}
+	if id.URL != "" {
+		c.URL = id.URL
+	}
+	if id.Signer != nil {
+		if err := c.Sign(id.Signer); err != nil {
+			return c, err
+		}
+	}
+	return c, nil
+}
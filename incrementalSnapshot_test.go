@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord/getter"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+func Test_IncrementalSnapshot(t *testing.T) {
+	stateless.SnapshotBaselineInterval = 3
+	cleanup := func() {
+		for _, pattern := range []string{"*.dat", "*.diff", "*.census", "*.holders", "state.layout"} {
+			files, _ := filepath.Glob(filepath.Join(".cache", pattern))
+			for _, file := range files {
+				_ = os.Remove(file)
+			}
+		}
+	}
+	cleanup()
+	t.Cleanup(func() {
+		stateless.SnapshotBaselineInterval = 10000
+		cleanup()
+	})
+
+	header := stateless.LoadHeader(true, 800000)
+	pkscript := "0014" + strings.Repeat("44", 20)
+	blocks := [][]getter.OrdTransfer{
+		{inscribe(strings.Repeat("a", 64)+"i0", pkscript, "", `{"p":"brc-20","op":"deploy","tick":"diff","max":"100","lim":"10"}`)},
+		{inscribe(strings.Repeat("a", 64)+"i1", pkscript, "", `{"p":"brc-20","op":"mint","tick":"diff","amt":"10"}`)},
+		{},
+		{inscribe(strings.Repeat("a", 64)+"i2", pkscript, "", `{"p":"brc-20","op":"mint","tick":"diff","amt":"10"}`)},
+		{inscribe(strings.Repeat("a", 64)+"i3", pkscript, "", `{"p":"brc-20","op":"mint","tick":"diff","amt":"5"}`)},
+	}
+	for _, ots := range blocks {
+		stateless.Exec(header, ots, header.Height+1)
+		if err := header.Paging(nil, false, stateless.NodeResolveFn); err != nil {
+			t.Fatal(err)
+		}
+		if err := stateless.StoreHeader(header, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A baseline every 3 blocks, and the diffs of the blocks in between.
+	for _, name := range []string{"800001.dat", "800002.diff", "800003.diff", "800004.dat", "800005.diff"} {
+		if _, err := os.Stat(filepath.Join(".cache", name)); err != nil {
+			t.Fatalf("Expected the state cache %s: %v", name, err)
+		}
+	}
+
+	check := func() {
+		loaded := stateless.LoadHeader(true, 800000)
+		if loaded.Height != header.Height || loaded.KV.Len() != header.KV.Len() {
+			t.Fatalf("Loaded %d keys at height %d, expected %d keys at height %d", loaded.KV.Len(), loaded.Height, header.KV.Len(), header.Height)
+		}
+		if loaded.Root.Commit().Bytes() != header.Root.Commit().Bytes() {
+			t.Fatalf("The loaded commitment differs at height %d", header.Height)
+		}
+		// The holders are stored along with the state cache.
+		holders, total, height, _ := stateless.TickHolders("diff", 0, 10)
+		if total != 1 || height != header.Height || holders[0].Pkscript != pkscript || holders[0].OverallBalance != "25000000000000000000" {
+			t.Fatalf("Unexpected holders %+v at height %d", holders, height)
+		}
+	}
+	check()
+
+	// The older baselines and their diffs are evicted, while the latest baseline is kept however old it is.
+	if err := stateless.StoreHeader(header, header.Height); err != nil {
+		t.Fatal(err)
+	}
+	for height := uint(800001); height <= 800005; height++ {
+		_, errDat := os.Stat(filepath.Join(".cache", fmt.Sprintf("%d.dat", height)))
+		_, errDiff := os.Stat(filepath.Join(".cache", fmt.Sprintf("%d.diff", height)))
+		kept := errDat == nil || errDiff == nil
+		if kept != (height >= 800004) {
+			t.Fatalf("Unexpected eviction of the state cache at height %d", height)
+		}
+	}
+	check()
+}
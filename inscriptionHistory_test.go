@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/RiemaLabs/modular-indexer-committee/apis"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/getter"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+func Test_InscriptionHistory(t *testing.T) {
+	pkscriptA := "0014" + strings.Repeat("a2", 20)
+	pkscriptB := "0014" + strings.Repeat("b2", 20)
+	transferA := strings.Repeat("a", 64) + "i7"
+	transferB := strings.Repeat("b", 64) + "i7"
+	move := func(inscriptionID string, pkscript string, sentAsFee bool) getter.OrdTransfer {
+		ot := inscribe(inscriptionID, pkscript, "", `{"p":"brc-20","op":"transfer","tick":"hist","amt":"4"}`)
+		ot.OldSatpoint = inscriptionID + ":0:0"
+		ot.SentAsFee = sentAsFee
+		return ot
+	}
+	g := &blocksGetter{
+		blocks: map[uint][]getter.OrdTransfer{
+			800001: {
+				inscribe(strings.Repeat("9", 64)+"i0", pkscriptA, "", `{"p":"brc-20","op":"deploy","tick":"hist","max":"100","lim":"10"}`),
+				inscribe(strings.Repeat("9", 64)+"i1", pkscriptA, "", `{"p":"brc-20","op":"mint","tick":"hist","amt":"10"}`),
+			},
+			800002: {
+				inscribe(transferA, pkscriptA, "", `{"p":"brc-20","op":"transfer","tick":"hist","amt":"4"}`),
+				inscribe(transferB, pkscriptA, "", `{"p":"brc-20","op":"transfer","tick":"hist","amt":"4"}`),
+			},
+			800003: {move(transferA, pkscriptB, false), move(transferB, pkscriptB, true)},
+		},
+		hashes: make(map[uint]string),
+	}
+	stateless.EventsPath = t.TempDir()
+	defer func() {
+		_ = stateless.CloseEvents()
+		stateless.EventsPath = ""
+	}()
+	header := stateless.LoadHeader(false, 800000)
+	queue, err := stateless.NewQueues(g, header, true, 800001)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := apis.NewRouter(queue, "brc-20", false, false)
+	history := func(inscriptionID string) (int, *apis.InscriptionHistoryResult) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/brc20_inscription/"+inscriptionID+"/history", nil))
+		var resp apis.InscriptionHistoryResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Unexpected response %d: %s", w.Code, w.Body.String())
+		}
+		return w.Code, resp.Result
+	}
+
+	code, result := history(transferA)
+	if code != http.StatusOK || result.Status != apis.InscriptionSpent || result.Tick != "hist" || result.Amount != "4000000000000000000" || result.FromHeight != 800001 || result.Height != queue.Header.Height {
+		t.Fatalf("Unexpected history %d: %+v", code, result)
+	}
+	if len(result.History) != 2 || result.History[0].Height != 800002 || result.History[0].Pkscript != pkscriptA ||
+		result.History[1].Height != 800003 || result.History[1].Pkscript != pkscriptA || result.History[1].SpentPkscript != pkscriptB {
+		t.Fatalf("Unexpected lifecycle %+v", result.History)
+	}
+	code, result = history(transferB)
+	if code != http.StatusOK || result.Status != apis.InscriptionSentAsFee || len(result.History) != 2 || !result.History[1].SentAsFee || result.History[1].SpentPkscript != "" {
+		t.Fatalf("Unexpected history %d: %+v", code, result)
+	}
+
+	// The reorganized block leaves the transfer unspent, which is transferable again.
+	g.blocks[800003] = []getter.OrdTransfer{move(transferB, pkscriptB, true)}
+	g.hashes[800003] = "fork800003"
+	if err := queue.Recovery(g, 800003); err != nil {
+		t.Fatal(err)
+	}
+	code, result = history(transferA)
+	if code != http.StatusOK || result.Status != apis.InscriptionTransferable || len(result.History) != 1 {
+		t.Fatalf("Unexpected history after the reorg %d: %+v", code, result)
+	}
+
+	// Neither a mint nor an unknown inscription is a transfer inscription.
+	if code, _ := history(strings.Repeat("9", 64) + "i1"); code != http.StatusNotFound {
+		t.Fatalf("Expected the mint to be not found, got %d", code)
+	}
+	if code, _ := history(strings.Repeat("c", 64) + "i0"); code != http.StatusNotFound {
+		t.Fatalf("Expected the unknown inscription to be not found, got %d", code)
+	}
+}
@@ -18,6 +18,7 @@ const (
 	StageServing
 	StageUpdating
 	StageReorg
+	StagePaused
 )
 
 func fqn(name string) string {
@@ -52,6 +53,28 @@ var (
 		Help: "Current height during catchup or serving",
 	})
 
+	LatestHeight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: fqn("latest_height"),
+		Help: "Latest height of the bitcoin chain reported by the getter",
+	})
+
+	ExecDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    fqn("exec_duration"),
+		Help:    "Duration of executing the transfers of a block",
+		Buckets: []float64{0.01, 0.05, 0.1, 0.5, 1, 5, 15, 60},
+	})
+
+	CommitDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    fqn("commit_duration"),
+		Help:    "Duration of committing the verkle tree after a block, except during the pipelined catchup",
+		Buckets: []float64{0.01, 0.05, 0.1, 0.5, 1, 5, 15},
+	})
+
+	KVSize = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: fqn("kv_size"),
+		Help: "Number of the key-values in the state",
+	})
+
 	HttpDuration = prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
 			Name:    fqn("http_duration"),
@@ -60,6 +83,199 @@ var (
 		},
 		[]string{"method", "path", "status"},
 	)
+
+	BlockTransfers = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: fqn("block_transfers"),
+		Help: "Number of the transfers of the block being executed",
+	})
+
+	BlockTransfersProcessed = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: fqn("block_transfers_processed"),
+		Help: "Number of the processed transfers of the block being executed",
+	})
+
+	PrefetchedBlocks = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: fqn("prefetched_blocks"),
+		Help: "Number of the blocks fetched ahead of the execution during catchup",
+	})
+
+	APIRejections = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: fqn("api_rejections_total"),
+			Help: "Number of the API requests rejected by the access control by the reason (unauthorized, rate_limited)",
+		},
+		[]string{"reason"},
+	)
+
+	GetterCache = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: fqn("getter_cache_total"),
+			Help: "Number of the lookups of the getter cache by the op (latestHeight, blockHash, transfers) and the result (hit, miss)",
+		},
+		[]string{"op", "result"},
+	)
+
+	BlockDeadlineExceeded = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: fqn("block_deadline_exceeded_total"),
+		Help: "Number of the blocks rolled back for exceeding the execution deadline",
+	})
+
+	PeerAudits = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: fqn("peer_audits_total"),
+			Help: "Number of the sampling audits of the other members by the result (match, mismatch, skipped, failed)",
+		},
+		[]string{"member", "result"},
+	)
+
+	CrossChecks = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: fqn("cross_checks_total"),
+			Help: "Number of the cross-checks of the checkpoints of the other members by the result (agree, diverge, missing, invalid, failed)",
+		},
+		[]string{"member", "result"},
+	)
+
+	DivergingMembers = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: fqn("diverging_members"),
+		Help: "Number of the members whose checkpoints diverge from the local one at the latest cross-checked height",
+	})
+
+	OPIChecks = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: fqn("opi_checks_total"),
+			Help: "Number of the cross-checks of the event hashes of the blocks against OPI by the result (agree, diverge, failed)",
+		},
+		[]string{"result"},
+	)
+
+	OPIDivergenceHeight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: fqn("opi_divergence_height"),
+		Help: "The first height whose event hashes diverge from the ones of OPI, 0 if none",
+	})
+
+	FraudProofs = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: fqn("fraud_proofs_total"),
+			Help: "Number of the fraud proofs of the diverging checkpoints by the result (saved, failed)",
+		},
+		[]string{"member", "result"},
+	)
+
+	SelfAuditFindings = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: fqn("self_audit_findings"),
+			Help: "Number of the published checkpoints failing the latest self-audit by the kind (tampered, missing, duplicate, diverged)",
+		},
+		[]string{"kind"},
+	)
+
+	RulesDisagreement = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: fqn("rules_disagreement"),
+		Help: "1 if the rules version disagrees with the majority of the audited members and the checkpoints are withheld",
+	})
+
+	ArchivedFiles = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: fqn("archived_files_total"),
+			Help: "Number of the local files moved to the archive tier by the class",
+		},
+		[]string{"class"},
+	)
+
+	ArchiveRetrievals = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: fqn("archive_retrievals_total"),
+			Help: "Number of the objects retrieved on demand from the archive tier by the class",
+		},
+		[]string{"class"},
+	)
+
+	DAFeeEstimate = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: fqn("da_fee_estimate"),
+		Help: "Latest estimated storage fee of publishing a checkpoint to the DA layer",
+	})
+
+	DASpent = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: fqn("da_spent"),
+		Help: "Storage fee spent on the DA publications in the current month",
+	})
+
+	DABudget = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: fqn("da_budget"),
+		Help: "Monthly budget of the storage fee of the DA publications, 0 if unlimited",
+	})
+
+	CheckpointUploads = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: fqn("checkpoint_uploads_total"),
+			Help: "Number of the checkpoint uploads by the method (DA, S3, Local, HTTP) and the result (success, failure)",
+		},
+		[]string{"method", "result"},
+	)
+
+	CheckpointQueue = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: fqn("checkpoint_queue_pending"),
+			Help: "Number of the checkpoints waiting to be published by the method",
+		},
+		[]string{"method"},
+	)
+
+	FailedTransfers = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: fqn("failed_transfers_total"),
+			Help: "Number of the transfers skipped by a failure of the execution, by the kind (error, panic)",
+		},
+		[]string{"kind"},
+	)
+
+	EvictedNodes = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: fqn("evicted_nodes_total"),
+			Help: "Number of the verkle nodes evicted from the memory into the state database",
+		},
+	)
+
+	DAPublications = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: fqn("da_publications_total"),
+			Help: "Number of the decisions of the DA budget on the due checkpoints (published, delayed, coalesced, over_budget)",
+		},
+		[]string{"decision"},
+	)
+
+	UpstreamCircuit = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: fqn("upstream_circuit"),
+			Help: "State of the circuit breaker of an upstream (0 closed, 1 open, 2 half-open), by the upstream (getter, DA)",
+		},
+		[]string{"upstream"},
+	)
+
+	UpstreamRetries = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: fqn("upstream_retries_total"),
+			Help: "Number of the retried calls to an upstream after a failed attempt, by the upstream",
+		},
+		[]string{"upstream"},
+	)
+
+	UpstreamRefusals = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: fqn("upstream_refusals_total"),
+			Help: "Number of the calls to an upstream refused by its open circuit, by the upstream",
+		},
+		[]string{"upstream"},
+	)
+
+	WebhookDeliveries = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: fqn("webhook_deliveries_total"),
+			Help: "Number of the blocks POSTed to the webhooks by the result (success, failure, dropped)",
+		},
+		[]string{"result"},
+	)
 )
 
 func ObserveDBQuery(op string, started time.Time) {
@@ -84,10 +300,78 @@ func init() {
 		Stage,
 		DBQueryDuration,
 		CurrentHeight,
+		LatestHeight,
+		ExecDuration,
+		CommitDuration,
+		KVSize,
 		HttpDuration,
+		BlockTransfers,
+		BlockTransfersProcessed,
+		PrefetchedBlocks,
+		GetterCache,
+		APIRejections,
+		BlockDeadlineExceeded,
+		PeerAudits,
+		CrossChecks,
+		DivergingMembers,
+		OPIChecks,
+		OPIDivergenceHeight,
+		FraudProofs,
+		SelfAuditFindings,
+		RulesDisagreement,
+		ArchivedFiles,
+		ArchiveRetrievals,
+		DAFeeEstimate,
+		DASpent,
+		DABudget,
+		CheckpointUploads,
+		CheckpointQueue,
+		FailedTransfers,
+		EvictedNodes,
+		DAPublications,
+		UpstreamCircuit,
+		UpstreamRetries,
+		UpstreamRefusals,
+		WebhookDeliveries,
 	)
 }
 
+// RegisterSkippedContents exposes the number of the skipped inscription contents, counted by the rules engine.
+func RegisterSkippedContents(reasons []string, count func(reason string) uint64) {
+	for _, reason := range reasons {
+		reason := reason
+		prometheus.MustRegister(prometheus.NewCounterFunc(
+			prometheus.CounterOpts{
+				Name:        fqn("skipped_contents"),
+				Help:        "Number of the inscription contents skipped by the content limits",
+				ConstLabels: prometheus.Labels{"reason": reason},
+			},
+			func() float64 { return float64(count(reason)) },
+		))
+	}
+}
+
+// RegisterStemCache exposes the hits and the misses of the stem cache of the blocks, counted by the rules engine.
+func RegisterStemCache(stats func() (uint64, uint64)) {
+	for _, result := range []string{"hit", "miss"} {
+		result := result
+		prometheus.MustRegister(prometheus.NewCounterFunc(
+			prometheus.CounterOpts{
+				Name:        fqn("stem_cache_lookups"),
+				Help:        "Number of the lookups of the key stems memoized in the blocks",
+				ConstLabels: prometheus.Labels{"result": result},
+			},
+			func() float64 {
+				hits, misses := stats()
+				if result == "hit" {
+					return float64(hits)
+				}
+				return float64(misses)
+			},
+		))
+	}
+}
+
 func ListenAndServe(addr string) {
 	mux := http.NewServeMux()
 	mux.Handle("/metrics", promhttp.Handler())
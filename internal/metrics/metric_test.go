@@ -39,6 +39,9 @@ func TestHTTP(t *testing.T) {
 
 	l := promlint.New(rsp.Body)
 	l.AddCustomValidations(func(mf *prompb.MetricFamily) []error {
+		if mf.GetName() != fqn("http_duration") {
+			return nil
+		}
 		for _, metric := range mf.GetMetric() {
 			if h := metric.Histogram; h != nil {
 				if sum := time.Duration(*h.SampleSum * float64(time.Second)); sum <= elapsed {
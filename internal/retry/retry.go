@@ -0,0 +1,246 @@
+// Package retry guards the calls to an upstream, i.e. the getter or the DA layer, by the retries with a jittered
+// exponential backoff, and by a circuit breaker failing the calls fast while the upstream is down, so that the
+// indexing pauses until the upstream recovers instead of crashing or spinning on a transient outage.
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/RiemaLabs/modular-indexer-committee/internal/metrics"
+)
+
+const (
+	DefaultAttempts   = 4
+	DefaultBackoff    = 500 * time.Millisecond
+	DefaultMaxBackoff = 10 * time.Second
+	DefaultThreshold  = 3
+	DefaultCooldown   = 30 * time.Second
+)
+
+// ErrUnavailable is returned once a call fails every attempt, or is refused by the open circuit.
+var ErrUnavailable = errors.New("the upstream is unavailable")
+
+type Config struct {
+	// The attempts of a call before it fails, 4 by default.
+	Attempts int `json:"attempts"`
+	// The milliseconds before the first retry, doubled by every retry up to the maxBackoff, 500 and 10000 by default.
+	// Each delay is jittered between its half and itself.
+	Backoff    int `json:"backoff"`
+	MaxBackoff int `json:"maxBackoff"`
+	// The consecutive failed calls opening the circuit, 3 by default.
+	Threshold int `json:"threshold"`
+	// The seconds the circuit stays open before a single call probes the upstream again, 30 by default.
+	Cooldown int `json:"cooldown"`
+}
+
+// State is the state of a circuit.
+type State int
+
+const (
+	// Closed passes the calls to the upstream.
+	Closed State = iota
+	// Open refuses the calls until the cooldown ends.
+	Open
+	// HalfOpen passes a single call probing the upstream, which closes the circuit once succeeded.
+	HalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	}
+	return fmt.Sprintf("State(%d)", int(s))
+}
+
+// Breaker guards the calls to an upstream named by the logs and the metrics. A nil Breaker calls the upstream once.
+type Breaker struct {
+	name       string
+	attempts   int
+	backoff    time.Duration
+	maxBackoff time.Duration
+	threshold  int
+	cooldown   time.Duration
+
+	mu       sync.Mutex
+	state    State
+	failures int
+	openedAt time.Time
+	probing  bool
+	lastErr  error
+
+	// Replaced by the tests.
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// NewBreaker returns the closed circuit of the upstream, whose unset config falls back to the defaults.
+func NewBreaker(name string, cfg Config) *Breaker {
+	b := &Breaker{
+		name:       name,
+		attempts:   DefaultAttempts,
+		backoff:    DefaultBackoff,
+		maxBackoff: DefaultMaxBackoff,
+		threshold:  DefaultThreshold,
+		cooldown:   DefaultCooldown,
+		now:        time.Now,
+		sleep:      sleep,
+	}
+	if cfg.Attempts > 0 {
+		b.attempts = cfg.Attempts
+	}
+	if cfg.Backoff > 0 {
+		b.backoff = time.Duration(cfg.Backoff) * time.Millisecond
+	}
+	if cfg.MaxBackoff > 0 {
+		b.maxBackoff = time.Duration(cfg.MaxBackoff) * time.Millisecond
+	}
+	if cfg.Threshold > 0 {
+		b.threshold = cfg.Threshold
+	}
+	if cfg.Cooldown > 0 {
+		b.cooldown = time.Duration(cfg.Cooldown) * time.Second
+	}
+	metrics.UpstreamCircuit.WithLabelValues(name).Set(float64(Closed))
+	return b
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Delay returns the jittered backoff before the retry, the first retry being 1.
+func (b *Breaker) Delay(retry int) time.Duration {
+	d := b.backoff
+	for i := 1; i < retry && d < b.maxBackoff; i++ {
+		d *= 2
+	}
+	d = min(d, b.maxBackoff)
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// State returns the state of the circuit.
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// Pause returns how long the callers wait before calling the upstream again after ErrUnavailable: the rest of the
+// cooldown while the circuit is open, or the initial backoff otherwise.
+func (b *Breaker) Pause() time.Duration {
+	if b == nil {
+		return DefaultBackoff
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == Open {
+		if rest := b.cooldown - b.now().Sub(b.openedAt); rest > 0 {
+			return rest
+		}
+	}
+	return b.backoff
+}
+
+// setState switches the circuit, which shall be called with the lock held.
+func (b *Breaker) setState(state State) {
+	if state == b.state {
+		return
+	}
+	switch state {
+	case Open:
+		log.Printf("Pause the calls to the %s for %v after %d failures: %v", b.name, b.cooldown, b.failures, b.lastErr)
+	case HalfOpen:
+		log.Printf("Probe the %s after the cooldown", b.name)
+	case Closed:
+		log.Printf("The %s is reachable again", b.name)
+	}
+	b.state = state
+	metrics.UpstreamCircuit.WithLabelValues(b.name).Set(float64(state))
+}
+
+// allow tells how many attempts the call is given, or refuses it while the circuit is open.
+func (b *Breaker) allow() (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == Open && b.now().Sub(b.openedAt) >= b.cooldown {
+		b.setState(HalfOpen)
+	}
+	switch {
+	case b.state == Open || (b.state == HalfOpen && b.probing):
+		metrics.UpstreamRefusals.WithLabelValues(b.name).Inc()
+		return 0, fmt.Errorf("%w: the circuit of the %s is open: %v", ErrUnavailable, b.name, b.lastErr)
+	case b.state == HalfOpen:
+		b.probing = true
+		return 1, nil
+	}
+	return b.attempts, nil
+}
+
+// record closes the circuit once the call succeeds, or counts the failure towards opening it.
+func (b *Breaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	if err == nil {
+		b.failures, b.lastErr = 0, nil
+		b.setState(Closed)
+		return
+	}
+	b.failures++
+	b.lastErr = err
+	if b.state == HalfOpen || b.failures >= b.threshold {
+		b.openedAt = b.now()
+		if b.state == Open {
+			return
+		}
+		b.setState(Open)
+	}
+}
+
+// Do calls the upstream, retrying the failed attempts after the backoff. Once every attempt fails, or the circuit
+// refuses the call, the returned error wraps ErrUnavailable along with the last error of the upstream. The retries
+// stop once the context is done.
+func (b *Breaker) Do(ctx context.Context, call func() error) error {
+	if b == nil {
+		return call()
+	}
+	attempts, err := b.allow()
+	if err != nil {
+		return err
+	}
+	for i := 0; i < attempts; i++ {
+		if i > 0 {
+			metrics.UpstreamRetries.WithLabelValues(b.name).Inc()
+			if serr := b.sleep(ctx, b.Delay(i)); serr != nil {
+				break
+			}
+		}
+		if err = call(); err == nil {
+			b.record(nil)
+			return nil
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	b.record(err)
+	return fmt.Errorf("%w: the %s failed: %w", ErrUnavailable, b.name, err)
+}
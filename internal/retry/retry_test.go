@@ -0,0 +1,98 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func newTestBreaker(cfg Config) (*Breaker, *time.Time, *[]time.Duration) {
+	now := time.Unix(1700000000, 0)
+	var slept []time.Duration
+	b := NewBreaker("test", cfg)
+	b.now = func() time.Time { return now }
+	b.sleep = func(_ context.Context, d time.Duration) error {
+		slept = append(slept, d)
+		return nil
+	}
+	return b, &now, &slept
+}
+
+func TestBreaker(t *testing.T) {
+	b, now, slept := newTestBreaker(Config{Attempts: 3, Backoff: 100, MaxBackoff: 150, Threshold: 2, Cooldown: 60})
+	down := errors.New("connection refused")
+
+	// A transient failure is retried after the jittered backoff.
+	calls := 0
+	err := b.Do(context.Background(), func() error {
+		calls++
+		if calls == 1 {
+			return down
+		}
+		return nil
+	})
+	if err != nil || calls != 2 || len(*slept) != 1 {
+		t.Fatalf("Expected the call to succeed by the retry, got %v after %d calls", err, calls)
+	}
+	if d := (*slept)[0]; d < 50*time.Millisecond || d > 100*time.Millisecond {
+		t.Fatalf("Unexpected backoff %v", d)
+	}
+
+	// The circuit opens after the consecutive failed calls, and refuses the calls until the cooldown ends.
+	calls = 0
+	for i := 0; i < 2; i++ {
+		if err := b.Do(context.Background(), func() error { calls++; return down }); !errors.Is(err, ErrUnavailable) || !errors.Is(err, down) {
+			t.Fatalf("Expected the upstream to be unavailable, got %v", err)
+		}
+	}
+	if calls != 6 || b.State() != Open {
+		t.Fatalf("Expected the circuit to open after 6 attempts, got %s after %d", b.State(), calls)
+	}
+	for _, d := range (*slept)[1:] {
+		if d > 150*time.Millisecond {
+			t.Fatalf("The backoff %v exceeds the max backoff", d)
+		}
+	}
+	if err := b.Do(context.Background(), func() error { calls++; return nil }); !errors.Is(err, ErrUnavailable) || calls != 6 {
+		t.Fatalf("Expected the open circuit to refuse the call, got %v", err)
+	}
+	if pause := b.Pause(); pause != time.Minute {
+		t.Fatalf("Expected to pause for the cooldown, got %v", pause)
+	}
+
+	// A failed probe after the cooldown opens the circuit again.
+	*now = now.Add(time.Minute)
+	if err := b.Do(context.Background(), func() error { calls++; return down }); !errors.Is(err, ErrUnavailable) || calls != 7 || b.State() != Open {
+		t.Fatalf("Expected a single failed probe to open the circuit, got %v after %d calls", err, calls)
+	}
+
+	// A succeeded probe closes it.
+	*now = now.Add(time.Minute)
+	if err := b.Do(context.Background(), func() error { calls++; return nil }); err != nil || b.State() != Closed {
+		t.Fatalf("Expected the probe to close the circuit, got %v in %s", err, b.State())
+	}
+	if pause := b.Pause(); pause != 100*time.Millisecond {
+		t.Fatalf("Expected to pause for the backoff, got %v", pause)
+	}
+}
+
+func TestBreakerCanceled(t *testing.T) {
+	b, _, _ := newTestBreaker(Config{Attempts: 5})
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	err := b.Do(ctx, func() error {
+		calls++
+		cancel()
+		return context.Canceled
+	})
+	if !errors.Is(err, context.Canceled) || calls != 1 {
+		t.Fatalf("Expected the retries to stop with the context, got %v after %d calls", err, calls)
+	}
+
+	var nilBreaker *Breaker
+	calls = 0
+	if err := nilBreaker.Do(context.Background(), func() error { calls++; return errors.New("down") }); err == nil || errors.Is(err, ErrUnavailable) || calls != 1 {
+		t.Fatalf("Expected the nil breaker to call once, got %v after %d calls", err, calls)
+	}
+}
@@ -0,0 +1,209 @@
+// Package tracing exports the spans of the indexing by OTLP, to see where the latency of a block goes when the
+// committee lags the chain. Until Init is called, the spans are dropped by the no-op tracer of OpenTelemetry.
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	instrumentation    = "github.com/RiemaLabs/modular-indexer-committee"
+	defaultServiceName = "modular-indexer-committee"
+	exportTimeout      = 10 * time.Second
+)
+
+type Config struct {
+	Enabled bool `json:"enabled"`
+	// The OTLP/HTTP endpoint of the collector, e.g. http://127.0.0.1:4318, to which the spans are posted at /v1/traces.
+	Endpoint string `json:"endpoint"`
+	// The service name of the spans, modular-indexer-committee by default.
+	ServiceName string `json:"serviceName"`
+	// The ratio of the traced blocks between 0 and 1, 0 traces every block.
+	SampleRatio float64 `json:"sampleRatio"`
+}
+
+var enabled atomic.Bool
+
+// Enabled tells whether the spans are exported, so that the stages are only timed if so.
+func Enabled() bool {
+	return enabled.Load()
+}
+
+// Init exports the spans to the collector of the config, and returns the shutdown flushing the pending spans.
+func Init(ctx context.Context, cfg Config, version string) (func(context.Context) error, error) {
+	if cfg.Endpoint == "" {
+		return nil, fmt.Errorf("the endpoint of the collector is missing")
+	}
+	exporter, err := otlptrace.New(ctx, &httpClient{
+		url:    strings.TrimSuffix(cfg.Endpoint, "/") + "/v1/traces",
+		client: &http.Client{Timeout: exportTimeout},
+	})
+	if err != nil {
+		return nil, err
+	}
+	serviceName := cfg.ServiceName
+	if serviceName == "" {
+		serviceName = defaultServiceName
+	}
+	sampler := sdktrace.AlwaysSample()
+	if cfg.SampleRatio > 0 && cfg.SampleRatio < 1 {
+		sampler = sdktrace.TraceIDRatioBased(cfg.SampleRatio)
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sampler)),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL,
+			semconv.ServiceNameKey.String(serviceName),
+			semconv.ServiceVersionKey.String(version),
+		)),
+	)
+	otel.SetTracerProvider(provider)
+	enabled.Store(true)
+	return provider.Shutdown, nil
+}
+
+// Start starts a span under the context.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentation).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End ends the span, marking it failed if err isn't nil.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Height is the attribute of the height of the traced block.
+func Height(height uint) attribute.KeyValue {
+	return attribute.Int64("block.height", int64(height))
+}
+
+type stage struct {
+	start, end time.Time
+	busy       time.Duration
+	calls      int
+}
+
+// Stages accumulates the time spent in the stages of a block which run many times, such as the protocol handlers
+// called once per transfer, so that each stage is traced by a single span rather than by a span per call.
+type Stages map[string]*stage
+
+// Observe records a call of the stage.
+func (s *Stages) Observe(name string, started time.Time, elapsed time.Duration) {
+	if *s == nil {
+		*s = make(Stages)
+	}
+	st, found := (*s)[name]
+	if !found {
+		st = &stage{start: started}
+		(*s)[name] = st
+	}
+	if started.Before(st.start) {
+		st.start = started
+	}
+	if end := started.Add(elapsed); end.After(st.end) {
+		st.end = end
+	}
+	st.busy += elapsed
+	st.calls++
+}
+
+// Merge adds the calls of the other stages, such as of the shards executed concurrently.
+func (s *Stages) Merge(other Stages) {
+	for name, st := range other {
+		if *s == nil {
+			*s = make(Stages)
+		}
+		merged, found := (*s)[name]
+		if !found {
+			copied := *st
+			(*s)[name] = &copied
+			continue
+		}
+		if st.start.Before(merged.start) {
+			merged.start = st.start
+		}
+		if st.end.After(merged.end) {
+			merged.end = st.end
+		}
+		merged.busy += st.busy
+		merged.calls += st.calls
+	}
+}
+
+// Emit traces each stage under the context by a span from its first call to the end of its last call,
+// along with the number of the calls and the time spent in them, which the span may well exceed.
+func (s Stages) Emit(ctx context.Context) {
+	for name, st := range s {
+		_, span := otel.Tracer(instrumentation).Start(ctx, name,
+			trace.WithTimestamp(st.start),
+			trace.WithAttributes(attribute.Int("calls", st.calls), attribute.Float64("busy_seconds", st.busy.Seconds())))
+		span.End(trace.WithTimestamp(st.end))
+	}
+}
+
+// httpClient exports the spans by OTLP/HTTP in the protobuf encoding. The export request is encoded here, since the
+// generated collector service of OTLP depends on the gRPC gateway, which is incompatible with the protobuf runtime.
+type httpClient struct {
+	url    string
+	client *http.Client
+}
+
+func (c *httpClient) Start(context.Context) error {
+	return nil
+}
+
+func (c *httpClient) Stop(context.Context) error {
+	c.client.CloseIdleConnections()
+	return nil
+}
+
+func (c *httpClient) UploadTraces(ctx context.Context, spans []*tracepb.ResourceSpans) error {
+	// The ExportTraceServiceRequest holds the resource spans as its repeated field 1.
+	var body []byte
+	for _, rs := range spans {
+		data, err := proto.Marshal(rs)
+		if err != nil {
+			return err
+		}
+		body = protowire.AppendTag(body, 1, protowire.BytesType)
+		body = protowire.AppendBytes(body, data)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("the collector replied %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package tracing
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestExport(t *testing.T) {
+	var mu sync.Mutex
+	names := make(map[string]int64)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" || r.Header.Get("Content-Type") != "application/x-protobuf" {
+			t.Errorf("Unexpected request %s %s", r.URL.Path, r.Header.Get("Content-Type"))
+		}
+		body, _ := io.ReadAll(r.Body)
+		for len(body) > 0 {
+			num, typ, n := protowire.ConsumeTag(body)
+			if num != 1 || typ != protowire.BytesType {
+				t.Errorf("Unexpected field %d of type %d", num, typ)
+				return
+			}
+			data, m := protowire.ConsumeBytes(body[n:])
+			body = body[n+m:]
+			var rs tracepb.ResourceSpans
+			if err := proto.Unmarshal(data, &rs); err != nil {
+				t.Error(err)
+				return
+			}
+			mu.Lock()
+			for _, ils := range rs.InstrumentationLibrarySpans {
+				for _, span := range ils.Spans {
+					names[span.Name] = int64(span.EndTimeUnixNano - span.StartTimeUnixNano)
+				}
+			}
+			mu.Unlock()
+		}
+	}))
+	defer srv.Close()
+
+	shutdown, err := Init(context.Background(), Config{Enabled: true, Endpoint: srv.URL}, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, span := Start(context.Background(), "block", Height(800001))
+	var stages Stages
+	started := time.Now()
+	stages.Observe("handler", started, time.Millisecond)
+	var shard Stages
+	shard.Observe("handler", started.Add(5*time.Millisecond), time.Millisecond)
+	stages.Merge(shard)
+	stages.Emit(ctx)
+	End(span, nil)
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if _, found := names["block"]; !found {
+		t.Fatalf("The block span isn't exported: %v", names)
+	}
+	// The stage spans from the first call to the end of the last call.
+	if d := names["handler"]; d != int64(6*time.Millisecond) {
+		t.Fatalf("Unexpected duration of the stage %d", d)
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-verkle"
+
+	"github.com/RiemaLabs/modular-indexer-committee/apis"
+	"github.com/RiemaLabs/modular-indexer-committee/ord"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/brc20"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/getter"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/protocol"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+func Test_KeyPreImages(t *testing.T) {
+	stateless.PreImagesPath = t.TempDir()
+	protocol.RecordPreImages(true)
+	defer func() {
+		protocol.RecordPreImages(false)
+		_ = stateless.ClosePreImages()
+		stateless.PreImagesPath = ""
+	}()
+	pkscript := "0014" + strings.Repeat("7e", 20)
+	transfer := strings.Repeat("7", 64) + "i2"
+	move := inscribe(transfer, pkscript, "", `{"p":"brc-20","op":"transfer","tick":"pimg","amt":"4"}`)
+	move.OldSatpoint = transfer + ":0:0"
+	move.NewPkscript, move.NewWallet = ord.Pkscript(successorPkscript), ord.Wallet(successorPkscript)
+	g := &blocksGetter{
+		blocks: map[uint][]getter.OrdTransfer{
+			800001: {
+				inscribe(strings.Repeat("7", 64)+"i0", pkscript, "", `{"p":"brc-20","op":"deploy","tick":"pimg","max":"100","lim":"10"}`),
+				inscribe(strings.Repeat("7", 64)+"i1", pkscript, "", `{"p":"brc-20","op":"mint","tick":"pimg","amt":"10"}`),
+				inscribe(transfer, pkscript, "", `{"p":"brc-20","op":"transfer","tick":"pimg","amt":"4"}`),
+			},
+			800002: {move},
+		},
+		hashes: make(map[uint]string),
+	}
+	header := stateless.LoadHeader(false, 800000)
+	queue, err := stateless.NewQueues(g, header, true, 800001)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Every key written is decoded by the preimage of its stem.
+	keys := 0
+	queue.Header.KV.Range(func(key [verkle.KeySize]byte, value [stateless.ValueSize]byte) bool {
+		keys++
+		decoded, err := stateless.DecodeKey(key[:])
+		if err != nil || decoded.PreImage == nil || decoded.PreImage.Domain != brc20.Name {
+			t.Fatalf("The key %x isn't decoded: %+v, %v", key, decoded, err)
+		}
+		return true
+	})
+	balance := brc20.GetTickPkscriptHash("pimg", ord.Pkscript(successorPkscript), brc20.OverallBalancePkscript)
+	r := apis.NewRouter(queue, "brc-20", false, false)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/state/preimages?key="+hex.EncodeToString(balance)+"&key="+strings.Repeat("00", 32), nil))
+	var resp apis.KeyPreImagesResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || w.Code != http.StatusOK || len(resp.Result) != 2 {
+		t.Fatalf("Unexpected response %d: %s", w.Code, w.Body.String())
+	}
+	decoded := resp.Result[0]
+	if decoded.PreImage == nil || decoded.PreImage.KeySpace != "GetTickPkscriptHash" || strings.Join(decoded.PreImage.Inputs, ",") != "pimg,"+successorPkscript ||
+		decoded.LocationID != brc20.OverallBalancePkscript || decoded.Location != "overallBalance" {
+		t.Fatalf("Unexpected decoded key %+v", decoded)
+	}
+	if resp.Result[1].PreImage != nil {
+		t.Fatalf("Expected the unknown key not to be decoded, got %+v", resp.Result[1])
+	}
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/state/preimages?key=00", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected the short key to be rejected, got %d", w.Code)
+	}
+
+	// The export holds a line of every stem.
+	output := filepath.Join(t.TempDir(), "preimages.jsonl")
+	if err := ExportPreImages(&PreImagesArguments{PreImagesPath: stateless.PreImagesPath, Output: output}); err != nil {
+		t.Fatal(err)
+	}
+	stems := make(map[[verkle.StemSize]byte]bool)
+	queue.Header.KV.Range(func(key [verkle.KeySize]byte, value [stateless.ValueSize]byte) bool {
+		stems[[verkle.StemSize]byte(key[:verkle.StemSize])] = true
+		return true
+	})
+	file, err := os.Open(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	lines := 0
+	for scanner := bufio.NewScanner(file); scanner.Scan(); lines++ {
+		var line struct {
+			Stem string `json:"stem"`
+			protocol.KeyPreImage
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil || line.Domain != brc20.Name || len(line.Stem) != 2*verkle.StemSize {
+			t.Fatalf("Unexpected line %s", scanner.Text())
+		}
+	}
+	if lines < len(stems) || keys == 0 {
+		t.Fatalf("Exported %d preimages of %d stems", lines, len(stems))
+	}
+
+	// The kept preimages migrate the state to another key scheme, and are kept by the stems of the scheme.
+	legacy := protocol.Keys
+	blake, _ := protocol.KeySchemeOf(protocol.KeySchemeBlake2b)
+	defer func() {
+		protocol.Keys = legacy
+	}()
+	if _, err := queue.Header.MigratePreImages(blake); err != nil {
+		t.Fatal(err)
+	}
+	protocol.Keys = blake
+	decoded, err = stateless.DecodeKey(brc20.GetTickPkscriptHash("pimg", ord.Pkscript(successorPkscript), brc20.OverallBalancePkscript))
+	if err != nil || decoded.PreImage == nil || decoded.Location != "overallBalance" {
+		t.Fatalf("Unexpected decoded key after the migration %+v, %v", decoded, err)
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/brc20"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/getter"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/protocol"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+func Test_KeyScheme(t *testing.T) {
+	pkscript := "0014" + strings.Repeat("5e", 20)
+	transfer := inscribe(strings.Repeat("5", 64)+"i2", pkscript, "", `{"p":"brc-20","op":"transfer","tick":"kysc","amt":"4"}`)
+	move := transfer
+	move.OldSatpoint = strings.Repeat("5", 64) + ":2:0"
+	move.NewPkscript, move.NewWallet = ord.Pkscript(successorPkscript), ord.Wallet(successorPkscript)
+	blocks := [][]getter.OrdTransfer{
+		{
+			inscribe(strings.Repeat("5", 64)+"i0", pkscript, "", `{"p":"brc-20","op":"deploy","tick":"kysc","max":"100","lim":"10"}`),
+			inscribe(strings.Repeat("5", 64)+"i1", pkscript, "", `{"p":"brc-20","op":"mint","tick":"kysc","amt":"10"}`),
+			transfer,
+		},
+		{move},
+	}
+	execute := func() *stateless.Header {
+		header := stateless.LoadHeader(false, 800000)
+		for i, ots := range blocks {
+			stateless.Exec(header, ots, 800001+uint(i))
+			if err := header.Paging(nil, false, stateless.NodeResolveFn); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return header
+	}
+	legacy := protocol.Keys
+	blake, _ := protocol.KeySchemeOf(protocol.KeySchemeBlake2b)
+	defer func() {
+		protocol.Keys = legacy
+	}()
+
+	protocol.Keys = blake
+	expected := execute()
+	if stateless.CurrentLayout().KeyScheme != protocol.KeySchemeBlake2b || brc20.StateSchema().KeyScheme != protocol.KeySchemeBlake2b {
+		t.Fatalf("Expected the fresh state of the key scheme, got %+v", stateless.CurrentLayout())
+	}
+
+	protocol.Keys = legacy
+	header := execute()
+	if header.Root.Commit().Bytes() == expected.Root.Commit().Bytes() {
+		t.Fatal("Expected the key schemes to commit to different roots")
+	}
+	// The keys of the wallets and the transfers aren't known without their preimages.
+	decoder := brc20.NewKeyDecoder()
+	decoder.AddTick("kysc")
+	decoder.AddBalance("kysc", ord.Pkscript(pkscript))
+	if _, err := header.MigrateKeyScheme(blake, decoder.PreImages()); err == nil || !strings.Contains(err.Error(), "unknown") {
+		t.Fatalf("Expected the migration to fail on the unknown keys, got %v", err)
+	}
+	for _, ots := range blocks {
+		decoder.AddTransfers(ots, header)
+	}
+	record, err := header.MigrateKeyScheme(blake, decoder.PreImages())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.Root.Commit().Bytes() != expected.Root.Commit().Bytes() {
+		t.Fatal("Expected the migrated state to be the state executed by the key scheme")
+	}
+
+	// The checkpoints after the migration publish the key scheme and the migration.
+	protocol.Keys = blake
+	c := newCheckpoint(NewRuntimeArguments(), &stateless.DiffState{Height: 800002, VerkleCommit: header.Root.Commit().Bytes()})
+	if c.KeyScheme != protocol.KeySchemeBlake2b || len(c.Migrations) != 1 || c.Migrations[0].KeyScheme != protocol.KeySchemeBlake2b ||
+		c.Migrations[0].Commitment != c.Commitment || record.Height != 800002 {
+		t.Fatalf("Unexpected checkpoint %+v", c)
+	}
+	if _, err := header.MigrateKeyScheme(blake, nil); err == nil {
+		t.Fatal("Expected the state of the key scheme not to be migrated again")
+	}
+}
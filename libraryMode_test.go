@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/RiemaLabs/modular-indexer-committee/checkpoint"
+	"github.com/RiemaLabs/modular-indexer-committee/committee"
+	"github.com/RiemaLabs/modular-indexer-committee/ord"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/getter"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+// tipGetter serves the blocks up to a tip moved by the test.
+type tipGetter struct {
+	blocksGetter
+	mu  sync.Mutex
+	tip uint
+}
+
+func (g *tipGetter) GetLatestBlockHeight() (uint, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.tip, nil
+}
+
+func (g *tipGetter) GetOrdTransfers(blockHeight uint) ([]getter.OrdTransfer, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.blocks[blockHeight], nil
+}
+
+// recordingPublisher keeps the published checkpoints, failing the first publication.
+type recordingPublisher struct {
+	mu        sync.Mutex
+	failed    atomic.Bool
+	published []checkpoint.Checkpoint
+}
+
+func (p *recordingPublisher) Method() string { return "Test" }
+
+func (p *recordingPublisher) Publish(_ context.Context, c *checkpoint.Checkpoint) error {
+	if !p.failed.Swap(true) {
+		return errors.New("unavailable")
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.published = append(p.published, *c)
+	return nil
+}
+
+func Test_LibraryMode(t *testing.T) {
+	pkscript := "0014" + strings.Repeat("ab", 20)
+	tip := 800001 + stateless.ReorgDepth + 2
+	g := &tipGetter{
+		blocksGetter: blocksGetter{
+			blocks: map[uint][]getter.OrdTransfer{
+				800002:  {inscribe(strings.Repeat("c", 64)+"i0", pkscript, "", `{"p":"brc-20","op":"deploy","tick":"libm","max":"100"}`)},
+				800003:  {inscribe(strings.Repeat("c", 64)+"i1", pkscript, "", `{"p":"brc-20","op":"mint","tick":"libm","amt":"5"}`)},
+				tip + 1: {inscribe(strings.Repeat("c", 64)+"i2", pkscript, "", `{"p":"brc-20","op":"mint","tick":"libm","amt":"7"}`)},
+			},
+			hashes: make(map[uint]string),
+		},
+		tip: tip,
+	}
+	publisher := &recordingPublisher{}
+	cfg := committee.Config{
+		GenesisHeight: 800001,
+		Interval:      10 * time.Millisecond,
+		Indexer:       checkpoint.IndexerIdentification{Name: "library", MetaProtocol: "brc-20"},
+		Queue:         checkpoint.QueueConfig{Backoff: 1},
+	}
+	c, err := committee.New(cfg, g, publisher)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Stop()
+	if _, err := committee.New(cfg, g, publisher); !errors.Is(err, committee.ErrRunning) {
+		t.Fatalf("Expected a single committee to run, got %v", err)
+	}
+	if _, _, _, err := c.Balance("libm", ord.Pkscript(pkscript)); !errors.Is(err, committee.ErrNotStarted) {
+		t.Fatalf("Expected the queries to wait for the catch-up, got %v", err)
+	}
+	if err := c.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	_, overall, height, err := c.Balance("LIBM", ord.Pkscript(pkscript))
+	if err != nil || height != tip || overall.Uint64() != 5000000000000000000 {
+		t.Fatalf("Unexpected balance %v at %d: %v", overall, height, err)
+	}
+	latest, err := c.Latest()
+	if err != nil || latest.Height != strconv.FormatUint(uint64(tip), 10) || latest.Name != "library" {
+		t.Fatalf("Unexpected latest checkpoint %+v: %v", latest, err)
+	}
+
+	g.mu.Lock()
+	g.tip++
+	g.mu.Unlock()
+	deadline := time.Now().Add(20 * time.Second)
+	for {
+		_, overall, height, err = c.Balance("libm", ord.Pkscript(pkscript))
+		published := c.Published()
+		if err == nil && height == tip+1 && published != nil && published.Height == strconv.FormatUint(uint64(tip+1), 10) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("The committee didn't follow the new block: %d, %v", height, published)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if overall.Uint64() != 12000000000000000000 {
+		t.Fatalf("Unexpected balance %v", overall)
+	}
+	publisher.mu.Lock()
+	// The failed publication is retried, and every state kept since the catch-up is published once in order.
+	n := len(publisher.published)
+	ordered := n == int(stateless.ReorgDepth)+2
+	for i := 1; i < n && ordered; i++ {
+		// The heights have as many digits.
+		ordered = publisher.published[i-1].Height < publisher.published[i].Height
+	}
+	publisher.mu.Unlock()
+	if !ordered {
+		t.Fatalf("Unexpected publications %d", n)
+	}
+
+	if err := c.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if c2, err := committee.New(cfg, g, nil); err != nil {
+		t.Fatalf("Expected a committee after the stop: %v", err)
+	} else {
+		_ = c2.Stop()
+	}
+}
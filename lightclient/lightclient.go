@@ -0,0 +1,235 @@
+// Package lightclient verifies the BRC-20 balances served by the committee indexers end-to-end, from a signed
+// checkpoint down to the claimed values, without any state. It's meant to be imported by wallets and marketplaces,
+// so it depends on neither the APIs nor the state of the committee indexer.
+package lightclient
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"unsafe"
+
+	"github.com/ethereum/go-verkle"
+	"github.com/holiman/uint256"
+
+	"github.com/RiemaLabs/modular-indexer-committee/checkpoint"
+	"github.com/RiemaLabs/modular-indexer-committee/ord"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/brc20"
+)
+
+// ParseBalance encodes the decimal balance as the value stored in the state.
+func ParseBalance(balance string) ([]byte, error) {
+	value, err := uint256.FromDecimal(balance)
+	if err != nil {
+		return []byte{}, err
+	}
+	var dest [verkle.LeafValueSize]byte
+	value.WriteToArray32(&dest)
+	return dest[:], err
+}
+
+// ParseProof decodes the proof served by the committee indexers, the base64 of the JSON of the verkle proof.
+func ParseProof(proof string) (*verkle.VerkleProof, error) {
+	vProofBytes, err := base64.StdEncoding.DecodeString(proof)
+	if err != nil {
+		return nil, err
+	}
+	var vProof verkle.VerkleProof
+	err = vProof.UnmarshalJSON(vProofBytes)
+	if err != nil {
+		return nil, err
+	}
+	return &vProof, nil
+}
+
+// ParseCommitment decodes the base64 state root, e.g. the commitment of a checkpoint.
+func ParseCommitment(commitment string) (*verkle.Point, error) {
+	bytes, err := base64.StdEncoding.DecodeString(commitment)
+	if err != nil {
+		return nil, err
+	}
+	var p verkle.Point
+	err = p.SetBytes(bytes)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// ParseStateDiff builds the state diff of the sorted keys from their values before and after, empty if absent.
+func ParseStateDiff(Keys, PreValues, PostValues [][]byte) *verkle.StateDiff {
+	var stemdiff *verkle.StemStateDiff
+	var statediff verkle.StateDiff
+	for i, key := range Keys {
+		stem := verkle.KeyToStem(key)
+		if stemdiff == nil || !bytes.Equal(stemdiff.Stem[:], stem) {
+			statediff = append(statediff, verkle.StemStateDiff{})
+			stemdiff = &statediff[len(statediff)-1]
+			copy(stemdiff.Stem[:], stem)
+		}
+		stemdiff.SuffixDiffs = append(stemdiff.SuffixDiffs, verkle.SuffixStateDiff{Suffix: key[verkle.StemSize]})
+		newsd := &stemdiff.SuffixDiffs[len(stemdiff.SuffixDiffs)-1]
+
+		var valueLen = len(PreValues[i])
+		switch valueLen {
+		case 0:
+			// null value
+		case 32:
+			newsd.CurrentValue = (*[32]byte)(PreValues[i])
+		default:
+			var aligned [32]byte
+			copy(aligned[:valueLen], PreValues[i])
+			newsd.CurrentValue = (*[32]byte)(unsafe.Pointer(&aligned[0]))
+		}
+
+		valueLen = len(PostValues[i])
+		switch valueLen {
+		case 0:
+			// null value
+		case 32:
+			newsd.NewValue = (*[32]byte)(PostValues[i])
+		default:
+			// TODO remove usage of unsafe
+			var aligned [32]byte
+			copy(aligned[:valueLen], PostValues[i])
+			newsd.NewValue = (*[32]byte)(unsafe.Pointer(&aligned[0]))
+		}
+	}
+	return &statediff
+}
+
+// verifyStateDiff verifies the proof of the state diff against the state root.
+func verifyStateDiff(rootC *verkle.Point, proof string, stateDiff verkle.StateDiff) error {
+	vProof, err := ParseProof(proof)
+	if err != nil {
+		return err
+	}
+	preProof, err := verkle.DeserializeProof(vProof, stateDiff)
+	if err != nil {
+		return err
+	}
+	preRoot, err := verkle.PreStateTreeFromProof(preProof, rootC)
+	if err != nil {
+		return err
+	}
+	return verkle.VerifyVerkleProofWithPreState(preProof, preRoot)
+}
+
+// VerifyValues verifies that the keys hold the values in the state of the root, where an empty value is of an
+// absent key. The keys are in the order of the proof, i.e. sorted.
+func VerifyValues(rootC *verkle.Point, proof string, keys, values [][]byte) error {
+	if len(keys) != len(values) {
+		return errors.New("the number of the keys mismatches the number of the values")
+	}
+	return verifyStateDiff(rootC, proof, *ParseStateDiff(keys, values, make([][]byte, len(keys))))
+}
+
+// ProvenValues verifies the proof of the state diff, each of which is the base64 of the JSON of a verkle.StemStateDiff,
+// against the root, and returns the proven value of every key, nil if the key is absent.
+func ProvenValues(rootC *verkle.Point, proof string, stateDiff []string) (map[[verkle.KeySize]byte]*[32]byte, error) {
+	diff := make(verkle.StateDiff, 0, len(stateDiff))
+	for _, s := range stateDiff {
+		sdBytes, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return nil, err
+		}
+		var sd verkle.StemStateDiff
+		if err := sd.UnmarshalJSON(sdBytes); err != nil {
+			return nil, err
+		}
+		diff = append(diff, sd)
+	}
+	if err := verifyStateDiff(rootC, proof, diff); err != nil {
+		return nil, err
+	}
+	proven := make(map[[verkle.KeySize]byte]*[32]byte)
+	for _, sd := range diff {
+		for _, suffixDiff := range sd.SuffixDiffs {
+			var key [verkle.KeySize]byte
+			copy(key[:], sd.Stem[:])
+			key[verkle.StemSize] = suffixDiff.Suffix
+			proven[key] = suffixDiff.CurrentValue
+		}
+	}
+	return proven, nil
+}
+
+// Balance is the balance of a pkscript of a tick claimed by a committee indexer, with the decimal amounts.
+type Balance struct {
+	Tick             string
+	Pkscript         string
+	AvailableBalance string
+	OverallBalance   string
+}
+
+// VerifyBalance verifies the proof of the balance, as served by the committee indexers, against the state root.
+func VerifyBalance(rootC *verkle.Point, b Balance, proof string) error {
+	availKey := brc20.GetTickPkscriptHash(b.Tick, ord.Pkscript(b.Pkscript), brc20.AvailableBalancePkscript)
+	overallKey := brc20.GetTickPkscriptHash(b.Tick, ord.Pkscript(b.Pkscript), brc20.OverallBalancePkscript)
+	availValue, err := ParseBalance(b.AvailableBalance)
+	if err != nil {
+		return err
+	}
+	overallValue, err := ParseBalance(b.OverallBalance)
+	if err != nil {
+		return err
+	}
+	return VerifyValues(rootC, proof, [][]byte{availKey, overallKey}, [][]byte{availValue, overallValue})
+}
+
+// TickCounters is the counters of a tick claimed by a committee indexer, with the decimal values.
+type TickCounters struct {
+	Tick         string
+	HolderCount  string
+	MintCount    string
+	MintedAmount string
+}
+
+// VerifyTickCounters verifies the proof of the counters of the tick, as served by the committee indexers, against
+// the state root.
+func VerifyTickCounters(rootC *verkle.Point, c TickCounters, proof string) error {
+	keys := [][]byte{
+		brc20.GetTickHash(c.Tick, brc20.HolderCount),
+		brc20.GetTickHash(c.Tick, brc20.MintCount),
+		brc20.GetTickHash(c.Tick, brc20.MintedAmount),
+	}
+	values := make([][]byte, len(keys))
+	for i, counter := range []string{c.HolderCount, c.MintCount, c.MintedAmount} {
+		value, err := ParseBalance(counter)
+		if err != nil {
+			return err
+		}
+		values[i] = value
+	}
+	return VerifyValues(rootC, proof, keys, values)
+}
+
+// VerifyCheckpoint checks that the checkpoint is signed by the trusted public key, and returns its state root.
+func VerifyCheckpoint(c *checkpoint.Checkpoint, publicKey string) (*verkle.Point, error) {
+	if !strings.EqualFold(c.PublicKey, publicKey) {
+		return nil, fmt.Errorf("the checkpoint is signed by %s rather than the trusted key", c.PublicKey)
+	}
+	if err := c.VerifySignature(); err != nil {
+		return nil, err
+	}
+	rootC, err := ParseCommitment(c.Commitment)
+	if err != nil {
+		return nil, fmt.Errorf("invalid commitment %s: %v", c.Commitment, err)
+	}
+	return rootC, nil
+}
+
+// Verify verifies the balance end-to-end: the checkpoint is signed by the trusted public key, and the balance is
+// proven against the commitment of the checkpoint.
+func Verify(c *checkpoint.Checkpoint, publicKey string, b Balance, proof string) error {
+	rootC, err := VerifyCheckpoint(c, publicKey)
+	if err != nil {
+		return err
+	}
+	if err := VerifyBalance(rootC, b, proof); err != nil {
+		return fmt.Errorf("invalid proof of the balance of the pkscript %s of the tick %s at height %s: %v", b.Pkscript, b.Tick, c.Height, err)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package lightclient
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-verkle"
+
+	"github.com/RiemaLabs/modular-indexer-committee/checkpoint"
+	"github.com/RiemaLabs/modular-indexer-committee/ord"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/brc20"
+)
+
+func TestVerify(t *testing.T) {
+	claimed := Balance{
+		Tick:             "ordi",
+		Pkscript:         "5120409943cab2dee3c71940969a612c6ee65c57cad1f064ca8db4508dab49260ca3",
+		AvailableBalance: "1000",
+		OverallBalance:   "1500",
+	}
+	availKey := brc20.GetTickPkscriptHash(claimed.Tick, ord.Pkscript(claimed.Pkscript), brc20.AvailableBalancePkscript)
+	overallKey := brc20.GetTickPkscriptHash(claimed.Tick, ord.Pkscript(claimed.Pkscript), brc20.OverallBalancePkscript)
+
+	// The state of the committee indexer holding the balance among others.
+	root := verkle.New()
+	for key, amount := range map[string]string{string(availKey): "1000", string(overallKey): "1500", strings.Repeat("\x07", 32): "42"} {
+		value, err := ParseBalance(amount)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := root.Insert([]byte(key), value, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	commitment := root.Commit().Bytes()
+	proof, _, _, _, err := verkle.MakeVerkleMultiProof(root, nil, [][]byte{availKey, overallKey}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vProof, _, err := verkle.SerializeProof(proof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vProofBytes, err := vProof.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	served := base64.StdEncoding.EncodeToString(vProofBytes)
+
+	signer, err := checkpoint.NewSigner(checkpoint.SchemeSchnorr, strings.Repeat("2a", 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	indexerID := checkpoint.IndexerIdentification{URL: "https://committee.example", Name: "committee", Version: "v1", MetaProtocol: "brc-20"}
+	c := checkpoint.NewCheckpoint(&indexerID, 780000, "00000000000000000002", base64.StdEncoding.EncodeToString(commitment[:]))
+	if err := c.Sign(signer); err != nil {
+		t.Fatal(err)
+	}
+	if err := Verify(&c, c.PublicKey, claimed, served); err != nil {
+		t.Fatal(err)
+	}
+
+	inflated := claimed
+	inflated.AvailableBalance = "1001"
+	if Verify(&c, c.PublicKey, inflated, served) == nil {
+		t.Fatal("Expected the inflated balance to fail the verification")
+	}
+	if Verify(&c, strings.ToUpper(c.PublicKey), claimed, served) != nil {
+		t.Fatal("Expected the public key to be case-insensitive")
+	}
+	other, err := checkpoint.NewSigner(checkpoint.SchemeSchnorr, strings.Repeat("2b", 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged := c
+	if err := forged.Sign(other); err != nil {
+		t.Fatal(err)
+	}
+	if Verify(&forged, c.PublicKey, claimed, served) == nil {
+		t.Fatal("Expected the checkpoint signed by another key to fail the verification")
+	}
+	tampered := c
+	tampered.Height = "780001"
+	if Verify(&tampered, c.PublicKey, claimed, served) == nil {
+		t.Fatal("Expected the tampered checkpoint to fail the verification")
+	}
+}
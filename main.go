@@ -2,6 +2,7 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
@@ -10,16 +11,37 @@ import (
 	_ "net/http/pprof"
 	"os"
 	"os/signal"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"syscall"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+
 	"github.com/RiemaLabs/modular-indexer-committee/apis"
+	"github.com/RiemaLabs/modular-indexer-committee/archive"
 	"github.com/RiemaLabs/modular-indexer-committee/checkpoint"
+	"github.com/RiemaLabs/modular-indexer-committee/committee"
+	"github.com/RiemaLabs/modular-indexer-committee/crosscheck"
 	"github.com/RiemaLabs/modular-indexer-committee/internal/metrics"
+	"github.com/RiemaLabs/modular-indexer-committee/internal/retry"
+	"github.com/RiemaLabs/modular-indexer-committee/internal/tracing"
 	"github.com/RiemaLabs/modular-indexer-committee/ord"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/bitmap"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/brc20"
 	"github.com/RiemaLabs/modular-indexer-committee/ord/getter"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/protocol"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/reexec"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/sanity"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/satpoint"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/sns"
 	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/subscription"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/watchlist"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/webhook"
+	"github.com/RiemaLabs/modular-indexer-committee/peer"
+	"github.com/RiemaLabs/modular-indexer-committee/selfaudit"
 )
 
 var (
@@ -27,227 +49,650 @@ var (
 	gitHash = "unknown"
 )
 
+// The number of the witnesses of the latest blocks kept for the fraud proofs of the cross-check.
+const fraudProofWitnesses = 6
+
 func CatchupStage(ordGetter getter.OrdGetter, arguments *RuntimeArguments, initHeight uint, latestHeight uint) (*stateless.Queue, error) {
 	metrics.Stage.Set(metrics.StageCatchup)
 
 	// Fetch the latest block height.
 	header := stateless.LoadHeader(arguments.EnableStateRootCache, initHeight)
-	curHeight := header.Height
-
-	log.Printf("Fast catchup to the lateset block height! From %d to %d \n", curHeight, latestHeight)
-
-	catchupHeight := latestHeight - ord.BitcoinConfirmations
-
-	// Create a channel to listen for SIGINT (Ctrl+C) signal
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT)
-
-	// Start to catch-up
-	// TODO: Medium. Refine the catchup performance by batching query.
-	if catchupHeight > curHeight {
-		for i := curHeight + 1; i <= catchupHeight; i++ {
-			select {
-			case <-sigChan:
-				// SIGINT received, stop the catch-up process
-				log.Printf("Saving cache file. Please don't force exit.")
-				_ = stateless.StoreHeader(header, header.Height-2000)
-				os.Exit(0)
-			default:
-				ordTransfer, err := ordGetter.GetOrdTransfers(i)
-				if err != nil {
-					return nil, err
-				}
-				header.Lock()
-				stateless.Exec(header, ordTransfer, i)
-				_ = header.Paging(ordGetter, false, stateless.NodeResolveFn)
-				header.Unlock()
-				if i%1000 == 0 {
-					log.Printf("Blocks: %d / %d \n", i, catchupHeight)
-					if arguments.EnableStateRootCache {
-						err := stateless.StoreHeader(header, header.Height-2000)
-						if err != nil {
-							log.Printf("Failed to store the cache at height: %d", i)
-						}
-					}
-				}
-			}
-		}
-	} else if catchupHeight == curHeight {
-		// stateRoot is located at catchupHeight.
-	} else if catchupHeight < curHeight {
-		return nil, errors.New("the stored stateRoot is too advanced to handle reorg situations")
-	}
-
-	// Currently, header.Height equals to catchupHeight.
-
-	ots, err := ordGetter.GetOrdTransfers(catchupHeight)
-	if err != nil {
+	if err := committee.MigrateHeader(header, arguments.EnableStateRootCache); err != nil {
 		return nil, err
 	}
-	header.OrdTrans = ots
-
-	if arguments.EnableStateRootCache {
-		err := stateless.StoreHeader(header, header.Height-2000)
-		if err != nil {
-			log.Printf("Failed to store the cache at height: %d", header.Height)
-		}
-	}
 
-	queue, err := stateless.NewQueues(ordGetter, header, true, catchupHeight+1)
-	if err != nil {
-		return nil, err
+	// SIGINT (Ctrl+C) and SIGTERM stop the catch-up after the block in progress.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	queue, err := committee.Catchup(ctx, ordGetter, header, latestHeight, committee.CatchupOptions{
+		StoreCache:      arguments.EnableStateRootCache,
+		Prefetch:        arguments.Prefetch,
+		PrefetchWorkers: arguments.PrefetchWorkers,
+		Pause:           GetterBreaker.Pause,
+	})
+	if ctx.Err() != nil && errors.Is(err, ctx.Err()) {
+		flushTraces()
+		os.Exit(0)
 	}
-	if queue.LatestHeight() != latestHeight {
-		return nil, fmt.Errorf("mismatched state height: %d and catchup height: %d", queue.LatestHeight(), latestHeight)
-	}
-	return queue, nil
+	return queue, err
 }
 
 func ServiceStage(ordGetter getter.OrdGetter, arguments *RuntimeArguments, queue *stateless.Queue, interval time.Duration) {
 	metrics.Stage.Set(metrics.StageServing)
 
-	// Create a channel to listen for SIGINT (Ctrl+C) signal
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT)
+	// SIGINT (Ctrl+C) and SIGTERM shut down after the block in progress.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 
 	var history = make(map[string]checkpoint.UploadRecord)
 
+	// SIGUSR1 requests the publication of the latest checkpoint.
+	var demanded atomic.Bool
+	demandChan := make(chan os.Signal, 1)
+	signal.Notify(demandChan, syscall.SIGUSR1)
+	go func() {
+		for range demandChan {
+			log.Printf("The publication of the latest checkpoint is requested")
+			demanded.Store(true)
+		}
+	}()
+
 	if arguments.EnableService {
 		if arguments.CommitteeIndexerURL != "" {
 			log.Printf("Providing API service at: %s", arguments.CommitteeIndexerURL)
 		} else {
 			log.Printf("Providing API service at: %s", GlobalConfig.Service.URL)
 		}
-		go apis.StartService(queue, arguments.EnableCommittee, arguments.EnableTest, arguments.EnablePprof)
+		metaProtocol := GlobalConfig.Service.MetaProtocol
+		if arguments.ProtocolName != "" {
+			metaProtocol = arguments.ProtocolName
+		}
+		if arguments.ProofCacheSize > 0 {
+			apis.Proofs = apis.NewProofCache(arguments.ProofCacheSize)
+		}
+		if arguments.WSMaxClients > 0 {
+			stateless.Subscriptions = subscription.NewHub(arguments.WSMaxClients)
+		}
+		go apis.StartService(queue, metaProtocol, arguments.EnableCommittee, arguments.EnableTest, arguments.EnablePprof)
+		if arguments.GRPCAddr != "" {
+			go apis.StartGRPCService(queue, metaProtocol, arguments.GRPCAddr)
+		}
 	}
 
 	for {
 		select {
-		case <-sigChan:
-			// TODO: High. Save the latest state is unsound if reorg happened.
-			// log.Printf("Saving cache file. Please don't force exit.")
-			// stateless.StoreHeader(queue.Header, queue.Header.Height-2000)
-			os.Exit(0)
+		case <-ctx.Done():
+			shutdown(arguments, queue)
 		default:
-			curHeight := queue.LatestHeight()
-			latestHeight, err := ordGetter.GetLatestBlockHeight()
-			if err != nil {
-				log.Fatalf("Failed to get the latest block height: %v", err)
-			}
-
-			if curHeight < latestHeight {
-				metrics.Stage.Set(metrics.StageUpdating)
-				err := queue.Update(ordGetter, latestHeight)
-				if err != nil {
-					log.Fatalf("Failed to update the queue: %v", err)
+			if apis.Admin != nil {
+				resync, republish := apis.Admin.TakeRequests()
+				if resync != 0 {
+					metrics.Stage.Set(metrics.StageReorg)
+					log.Printf("Resync the blocks from %d as requested by the operator", resync)
+					err := queue.Recovery(ordGetter, resync)
+					apis.Admin.Finish(apis.OperationResync, err)
+					if errors.Is(err, stateless.ErrReorgTooDeep) {
+						// The block is no longer kept since the request.
+						log.Printf("Unable to resync the blocks: %v", err)
+					} else if err != nil {
+						log.Fatalf("Failed to resync the queue: %v", err)
+					}
+					metrics.Stage.Set(metrics.StageServing)
+				}
+				if len(republish) != 0 {
+					apis.Admin.Finish(apis.OperationRepublish, republishCheckpoints(arguments, queue, republish))
+				}
+				if apis.Admin.Paused() {
+					metrics.Stage.Set(metrics.StagePaused)
+					waitRound(ctx, interval)
+					continue
 				}
 				metrics.Stage.Set(metrics.StageServing)
 			}
 
-			reorgHeight, err := queue.CheckForReorg(ordGetter)
-
+			round, err := committee.Follow(ctx, queue, ordGetter)
+			if round.Tip != 0 {
+				apis.RecordTip(round.Tip)
+			}
+			if err == nil || errors.Is(err, retry.ErrUnavailable) {
+				apis.RecordUpstream(apis.UpstreamGetter, err)
+			}
+			if ctx.Err() != nil {
+				// The block in progress has been finished, shut down right away.
+				continue
+			}
+			if errors.Is(err, sanity.ErrQuarantined) || errors.Is(err, stateless.ErrDeadlineExceeded) {
+				// Keep serving the last executed state until the operator reviews the block,
+				// or until the block is retried after the rollback.
+				log.Printf("Stop updating the queue: %v", err)
+				waitRound(ctx, interval)
+				continue
+			}
+			if errors.Is(err, retry.ErrUnavailable) {
+				// The block failing to be fetched is left unexecuted, and a reorg failing to be fetched is recovered
+				// at the next round, since the blocks are fetched before the rollback.
+				pauseIndexing(ctx, interval, err)
+				continue
+			}
+			if errors.Is(err, stateless.ErrReorgTooDeep) {
+				log.Fatalf("Failed to recover the reorganization, increase --reorg-depth and resync from a snapshot: %v", err)
+			}
 			if err != nil {
-				log.Fatalf("Failed to check the reorganization: %v", err)
+				log.Fatalf("Failed to follow the blocks: %v", err)
 			}
+			curHeight, latestHeight, catchingUp := round.Previous, round.Tip, round.CatchingUp()
 
-			if reorgHeight != 0 {
-				metrics.Stage.Set(metrics.StageReorg)
-				err := queue.Recovery(ordGetter, reorgHeight)
-				if err != nil {
-					log.Fatalf("Failed to update the queue: %v", err)
-				}
-				metrics.Stage.Set(metrics.StageServing)
+			if arguments.EnableService && apis.Proofs != nil {
+				apis.Proofs.Precompute(queue.Header, apis.PrecomputedProofs)
 			}
 
-			if arguments.EnableCommittee {
-				latestHistory := stateless.DiffState{
-					Height:       queue.Header.Height,
-					Hash:         queue.Header.Hash,
-					VerkleCommit: queue.Header.Root.Commit().Bytes(),
-					Access:       stateless.AccessList{},
+			// Audit the new block with the other members, unless it's one of many blocks to catch up.
+			if queue.LatestHeight() != curHeight && !catchingUp {
+				apis.AuditMembers(context.Background(), queue, queue.LatestHeight())
+				if apis.CrossCheck != nil {
+					latest := committee.LatestState(queue)
+					go apis.CrossCheck.Check(context.Background(), newCheckpoint(arguments, &latest))
 				}
+			}
+			apis.CheckRules(context.Background())
+
+			if arguments.EnableCommittee && apis.SafeMode() {
+				log.Printf("Withhold the checkpoints at height %d, since the rules disagree with the majority of the members", queue.LatestHeight())
+			} else if arguments.EnableCommittee {
+				latestHistory := committee.LatestState(queue)
 				hs := make([]*stateless.DiffState, 0)
 				for _, i := range queue.History {
 					hs = append(hs, &i)
 				}
 				hs = append(hs, &latestHistory)
-				for _, i := range hs {
-					key := fmt.Sprintf("%d", i.Height) + i.Hash
-					if curRecord, found := history[key]; !(found && curRecord.Success) {
-						committeeIndexerName := GlobalConfig.Service.Name
-						if arguments.CommitteeIndexerName != "" {
-							committeeIndexerName = arguments.CommitteeIndexerName
-						}
-						serviceURL := GlobalConfig.Service.URL
-						if arguments.CommitteeIndexerURL != "" {
-							serviceURL = arguments.CommitteeIndexerURL
-						}
-						metaProtocol := GlobalConfig.Service.MetaProtocol
-						if arguments.ProtocolName != "" {
-							metaProtocol = arguments.ProtocolName
-						}
-						indexerID := checkpoint.IndexerIdentification{
-							URL:          serviceURL,
-							Name:         committeeIndexerName,
-							Version:      version,
-							MetaProtocol: metaProtocol,
-						}
-						commitment := base64.StdEncoding.EncodeToString(i.VerkleCommit[:])
-						c := checkpoint.NewCheckpoint(&indexerID, i.Height, i.Hash, commitment)
-						timeout := time.Duration(GlobalConfig.Report.Timeout) * time.Millisecond
-						if GlobalConfig.Report.Method == "S3" {
-							log.Printf("Uploading the checkpoint by S3 at height: %s\n", c.Height)
-							s3cfg := GlobalConfig.Report.S3
-							err = checkpoint.UploadCheckpointByS3(&c,
-								s3cfg.AccessKey, s3cfg.SecretKey, s3cfg.Region, s3cfg.Bucket, timeout)
-							if err != nil {
-								log.Printf("Unable to upload the checkpoint by S3 due to: %v", err)
-							} else {
-								log.Printf("Succeed to upload the checkpoint by S3 at height: %s\n", c.Height)
-							}
-						} else if GlobalConfig.Report.Method == "DA" {
-							log.Printf("Uploading the checkpoint by DA at height: %s\n", c.Height)
-							dacfg := GlobalConfig.Report.Da
-							err = checkpoint.UploadCheckpointByDA(&c,
-								dacfg.PrivateKey, dacfg.GasCoupon, dacfg.NamespaceID, dacfg.Network, timeout)
-							if err != nil {
-								log.Printf("Unable to upload the checkpoint by DA due to: %v", err)
-							} else {
-								log.Printf("Succeed to upload the checkpoint by DA at height: %s\n", c.Height)
-							}
-						}
-						history[key] = checkpoint.UploadRecord{
-							Success: true,
-						}
-					}
-				}
+				publishCheckpoints(arguments, hs, history, latestHistory.Height, demanded.Swap(false), catchingUp)
 			}
 			if !arguments.EnableTest {
 				log.Printf("Listening for new Bitcoin block, current height: %d\n", latestHeight)
 			}
-			time.Sleep(interval)
+			waitRound(ctx, interval)
+		}
+	}
+}
+
+// shutdown exits once the queued checkpoints are published and the finalized state is stored, so that a restart
+// neither replays the blocks since the last catch-up nor publishes the same checkpoints again.
+func shutdown(arguments *RuntimeArguments, queue *stateless.Queue) {
+	log.Printf("Shutting down at height %d. Please don't force exit.", queue.LatestHeight())
+	if arguments.EnableCommittee {
+		for _, id := range PublishingIdentities() {
+			for n, method := range id.Targets() {
+				publisher, err := id.Publisher(method)
+				if err != nil {
+					continue
+				}
+				publishQueued(id, publisher, id.Primary() && n == 0)
+				target := id.Target(method)
+				if pending := id.Queue.Len(target); pending != 0 && id.Queue.Persisted() {
+					log.Printf("Keep %d checkpoints by %s queued until the restart", pending, target)
+				} else if pending != 0 {
+					log.Printf("Drop %d checkpoints by %s, since the checkpoint queue isn't persisted", pending, target)
+				}
+			}
+		}
+	}
+	if apis.Publications != nil {
+		if err := apis.Publications.Close(); err != nil {
+			log.Printf("Unable to close the publications database: %v", err)
 		}
 	}
+	if arguments.EnableStateRootCache {
+		if err := queue.StoreFinalized(); err != nil {
+			log.Printf("Unable to store the finalized state: %v", err)
+		}
+	}
+	if stateless.Webhooks != nil {
+		stateless.Webhooks.Stop()
+	}
+	flushTraces()
+	os.Exit(0)
+}
+
+// waitRound waits for the next round of the service loop, for a request of the operators, or for the shutdown.
+func waitRound(ctx context.Context, interval time.Duration) {
+	var wake <-chan struct{}
+	if apis.Admin != nil {
+		wake = apis.Admin.Wake()
+	}
+	select {
+	case <-time.After(interval):
+	case <-wake:
+	case <-ctx.Done():
+	}
+}
+
+// pauseIndexing keeps serving the last executed state while the getter is unavailable, until the upstream is expected
+// back or the next round, whichever is later.
+func pauseIndexing(ctx context.Context, interval time.Duration, err error) {
+	pause := max(interval, GetterBreaker.Pause())
+	log.Printf("Pause indexing for %v: %v", pause, err)
+	metrics.Stage.Set(metrics.StagePaused)
+	waitRound(ctx, pause)
+	metrics.Stage.Set(metrics.StageServing)
+}
+
+// republishCheckpoints publishes the checkpoints of the kept blocks at the heights to every target again,
+// regardless of the schedules and the previous uploads, and fails if any of them can't be queued.
+func republishCheckpoints(arguments *RuntimeArguments, queue *stateless.Queue, heights []uint) error {
+	if !arguments.EnableCommittee {
+		log.Printf("Unable to republish the checkpoints at heights %v without --committee", heights)
+		return errors.New("unable to republish the checkpoints without --committee")
+	}
+	failures := 0
+	states := make(map[uint]stateless.DiffState)
+	for _, state := range queue.History {
+		states[state.Height] = state
+	}
+	states[queue.Header.Height] = committee.LatestState(queue)
+	for _, id := range PublishingIdentities() {
+		for n, method := range id.Targets() {
+			target := id.Target(method)
+			publisher, err := id.Publisher(method)
+			if err != nil {
+				log.Printf("Unable to publish the checkpoints by %s due to: %v", target, err)
+				failures += len(heights)
+				continue
+			}
+			feePublisher, charged := publisher.(checkpoint.FeePublisher)
+			for _, height := range heights {
+				state, found := states[height]
+				if !found {
+					log.Printf("Unable to republish the checkpoint at height %d, which is no longer kept", height)
+					failures++
+					continue
+				}
+				c, err := id.Checkpoint(arguments, &state)
+				if err != nil {
+					log.Printf("Unable to sign the checkpoint at height %s due to: %v", c.Height, err)
+					failures++
+					continue
+				}
+				// The budget is bypassed, while the spend is still recorded.
+				var fee uint64
+				if charged && DABudget != nil && id.Primary() {
+					ctx, cancel := context.WithTimeout(context.Background(), time.Duration(GlobalConfig.Report.Timeout)*time.Millisecond)
+					fee, err = feePublisher.EstimateFee(ctx, &c)
+					cancel()
+					if err != nil {
+						log.Printf("Unable to estimate the DA fee due to: %v", err)
+						failures++
+						continue
+					}
+				}
+				if err := id.Queue.Push(target, c, fee); err != nil {
+					log.Printf("Unable to queue the checkpoint at height %s by %s due to: %v", c.Height, target, err)
+					failures++
+					continue
+				}
+				log.Printf("Republish the checkpoint by %s at height %s as requested by the operator", target, c.Height)
+			}
+			publishQueued(id, publisher, id.Primary() && n == 0)
+		}
+	}
+	if failures != 0 {
+		return fmt.Errorf("unable to republish %d of the checkpoints, see the logs", failures)
+	}
+	return nil
+}
+
+// newCheckpoint returns the checkpoint of the state by the identity of the member.
+func newCheckpoint(arguments *RuntimeArguments, state *stateless.DiffState) checkpoint.Checkpoint {
+	committeeIndexerName := GlobalConfig.Service.Name
+	if arguments.CommitteeIndexerName != "" {
+		committeeIndexerName = arguments.CommitteeIndexerName
+	}
+	serviceURL := GlobalConfig.Service.URL
+	if arguments.CommitteeIndexerURL != "" {
+		serviceURL = arguments.CommitteeIndexerURL
+	}
+	metaProtocol := GlobalConfig.Service.MetaProtocol
+	if arguments.ProtocolName != "" {
+		metaProtocol = arguments.ProtocolName
+	}
+	indexerID := checkpoint.IndexerIdentification{
+		URL:          serviceURL,
+		Name:         committeeIndexerName,
+		Version:      version,
+		MetaProtocol: metaProtocol,
+		GetterSource: GetterSource,
+	}
+	return committee.NewCheckpoint(indexerID, state, arguments.WitnessHash)
+}
+
+// publishCheckpoint pushes the uploaded checkpoint to the websocket subscribers, if any.
+func publishCheckpoint(c *checkpoint.Checkpoint) {
+	if stateless.Subscriptions == nil {
+		return
+	}
+	height, _ := strconv.ParseUint(c.Height, 10, 64)
+	if err := stateless.Subscriptions.PublishCheckpoint(uint(height), c.Hash, c); err != nil {
+		log.Printf("Unable to push the checkpoint at height %s due to: %v", c.Height, err)
+	}
+}
+
+// historyKey identifies the checkpoint of the state queued for the target.
+func historyKey(method string, state *stateless.DiffState) string {
+	return fmt.Sprintf("%s:%d%s", method, state.Height, state.Hash)
+}
+
+// publishCheckpoints queues and publishes the due checkpoints of every member by each of its targets.
+func publishCheckpoints(arguments *RuntimeArguments, hs []*stateless.DiffState, history map[string]checkpoint.UploadRecord, latestHeight uint, demanded bool, catchingUp bool) {
+	for _, id := range PublishingIdentities() {
+		for n, method := range id.Targets() {
+			publisher, err := id.Publisher(method)
+			if err != nil {
+				log.Printf("Unable to publish the checkpoints by %s due to: %v", id.Target(method), err)
+				continue
+			}
+			queueCheckpoints(arguments, id, publisher, hs, history, latestHeight, demanded, catchingUp)
+			// The checkpoints of the service published to its first target are pushed to the websocket subscribers.
+			publishQueued(id, publisher, id.Primary() && n == 0)
+		}
+	}
+}
+
+// queueCheckpoints queues the checkpoints of the member due by the schedule of the target, which are published by
+// publishQueued.
+func queueCheckpoints(arguments *RuntimeArguments, id *Identity, publisher checkpoint.Publisher, hs []*stateless.DiffState, history map[string]checkpoint.UploadRecord, latestHeight uint, demanded bool, catchingUp bool) {
+	method := publisher.Method()
+	target := id.Target(method)
+	hs = committee.DueCheckpoints(hs, ReportSchedule(method), latestHeight, demanded, catchingUp)
+	pending := make([]*stateless.DiffState, 0, len(hs))
+	for _, i := range hs {
+		if curRecord, found := history[historyKey(target, i)]; found && curRecord.Success {
+			continue
+		}
+		// The checkpoints queued before a restart are published from the persisted queue, if not yet.
+		if id.Queue.Queued(target, strconv.FormatUint(uint64(i.Height), 10), i.Hash) {
+			continue
+		}
+		pending = append(pending, i)
+	}
+	// The budget caps the spend of the account of the service only.
+	var fee uint64
+	if feePublisher, charged := publisher.(checkpoint.FeePublisher); charged && DABudget != nil && id.Primary() && len(pending) != 0 {
+		pending, fee = budgetCheckpoints(arguments, feePublisher, pending, history, latestHeight)
+	}
+	for _, i := range pending {
+		c, err := id.Checkpoint(arguments, i)
+		if err != nil {
+			log.Printf("Unable to sign the checkpoint at height %s due to: %v", c.Height, err)
+			continue
+		}
+		if err := id.Queue.Push(target, c, fee); err != nil {
+			log.Printf("Unable to queue the checkpoint at height %s by %s due to: %v", c.Height, target, err)
+			continue
+		}
+		history[historyKey(target, i)] = checkpoint.UploadRecord{
+			Success: true,
+		}
+	}
+}
+
+// publishQueued publishes the queued checkpoints of the member by the target in order, until one fails,
+// after which the target is retried with a backoff.
+func publishQueued(id *Identity, publisher checkpoint.Publisher, primary bool) {
+	method := publisher.Method()
+	target := id.Target(method)
+	feePublisher, charged := publisher.(checkpoint.FeePublisher)
+	charged = charged && id.Primary()
+	for _, p := range id.Queue.Due(target) {
+		c := p.Checkpoint
+		height, _ := strconv.ParseUint(c.Height, 10, 64)
+		log.Printf("Uploading the checkpoint by %s at height: %s\n", target, c.Height)
+		timeout := time.Duration(GlobalConfig.Report.Timeout) * time.Millisecond
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		ctx, span := tracing.Start(ctx, "checkpoint.upload", tracing.Height(uint(height)), attribute.String("method", target))
+		var err error
+		if charged {
+			err = feePublisher.PublishAtFee(ctx, &c, p.Fee)
+		} else {
+			err = publisher.Publish(ctx, &c)
+		}
+		tracing.End(span, err)
+		cancel()
+		if err != nil {
+			metrics.CheckpointUploads.WithLabelValues(target, "failure").Inc()
+			apis.RecordUpstream(method, err)
+			retry, qerr := id.Queue.Fail(target, err)
+			if qerr != nil {
+				log.Printf("Unable to save the checkpoint queue due to: %v", qerr)
+			}
+			log.Printf("Unable to upload the checkpoint by %s due to: %v, retrying the %d queued checkpoints after %s",
+				target, err, id.Queue.Len(target), retry.Format(time.RFC3339))
+			return
+		}
+		metrics.CheckpointUploads.WithLabelValues(target, "success").Inc()
+		apis.RecordUpstream(method, nil)
+		apis.RecordCheckpoint()
+		if apis.Publications != nil {
+			var receipt checkpoint.Receipt
+			if r, ok := publisher.(checkpoint.ReceiptPublisher); ok {
+				receipt = r.Receipt(&c)
+			}
+			if err := apis.Publications.Record(&c, target, receipt, time.Now()); err != nil {
+				log.Printf("Unable to record the publication of the checkpoint at height %s due to: %v", c.Height, err)
+			}
+		}
+		log.Printf("Succeed to upload the checkpoint by %s at height: %s\n", target, c.Height)
+		if err := id.Queue.Done(target, &c); err != nil {
+			log.Printf("Unable to save the checkpoint queue due to: %v", err)
+		}
+		if primary {
+			publishCheckpoint(&c)
+		}
+		if DABudget != nil && charged {
+			if err := DABudget.Spend(p.Fee); err != nil {
+				log.Printf("Unable to record the DA spend due to: %v", err)
+			}
+		}
+	}
+}
+
+// budgetCheckpoints selects the pending checkpoints to publish to the DA layer within the budget,
+// returning them with the estimated fee. The coalesced checkpoints are recorded as done, since they are never published.
+func budgetCheckpoints(arguments *RuntimeArguments, publisher checkpoint.FeePublisher, hs []*stateless.DiffState, history map[string]checkpoint.UploadRecord, latestHeight uint) ([]*stateless.DiffState, uint64) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(GlobalConfig.Report.Timeout)*time.Millisecond)
+	defer cancel()
+	// The checkpoints are about the same size, so the fee of the latest one stands for all of them.
+	latest := newCheckpoint(arguments, hs[len(hs)-1])
+	if CheckpointSigner != nil {
+		_ = latest.Sign(CheckpointSigner)
+	}
+	fee, err := publisher.EstimateFee(ctx, &latest)
+	if err != nil {
+		log.Printf("Unable to estimate the DA fee due to: %v", err)
+		return nil, 0
+	}
+
+	heights := make([]uint, len(hs))
+	for i, h := range hs {
+		heights[i] = h.Height
+	}
+	decisions := DABudget.Plan(heights, latestHeight, ord.BitcoinConfirmations, fee)
+	selected := make([]*stateless.DiffState, 0, len(hs))
+	for i, h := range hs {
+		switch decisions[i] {
+		case checkpoint.DecisionPublish:
+			selected = append(selected, h)
+		case checkpoint.DecisionCoalesce:
+			history[historyKey(publisher.Method(), h)] = checkpoint.UploadRecord{Success: true}
+		}
+	}
+	if len(selected) != len(hs) {
+		log.Printf("Publish %d of %d checkpoints at the estimated DA fee %d, spent %d this month", len(selected), len(hs), fee, DABudget.Spent())
+	}
+	return selected, fee
+}
+
+// GenesisHeight returns the height of the first block indexed, the one of the network unless configured.
+func GenesisHeight() uint {
+	if GlobalConfig.Genesis.Height != 0 {
+		return GlobalConfig.Genesis.Height
+	}
+	return ord.IndexedNetwork.GenesisHeight()
+}
+
+// setupAccess limits the APIs by the API keys and the rate of the access config, if any.
+func setupAccess() {
+	access := GlobalConfig.Service.Access
+	if err := access.Validate(); err != nil {
+		log.Fatalf("Invalid access config: %v", err)
+	}
+	apis.TrustedProxies = access.TrustedProxies
+	if len(access.Keys) == 0 && access.Rate <= 0 {
+		return
+	}
+	apis.Access = apis.NewAccessControl(access, func() []string {
+		keys := make([]string, len(access.Keys))
+		for i, key := range access.Keys {
+			keys[i] = Secrets.Get(key)
+		}
+		return keys
+	})
+	log.Printf("Limit the APIs to %v requests per second of every IP and %d API keys", access.Rate, len(access.Keys))
+}
+
+// localCommitment returns the commitment of the block at the height recomputed locally: the one of the state kept by
+// the queue, or else the one of replaying the stored witness of the block, which is of the last block executed at
+// the height. False if neither is kept.
+func localCommitment(queue *stateless.Queue, height uint, hash string) (string, bool) {
+	queue.RLock()
+	if queue.Header.Height == height && queue.Header.Hash == hash {
+		commitment := queue.Header.Root.Commit().Bytes()
+		queue.RUnlock()
+		return base64.StdEncoding.EncodeToString(commitment[:]), true
+	}
+	for _, state := range queue.History {
+		if state.Height == height && state.Hash == hash {
+			queue.RUnlock()
+			return base64.StdEncoding.EncodeToString(state.VerkleCommit[:]), true
+		}
+	}
+	queue.RUnlock()
+	if stateless.WitnessPath == "" {
+		return "", false
+	}
+	w, err := stateless.LoadWitness(stateless.WitnessPath, height)
+	if err != nil {
+		return "", false
+	}
+	replayed, _, err := reexec.Replay(w)
+	if err != nil {
+		log.Printf("Failed to replay the witness at height %d: %v", height, err)
+		return "", false
+	}
+	return replayed, true
 }
 
 func Execution(arguments *RuntimeArguments) {
 	go metrics.ListenAndServe(arguments.MetricAddr)
 	metrics.Version.WithLabelValues(version).Set(1)
 	metrics.Stage.Set(metrics.StageInitializing)
+	stateless.ExecShards = arguments.ExecShards
+	if arguments.ReorgDepth < ord.BitcoinConfirmations {
+		log.Fatalf("The reorg depth %d is less than %d confirmations", arguments.ReorgDepth, ord.BitcoinConfirmations)
+	}
+	stateless.ReorgDepth = arguments.ReorgDepth
+	getter.PendingWindow = arguments.ReorgDepth + 2
+	stateless.StateDBPath = arguments.StateDBPath
+	if arguments.EvictInterval != 0 && arguments.StateDBPath == "" {
+		log.Fatalf("The eviction of the verkle tree requires --state-db")
+	}
+	stateless.EvictInterval = arguments.EvictInterval
+	apis.MaxLag = arguments.MaxLag
+	if arguments.Replica && (arguments.EnableCommittee || !arguments.EnableStateRootCache) {
+		log.Fatalf("The replica serves the state root cache without publishing the checkpoints, it requires --cache without --committee")
+	}
+	stateless.EvictDepth = arguments.EvictDepth
+	stateless.HistoryPath = arguments.HistoryPath
+	stateless.EventsPath = arguments.EventsPath
+	stateless.PreImagesPath = arguments.PreImagesPath
+	protocol.RecordPreImages(arguments.PreImagesPath != "")
+	stateless.SnapshotBaselineInterval = arguments.SnapshotBaseline
+	stateless.WriteAheadLog = arguments.WriteAheadLog
+	if arguments.WitnessPath != "" {
+		err := os.MkdirAll(arguments.WitnessPath, 0755)
+		if err != nil {
+			log.Fatalf("Failed to create the witness directory: %v", err)
+		}
+		stateless.WitnessPath = arguments.WitnessPath
+	}
+	if arguments.WitnessHash {
+		// The checkpoints are of the states kept for the reorgs.
+		stateless.KeepRecentWitnesses(stateless.ReorgDepth + 1)
+	}
 
 	// Get the configuration.
-	configFile, err := os.ReadFile(arguments.ConfigFilePath)
-	if err != nil {
-		log.Fatalf("Failed to read config file: %v", err)
+	loadConfig(arguments.ConfigFilePath, arguments.Network)
+	go Secrets.Run(context.Background())
+
+	var err error
+	if GlobalConfig.Tracing.Enabled {
+		ShutdownTracing, err = tracing.Init(context.Background(), GlobalConfig.Tracing, version)
+		if err != nil {
+			log.Fatalf("Failed to initialize the tracing: %v", err)
+		}
+		log.Printf("Exporting the spans to %s", GlobalConfig.Tracing.Endpoint)
 	}
 
-	err = json.Unmarshal(configFile, &GlobalConfig)
-	if err != nil {
-		log.Fatalf("Failed to parse config file: %v", err)
+	if arguments.EnableCommittee {
+		for _, method := range ReportTargets() {
+			schedule := ReportSchedule(method)
+			if err := schedule.Validate(ord.BitcoinConfirmations); err != nil {
+				log.Fatalf("Invalid publication schedule of %s: %v", method, err)
+			}
+			policy := schedule.Policy
+			if policy == "" {
+				policy = checkpoint.PolicyEveryBlock
+			}
+			log.Printf("The publication policy of the checkpoints by %s is %s", method, policy)
+			if _, err := ReportPublisher(method); err != nil {
+				log.Fatalf("Invalid report config: %v", err)
+			}
+		}
+		CheckpointQueue, err = checkpoint.NewQueue(GlobalConfig.Report.Queue)
+		if err != nil {
+			log.Fatalf("Invalid checkpoint queue: %v", err)
+		}
+		Identities, err = LoadIdentities(arguments)
+		if err != nil {
+			log.Fatalf("Invalid report identities: %v", err)
+		}
+		if reportsTo("DA") || identitiesReportTo("DA") {
+			DABreaker = retry.NewBreaker("DA", GlobalConfig.Retry.Da)
+		}
+		if arguments.PublicationsPath != "" {
+			apis.Publications, err = checkpoint.OpenLedger(arguments.PublicationsPath)
+			if err != nil {
+				log.Fatalf("Failed to open the publications database: %v", err)
+			}
+		}
+
+		if budget := GlobalConfig.Report.Da.Budget; reportsTo("DA") && budget.Enabled() {
+			DABudget, err = checkpoint.NewBudget(budget)
+			if err != nil {
+				log.Fatalf("Invalid DA budget: %v", err)
+			}
+			log.Printf("The DA budget is %d per month, delaying the non-final checkpoints above the fee %d", budget.Monthly, budget.SpikeFee)
+		}
+
+		if signature := GlobalConfig.Report.Signature; signature.Scheme != "" {
+			CheckpointSigner, err = checkpoint.NewSigner(signature.Scheme, Secrets.Get(signature.PrivateKey))
+			if err != nil {
+				log.Fatalf("Invalid checkpoint signature: %v", err)
+			}
+			log.Printf("The checkpoints are signed by the %s public key %x", signature.Scheme, CheckpointSigner.PublicKey())
+		}
 	}
 
-	if GlobalConfig.Report.Method == "DA" && arguments.EnableCommittee {
-		if !checkpoint.IsValidNamespaceID(GlobalConfig.Report.Da.NamespaceID) {
+	if reportsTo("DA") && arguments.EnableCommittee {
+		if !checkpoint.IsValidNamespaceID(DANamespaceID()) {
 			log.Printf("Got invalid Namespace ID from the config.json. Initializing a new namespace.")
 			scanner := bufio.NewScanner(os.Stdin)
 			namespaceName := ""
@@ -262,11 +707,18 @@ func Execution(arguments *RuntimeArguments) {
 					}
 				}
 			}
-			nid, err := checkpoint.CreateNamespace(GlobalConfig.Report.Da.PrivateKey, GlobalConfig.Report.Da.GasCoupon, namespaceName, GlobalConfig.Report.Da.Network)
+			nid, err := checkpoint.CreateNamespace(Secrets.Get(GlobalConfig.Report.Da.PrivateKey), Secrets.Get(GlobalConfig.Report.Da.GasCoupon), namespaceName, GlobalConfig.Report.Da.Network)
 			if err != nil {
 				log.Fatalf("Failed to create namespace due to %v", err)
 			}
-			GlobalConfig.Report.Da.NamespaceID = nid
+			if ord.IndexedNetwork == ord.Mainnet {
+				GlobalConfig.Report.Da.NamespaceID = nid
+			} else {
+				if GlobalConfig.Report.Da.Namespaces == nil {
+					GlobalConfig.Report.Da.Namespaces = make(map[string]string)
+				}
+				GlobalConfig.Report.Da.Namespaces[string(ord.IndexedNetwork)] = nid
+			}
 			bytes, err := json.Marshal(GlobalConfig)
 			if err != nil {
 				log.Fatalf("Failed to save namespace ID to local file due to %v", err)
@@ -279,32 +731,395 @@ func Execution(arguments *RuntimeArguments) {
 		}
 	}
 
-	// Use OPI database as the ordGetter.
-	gd := getter.DatabaseConfig(GlobalConfig.Database)
-	var ordGetter getter.OrdGetter
-	if arguments.EnableTest {
-		ordGetter, err = getter.NewOPIOrdGetterTest(&gd, arguments.TestBlockHeightLimit, arguments.TestBlockHeightLimit)
-	} else {
-		ordGetter, err = getter.NewOPIOrdGetter(&gd)
+	if arguments.Replica {
+		setupAccess()
+		ReplicaStage(arguments, GenesisHeight()-1)
+		return
 	}
-	if err != nil {
-		log.Fatalf("Failed to initial getter from opi database: %v", err)
+
+	// Use OPI database as the ordGetter, unless bitcoind or the ord server is enabled.
+	ordGetter := newOrdGetter(arguments)
+	stateless.SecondaryCommitment = arguments.SecondaryCommitment
+	stateless.BlockDeadline = arguments.BlockDeadline
+
+	if GlobalConfig.Validation.Enabled {
+		if err := GlobalConfig.Validation.Validate(); err != nil {
+			log.Fatalf("Invalid validation config: %v", err)
+		}
+		ordGetter = sanity.NewGetter(ordGetter, GlobalConfig.Validation)
+		log.Printf("Suspicious blocks are quarantined in %s", GlobalConfig.Validation.QuarantineDir)
+	}
+	if arguments.SatpointRPC != "" {
+		tracker := satpoint.NewTracker(satpoint.NewRPCTxSource(arguments.SatpointRPC))
+		ordGetter = satpoint.NewGetter(ordGetter, tracker)
+	}
+	if arguments.GetterCacheSize > 0 {
+		// The hashes of the blocks which may still be reorganized are always queried.
+		ordGetter = getter.NewCache(ordGetter, arguments.GetterCacheSize, arguments.GetterCacheTTL, arguments.LatestHeightTTL, arguments.ReorgDepth+1)
 	}
 
 	latestHeight, err := ordGetter.GetLatestBlockHeight()
+	for errors.Is(err, retry.ErrUnavailable) {
+		log.Printf("Wait for the getter to get the latest block height: %v", err)
+		time.Sleep(GetterBreaker.Pause())
+		latestHeight, err = ordGetter.GetLatestBlockHeight()
+	}
 	if err != nil {
 		log.Fatalf("Failed to get the latest block height: %v", err)
 	}
 
-	queue, err := CatchupStage(ordGetter, arguments, stateless.BRC20StartHeight-1, latestHeight)
+	genesisHeight := GenesisHeight()
+	log.Printf("The genesis height is %d", genesisHeight)
+	if GlobalConfig.Genesis.Bootstrap != "" {
+		stateless.Genesis, err = stateless.LoadGenesis(GlobalConfig.Genesis.Bootstrap)
+		if err != nil {
+			log.Fatalf("Failed to load the bootstrap state: %v", err)
+		}
+	}
+
+	configureRules()
+
+	if len(GlobalConfig.Watchlist.Wallets) != 0 || len(GlobalConfig.Watchlist.Pkscripts) != 0 {
+		stateless.Watchlist = watchlist.New(GlobalConfig.Watchlist)
+		log.Printf("Watching %d wallets and %d pkscripts", len(GlobalConfig.Watchlist.Wallets), len(GlobalConfig.Watchlist.Pkscripts))
+	}
+	if len(GlobalConfig.Webhooks.Hooks) != 0 {
+		notifier, err := webhook.New(GlobalConfig.Webhooks, Secrets.Get)
+		if err != nil {
+			log.Fatalf("Invalid webhooks config: %v", err)
+		}
+		stateless.Webhooks = notifier
+		log.Printf("Notify %d webhooks of the events of every block", len(GlobalConfig.Webhooks.Hooks))
+	}
+
+	if err := GlobalConfig.Peers.Validate(); err != nil {
+		log.Fatalf("Invalid peers config: %v", err)
+	}
+	if GlobalConfig.Peers.Enabled {
+		signer, err := peer.NewSigner(Secrets.Get(GlobalConfig.Peers.SigningKey))
+		if err != nil {
+			log.Fatalf("Invalid peers config: %v", err)
+		}
+		apis.Peers = &apis.PeerService{
+			Signer: signer,
+			Tokens: func() []string {
+				tokens := make([]string, len(GlobalConfig.Peers.Tokens))
+				for i, token := range GlobalConfig.Peers.Tokens {
+					tokens[i] = Secrets.Get(token)
+				}
+				return tokens
+			},
+			Audit: GlobalConfig.Peers.Audit,
+			MemberToken: func(m peer.Member) string {
+				return Secrets.Get(m.Token)
+			},
+		}
+		log.Printf("Serving the signed diffs to %d peers, verified by the public key %s", len(GlobalConfig.Peers.Tokens), signer.PublicKey())
+		if len(GlobalConfig.Peers.Audit.Members) != 0 {
+			log.Printf("Auditing %d members after each block", len(GlobalConfig.Peers.Audit.Members))
+		}
+	}
+
+	if GlobalConfig.CrossCheck.Enabled {
+		apis.CrossCheck, err = crosscheck.New(GlobalConfig.CrossCheck, OpenSource)
+		if err != nil {
+			log.Fatalf("Invalid cross-check config: %v", err)
+		}
+		log.Printf("Cross-checking the checkpoints of %d members after each block", len(GlobalConfig.CrossCheck.Members))
+		if GlobalConfig.CrossCheck.ProofDir != "" {
+			if err := os.MkdirAll(GlobalConfig.CrossCheck.ProofDir, 0755); err != nil {
+				log.Fatalf("Failed to create the directory of the fraud proofs: %v", err)
+			}
+			// The witnesses of the latest blocks outlive the wait for the checkpoints of the members.
+			stateless.KeepRecentWitnesses(fraudProofWitnesses)
+			apis.CrossCheck.Witnesses = stateless.RecentWitness
+			log.Printf("Save the fraud proofs of the diverging checkpoints to %s", GlobalConfig.CrossCheck.ProofDir)
+		}
+	}
+
+	if GlobalConfig.OPICheck.Enabled {
+		stateless.OPICheck, err = crosscheck.NewOPI(GlobalConfig.OPICheck)
+		if err != nil {
+			log.Fatalf("Invalid OPI cross-check config: %v", err)
+		}
+		go stateless.OPICheck.Run(context.Background())
+		log.Printf("Cross-checking the event hashes of each block with OPI at %s", GlobalConfig.OPICheck.URL)
+	}
+
+	if GlobalConfig.SelfAudit.Enabled {
+		if apis.Publications == nil {
+			log.Fatalf("The self-audit requires --committee and --publications")
+		}
+		source := GlobalConfig.SelfAudit.Source
+		if source == "" {
+			source = "da://" + DANamespaceID()
+		}
+		s, err := OpenSource(source)
+		if err != nil {
+			log.Fatalf("Failed to open the published checkpoints at %s: %v", source, err)
+		}
+		apis.SelfAudit, err = selfaudit.New(GlobalConfig.SelfAudit, apis.Publications, s)
+		if err != nil {
+			log.Fatalf("Invalid self-audit config: %v", err)
+		}
+		apis.SelfAudit.Name, apis.SelfAudit.MetaProtocol = GlobalConfig.Service.Name, GlobalConfig.Service.MetaProtocol
+		if arguments.CommitteeIndexerName != "" {
+			apis.SelfAudit.Name = arguments.CommitteeIndexerName
+		}
+		if arguments.ProtocolName != "" {
+			apis.SelfAudit.MetaProtocol = arguments.ProtocolName
+		}
+		log.Printf("Audit the published checkpoints read back from %s every %d seconds", source, GlobalConfig.SelfAudit.Interval)
+	}
+
+	if GlobalConfig.Service.DryRun.Enabled {
+		if len(GlobalConfig.Service.DryRun.Tokens) == 0 {
+			log.Fatalf("At least one token is required by the dry runs")
+		}
+		apis.DryRuns = &apis.DryRunService{
+			Tokens: func() []string {
+				tokens := make([]string, len(GlobalConfig.Service.DryRun.Tokens))
+				for i, token := range GlobalConfig.Service.DryRun.Tokens {
+					tokens[i] = Secrets.Get(token)
+				}
+				return tokens
+			},
+		}
+		log.Printf("Serving the dry runs to %d wallets", len(GlobalConfig.Service.DryRun.Tokens))
+	}
+
+	if len(GlobalConfig.Service.Admin.Tokens) != 0 {
+		apis.Admin = &apis.AdminService{
+			Tokens: func() []string {
+				tokens := make([]string, len(GlobalConfig.Service.Admin.Tokens))
+				for i, token := range GlobalConfig.Service.Admin.Tokens {
+					tokens[i] = Secrets.Get(token)
+				}
+				return tokens
+			},
+			Journal: GlobalConfig.Service.Admin.Journal,
+		}
+		if err := apis.Admin.LoadJournal(); err != nil {
+			log.Fatalf("Failed to load the admin journal: %v", err)
+		}
+		log.Printf("Serving the admin APIs to %d operators", len(GlobalConfig.Service.Admin.Tokens))
+	}
+
+	setupAccess()
+
+	var archiver *archive.Archiver
+	if GlobalConfig.Archive.Enabled {
+		cfg := GlobalConfig.Archive
+		cfg.AccessKey, cfg.SecretKey = Secrets.Get(cfg.AccessKey), Secrets.Get(cfg.SecretKey)
+		archiver, err = archive.New(cfg)
+		if err != nil {
+			log.Fatalf("Invalid archive config: %v", err)
+		}
+		stateless.Archive = archiver
+		if stateless.WitnessPath != "" {
+			archiver.Register(archive.ClassWitnesses, stateless.WitnessPath, ".json")
+		}
+		if GlobalConfig.Validation.Enabled {
+			sanity.Archive = archiver
+			archiver.Register(archive.ClassQuarantine, GlobalConfig.Validation.QuarantineDir, ".json")
+		}
+		if stateless.Watchlist != nil {
+			stateless.Watchlist.SetArchive(archive.NewEventArchive(archiver))
+		}
+		log.Printf("Move the archive data to the %s bucket %s", cfg.Provider, cfg.Bucket)
+	}
+
+	if err := GlobalConfig.Retention.Validate(); err != nil {
+		log.Fatalf("Invalid retention config: %v", err)
+	}
+	stateless.Retention = GlobalConfig.Retention
+
+	if arguments.BisectA != "" || arguments.BisectB != "" {
+		if arguments.BisectA == "" || arguments.BisectB == "" {
+			log.Fatalf("Both --bisect-a and --bisect-b are required to bisect")
+		}
+		metaProtocol := GlobalConfig.Service.MetaProtocol
+		if arguments.ProtocolName != "" {
+			metaProtocol = arguments.ProtocolName
+		}
+		if err := Bisect(ordGetter, arguments, genesisHeight-1, metaProtocol); err != nil {
+			log.Fatalf("Failed to bisect the checkpoints: %v", err)
+		}
+		return
+	}
+
+	if snapshotBootstrapEnabled() {
+		if !arguments.EnableStateRootCache {
+			log.Fatalf("The snapshot bootstrap requires the state root cache")
+		}
+		metaProtocol := GlobalConfig.Service.MetaProtocol
+		if arguments.ProtocolName != "" {
+			metaProtocol = arguments.ProtocolName
+		}
+		if err := BootstrapSnapshot(context.Background(), ordGetter, metaProtocol); err != nil {
+			log.Fatalf("Failed to bootstrap the state from the snapshot: %v", err)
+		}
+	}
+
+	queue, err := CatchupStage(ordGetter, arguments, genesisHeight-1, latestHeight)
 
 	if err != nil {
 		log.Fatalf("Failed to catchup the latest state: %v", err)
 	}
 
+	if archiver != nil {
+		interval := time.Duration(GlobalConfig.Archive.Interval) * time.Second
+		if interval <= 0 {
+			interval = time.Minute
+		}
+		go archiver.Run(context.Background(), interval, queue.LatestHeight)
+	}
+	go stateless.RunPruning(context.Background(), queue.LatestHeight)
+	if apis.SelfAudit != nil {
+		apis.SelfAudit.Local = func(height uint, hash string) (string, bool) {
+			return localCommitment(queue, height, hash)
+		}
+		go apis.SelfAudit.Run(context.Background())
+	}
+
 	ServiceStage(ordGetter, arguments, queue, 60*time.Second)
 }
 
+// loadConfig reads the config file, selects the network to index, replacing the network of the config if not empty,
+// and loads the secrets of the config.
+func loadConfig(path string, network string) {
+	configFile, err := os.ReadFile(path)
+	if err != nil {
+		log.Fatalf("Failed to read config file: %v", err)
+	}
+
+	err = json.Unmarshal(configFile, &GlobalConfig)
+	if err != nil {
+		log.Fatalf("Failed to parse config file: %v", err)
+	}
+
+	if err := SelectNetwork(network); err != nil {
+		log.Fatalf("Invalid network: %v", err)
+	}
+	log.Printf("Indexing the Bitcoin %s", ord.IndexedNetwork)
+
+	if err := LoadSecrets(context.Background()); err != nil {
+		log.Fatalf("Failed to load the secrets: %v", err)
+	}
+}
+
+// newOrdGetter opens the getter of the config, the OPI database unless bitcoind or the ord server is enabled, whose
+// transient failures are retried.
+func newOrdGetter(arguments *RuntimeArguments) getter.OrdGetter {
+	gd := DatabaseConfig()
+	var ordGetter getter.OrdGetter
+	var err error
+	if arguments.EnableTest {
+		ordGetter, err = getter.NewOPIOrdGetterTest(&gd, arguments.TestBlockHeightLimit, arguments.TestBlockHeightLimit)
+		GetterSource = GetterSourceTest
+	} else if GlobalConfig.Bitcoind.Enabled {
+		cfg := GlobalConfig.Bitcoind
+		cfg.URL = Secrets.Get(cfg.URL)
+		ordGetter, err = getter.NewBitcoindGetter(cfg)
+		GetterSource = GetterSourceBitcoind
+		log.Printf("Read the inscriptions from the blocks of bitcoind")
+	} else if GlobalConfig.Ord.Enabled {
+		ordGetter, err = getter.NewOrdServerGetter(GlobalConfig.Ord)
+		GetterSource = GetterSourceOrd
+		log.Printf("Read the inscriptions from the ord server %s", GlobalConfig.Ord.URL)
+	} else {
+		var opiGetter *getter.OPIOrdGetter
+		opiGetter, err = getter.NewOPIOrdGetter(&gd)
+		db := GlobalConfig.Database
+		Secrets.Watch(func() {
+			gd := DatabaseConfig()
+			if err := opiGetter.Reconnect(&gd); err != nil {
+				log.Printf("Failed to reconnect the opi database with the rotated credentials: %v", err)
+			} else {
+				log.Printf("Reconnected the opi database with the rotated credentials")
+			}
+		}, db.Host, db.User, db.Password, db.DBname, db.Port)
+		ordGetter = opiGetter
+	}
+	if err != nil {
+		log.Fatalf("Failed to initial getter: %v", err)
+	}
+	// The transient failures of the upstream are retried below the validation and the caches.
+	GetterBreaker = retry.NewBreaker("getter", GlobalConfig.Retry.Getter)
+	ordGetter = getter.NewRetrying(ordGetter, GetterBreaker)
+	return ordGetter
+}
+
+// configureRules sets the rules of the config, and registers the protocols it enables other than BRC-20.
+func configureRules() {
+	// The ticks are folded by the rules before the reserved ticks are validated.
+	if err := GlobalConfig.Rules.Ticks.Validate(); err != nil {
+		log.Fatalf("Invalid tick rules: %v", err)
+	}
+	brc20.Ticks = GlobalConfig.Rules.Ticks
+	if brc20.CurrentRules().Ticks != nil {
+		log.Printf("The ticks are validated by the rules %+v", *brc20.CurrentRules().Ticks)
+	}
+
+	if len(GlobalConfig.Rules.Deploy) != 0 {
+		if err := GlobalConfig.Rules.Deploy.Validate(); err != nil {
+			log.Fatalf("Invalid deploy rules: %v", err)
+		}
+		brc20.DeployPolicies = []brc20.DeployPolicy{GlobalConfig.Rules.Deploy}
+		log.Printf("%d deploy rules are enabled", len(GlobalConfig.Rules.Deploy))
+	}
+
+	if GlobalConfig.Rules.Content.MaxContentSize < 0 || GlobalConfig.Rules.Content.MaxJSONDepth < 0 {
+		log.Fatalf("Invalid content limits: %+v", GlobalConfig.Rules.Content)
+	}
+	brc20.Limits = GlobalConfig.Rules.Content
+	metrics.RegisterSkippedContents(brc20.SkipReasons, brc20.SkippedContents)
+	metrics.RegisterStemCache(brc20.StemCacheStats)
+
+	brc20.Numbers = GlobalConfig.Rules.Numbers
+	if brc20.Numbers.Strict {
+		log.Printf("The strict validation of the numbers is enabled")
+	}
+
+	if GlobalConfig.Rules.AuthorityTransfer.ActivationHeight != 0 {
+		brc20.AuthorityTransferHeight = GlobalConfig.Rules.AuthorityTransfer.ActivationHeight
+		log.Printf("The mint authority transfer activates at the block %d", brc20.AuthorityTransferHeight)
+	}
+	if GlobalConfig.Rules.Burn.ActivationHeight != 0 {
+		brc20.BurnHeight = GlobalConfig.Rules.Burn.ActivationHeight
+		log.Printf("The burns of the transfers to the unspendable outputs activate at the block %d", brc20.BurnHeight)
+	}
+	if GlobalConfig.Rules.Counters.ActivationHeight != 0 {
+		brc20.CountersHeight = GlobalConfig.Rules.Counters.ActivationHeight
+		log.Printf("The per-tick counters are kept from the block %d", brc20.CountersHeight)
+	}
+	if GlobalConfig.Rules.StrictUTF8.ActivationHeight != 0 {
+		brc20.StrictUTF8Height = GlobalConfig.Rules.StrictUTF8.ActivationHeight
+		log.Printf("The contents that aren't valid UTF-8 are rejected from the block %d", brc20.StrictUTF8Height)
+	}
+	keys, err := protocol.KeySchemeOf(GlobalConfig.Rules.KeyScheme)
+	if err != nil {
+		log.Fatalf("Invalid key scheme: %v", err)
+	}
+	protocol.Keys = keys
+	if protocol.KeySchemeFlag(keys) != "" {
+		log.Printf("The keys of the state are derived by the key scheme %s", keys.Name())
+	}
+	if GlobalConfig.SNS.Enabled {
+		sns.Configure(GlobalConfig.SNS)
+		sns.Register()
+		apis.Modules = append(apis.Modules, apis.SNSModule)
+		log.Printf("The sats names of %v are indexed from the block %d", sns.Namespaces, sns.ActivationHeight)
+	}
+	if GlobalConfig.Bitmap.Enabled {
+		bitmap.Configure(GlobalConfig.Bitmap)
+		bitmap.Register()
+		apis.Modules = append(apis.Modules, apis.BitmapModule)
+		log.Printf("The Bitmap districts are indexed from the block %d", bitmap.ActivationHeight)
+	}
+	log.Printf("The rules version is %s", brc20.RulesVersion())
+}
+
 func main() {
 	arguments := NewRuntimeArguments()
 	rootCmd := arguments.MakeCmd()
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/brc20"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/getter"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/reexec"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+func Test_MalformedTransfer(t *testing.T) {
+	stateless.WitnessPath = t.TempDir()
+	defer func() { stateless.WitnessPath = "" }()
+	pkscript := "0014" + strings.Repeat("a4", 20)
+	g := &blocksGetter{
+		blocks: map[uint][]getter.OrdTransfer{
+			800001: {inscribe(strings.Repeat("8", 64)+"i0", pkscript, "", `{"p":"brc-20","op":"deploy","tick":"FAIL","max":"100"}`)},
+			800002: {
+				// The pkscript isn't hex, which fails after the balance is credited.
+				inscribe(strings.Repeat("9", 64)+"i0", "zz", "", `{"p":"brc-20","op":"mint","tick":"fail","amt":"10"}`),
+				// The inscription ID can't be stored.
+				inscribe("malformed", pkscript, "", `{"p":"brc-20","op":"deploy","tick":"badi","max":"100"}`),
+				inscribe(strings.Repeat("9", 64)+"i1", pkscript, "", `{"p":"brc-20","op":"mint","tick":"fail","amt":"10"}`),
+			},
+		},
+		hashes: make(map[uint]string),
+	}
+	header := stateless.LoadHeader(false, 800000)
+	queue, err := stateless.NewQueues(g, header, true, 800001)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The failed transfers are skipped as a whole, while the rest of the block goes on.
+	_, _, available, _, err := brc20.GetBalances(queue.Header, "fail", ord.Pkscript(pkscript))
+	if err != nil || available.Dec() != "10000000000000000000" {
+		t.Fatalf("Unexpected balance %v, %v", available, err)
+	}
+	_, _, available, _, err = brc20.GetBalances(queue.Header, "fail", "zz")
+	if err != nil || !available.IsZero() {
+		t.Fatalf("Expected the failed mint to be skipped, got %v, %v", available, err)
+	}
+	remaining, err := queue.Header.GetUInt256(brc20.GetTickHash("fail", brc20.RemainingSupply))
+	if err != nil || remaining.Dec() != "90000000000000000000" {
+		t.Fatalf("Unexpected remaining supply %v, %v", remaining, err)
+	}
+	exists, err := queue.Header.GetUInt256(brc20.GetTickHash("badi", brc20.Exists))
+	if err != nil || !exists.IsZero() {
+		t.Fatalf("Expected the failed deploy to be skipped, got %v, %v", exists, err)
+	}
+
+	// The stateless re-execution skips the same transfers.
+	w, err := stateless.LoadWitness(stateless.WitnessPath, 800002)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := reexec.Verify(w); err != nil {
+		t.Fatal(err)
+	}
+}
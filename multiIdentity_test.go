@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/RiemaLabs/modular-indexer-committee/checkpoint"
+	"github.com/RiemaLabs/modular-indexer-committee/committee"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/getter"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+func Test_MultiIdentity(t *testing.T) {
+	g := &blocksGetter{blocks: map[uint][]getter.OrdTransfer{}, hashes: make(map[uint]string)}
+	header := stateless.LoadHeader(false, 800000)
+	queue, err := stateless.NewQueues(g, header, true, 800001)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report, service := GlobalConfig.Report, GlobalConfig.Service
+	defer func() {
+		GlobalConfig.Report, GlobalConfig.Service = report, service
+		CheckpointQueue, Identities = nil, nil
+	}()
+	GlobalConfig.Service.Name = "mainnet-member"
+	GlobalConfig.Report.Targets = []string{"Local"}
+	GlobalConfig.Report.Local.Dir = t.TempDir()
+	if CheckpointQueue, err = checkpoint.NewQueue(checkpoint.QueueConfig{}); err != nil {
+		t.Fatal(err)
+	}
+	canary := IdentityConfig{Name: "canary-member", Method: "Local"}
+	canary.Signature = checkpoint.SignatureConfig{Scheme: checkpoint.SchemeEd25519, PrivateKey: strings.Repeat("5a", 32)}
+	canary.Local.Dir = t.TempDir()
+	arguments := RuntimeArguments{EnableCommittee: true}
+
+	// The names and the queue files of the identities are distinct.
+	for _, invalid := range []IdentityConfig{
+		{Name: "mainnet-member", Method: "Local"},
+		{Name: "da-member", Da: canary.Da},
+		{Method: "Local"},
+	} {
+		invalid.Local.Dir = t.TempDir()
+		GlobalConfig.Report.Identities = []IdentityConfig{invalid}
+		if _, err := LoadIdentities(&arguments); err == nil {
+			t.Fatalf("Expected the identity %+v to be rejected", invalid)
+		}
+	}
+	canary.Queue.Path = filepath.Join(t.TempDir(), "queue.json")
+	shared := canary
+	shared.Name = "another-member"
+	GlobalConfig.Report.Identities = []IdentityConfig{canary, shared}
+	if _, err := LoadIdentities(&arguments); err == nil {
+		t.Fatal("Expected the shared queue to be rejected")
+	}
+
+	GlobalConfig.Report.Identities = []IdentityConfig{canary}
+	if Identities, err = LoadIdentities(&arguments); err != nil {
+		t.Fatal(err)
+	}
+	latest := committee.LatestState(queue)
+	history := make(map[string]checkpoint.UploadRecord)
+	publishCheckpoints(&arguments, []*stateless.DiffState{&latest}, history, latest.Height, false, false)
+	// The checkpoints already published aren't published again.
+	publishCheckpoints(&arguments, []*stateless.DiffState{&latest}, history, latest.Height, false, false)
+
+	read := func(dir string) checkpoint.Checkpoint {
+		files, err := os.ReadDir(dir)
+		if err != nil || len(files) != 1 {
+			t.Fatalf("Expected a checkpoint in %s, got %d files: %v", dir, len(files), err)
+		}
+		bytes, err := os.ReadFile(filepath.Join(dir, files[0].Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var c checkpoint.Checkpoint
+		if err := json.Unmarshal(bytes, &c); err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+	primary, other := read(GlobalConfig.Report.Local.Dir), read(canary.Local.Dir)
+	if primary.Name != "mainnet-member" || primary.SignatureScheme != "" {
+		t.Fatalf("Unexpected checkpoint of the service %+v", primary)
+	}
+	if other.Name != "canary-member" || other.Commitment != primary.Commitment || other.Height != primary.Height {
+		t.Fatalf("Unexpected checkpoint of the canary %+v", other)
+	}
+	if err := other.VerifySignature(); err != nil {
+		t.Fatalf("Expected the checkpoint of the canary to be signed by its key: %v", err)
+	}
+	if Identities[0].Queue.Len("Local/canary-member") != 0 || CheckpointQueue.Len("Local") != 0 {
+		t.Fatal("Expected the queues to be drained")
+	}
+}
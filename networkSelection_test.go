@@ -0,0 +1,69 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/RiemaLabs/modular-indexer-committee/checkpoint"
+	"github.com/RiemaLabs/modular-indexer-committee/ord"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/brc20"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+func Test_NetworkSelection(t *testing.T) {
+	saved := GlobalConfig
+	t.Cleanup(func() {
+		GlobalConfig = saved
+		ord.IndexedNetwork = ord.Mainnet
+		brc20.SelfMintEnableHeight = ord.Mainnet.SelfMintHeight()
+	})
+
+	// The getters read the indexed network unless set, and never another one.
+	GlobalConfig.Network = "testnet"
+	GlobalConfig.Bitcoind.Enabled = true
+	GlobalConfig.Bitcoind.Network = ""
+	GlobalConfig.Report.Da.NamespaceID = "0x1"
+	GlobalConfig.Report.Da.Namespaces = map[string]string{"signet": "0x2"}
+	if err := SelectNetwork("signet"); err != nil {
+		t.Fatal(err)
+	}
+	if ord.IndexedNetwork != ord.Signet || GlobalConfig.Bitcoind.Network != "signet" || DANamespaceID() != "0x2" {
+		t.Fatalf("Unexpected network %s of bitcoind %s in the namespace %s", ord.IndexedNetwork, GlobalConfig.Bitcoind.Network, DANamespaceID())
+	}
+	if ord.IndexedNetwork.GenesisHeight() != 112402 {
+		t.Fatalf("Unexpected genesis height %d of the signet", ord.IndexedNetwork.GenesisHeight())
+	}
+	// The self-mint ticks are deployed from the first inscription block rather than the mainnet activation.
+	if brc20.SelfMintEnableHeight != 112402 {
+		t.Fatalf("Unexpected self-mint height %d of the signet", brc20.SelfMintEnableHeight)
+	}
+	GlobalConfig.Bitcoind.Network = "mainnet"
+	if err := SelectNetwork("regtest"); err == nil || ord.IndexedNetwork != ord.Signet {
+		t.Fatalf("Expected the network of bitcoind to be rejected: %v", err)
+	}
+	if err := SelectNetwork("litecoin"); err == nil {
+		t.Fatal("Expected the unknown network to be rejected")
+	}
+
+	// The checkpoints of the signet are tagged under the signature, while the ones of the mainnet are unchanged.
+	signer, err := checkpoint.NewSigner(checkpoint.SchemeEd25519, strings.Repeat("3c", 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	state := &stateless.DiffState{Height: 200000, Hash: "00000000000000000003"}
+	c := newCheckpoint(NewRuntimeArguments(), state)
+	if err := c.Sign(signer); err != nil {
+		t.Fatal(err)
+	}
+	if c.Network != "signet" || c.CheckNetwork("signet") != nil || c.CheckNetwork("") == nil {
+		t.Fatalf("Unexpected network %q of the checkpoint", c.Network)
+	}
+	c.Network = ""
+	if err := c.VerifySignature(); err == nil {
+		t.Fatal("Expected the signature to cover the network")
+	}
+	ord.IndexedNetwork = ord.Mainnet
+	if c := newCheckpoint(NewRuntimeArguments(), state); c.Network != "" || c.CheckNetwork("") != nil {
+		t.Fatalf("Unexpected network %q of the mainnet checkpoint", c.Network)
+	}
+}
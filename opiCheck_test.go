@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RiemaLabs/modular-indexer-committee/apis"
+	"github.com/RiemaLabs/modular-indexer-committee/crosscheck"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/getter"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+func Test_OPICheck(t *testing.T) {
+	sha := func(s string) string {
+		sum := sha256.Sum256([]byte(s))
+		return hex.EncodeToString(sum[:])
+	}
+	deployID, mintID := strings.Repeat("c", 64)+"i0", strings.Repeat("c", 64)+"i1"
+	// The events of the blocks as OPI writes them.
+	blocks := map[uint]string{
+		800001: "deploy-inscribe;" + deployID + ";" + deployerPkscript + ";opic;OPIC;100.00;2;10.00;false",
+		800002: "mint-inscribe;" + mintID + ";" + successorPkscript + ";opic;opic;2.50;",
+	}
+	cumulative := map[uint]string{800000: sha("seed")}
+	cumulative[800001] = sha(cumulative[800000] + sha(blocks[800001]))
+	cumulative[800002] = sha(cumulative[800001] + sha(blocks[800002]))
+	opi := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var height uint
+		fmt.Sscan(r.URL.Query().Get("block_height"), &height)
+		if _, ok := cumulative[height]; !ok {
+			json.NewEncoder(w).Encode(map[string]any{"error": "block not indexed", "result": nil})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"error": nil, "result": map[string]any{
+			"block_height": height, "block_event_hash": sha(blocks[height]), "cumulative_event_hash": cumulative[height],
+		}})
+	}))
+	defer opi.Close()
+
+	checker, err := crosscheck.NewOPI(crosscheck.OPIConfig{Enabled: true, URL: opi.URL, Wait: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go checker.Run(ctx)
+	stateless.OPICheck = checker
+	defer func() {
+		stateless.OPICheck = nil
+	}()
+
+	g := &blocksGetter{
+		blocks: map[uint][]getter.OrdTransfer{
+			800001: {inscribe(deployID, deployerPkscript, "", `{"p":"brc-20","op":"deploy","tick":"OPIC","max":"100","lim":"10","dec":"2"}`)},
+			800002: {inscribe(mintID, successorPkscript, "", `{"p":"brc-20","op":"mint","tick":"opic","amt":"2.5"}`)},
+		},
+		hashes: make(map[uint]string),
+	}
+	header := stateless.LoadHeader(false, 800000)
+	queue, err := stateless.NewQueues(g, header, true, 800001)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for checker.At(800002) == nil {
+		time.Sleep(10 * time.Millisecond)
+	}
+	r := apis.NewRouter(queue, "brc-20", false, false)
+	for _, height := range []uint{800001, 800002} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/v1/opicheck?height=%d", height), nil))
+		var resp apis.OPICheckResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || w.Code != http.StatusOK {
+			t.Fatalf("Unexpected response %d: %s", w.Code, w.Body.String())
+		}
+		if resp.Result.Status.Result != crosscheck.ResultAgree || resp.Result.Divergence != nil {
+			t.Fatalf("Expected the block %d to agree with OPI, got %s", height, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,173 @@
+// Package bitmap indexes the Bitmap districts: the first valid inscription of "<N>.bitmap" claims the district of
+// the block N, and the district follows the inscription as it's transferred. The districts are kept in the namespace
+// of the protocol in the state, so they are committed by the checkpoints along with BRC-20.
+package bitmap
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	uint256 "github.com/holiman/uint256"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/protocol"
+)
+
+// The name of the protocol, which namespaces its keys.
+const Name = "bitmap"
+
+// The suffix of the content of a claim.
+const Suffix = ".bitmap"
+
+// The max length of an owner in bytes, so that it fits the slots of its key.
+const MaxOwnerLength = 4096
+
+type Config struct {
+	Enabled bool `json:"enabled"`
+	// The height from which the districts are claimed, the inscriptions before it are ignored.
+	ActivationHeight uint `json:"activationHeight"`
+}
+
+// The height from which the districts are claimed, set by Configure. Re-executions of the committee must use the same.
+var ActivationHeight uint
+
+// Configure sets the rules of the execution from the config.
+func Configure(cfg Config) {
+	ActivationHeight = cfg.ActivationHeight
+}
+
+// handler claims the plain texts which are districts, so that the other protocols of the plain texts receive the rest.
+type handler struct{}
+
+func (handler) Exec(state protocol.KVStorage, ots []ord.OrdTransfer, blockHeight uint) {
+	Exec(state, ots, blockHeight)
+}
+
+func (handler) ActivationHeight() uint {
+	return ActivationHeight
+}
+
+func (handler) Claims(ot ord.OrdTransfer) bool {
+	_, ok := ParseDistrict(ot.Content)
+	return ok
+}
+
+// Register registers the protocol next to BRC-20.
+func Register() {
+	protocol.Register(Name, handler{}, "text/plain")
+}
+
+// District State
+// Key: Keccak256(district + "GetDistrictHash")[:StemSize] + LocationID, the district in decimal
+var DistrictExists byte = 0x00
+var DistrictInscriptionID byte = 0x01 // inscription should take 2 slots, next should start with 03
+var DistrictOwner byte = 0x03         // bytes, taking the rest of the slots
+
+func GetDistrictHash(district uint64, locationID byte) []byte {
+	return protocol.StemKey(Name, "GetDistrictHash", locationID, strconv.FormatUint(district, 10))
+}
+
+// ParseDistrict returns the district claimed by the content, false if none. The content must be exactly
+// "<N>.bitmap", where N is the height in decimal without leading zeros.
+func ParseDistrict(content []byte) (uint64, bool) {
+	number, found := strings.CutSuffix(string(content), Suffix)
+	if !found || number == "" || (len(number) > 1 && number[0] == '0') {
+		return 0, false
+	}
+	for _, c := range number {
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+	}
+	district, err := strconv.ParseUint(number, 10, 64)
+	return district, err == nil
+}
+
+// must panics on an error of the state, since the values are checked before they are written.
+func must(err error) {
+	if err != nil {
+		panic(fmt.Errorf("failed to update the districts: %w", err))
+	}
+}
+
+// Exec claims the districts of the inscriptions of the block, and moves the districts of the transferred ones
+// to their new owners. A district claims a block already mined, i.e. not above the height of the inscription.
+func Exec(state protocol.KVStorage, ots []ord.OrdTransfer, blockHeight uint) {
+	if blockHeight < ActivationHeight {
+		return
+	}
+	for _, ot := range ots {
+		// The inscriptions sent as fee don't claim or move the districts.
+		if ot.SentAsFee {
+			continue
+		}
+		district, ok := ParseDistrict(ot.Content)
+		if !ok {
+			continue
+		}
+		owner := protocol.Owner(ot.NewWallet, ot.NewPkscript)
+		if owner == "" || len(owner) > MaxOwnerLength {
+			continue
+		}
+		exists, err := state.GetUInt256(GetDistrictHash(district, DistrictExists))
+		must(err)
+		if ot.OldSatpoint == "" {
+			if !exists.IsZero() || district > uint64(blockHeight) {
+				continue // claimed already, or a block to come
+			}
+			if _, _, err := protocol.SplitInscriptionID(ot.InscriptionID); err != nil {
+				continue
+			}
+			must(state.InsertUInt256(GetDistrictHash(district, DistrictExists), uint256.NewInt(1)))
+			must(state.InsertInscriptionID(GetDistrictHash(district, DistrictInscriptionID), ot.InscriptionID))
+			must(state.InsertBytes(GetDistrictHash(district, DistrictOwner), []byte(owner)))
+			continue
+		}
+		if exists.IsZero() {
+			continue
+		}
+		inscriptionID, err := state.GetInscriptionID(GetDistrictHash(district, DistrictInscriptionID))
+		must(err)
+		if inscriptionID == ot.InscriptionID {
+			must(state.InsertBytes(GetDistrictHash(district, DistrictOwner), []byte(owner)))
+		}
+	}
+}
+
+// Record is a claimed district.
+type Record struct {
+	District      uint64 `json:"district"`
+	InscriptionID string `json:"inscriptionID"`
+	Owner         string `json:"owner"`
+}
+
+// Resolve returns the record of the district from the state of the protocol, false if it isn't claimed.
+func Resolve(state protocol.KVStorage, district uint64) (Record, bool, error) {
+	exists, err := state.GetUInt256(GetDistrictHash(district, DistrictExists))
+	if err != nil || exists.IsZero() {
+		return Record{}, false, err
+	}
+	inscriptionID, err := state.GetInscriptionID(GetDistrictHash(district, DistrictInscriptionID))
+	if err != nil {
+		return Record{}, false, err
+	}
+	owner, err := state.GetBytes(GetDistrictHash(district, DistrictOwner))
+	if err != nil {
+		return Record{}, false, err
+	}
+	return Record{District: district, InscriptionID: inscriptionID, Owner: string(owner)}, true, nil
+}
+
+// RecordKeys returns the keys of the record of the district in the state of the protocol, in their order.
+func RecordKeys(r Record) [][]byte {
+	keys := [][]byte{
+		GetDistrictHash(r.District, DistrictExists),
+		GetDistrictHash(r.District, DistrictInscriptionID),
+		GetDistrictHash(r.District, DistrictInscriptionID+1),
+	}
+	for i := 0; i <= (len(r.Owner)+protocol.SlotSize-1)/protocol.SlotSize; i++ {
+		keys = append(keys, GetDistrictHash(r.District, DistrictOwner+byte(i)))
+	}
+	return keys
+}
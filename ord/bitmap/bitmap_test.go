@@ -0,0 +1,93 @@
+package bitmap
+
+import (
+	"strings"
+	"testing"
+
+	uint256 "github.com/holiman/uint256"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord"
+)
+
+type memoryState map[string][]byte
+
+func (m memoryState) InsertInscriptionID(key []byte, value string) error {
+	m[string(key)] = []byte(value)
+	return nil
+}
+func (m memoryState) GetInscriptionID(key []byte) (string, error) { return string(m[string(key)]), nil }
+func (m memoryState) InsertUInt256(key []byte, value *uint256.Int) error {
+	m[string(key)] = value.Bytes()
+	return nil
+}
+func (m memoryState) GetUInt256(key []byte) (*uint256.Int, error) {
+	return uint256.NewInt(0).SetBytes(m[string(key)]), nil
+}
+func (m memoryState) InsertBytes(key []byte, value []byte) error {
+	m[string(key)] = value
+	return nil
+}
+func (m memoryState) GetBytes(key []byte) ([]byte, error) { return m[string(key)], nil }
+func (m memoryState) GetHeight() uint                     { return 0 }
+func (m memoryState) Delete(key []byte) error {
+	delete(m, string(key))
+	return nil
+}
+func (m memoryState) Range(prefix []byte, fn func(key []byte, value []byte) bool) error {
+	return nil
+}
+
+func TestParseDistrict(t *testing.T) {
+	for content, expected := range map[string]int64{
+		"0.bitmap":      0,
+		"800000.bitmap": 800000,
+		"01.bitmap":     -1,
+		".bitmap":       -1,
+		"1.bitmap\n":    -1,
+		" 1.bitmap":     -1,
+		"-1.bitmap":     -1,
+		"1e3.bitmap":    -1,
+		"1.BITMAP":      -1,
+	} {
+		district, ok := ParseDistrict([]byte(content))
+		if ok != (expected >= 0) || (ok && district != uint64(expected)) {
+			t.Fatalf("Expected %d from %q, got %d, %v", expected, content, district, ok)
+		}
+	}
+}
+
+func TestExec(t *testing.T) {
+	state := memoryState{}
+	inscribe := func(id string, wallet string, content string) ord.OrdTransfer {
+		return ord.OrdTransfer{InscriptionID: strings.Repeat(id, 64) + "i0", NewWallet: ord.Wallet(wallet), Content: []byte(content)}
+	}
+	transfer := func(id string, wallet string, content string) ord.OrdTransfer {
+		ot := inscribe(id, wallet, content)
+		ot.OldSatpoint = strings.Repeat(id, 64) + ":0:0"
+		return ot
+	}
+	Exec(state, []ord.OrdTransfer{
+		inscribe("a", "alice", "799999.bitmap"),
+		inscribe("b", "bob", "799999.bitmap"),
+		// The block to come can't be claimed yet.
+		inscribe("c", "bob", "800001.bitmap"),
+	}, 800000)
+	Exec(state, []ord.OrdTransfer{
+		inscribe("d", "carol", "800001.bitmap"),
+		transfer("b", "carol", "799999.bitmap"),
+		transfer("a", "bob", "799999.bitmap"),
+	}, 800001)
+
+	for district, expected := range map[uint64]Record{
+		799999: {District: 799999, InscriptionID: strings.Repeat("a", 64) + "i0", Owner: "bob"},
+		800001: {District: 800001, InscriptionID: strings.Repeat("d", 64) + "i0", Owner: "carol"},
+	} {
+		record, found, err := Resolve(state, district)
+		if err != nil || !found || record != expected {
+			t.Fatalf("Unexpected record %+v, %v", record, err)
+		}
+	}
+	if _, found, _ := Resolve(state, 800000); found {
+		t.Fatal("Expected the district to be unclaimed")
+	}
+}
@@ -0,0 +1,671 @@
+package brc20
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"runtime/debug"
+	"strconv"
+	"strings"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/protocol"
+	"github.com/ethereum/go-verkle"
+
+	uint256 "github.com/holiman/uint256"
+)
+
+// LocationID is used to indicate the last digit of the Key to fully utilize the characteristics of the Verkle Tree and save memory.
+type LocationID = byte
+
+type Dynamic = string
+
+// Tick+Pkscript State
+// Key: Keccak256(tick + Pkscript + "GetTickPkscriptHash")[:StemSize] + LocationID
+// Value: uint256
+var AvailableBalancePkscript LocationID = 0x00
+var OverallBalancePkscript LocationID = 0x01
+
+func GetTickPkscriptHash(tick string, Pkscript ord.Pkscript, stateID LocationID) []byte {
+	return hashStem("GetTickPkscriptHash", stateID, tick, string(Pkscript))
+}
+
+// updateBalance updates the balance by f, which may update the value read in place.
+func updateBalance(f func(*uint256.Int) *uint256.Int, state *txn, tick string, Pkscript ord.Pkscript, loc LocationID) {
+	buf := keys.Get().(*[verkle.KeySize]byte)
+	defer keys.Put(buf)
+	key := stemKey(buf, "GetTickPkscriptHash", loc, tick, string(Pkscript))
+	value := state.getUInt256(key)
+	held := !value.IsZero()
+	state.insertUInt256(key, f(value))
+	observe(state, key, CategoryBalances)
+	if loc == OverallBalancePkscript && state.counted && !state.getUInt256(GetTickHash(tick, HoldersCounted)).IsZero() {
+		countHolder(state, tick, held, !value.IsZero())
+	}
+	uint256s.Put(value)
+}
+
+// countHolder counts the pkscript in or out of the holders of the tick as its overall balance turns positive or zero.
+// Only the holders of the ticks deployed once the counters are active are counted, see HoldersCounted, since the
+// holders from before the activation can't be told from the others.
+func countHolder(state *txn, tick string, held bool, holds bool) {
+	switch {
+	case !held && holds:
+		updateTickState(func(v *uint256.Int) *uint256.Int {
+			return v.AddUint64(v, 1)
+		}, state, tick, HolderCount)
+	case held && !holds:
+		updateTickState(func(v *uint256.Int) *uint256.Int {
+			return v.SubUint64(v, 1)
+		}, state, tick, HolderCount)
+	}
+}
+
+// Available, OverallBalances
+func GetBalances(state KVStorage, tick string, Pkscript ord.Pkscript) ([]byte, []byte, *uint256.Int, *uint256.Int, error) {
+	key0 := GetTickPkscriptHash(tick, Pkscript, AvailableBalancePkscript)
+	key1 := GetTickPkscriptHash(tick, Pkscript, OverallBalancePkscript)
+	value0, err := state.GetUInt256(key0)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	value1, err := state.GetUInt256(key1)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	return key0, key1, value0, value1, nil
+}
+
+// Tick State
+// Key: Keccak256(tick + "GetTickHash")[:StemSize] + LocationID
+// Value: uint256
+var Exists LocationID = 0x00
+var RemainingSupply LocationID = 0x01
+var MaxSupply LocationID = 0x02
+var LimitPerMint LocationID = 0x03
+var Decimals LocationID = 0x04
+var IsSelfMint LocationID = 0x05
+var InscriptionID LocationID = 0x06 // inscription should take 2 slots, next should start with 08
+
+// The authority to mint a self-mint tick, once it has been transferred away from the deploy inscription.
+var MintAuthorityExists LocationID = 0x08
+var MintAuthority LocationID = 0x09 // inscription should take 2 slots, next should start with 0b
+
+// The cumulative amount burned by the transfers to the unspendable outputs.
+var BurnedSupply LocationID = 0x0b
+
+// The counters maintained by the execution from their activation height: the number of the pkscripts holding
+// a positive overall balance, the number of the valid mints, and the amount minted by them.
+var HolderCount LocationID = 0x0c
+var MintCount LocationID = 0x0d
+var MintedAmount LocationID = 0x0e
+
+// 1 if the holders of the tick are counted, i.e. the tick is deployed, or bootstrapped, once the counters are active.
+var HoldersCounted LocationID = 0x0f
+
+func GetTickHash(tick string, locationID LocationID) []byte {
+	return hashStem("GetTickHash", locationID, tick)
+}
+
+// HolderCount, MintCount, MintedAmount, along with their keys
+func GetTickCounters(state KVStorage, tick string) ([][]byte, *uint256.Int, *uint256.Int, *uint256.Int, error) {
+	keys := [][]byte{GetTickHash(tick, HolderCount), GetTickHash(tick, MintCount), GetTickHash(tick, MintedAmount)}
+	values := make([]*uint256.Int, len(keys))
+	for i, key := range keys {
+		value, err := state.GetUInt256(key)
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
+		values[i] = value
+	}
+	return keys, values[0], values[1], values[2], nil
+}
+
+// getTickStatus returns the keys of the status of the tick, sharing a single buffer.
+func getTickStatus(tick string) ([]byte, []byte, []byte, []byte, []byte, []byte, []byte) {
+	buf := new([7][verkle.KeySize]byte)
+	key := func(i int, loc LocationID) []byte { return stemKey(&buf[i], "GetTickHash", loc, tick) }
+	return key(0, Exists), key(1, RemainingSupply), key(2, MaxSupply), key(3, LimitPerMint), key(4, Decimals), key(5, InscriptionID), key(6, IsSelfMint)
+}
+
+// updateTickState updates the state of the tick by f, which may update the value read in place.
+func updateTickState(f func(*uint256.Int) *uint256.Int, state *txn, tick string, loc LocationID) {
+	buf := keys.Get().(*[verkle.KeySize]byte)
+	defer keys.Put(buf)
+	key := stemKey(buf, "GetTickHash", loc, tick)
+	value := state.getUInt256(key)
+	state.insertUInt256(key, f(value))
+	observe(state, key, CategoryTicks)
+	uint256s.Put(value)
+}
+
+// Wallet State
+// Key: Keccak256(wallet + "GetWalletHash")[:StemSize] + LocationID
+// Value: []byte (Less than 1534 bytes)
+var WalletLatestPkscript LocationID = 0x00
+
+func GetWalletHash(wallet string, locationID LocationID) []byte {
+	return hashStem("GetWalletHash", locationID, wallet)
+}
+
+func updateLatestPkscript(state *txn, wallet ord.Wallet, Pkscript ord.Pkscript) {
+	key := GetWalletHash(string(wallet), WalletLatestPkscript)
+	value := string(Pkscript)
+	bytes, err := hex.DecodeString(value)
+	if err != nil {
+		state.fail(fmt.Errorf("error decoding Pkscript: %v", err))
+		return
+	}
+	state.insertBytes(key, bytes)
+	observe(state, key, CategoryWallets)
+}
+
+func GetLatestPkscript(state KVStorage, wallet string) ([]byte, string, error) {
+	key := GetWalletHash(wallet, WalletLatestPkscript)
+	value, err := state.GetBytes(key)
+	if err != nil {
+		return nil, "", err
+	}
+	return key, hex.EncodeToString(value), nil
+}
+
+// TODO: High. Flush to the disk.
+// Inscription Event State
+// Key: Keccak256(inscriptionID + "GetEventHash")[:StemSize] + LocationID
+// Value: uint256
+var TransferInscribeCount LocationID = 0x0
+var TransferTransferCount LocationID = 0x1
+
+// Value: []byte (Less than 34 bytes, Next slot is 0x2 + 1 + (34 / 32) + 1 = 0x5)
+var TransferInscribeSourceWallet LocationID = 0x2
+
+// Value: []byte (Less than 1534 bytes)
+var TransferInscribeSourcePkscript LocationID = 0x5
+
+func GetEventHash(inscriptionID string, locationID LocationID) []byte {
+	return hashStem("GetEventHash", locationID, inscriptionID)
+}
+
+func updateWalletAndPkscript(state *txn, inscriptionID string, wallet ord.Wallet, Pkscript ord.Pkscript) {
+	walletKey := GetEventHash(inscriptionID, TransferInscribeSourceWallet)
+	walletBytes := decodeBitcoinWallet(string(wallet))
+	state.insertBytes(walletKey, walletBytes)
+
+	PkscriptKey := GetEventHash(inscriptionID, TransferInscribeSourcePkscript)
+	PkscriptBytes, err := hex.DecodeString(string(Pkscript))
+	if err != nil {
+		state.fail(fmt.Errorf("error decoding Pkscript: %v", err))
+		return
+	}
+	state.insertBytes(PkscriptKey, PkscriptBytes)
+	observe(state, PkscriptKey, CategoryEvents)
+}
+
+func getWalletAndPkscript(state *txn, inscriptionID string) (ord.Wallet, ord.Pkscript) {
+	walletKey := GetEventHash(inscriptionID, TransferInscribeSourceWallet)
+	walletBytes := state.getBytes(walletKey)
+	wallet := encodeBitcoinWallet(walletBytes)
+	PkscriptKey := GetEventHash(inscriptionID, TransferInscribeSourcePkscript)
+	PkscriptBytes := state.getBytes(PkscriptKey)
+	Pkscript := hex.EncodeToString(PkscriptBytes)
+	return ord.Wallet(wallet), ord.Pkscript(Pkscript)
+}
+
+func getEventCounts(state *txn, inscriptionID string) (*uint256.Int, *uint256.Int) {
+	key0 := GetEventHash(inscriptionID, TransferInscribeCount)
+	key1 := GetEventHash(inscriptionID, TransferTransferCount)
+	value0 := state.getUInt256(key0)
+	value1 := state.getUInt256(key1)
+	return value0, value1
+}
+
+// countEvent increments the count of the events of the inscription at the location.
+func countEvent(state *txn, inscriptionID string, loc LocationID) {
+	updateEventState(func(v *uint256.Int) *uint256.Int { return v.AddUint64(v, 1) }, state, inscriptionID, loc)
+}
+
+// updateEventState updates the state of the event by f, which may update the value read in place.
+func updateEventState(f func(*uint256.Int) *uint256.Int, state *txn, inscriptionID string, loc LocationID) {
+	buf := keys.Get().(*[verkle.KeySize]byte)
+	defer keys.Put(buf)
+	key := stemKey(buf, "GetEventHash", loc, inscriptionID)
+	value := state.getUInt256(key)
+	state.insertUInt256(key, f(value))
+	observe(state, key, CategoryEvents)
+	uint256s.Put(value)
+}
+
+// BRC-20 Computation
+func isUsedOrInvalid(state *txn, inscriptionID string) bool {
+	transferInscribeCount, transferTransferCount := getEventCounts(state, inscriptionID)
+	return !transferInscribeCount.Eq(uint256.NewInt(1)) || !transferTransferCount.Eq(uint256.NewInt(0))
+}
+
+func deployInscribe(state *txn, inscriptionID string, tick string, maxSupply *uint256.Int, decimals *uint256.Int, limitPerMint *uint256.Int, isSelfMint string) {
+	keyExists, keyRemainingSupply, keyMaxSupply, keyLimitPerMint, keyDecimals, keyInscriptionID, keyIsSelfMint := getTickStatus(tick)
+	state.insertUInt256(keyExists, uint256.NewInt(1))
+	state.insertUInt256(keyRemainingSupply, maxSupply)
+	state.insertUInt256(keyMaxSupply, maxSupply)
+	state.insertUInt256(keyDecimals, decimals)
+	state.insertUInt256(keyLimitPerMint, limitPerMint)
+
+	if isSelfMint == "true" {
+		state.insertUInt256(keyIsSelfMint, uint256.NewInt(1))
+	} else {
+		state.insertUInt256(keyIsSelfMint, uint256.NewInt(0))
+	}
+
+	// state.insertBytes(keyInscriptionID, inscriptionIDBytes)
+	state.insertInscriptionID(keyInscriptionID, inscriptionID)
+	if state.counted {
+		state.insertUInt256(GetTickHash(tick, HoldersCounted), uint256.NewInt(1))
+	}
+	observe(state, keyExists, CategoryTicks)
+	observeTick(state, tick, true)
+}
+
+// mintAuthority returns the inscription whose children may mint the self-mint tick,
+// which is the deploy inscription unless the authority has been transferred.
+func mintAuthority(state *txn, tick string, deployID string) string {
+	if state.getUInt256(GetTickHash(tick, MintAuthorityExists)).IsZero() {
+		return deployID
+	}
+	return state.getInscriptionID(GetTickHash(tick, MintAuthority))
+}
+
+func authorityInscribe(state *txn, tick string, inscriptionID string) {
+	keyAuthorityExists := GetTickHash(tick, MintAuthorityExists)
+	state.insertUInt256(keyAuthorityExists, uint256.NewInt(1))
+	state.insertInscriptionID(GetTickHash(tick, MintAuthority), inscriptionID)
+	observe(state, keyAuthorityExists, CategoryTicks)
+}
+
+func mintInscribe(state *txn, newPkscript ord.Pkscript, newWallet ord.Wallet, tick string, amount *uint256.Int) {
+	// update balances
+	f_add := func(v *uint256.Int) *uint256.Int {
+		return v.Add(v, amount)
+	}
+
+	updateBalance(f_add, state, tick, newPkscript, AvailableBalancePkscript)
+	updateBalance(f_add, state, tick, newPkscript, OverallBalancePkscript)
+
+	f_sub := func(v *uint256.Int) *uint256.Int {
+		return v.Sub(v, amount)
+	}
+	updateTickState(f_sub, state, tick, RemainingSupply)
+	if state.counted {
+		updateTickState(func(v *uint256.Int) *uint256.Int {
+			return v.AddUint64(v, 1)
+		}, state, tick, MintCount)
+		updateTickState(f_add, state, tick, MintedAmount)
+	}
+	updateLatestPkscript(state, newWallet, newPkscript)
+	observeTick(state, tick, false)
+	observeHolder(state, tick, newPkscript)
+}
+
+func transferInscribe(state *txn, inscriptionID string, sourcePkscript ord.Pkscript, sourceWallet ord.Wallet, tick string, amount *uint256.Int) {
+	f_sub := func(v *uint256.Int) *uint256.Int {
+		return v.Sub(v, amount)
+	}
+	updateBalance(f_sub, state, tick, sourcePkscript, AvailableBalancePkscript)
+	updateLatestPkscript(state, sourceWallet, sourcePkscript)
+
+	// store transfer-inscribe event
+	updateWalletAndPkscript(state, inscriptionID, sourceWallet, sourcePkscript)
+
+	// update transfer-inscribe event count
+	countEvent(state, inscriptionID, TransferInscribeCount)
+}
+
+// transferTransferSpendToFee returns the transfer to its source, which is returned as well.
+func transferTransferSpendToFee(state *txn, inscriptionID string, tick string, amount *uint256.Int) (ord.Wallet, ord.Pkscript) {
+	sourceWallet, sourcePkscript := getWalletAndPkscript(state, inscriptionID)
+	f_add := func(v *uint256.Int) *uint256.Int {
+		return v.Add(v, amount)
+	}
+	updateBalance(f_add, state, tick, sourcePkscript, AvailableBalancePkscript)
+	updateLatestPkscript(state, sourceWallet, sourcePkscript)
+
+	// update transfer-transfer event count
+	countEvent(state, inscriptionID, TransferTransferCount)
+	return sourceWallet, sourcePkscript
+}
+
+// transferTransferNormal moves the transfer from its source, which is returned, to the spent pkscript.
+func transferTransferNormal(state *txn, inscriptionID string, spentPkscript ord.Pkscript, spentWallet ord.Wallet, tick string, amount *uint256.Int) (ord.Wallet, ord.Pkscript) {
+	sourceWallet, sourcePkscript := getWalletAndPkscript(state, inscriptionID)
+	f_sub := func(v *uint256.Int) *uint256.Int {
+		return v.Sub(v, amount)
+	}
+	updateBalance(f_sub, state, tick, sourcePkscript, OverallBalancePkscript)
+
+	// Don't worry about sourcePkscript == spentPkscript.
+	// The update read the value from the storage again.
+	f_add := func(v *uint256.Int) *uint256.Int {
+		return v.Add(v, amount)
+	}
+	updateBalance(f_add, state, tick, spentPkscript, AvailableBalancePkscript)
+	updateBalance(f_add, state, tick, spentPkscript, OverallBalancePkscript)
+	updateLatestPkscript(state, sourceWallet, sourcePkscript)
+	updateLatestPkscript(state, spentWallet, spentPkscript)
+	observeHolder(state, tick, sourcePkscript)
+	observeHolder(state, tick, spentPkscript)
+
+	// update transfer-transfer event count
+	countEvent(state, inscriptionID, TransferTransferCount)
+	return sourceWallet, sourcePkscript
+}
+
+// transferTransferBurn burns the transfer from its source, which is returned, since it's spent to an unspendable
+// output. The burned amount leaves the supply rather than crediting the output.
+func transferTransferBurn(state *txn, inscriptionID string, tick string, amount *uint256.Int) (ord.Wallet, ord.Pkscript) {
+	sourceWallet, sourcePkscript := getWalletAndPkscript(state, inscriptionID)
+	f_sub := func(v *uint256.Int) *uint256.Int {
+		return v.Sub(v, amount)
+	}
+	updateBalance(f_sub, state, tick, sourcePkscript, OverallBalancePkscript)
+	f_add := func(v *uint256.Int) *uint256.Int {
+		return v.Add(v, amount)
+	}
+	updateTickState(f_add, state, tick, BurnedSupply)
+	updateLatestPkscript(state, sourceWallet, sourcePkscript)
+	observeHolder(state, tick, sourcePkscript)
+
+	// update transfer-transfer event count
+	countEvent(state, inscriptionID, TransferTransferCount)
+	return sourceWallet, sourcePkscript
+}
+
+// The name of the protocol, which receives the transfers not claimed by the other protocols.
+const Name = "brc-20"
+
+func init() {
+	protocol.RegisterDefault(Name, protocol.HandlerFunc(Exec))
+}
+
+// Input previous verkle tree and all ord records in a block, then get the K-V array that the verkle tree should update
+func Exec(state KVStorage, ots []ord.OrdTransfer, blockHeight uint) {
+	if state.GetHeight() != blockHeight-1 {
+		panic(fmt.Errorf("mismatched state header: %d and block height: %d", state.GetHeight(), blockHeight-1))
+	}
+	beginBlock(blockHeight)
+	upperLimit := getLimit()
+	for _, ot := range ots {
+		t := newTxn(state)
+		t.counted = countersEnabled(blockHeight)
+		execTransfer(t, ot, blockHeight, upperLimit)
+		if err := t.commit(); err != nil {
+			// A failure of the rules skips the transfer, which all the nodes do alike, while a failure of the storage
+			// is panicked as a protocol.StorageError by the commit and aborts the block.
+			if o, ok := state.(FailureObserver); ok {
+				o.ObserveFailure(ot, blockHeight, err)
+			}
+		}
+		t.release()
+	}
+}
+
+// execTransfer executes a transfer on its own txn, which keeps the failure of the transfer, including a panic of the
+// rules. A protocol.StorageError is panicked again, which aborts the block.
+func execTransfer(state *txn, ot ord.OrdTransfer, blockHeight uint, upperLimit *uint256.Int) {
+	defer func() {
+		if r := recover(); r != nil {
+			if _, storage := r.(*protocol.StorageError); storage {
+				panic(r)
+			}
+			state.fail(&PanicError{Value: r, Stack: debug.Stack()})
+		}
+	}()
+	inscriptionID, oldSatpoint, newPkscript, newWallet, sentAsFee, content, contentType, parentID :=
+		ot.InscriptionID, ot.OldSatpoint, ot.NewPkscript, ot.NewWallet, ot.SentAsFee, ot.Content, ot.ContentType, ot.ParentID
+	if !acceptContent(content) {
+		return // oversized or pathological content
+	}
+	if sentAsFee && oldSatpoint == "" {
+		return // inscribed as fee
+	}
+	if contentType == "" {
+		return // invalid inscription
+	}
+	decodedBytes, err := hex.DecodeString(contentType)
+	if err == nil {
+		contentType = string(decodedBytes)
+	}
+	contentType = strings.Split(contentType, ";")[0]
+	if contentType != "application/json" && contentType != "text/plain" {
+		return // invalid inscription
+	}
+	// The content is parsed once the inscription may be a BRC-20 one, so the images and such cost nothing.
+	var js map[string]string
+	protocol.Timed(state, "parse."+Name, func() { js = ParseContent(content, blockHeight) })
+	tick, ok := js["tick"]
+	if !ok {
+		return // invalid inscription
+	}
+	if _, ok := js["op"]; !ok {
+		return // invalid inscription
+	}
+	originalTick := tick
+	tick = NormalizeTick(tick)
+	if !Ticks.Valid(tick) {
+		return // invalid tick
+	}
+	// The events keep the tick as inscribed only if it differs from the folded one.
+	if originalTick == tick {
+		originalTick = ""
+	}
+
+	// handle deploy
+	if js["op"] == "deploy" && oldSatpoint == "" {
+		maxSupplyValue, ok := js["max"]
+		if !ok {
+			return // invalid inscription
+		}
+		keyExists, _, _, _, _, _, _ := getTickStatus(tick)
+		tickExists := state.getUInt256(keyExists)
+		if !tickExists.Eq(uint256.NewInt(0)) {
+			return // already deployed
+		}
+		decimals := uint256.NewInt(18)
+		if decValue, ok := js["dec"]; ok {
+			if !isPositiveNumber(decValue, false) {
+				return // invalid decimals
+			} else {
+				decimalsInt, err := strconv.Atoi(decValue)
+				if err != nil {
+					return
+				}
+				decimals, _ = uint256.FromBig(big.NewInt(int64(decimalsInt)))
+			}
+		}
+		if decimals.Gt(uint256.NewInt(18)) {
+			return // invalid decimals
+		}
+		var maxSupply *uint256.Int
+		if !isPositiveNumberWithDot(maxSupplyValue, false) {
+			return
+		} else {
+			maxSupply, err = getNumberExtendedTo18Decimals(maxSupplyValue, decimals, false)
+			if err != nil || maxSupply == nil {
+				return // invalid max supply
+			}
+			if maxSupply.Gt(upperLimit) || maxSupply.IsZero() {
+				return // invalid max supply
+			}
+		}
+		limitPerMint := maxSupply
+		if lim, ok := js["lim"]; ok {
+			if !ok {
+				return
+			}
+			if !isPositiveNumberWithDot(lim, false) {
+				return // invalid limit per mint
+			} else {
+				limitPerMint, err = getNumberExtendedTo18Decimals(lim, decimals, false)
+				if err != nil || limitPerMint == nil {
+					return // invalid limit per mint
+				}
+				if limitPerMint.Gt(upperLimit) || limitPerMint.IsZero() {
+					return // invalid limit per mint
+				}
+			}
+		}
+		isSelfMint := "false"
+		if Ticks.Len(tick) == 5 {
+			if blockHeight < SelfMintEnableHeight {
+				return // self-mint not enabled yet
+			}
+			if _, ok := js["self_mint"]; !ok {
+				return // invalid inscription
+			}
+			if js["self_mint"] != "true" {
+				return // invalid inscription
+			}
+			isSelfMint = "true"
+			if maxSupply.IsZero() {
+				maxSupply = upperLimit
+				if limitPerMint.IsZero() {
+					limitPerMint = upperLimit
+				}
+			}
+		} // this is a self-mint token
+		if maxSupply.IsZero() {
+			return // invalid max supply
+		}
+		if !allowDeploy(tick, isSelfMint == "true", blockHeight) {
+			return // rejected by the deploy policies
+		}
+		deployInscribe(state, inscriptionID, tick, maxSupply, decimals, limitPerMint, isSelfMint)
+		observeDeploy(state, tick, newPkscript, newWallet)
+		emit(state, DeployEvent{
+			InscriptionID: inscriptionID,
+			Tick:          tick,
+			OriginalTick:  originalTick,
+			Pkscript:      newPkscript,
+			Wallet:        newWallet,
+			MaxSupply:     maxSupply.Dec(),
+			LimitPerMint:  limitPerMint.Dec(),
+			Decimals:      decimals.Uint64(),
+			SelfMint:      isSelfMint == "true",
+		})
+	}
+
+	// handle mint
+	if js["op"] == "mint" && oldSatpoint == "" {
+		amountString, ok := js["amt"]
+		if !ok {
+			return // invalid inscription
+		}
+		keyExists, keyRemainingSupply, _, keyLimitPerMint, keyDecimals, keyInscriptionID, keyIsSelfMint := getTickStatus(tick)
+		tickExists := state.getUInt256(keyExists)
+		if tickExists.Eq(uint256.NewInt(0)) {
+			return // not deployed
+		}
+		remainingSupply := state.getUInt256(keyRemainingSupply)
+		limitPerMint := state.getUInt256(keyLimitPerMint)
+		decimals := state.getUInt256(keyDecimals)
+		if !isPositiveNumberWithDot(amountString, false) {
+			return // invalid amount
+		}
+		amount, err := getNumberExtendedTo18Decimals(amountString, decimals, false)
+		if err != nil || amount == nil {
+			return // invalid amount
+		}
+		if amount.Gt(upperLimit) || amount.IsZero() {
+			return // invalid amount
+		}
+		if remainingSupply.IsZero() {
+			return // mint ended
+		}
+		if limitPerMint != nil && amount.Gt(limitPerMint) {
+			return // mint too much
+		}
+		if amount.Gt(remainingSupply) {
+			amount.Set(remainingSupply) // mint remaining token
+		}
+		isSelfMint := state.getUInt256(keyIsSelfMint)
+		tickParentID := state.getInscriptionID(keyInscriptionID)
+		if isSelfMint.Eq(uint256.NewInt(1)) {
+			if authorityTransferEnabled(blockHeight) {
+				tickParentID = mintAuthority(state, tick, tickParentID)
+			}
+			if tickParentID != parentID {
+				return
+			}
+		}
+		mintInscribe(state, newPkscript, newWallet, tick, amount)
+		event := MintEvent{InscriptionID: inscriptionID, Tick: tick, OriginalTick: originalTick, Pkscript: newPkscript, Wallet: newWallet, Amount: amount.Dec()}
+		if isSelfMint.Eq(uint256.NewInt(1)) {
+			event.ParentID = parentID
+		}
+		emit(state, event)
+	}
+
+	// handle authority transfer
+	// The current authority of a self-mint tick signs it over by parenting the new authority inscription,
+	// whose holder authorizes the later mints by parenting them in turn.
+	if js["op"] == "authority" && oldSatpoint == "" && authorityTransferEnabled(blockHeight) {
+		keyExists, _, _, _, _, keyInscriptionID, keyIsSelfMint := getTickStatus(tick)
+		if state.getUInt256(keyExists).IsZero() {
+			return // not deployed
+		}
+		if !state.getUInt256(keyIsSelfMint).Eq(uint256.NewInt(1)) {
+			return // not a self-mint tick
+		}
+		if mintAuthority(state, tick, state.getInscriptionID(keyInscriptionID)) != parentID {
+			return // not signed by the current authority
+		}
+		authorityInscribe(state, tick, inscriptionID)
+	}
+
+	// handle transfer
+	if js["op"] == "transfer" {
+		amountString, ok := js["amt"]
+		if !ok {
+			return // invalid inscription
+		}
+		keyExists, _, _, _, keyDecimals, _, _ := getTickStatus(tick)
+		tickExists := state.getUInt256(keyExists)
+		if tickExists.Eq(uint256.NewInt(0)) {
+			return // not deployed
+		}
+		deicmals := state.getUInt256(keyDecimals)
+		if !isPositiveNumberWithDot(amountString, false) {
+			return // invalid amount
+		}
+		amount, err := getNumberExtendedTo18Decimals(amountString, deicmals, false)
+		if err != nil || amount == nil {
+			return // invalid amount
+		}
+		if amount.Gt(upperLimit) || amount.IsZero() {
+			return // invalid amount
+		}
+		// check if available balance is enough
+		if oldSatpoint == "" {
+			availableBalance := state.getUInt256(GetTickPkscriptHash(tick, newPkscript, AvailableBalancePkscript))
+
+			if availableBalance.Lt(amount) {
+				return // not enough available balance
+			} else {
+				transferInscribe(state, inscriptionID, newPkscript, newWallet, tick, amount)
+				emit(state, TransferInscribeEvent{InscriptionID: inscriptionID, Tick: tick, OriginalTick: originalTick, Pkscript: newPkscript, Wallet: newWallet, Amount: amount.Dec()})
+			}
+		} else {
+			if isUsedOrInvalid(state, inscriptionID) {
+				return // already used or invalid
+			}
+			event := TransferTransferEvent{InscriptionID: inscriptionID, Tick: tick, OriginalTick: originalTick, Amount: amount.Dec(), SentAsFee: sentAsFee}
+			if sentAsFee {
+				event.SourceWallet, event.SourcePkscript = transferTransferSpendToFee(state, inscriptionID, tick, amount)
+			} else if burnEnabled(blockHeight) && IsUnspendable(newPkscript) {
+				event.SourceWallet, event.SourcePkscript = transferTransferBurn(state, inscriptionID, tick, amount)
+				event.SpentPkscript, event.SpentWallet, event.Burned = newPkscript, newWallet, true
+			} else {
+				event.SourceWallet, event.SourcePkscript = transferTransferNormal(state, inscriptionID, newPkscript, newWallet, tick, amount)
+				event.SpentPkscript, event.SpentWallet = newPkscript, newWallet
+			}
+			emit(state, event)
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package brc20
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	uint256 "github.com/holiman/uint256"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/protocol"
+)
+
+// memState is the state of the benchmarks, keeping the values by their types as the txn.
+type memState struct {
+	height   uint
+	uint256s map[string]uint256.Int
+	bytes    map[string][]byte
+	ids      map[string]string
+}
+
+func newMemState(height uint) *memState {
+	return &memState{height: height, uint256s: make(map[string]uint256.Int), bytes: make(map[string][]byte), ids: make(map[string]string)}
+}
+
+func (m *memState) InsertInscriptionID(key []byte, value string) error {
+	m.ids[string(key)] = value
+	return nil
+}
+
+func (m *memState) GetInscriptionID(key []byte) (string, error) {
+	return m.ids[string(key)], nil
+}
+
+func (m *memState) InsertUInt256(key []byte, value *uint256.Int) error {
+	m.uint256s[string(key)] = *value
+	return nil
+}
+
+func (m *memState) GetUInt256(key []byte) (*uint256.Int, error) {
+	value := m.uint256s[string(key)]
+	return &value, nil
+}
+
+func (m *memState) InsertBytes(key []byte, value []byte) error {
+	m.bytes[string(key)] = bytes.Clone(value)
+	return nil
+}
+
+func (m *memState) GetBytes(key []byte) ([]byte, error) {
+	return bytes.Clone(m.bytes[string(key)]), nil
+}
+
+func (m *memState) Delete(key []byte) error {
+	delete(m.uint256s, string(key))
+	delete(m.bytes, string(key))
+	delete(m.ids, string(key))
+	return nil
+}
+
+func (m *memState) Range(prefix []byte, fn func(key []byte, value []byte) bool) error {
+	return protocol.ErrUnsupported
+}
+
+func (m *memState) GetHeight() uint {
+	return m.height
+}
+
+// execBlock executes the block on the state, which moves to the block.
+func (m *memState) execBlock(ots []ord.OrdTransfer) {
+	Exec(m, ots, m.height+1)
+	m.height++
+}
+
+// The pkscripts of the benchmarks, which a hot block repeats.
+func benchPkscripts(n int) []ord.Pkscript {
+	pkscripts := make([]ord.Pkscript, n)
+	for i := range pkscripts {
+		pkscripts[i] = ord.Pkscript("0014" + strings.Repeat(fmt.Sprintf("%02x", i), 20))
+	}
+	return pkscripts
+}
+
+func benchInscribe(i int, pkscript ord.Pkscript, content string) ord.OrdTransfer {
+	return ord.OrdTransfer{
+		InscriptionID: fmt.Sprintf("%064xi0", i),
+		NewPkscript:   pkscript,
+		NewWallet:     ord.Wallet(pkscript),
+		Content:       []byte(content),
+		ContentType:   "text/plain",
+	}
+}
+
+// benchAmount is the amount of the tick of 18 decimals as stored in the state.
+func benchAmount(amount uint64) *uint256.Int {
+	return new(uint256.Int).Mul(uint256.NewInt(amount), uint256.NewInt(1_000_000_000_000_000_000))
+}
+
+func benchDeploy(state *memState, pkscript ord.Pkscript) {
+	state.execBlock([]ord.OrdTransfer{
+		benchInscribe(0, pkscript, `{"p":"brc-20","op":"deploy","tick":"bnch","max":"1000000000000","lim":"1000"}`),
+	})
+}
+
+// BenchmarkMint executes the blocks of 1000 mints to 10 pkscripts.
+func BenchmarkMint(b *testing.B) {
+	pkscripts := benchPkscripts(10)
+	state := newMemState(779999)
+	benchDeploy(state, pkscripts[0])
+	ots := make([]ord.OrdTransfer, 1000)
+	for i := range ots {
+		ots[i] = benchInscribe(i+1, pkscripts[i%len(pkscripts)], `{"p":"brc-20","op":"mint","tick":"bnch","amt":"1"}`)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		state.execBlock(ots)
+	}
+	b.StopTimer()
+	if _, _, _, overall, _ := GetBalances(state, "bnch", pkscripts[0]); !overall.Eq(benchAmount(uint64(100 * b.N))) {
+		b.Fatalf("Unexpected balance %v after %d blocks", overall, b.N)
+	}
+}
+
+// BenchmarkTransfer executes the blocks of 500 transfers inscribed and then sent between 10 pkscripts.
+func BenchmarkTransfer(b *testing.B) {
+	pkscripts := benchPkscripts(10)
+	state := newMemState(779999)
+	benchDeploy(state, pkscripts[0])
+	mints := make([]ord.OrdTransfer, 0)
+	for i, pkscript := range pkscripts {
+		mints = append(mints, benchInscribe(i+1, pkscript, `{"p":"brc-20","op":"mint","tick":"bnch","amt":"1000"}`))
+	}
+	state.execBlock(mints)
+	b.ReportAllocs()
+	b.ResetTimer()
+	next := len(pkscripts) + 1
+	for i := 0; i < b.N; i++ {
+		ots := make([]ord.OrdTransfer, 0, 1000)
+		for j := 0; j < 500; j++ {
+			inscribe := benchInscribe(next, pkscripts[j%len(pkscripts)], `{"p":"brc-20","op":"transfer","tick":"bnch","amt":"1"}`)
+			next++
+			send := inscribe
+			send.OldSatpoint = "satpoint"
+			send.NewPkscript = pkscripts[(j+1)%len(pkscripts)]
+			send.NewWallet = ord.Wallet(send.NewPkscript)
+			ots = append(ots, inscribe, send)
+		}
+		state.execBlock(ots)
+	}
+	b.StopTimer()
+	if _, _, available, overall, _ := GetBalances(state, "bnch", pkscripts[0]); !available.Eq(benchAmount(1000)) || !overall.Eq(benchAmount(1000)) {
+		b.Fatalf("Unexpected balances %v and %v after %d blocks", available, overall, b.N)
+	}
+}
+
+// failingState fails the reads by a panic and the writes by an error, if set.
+type failingState struct {
+	*memState
+	readPanic any
+	writeErr  error
+}
+
+func (f *failingState) read() {
+	if f.readPanic != nil {
+		panic(f.readPanic)
+	}
+}
+
+func (f *failingState) GetInscriptionID(key []byte) (string, error) {
+	f.read()
+	return f.memState.GetInscriptionID(key)
+}
+
+func (f *failingState) GetUInt256(key []byte) (*uint256.Int, error) {
+	f.read()
+	return f.memState.GetUInt256(key)
+}
+
+func (f *failingState) GetBytes(key []byte) ([]byte, error) {
+	f.read()
+	return f.memState.GetBytes(key)
+}
+
+func (f *failingState) InsertInscriptionID(key []byte, value string) error {
+	if f.writeErr != nil {
+		return f.writeErr
+	}
+	return f.memState.InsertInscriptionID(key, value)
+}
+
+func (f *failingState) InsertUInt256(key []byte, value *uint256.Int) error {
+	if f.writeErr != nil {
+		return f.writeErr
+	}
+	return f.memState.InsertUInt256(key, value)
+}
+
+func (f *failingState) InsertBytes(key []byte, value []byte) error {
+	if f.writeErr != nil {
+		return f.writeErr
+	}
+	return f.memState.InsertBytes(key, value)
+}
+
+func TestStorageFailure(t *testing.T) {
+	deploy := []ord.OrdTransfer{
+		benchInscribe(0, benchPkscripts(1)[0], `{"p":"brc-20","op":"deploy","tick":"fail","max":"1000","lim":"10"}`),
+	}
+	storageErr := &protocol.StorageError{Err: fmt.Errorf("disk failure")}
+	execPanic := func(state *failingState) (r any) {
+		defer func() { r = recover() }()
+		Exec(state, deploy, state.height+1)
+		return nil
+	}
+
+	// A panic of the rules skips the transfer.
+	state := &failingState{memState: newMemState(779999), readPanic: "rule failure"}
+	if r := execPanic(state); r != nil {
+		t.Fatalf("Expected the transfer to be skipped, got the panic %v", r)
+	}
+	if len(state.uint256s) != 0 || len(state.bytes) != 0 || len(state.ids) != 0 {
+		t.Fatal("Expected none of the writes of the skipped transfer")
+	}
+
+	// A failing storage aborts the block, whether it fails a read or a write.
+	for _, state := range []*failingState{
+		{memState: newMemState(779999), readPanic: storageErr},
+		{memState: newMemState(779999), writeErr: fmt.Errorf("disk failure")},
+	} {
+		if _, ok := execPanic(state).(*protocol.StorageError); !ok {
+			t.Fatalf("Expected the storage failure to abort the block, read %v, write %v", state.readPanic, state.writeErr)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package brc20
+
+import (
+	"bytes"
+	"encoding/json"
+	"unicode/utf8"
+)
+
+// ParseContent parses the content of an inscription of the block into its string fields as the reference indexer, nil
+// if it isn't a JSON object. It decodes the content as the decoding straight into a map of strings, except that the
+// content that isn't valid UTF-8 is rejected as by the decoding of Python from StrictUTF8Height on, instead of
+// replacing the invalid bytes by U+FFFD as before. The last of the duplicated keys wins, and the fields that aren't
+// strings, e.g. "dec": 5 or "lim": null, are kept as empty strings, so they're invalid rather than missing.
+//
+// The content not starting with an object, such as the plain text, isn't unmarshalled at all.
+func ParseContent(content []byte, blockHeight uint) map[string]string {
+	trimmed := bytes.TrimLeft(content, " \t\r\n")
+	if len(trimmed) == 0 || trimmed[0] != '{' {
+		return nil
+	}
+	if strictUTF8Enabled(blockHeight) && !utf8.Valid(content) {
+		return nil
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(content, &fields); err != nil {
+		return nil
+	}
+	js := make(map[string]string, len(fields))
+	for key, value := range fields {
+		var s string
+		if len(value) != 0 && value[0] == '"' {
+			_ = json.Unmarshal(value, &s)
+		}
+		js[key] = s
+	}
+	return js
+}
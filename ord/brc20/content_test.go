@@ -0,0 +1,58 @@
+package brc20
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestParseContent(t *testing.T) {
+	vectors := []struct {
+		content string
+		js      map[string]string
+	}{
+		{`{"p":"brc-20","op":"mint","tick":"ordi","amt":"1000"}`, map[string]string{"p": "brc-20", "op": "mint", "tick": "ordi", "amt": "1000"}},
+		{" \n\t{\"tick\":\"ordi\"} ", map[string]string{"tick": "ordi"}},
+		// The fields that aren't strings are present but empty.
+		{`{"op":"mint","tick":"ordi","amt":1000}`, map[string]string{"op": "mint", "tick": "ordi", "amt": ""}},
+		{`{"tick":"ordi","amt":null,"dec":["1"],"max":{"v":"1"},"lim":true}`, map[string]string{"tick": "ordi", "amt": "", "dec": "", "max": "", "lim": ""}},
+		// The last of the duplicated keys wins, even if it isn't a string.
+		{`{"tick":"ordi","amt":"1","amt":"2"}`, map[string]string{"tick": "ordi", "amt": "2"}},
+		{`{"tick":"ordi","amt":"1","amt":2}`, map[string]string{"tick": "ordi", "amt": ""}},
+		{`{"tick":"ordi"}`, map[string]string{"tick": "ordi"}},
+		// The contents that aren't JSON objects.
+		{``, nil},
+		{`ordi`, nil},
+		{`["tick","ordi"]`, nil},
+		{`"{\"tick\":\"ordi\"}"`, nil},
+		{`{"tick":"ordi"`, nil},
+		{`{"tick":"ordi"} {"tick":"sats"}`, nil},
+		{"{\"tick\":\"or\xffi\"}", nil},
+	}
+	// The valid contents are parsed as by the decoding into a map of strings.
+	for _, v := range vectors {
+		if v.js == nil || !utf8.ValidString(v.content) {
+			continue
+		}
+		var decoded map[string]string
+		_ = json.Unmarshal([]byte(v.content), &decoded)
+		if !reflect.DeepEqual(decoded, v.js) {
+			t.Fatalf("The content %q is decoded to %v, expected %v", v.content, decoded, v.js)
+		}
+	}
+
+	defer func(height uint) { StrictUTF8Height = height }(StrictUTF8Height)
+	StrictUTF8Height = 800000
+	for _, v := range vectors {
+		if js := ParseContent([]byte(v.content), StrictUTF8Height); !reflect.DeepEqual(js, v.js) {
+			t.Fatalf("The content %q is parsed to %v, expected %v", v.content, js, v.js)
+		}
+	}
+
+	// The invalid bytes are replaced before the activation.
+	invalid := []byte("{\"tick\":\"or\xffi\"}")
+	if js := ParseContent(invalid, StrictUTF8Height-1); js["tick"] != "or\ufffdi" {
+		t.Fatalf("Expected the invalid bytes to be replaced before the activation, got %v", js)
+	}
+}
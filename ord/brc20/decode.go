@@ -0,0 +1,167 @@
+package brc20
+
+import (
+	"encoding/hex"
+	"sync"
+
+	verkle "github.com/ethereum/go-verkle"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/protocol"
+)
+
+// KeyMeaning is a key of the state decoded by its preimage: the key space, its inputs and the location.
+type KeyMeaning struct {
+	KeySpace      string `json:"keySpace"`
+	Tick          string `json:"tick,omitempty"`
+	Pkscript      string `json:"pkscript,omitempty"`
+	Wallet        string `json:"wallet,omitempty"`
+	InscriptionID string `json:"inscriptionID,omitempty"`
+	// The name of the value at the location, and the slot of the value if it takes several.
+	Location   string     `json:"location"`
+	LocationID LocationID `json:"locationID"`
+	Slot       int        `json:"slot,omitempty"`
+}
+
+// KeyDecoder decodes the keys of the state whose preimages it has been given. The keys are hashes,
+// so only the keys of the known ticks, pkscripts, wallets and inscriptions are decoded.
+type KeyDecoder struct {
+	stems  map[[verkle.StemSize]byte]KeyMeaning
+	ticks  map[string]bool
+	spaces map[string]SchemaKeySpace
+}
+
+func NewKeyDecoder() *KeyDecoder {
+	return &KeyDecoder{
+		stems:  make(map[[verkle.StemSize]byte]KeyMeaning),
+		ticks:  make(map[string]bool),
+		spaces: keySpaces(),
+	}
+}
+
+// keySpaces returns the key spaces of the schema by their names, whose inputs and suffixes never change.
+var keySpaces = sync.OnceValue(func() map[string]SchemaKeySpace {
+	spaces := make(map[string]SchemaKeySpace)
+	for _, space := range StateSchema().KeySpaces {
+		spaces[space.Name] = space
+	}
+	return spaces
+})
+
+func (d *KeyDecoder) add(key []byte, meaning KeyMeaning) {
+	d.stems[[verkle.StemSize]byte(key[:verkle.StemSize])] = meaning
+}
+
+// AddTick adds the keys of the tick.
+func (d *KeyDecoder) AddTick(tick string) {
+	tick = NormalizeTick(tick)
+	d.ticks[tick] = true
+	d.add(GetTickHash(tick, 0), KeyMeaning{KeySpace: "tick", Tick: tick})
+}
+
+// AddBalance adds the keys of the balances of the pkscript of the tick.
+func (d *KeyDecoder) AddBalance(tick string, pkscript ord.Pkscript) {
+	tick = NormalizeTick(tick)
+	d.add(GetTickPkscriptHash(tick, pkscript, 0), KeyMeaning{KeySpace: "tickPkscript", Tick: tick, Pkscript: string(pkscript)})
+}
+
+// AddWallet adds the keys of the wallet.
+func (d *KeyDecoder) AddWallet(wallet ord.Wallet) {
+	d.add(GetWalletHash(string(wallet), 0), KeyMeaning{KeySpace: "wallet", Wallet: string(wallet)})
+}
+
+// AddEvent adds the keys of the inscription.
+func (d *KeyDecoder) AddEvent(inscriptionID string) {
+	d.add(GetEventHash(inscriptionID, 0), KeyMeaning{KeySpace: "event", InscriptionID: inscriptionID})
+}
+
+// AddTransfers adds the keys the transfers of a block may access: the ticks named by their contents, the inscriptions,
+// the wallets, and the balances of the ticks added so far with the pkscripts receiving the transfers. The state, if
+// not nil, resolves the pkscripts and the wallets inscribing the transfer inscriptions, which are debited when they move.
+func (d *KeyDecoder) AddTransfers(ots []ord.OrdTransfer, state KVStorage) {
+	pkscripts := make(map[ord.Pkscript]bool)
+	for _, ot := range ots {
+		// The invalid bytes are replaced regardless of the height, which names the ticks of the content anyway.
+		if tick := ParseContent(ot.Content, 0)["tick"]; tick != "" {
+			d.AddTick(tick)
+		}
+		d.AddEvent(ot.InscriptionID)
+		if ot.NewWallet != "" {
+			d.AddWallet(ot.NewWallet)
+		}
+		pkscripts[ot.NewPkscript] = true
+		if state != nil {
+			if source, err := state.GetBytes(GetEventHash(ot.InscriptionID, TransferInscribeSourcePkscript)); err == nil && len(source) != 0 {
+				pkscripts[ord.Pkscript(hex.EncodeToString(source))] = true
+			}
+			// The wallets which aren't decoded are stored empty, and their latest pkscripts under the empty wallet.
+			if source, err := state.GetBytes(GetEventHash(ot.InscriptionID, TransferInscribeSourceWallet)); err == nil {
+				d.AddWallet(ord.Wallet(encodeBitcoinWallet(source)))
+			}
+		}
+	}
+	for tick := range d.ticks {
+		for pkscript := range pkscripts {
+			if pkscript != "" {
+				d.AddBalance(tick, pkscript)
+			}
+		}
+	}
+}
+
+// Decode returns the meaning of the key, false if its preimage is unknown, e.g. of another protocol.
+func (d *KeyDecoder) Decode(key []byte) (KeyMeaning, bool) {
+	if len(key) != verkle.KeySize {
+		return KeyMeaning{}, false
+	}
+	meaning, found := d.stems[[verkle.StemSize]byte(key[:verkle.StemSize])]
+	if !found {
+		return KeyMeaning{}, false
+	}
+	id := key[verkle.StemSize]
+	meaning.LocationID, meaning.Location = id, "unknown"
+	for _, location := range d.spaces[meaning.KeySpace].Locations {
+		if id >= location.LocationID && int(id) < int(location.LocationID)+location.Slots {
+			meaning.Location, meaning.Slot = location.Name, int(id-location.LocationID)
+			break
+		}
+	}
+	return meaning, true
+}
+
+// LocationOf returns the name of the location ID in the key space of the suffix, e.g. GetTickHash, and the slot of the
+// value at the location if it takes several.
+func LocationOf(suffix string, id LocationID) (string, int, bool) {
+	for _, space := range keySpaces() {
+		if space.Suffix != suffix {
+			continue
+		}
+		for _, location := range space.Locations {
+			if id >= location.LocationID && int(id) < int(location.LocationID)+location.Slots {
+				return location.Name, int(id - location.LocationID), true
+			}
+		}
+	}
+	return "", 0, false
+}
+
+// PreImage returns the preimage of the stem of the key, which the stem is derived from by any key scheme.
+func (m KeyMeaning) PreImage() protocol.PreImage {
+	inputs := map[string]string{"tick": m.Tick, "pkscript": m.Pkscript, "wallet": m.Wallet, "inscriptionID": m.InscriptionID}
+	var data []byte
+	space := keySpaces()[m.KeySpace]
+	for _, input := range space.Inputs {
+		data = append(data, inputs[input]...)
+	}
+	data = append(data, space.Suffix...)
+	return protocol.PreImage{Domain: Name, Data: data}
+}
+
+// PreImages returns the preimages of the stems added to the decoder.
+func (d *KeyDecoder) PreImages() []protocol.PreImage {
+	res := make([]protocol.PreImage, 0, len(d.stems))
+	for _, meaning := range d.stems {
+		res = append(res, meaning.PreImage())
+	}
+	return res
+}
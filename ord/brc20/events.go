@@ -0,0 +1,144 @@
+package brc20
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord"
+)
+
+// EventType names the events as OPI does, so that the events can be cross-checked against other indexers.
+type EventType string
+
+const (
+	EventDeployInscribe   EventType = "deploy-inscribe"
+	EventMintInscribe     EventType = "mint-inscribe"
+	EventTransferInscribe EventType = "transfer-inscribe"
+	EventTransferTransfer EventType = "transfer-transfer"
+)
+
+// Event is an effect of a transfer on the state, emitted by Exec in the order of the block.
+// The amounts are decimal and extended to 18 decimals, as the balances.
+type Event interface {
+	Type() EventType
+}
+
+type DeployEvent struct {
+	InscriptionID string `json:"inscriptionID"`
+	Tick          string `json:"tick"`
+	// The tick as inscribed, only set if it differs from the folded one.
+	OriginalTick string       `json:"originalTick,omitempty"`
+	Pkscript     ord.Pkscript `json:"pkscript"`
+	Wallet       ord.Wallet   `json:"wallet"`
+	MaxSupply    string       `json:"maxSupply"`
+	LimitPerMint string       `json:"limitPerMint"`
+	Decimals     uint64       `json:"decimals"`
+	SelfMint     bool         `json:"selfMint"`
+}
+
+type MintEvent struct {
+	InscriptionID string       `json:"inscriptionID"`
+	Tick          string       `json:"tick"`
+	OriginalTick  string       `json:"originalTick,omitempty"`
+	Pkscript      ord.Pkscript `json:"pkscript"`
+	Wallet        ord.Wallet   `json:"wallet"`
+	Amount        string       `json:"amount"`
+	// The parent inscription authorizing the mint of a self-mint tick.
+	ParentID string `json:"parentID,omitempty"`
+}
+
+type TransferInscribeEvent struct {
+	InscriptionID string       `json:"inscriptionID"`
+	Tick          string       `json:"tick"`
+	OriginalTick  string       `json:"originalTick,omitempty"`
+	Pkscript      ord.Pkscript `json:"pkscript"`
+	Wallet        ord.Wallet   `json:"wallet"`
+	Amount        string       `json:"amount"`
+}
+
+type TransferTransferEvent struct {
+	InscriptionID string `json:"inscriptionID"`
+	Tick          string `json:"tick"`
+	OriginalTick  string `json:"originalTick,omitempty"`
+	// The source kept by the transfer-inscribe, whose wallet is empty unless it's a base58 address.
+	SourcePkscript ord.Pkscript `json:"sourcePkscript"`
+	SourceWallet   ord.Wallet   `json:"sourceWallet"`
+	// The receiver, empty if the transfer is spent to the fee and returned to the source.
+	SpentPkscript ord.Pkscript `json:"spentPkscript"`
+	SpentWallet   ord.Wallet   `json:"spentWallet"`
+	Amount        string       `json:"amount"`
+	SentAsFee     bool         `json:"sentAsFee"`
+	// Whether the transfer is spent to an unspendable output, burning the amount, see BurnHeight.
+	Burned bool `json:"burned,omitempty"`
+}
+
+func (DeployEvent) Type() EventType           { return EventDeployInscribe }
+func (MintEvent) Type() EventType             { return EventMintInscribe }
+func (TransferInscribeEvent) Type() EventType { return EventTransferInscribe }
+func (TransferTransferEvent) Type() EventType { return EventTransferTransfer }
+
+// EventObserver is optionally implemented by the KVStorage following the events of the execution.
+type EventObserver interface {
+	ObserveEvent(event Event)
+}
+
+func emit(state KVStorage, event Event) {
+	if o, ok := state.(EventObserver); ok {
+		o.ObserveEvent(event)
+	}
+}
+
+// taggedEvent is the JSON of an event along with its type.
+type taggedEvent struct {
+	Type  EventType       `json:"type"`
+	Event json.RawMessage `json:"event"`
+}
+
+// MarshalEvents encodes the events tagged by their types.
+func MarshalEvents(events []Event) ([]byte, error) {
+	tagged := make([]taggedEvent, len(events))
+	for i, event := range events {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return nil, err
+		}
+		tagged[i] = taggedEvent{Type: event.Type(), Event: data}
+	}
+	return json.Marshal(tagged)
+}
+
+// UnmarshalEvents decodes the events encoded by MarshalEvents.
+func UnmarshalEvents(data []byte) ([]Event, error) {
+	var tagged []taggedEvent
+	if err := json.Unmarshal(data, &tagged); err != nil {
+		return nil, err
+	}
+	events := make([]Event, len(tagged))
+	for i, t := range tagged {
+		var err error
+		switch t.Type {
+		case EventDeployInscribe:
+			var e DeployEvent
+			err = json.Unmarshal(t.Event, &e)
+			events[i] = e
+		case EventMintInscribe:
+			var e MintEvent
+			err = json.Unmarshal(t.Event, &e)
+			events[i] = e
+		case EventTransferInscribe:
+			var e TransferInscribeEvent
+			err = json.Unmarshal(t.Event, &e)
+			events[i] = e
+		case EventTransferTransfer:
+			var e TransferTransferEvent
+			err = json.Unmarshal(t.Event, &e)
+			events[i] = e
+		default:
+			err = fmt.Errorf("unknown event type %s", t.Type)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return events, nil
+}
@@ -0,0 +1,183 @@
+package brc20
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord"
+	uint256 "github.com/holiman/uint256"
+)
+
+// Genesis is the bootstrap state injected before the first indexed block,
+// so that testnets and research forks can start from a pre-seeded state.
+// The amounts are integers extended to 18 decimals, as they are stored in the state.
+type Genesis struct {
+	Ticks    []GenesisTick    `json:"ticks"`
+	Balances []GenesisBalance `json:"balances"`
+	Wallets  []GenesisWallet  `json:"wallets"`
+}
+
+type GenesisTick struct {
+	Tick            string `json:"tick"`
+	InscriptionID   string `json:"inscriptionID"`
+	MaxSupply       string `json:"maxSupply"`
+	RemainingSupply string `json:"remainingSupply"`
+	LimitPerMint    string `json:"limitPerMint"`
+	Decimals        uint64 `json:"decimals"`
+	SelfMint        bool   `json:"selfMint"`
+	// The amount burned before the genesis, empty if none.
+	BurnedSupply string `json:"burnedSupply,omitempty"`
+	// The number of the mints before the genesis, counted if the counters are active at the genesis.
+	Mints uint64 `json:"mints,omitempty"`
+}
+
+type GenesisBalance struct {
+	Tick             string `json:"tick"`
+	Pkscript         string `json:"pkscript"`
+	AvailableBalance string `json:"availableBalance"`
+	OverallBalance   string `json:"overallBalance"`
+}
+
+type GenesisWallet struct {
+	Wallet   string `json:"wallet"`
+	Pkscript string `json:"pkscript"`
+}
+
+func parseGenesisAmount(value string, name string) (*uint256.Int, error) {
+	amount, err := uint256.FromDecimal(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %s: %v", name, value, err)
+	}
+	if amount.Gt(getLimit()) {
+		return nil, fmt.Errorf("the %s %s exceeds the upper limit", name, value)
+	}
+	return amount, nil
+}
+
+// ValidateGenesis checks the bootstrap state against the rules of the deploy and the balances.
+func ValidateGenesis(g *Genesis) error {
+	ticks := make(map[string]bool)
+	for _, t := range g.Ticks {
+		tick := NormalizeTick(t.Tick)
+		if !Ticks.Valid(tick) {
+			return fmt.Errorf("invalid tick %s", t.Tick)
+		}
+		if ticks[tick] {
+			return fmt.Errorf("duplicated tick %s", t.Tick)
+		}
+		ticks[tick] = true
+		if !ord.IsInscriptionID(t.InscriptionID) {
+			return fmt.Errorf("invalid inscription ID %s of the tick %s", t.InscriptionID, t.Tick)
+		}
+		if Ticks.Len(tick) == 5 && !t.SelfMint {
+			return fmt.Errorf("the tick %s of 5 bytes must be self-mint", t.Tick)
+		}
+		if t.Decimals > 18 {
+			return fmt.Errorf("invalid decimals %d of the tick %s", t.Decimals, t.Tick)
+		}
+		maxSupply, err := parseGenesisAmount(t.MaxSupply, "max supply")
+		if err != nil {
+			return err
+		}
+		remainingSupply, err := parseGenesisAmount(t.RemainingSupply, "remaining supply")
+		if err != nil {
+			return err
+		}
+		limitPerMint, err := parseGenesisAmount(t.LimitPerMint, "limit per mint")
+		if err != nil {
+			return err
+		}
+		if maxSupply.IsZero() || limitPerMint.IsZero() {
+			return fmt.Errorf("the max supply and the limit per mint of the tick %s must be positive", t.Tick)
+		}
+		if remainingSupply.Gt(maxSupply) {
+			return fmt.Errorf("the remaining supply of the tick %s exceeds the max supply", t.Tick)
+		}
+		if t.BurnedSupply != "" {
+			burnedSupply, err := parseGenesisAmount(t.BurnedSupply, "burned supply")
+			if err != nil {
+				return err
+			}
+			if burnedSupply.Gt(new(uint256.Int).Sub(maxSupply, remainingSupply)) {
+				return fmt.Errorf("the burned supply of the tick %s exceeds the minted supply", t.Tick)
+			}
+		}
+	}
+	balances := make(map[[2]string]bool)
+	for _, b := range g.Balances {
+		if !ticks[NormalizeTick(b.Tick)] {
+			return fmt.Errorf("the balance of the tick %s is not deployed in the genesis", b.Tick)
+		}
+		if balances[[2]string{NormalizeTick(b.Tick), b.Pkscript}] {
+			return fmt.Errorf("duplicated balance of the pkscript %s of the tick %s", b.Pkscript, b.Tick)
+		}
+		balances[[2]string{NormalizeTick(b.Tick), b.Pkscript}] = true
+		if _, err := hex.DecodeString(b.Pkscript); err != nil {
+			return fmt.Errorf("invalid pkscript %s: %v", b.Pkscript, err)
+		}
+		available, err := parseGenesisAmount(b.AvailableBalance, "available balance")
+		if err != nil {
+			return err
+		}
+		overall, err := parseGenesisAmount(b.OverallBalance, "overall balance")
+		if err != nil {
+			return err
+		}
+		if available.Gt(overall) {
+			return fmt.Errorf("the available balance of the pkscript %s of the tick %s exceeds the overall balance", b.Pkscript, b.Tick)
+		}
+	}
+	for _, w := range g.Wallets {
+		if w.Wallet == "" {
+			return fmt.Errorf("the wallet of the pkscript %s is empty", w.Pkscript)
+		}
+		if _, err := hex.DecodeString(w.Pkscript); err != nil {
+			return fmt.Errorf("invalid pkscript %s: %v", w.Pkscript, err)
+		}
+	}
+	return nil
+}
+
+// ApplyGenesis writes the bootstrap state into the state storage.
+func ApplyGenesis(kv KVStorage, g *Genesis) error {
+	if err := ValidateGenesis(g); err != nil {
+		return err
+	}
+	state := newTxn(kv)
+	state.counted = countersEnabled(kv.GetHeight() + 1)
+	for _, t := range g.Ticks {
+		tick := NormalizeTick(t.Tick)
+		maxSupply, _ := uint256.FromDecimal(t.MaxSupply)
+		remainingSupply, _ := uint256.FromDecimal(t.RemainingSupply)
+		limitPerMint, _ := uint256.FromDecimal(t.LimitPerMint)
+		isSelfMint := "false"
+		if t.SelfMint {
+			isSelfMint = "true"
+		}
+		deployInscribe(state, t.InscriptionID, tick, maxSupply, uint256.NewInt(t.Decimals), limitPerMint, isSelfMint)
+		state.insertUInt256(GetTickHash(tick, RemainingSupply), remainingSupply)
+		if burnedSupply, _ := uint256.FromDecimal(t.BurnedSupply); t.BurnedSupply != "" && !burnedSupply.IsZero() {
+			state.insertUInt256(GetTickHash(tick, BurnedSupply), burnedSupply)
+		}
+		if state.counted {
+			state.insertUInt256(GetTickHash(tick, MintCount), uint256.NewInt(t.Mints))
+			state.insertUInt256(GetTickHash(tick, MintedAmount), new(uint256.Int).Sub(maxSupply, remainingSupply))
+		}
+	}
+	for _, b := range g.Balances {
+		tick := NormalizeTick(b.Tick)
+		available, _ := uint256.FromDecimal(b.AvailableBalance)
+		overall, _ := uint256.FromDecimal(b.OverallBalance)
+		state.insertUInt256(GetTickPkscriptHash(tick, ord.Pkscript(b.Pkscript), AvailableBalancePkscript), available)
+		state.insertUInt256(GetTickPkscriptHash(tick, ord.Pkscript(b.Pkscript), OverallBalancePkscript), overall)
+		observe(state, GetTickPkscriptHash(tick, ord.Pkscript(b.Pkscript), AvailableBalancePkscript), CategoryBalances)
+		observeHolder(state, tick, ord.Pkscript(b.Pkscript))
+		if state.counted {
+			countHolder(state, tick, false, !overall.IsZero())
+		}
+	}
+	for _, w := range g.Wallets {
+		updateLatestPkscript(state, ord.Wallet(w.Wallet), ord.Pkscript(w.Pkscript))
+	}
+	return state.commit()
+}
@@ -0,0 +1,99 @@
+package brc20
+
+import (
+	"sync/atomic"
+)
+
+// ContentLimits bounds the content processed by Exec, protecting the nodes from adversarial inscriptions.
+// Zero values disable the limits. Re-executions of the committee must use the same limits.
+type ContentLimits struct {
+	// The max size of the content in bytes.
+	MaxContentSize int `json:"maxContentSize"`
+	// The max nesting depth of the JSON objects and arrays in the content.
+	MaxJSONDepth int `json:"maxJSONDepth"`
+}
+
+// The reasons of skipping a content.
+const (
+	SkipOversized = "oversized"
+	SkipTooDeep   = "too_deep"
+)
+
+// SkipReasons lists every reason of skipping a content.
+var SkipReasons = []string{SkipOversized, SkipTooDeep}
+
+// The limits evaluated at every ord transfer before parsing its content.
+var Limits ContentLimits
+
+var skippedOversized, skippedTooDeep atomic.Uint64
+
+// SkippedContents returns the number of the contents skipped for the reason since the start.
+func SkippedContents(reason string) uint64 {
+	switch reason {
+	case SkipOversized:
+		return skippedOversized.Load()
+	case SkipTooDeep:
+		return skippedTooDeep.Load()
+	default:
+		return 0
+	}
+}
+
+// jsonDepth returns the max nesting depth of the JSON content, stopping as soon as it exceeds the limit.
+// It only scans the bytes, so the malformed content is measured as well without any allocation.
+func jsonDepth(content []byte, limit int) int {
+	depth, maxDepth := 0, 0
+	inString, escaped := false, false
+	for _, c := range content {
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			inString = true
+		case '{', '[':
+			depth++
+			if depth > maxDepth {
+				maxDepth = depth
+				if maxDepth > limit {
+					return maxDepth
+				}
+			}
+		case '}', ']':
+			depth--
+		}
+	}
+	return maxDepth
+}
+
+// SkipContent reports the reason of skipping the content under the limits, or an empty string if it is accepted.
+func (l ContentLimits) SkipContent(content []byte) string {
+	if l.MaxContentSize > 0 && len(content) > l.MaxContentSize {
+		return SkipOversized
+	}
+	if l.MaxJSONDepth > 0 && jsonDepth(content, l.MaxJSONDepth) > l.MaxJSONDepth {
+		return SkipTooDeep
+	}
+	return ""
+}
+
+// acceptContent counts the skipped content.
+func acceptContent(content []byte) bool {
+	switch Limits.SkipContent(content) {
+	case SkipOversized:
+		skippedOversized.Add(1)
+		return false
+	case SkipTooDeep:
+		skippedTooDeep.Add(1)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,111 @@
+package brc20
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/ethereum/go-verkle"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord/protocol"
+)
+
+// The max number of stems memoized in a block, which bounds the memory taken by the blocks of many distinct keys.
+const MaxBlockStems = 1 << 18
+
+// stemCache memoizes the stems of a block by their preimages, i.e. the unique ID of the key followed by the name of
+// its key space. A hot block repeats the same pkscripts and wallets in many transfers, each of which hashes them
+// for the available and the overall balances, the latest pkscripts and the events.
+type stemCache struct {
+	height uint
+	// The key scheme of the stems, see protocol.Keys.
+	scheme protocol.KeyScheme
+	mu     sync.RWMutex
+	stems  map[string][verkle.StemSize]byte
+}
+
+var (
+	stems atomic.Pointer[stemCache]
+	// The hits and the misses of the stem cache since the start.
+	stemHits, stemMisses atomic.Uint64
+)
+
+// beginBlock starts the stem cache of the block, unless the block is already started, e.g. by another transfer of
+// the block executed by its own Exec.
+func beginBlock(blockHeight uint) {
+	if current := stems.Load(); current == nil || current.height != blockHeight || current.scheme != protocol.Keys {
+		stems.Store(&stemCache{height: blockHeight, scheme: protocol.Keys, stems: make(map[string][verkle.StemSize]byte)})
+	}
+}
+
+// StemCacheStats returns the hits and the misses of the stem cache since the start.
+func StemCacheStats() (uint64, uint64) {
+	return stemHits.Load(), stemMisses.Load()
+}
+
+// preImage is the buffer of a preimage, whose lookup in the cache doesn't allocate.
+type preImage struct {
+	buf []byte
+}
+
+var preImages = sync.Pool{New: func() any {
+	return &preImage{buf: make([]byte, 0, 128)}
+}}
+
+// The buffers of the keys read and written by the execution, which the states copy rather than keep.
+var keys = sync.Pool{New: func() any { return new([verkle.KeySize]byte) }}
+
+// hashStem returns the key of the location ID under the stem of the preimage uniqueID + keySpace by the key scheme,
+// Keccak256(uniqueID + keySpace)[:StemSize] by the legacy one, where the unique ID is the concatenation of the parts,
+// memoized in the block.
+func hashStem(keySpace string, locationID LocationID, parts ...string) []byte {
+	return stemKey(new([verkle.KeySize]byte), keySpace, locationID, parts...)
+}
+
+// stemKey is hashStem writing the key into dst, which a caller may take from the pool of the keys.
+func stemKey(dst *[verkle.KeySize]byte, keySpace string, locationID LocationID, parts ...string) []byte {
+	key := dst[:verkle.StemSize+1]
+	key[verkle.StemSize] = locationID
+	pre := preImages.Get().(*preImage)
+	defer preImages.Put(pre)
+	pre.buf = pre.buf[:0]
+	for _, part := range parts {
+		pre.buf = append(pre.buf, part...)
+	}
+	pre.buf = append(pre.buf, keySpace...)
+
+	scheme := protocol.Keys
+	cache := stems.Load()
+	if cache != nil && cache.scheme != scheme {
+		cache = nil
+	}
+	if cache != nil {
+		cache.mu.RLock()
+		stem, found := cache.stems[string(pre.buf)]
+		cache.mu.RUnlock()
+		if found {
+			stemHits.Add(1)
+			copy(key, stem[:])
+			recordPreImage(stem, keySpace, parts)
+			return key
+		}
+	}
+	stemMisses.Add(1)
+	stem := scheme.Stem(Name, pre.buf)
+	copy(key, stem[:])
+	if cache != nil {
+		cache.mu.Lock()
+		if len(cache.stems) < MaxBlockStems {
+			cache.stems[string(pre.buf)] = stem
+		}
+		cache.mu.Unlock()
+	}
+	recordPreImage(stem, keySpace, parts)
+	return key
+}
+
+// recordPreImage records the preimage of the stem, if recording, see protocol.RecordPreImages.
+func recordPreImage(stem [verkle.StemSize]byte, keySpace string, parts []string) {
+	if protocol.RecordingPreImages() {
+		protocol.RecordPreImage(stem, protocol.KeyPreImage{Domain: Name, KeySpace: keySpace, Inputs: parts})
+	}
+}
@@ -0,0 +1,62 @@
+package brc20
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ethereum/go-verkle"
+	"golang.org/x/crypto/sha3"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord"
+)
+
+func TestHashStem(t *testing.T) {
+	keccak := func(preImg string, locationID LocationID) []byte {
+		hasher := sha3.NewLegacyKeccak256()
+		hasher.Write([]byte(preImg))
+		return append(hasher.Sum(nil)[:verkle.StemSize], locationID)
+	}
+	pkscript := ord.Pkscript("0014" + "6d6d6d6d6d6d6d6d6d6d6d6d6d6d6d6d6d6d6d6d")
+
+	stems.Store(nil)
+	uncached := GetTickPkscriptHash("ordi", pkscript, OverallBalancePkscript)
+	if !bytes.Equal(uncached, keccak("ordi"+string(pkscript)+"GetTickPkscriptHash", OverallBalancePkscript)) {
+		t.Fatalf("Unexpected key %x out of the blocks", uncached)
+	}
+
+	beginBlock(800000)
+	hits, _ := StemCacheStats()
+	available := GetTickPkscriptHash("ordi", pkscript, AvailableBalancePkscript)
+	// The key of the caller is its own, which doesn't alter the memoized stem.
+	available[0] ^= 0xff
+	overall := GetTickPkscriptHash("ordi", pkscript, OverallBalancePkscript)
+	if !bytes.Equal(overall, uncached) {
+		t.Fatalf("The memoized key %x differs from %x", overall, uncached)
+	}
+	if h, _ := StemCacheStats(); h != hits+1 {
+		t.Fatalf("Expected a hit of the stem cache, got %d hits after %d", h, hits)
+	}
+	for _, v := range []struct {
+		key    []byte
+		preImg string
+	}{
+		{GetTickHash("ordi", Exists), "ordi" + "GetTickHash"},
+		{GetWalletHash("bc1q", WalletLatestPkscript), "bc1q" + "GetWalletHash"},
+		{GetEventHash("i0", TransferInscribeCount), "i0" + "GetEventHash"},
+	} {
+		if !bytes.Equal(v.key, keccak(v.preImg, v.key[verkle.StemSize])) {
+			t.Fatalf("Unexpected key %x of %s", v.key, v.preImg)
+		}
+	}
+
+	// The stems are dropped by the next block, and kept by another transfer of the same block.
+	beginBlock(800000)
+	if len(stems.Load().stems) != 4 {
+		t.Fatalf("Expected the 4 stems of the block, got %d", len(stems.Load().stems))
+	}
+	beginBlock(800001)
+	if len(stems.Load().stems) != 0 {
+		t.Fatalf("Expected the stems of the previous block to be dropped, got %d", len(stems.Load().stems))
+	}
+	stems.Store(nil)
+}
@@ -0,0 +1,107 @@
+package brc20
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+)
+
+// OPIEventSeparator joins the event strings of a block before they are hashed, as OPI does.
+const OPIEventSeparator = "|"
+
+// opiAmount formats an amount extended to 18 decimals as OPI does: a decimal number cut to the decimals of the tick,
+// keeping the trailing zeros of the fraction.
+func opiAmount(amount string, decimals uint64) string {
+	if len(amount) <= 18 {
+		amount = "0." + strings.Repeat("0", 18-len(amount)) + amount
+	} else {
+		amount = amount[:len(amount)-18] + "." + amount[len(amount)-18:]
+	}
+	if decimals < 18 {
+		amount = amount[:len(amount)-18+int(decimals)]
+	}
+	return strings.TrimSuffix(amount, ".")
+}
+
+// OPIEventString returns the string of the event hashed by OPI, where decimals are the ones of the tick.
+func OPIEventString(event Event, decimals uint64) string {
+	originalTick := func(tick, original string) string {
+		if original == "" {
+			return tick
+		}
+		return original
+	}
+	var fields []string
+	switch e := event.(type) {
+	case DeployEvent:
+		fields = []string{string(e.Pkscript), e.Tick, originalTick(e.Tick, e.OriginalTick), opiAmount(e.MaxSupply, e.Decimals),
+			strconv.FormatUint(e.Decimals, 10), opiAmount(e.LimitPerMint, e.Decimals), strconv.FormatBool(e.SelfMint)}
+	case MintEvent:
+		fields = []string{string(e.Pkscript), e.Tick, originalTick(e.Tick, e.OriginalTick), opiAmount(e.Amount, decimals), e.ParentID}
+	case TransferInscribeEvent:
+		fields = []string{string(e.Pkscript), e.Tick, originalTick(e.Tick, e.OriginalTick), opiAmount(e.Amount, decimals)}
+	case TransferTransferEvent:
+		// The spent pkscript of a transfer sent as fee is empty.
+		fields = []string{string(e.SourcePkscript), string(e.SpentPkscript), e.Tick, originalTick(e.Tick, e.OriginalTick), opiAmount(e.Amount, decimals)}
+	}
+	return strings.Join(append([]string{string(event.Type()), eventInscriptionID(event)}, fields...), ";")
+}
+
+func eventInscriptionID(event Event) string {
+	switch e := event.(type) {
+	case DeployEvent:
+		return e.InscriptionID
+	case MintEvent:
+		return e.InscriptionID
+	case TransferInscribeEvent:
+		return e.InscriptionID
+	case TransferTransferEvent:
+		return e.InscriptionID
+	}
+	return ""
+}
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+// OPIBlockEventHash returns the hex of the SHA-256 of the event strings of a block joined by the separator, where
+// decimals returns the decimals of a tick deployed before the event.
+func OPIBlockEventHash(events []Event, decimals func(tick string) uint64) string {
+	// The ticks deployed in the block are looked up in the block itself.
+	deployed := make(map[string]uint64)
+	strs := make([]string, len(events))
+	for i, event := range events {
+		var dec uint64
+		switch e := event.(type) {
+		case DeployEvent:
+			deployed[e.Tick] = e.Decimals
+		case MintEvent:
+			dec = tickDecimals(deployed, e.Tick, decimals)
+		case TransferInscribeEvent:
+			dec = tickDecimals(deployed, e.Tick, decimals)
+		case TransferTransferEvent:
+			dec = tickDecimals(deployed, e.Tick, decimals)
+		}
+		strs[i] = OPIEventString(event, dec)
+	}
+	return sha256Hex(strings.Join(strs, OPIEventSeparator))
+}
+
+func tickDecimals(deployed map[string]uint64, tick string, decimals func(tick string) uint64) uint64 {
+	if dec, ok := deployed[tick]; ok {
+		return dec
+	}
+	return decimals(tick)
+}
+
+// OPICumulativeEventHash chains the event hash of a block to the cumulative one of the previous block, which is
+// empty before the first block of BRC-20.
+func OPICumulativeEventHash(previous, block string) string {
+	if previous == "" {
+		return block
+	}
+	return sha256Hex(previous + block)
+}
@@ -0,0 +1,82 @@
+package brc20
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestOPIEventString(t *testing.T) {
+	for _, c := range []struct {
+		amount   string
+		decimals uint64
+		expected string
+	}{
+		{"10000000000000000000", 18, "10.000000000000000000"},
+		{"5500000000000000000", 1, "5.5"},
+		{"100000000000000000000", 0, "100"},
+		{"1", 0, "0"},
+		{"1", 18, "0.000000000000000001"},
+		{"120000000000000000", 2, "0.12"},
+	} {
+		if actual := opiAmount(c.amount, c.decimals); actual != c.expected {
+			t.Fatalf("Expected %s from %s with %d decimals, got %s", c.expected, c.amount, c.decimals, actual)
+		}
+	}
+
+	id := strings.Repeat("1", 64) + "i0"
+	for _, c := range []struct {
+		event    Event
+		expected string
+	}{
+		{
+			DeployEvent{InscriptionID: id, Tick: "ordi", OriginalTick: "ORDI", Pkscript: "0014aa", MaxSupply: "21000000000000000000000000", LimitPerMint: "1000000000000000000000", Decimals: 18},
+			"deploy-inscribe;" + id + ";0014aa;ordi;ORDI;21000000.000000000000000000;18;1000.000000000000000000;false",
+		},
+		{
+			MintEvent{InscriptionID: id, Tick: "ordi", Pkscript: "0014aa", Amount: "1000000000000000000000"},
+			"mint-inscribe;" + id + ";0014aa;ordi;ordi;1000.00;",
+		},
+		{
+			TransferInscribeEvent{InscriptionID: id, Tick: "ordi", Pkscript: "0014aa", Amount: "1500000000000000000"},
+			"transfer-inscribe;" + id + ";0014aa;ordi;ordi;1.50",
+		},
+		{
+			// The transfer sent as fee has no spent pkscript.
+			TransferTransferEvent{InscriptionID: id, Tick: "ordi", SourcePkscript: "0014aa", Amount: "1500000000000000000", SentAsFee: true},
+			"transfer-transfer;" + id + ";0014aa;;ordi;ordi;1.50",
+		},
+	} {
+		if actual := OPIEventString(c.event, 2); actual != c.expected {
+			t.Fatalf("Expected %s, got %s", c.expected, actual)
+		}
+	}
+}
+
+func TestOPIBlockEventHash(t *testing.T) {
+	sha := func(s string) string {
+		sum := sha256.Sum256([]byte(s))
+		return hex.EncodeToString(sum[:])
+	}
+	id := strings.Repeat("1", 64) + "i0"
+	events := []Event{
+		DeployEvent{InscriptionID: id, Tick: "abcd", Pkscript: "0014aa", MaxSupply: "100000000000000000000", LimitPerMint: "100000000000000000000", Decimals: 1},
+		MintEvent{InscriptionID: id, Tick: "abcd", Pkscript: "0014aa", Amount: "5500000000000000000"},
+		MintEvent{InscriptionID: id, Tick: "efgh", Pkscript: "0014aa", Amount: "5500000000000000000"},
+	}
+	// The decimals of the tick deployed in the block are the ones of its deploy.
+	block := OPIBlockEventHash(events, func(tick string) uint64 { return 0 })
+	expected := sha("deploy-inscribe;" + id + ";0014aa;abcd;abcd;100.0;1;100.0;false|" +
+		"mint-inscribe;" + id + ";0014aa;abcd;abcd;5.5;|" +
+		"mint-inscribe;" + id + ";0014aa;efgh;efgh;5;")
+	if block != expected {
+		t.Fatalf("Expected the block event hash %s, got %s", expected, block)
+	}
+	if empty := OPIBlockEventHash(nil, nil); empty != sha("") {
+		t.Fatalf("Unexpected hash %s of an empty block", empty)
+	}
+	if OPICumulativeEventHash("", block) != block || OPICumulativeEventHash("ab", block) != sha("ab"+block) {
+		t.Fatal("Unexpected cumulative event hash")
+	}
+}
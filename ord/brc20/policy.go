@@ -0,0 +1,76 @@
+package brc20
+
+import "fmt"
+
+// DeployPolicy decides whether a new tick can be deployed at the block height, beyond the existence of the tick.
+type DeployPolicy interface {
+	AllowDeploy(tick string, isSelfMint bool, blockHeight uint) bool
+}
+
+// DeployRule is a governance decision on deploys, activated from a block height on.
+type DeployRule struct {
+	ActivationHeight uint `json:"activationHeight"`
+	// The ticks that can't be deployed, compared in lowercase.
+	ReservedTicks []string `json:"reservedTicks"`
+	// The window [ClaimStartHeight, ClaimEndHeight) in which the 5-byte ticks can be claimed. Zero values leave it open.
+	ClaimStartHeight uint `json:"claimStartHeight"`
+	ClaimEndHeight   uint `json:"claimEndHeight"`
+}
+
+func (r *DeployRule) AllowDeploy(tick string, isSelfMint bool, blockHeight uint) bool {
+	if blockHeight < r.ActivationHeight {
+		return true
+	}
+	for _, reserved := range r.ReservedTicks {
+		if NormalizeTick(reserved) == tick {
+			return false
+		}
+	}
+	if Ticks.Len(tick) == 5 {
+		if blockHeight < r.ClaimStartHeight {
+			return false
+		}
+		if r.ClaimEndHeight != 0 && blockHeight >= r.ClaimEndHeight {
+			return false
+		}
+	}
+	return true
+}
+
+// DeployRules allows a deploy only if every rule allows it.
+type DeployRules []DeployRule
+
+func (rules DeployRules) AllowDeploy(tick string, isSelfMint bool, blockHeight uint) bool {
+	for i := range rules {
+		if !rules[i].AllowDeploy(tick, isSelfMint, blockHeight) {
+			return false
+		}
+	}
+	return true
+}
+
+func (rules DeployRules) Validate() error {
+	for _, r := range rules {
+		if r.ClaimEndHeight != 0 && r.ClaimEndHeight <= r.ClaimStartHeight {
+			return fmt.Errorf("the claim window [%d, %d) of the rule activated at %d is empty", r.ClaimStartHeight, r.ClaimEndHeight, r.ActivationHeight)
+		}
+		for _, tick := range r.ReservedTicks {
+			if !Ticks.Valid(NormalizeTick(tick)) {
+				return fmt.Errorf("invalid reserved tick %s of the rule activated at %d", tick, r.ActivationHeight)
+			}
+		}
+	}
+	return nil
+}
+
+// The policies evaluated at every deploy. Re-executions of the committee must use the same policies.
+var DeployPolicies []DeployPolicy = nil
+
+func allowDeploy(tick string, isSelfMint bool, blockHeight uint) bool {
+	for _, policy := range DeployPolicies {
+		if !policy.AllowDeploy(tick, isSelfMint, blockHeight) {
+			return false
+		}
+	}
+	return true
+}
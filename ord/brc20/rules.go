@@ -0,0 +1,62 @@
+package brc20
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord/protocol"
+)
+
+// RuleSet is the effective rules of the execution, which every member attesting the same meta protocol must share.
+type RuleSet struct {
+	SelfMintEnableHeight    uint           `json:"selfMintEnableHeight"`
+	AuthorityTransferHeight uint           `json:"authorityTransferHeight"`
+	DeployPolicies          []DeployPolicy `json:"deployPolicies"`
+	Limits                  ContentLimits  `json:"limits"`
+	// Omitted unless enabled, so that the version of the default rules is kept.
+	StrictNumbers bool `json:"strictNumbers,omitempty"`
+	// Omitted unless configured, as the strict numbers.
+	Ticks *TickRules `json:"ticks,omitempty"`
+	// Omitted while the burns are disabled, as the strict numbers.
+	BurnHeight uint `json:"burnHeight,omitempty"`
+	// Omitted while the counters are disabled, as the burns.
+	CountersHeight uint `json:"countersHeight,omitempty"`
+	// Omitted while the validation of UTF-8 is disabled, as the burns.
+	StrictUTF8Height uint `json:"strictUTF8Height,omitempty"`
+	// The protocols registered along with BRC-20, sharing the state.
+	Protocols []string `json:"protocols,omitempty"`
+	// Omitted for the legacy key scheme, see protocol.KeySchemeFlag.
+	KeyScheme string `json:"keyScheme,omitempty"`
+}
+
+func CurrentRules() RuleSet {
+	var ticks *TickRules
+	if rules := Ticks.withDefaults(); rules != (TickRules{}).withDefaults() {
+		ticks = &rules
+	}
+	return RuleSet{
+		SelfMintEnableHeight:    SelfMintEnableHeight,
+		AuthorityTransferHeight: AuthorityTransferHeight,
+		DeployPolicies:          DeployPolicies,
+		Limits:                  Limits,
+		StrictNumbers:           Numbers.Strict,
+		Ticks:                   ticks,
+		BurnHeight:              BurnHeight,
+		CountersHeight:          CountersHeight,
+		StrictUTF8Height:        StrictUTF8Height,
+		Protocols:               protocol.Protocols()[1:],
+		KeyScheme:               protocol.KeySchemeFlag(protocol.Keys),
+	}
+}
+
+// RulesVersion identifies the effective rules by the hex of the first 8 bytes of the SHA-256 of their JSON,
+// so that the members running different rules are told apart from their checkpoints.
+func RulesVersion() string {
+	bytes, err := json.Marshal(CurrentRules())
+	if err != nil {
+		panic(err)
+	}
+	sum := sha256.Sum256(bytes)
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,162 @@
+package brc20
+
+import (
+	"encoding/hex"
+
+	verkle "github.com/ethereum/go-verkle"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord/protocol"
+)
+
+// The version of the state schema, bumped on any change of the key construction or the value encodings,
+// including a new location ID.
+const SchemaVersion = 1
+
+// The encodings of the values in the state.
+const (
+	// A 32-byte big-endian unsigned integer, zero if the key is absent.
+	EncodingUInt256 = "uint256"
+	// The length of the bytes as an uint256 at the location, followed by the bytes right-padded with zeros
+	// in the next ceil(length / 32) slots.
+	EncodingBytes = "bytes"
+	// The 32-byte transaction ID in the hex order at the location, followed by the output index as an uint256.
+	EncodingInscriptionID = "inscriptionID"
+)
+
+// SchemaLocation is a value of a key space, stored at the location ID and the following slots if any.
+type SchemaLocation struct {
+	Name       string `json:"name"`
+	LocationID byte   `json:"locationID"`
+	Encoding   string `json:"encoding"`
+	// The maximum number of the slots taken by the value, including the location itself.
+	Slots       int    `json:"slots"`
+	Description string `json:"description"`
+}
+
+// SchemaExample is the key of the first location derived by the implementation, so that a verifier can check its own derivation.
+type SchemaExample struct {
+	Inputs []string `json:"inputs"`
+	Key    string   `json:"key"`
+}
+
+// SchemaKeySpace is a family of the keys sharing the same stem for the same inputs.
+type SchemaKeySpace struct {
+	Name string `json:"name"`
+	// The inputs concatenated into the preimage, followed by the suffix.
+	Inputs    []string         `json:"inputs"`
+	Suffix    string           `json:"suffix"`
+	Category  string           `json:"category"`
+	Locations []SchemaLocation `json:"locations"`
+	Example   SchemaExample    `json:"example"`
+}
+
+// Schema is the registry of the state keys and the value encodings, as implemented.
+type Schema struct {
+	Version int `json:"version"`
+	// The key of a value is the stem of the hash of the preimage followed by the location ID.
+	KeyRule string `json:"keyRule"`
+	Hash    string `json:"hash"`
+	// The key scheme of the hash, omitted for the legacy one, see protocol.Keys.
+	KeyScheme string            `json:"keyScheme,omitempty"`
+	StemSize  int               `json:"stemSize"`
+	SlotSize  int               `json:"slotSize"`
+	Inputs    map[string]string `json:"inputs"`
+	Encodings map[string]string `json:"encodings"`
+	KeySpaces []SchemaKeySpace  `json:"keySpaces"`
+}
+
+func schemaExample(key []byte, inputs ...string) SchemaExample {
+	return SchemaExample{Inputs: inputs, Key: hex.EncodeToString(key)}
+}
+
+// StateSchema returns the schema of the state of BRC-20, along with the example keys derived by GetTickHash and others.
+func StateSchema() Schema {
+	const (
+		exampleTick          = "ordi"
+		examplePkscript      = "5120b5a92909148dd4a5230e1c1e43f94e3ba5b8cbf33d8fa27d29c10b8a1181a8e6"
+		exampleWallet        = "bc1pkj5jjzglh99zxqu6w9vwdlpk7rqr706jw8t2jtsf4yvfrrvc6ggqlefhke"
+		exampleInscriptionID = "b61b0172d95e266c18aea0c624db987e971a5d6d4ebc2aaed85da4642d635735i0"
+	)
+	keyRule, hash := "Keccak256(inputs + suffix)[:stemSize] + locationID", "keccak256 (legacy, as Ethereum)"
+	if protocol.Keys.Name() != protocol.KeySchemeKeccak256 {
+		keyRule, hash = protocol.Keys.Rule()+" + locationID, where preimage = inputs + suffix and domain = "+Name, protocol.Keys.Name()
+	}
+	return Schema{
+		Version:   SchemaVersion,
+		KeyRule:   keyRule,
+		Hash:      hash,
+		KeyScheme: protocol.KeySchemeFlag(protocol.Keys),
+		StemSize:  verkle.StemSize,
+		SlotSize:  32,
+		Inputs: map[string]string{
+			"tick":          "The lowercase tick as UTF-8, 4 or 5 bytes.",
+			"pkscript":      "The hex of the pkscript as text, not the decoded bytes.",
+			"wallet":        "The address of the wallet as text.",
+			"inscriptionID": "The inscription ID as text, <txid>i<index>.",
+		},
+		Encodings: map[string]string{
+			EncodingUInt256:       "A 32-byte big-endian unsigned integer, zero if the key is absent.",
+			EncodingBytes:         "The length as an uint256 at the location, followed by the bytes right-padded with zeros in the next ceil(length / 32) slots, whose location IDs follow the location.",
+			EncodingInscriptionID: "The 32-byte transaction ID in the hex order at the location, followed by the output index as an uint256 at the next location ID.",
+		},
+		KeySpaces: []SchemaKeySpace{
+			{
+				Name:     "tickPkscript",
+				Inputs:   []string{"tick", "pkscript"},
+				Suffix:   "GetTickPkscriptHash",
+				Category: CategoryBalances.String(),
+				Locations: []SchemaLocation{
+					{"availableBalance", AvailableBalancePkscript, EncodingUInt256, 1, "The balance not locked in the transfer inscriptions, extended to 18 decimals."},
+					{"overallBalance", OverallBalancePkscript, EncodingUInt256, 1, "The balance including the transfer inscriptions, extended to 18 decimals."},
+				},
+				Example: schemaExample(GetTickPkscriptHash(exampleTick, examplePkscript, AvailableBalancePkscript), exampleTick, examplePkscript),
+			},
+			{
+				Name:     "tick",
+				Inputs:   []string{"tick"},
+				Suffix:   "GetTickHash",
+				Category: CategoryTicks.String(),
+				Locations: []SchemaLocation{
+					{"exists", Exists, EncodingUInt256, 1, "1 if the tick is deployed."},
+					{"remainingSupply", RemainingSupply, EncodingUInt256, 1, "The supply left to mint, extended to 18 decimals."},
+					{"maxSupply", MaxSupply, EncodingUInt256, 1, "The max supply, extended to 18 decimals."},
+					{"limitPerMint", LimitPerMint, EncodingUInt256, 1, "The limit per mint, extended to 18 decimals."},
+					{"decimals", Decimals, EncodingUInt256, 1, "The decimals of the tick."},
+					{"isSelfMint", IsSelfMint, EncodingUInt256, 1, "1 if only the children of the mint authority may mint the tick."},
+					{"inscriptionID", InscriptionID, EncodingInscriptionID, 2, "The deploy inscription."},
+					{"mintAuthorityExists", MintAuthorityExists, EncodingUInt256, 1, "1 if the mint authority of the self-mint tick has been transferred."},
+					{"mintAuthority", MintAuthority, EncodingInscriptionID, 2, "The inscription authorizing the mints of the self-mint tick, if transferred."},
+					{"burnedSupply", BurnedSupply, EncodingUInt256, 1, "The amount burned by the transfers to the unspendable outputs, extended to 18 decimals."},
+					{"holderCount", HolderCount, EncodingUInt256, 1, "The number of the pkscripts holding a positive overall balance, only counted if holdersCounted."},
+					{"mintCount", MintCount, EncodingUInt256, 1, "The number of the valid mints since the activation of the counters."},
+					{"mintedAmount", MintedAmount, EncodingUInt256, 1, "The amount minted since the activation of the counters, extended to 18 decimals."},
+					{"holdersCounted", HoldersCounted, EncodingUInt256, 1, "1 if the tick is deployed once the counters are active, whose holders are counted."},
+				},
+				Example: schemaExample(GetTickHash(exampleTick, Exists), exampleTick),
+			},
+			{
+				Name:     "wallet",
+				Inputs:   []string{"wallet"},
+				Suffix:   "GetWalletHash",
+				Category: CategoryWallets.String(),
+				Locations: []SchemaLocation{
+					{"latestPkscript", WalletLatestPkscript, EncodingBytes, verkle.NodeWidth - int(WalletLatestPkscript), "The latest pkscript received by the wallet."},
+				},
+				Example: schemaExample(GetWalletHash(exampleWallet, WalletLatestPkscript), exampleWallet),
+			},
+			{
+				Name:     "event",
+				Inputs:   []string{"inscriptionID"},
+				Suffix:   "GetEventHash",
+				Category: CategoryEvents.String(),
+				Locations: []SchemaLocation{
+					{"transferInscribeCount", TransferInscribeCount, EncodingUInt256, 1, "The number of the valid inscribes of the transfer inscription."},
+					{"transferTransferCount", TransferTransferCount, EncodingUInt256, 1, "The number of the transfers of the transfer inscription."},
+					{"transferInscribeSourceWallet", TransferInscribeSourceWallet, EncodingBytes, int(TransferInscribeSourcePkscript - TransferInscribeSourceWallet), "The base58-decoded wallet inscribing the transfer inscription."},
+					{"transferInscribeSourcePkscript", TransferInscribeSourcePkscript, EncodingBytes, verkle.NodeWidth - int(TransferInscribeSourcePkscript), "The pkscript inscribing the transfer inscription."},
+				},
+				Example: schemaExample(GetEventHash(exampleInscriptionID, TransferInscribeCount), exampleInscriptionID),
+			},
+		},
+	}
+}
@@ -0,0 +1,173 @@
+package brc20
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// The units counting the length of the ticks.
+const (
+	// The bytes of the UTF-8 encoding, as the canonical BRC-20.
+	TickLengthBytes = "bytes"
+	// The Unicode code points, so that a tick of 4 non-ASCII characters is valid as well.
+	TickLengthRunes = "runes"
+)
+
+// The characters allowed in the ticks.
+const (
+	// Any character, as the canonical BRC-20.
+	TickCharsetAny = "any"
+	// The graphic characters except the spaces.
+	TickCharsetPrintable = "printable"
+	// The printable ASCII characters except the space.
+	TickCharsetASCII = "ascii"
+)
+
+// The case foldings of the ticks.
+const (
+	// The full lowercase mapping of Unicode, as str.lower of Python used by the reference indexer.
+	TickFoldingPython = "python"
+	// The simple lowercase mapping of Unicode, as strings.ToLower of Go. It differs from the reference indexer on
+	// the capital dotted I and the final capital sigma, e.g. "ΜΣ" is folded to "μσ" instead of "μς".
+	TickFoldingSimple = "simple"
+)
+
+// TickRules is the validation of the ticks. The empty values are the lengths of 4 or 5 bytes and any character, as the
+// canonical BRC-20, and the simple case folding, which the indexer has always folded the ticks by. The folding of the
+// reference indexer is opted into, as it changes the keys of a few ticks. Re-executions of the committee must use
+// the same rules.
+type TickRules struct {
+	Length      string `json:"length"`
+	Charset     string `json:"charset"`
+	CaseFolding string `json:"caseFolding"`
+}
+
+// The rules evaluated at every tick of the inscriptions.
+var Ticks TickRules
+
+// withDefaults fills the empty values by the canonical BRC-20.
+func (r TickRules) withDefaults() TickRules {
+	if r.Length == "" {
+		r.Length = TickLengthBytes
+	}
+	if r.Charset == "" {
+		r.Charset = TickCharsetAny
+	}
+	if r.CaseFolding == "" {
+		r.CaseFolding = TickFoldingSimple
+	}
+	return r
+}
+
+func (r TickRules) Validate() error {
+	if r.Length != "" && r.Length != TickLengthBytes && r.Length != TickLengthRunes {
+		return fmt.Errorf("invalid length %s of the ticks", r.Length)
+	}
+	if r.Charset != "" && r.Charset != TickCharsetAny && r.Charset != TickCharsetPrintable && r.Charset != TickCharsetASCII {
+		return fmt.Errorf("invalid charset %s of the ticks", r.Charset)
+	}
+	if r.CaseFolding != "" && r.CaseFolding != TickFoldingPython && r.CaseFolding != TickFoldingSimple {
+		return fmt.Errorf("invalid case folding %s of the ticks", r.CaseFolding)
+	}
+	return nil
+}
+
+// Fold lowercases the tick.
+func (r TickRules) Fold(tick string) string {
+	if r.CaseFolding == TickFoldingPython {
+		return pythonLower(tick)
+	}
+	return strings.ToLower(tick)
+}
+
+// Len returns the length of the folded tick, which is 4, or 5 for the self-mint ticks.
+func (r TickRules) Len(tick string) int {
+	if r.Length == TickLengthRunes {
+		return utf8.RuneCountInString(tick)
+	}
+	return len(tick)
+}
+
+// Valid reports whether the folded tick is of a valid length and charset.
+func (r TickRules) Valid(tick string) bool {
+	if n := r.Len(tick); n != 4 && n != 5 {
+		return false
+	}
+	for _, ch := range tick {
+		switch r.Charset {
+		case TickCharsetPrintable:
+			if !unicode.IsGraphic(ch) || unicode.IsSpace(ch) {
+				return false
+			}
+		case TickCharsetASCII:
+			if ch <= ' ' || ch > '~' {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// NormalizeTick folds the tick by the rules, which keys the tick in the state.
+func NormalizeTick(tick string) string {
+	return Ticks.Fold(tick)
+}
+
+// pythonLower lowercases the string as str.lower of Python. On top of the simple mapping, the capital dotted I is
+// lowered to "i̇" and the capital sigma at the end of a word to the final sigma.
+func pythonLower(s string) string {
+	if !strings.ContainsAny(s, "İΣ") {
+		return strings.ToLower(s)
+	}
+	runes := []rune(s)
+	var b strings.Builder
+	b.Grow(len(s))
+	for i, ch := range runes {
+		switch ch {
+		case 'İ':
+			b.WriteString("i\u0307")
+		case 'Σ':
+			if finalSigma(runes, i) {
+				b.WriteRune('ς')
+			} else {
+				b.WriteRune('σ')
+			}
+		default:
+			b.WriteRune(unicode.ToLower(ch))
+		}
+	}
+	return b.String()
+}
+
+// finalSigma follows the Final_Sigma condition of Unicode as Python: the sigma is preceded by a cased letter and not
+// followed by one, skipping the case-ignorable characters.
+func finalSigma(runes []rune, i int) bool {
+	j := i - 1
+	for j >= 0 && caseIgnorable(runes[j]) {
+		j--
+	}
+	if j < 0 || !cased(runes[j]) {
+		return false
+	}
+	j = i + 1
+	for j < len(runes) && caseIgnorable(runes[j]) {
+		j++
+	}
+	return j == len(runes) || !cased(runes[j])
+}
+
+func cased(ch rune) bool {
+	return unicode.IsUpper(ch) || unicode.IsLower(ch) || unicode.IsTitle(ch) ||
+		unicode.Is(unicode.Other_Lowercase, ch) || unicode.Is(unicode.Other_Uppercase, ch)
+}
+
+// caseIgnorable approximates the Case_Ignorable property by its categories and the word-internal punctuations.
+func caseIgnorable(ch rune) bool {
+	switch ch {
+	case '\'', '.', ':', '·', '·', '״', '‘', '’', '․', '‧', '︓', '﹒', '﹕', '＇', '．', '：':
+		return true
+	}
+	return unicode.In(ch, unicode.Mn, unicode.Me, unicode.Cf, unicode.Lm, unicode.Sk)
+}
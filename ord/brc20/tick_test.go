@@ -0,0 +1,67 @@
+package brc20
+
+import "testing"
+
+func TestTickRules(t *testing.T) {
+	defer func() { Ticks = TickRules{} }()
+	folds := []struct {
+		tick, python, simple string
+	}{
+		{"ORDI", "ordi", "ordi"},
+		// The final sigma is lowered as by the reference indexer, if opted into.
+		{"ΜΣ", "μς", "μσ"},
+		{"ΣΜ", "σμ", "σμ"},
+		{"Μ'Σ", "μ'ς", "μ'σ"},
+		{"ΜΣΑ", "μσα", "μσα"},
+		{"İBX", "i̇bx", "ibx"},
+	}
+	for _, f := range folds {
+		if folded := (TickRules{CaseFolding: TickFoldingPython}).Fold(f.tick); folded != f.python {
+			t.Fatalf("The tick %s is folded to %s, expected %s", f.tick, folded, f.python)
+		}
+		if folded := (TickRules{}).Fold(f.tick); folded != f.simple {
+			t.Fatalf("The tick %s is folded simply to %s, expected %s", f.tick, folded, f.simple)
+		}
+	}
+
+	valid := []struct {
+		rules TickRules
+		tick  string
+		valid bool
+	}{
+		{TickRules{}, "ordi", true},
+		{TickRules{}, "sats5", true},
+		{TickRules{}, "ord", false},
+		{TickRules{}, "μς", true},
+		{TickRules{}, "μςμς", false},
+		{TickRules{}, "a b\x00", true},
+		{TickRules{Length: TickLengthRunes}, "μςμς", true},
+		{TickRules{Length: TickLengthRunes}, "μς", false},
+		{TickRules{Charset: TickCharsetPrintable}, "a b\x00", false},
+		{TickRules{Charset: TickCharsetPrintable}, "μς!", true},
+		{TickRules{Charset: TickCharsetASCII}, "μς", false},
+		{TickRules{Charset: TickCharsetASCII}, "$ord", true},
+	}
+	for _, v := range valid {
+		if v.rules.Valid(v.tick) != v.valid {
+			t.Fatalf("The tick %q is valid: %v by the rules %+v, expected %v", v.tick, !v.valid, v.rules, v.valid)
+		}
+	}
+
+	if err := (TickRules{Length: "chars"}).Validate(); err == nil {
+		t.Fatal("Expected the unknown length to be rejected")
+	}
+	// The explicit default rules keep the rules version, the others change it, including the folding of the
+	// reference indexer.
+	version := RulesVersion()
+	Ticks = TickRules{Length: TickLengthBytes, Charset: TickCharsetAny, CaseFolding: TickFoldingSimple}
+	if RulesVersion() != version {
+		t.Fatal("Expected the default tick rules to keep the rules version")
+	}
+	for _, rules := range []TickRules{{Length: TickLengthRunes}, {CaseFolding: TickFoldingPython}} {
+		Ticks = rules
+		if RulesVersion() == version {
+			t.Fatalf("Expected the tick rules %+v to change the rules version", rules)
+		}
+	}
+}
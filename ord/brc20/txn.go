@@ -0,0 +1,292 @@
+package brc20
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	uint256 "github.com/holiman/uint256"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/protocol"
+)
+
+// FailureObserver is optionally implemented by the KVStorage diagnosing the transfers skipped by a failure,
+// such as a malformed value rejected by the state, which would otherwise crash the node mid-block.
+type FailureObserver interface {
+	ObserveFailure(ot ord.OrdTransfer, blockHeight uint, err error)
+}
+
+// PanicError is the failure of a transfer whose execution panicked.
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// txn buffers the writes and the observations of a transfer, which reach the state only if the transfer succeeds.
+// A failing transfer is thus skipped as a whole by every state, including the stateless one of the re-execution.
+//
+// The rules read and write through the unexported methods, which keep the first error of the transfer instead of
+// returning it: after a failure, the reads return zero values and nothing is committed, so the rules may go on.
+// A key is always read and written as the same type, so the written values are buffered by their types.
+type txn struct {
+	state KVStorage
+	err   error
+
+	uint256s map[string]*uint256.Int
+	bytes    map[string][]byte
+	ids      map[string]string
+	// The keys deleted by the transfer and not written since, which read as zeros.
+	deleted map[string]bool
+	// The writes and the observations, applied to the state in order by commit.
+	ops []func() error
+	// Whether the per-tick counters are maintained at the height of the transfer.
+	counted bool
+}
+
+// The txns and their buffered values are reused across the transfers, which relieves the GC of the catch-up.
+var (
+	txns     = sync.Pool{New: func() any { return new(txn) }}
+	uint256s = sync.Pool{New: func() any { return new(uint256.Int) }}
+)
+
+func newTxn(state KVStorage) *txn {
+	t := txns.Get().(*txn)
+	t.state = state
+	return t
+}
+
+// release returns the txn to the pool once it's committed. The states copy the keys and the values written to
+// them, so the buffered values are released along with the txn.
+func (t *txn) release() {
+	for _, value := range t.uint256s {
+		uint256s.Put(value)
+	}
+	clear(t.uint256s)
+	clear(t.bytes)
+	clear(t.ids)
+	clear(t.deleted)
+	clear(t.ops)
+	t.ops = t.ops[:0]
+	t.state, t.err, t.counted = nil, nil, false
+	txns.Put(t)
+}
+
+// fail keeps the first error of the transfer.
+func (t *txn) fail(err error) {
+	if t.err == nil {
+		t.err = err
+	}
+}
+
+// commit applies the writes and the observations to the state, unless the transfer failed. The values are checked
+// when they are buffered, so an error here is a failure of the state itself rather than of the transfer, which
+// panics as a protocol.StorageError, since the writes before it are applied already.
+func (t *txn) commit() error {
+	if t.err != nil {
+		return t.err
+	}
+	for _, op := range t.ops {
+		if err := op(); err != nil {
+			panic(&protocol.StorageError{Err: fmt.Errorf("failed to commit the transfer: %w", err)})
+		}
+	}
+	return nil
+}
+
+func (t *txn) InsertInscriptionID(key []byte, value string) error {
+	if _, _, err := protocol.SplitInscriptionID(value); err != nil {
+		return err
+	}
+	if err := protocol.CheckKey(key); err != nil {
+		return err
+	}
+	key = bytes.Clone(key)
+	if t.ids == nil {
+		t.ids = make(map[string]string)
+	}
+	t.ids[string(key)] = value
+	delete(t.deleted, string(key))
+	t.ops = append(t.ops, func() error { return t.state.InsertInscriptionID(key, value) })
+	return nil
+}
+
+func (t *txn) GetInscriptionID(key []byte) (string, error) {
+	if value, found := t.ids[string(key)]; found {
+		return value, nil
+	}
+	if t.deleted[string(key)] {
+		return hex.EncodeToString(make([]byte, protocol.SlotSize)) + "i0", nil
+	}
+	return t.state.GetInscriptionID(key)
+}
+
+func (t *txn) InsertUInt256(key []byte, value *uint256.Int) error {
+	if err := protocol.CheckKey(key); err != nil {
+		return err
+	}
+	key, value = bytes.Clone(key), uint256s.Get().(*uint256.Int).Set(value)
+	if t.uint256s == nil {
+		t.uint256s = make(map[string]*uint256.Int)
+	}
+	t.uint256s[string(key)] = value
+	delete(t.deleted, string(key))
+	t.ops = append(t.ops, func() error { return t.state.InsertUInt256(key, value) })
+	return nil
+}
+
+func (t *txn) GetUInt256(key []byte) (*uint256.Int, error) {
+	if value, found := t.uint256s[string(key)]; found {
+		return uint256s.Get().(*uint256.Int).Set(value), nil
+	}
+	if t.deleted[string(key)] {
+		return uint256.NewInt(0), nil
+	}
+	return t.state.GetUInt256(key)
+}
+
+func (t *txn) InsertBytes(key []byte, value []byte) error {
+	if err := protocol.CheckBytes(key, value); err != nil {
+		return err
+	}
+	key, value = bytes.Clone(key), bytes.Clone(value)
+	if t.bytes == nil {
+		t.bytes = make(map[string][]byte)
+	}
+	t.bytes[string(key)] = value
+	delete(t.deleted, string(key))
+	t.ops = append(t.ops, func() error { return t.state.InsertBytes(key, value) })
+	return nil
+}
+
+func (t *txn) GetBytes(key []byte) ([]byte, error) {
+	if value, found := t.bytes[string(key)]; found {
+		return bytes.Clone(value), nil
+	}
+	if t.deleted[string(key)] {
+		return make([]byte, 0), nil
+	}
+	return t.state.GetBytes(key)
+}
+
+func (t *txn) Delete(key []byte) error {
+	if err := protocol.CheckKey(key); err != nil {
+		return err
+	}
+	key = bytes.Clone(key)
+	delete(t.ids, string(key))
+	delete(t.uint256s, string(key))
+	delete(t.bytes, string(key))
+	if t.deleted == nil {
+		t.deleted = make(map[string]bool)
+	}
+	t.deleted[string(key)] = true
+	t.ops = append(t.ops, func() error { return t.state.Delete(key) })
+	return nil
+}
+
+// Range ranges the state, without the writes buffered by the transfer.
+func (t *txn) Range(prefix []byte, fn func(key []byte, value []byte) bool) error {
+	return t.state.Range(prefix, fn)
+}
+
+func (t *txn) GetHeight() uint {
+	return t.state.GetHeight()
+}
+
+func (t *txn) insertInscriptionID(key []byte, value string) {
+	if t.err == nil {
+		t.fail(t.InsertInscriptionID(key, value))
+	}
+}
+
+func (t *txn) getInscriptionID(key []byte) string {
+	if t.err != nil {
+		return ""
+	}
+	value, err := t.GetInscriptionID(key)
+	t.fail(err)
+	return value
+}
+
+func (t *txn) insertUInt256(key []byte, value *uint256.Int) {
+	if t.err == nil {
+		t.fail(t.InsertUInt256(key, value))
+	}
+}
+
+func (t *txn) getUInt256(key []byte) *uint256.Int {
+	if t.err != nil {
+		return uint256.NewInt(0)
+	}
+	value, err := t.GetUInt256(key)
+	if err != nil {
+		t.fail(err)
+		return uint256.NewInt(0)
+	}
+	return value
+}
+
+func (t *txn) insertBytes(key []byte, value []byte) {
+	if t.err == nil {
+		t.fail(t.InsertBytes(key, value))
+	}
+}
+
+func (t *txn) getBytes(key []byte) []byte {
+	if t.err != nil {
+		return make([]byte, 0)
+	}
+	value, err := t.GetBytes(key)
+	if err != nil {
+		t.fail(err)
+		return make([]byte, 0)
+	}
+	return value
+}
+
+// The observations are buffered as the ops which never fail, and only if the state follows them.
+
+func (t *txn) ObserveCategory(key []byte, category Category) {
+	if o, ok := t.state.(CategoryObserver); ok {
+		key = bytes.Clone(key)
+		t.ops = append(t.ops, func() error { o.ObserveCategory(key, category); return nil })
+	}
+}
+
+func (t *txn) ObserveTick(tick string, deployed bool) {
+	if o, ok := t.state.(TickObserver); ok {
+		t.ops = append(t.ops, func() error { o.ObserveTick(tick, deployed); return nil })
+	}
+}
+
+func (t *txn) ObserveHolder(tick string, pkscript ord.Pkscript) {
+	if o, ok := t.state.(HolderObserver); ok {
+		t.ops = append(t.ops, func() error { o.ObserveHolder(tick, pkscript); return nil })
+	}
+}
+
+func (t *txn) ObserveDeploy(tick string, pkscript ord.Pkscript, wallet ord.Wallet) {
+	if o, ok := t.state.(DeployObserver); ok {
+		t.ops = append(t.ops, func() error { o.ObserveDeploy(tick, pkscript, wallet); return nil })
+	}
+}
+
+func (t *txn) ObserveEvent(event Event) {
+	if o, ok := t.state.(EventObserver); ok {
+		t.ops = append(t.ops, func() error { o.ObserveEvent(event); return nil })
+	}
+}
+
+// ObserveStage isn't buffered, since the time is spent whether the transfer succeeds or not.
+func (t *txn) ObserveStage(name string, started time.Time, elapsed time.Duration) {
+	if o, ok := t.state.(protocol.StageObserver); ok {
+		o.ObserveStage(name, started, elapsed)
+	}
+}
@@ -0,0 +1,75 @@
+package brc20
+
+import (
+	"github.com/RiemaLabs/modular-indexer-committee/ord"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/protocol"
+)
+
+// KVStorage is the state read and written by the execution, whose keys are the root namespace of the protocols.
+type KVStorage = protocol.KVStorage
+
+// Category is the kind of the entities of the state. Keys are hashed, so their category is only known when written.
+type Category int
+
+const (
+	CategoryOther Category = iota
+	CategoryBalances
+	CategoryTicks
+	CategoryWallets
+	CategoryEvents
+	NumCategories
+)
+
+var categoryNames = [NumCategories]string{"other", "balances", "ticks", "wallets", "events"}
+
+func (c Category) String() string {
+	return categoryNames[c]
+}
+
+// CategoryObserver is optionally implemented by the KVStorage following the growth of each category.
+// The category applies to every key sharing the stem of the written key.
+type CategoryObserver interface {
+	ObserveCategory(key []byte, category Category)
+}
+
+func observe(state KVStorage, key []byte, category Category) {
+	if o, ok := state.(CategoryObserver); ok {
+		o.ObserveCategory(key, category)
+	}
+}
+
+// TickObserver is optionally implemented by the KVStorage following the deploys and the mints of the ticks,
+// whose names are lost in the hashed keys.
+type TickObserver interface {
+	ObserveTick(tick string, deployed bool)
+}
+
+func observeTick(state KVStorage, tick string, deployed bool) {
+	if o, ok := state.(TickObserver); ok {
+		o.ObserveTick(tick, deployed)
+	}
+}
+
+// HolderObserver is optionally implemented by the KVStorage following the holders of the ticks,
+// whose pkscripts are lost in the hashed keys. It's called whenever an overall balance may change.
+type HolderObserver interface {
+	ObserveHolder(tick string, pkscript ord.Pkscript)
+}
+
+func observeHolder(state KVStorage, tick string, pkscript ord.Pkscript) {
+	if o, ok := state.(HolderObserver); ok {
+		o.ObserveHolder(tick, pkscript)
+	}
+}
+
+// DeployObserver is optionally implemented by the KVStorage following the deployers of the ticks,
+// which aren't kept by the state.
+type DeployObserver interface {
+	ObserveDeploy(tick string, pkscript ord.Pkscript, wallet ord.Wallet)
+}
+
+func observeDeploy(state KVStorage, tick string, pkscript ord.Pkscript, wallet ord.Wallet) {
+	if o, ok := state.(DeployObserver); ok {
+		o.ObserveDeploy(tick, pkscript, wallet)
+	}
+}
@@ -0,0 +1,170 @@
+package brc20
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	base58 "github.com/btcsuite/btcd/btcutil/base58"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord"
+
+	uint256 "github.com/holiman/uint256"
+)
+
+// Start Height of the Self-Mint, the one of the indexed network, see ord.Network.SelfMintHeight.
+var SelfMintEnableHeight uint = ord.Mainnet.SelfMintHeight()
+
+// The activation height of transferring the mint authority of the self-mint ticks, 0 disables it
+// until a proposal defines one.
+var AuthorityTransferHeight uint = 0
+
+func authorityTransferEnabled(blockHeight uint) bool {
+	return AuthorityTransferHeight != 0 && blockHeight >= AuthorityTransferHeight
+}
+
+// The activation height of the burns, from which a transfer spent to a provably unspendable output burns its amount
+// instead of crediting the output, 0 disables it.
+var BurnHeight uint = 0
+
+func burnEnabled(blockHeight uint) bool {
+	return BurnHeight != 0 && blockHeight >= BurnHeight
+}
+
+// The activation height of the per-tick counters, from which the holders, the mints and the minted amount of each
+// tick are counted in the state, 0 disables it. The counters only count the blocks from the activation height on.
+var CountersHeight uint = 0
+
+func countersEnabled(blockHeight uint) bool {
+	return CountersHeight != 0 && blockHeight >= CountersHeight
+}
+
+// The activation height of the validation of UTF-8, from which the content that isn't valid UTF-8 is rejected as by
+// the reference indexer instead of having its invalid bytes replaced, 0 disables it.
+var StrictUTF8Height uint = 0
+
+func strictUTF8Enabled(blockHeight uint) bool {
+	return StrictUTF8Height != 0 && blockHeight >= StrictUTF8Height
+}
+
+// IsUnspendable tells whether the pkscript is provably unspendable, i.e. it starts with OP_RETURN.
+func IsUnspendable(pkscript ord.Pkscript) bool {
+	return strings.HasPrefix(strings.ToLower(string(pkscript)), "6a")
+}
+
+// NumberRules tightens the validation of the numbers of the inscriptions, i.e. max, lim, amt and dec.
+// Re-executions of the committee must use the same rules.
+type NumberRules struct {
+	// Strict matches the reference indexer exactly: on top of rejecting the empty values, the scientific notation,
+	// the signs and the amounts with more decimal places than the tick, which every ruleset does, it only accepts
+	// the ASCII digits and rejects the numbers longer than MaxNumberLength, e.g. padded by zeros.
+	Strict bool `json:"strict"`
+}
+
+// MaxNumberLength is the length of the longest number of the strict rules, the integer digits of the max supply
+// (2^64-1), the dot and 18 decimals.
+const MaxNumberLength = 39
+
+// The rules evaluated at every number of the inscriptions.
+var Numbers NumberRules
+
+func isPositiveNumber(s string, doStrip bool) bool {
+	if doStrip {
+		s = strings.TrimSpace(s)
+	}
+	if len(s) == 0 || (Numbers.Strict && len(s) > MaxNumberLength) {
+		return false
+	}
+	for _, ch := range s {
+		if Numbers.Strict && (ch < '0' || ch > '9') {
+			return false
+		}
+		if !unicode.IsDigit(ch) {
+			return false
+		}
+	}
+	return true
+}
+
+func isPositiveNumberWithDot(s string, doStrip bool) bool {
+	if doStrip {
+		s = strings.TrimSpace(s)
+	}
+	if len(s) == 0 || s[0] == '.' || s[len(s)-1] == '.' || (Numbers.Strict && len(s) > MaxNumberLength) {
+		return false
+	}
+	dotFound := false
+	for _, ch := range s {
+		if ch < '0' || ch > '9' {
+			if ch != '.' || dotFound {
+				return false
+			}
+			dotFound = true
+		}
+	}
+	return true
+}
+
+func getNumberExtendedTo18Decimals(s string, decimals *uint256.Int, doStrip bool) (*uint256.Int, error) {
+	if doStrip {
+		s = strings.TrimSpace(s)
+	}
+
+	eighteen := uint256.NewInt(18)
+
+	if strings.Contains(s, ".") {
+		parts := strings.Split(s, ".")
+		normalPart := parts[0]
+		decimalPart := parts[1]
+
+		decimalLength := uint256.NewInt(uint64(len(decimalPart)))
+
+		if decimalLength.Gt(decimals) || len(decimalPart) == 0 {
+			// More decimal digits than allowed or no decimal digits
+			return nil, nil
+		}
+
+		// Ensure decimal part is not longer than decimals and extend to 18 digits
+		requiredZeros := eighteen.Sub(eighteen, decimalLength)
+		decimalPart += strings.Repeat("0", int(requiredZeros.Uint64()))
+
+		// Convert the concatenated string to *uint256.Int
+		result, err := uint256.FromDecimal(normalPart + decimalPart)
+		if err != nil {
+			return nil, fmt.Errorf("number overflow: %s", normalPart+decimalPart)
+		}
+		return result, nil
+	} else {
+		// No decimal point, directly extend to 18 digits
+		result, err := uint256.FromDecimal(s + strings.Repeat("0", 18))
+		if err != nil {
+			return nil, fmt.Errorf("number overflow: %s", s)
+		}
+		return result, nil
+	}
+}
+
+func getLimit() *uint256.Int {
+	two64Minus1 := uint256.NewInt(0).Sub(uint256.NewInt(0).Lsh(uint256.NewInt(1), 64), uint256.NewInt(1))
+
+	// Create a uint256.Int representation of (10^18)
+	ten18 := uint256.NewInt(0)
+	for i := 0; i < 18; i++ {
+		ten18 = ten18.Mul(ten18, uint256.NewInt(10))
+		if i == 0 { // Initialize to 10 on the first iteration
+			ten18 = uint256.NewInt(10)
+		}
+	}
+
+	// Calculate (2^64 - 1) * (10^18)
+	result := uint256.NewInt(0).Mul(two64Minus1, ten18)
+	return result
+}
+
+func decodeBitcoinWallet(s string) []byte {
+	return base58.Decode(s)
+}
+
+func encodeBitcoinWallet(b []byte) string {
+	return base58.Encode(b)
+}
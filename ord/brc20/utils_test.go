@@ -0,0 +1,86 @@
+package brc20
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/holiman/uint256"
+)
+
+// numberVector is a number of an inscription along with whether the default and the strict rules accept it
+// for a tick of 2 decimals.
+type numberVector struct {
+	s       string
+	dot     bool
+	lax     bool
+	strict  bool
+	extends string
+}
+
+var numberVectors = []numberVector{
+	{s: "", dot: true},
+	{s: "1e5", dot: true},
+	{s: "1E5", dot: true},
+	{s: "+5", dot: true},
+	{s: "-5", dot: true},
+	{s: " 5", dot: true},
+	{s: "5 ", dot: true},
+	{s: "1.", dot: true},
+	{s: ".5", dot: true},
+	{s: "1.2.3", dot: true},
+	{s: "0x10", dot: true},
+	{s: "1_000", dot: true},
+	{s: "５", dot: true},
+	// More decimal places than the tick are parsed but rejected by the extension.
+	{s: "1.234", dot: true, lax: true, strict: true},
+	{s: "1.5", dot: true, lax: true, strict: true, extends: "1500000000000000000"},
+	{s: "00.5", dot: true, lax: true, strict: true, extends: "500000000000000000"},
+	{s: "18446744073709551615.000000000000000000", dot: true, lax: true, strict: true},
+	{s: strings.Repeat("0", 100) + "1", dot: true, lax: true, extends: "1000000000000000000"},
+	{s: "18446744073709551615.0000000000000000000", dot: true, lax: true},
+	{s: "18", lax: true, strict: true},
+	{s: "018", lax: true, strict: true},
+	{s: "+1"},
+	{s: "١٨", lax: true},
+	{s: strings.Repeat("0", 40) + "2", lax: true},
+}
+
+func TestNumberRules(t *testing.T) {
+	defer func() { Numbers = NumberRules{} }()
+	for _, strict := range []bool{false, true} {
+		Numbers.Strict = strict
+		for _, v := range numberVectors {
+			expected := v.lax
+			if strict {
+				expected = v.strict
+			}
+			var accepted bool
+			if v.dot {
+				accepted = isPositiveNumberWithDot(v.s, false)
+			} else {
+				accepted = isPositiveNumber(v.s, false)
+			}
+			if accepted != expected {
+				t.Fatalf("The number %q is accepted: %v with the strict rules: %v, expected %v", v.s, accepted, strict, expected)
+			}
+			if !accepted || v.extends == "" {
+				continue
+			}
+			extended, err := getNumberExtendedTo18Decimals(v.s, uint256.NewInt(2), false)
+			if err != nil || extended == nil || extended.Dec() != v.extends {
+				t.Fatalf("The number %q is extended to %v, %v, expected %s", v.s, extended, err, v.extends)
+			}
+		}
+	}
+	if extended, _ := getNumberExtendedTo18Decimals("1.234", uint256.NewInt(2), false); extended != nil {
+		t.Fatalf("Expected more decimal places than the tick to be rejected, got %v", extended)
+	}
+
+	// The strict rules change the rules version, the default ones keep it.
+	Numbers.Strict = false
+	version := RulesVersion()
+	Numbers.Strict = true
+	if RulesVersion() == version {
+		t.Fatal("Expected the strict rules to change the rules version")
+	}
+}
@@ -0,0 +1,206 @@
+package getter
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/RiemaLabs/modular-indexer-committee/internal/metrics"
+)
+
+// Invalidator is implemented by the getters keeping the results of the blocks, which drop the results from the
+// first block of a reorg on.
+type Invalidator interface {
+	Invalidate(fromHeight uint)
+}
+
+const (
+	cacheOpBlockHash = "blockHash"
+	cacheOpTransfers = "transfers"
+)
+
+type cacheKey struct {
+	op     string
+	height uint
+}
+
+type cacheEntry struct {
+	key       cacheKey
+	hash      string
+	transfers []OrdTransfer
+	expiresAt time.Time
+}
+
+// Cache keeps the recent results of the wrapped getter, so that the re-executions of the rollbacks and the replays
+// of the APIs don't query the upstream again. The blocks are evicted by the least recent use and by the TTL.
+//
+// The hashes of the blocks within the confirmations of the latest height aren't cached, so that the reorgs are still
+// seen by their hashes. The transfers are dropped by Invalidate from the first block of the reorg on.
+type Cache struct {
+	OrdGetter
+	size          int
+	ttl           time.Duration
+	latestTTL     time.Duration
+	confirmations uint
+
+	mu      sync.Mutex
+	entries map[cacheKey]*list.Element
+	lru     *list.List
+	// The latest height of the upstream, and the highest block seen so far.
+	latest   uint
+	latestAt time.Time
+	tip      uint
+}
+
+// NewCache caches at most size results of the blocks for the ttl, and the latest height for the latestTTL.
+// A zero TTL never expires the blocks, while a zero latestTTL always queries the latest height.
+func NewCache(g OrdGetter, size int, ttl, latestTTL time.Duration, confirmations uint) *Cache {
+	return &Cache{
+		OrdGetter:     g,
+		size:          size,
+		ttl:           ttl,
+		latestTTL:     latestTTL,
+		confirmations: confirmations,
+		entries:       make(map[cacheKey]*list.Element),
+		lru:           list.New(),
+	}
+}
+
+// ConcurrentFetch follows the wrapped getter, since the missed blocks are fetched by it.
+func (c *Cache) ConcurrentFetch() bool {
+	concurrent, ok := c.OrdGetter.(ConcurrentGetter)
+	return ok && concurrent.ConcurrentFetch()
+}
+
+func observeCache(op string, hit bool) {
+	if hit {
+		metrics.GetterCache.WithLabelValues(op, "hit").Inc()
+	} else {
+		metrics.GetterCache.WithLabelValues(op, "miss").Inc()
+	}
+}
+
+// lookup returns the unexpired entry of the key, which shall be called with the lock held.
+func (c *Cache) lookup(key cacheKey) (*cacheEntry, bool) {
+	elem, found := c.entries[key]
+	if !found {
+		return nil, false
+	}
+	entry := elem.Value.(*cacheEntry)
+	if c.ttl > 0 && time.Now().After(entry.expiresAt) {
+		c.lru.Remove(elem)
+		delete(c.entries, key)
+		return nil, false
+	}
+	c.lru.MoveToFront(elem)
+	return entry, true
+}
+
+// store keeps the entry as the most recently used one, which shall be called with the lock held.
+func (c *Cache) store(entry *cacheEntry) {
+	if c.size <= 0 {
+		return
+	}
+	entry.expiresAt = time.Now().Add(c.ttl)
+	if elem, found := c.entries[entry.key]; found {
+		elem.Value = entry
+		c.lru.MoveToFront(elem)
+		return
+	}
+	c.entries[entry.key] = c.lru.PushFront(entry)
+	for c.lru.Len() > c.size {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+func (c *Cache) seen(height uint) {
+	if height > c.tip {
+		c.tip = height
+	}
+}
+
+func (c *Cache) GetLatestBlockHeight() (uint, error) {
+	c.mu.Lock()
+	if c.latestTTL > 0 && !c.latestAt.IsZero() && time.Since(c.latestAt) < c.latestTTL {
+		latest := c.latest
+		c.mu.Unlock()
+		observeCache("latestHeight", true)
+		return latest, nil
+	}
+	c.mu.Unlock()
+	observeCache("latestHeight", false)
+
+	latest, err := c.OrdGetter.GetLatestBlockHeight()
+	if err != nil {
+		return 0, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.latest, c.latestAt = latest, time.Now()
+	c.seen(latest)
+	return latest, nil
+}
+
+func (c *Cache) GetBlockHash(blockHeight uint) (string, error) {
+	key := cacheKey{op: cacheOpBlockHash, height: blockHeight}
+	c.mu.Lock()
+	entry, found := c.lookup(key)
+	c.mu.Unlock()
+	observeCache(cacheOpBlockHash, found)
+	if found {
+		return entry.hash, nil
+	}
+
+	hash, err := c.OrdGetter.GetBlockHash(blockHeight)
+	if err != nil {
+		return "", err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if blockHeight+c.confirmations <= c.tip {
+		c.store(&cacheEntry{key: key, hash: hash})
+	}
+	return hash, nil
+}
+
+func (c *Cache) GetOrdTransfers(blockHeight uint) ([]OrdTransfer, error) {
+	key := cacheKey{op: cacheOpTransfers, height: blockHeight}
+	c.mu.Lock()
+	entry, found := c.lookup(key)
+	c.mu.Unlock()
+	observeCache(cacheOpTransfers, found)
+	if found {
+		return entry.transfers, nil
+	}
+
+	transfers, err := c.OrdGetter.GetOrdTransfers(blockHeight)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.seen(blockHeight)
+	c.store(&cacheEntry{key: key, transfers: transfers})
+	return transfers, nil
+}
+
+// Invalidate drops the cached blocks from the height on, whose transfers may belong to the reorganized chain.
+func (c *Cache) Invalidate(fromHeight uint) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, elem := range c.entries {
+		if key.height >= fromHeight {
+			c.lru.Remove(elem)
+			delete(c.entries, key)
+		}
+	}
+}
+
+// Len returns the number of the cached results of the blocks.
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
@@ -0,0 +1,98 @@
+package getter
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// countingGetter serves the blocks of a chain whose hashes change by the fork, counting the upstream queries.
+type countingGetter struct {
+	latest                     uint
+	fork                       string
+	latests, hashes, transfers int
+}
+
+func (g *countingGetter) GetLatestBlockHeight() (uint, error) {
+	g.latests++
+	return g.latest, nil
+}
+
+func (g *countingGetter) GetBlockHash(blockHeight uint) (string, error) {
+	g.hashes++
+	return fmt.Sprintf("%s%d", g.fork, blockHeight), nil
+}
+
+func (g *countingGetter) GetOrdTransfers(blockHeight uint) ([]OrdTransfer, error) {
+	g.transfers++
+	return []OrdTransfer{{BlockHeight: blockHeight, InscriptionID: g.fork}}, nil
+}
+
+func TestCache(t *testing.T) {
+	g := &countingGetter{latest: 120}
+	c := NewCache(g, 12, time.Hour, time.Hour, 7)
+	if latest, _ := c.GetLatestBlockHeight(); latest != 120 {
+		t.Fatalf("Unexpected latest height %d", latest)
+	}
+	g.latest = 121
+	if latest, _ := c.GetLatestBlockHeight(); latest != 120 || g.latests != 1 {
+		t.Fatalf("Expected the cached latest height, got %d after %d queries", latest, g.latests)
+	}
+
+	for i := 0; i < 2; i++ {
+		for height := uint(110); height <= 115; height++ {
+			if ots, err := c.GetOrdTransfers(height); err != nil || ots[0].BlockHeight != height {
+				t.Fatalf("Unexpected transfers of the block %d: %v, %v", height, ots, err)
+			}
+			if _, err := c.GetBlockHash(height); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if g.transfers != 6 {
+		t.Fatalf("Expected the transfers to be fetched once, got %d queries", g.transfers)
+	}
+	// The hashes of the blocks within the confirmations are queried every time.
+	if g.hashes != 8 {
+		t.Fatalf("Expected the final hashes to be fetched once, got %d queries", g.hashes)
+	}
+
+	// A reorg drops the transfers from its first block on.
+	g.fork = "fork"
+	c.Invalidate(114)
+	if ots, _ := c.GetOrdTransfers(113); ots[0].InscriptionID != "" {
+		t.Fatal("Expected the transfers before the reorg to be kept")
+	}
+	if ots, _ := c.GetOrdTransfers(114); ots[0].InscriptionID != "fork" {
+		t.Fatal("Expected the transfers of the reorganized block to be fetched again")
+	}
+
+	// The least recently used blocks are evicted beyond the size.
+	for height := uint(90); height < 100; height++ {
+		_, _ = c.GetOrdTransfers(height)
+	}
+	if c.Len() != 12 {
+		t.Fatalf("Unexpected %d cached results", c.Len())
+	}
+	queries := g.transfers
+	_, _ = c.GetOrdTransfers(110)
+	if g.transfers != queries+1 {
+		t.Fatal("Expected the evicted block to be fetched again")
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	g := &countingGetter{latest: 100}
+	c := NewCache(g, 8, time.Millisecond, 0, 6)
+	_, _ = c.GetLatestBlockHeight()
+	_, _ = c.GetLatestBlockHeight()
+	if g.latests != 2 {
+		t.Fatalf("Expected the latest height to be queried every time, got %d queries", g.latests)
+	}
+	_, _ = c.GetOrdTransfers(90)
+	time.Sleep(5 * time.Millisecond)
+	_, _ = c.GetOrdTransfers(90)
+	if g.transfers != 2 {
+		t.Fatalf("Expected the expired block to be fetched again, got %d queries", g.transfers)
+	}
+}
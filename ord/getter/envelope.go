@@ -0,0 +1,214 @@
+package getter
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// The tags of the envelope fields, as defined by ord.
+const (
+	tagContentType     = 1
+	tagPointer         = 2
+	tagParent          = 3
+	tagMetadata        = 5
+	tagMetaprotocol    = 7
+	tagContentEncoding = 9
+	tagDelegate        = 11
+	tagRune            = 13
+	tagNote            = 15
+)
+
+// Envelope is an inscription parsed from the tapscript of an input, following the envelope of ord:
+// OP_FALSE OP_IF "ord" <tag> <value> ... OP_0 <body> ... OP_ENDIF.
+type Envelope struct {
+	// The input of the envelope, and its index among the envelopes of the input.
+	Input  int
+	Offset int
+
+	ContentType     string
+	ContentEncoding []byte
+	Content         []byte
+	// The raw pointer field, nil if absent. An inscription with a pointer is cursed.
+	Pointer  []byte
+	ParentID string
+
+	// The signs of the cursed inscriptions, other than the reinscriptions.
+	Pushnum               bool
+	Stutter               bool
+	DuplicateField        bool
+	IncompleteField       bool
+	UnrecognizedEvenField bool
+}
+
+// Cursed tells whether the inscription is cursed by ord, which is never a valid BRC-20 inscription even if vindicated.
+// The reinscriptions are cursed too, while telling them apart needs the index of every inscribed sat.
+func (e *Envelope) Cursed() bool {
+	return e.Input != 0 || e.Offset != 0 || e.Pointer != nil || e.Pushnum || e.Stutter ||
+		e.DuplicateField || e.IncompleteField || e.UnrecognizedEvenField
+}
+
+// tapscript returns the script of a taproot script path spend, which is followed by the control block and the annex if any.
+func tapscript(witness wire.TxWitness) []byte {
+	n := len(witness)
+	if n >= 2 && len(witness[n-1]) != 0 && witness[n-1][0] == txscript.TaprootAnnexTag {
+		n--
+	}
+	if n < 2 {
+		return nil
+	}
+	return witness[n-2]
+}
+
+type instruction struct {
+	opcode byte
+	data   []byte
+}
+
+func (i instruction) isPush() bool {
+	return i.opcode <= txscript.OP_PUSHDATA4
+}
+
+func (i instruction) isEmptyPush() bool {
+	return i.isPush() && len(i.data) == 0
+}
+
+// ParseEnvelopes returns the envelopes of every input of the transaction, in the order of the inscription IDs.
+// The inputs whose tapscript can't be parsed have no envelopes.
+func ParseEnvelopes(tx *wire.MsgTx) []Envelope {
+	var envelopes []Envelope
+	for input, in := range tx.TxIn {
+		script := tapscript(in.Witness)
+		if script == nil {
+			continue
+		}
+		var instructions []instruction
+		tokenizer := txscript.MakeScriptTokenizer(0, script)
+		for tokenizer.Next() {
+			instructions = append(instructions, instruction{opcode: tokenizer.Opcode(), data: tokenizer.Data()})
+		}
+		if tokenizer.Err() != nil {
+			continue
+		}
+		envelopes = append(envelopes, parseTapscript(instructions, input)...)
+	}
+	return envelopes
+}
+
+func parseTapscript(instructions []instruction, input int) []Envelope {
+	var envelopes []Envelope
+	stuttered := false
+	for i := 0; i < len(instructions); i++ {
+		if !instructions[i].isEmptyPush() {
+			continue
+		}
+		// OP_FALSE OP_IF "ord"
+		start := i + 1
+		if start >= len(instructions) || instructions[start].opcode != txscript.OP_IF {
+			stuttered = start < len(instructions) && instructions[start].isEmptyPush()
+			continue
+		}
+		start++
+		if start >= len(instructions) || !instructions[start].isPush() || !bytes.Equal(instructions[start].data, []byte("ord")) {
+			stuttered = start < len(instructions) && instructions[start].isEmptyPush()
+			i = start - 1
+			continue
+		}
+		var payload [][]byte
+		pushnum, closed := false, false
+		for i = start + 1; i < len(instructions) && !closed; i++ {
+			inst := instructions[i]
+			if inst.opcode == txscript.OP_ENDIF {
+				closed = true
+			} else if inst.opcode == txscript.OP_1NEGATE {
+				pushnum = true
+				payload = append(payload, []byte{0x81})
+			} else if inst.opcode >= txscript.OP_1 && inst.opcode <= txscript.OP_16 {
+				pushnum = true
+				payload = append(payload, []byte{inst.opcode - txscript.OP_1 + 1})
+			} else if inst.isPush() {
+				payload = append(payload, inst.data)
+			} else {
+				// Any other opcode discards the envelope.
+				break
+			}
+		}
+		if !closed {
+			stuttered = false
+			continue
+		}
+		// The OP_ENDIF is the last instruction of the envelope.
+		i--
+		envelope := parsePayload(payload)
+		envelope.Input, envelope.Offset = input, len(envelopes)
+		envelope.Pushnum, envelope.Stutter = pushnum, stuttered
+		envelopes = append(envelopes, envelope)
+	}
+	return envelopes
+}
+
+func parsePayload(payload [][]byte) Envelope {
+	var envelope Envelope
+	body := len(payload)
+	for i := 0; i < len(payload); i += 2 {
+		if len(payload[i]) == 0 {
+			body = i
+			envelope.Content = bytes.Join(payload[i+1:], nil)
+			break
+		}
+	}
+	fields := make(map[string][][]byte)
+	for i := 0; i < body; i += 2 {
+		if i+1 == body {
+			envelope.IncompleteField = true
+			break
+		}
+		fields[string(payload[i])] = append(fields[string(payload[i])], payload[i+1])
+	}
+	for tag, values := range fields {
+		// The metadata is chunked, and an inscription may have many parents.
+		chunked := tag == string([]byte{tagMetadata}) || tag == string([]byte{tagParent})
+		if len(values) > 1 && !chunked {
+			envelope.DuplicateField = true
+		}
+		switch tag {
+		case string([]byte{tagContentType}):
+			envelope.ContentType = string(values[0])
+		case string([]byte{tagContentEncoding}):
+			envelope.ContentEncoding = values[0]
+		case string([]byte{tagPointer}):
+			envelope.Pointer = values[0]
+		case string([]byte{tagParent}):
+			envelope.ParentID = parseInscriptionID(values[0])
+		case string([]byte{tagMetadata}), string([]byte{tagMetaprotocol}), string([]byte{tagDelegate}),
+			string([]byte{tagRune}), string([]byte{tagNote}):
+		default:
+			if tag[0]%2 == 0 {
+				envelope.UnrecognizedEvenField = true
+			}
+		}
+	}
+	return envelope
+}
+
+// parseInscriptionID decodes the inscription ID of a field: the transaction ID followed by the little-endian index
+// without the trailing zeros. An invalid value is ignored.
+func parseInscriptionID(value []byte) string {
+	if len(value) < chainhash.HashSize {
+		return ""
+	}
+	txID, _ := chainhash.NewHash(value[:chainhash.HashSize])
+	indexBytes := value[chainhash.HashSize:]
+	for _, b := range indexBytes[min(len(indexBytes), 4):] {
+		if b != 0 {
+			return ""
+		}
+	}
+	var index [4]byte
+	copy(index[:], indexBytes)
+	return fmt.Sprintf("%si%d", txID.String(), binary.LittleEndian.Uint32(index[:]))
+}
@@ -0,0 +1,150 @@
+package getter
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// envelopeScript builds a tapscript with the envelope of the fields and the body, prefixed by the extra opcodes.
+// The fields are pushed as bytes like ord does, never as the minimal pushnums of the script builder.
+func envelopeScript(prefix []byte, fields [][]byte, body string) []byte {
+	script := append([]byte{}, prefix...)
+	script = append(script, txscript.OP_FALSE, txscript.OP_IF, 3, 'o', 'r', 'd')
+	for _, field := range fields {
+		script = append(append(script, byte(len(field))), field...)
+	}
+	script = append(script, txscript.OP_0, byte(len(body)))
+	return append(append(script, body...), txscript.OP_ENDIF)
+}
+
+func inscribeTx(scripts ...[]byte) *wire.MsgTx {
+	tx := fakeTx(1, nil, 546)
+	for i, script := range scripts {
+		in := wire.NewTxIn(&wire.OutPoint{Hash: chainhash.Hash{byte(i + 1)}}, nil, wire.TxWitness{{0x01}, script, {0xc0}})
+		tx.AddTxIn(in)
+	}
+	return tx
+}
+
+func TestParseEnvelopes(t *testing.T) {
+	parent := make([]byte, 33)
+	parent[0], parent[32] = 0xab, 2
+	content := `{"p":"brc-20","op":"mint","tick":"ordi","amt":"1"}`
+
+	envelopes := ParseEnvelopes(inscribeTx(envelopeScript(nil, [][]byte{{tagContentType}, []byte("text/plain"), {tagParent}, parent}, content)))
+	if len(envelopes) != 1 || envelopes[0].Cursed() || string(envelopes[0].Content) != content || envelopes[0].ContentType != "text/plain" {
+		t.Fatalf("Unexpected envelopes %+v", envelopes)
+	}
+	var expectedParent chainhash.Hash
+	expectedParent[0] = 0xab
+	if envelopes[0].ParentID != expectedParent.String()+"i2" {
+		t.Fatalf("Unexpected parent %s", envelopes[0].ParentID)
+	}
+
+	cursed := map[string]*wire.MsgTx{
+		"second input":          inscribeTx([]byte{txscript.OP_TRUE}, envelopeScript(nil, nil, content)),
+		"second envelope":       inscribeTx(append(envelopeScript(nil, nil, content), envelopeScript(nil, nil, content)...)),
+		"pointer":               inscribeTx(envelopeScript(nil, [][]byte{{tagPointer}, {1}}, content)),
+		"duplicate field":       inscribeTx(envelopeScript(nil, [][]byte{{tagContentType}, []byte("text/plain"), {tagContentType}, []byte("text/plain")}, content)),
+		"incomplete field":      inscribeTx(envelopeScript(nil, [][]byte{{tagContentType}}, content)),
+		"unrecognized even tag": inscribeTx(envelopeScript(nil, [][]byte{{4}, {1}}, content)),
+		"stutter":               inscribeTx(envelopeScript([]byte{txscript.OP_FALSE}, nil, content)),
+	}
+	// A pushnum is an opcode rather than a push, so it's appended by hand.
+	pushnum := envelopeScript(nil, nil, content)
+	pushnum = append(pushnum[:len(pushnum)-1], txscript.OP_1, txscript.OP_1, txscript.OP_ENDIF)
+	cursed["pushnum"] = inscribeTx(pushnum)
+	for name, tx := range cursed {
+		envelopes := ParseEnvelopes(tx)
+		if len(envelopes) == 0 || !envelopes[len(envelopes)-1].Cursed() {
+			t.Fatalf("Expected the %s to be cursed, got %+v", name, envelopes)
+		}
+	}
+
+	// Any other opcode discards the envelope.
+	invalid := envelopeScript(nil, nil, content)
+	invalid = append(invalid[:len(invalid)-1], txscript.OP_CHECKSIG, txscript.OP_ENDIF)
+	if envelopes := ParseEnvelopes(inscribeTx(invalid)); len(envelopes) != 0 {
+		t.Fatalf("Expected no envelope, got %+v", envelopes)
+	}
+}
+
+func TestBitcoindGetter(t *testing.T) {
+	content := `{"p":"brc-20","op":"transfer","tick":"ordi","amt":"1"}`
+	coinbase100 := fakeTx(100, []wire.OutPoint{{Index: wire.MaxPrevOutIndex}}, 5000)
+	inscribe := inscribeTx(envelopeScript(nil, [][]byte{{tagContentType}, []byte("text/plain;charset=utf-8")}, content))
+	coinbase101 := fakeTx(101, []wire.OutPoint{{Index: wire.MaxPrevOutIndex}}, 5000)
+	// The inscription at the offset 0 of the second input lands at the offset 100 of the second output.
+	move := fakeTx(2, []wire.OutPoint{{Hash: chainhash.Hash{9}}, {Hash: inscribe.TxHash(), Index: 0}}, 900, 600)
+
+	type vin struct {
+		Prevout *struct {
+			Value json.Number `json:"value"`
+		} `json:"prevout,omitempty"`
+	}
+	rawTx := func(tx *wire.MsgTx, values ...string) map[string]any {
+		var buf bytes.Buffer
+		_ = tx.Serialize(&buf)
+		vins := make([]vin, len(tx.TxIn))
+		for i, value := range values {
+			vins[i].Prevout = &struct {
+				Value json.Number `json:"value"`
+			}{json.Number(value)}
+		}
+		return map[string]any{"hex": hex.EncodeToString(buf.Bytes()), "vin": vins}
+	}
+	blocks := map[string][]map[string]any{
+		"hash100": {rawTx(coinbase100), rawTx(inscribe, "0.00010000")},
+		"hash101": {rawTx(coinbase101), rawTx(move, "0.00001000", "0.00000546")},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var req struct {
+			Method string `json:"method"`
+			Params []any  `json:"params"`
+		}
+		_ = json.Unmarshal(body, &req)
+		var result any
+		switch req.Method {
+		case "getblockhash":
+			result = fmt.Sprintf("hash%v", req.Params[0])
+		case "getblock":
+			result = map[string]any{"tx": blocks[req.Params[0].(string)]}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"result": result})
+	}))
+	defer server.Close()
+
+	g, err := NewBitcoindGetter(BitcoindConfig{URL: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ots, err := g.GetOrdTransfers(100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := inscribe.TxHash().String() + "i0"
+	if len(ots) != 1 || ots[0].InscriptionID != id || ots[0].NewSatpoint != inscribe.TxHash().String()+":0:0" || string(ots[0].Content) != content {
+		t.Fatalf("Unexpected inscribes %+v", ots)
+	}
+	ots, err = g.GetOrdTransfers(101)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ots) != 1 || ots[0].InscriptionID != id || ots[0].OldSatpoint != inscribe.TxHash().String()+":0:0" || ots[0].NewSatpoint != move.TxHash().String()+":1:100" {
+		t.Fatalf("Unexpected moves %+v", ots)
+	}
+	if sats, err := btcToSats("21.00000546"); err != nil || sats != 2100000546 {
+		t.Fatalf("Unexpected sats %d: %v", sats, err)
+	}
+}
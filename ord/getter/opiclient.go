@@ -2,6 +2,7 @@ package getter
 
 import (
 	"fmt"
+	"sync"
 	"time"
 
 	"gorm.io/driver/postgres"
@@ -20,6 +21,7 @@ type DatabaseConfig struct {
 
 type OPIOrdGetter struct {
 	db *gorm.DB
+	sync.RWMutex
 }
 
 func ConnectOPIDatabase(config *DatabaseConfig) (*gorm.DB, error) {
@@ -43,6 +45,28 @@ func NewOPIOrdGetter(config *DatabaseConfig) (*OPIOrdGetter, error) {
 	return &getter, err
 }
 
+func (opi *OPIOrdGetter) conn() *gorm.DB {
+	opi.RLock()
+	defer opi.RUnlock()
+	return opi.db
+}
+
+// Reconnect switches to a new connection, e.g. after the rotation of the credentials, and closes the previous one.
+func (opi *OPIOrdGetter) Reconnect(config *DatabaseConfig) error {
+	db, err := ConnectOPIDatabase(config)
+	if err != nil {
+		return err
+	}
+	opi.Lock()
+	old := opi.db
+	opi.db = db
+	opi.Unlock()
+	if sqlDB, err := old.DB(); err == nil {
+		_ = sqlDB.Close()
+	}
+	return nil
+}
+
 func (opi *OPIOrdGetter) GetLatestBlockHeight() (uint, error) {
 	defer metrics.ObserveDBQuery("getLatestBlockHeight", time.Now())
 
@@ -51,7 +75,7 @@ func (opi *OPIOrdGetter) GetLatestBlockHeight() (uint, error) {
 		SELECT block_height
 		FROM block_hashes ORDER BY block_height DESC LIMIT 1
 	`
-	err := opi.db.Raw(sql).Scan(&blockHeight).Error
+	err := opi.conn().Raw(sql).Scan(&blockHeight).Error
 	if err != nil {
 		return 0, err
 	}
@@ -67,7 +91,7 @@ func (opi *OPIOrdGetter) GetBlockHash(blockHeight uint) (string, error) {
 		FROM block_hashes
 		WHERE block_height = $1
 	`
-	err := opi.db.Raw(sql, blockHeight).Scan(&blockHash).Error
+	err := opi.conn().Raw(sql, blockHeight).Scan(&blockHash).Error
 	if err != nil {
 		return "", err
 	}
@@ -88,9 +112,14 @@ func (opi *OPIOrdGetter) GetOrdTransfers(blockHeight uint) ([]OrdTransfer, error
 			AND oc."content" is not null AND oc."content"->>'p' = 'brc-20'
 		ORDER BY ot.id asc;
 		`
-	err := opi.db.Raw(sql, blockHeight).Scan(&ordTransfers).Error
+	err := opi.conn().Raw(sql, blockHeight).Scan(&ordTransfers).Error
 	if err != nil {
 		return make([]OrdTransfer, 0), err
 	}
 	return ordTransfers, nil
 }
+
+// ConcurrentFetch reports that the blocks are queried independently of each other.
+func (opi *OPIOrdGetter) ConcurrentFetch() bool {
+	return true
+}
@@ -115,3 +115,7 @@ func (opi *OPIOrdGetterTest) GetOrdTransfers(blockHeight uint) ([]OrdTransfer, e
 	}
 	return filteredOrdTransfers, nil
 }
+
+func (opi *OPIOrdGetterTest) ConcurrentFetch() bool {
+	return true
+}
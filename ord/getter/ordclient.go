@@ -0,0 +1,292 @@
+package getter
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+
+	"github.com/RiemaLabs/modular-indexer-committee/internal/metrics"
+	"github.com/RiemaLabs/modular-indexer-committee/ord"
+)
+
+type OrdServerConfig struct {
+	Enabled bool `json:"enabled"`
+	// The base URL of the ord server, whose JSON API is enabled.
+	URL string `json:"url"`
+	// The network of the ord server: mainnet, testnet, signet or regtest, which the wallets are encoded for.
+	Network string `json:"network"`
+	// The file persisting the unmoved transfer inscriptions, so that the getter resumes along with the state cache.
+	PendingPath string `json:"pendingPath"`
+	// The number of the concurrent requests to the ord server.
+	Concurrency int `json:"concurrency"`
+}
+
+// The JSON of the ord server, only the fields read by the getter.
+type ordBlock struct {
+	Hash         string   `json:"hash"`
+	Height       uint     `json:"height"`
+	Inscriptions []string `json:"inscriptions"`
+	Transactions []ordTx  `json:"transactions"`
+}
+
+type ordTx struct {
+	Version  int32  `json:"version"`
+	LockTime uint32 `json:"lock_time"`
+	Input    []struct {
+		PreviousOutput string   `json:"previous_output"`
+		ScriptSig      string   `json:"script_sig"`
+		Sequence       uint32   `json:"sequence"`
+		Witness        []string `json:"witness"`
+	} `json:"input"`
+	Output []struct {
+		Value        int64  `json:"value"`
+		ScriptPubkey string `json:"script_pubkey"`
+	} `json:"output"`
+}
+
+type ordInscription struct {
+	ID          string   `json:"id"`
+	Charms      []string `json:"charms"`
+	ContentType string   `json:"content_type"`
+	Parents     []string `json:"parents"`
+	Satpoint    string   `json:"satpoint"`
+}
+
+type ordOutput struct {
+	Value int64 `json:"value"`
+}
+
+func (t *ordTx) msgTx() (*wire.MsgTx, error) {
+	tx := wire.NewMsgTx(t.Version)
+	tx.LockTime = t.LockTime
+	for _, in := range t.Input {
+		prevOut, err := wire.NewOutPointFromString(in.PreviousOutput)
+		if err != nil {
+			return nil, err
+		}
+		scriptSig, err := hex.DecodeString(in.ScriptSig)
+		if err != nil {
+			return nil, err
+		}
+		txIn := wire.NewTxIn(prevOut, scriptSig, nil)
+		txIn.Sequence = in.Sequence
+		for _, w := range in.Witness {
+			item, err := hex.DecodeString(w)
+			if err != nil {
+				return nil, err
+			}
+			txIn.Witness = append(txIn.Witness, item)
+		}
+		tx.AddTxIn(txIn)
+	}
+	for _, out := range t.Output {
+		pkScript, err := hex.DecodeString(out.ScriptPubkey)
+		if err != nil {
+			return nil, err
+		}
+		tx.AddTxOut(wire.NewTxOut(out.Value, pkScript))
+	}
+	return tx, nil
+}
+
+// OrdServerGetter reads the ord transfers of BRC-20 from the JSON API of an ord server instead of the OPI database.
+// The inscriptions of a block are listed by the ord server, while their moves are followed by the getter.
+type OrdServerGetter struct {
+	url         string
+	client      *http.Client
+	params      *chaincfg.Params
+	concurrency int
+	tracker     *transferTracker
+}
+
+func NewOrdServerGetter(cfg OrdServerConfig) (*OrdServerGetter, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("the URL of the ord server is required")
+	}
+	params, err := networkParams(cfg.Network)
+	if err != nil {
+		return nil, err
+	}
+	tracker, err := newTransferTracker(cfg.PendingPath)
+	if err != nil {
+		return nil, err
+	}
+	return &OrdServerGetter{
+		url:         strings.TrimRight(cfg.URL, "/"),
+		client:      &http.Client{Timeout: 60 * time.Second},
+		params:      params,
+		concurrency: max(cfg.Concurrency, 1),
+		tracker:     tracker,
+	}, nil
+}
+
+func (g *OrdServerGetter) get(path string, v any) error {
+	req, err := http.NewRequest(http.MethodGet, g.url+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("the ord server returns %d for %s: %s", resp.StatusCode, path, bytes.TrimSpace(body))
+	}
+	if raw, ok := v.(*[]byte); ok {
+		*raw = body
+		return nil
+	}
+	return json.Unmarshal(body, v)
+}
+
+func (g *OrdServerGetter) GetLatestBlockHeight() (uint, error) {
+	defer metrics.ObserveDBQuery("ordBlockHeight", time.Now())
+	var height uint
+	err := g.get("/r/blockheight", &height)
+	return height, err
+}
+
+func (g *OrdServerGetter) GetBlockHash(blockHeight uint) (string, error) {
+	defer metrics.ObserveDBQuery("ordBlockHash", time.Now())
+	var hash string
+	err := g.get(fmt.Sprintf("/r/blockhash/%d", blockHeight), &hash)
+	return hash, err
+}
+
+// brc20Inscription returns the inscription if it's a BRC-20 inscription indexed by OPI, along with its content.
+func (g *OrdServerGetter) brc20Inscription(id string) (*ordInscription, []byte, error) {
+	var inscription ordInscription
+	if err := g.get("/inscription/"+id, &inscription); err != nil {
+		return nil, nil, err
+	}
+	// The cursed inscriptions, including the vindicated ones, are never valid BRC-20 inscriptions.
+	if slices.Contains(inscription.Charms, "cursed") || slices.Contains(inscription.Charms, "vindicated") {
+		return nil, nil, nil
+	}
+	if !isBRC20ContentType(inscription.ContentType) {
+		return nil, nil, nil
+	}
+	var content []byte
+	if err := g.get("/content/"+id, &content); err != nil {
+		return nil, nil, err
+	}
+	if !isBRC20(inscription.ContentType, content) {
+		return nil, nil, nil
+	}
+	return &inscription, content, nil
+}
+
+type brc20Inscribe struct {
+	inscription *ordInscription
+	content     []byte
+}
+
+// inscribes fetches the BRC-20 inscriptions of the block concurrently, in the order of the ord server.
+func (g *OrdServerGetter) inscribes(ids []string) ([]brc20Inscribe, error) {
+	res := make([]brc20Inscribe, len(ids))
+	errs := make([]error, len(ids))
+	sem := make(chan struct{}, g.concurrency)
+	var wg sync.WaitGroup
+	for i, id := range ids {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, id string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			res[i].inscription, res[i].content, errs[i] = g.brc20Inscription(id)
+		}(i, id)
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(res, func(r brc20Inscribe) bool { return r.inscription == nil }), nil
+}
+
+func (g *OrdServerGetter) GetOrdTransfers(blockHeight uint) ([]OrdTransfer, error) {
+	defer metrics.ObserveDBQuery("ordTransfers", time.Now())
+
+	pending, err := g.tracker.base(blockHeight)
+	if err != nil {
+		return nil, err
+	}
+	var block ordBlock
+	if err := g.get(fmt.Sprintf("/block/%d", blockHeight), &block); err != nil {
+		return nil, err
+	}
+	txs := make([]*wire.MsgTx, len(block.Transactions))
+	for i := range block.Transactions {
+		if txs[i], err = block.Transactions[i].msgTx(); err != nil {
+			return nil, fmt.Errorf("invalid transaction %d of the block %d: %v", i, blockHeight, err)
+		}
+	}
+	if len(txs) == 0 {
+		return nil, fmt.Errorf("the block %d has no transactions", blockHeight)
+	}
+	flow := newBlockFlow(txs, func(prevOut wire.OutPoint) (int64, error) {
+		var output ordOutput
+		err := g.get("/output/"+prevOut.String(), &output)
+		return output.Value, err
+	})
+	inscribes, err := g.inscribes(block.Inscriptions)
+	if err != nil {
+		return nil, err
+	}
+	inscriptions := make([]newInscription, 0, len(inscribes))
+	for _, inscribe := range inscribes {
+		genesis, err := chainhash.NewHashFromStr(strings.Split(inscribe.inscription.ID, "i")[0])
+		if err != nil {
+			return nil, err
+		}
+		i, found := flow.indexes[*genesis]
+		if !found {
+			return nil, fmt.Errorf("the inscription %s isn't inscribed in the block %d", inscribe.inscription.ID, blockHeight)
+		}
+		inscription := newInscription{
+			tx:          i,
+			id:          inscribe.inscription.ID,
+			content:     inscribe.content,
+			contentType: inscribe.inscription.ContentType,
+		}
+		// An inscription not moved since is located by the ord server, otherwise it's assumed at the first sat
+		// of the first output, where the inscriptions without a pointer are inscribed.
+		point, err := ord.ParseSatPoint(inscribe.inscription.Satpoint)
+		if err == nil && string(point.OutPoint().TxID()) == txs[i].TxHash().String() && point.OutPoint().OutputIndex() < uint64(len(txs[i].TxOut)) {
+			inscription.satpoint = inscribe.inscription.Satpoint
+			inscription.pkScript = txs[i].TxOut[point.OutPoint().OutputIndex()].PkScript
+		} else {
+			inscription.satpoint, inscription.pkScript, inscription.sentAsFee = flow.locate(txs[i], 0)
+		}
+		if len(inscribe.inscription.Parents) != 0 {
+			inscription.parentID = inscribe.inscription.Parents[0]
+		}
+		inscriptions = append(inscriptions, inscription)
+	}
+
+	ots, err := resolveTransfers(blockHeight, flow, inscriptions, pending, g.params)
+	if err != nil {
+		return nil, err
+	}
+	if err := g.tracker.save(blockHeight, pending); err != nil {
+		return nil, err
+	}
+	return ots, nil
+}
@@ -0,0 +1,135 @@
+package getter
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+func fakeOrdTx(tx *wire.MsgTx) ordTx {
+	var t ordTx
+	t.Version, t.LockTime = tx.Version, tx.LockTime
+	for _, in := range tx.TxIn {
+		t.Input = append(t.Input, struct {
+			PreviousOutput string   `json:"previous_output"`
+			ScriptSig      string   `json:"script_sig"`
+			Sequence       uint32   `json:"sequence"`
+			Witness        []string `json:"witness"`
+		}{PreviousOutput: in.PreviousOutPoint.String(), ScriptSig: hex.EncodeToString(in.SignatureScript), Sequence: in.Sequence})
+	}
+	for _, out := range tx.TxOut {
+		t.Output = append(t.Output, struct {
+			Value        int64  `json:"value"`
+			ScriptPubkey string `json:"script_pubkey"`
+		}{Value: out.Value, ScriptPubkey: hex.EncodeToString(out.PkScript)})
+	}
+	return t
+}
+
+func fakeTx(lockTime uint32, prevOuts []wire.OutPoint, values ...int64) *wire.MsgTx {
+	tx := wire.NewMsgTx(2)
+	tx.LockTime = lockTime
+	for i := range prevOuts {
+		tx.AddTxIn(wire.NewTxIn(&prevOuts[i], nil, nil))
+	}
+	for i, value := range values {
+		pkScript, _ := hex.DecodeString(fmt.Sprintf("0014%040x", lockTime*10+uint32(i)))
+		tx.AddTxOut(wire.NewTxOut(value, pkScript))
+	}
+	return tx
+}
+
+func TestOrdServerGetter(t *testing.T) {
+	external := wire.OutPoint{Hash: chainhash.Hash{1}, Index: 0}
+	// The block 100 inscribes a transfer and a mint, while the block 101 moves the transfer inscription.
+	coinbase100 := fakeTx(100, []wire.OutPoint{{Index: wire.MaxPrevOutIndex}}, 5000)
+	inscribe := fakeTx(1, []wire.OutPoint{external}, 546, 1000)
+	coinbase101 := fakeTx(101, []wire.OutPoint{{Index: wire.MaxPrevOutIndex}}, 5000)
+	// The input of 1000 sats comes first, so the inscription lands at the offset 100 of the second output.
+	move := fakeTx(2, []wire.OutPoint{{Hash: inscribe.TxHash(), Index: 1}, {Hash: inscribe.TxHash(), Index: 0}}, 900, 600)
+	transferID := inscribe.TxHash().String() + "i0"
+	mintID := inscribe.TxHash().String() + "i1"
+	otherID := inscribe.TxHash().String() + "i2"
+
+	blocks := map[string]ordBlock{
+		"100": {Height: 100, Inscriptions: []string{transferID, mintID, otherID}, Transactions: []ordTx{fakeOrdTx(coinbase100), fakeOrdTx(inscribe)}},
+		"101": {Height: 101, Transactions: []ordTx{fakeOrdTx(coinbase101), fakeOrdTx(move)}},
+	}
+	inscriptions := map[string]ordInscription{
+		transferID: {ID: transferID, ContentType: "text/plain;charset=utf-8", Satpoint: fmt.Sprintf("%s:1:100", move.TxHash())},
+		mintID:     {ID: mintID, ContentType: "application/json", Satpoint: inscribe.TxHash().String() + ":0:0"},
+		otherID:    {ID: otherID, ContentType: "text/plain", Satpoint: inscribe.TxHash().String() + ":0:0"},
+	}
+	contents := map[string]string{
+		transferID: `{"p":"brc-20","op":"transfer","tick":"ordi","amt":"1"}`,
+		mintID:     `{"p":"brc-20","op":"mint","tick":"ordi","amt":"1"}`,
+		otherID:    `{"p":"sns","op":"reg","name":"a.sats"}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.Split(strings.TrimPrefix(r.URL.Path, "/"), "/")
+		var v any
+		switch path[0] {
+		case "block":
+			v = blocks[path[1]]
+		case "inscription":
+			v = inscriptions[path[1]]
+		case "content":
+			_, _ = w.Write([]byte(contents[path[1]]))
+			return
+		case "output":
+			v = ordOutput{Value: 1000}
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(v)
+	}))
+	defer server.Close()
+
+	g, err := NewOrdServerGetter(OrdServerConfig{URL: server.URL, Concurrency: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ots, err := g.GetOrdTransfers(100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ots) != 2 || ots[0].InscriptionID != transferID || ots[1].InscriptionID != mintID {
+		t.Fatalf("unexpected inscribes %+v", ots)
+	}
+	// The transfer inscription has been moved since, so it's assumed at the first sat of its first output.
+	if ots[0].OldSatpoint != "" || ots[0].NewSatpoint != inscribe.TxHash().String()+":0:0" {
+		t.Fatalf("unexpected satpoint of the inscribe %+v", ots[0])
+	}
+	if string(ots[0].NewPkscript) != "0014"+fmt.Sprintf("%040x", 10) || ots[0].NewWallet == "" {
+		t.Fatalf("unexpected receiver of the inscribe %+v", ots[0])
+	}
+
+	for range 2 {
+		// The block fetched again, as after a reorg, moves the same inscription.
+		ots, err = g.GetOrdTransfers(101)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ots) != 1 || ots[0].InscriptionID != transferID {
+			t.Fatalf("unexpected moves %+v", ots)
+		}
+		expected := fmt.Sprintf("%s:1:100", move.TxHash())
+		if ots[0].OldSatpoint != inscribe.TxHash().String()+":0:0" || ots[0].NewSatpoint != expected || ots[0].SentAsFee {
+			t.Fatalf("unexpected move %+v", ots[0])
+		}
+		if string(ots[0].Content) != contents[transferID] {
+			t.Fatalf("unexpected content %s", ots[0].Content)
+		}
+	}
+	if _, err := g.GetOrdTransfers(103); err == nil {
+		t.Fatal("the block after a gap must fail")
+	}
+}
@@ -0,0 +1,171 @@
+package getter
+
+import (
+	"sync"
+
+	"github.com/RiemaLabs/modular-indexer-committee/internal/metrics"
+)
+
+// ConcurrentGetter is implemented by the getters whose blocks may be fetched concurrently and out of order.
+// The other getters keep a state along the blocks, so they are prefetched one block after another.
+type ConcurrentGetter interface {
+	ConcurrentFetch() bool
+}
+
+type prefetched struct {
+	transfers []OrdTransfer
+	err       error
+}
+
+// Prefetcher fetches the transfers of the blocks in [from, to] ahead of their execution, at most window blocks
+// ahead of the block being handed off. The blocks shall be requested in order; the other blocks and the other
+// calls go to the wrapped getter directly.
+type Prefetcher struct {
+	OrdGetter
+	from, to uint
+	window   chan struct{}
+	workers  uint
+
+	mu sync.Mutex
+	// The pending results of the dispatched blocks.
+	results map[uint]chan prefetched
+	// The next block to be dispatched and to be handed off.
+	next, handed uint
+	stopped      bool
+	failed       bool
+
+	done      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// NewPrefetcher starts prefetching the blocks from the height from to the height to. Concurrent getters are
+// fetched by at most workers blocks at once, the others by a single block at once.
+func NewPrefetcher(g OrdGetter, from, to uint, window, workers uint) *Prefetcher {
+	if concurrent, ok := g.(ConcurrentGetter); !ok || !concurrent.ConcurrentFetch() || workers == 0 {
+		workers = 1
+	}
+	if window < workers {
+		window = workers
+	}
+	p := &Prefetcher{
+		OrdGetter: g,
+		from:      from,
+		to:        to,
+		window:    make(chan struct{}, window),
+		workers:   workers,
+		results:   make(map[uint]chan prefetched),
+		next:      from,
+		handed:    from,
+		done:      make(chan struct{}),
+	}
+	p.wg.Add(1)
+	go p.dispatch()
+	return p
+}
+
+// result returns the channel of the block, which shall be called with the lock held.
+func (p *Prefetcher) result(height uint) chan prefetched {
+	r, found := p.results[height]
+	if !found {
+		r = make(chan prefetched, 1)
+		p.results[height] = r
+	}
+	return r
+}
+
+func (p *Prefetcher) dispatch() {
+	defer p.wg.Done()
+	defer func() {
+		// The blocks awaited but never dispatched are fetched by the callers themselves.
+		p.mu.Lock()
+		p.stopped = true
+		for height, r := range p.results {
+			if height >= p.next {
+				close(r)
+				delete(p.results, height)
+			}
+		}
+		p.mu.Unlock()
+	}()
+	fetchers := make(chan struct{}, p.workers)
+	for height := p.from; height <= p.to && height >= p.from; height++ {
+		select {
+		case p.window <- struct{}{}:
+		case <-p.done:
+			return
+		}
+		select {
+		case fetchers <- struct{}{}:
+		case <-p.done:
+			<-p.window
+			return
+		}
+		p.mu.Lock()
+		if p.failed {
+			p.mu.Unlock()
+			<-fetchers
+			<-p.window
+			return
+		}
+		r := p.result(height)
+		p.next = height + 1
+		p.mu.Unlock()
+
+		metrics.PrefetchedBlocks.Inc()
+		p.wg.Add(1)
+		go func(height uint) {
+			defer p.wg.Done()
+			transfers, err := p.OrdGetter.GetOrdTransfers(height)
+			if err != nil {
+				// A stateful getter can't continue after a failed block.
+				p.mu.Lock()
+				p.failed = true
+				p.mu.Unlock()
+			}
+			r <- prefetched{transfers: transfers, err: err}
+			<-fetchers
+		}(height)
+	}
+}
+
+// GetOrdTransfers hands off the prefetched transfers of the next block, waiting for them if necessary.
+func (p *Prefetcher) GetOrdTransfers(blockHeight uint) ([]OrdTransfer, error) {
+	p.mu.Lock()
+	if blockHeight != p.handed || blockHeight > p.to || (p.stopped && blockHeight >= p.next) {
+		p.mu.Unlock()
+		return p.OrdGetter.GetOrdTransfers(blockHeight)
+	}
+	r := p.result(blockHeight)
+	p.mu.Unlock()
+
+	result, dispatched := <-r
+	if !dispatched {
+		return p.OrdGetter.GetOrdTransfers(blockHeight)
+	}
+	p.mu.Lock()
+	delete(p.results, blockHeight)
+	p.handed = blockHeight + 1
+	p.mu.Unlock()
+	metrics.PrefetchedBlocks.Dec()
+	<-p.window
+	return result.transfers, result.err
+}
+
+// Close stops prefetching and waits for the blocks being fetched, after which the wrapped getter is
+// no longer called in the background. The blocks prefetched but not handed off are dropped.
+func (p *Prefetcher) Close() {
+	p.closeOnce.Do(func() {
+		close(p.done)
+		p.wg.Wait()
+		p.mu.Lock()
+		for height, r := range p.results {
+			if len(r) != 0 {
+				metrics.PrefetchedBlocks.Dec()
+			}
+			delete(p.results, height)
+		}
+		p.next = p.handed
+		p.mu.Unlock()
+	})
+}
@@ -0,0 +1,98 @@
+package getter
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeBlockGetter returns a single transfer per block, recording how many blocks are fetched at once.
+type fakeBlockGetter struct {
+	concurrent bool
+	failAt     uint
+	mu         sync.Mutex
+	inFlight   int
+	maxFlight  int
+	fetched    []uint
+}
+
+func (g *fakeBlockGetter) GetLatestBlockHeight() (uint, error)           { return 0, nil }
+func (g *fakeBlockGetter) GetBlockHash(blockHeight uint) (string, error) { return "", nil }
+func (g *fakeBlockGetter) ConcurrentFetch() bool                         { return g.concurrent }
+
+func (g *fakeBlockGetter) GetOrdTransfers(blockHeight uint) ([]OrdTransfer, error) {
+	g.mu.Lock()
+	g.inFlight++
+	if g.inFlight > g.maxFlight {
+		g.maxFlight = g.inFlight
+	}
+	g.fetched = append(g.fetched, blockHeight)
+	g.mu.Unlock()
+	time.Sleep(time.Millisecond)
+	g.mu.Lock()
+	g.inFlight--
+	g.mu.Unlock()
+	if blockHeight == g.failAt {
+		return nil, fmt.Errorf("failed at %d", blockHeight)
+	}
+	return []OrdTransfer{{BlockHeight: blockHeight}}, nil
+}
+
+func TestPrefetcher(t *testing.T) {
+	for _, concurrent := range []bool{true, false} {
+		g := &fakeBlockGetter{concurrent: concurrent}
+		p := NewPrefetcher(g, 100, 199, 8, 4)
+		for i := uint(100); i < 200; i++ {
+			ots, err := p.GetOrdTransfers(i)
+			if err != nil || len(ots) != 1 || ots[0].BlockHeight != i {
+				t.Fatalf("Unexpected transfers of the block %d: %v, %v", i, ots, err)
+			}
+		}
+		// A block outside the range is fetched on demand.
+		if ots, err := p.GetOrdTransfers(200); err != nil || ots[0].BlockHeight != 200 {
+			t.Fatalf("Unexpected transfers of the block 200: %v, %v", ots, err)
+		}
+		p.Close()
+
+		if concurrent && (g.maxFlight < 2 || g.maxFlight > 4) {
+			t.Fatalf("Expected at most 4 blocks fetched at once, got %d", g.maxFlight)
+		}
+		if !concurrent {
+			if g.maxFlight != 1 {
+				t.Fatalf("Expected the blocks fetched one after another, got %d at once", g.maxFlight)
+			}
+			for i, height := range g.fetched {
+				if height != 100+uint(i) {
+					t.Fatalf("Expected the blocks fetched in order, got %v", g.fetched)
+				}
+			}
+		}
+	}
+}
+
+func TestPrefetcherFailure(t *testing.T) {
+	g := &fakeBlockGetter{failAt: 105}
+	p := NewPrefetcher(g, 100, 199, 8, 4)
+	defer p.Close()
+	for i := uint(100); i < 105; i++ {
+		if _, err := p.GetOrdTransfers(i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := p.GetOrdTransfers(105); err == nil {
+		t.Fatal("Expected the failure of the block 105")
+	}
+	// Nothing is prefetched past the failed block, and the next blocks are fetched on demand.
+	time.Sleep(10 * time.Millisecond)
+	g.mu.Lock()
+	last := g.fetched[len(g.fetched)-1]
+	g.mu.Unlock()
+	if last != 105 {
+		t.Fatalf("Expected the prefetch to stop at the block 105, fetched %d", last)
+	}
+	g.failAt = 0
+	if ots, err := p.GetOrdTransfers(106); err != nil || ots[0].BlockHeight != 106 {
+		t.Fatalf("Unexpected transfers of the block 106: %v, %v", ots, err)
+	}
+}
@@ -0,0 +1,52 @@
+package getter
+
+import (
+	"context"
+
+	"github.com/RiemaLabs/modular-indexer-committee/internal/retry"
+)
+
+// Retrying retries the failed calls to the wrapped getter by the breaker, so that the transient failures of the
+// upstream, e.g. a restart of the OPI database, don't fail the blocks. Once the upstream is down, the calls fail fast
+// with retry.ErrUnavailable until the circuit closes again.
+type Retrying struct {
+	OrdGetter
+	breaker *retry.Breaker
+}
+
+func NewRetrying(g OrdGetter, breaker *retry.Breaker) *Retrying {
+	return &Retrying{OrdGetter: g, breaker: breaker}
+}
+
+// ConcurrentFetch follows the wrapped getter, since the blocks are fetched by it.
+func (r *Retrying) ConcurrentFetch() bool {
+	concurrent, ok := r.OrdGetter.(ConcurrentGetter)
+	return ok && concurrent.ConcurrentFetch()
+}
+
+func (r *Retrying) GetLatestBlockHeight() (uint, error) {
+	var latest uint
+	err := r.breaker.Do(context.Background(), func() (err error) {
+		latest, err = r.OrdGetter.GetLatestBlockHeight()
+		return err
+	})
+	return latest, err
+}
+
+func (r *Retrying) GetBlockHash(blockHeight uint) (string, error) {
+	var hash string
+	err := r.breaker.Do(context.Background(), func() (err error) {
+		hash, err = r.OrdGetter.GetBlockHash(blockHeight)
+		return err
+	})
+	return hash, err
+}
+
+func (r *Retrying) GetOrdTransfers(blockHeight uint) ([]OrdTransfer, error) {
+	var transfers []OrdTransfer
+	err := r.breaker.Do(context.Background(), func() (err error) {
+		transfers, err = r.OrdGetter.GetOrdTransfers(blockHeight)
+		return err
+	})
+	return transfers, err
+}
@@ -0,0 +1,290 @@
+package getter
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord"
+)
+
+// The getters reading the blocks themselves, instead of the OPI database, follow the moves of the inscriptions
+// through the transactions of each block in the first-in-first-out order of the sats. Only the transfer inscriptions
+// not moved yet are followed, since the later moves of any inscription don't change the BRC-20 state.
+
+func networkParams(network string) (*chaincfg.Params, error) {
+	switch network {
+	case "", "mainnet":
+		return &chaincfg.MainNetParams, nil
+	case "testnet":
+		return &chaincfg.TestNet3Params, nil
+	case "signet":
+		return &chaincfg.SigNetParams, nil
+	case "regtest":
+		return &chaincfg.RegressionNetParams, nil
+	}
+	return nil, fmt.Errorf("unknown network %s", network)
+}
+
+func walletOf(pkScript []byte, params *chaincfg.Params) ord.Wallet {
+	_, addrs, _, err := txscript.ExtractPkScriptAddrs(pkScript, params)
+	if err != nil || len(addrs) != 1 {
+		return ""
+	}
+	return ord.Wallet(addrs[0].EncodeAddress())
+}
+
+func isTransfer(content []byte) bool {
+	var js map[string]any
+	return json.Unmarshal(content, &js) == nil && js["op"] == "transfer"
+}
+
+func isBRC20ContentType(contentType string) bool {
+	mediaType := strings.TrimSpace(strings.Split(contentType, ";")[0])
+	return mediaType == "application/json" || mediaType == "text/plain"
+}
+
+// isBRC20 tells whether the inscription is indexed as BRC-20 by OPI, by its content type and its content.
+func isBRC20(contentType string, content []byte) bool {
+	if !isBRC20ContentType(contentType) {
+		return false
+	}
+	var js map[string]any
+	return json.Unmarshal(content, &js) == nil && js["p"] == "brc-20"
+}
+
+// pendingTransfer is a transfer inscription not moved yet, along with what its move reports.
+type pendingTransfer struct {
+	Satpoint    string `json:"satpoint"`
+	Content     []byte `json:"content"`
+	ContentType string `json:"contentType"`
+	ParentID    string `json:"parentID"`
+}
+
+// The number of the blocks whose pending transfer inscriptions are kept, which covers the reorgs recovered by the queue.
+var PendingWindow = ord.BitcoinConfirmations + 2
+
+type pendingFile struct {
+	Height  uint                       `json:"height"`
+	Pending map[string]pendingTransfer `json:"pending"`
+}
+
+// transferTracker keeps the pending transfer inscriptions after each of the latest blocks,
+// so that the blocks fetched again after a reorg start from the same inscriptions.
+type transferTracker struct {
+	sync.Mutex
+	path    string
+	pending map[uint]map[string]pendingTransfer
+}
+
+// newTransferTracker resumes the pending transfer inscriptions persisted in the path, if any.
+func newTransferTracker(path string) (*transferTracker, error) {
+	t := transferTracker{path: path, pending: make(map[uint]map[string]pendingTransfer)}
+	if path == "" {
+		return &t, nil
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &t, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var f pendingFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("invalid pending transfer inscriptions %s: %v", path, err)
+	}
+	t.pending[f.Height] = f.Pending
+	return &t, nil
+}
+
+// base returns a copy of the pending transfer inscriptions before the block.
+func (t *transferTracker) base(blockHeight uint) (map[string]pendingTransfer, error) {
+	t.Lock()
+	defer t.Unlock()
+	base, found := t.pending[blockHeight-1]
+	if !found && len(t.pending) != 0 {
+		return nil, fmt.Errorf("the pending transfer inscriptions before the block %d are unknown", blockHeight)
+	}
+	// Nothing has been indexed if nothing is tracked, so nothing is pending.
+	pending := make(map[string]pendingTransfer, len(base))
+	for id, p := range base {
+		pending[id] = p
+	}
+	return pending, nil
+}
+
+func (t *transferTracker) save(blockHeight uint, pending map[string]pendingTransfer) error {
+	t.Lock()
+	defer t.Unlock()
+	t.pending[blockHeight] = pending
+	for height := range t.pending {
+		if height > blockHeight || height+PendingWindow < blockHeight {
+			delete(t.pending, height)
+		}
+	}
+	if t.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(pendingFile{Height: blockHeight, Pending: pending})
+	if err != nil {
+		return err
+	}
+	tmp := t.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, t.path)
+}
+
+// blockFlow follows the sats through the transactions of a block.
+type blockFlow struct {
+	txs     []*wire.MsgTx
+	indexes map[chainhash.Hash]int
+	// value returns the value of an output spent by the block but created before it.
+	value func(prevOut wire.OutPoint) (int64, error)
+}
+
+func newBlockFlow(txs []*wire.MsgTx, value func(prevOut wire.OutPoint) (int64, error)) *blockFlow {
+	f := blockFlow{txs: txs, indexes: make(map[chainhash.Hash]int, len(txs)), value: value}
+	for i, tx := range txs {
+		f.indexes[tx.TxHash()] = i
+	}
+	return &f
+}
+
+func (f *blockFlow) outputValue(prevOut wire.OutPoint) (int64, error) {
+	if i, found := f.indexes[prevOut.Hash]; found {
+		if int(prevOut.Index) >= len(f.txs[i].TxOut) {
+			return 0, fmt.Errorf("the missing output %s", prevOut.String())
+		}
+		return f.txs[i].TxOut[prevOut.Index].Value, nil
+	}
+	return f.value(prevOut)
+}
+
+// inputOffset returns the position of the first sat of the input among the sats of all inputs.
+func (f *blockFlow) inputOffset(tx *wire.MsgTx, input int) (uint64, error) {
+	var position uint64
+	for _, in := range tx.TxIn[:input] {
+		value, err := f.outputValue(in.PreviousOutPoint)
+		if err != nil {
+			return 0, err
+		}
+		position += uint64(value)
+	}
+	return position, nil
+}
+
+// locate returns the satpoint of the sat at the position among the sats of all inputs, and whether it's spent as fee.
+func (f *blockFlow) locate(tx *wire.MsgTx, position uint64) (string, []byte, bool) {
+	for index, out := range tx.TxOut {
+		if position < uint64(out.Value) {
+			return fmt.Sprintf("%s:%d:%d", tx.TxHash().String(), index, position), out.PkScript, false
+		}
+		position -= uint64(out.Value)
+	}
+	// The fees are collected by the coinbase, the exact sat of which doesn't matter to BRC-20.
+	coinbase := f.txs[0]
+	return fmt.Sprintf("%s:0:0", coinbase.TxHash().String()), coinbase.TxOut[0].PkScript, true
+}
+
+// newInscription is a BRC-20 inscription inscribed by the transaction of the index in the block.
+type newInscription struct {
+	tx          int
+	id          string
+	content     []byte
+	contentType string
+	parentID    string
+	satpoint    string
+	pkScript    []byte
+	sentAsFee   bool
+}
+
+// resolveTransfers returns the ord transfers of the block: the moves of the pending transfer inscriptions spent by
+// each transaction, followed by the inscriptions of the transaction, in the order of the transactions.
+// The pending transfer inscriptions are updated along.
+func resolveTransfers(blockHeight uint, flow *blockFlow, inscriptions []newInscription, pending map[string]pendingTransfer, params *chaincfg.Params) ([]OrdTransfer, error) {
+	created := make(map[int][]newInscription)
+	for _, inscription := range inscriptions {
+		created[inscription.tx] = append(created[inscription.tx], inscription)
+	}
+	var ots []OrdTransfer
+	emit := func(ot OrdTransfer, pkScript []byte) {
+		ot.ID = blockHeight*1000000 + uint(len(ots))
+		ot.BlockHeight = blockHeight
+		ot.NewPkscript = ord.Pkscript(hex.EncodeToString(pkScript))
+		ot.NewWallet = walletOf(pkScript, params)
+		ots = append(ots, ot)
+	}
+	for i, tx := range flow.txs {
+		for input, in := range tx.TxIn {
+			var moved []string
+			for id, p := range pending {
+				point, err := ord.ParseSatPoint(p.Satpoint)
+				if err != nil {
+					return nil, err
+				}
+				if string(point.OutPoint().TxID()) == in.PreviousOutPoint.Hash.String() && point.OutPoint().OutputIndex() == uint64(in.PreviousOutPoint.Index) {
+					moved = append(moved, id)
+				}
+			}
+			// The inscriptions of the same output move in the order of their sats, then of their IDs.
+			sort.Slice(moved, func(a, b int) bool {
+				pa, _ := ord.ParseSatPoint(pending[moved[a]].Satpoint)
+				pb, _ := ord.ParseSatPoint(pending[moved[b]].Satpoint)
+				if pa.Offset() != pb.Offset() {
+					return pa.Offset() < pb.Offset()
+				}
+				return moved[a] < moved[b]
+			})
+			for _, id := range moved {
+				p := pending[id]
+				point, _ := ord.ParseSatPoint(p.Satpoint)
+				offset, err := flow.inputOffset(tx, input)
+				if err != nil {
+					return nil, fmt.Errorf("failed to move the inscription %s at the block %d: %v", id, blockHeight, err)
+				}
+				satpoint, pkScript, sentAsFee := flow.locate(tx, offset+point.Offset())
+				emit(OrdTransfer{
+					InscriptionID: id,
+					OldSatpoint:   p.Satpoint,
+					NewSatpoint:   satpoint,
+					SentAsFee:     sentAsFee,
+					Content:       p.Content,
+					ContentType:   p.ContentType,
+					ParentID:      p.ParentID,
+				}, pkScript)
+				delete(pending, id)
+			}
+		}
+		for _, inscription := range created[i] {
+			emit(OrdTransfer{
+				InscriptionID: inscription.id,
+				NewSatpoint:   inscription.satpoint,
+				SentAsFee:     inscription.sentAsFee,
+				Content:       inscription.content,
+				ContentType:   inscription.contentType,
+				ParentID:      inscription.parentID,
+			}, inscription.pkScript)
+			if !inscription.sentAsFee && isTransfer(inscription.content) {
+				pending[inscription.id] = pendingTransfer{
+					Satpoint:    inscription.satpoint,
+					Content:     inscription.content,
+					ContentType: inscription.contentType,
+					ParentID:    inscription.parentID,
+				}
+			}
+		}
+	}
+	return ots, nil
+}
@@ -2,20 +2,8 @@ package getter
 
 import "github.com/RiemaLabs/modular-indexer-committee/ord"
 
-// TODO: High. Record Old satpoint- Current satpoint to get OrdTransfer from the Bitcoin block directly.
-type OrdTransfer struct {
-	ID            uint
-	InscriptionID string
-	BlockHeight   uint
-	OldSatpoint   string
-	NewSatpoint   string
-	NewPkscript   ord.Pkscript
-	NewWallet     ord.Wallet
-	SentAsFee     bool
-	Content       []byte
-	ContentType   string
-	ParentID      string
-}
+// OrdTransfer lives in the ord package so that the execution doesn't depend on any getter.
+type OrdTransfer = ord.OrdTransfer
 
 type OrdGetter interface {
 	GetLatestBlockHeight() (uint, error)
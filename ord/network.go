@@ -0,0 +1,68 @@
+package ord
+
+import "fmt"
+
+// Network is the Bitcoin network indexed by the committee indexer.
+type Network string
+
+const (
+	Mainnet Network = "mainnet"
+	Testnet Network = "testnet"
+	Signet  Network = "signet"
+	Regtest Network = "regtest"
+)
+
+var Networks = []Network{Mainnet, Testnet, Signet, Regtest}
+
+// The network indexed by the committee indexer, whose checkpoints carry its tag.
+var IndexedNetwork = Mainnet
+
+// The first block height indexed on each network by default: the first brc-20 block on the mainnet,
+// and the first inscription block elsewhere.
+var genesisHeights = map[Network]uint{
+	Mainnet: 779832,
+	Testnet: 2413343,
+	Signet:  112402,
+	Regtest: 1,
+}
+
+// The first block height of the self-mint ticks on each network: the activation of the reference indexer on the
+// mainnet, and the first inscription block elsewhere, whose chains start with the self-mint already activated.
+var selfMintHeights = map[Network]uint{
+	Mainnet: 837090,
+	Testnet: 2413343,
+	Signet:  112402,
+	Regtest: 1,
+}
+
+// ParseNetwork parses the name of the network, where empty is the mainnet.
+func ParseNetwork(name string) (Network, error) {
+	if name == "" {
+		return Mainnet, nil
+	}
+	for _, n := range Networks {
+		if Network(name) == n {
+			return n, nil
+		}
+	}
+	return "", fmt.Errorf("unknown network %s, expected one of %v", name, Networks)
+}
+
+// GenesisHeight returns the first block height indexed on the network by default.
+func (n Network) GenesisHeight() uint {
+	return genesisHeights[n]
+}
+
+// SelfMintHeight returns the first block height of the self-mint ticks on the network.
+func (n Network) SelfMintHeight() uint {
+	return selfMintHeights[n]
+}
+
+// Tag returns the network carried by the checkpoints, which is empty on the mainnet so that the checkpoints of
+// the mainnet are unchanged.
+func (n Network) Tag() string {
+	if n == Mainnet {
+		return ""
+	}
+	return string(n)
+}
@@ -0,0 +1,152 @@
+package protocol
+
+import (
+	"fmt"
+	"hash"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-verkle"
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/sha3"
+)
+
+// The names of the key schemes.
+const (
+	// The legacy scheme: Keccak256(preimage)[:StemSize], the same for every protocol.
+	KeySchemeKeccak256 = "keccak256"
+	// BLAKE2b-256(domain + 0x00 + preimage)[:StemSize], separating the key spaces of the protocols by their domains.
+	KeySchemeBlake2b = "blake2b"
+)
+
+// KeyScheme derives the stems of the state keys from their preimages. A preimage is the inputs of a key followed by
+// the suffix of its key space, and the domain names the protocol deriving it, e.g. brc-20.
+// The scheme is part of the key layout of the state, so changing it takes a migration of the whole state.
+type KeyScheme interface {
+	Name() string
+	// Stem returns the stem of the preimage of the domain, without keeping the preimage.
+	Stem(domain string, preImage []byte) [verkle.StemSize]byte
+	// Rule describes the derivation for the verifiers, as published by the schema.
+	Rule() string
+}
+
+type keccak256Scheme struct{}
+
+var keccakHashers = sync.Pool{New: func() any { return sha3.NewLegacyKeccak256() }}
+
+func (keccak256Scheme) Name() string { return KeySchemeKeccak256 }
+
+func (keccak256Scheme) Stem(domain string, preImage []byte) [verkle.StemSize]byte {
+	hasher := keccakHashers.Get().(keccakState)
+	defer keccakHashers.Put(hasher)
+	hasher.Reset()
+	hasher.Write(preImage)
+	// The legacy Keccak256 is read out by the first 32 bytes of its sponge, the same as its Sum, without allocating.
+	var sum [32]byte
+	hasher.Read(sum[:])
+	return [verkle.StemSize]byte(sum[:verkle.StemSize])
+}
+
+func (keccak256Scheme) Rule() string { return "Keccak256(preimage)[:stemSize]" }
+
+type keccakState interface {
+	hash.Hash
+	Read(p []byte) (int, error)
+}
+
+type blake2bScheme struct{}
+
+var blake2bHashers = sync.Pool{New: func() any {
+	hasher, err := blake2b.New256(nil)
+	if err != nil {
+		panic(err)
+	}
+	return hasher
+}}
+
+func (blake2bScheme) Name() string { return KeySchemeBlake2b }
+
+func (blake2bScheme) Stem(domain string, preImage []byte) [verkle.StemSize]byte {
+	hasher := blake2bHashers.Get().(hash.Hash)
+	defer blake2bHashers.Put(hasher)
+	hasher.Reset()
+	hasher.Write([]byte(domain))
+	hasher.Write([]byte{0})
+	hasher.Write(preImage)
+	var sum [32]byte
+	return [verkle.StemSize]byte(hasher.Sum(sum[:0])[:verkle.StemSize])
+}
+
+func (blake2bScheme) Rule() string { return "BLAKE2b-256(domain + 0x00 + preimage)[:stemSize]" }
+
+var keySchemes = map[string]KeyScheme{
+	KeySchemeKeccak256: keccak256Scheme{},
+	KeySchemeBlake2b:   blake2bScheme{},
+}
+
+// KeySchemes returns the names of the supported key schemes.
+func KeySchemes() []string {
+	names := make([]string, 0, len(keySchemes))
+	for name := range keySchemes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// KeySchemeOf returns the key scheme of the name, the legacy one if the name is empty.
+func KeySchemeOf(name string) (KeyScheme, error) {
+	if name == "" {
+		name = KeySchemeKeccak256
+	}
+	scheme, found := keySchemes[name]
+	if !found {
+		return nil, fmt.Errorf("unknown key scheme %s, expected one of %v", name, KeySchemes())
+	}
+	return scheme, nil
+}
+
+// Keys is the scheme deriving the keys of the state, the legacy one unless another is configured before the state is
+// loaded. The execution must not run while it's changed.
+var Keys KeyScheme = keccak256Scheme{}
+
+// KeySchemeFlag returns the name of the scheme as recorded by the layouts and the checkpoints, which is empty for the
+// legacy one, so that they are unchanged until another scheme is used.
+func KeySchemeFlag(scheme KeyScheme) string {
+	if scheme.Name() == KeySchemeKeccak256 {
+		return ""
+	}
+	return scheme.Name()
+}
+
+// StemKey returns the key of the location ID under the stem of the preimage parts + keySpace of the domain by the
+// current scheme, recording its preimage.
+func StemKey(domain string, keySpace string, locationID byte, parts ...string) []byte {
+	var preImage []byte
+	for _, part := range parts {
+		preImage = append(preImage, part...)
+	}
+	preImage = append(preImage, keySpace...)
+	stem := Keys.Stem(domain, preImage)
+	RecordPreImage(stem, KeyPreImage{Domain: domain, KeySpace: keySpace, Inputs: parts})
+	return append(stem[:], locationID)
+}
+
+// PreImage is the preimage of a stem of the state, which the stem is derived from by any scheme.
+type PreImage struct {
+	// The protocol deriving the stem, e.g. brc-20.
+	Domain string `json:"domain"`
+	// The inputs of the key followed by the suffix of its key space.
+	Data []byte `json:"data"`
+	// The namespace of the protocol if its keys are namespaced, see Namespace.
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// StemOf derives the stem of the preimage by the scheme.
+func (p PreImage) StemOf(scheme KeyScheme) [verkle.StemSize]byte {
+	stem := scheme.Stem(p.Domain, p.Data)
+	if p.Namespace == "" {
+		return stem
+	}
+	return namespaceStem(scheme, p.Namespace, stem)
+}
@@ -0,0 +1,55 @@
+package protocol
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ethereum/go-verkle"
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/sha3"
+)
+
+func TestKeyScheme(t *testing.T) {
+	if _, err := KeySchemeOf("sha1"); err == nil {
+		t.Fatal("Expected the unknown scheme to be rejected")
+	}
+	legacy, _ := KeySchemeOf("")
+	if legacy.Name() != KeySchemeKeccak256 || KeySchemeFlag(legacy) != "" {
+		t.Fatalf("Expected the legacy scheme by default, got %s", legacy.Name())
+	}
+	blake, err := KeySchemeOf(KeySchemeBlake2b)
+	if err != nil || KeySchemeFlag(blake) != KeySchemeBlake2b {
+		t.Fatalf("Unexpected scheme %v", err)
+	}
+
+	preImage := []byte("ordiGetTickHash")
+	keccak := sha3.NewLegacyKeccak256()
+	keccak.Write(preImage)
+	if stem := legacy.Stem("brc-20", preImage); !bytes.Equal(stem[:], keccak.Sum(nil)[:verkle.StemSize]) {
+		t.Fatalf("Unexpected legacy stem %x", stem)
+	}
+	if legacy.Stem("brc-20", preImage) != legacy.Stem("sns", preImage) {
+		t.Fatal("Expected the legacy scheme to ignore the domain")
+	}
+	sum := blake2b.Sum256(append([]byte("brc-20\x00"), preImage...))
+	if stem := blake.Stem("brc-20", preImage); !bytes.Equal(stem[:], sum[:verkle.StemSize]) {
+		t.Fatalf("Unexpected blake2b stem %x", stem)
+	}
+	if blake.Stem("brc-20", preImage) == blake.Stem("sns", preImage) {
+		t.Fatal("Expected the domains to be separated")
+	}
+
+	// The stem of a preimage of a namespace is the one of its namespaced keys.
+	defer func() {
+		Keys = legacy
+	}()
+	for _, scheme := range []KeyScheme{legacy, blake} {
+		Keys = scheme
+		key := StemKey("sns", "GetNameHash", 0x03, "satoshi.sats")
+		p := PreImage{Domain: "sns", Data: []byte("satoshi.satsGetNameHash"), Namespace: "sns"}
+		stem := p.StemOf(scheme)
+		if namespaced := NamespaceKey("sns", key); !bytes.Equal(namespaced, append(stem[:], 0x03)) {
+			t.Fatalf("Unexpected namespaced key %x by %s", namespaced, scheme.Name())
+		}
+	}
+}
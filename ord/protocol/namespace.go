@@ -0,0 +1,73 @@
+package protocol
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-verkle"
+	uint256 "github.com/holiman/uint256"
+)
+
+// Keys of a namespace
+// Key: Keccak256(name + "Namespace" + key[:StemSize])[:StemSize] + key[StemSize], by the legacy key scheme
+// The last byte is kept, so the keys sharing a stem in the protocol still share a stem in the state.
+type namespaced struct {
+	state KVStorage
+	name  string
+}
+
+// Namespace isolates the keys of a protocol from the keys of the others by the name of the protocol.
+func Namespace(state KVStorage, name string) KVStorage {
+	return &namespaced{state: state, name: name}
+}
+
+// NamespaceKey returns the key in the state of a key of the protocol, derived by the key scheme in the domain of the protocol.
+func NamespaceKey(name string, key []byte) []byte {
+	stem := namespaceStem(Keys, name, [verkle.StemSize]byte(key[:verkle.StemSize]))
+	if RecordingPreImages() {
+		recordNamespaced(name, [verkle.StemSize]byte(key[:verkle.StemSize]), stem)
+	}
+	return append(stem[:], key[verkle.StemSize])
+}
+
+func namespaceStem(scheme KeyScheme, name string, stem [verkle.StemSize]byte) [verkle.StemSize]byte {
+	preImage := make([]byte, 0, len(name)+len("Namespace")+verkle.StemSize)
+	preImage = append(append(append(preImage, name...), "Namespace"...), stem[:]...)
+	return scheme.Stem(name, preImage)
+}
+
+func (n *namespaced) InsertInscriptionID(key []byte, value string) error {
+	return n.state.InsertInscriptionID(NamespaceKey(n.name, key), value)
+}
+
+func (n *namespaced) GetInscriptionID(key []byte) (string, error) {
+	return n.state.GetInscriptionID(NamespaceKey(n.name, key))
+}
+
+func (n *namespaced) InsertUInt256(key []byte, value *uint256.Int) error {
+	return n.state.InsertUInt256(NamespaceKey(n.name, key), value)
+}
+
+func (n *namespaced) GetUInt256(key []byte) (*uint256.Int, error) {
+	return n.state.GetUInt256(NamespaceKey(n.name, key))
+}
+
+func (n *namespaced) InsertBytes(key []byte, value []byte) error {
+	return n.state.InsertBytes(NamespaceKey(n.name, key), value)
+}
+
+func (n *namespaced) GetBytes(key []byte) ([]byte, error) {
+	return n.state.GetBytes(NamespaceKey(n.name, key))
+}
+
+func (n *namespaced) Delete(key []byte) error {
+	return n.state.Delete(NamespaceKey(n.name, key))
+}
+
+// Range isn't supported, since the stems of the namespace are hashed and no prefix of the protocol maps to one of the state.
+func (n *namespaced) Range(prefix []byte, fn func(key []byte, value []byte) bool) error {
+	return fmt.Errorf("%w: the keys of the namespace %s are hashed", ErrUnsupported, n.name)
+}
+
+func (n *namespaced) GetHeight() uint {
+	return n.state.GetHeight()
+}
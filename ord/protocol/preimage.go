@@ -0,0 +1,81 @@
+package protocol
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ethereum/go-verkle"
+)
+
+// KeyPreImage is the preimage of a stem of the state as derived by a protocol: the unique ID of the key, i.e. its
+// inputs such as the tick and the pkscript, followed by the suffix naming its key space. The location ID of a key, the
+// last byte, isn't hashed, so the preimage of its stem with the location ID tells what the key is.
+type KeyPreImage struct {
+	Domain   string   `json:"domain"`
+	KeySpace string   `json:"keySpace"`
+	Inputs   []string `json:"inputs"`
+	// The namespace of the protocol if its keys are namespaced, see Namespace.
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// PreImage returns the hashed preimage, which the stem is derived from by any key scheme.
+func (p KeyPreImage) PreImage() PreImage {
+	return PreImage{Domain: p.Domain, Data: []byte(strings.Join(p.Inputs, "") + p.KeySpace), Namespace: p.Namespace}
+}
+
+// The preimages of the stems derived since they were taken, while recording.
+var (
+	recording atomic.Bool
+	recorded  struct {
+		sync.Mutex
+		stems map[[verkle.StemSize]byte]KeyPreImage
+	}
+)
+
+// RecordPreImages enables or disables the recording of the preimages of the derived stems.
+func RecordPreImages(enabled bool) {
+	recording.Store(enabled)
+	if !enabled {
+		TakePreImages()
+	}
+}
+
+// RecordingPreImages returns whether the preimages of the derived stems are recorded.
+func RecordingPreImages() bool {
+	return recording.Load()
+}
+
+// RecordPreImage records the preimage of the stem, if recording. The inputs are kept, so they must not be changed.
+func RecordPreImage(stem [verkle.StemSize]byte, p KeyPreImage) {
+	if !recording.Load() {
+		return
+	}
+	recorded.Lock()
+	defer recorded.Unlock()
+	if recorded.stems == nil {
+		recorded.stems = make(map[[verkle.StemSize]byte]KeyPreImage)
+	}
+	recorded.stems[stem] = p
+}
+
+// TakePreImages returns the preimages recorded since they were last taken, which the recording starts over from.
+func TakePreImages() map[[verkle.StemSize]byte]KeyPreImage {
+	recorded.Lock()
+	defer recorded.Unlock()
+	stems := recorded.stems
+	recorded.stems = nil
+	return stems
+}
+
+// recordNamespaced records the preimage of the stem of a namespace, given the stem of the protocol recorded before.
+func recordNamespaced(name string, stem, namespaced [verkle.StemSize]byte) {
+	recorded.Lock()
+	defer recorded.Unlock()
+	p, found := recorded.stems[stem]
+	if !found {
+		return
+	}
+	p.Namespace = name
+	recorded.stems[namespaced] = p
+}
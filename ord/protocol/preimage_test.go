@@ -0,0 +1,35 @@
+package protocol
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-verkle"
+)
+
+func TestRecordPreImages(t *testing.T) {
+	StemKey("sns", "GetNameHash", 0x00, "ignored.sats")
+	if len(TakePreImages()) != 0 {
+		t.Fatal("Expected no preimage recorded unless recording")
+	}
+	RecordPreImages(true)
+	defer RecordPreImages(false)
+
+	key := StemKey("sns", "GetNameHash", 0x03, "satoshi.sats")
+	namespaced := NamespaceKey("sns", key)
+	recorded := TakePreImages()
+	p, found := recorded[[verkle.StemSize]byte(key[:verkle.StemSize])]
+	if !found || p.Domain != "sns" || p.KeySpace != "GetNameHash" || len(p.Inputs) != 1 || p.Inputs[0] != "satoshi.sats" || p.Namespace != "" {
+		t.Fatalf("Unexpected preimage %+v", p)
+	}
+	n, found := recorded[[verkle.StemSize]byte(namespaced[:verkle.StemSize])]
+	if !found || n.Namespace != "sns" || n.KeySpace != p.KeySpace {
+		t.Fatalf("Unexpected preimage of the namespaced key %+v", n)
+	}
+	// The preimages derive the stems by any scheme.
+	if stem := n.PreImage().StemOf(Keys); stem != [verkle.StemSize]byte(namespaced[:verkle.StemSize]) {
+		t.Fatalf("The preimage derives the stem %x instead of %x", stem, namespaced[:verkle.StemSize])
+	}
+	if len(TakePreImages()) != 0 {
+		t.Fatal("Expected the taken preimages to be cleared")
+	}
+}
@@ -0,0 +1,251 @@
+package protocol
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord"
+	uint256 "github.com/holiman/uint256"
+)
+
+// KVStorage is the state read and written by the execution.
+// The execution is deterministic and free of I/O, so any storage may back it, including a stateless verkle tree.
+// A malformed key or value is rejected by an error before anything is written, see CheckKey, CheckBytes and
+// SplitInscriptionID, so that the storages agree on the values they accept. The keys and the values passed in remain
+// the caller's, which may reuse their buffers once the call returns, so the storages copy rather than keep them.
+type KVStorage interface {
+	InsertInscriptionID(key []byte, value string) error
+
+	GetInscriptionID(key []byte) (string, error)
+
+	InsertUInt256(key []byte, value *uint256.Int) error
+
+	GetUInt256(key []byte) (*uint256.Int, error)
+
+	InsertBytes(key []byte, value []byte) error
+
+	GetBytes(key []byte) ([]byte, error)
+
+	// Delete removes the value of a single slot, so a value spanning several slots, such as the bytes or an
+	// inscription ID, is removed slot by slot. A deleted key reads as zeros. The stateless view of the re-execution
+	// can't remove keys, so the deletions are meant for the maintenance of the state, such as the migrations,
+	// rather than for the rules of a protocol.
+	Delete(key []byte) error
+
+	// Range calls fn on the value of every slot whose key starts with the prefix, in no particular order, until fn
+	// returns false. The values ranged aren't recorded as accesses, and the storages which only hold the keys
+	// accessed by a block, or whose keys are hashed, return ErrUnsupported instead.
+	Range(prefix []byte, fn func(key []byte, value []byte) bool) error
+
+	GetHeight() uint
+}
+
+// Handler executes the transfers of a meta-protocol in a block.
+// Executing the transfers of a block one by one must be the same as executing them at once.
+type Handler interface {
+	Exec(state KVStorage, ots []ord.OrdTransfer, blockHeight uint)
+}
+
+// HandlerFunc adapts a function to the Handler.
+type HandlerFunc func(state KVStorage, ots []ord.OrdTransfer, blockHeight uint)
+
+func (f HandlerFunc) Exec(state KVStorage, ots []ord.OrdTransfer, blockHeight uint) {
+	f(state, ots, blockHeight)
+}
+
+// StageObserver is optionally implemented by the KVStorage timing the stages of the execution, e.g. to trace them.
+type StageObserver interface {
+	ObserveStage(name string, started time.Time, elapsed time.Duration)
+}
+
+// Timed runs f as the stage of the execution, timed only if the state observes the stages,
+// so that the execution stays free of the clock elsewhere, such as in the re-execution of the witnesses.
+func Timed(state any, stage string, f func()) {
+	o, ok := state.(StageObserver)
+	if !ok {
+		f()
+		return
+	}
+	started := time.Now()
+	f()
+	o.ObserveStage(stage, started, time.Since(started))
+}
+
+type registration struct {
+	name         string
+	handler      Handler
+	contentTypes []string
+}
+
+var (
+	defaultProtocol *registration
+	registry        = make(map[string]*registration)
+)
+
+// RegisterDefault registers the protocol receiving the transfers not claimed by any other protocol.
+// Its keys live in the root of the state, so it's reserved for BRC-20, whose key layout predates the registry.
+func RegisterDefault(name string, h Handler) {
+	if defaultProtocol != nil {
+		panic(fmt.Errorf("the default protocol is already registered as %s", defaultProtocol.name))
+	}
+	defaultProtocol = &registration{name: name, handler: h}
+}
+
+// Activated is optionally implemented by the Handler of a protocol executed from an activation height on, which
+// claims no transfer of the earlier blocks, so that they go to the default protocol as before the registration.
+type Activated interface {
+	ActivationHeight() uint
+}
+
+// Claimer is optionally implemented by the Handler sharing its content types with other protocols, e.g. the plain
+// texts, so that it only receives the transfers of those content types it claims by their content.
+type Claimer interface {
+	Claims(ot ord.OrdTransfer) bool
+}
+
+// Register registers the handler of a protocol, which receives the transfers of one of the content types whose JSON
+// content names the protocol in the "p" field, or, lacking one, which the handler claims if it's a Claimer. The
+// transfers of other content types go to the default protocol whatever they name, as before the registry. The protocols are tried in the order of their names. Its keys are namespaced by the name.
+// Register shall be called before the execution of any block.
+func Register(name string, h Handler, contentTypes ...string) {
+	name = strings.ToLower(name)
+	if _, found := registry[name]; found || (defaultProtocol != nil && defaultProtocol.name == name) {
+		panic(fmt.Errorf("the protocol %s is already registered", name))
+	}
+	registry[name] = &registration{name: name, handler: h, contentTypes: contentTypes}
+}
+
+// Protocols returns the names of the registered protocols, the default one first.
+func Protocols() []string {
+	names := make([]string, 0, len(registry)+1)
+	if defaultProtocol != nil {
+		names = append(names, defaultProtocol.name)
+	}
+	return append(names, registered()...)
+}
+
+// Modules returns the activation heights of the protocols other than the default one by their names, 0 if the
+// handler isn't Activated.
+func Modules() map[string]uint {
+	modules := make(map[string]uint, len(registry))
+	for name, r := range registry {
+		if a, ok := r.handler.(Activated); ok {
+			modules[name] = a.ActivationHeight()
+		} else {
+			modules[name] = 0
+		}
+	}
+	return modules
+}
+
+// registered returns the names of the protocols other than the default one in order.
+func registered() []string {
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Owner is the address owning what the output receives, its pkscript if the wallet is unknown.
+func Owner(wallet ord.Wallet, pkscript ord.Pkscript) string {
+	if wallet != "" {
+		return string(wallet)
+	}
+	return string(pkscript)
+}
+
+// ContentType returns the media type of the transfer, which may be hex encoded by the getters.
+func ContentType(ot ord.OrdTransfer) string {
+	contentType := ot.ContentType
+	if decodedBytes, err := hex.DecodeString(contentType); err == nil {
+		contentType = string(decodedBytes)
+	}
+	return strings.Split(contentType, ";")[0]
+}
+
+// active tells whether the protocol claims the transfers of the block, i.e. the block isn't before its activation.
+func (r *registration) active(blockHeight uint) bool {
+	a, ok := r.handler.(Activated)
+	return !ok || blockHeight >= a.ActivationHeight()
+}
+
+// Of returns the protocol of the transfer of the block, empty if it goes to the default protocol.
+func Of(ot ord.OrdTransfer, blockHeight uint) string {
+	if len(registry) == 0 {
+		return ""
+	}
+	var js struct {
+		P string `json:"p"`
+	}
+	_ = json.Unmarshal(ot.Content, &js)
+	contentType := ContentType(ot)
+	if js.P != "" {
+		// The names are matched as they are registered, in lower case, so that the other cases go to the default one.
+		if r, found := registry[js.P]; found && r.active(blockHeight) && slices.Contains(r.contentTypes, contentType) {
+			return r.name
+		}
+		return ""
+	}
+	for _, name := range registered() {
+		r := registry[name]
+		if !r.active(blockHeight) || !slices.Contains(r.contentTypes, contentType) {
+			continue
+		}
+		if c, ok := r.handler.(Claimer); !ok || c.Claims(ot) {
+			return name
+		}
+	}
+	return ""
+}
+
+// Exec dispatches the transfers of a block to the handlers of their protocols, keeping the order of the block
+// for each protocol. Every handler is called, even without any transfer, the default one first.
+func Exec(state KVStorage, ots []ord.OrdTransfer, blockHeight uint) {
+	if defaultProtocol == nil {
+		panic(fmt.Errorf("no default protocol is registered"))
+	}
+	if len(registry) == 0 {
+		Timed(state, "handler."+defaultProtocol.name, func() { defaultProtocol.handler.Exec(state, ots, blockHeight) })
+		return
+	}
+	claimed := make(map[string][]ord.OrdTransfer, len(registry))
+	unclaimed := make([]ord.OrdTransfer, 0, len(ots))
+	Timed(state, "dispatch", func() {
+		for _, ot := range ots {
+			if name := Of(ot, blockHeight); name != "" {
+				claimed[name] = append(claimed[name], ot)
+			} else {
+				unclaimed = append(unclaimed, ot)
+			}
+		}
+	})
+	Timed(state, "handler."+defaultProtocol.name, func() { defaultProtocol.handler.Exec(state, unclaimed, blockHeight) })
+	for _, name := range Protocols()[1:] {
+		Timed(state, "handler."+name, func() { registry[name].handler.Exec(Namespace(state, name), claimed[name], blockHeight) })
+	}
+}
+
+// ExecModule executes the transfers of a block claimed by the protocol other than the default one on its namespace
+// alone, as Exec does, e.g. to backfill the blocks a protocol newly enabled on a state missed. The keys of the other
+// protocols are left as they are.
+func ExecModule(state KVStorage, name string, ots []ord.OrdTransfer, blockHeight uint) error {
+	r, found := registry[strings.ToLower(name)]
+	if !found {
+		return fmt.Errorf("the protocol %s isn't registered", name)
+	}
+	claimed := make([]ord.OrdTransfer, 0)
+	for _, ot := range ots {
+		if Of(ot, blockHeight) == r.name {
+			claimed = append(claimed, ot)
+		}
+	}
+	Timed(state, "handler."+r.name, func() { r.handler.Exec(Namespace(state, r.name), claimed, blockHeight) })
+	return nil
+}
@@ -0,0 +1,167 @@
+package protocol
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord"
+	uint256 "github.com/holiman/uint256"
+)
+
+type memoryState map[string][]byte
+
+func (m memoryState) InsertInscriptionID(key []byte, value string) error {
+	m[string(key)] = []byte(value)
+	return nil
+}
+func (m memoryState) GetInscriptionID(key []byte) (string, error) { return string(m[string(key)]), nil }
+func (m memoryState) InsertUInt256(key []byte, value *uint256.Int) error {
+	m[string(key)] = value.Bytes()
+	return nil
+}
+func (m memoryState) GetUInt256(key []byte) (*uint256.Int, error) {
+	return uint256.NewInt(0).SetBytes(m[string(key)]), nil
+}
+func (m memoryState) InsertBytes(key []byte, value []byte) error {
+	m[string(key)] = value
+	return nil
+}
+func (m memoryState) GetBytes(key []byte) ([]byte, error) { return m[string(key)], nil }
+func (m memoryState) GetHeight() uint                     { return 0 }
+func (m memoryState) Delete(key []byte) error {
+	delete(m, string(key))
+	return nil
+}
+func (m memoryState) Range(prefix []byte, fn func(key []byte, value []byte) bool) error {
+	for key, value := range m {
+		if strings.HasPrefix(key, string(prefix)) && !fn([]byte(key), value) {
+			break
+		}
+	}
+	return nil
+}
+
+// get reads the counter of the test, whose key is always valid.
+func get(state KVStorage, key []byte) *uint256.Int {
+	value, _ := state.GetUInt256(key)
+	return value
+}
+
+// counter counts the transfers it receives under the same key, whatever the protocol.
+func counter(received *[]string) Handler {
+	key := bytes.Repeat([]byte{1}, 32)
+	return HandlerFunc(func(state KVStorage, ots []ord.OrdTransfer, blockHeight uint) {
+		for _, ot := range ots {
+			*received = append(*received, ot.InscriptionID)
+			_ = state.InsertUInt256(key, uint256.NewInt(0).AddUint64(get(state, key), 1))
+		}
+	})
+}
+
+func TestExec(t *testing.T) {
+	defer func() { defaultProtocol, registry = nil, make(map[string]*registration) }()
+	var brc20, toy []string
+	RegisterDefault("brc-20", counter(&brc20))
+	Register("TOY", counter(&toy), "text/toy")
+
+	state := memoryState{}
+	ots := []ord.OrdTransfer{
+		{InscriptionID: "a", ContentType: "text/plain", Content: []byte(`{"p":"brc-20","op":"mint","tick":"ordi"}`)},
+		{InscriptionID: "b", ContentType: "application/json", Content: []byte(`{"p":"toy","op":"mint"}`)},
+		{InscriptionID: "c", ContentType: "746578742f746f793b636861727365743d7574662d38", Content: []byte("plain toy")},
+		{InscriptionID: "d", ContentType: "text/plain", Content: []byte(`{"p":"unknown"}`)},
+		{InscriptionID: "e", ContentType: "text/toy", Content: []byte(`{"p":"brc-20"}`)},
+		{InscriptionID: "f", ContentType: "text/toy", Content: []byte(`{"p":"toy","op":"mint"}`)},
+		{InscriptionID: "g", ContentType: "text/toy", Content: []byte(`{"p":"TOY","op":"mint"}`)},
+	}
+	Exec(state, ots, 1)
+	// The transfers naming the protocol in another content type or another case go to the default one.
+	if strings.Join(brc20, "") != "abdeg" {
+		t.Fatalf("Unexpected transfers of the default protocol: %v", brc20)
+	}
+	if strings.Join(toy, "") != "cf" {
+		t.Fatalf("Unexpected transfers of the toy protocol: %v", toy)
+	}
+
+	// The same key of both protocols is apart in the state.
+	key := bytes.Repeat([]byte{1}, 32)
+	if get(state, key).Uint64() != 5 || get(Namespace(state, "toy"), key).Uint64() != 2 || len(state) != 2 {
+		t.Fatalf("Expected the keys of the protocols to be isolated, got %d keys", len(state))
+	}
+	if nk := NamespaceKey("toy", key); nk[31] != key[31] || bytes.Equal(nk, key) {
+		t.Fatalf("Unexpected namespaced key %x", nk)
+	}
+	if got := Protocols(); len(got) != 2 || got[0] != "brc-20" || got[1] != "toy" {
+		t.Fatalf("Unexpected protocols %v", got)
+	}
+}
+
+// claimer counts the transfers it receives, and claims the plain texts with the suffix.
+type claimer struct {
+	Handler
+	suffix string
+}
+
+func (c claimer) Claims(ot ord.OrdTransfer) bool {
+	return strings.HasSuffix(string(ot.Content), c.suffix)
+}
+
+func TestClaimer(t *testing.T) {
+	defer func() { defaultProtocol, registry = nil, make(map[string]*registration) }()
+	var brc20, names, districts []string
+	RegisterDefault("brc-20", counter(&brc20))
+	Register("names", claimer{counter(&names), ".sats"}, "text/plain")
+	Register("districts", claimer{counter(&districts), ".bitmap"}, "text/plain")
+
+	ots := []ord.OrdTransfer{
+		{InscriptionID: "a", ContentType: "text/plain", Content: []byte("satoshi.sats")},
+		{InscriptionID: "b", ContentType: "text/plain", Content: []byte("800000.bitmap")},
+		{InscriptionID: "c", ContentType: "text/plain", Content: []byte("hello")},
+		{InscriptionID: "d", ContentType: "text/plain", Content: []byte(`{"p":"names","op":"reg"}`)},
+	}
+	Exec(memoryState{}, ots, 1)
+	if len(names) != 2 || names[0] != "a" || names[1] != "d" {
+		t.Fatalf("Unexpected transfers of the names: %v", names)
+	}
+	if len(districts) != 1 || districts[0] != "b" {
+		t.Fatalf("Unexpected transfers of the districts: %v", districts)
+	}
+	if len(brc20) != 1 || brc20[0] != "c" {
+		t.Fatalf("Unexpected transfers of the default protocol: %v", brc20)
+	}
+}
+
+// activated is a claimer activated at the height.
+type activated struct {
+	claimer
+	height uint
+}
+
+func (a activated) ActivationHeight() uint {
+	return a.height
+}
+
+func TestActivation(t *testing.T) {
+	defer func() { defaultProtocol, registry = nil, make(map[string]*registration) }()
+	var brc20, names []string
+	RegisterDefault("brc-20", counter(&brc20))
+	Register("names", activated{claimer{counter(&names), ".sats"}, 10}, "text/plain")
+
+	ots := []ord.OrdTransfer{
+		{InscriptionID: "a", ContentType: "text/plain", Content: []byte("satoshi.sats")},
+		{InscriptionID: "b", ContentType: "text/plain", Content: []byte(`{"p":"names","op":"reg"}`)},
+	}
+	// Before the activation, the transfers naming the protocol go to the default one.
+	Exec(memoryState{}, ots, 9)
+	if len(names) != 0 || len(brc20) != 2 {
+		t.Fatalf("Unexpected transfers before the activation: %v and %v", names, brc20)
+	}
+	if got := Modules(); len(got) != 1 || got["names"] != 10 {
+		t.Fatalf("Unexpected modules %v", got)
+	}
+	Exec(memoryState{}, ots, 10)
+	if len(names) != 2 || len(brc20) != 2 {
+		t.Fatalf("Unexpected transfers from the activation: %v and %v", names, brc20)
+	}
+}
@@ -0,0 +1,73 @@
+package protocol
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-verkle"
+	uint256 "github.com/holiman/uint256"
+)
+
+var (
+	ErrInvalidKey           = errors.New("invalid key")
+	ErrValueTooLarge        = errors.New("value too large")
+	ErrInvalidInscriptionID = errors.New("invalid inscription ID")
+	// ErrUnsupported is returned by the storages which can't range or delete their keys.
+	ErrUnsupported = errors.New("unsupported by the storage")
+)
+
+// StorageError is the panic of a state failing to read or write its storage, e.g. a failing disk. Unlike the failure of
+// a transfer, it isn't the same on every node, so it aborts the block instead of skipping the transfer.
+type StorageError struct {
+	Err error
+}
+
+func (e *StorageError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *StorageError) Unwrap() error {
+	return e.Err
+}
+
+// The size of a value slot of the state.
+const SlotSize = verkle.LeafValueSize
+
+// CheckKey checks the length of the key.
+func CheckKey(key []byte) error {
+	if len(key) != verkle.KeySize {
+		return fmt.Errorf("%w: the length of the key must be %d, current is: %d", ErrInvalidKey, verkle.KeySize, len(key))
+	}
+	return nil
+}
+
+// CheckBytes checks that the bytes fit the slots following the key, the first one holding their length.
+func CheckBytes(key []byte, value []byte) error {
+	if err := CheckKey(key); err != nil {
+		return err
+	}
+	maxSize := (verkle.NodeWidth - int(key[verkle.StemSize])) * SlotSize
+	if len(value) > maxSize {
+		return fmt.Errorf("%w: the max length of the bytes is: %d at key %x, current is: %d", ErrValueTooLarge, maxSize, key, len(value))
+	}
+	return nil
+}
+
+// SplitInscriptionID splits the inscription ID into its transaction ID, stored in the slot of the key,
+// and its output index, stored in the next slot.
+func SplitInscriptionID(value string) ([]byte, *uint256.Int, error) {
+	const txidLen = SlotSize * 2
+	if len(value) <= txidLen {
+		return nil, nil, fmt.Errorf("%w: %q", ErrInvalidInscriptionID, value)
+	}
+	transactionID, err := hex.DecodeString(value[:txidLen])
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %q: %v", ErrInvalidInscriptionID, value, err)
+	}
+	outputIndex, err := uint256.FromDecimal(value[txidLen+1:])
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %q: %v", ErrInvalidInscriptionID, value, err)
+	}
+	return transactionID, outputIndex, nil
+}
@@ -0,0 +1,183 @@
+package reexec
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/ethereum/go-verkle"
+	uint256 "github.com/holiman/uint256"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord/protocol"
+)
+
+// LightHeader is a state backed by a stateless verkle tree, rebuilt from the proof of the keys accessed by a block.
+type LightHeader struct {
+	// Verkle Tree Root
+	Root verkle.VerkleNode
+	// The state is after the execution of Block Height.
+	Height uint
+	// Block Hash.
+	Hash string
+}
+
+func (h *LightHeader) insert(key []byte, value []byte, nodeResolverFn verkle.NodeResolverFn) error {
+	if err := protocol.CheckKey(key); err != nil {
+		return err
+	}
+	_ = h.Root.Insert(key, value, nodeResolverFn)
+	return nil
+}
+
+func (h *LightHeader) get(key []byte, nodeResolverFn verkle.NodeResolverFn) ([]byte, error) {
+	if err := protocol.CheckKey(key); err != nil {
+		return nil, err
+	}
+	oldValue, err := h.Root.Get(key, nodeResolverFn)
+	if err != nil {
+		if err.Error() == "trying to access a node that is missing from the stateless view" {
+			// stateless view doesn't include values that first read then write.
+			var res [verkle.LeafValueSize]byte
+			return res[:], nil
+		} else {
+			return nil, err
+		}
+	}
+	return oldValue, nil
+}
+
+func (h *LightHeader) InsertInscriptionID(key []byte, value string) error {
+	transactionID, outputIndex, err := protocol.SplitInscriptionID(value)
+	if err == nil {
+		err = protocol.CheckKey(key)
+	}
+	if err != nil {
+		return err
+	}
+	// The first slot contains the first 32 bytes of the InscriptionID
+	firstKey := make([]byte, verkle.KeySize)
+	copy(firstKey, key)
+	if err := h.insert(firstKey, transactionID, nil); err != nil {
+		return err
+	}
+
+	// The second slot contains the output index of the InscriptionID
+	secondKey := make([]byte, verkle.KeySize)
+	copy(secondKey, key)
+	secondKey[verkle.StemSize] = firstKey[verkle.StemSize] + byte(1)
+	return h.InsertUInt256(secondKey, outputIndex)
+}
+
+func (h *LightHeader) GetInscriptionID(key []byte) (string, error) {
+	if err := protocol.CheckKey(key); err != nil {
+		return "", err
+	}
+	// The first Key
+	firstKey := make([]byte, verkle.KeySize)
+	copy(firstKey, key)
+	transactionIDBytes, err := h.get(firstKey, nil)
+	if err != nil {
+		return "", err
+	}
+	transactionID := hex.EncodeToString(transactionIDBytes)
+
+	// The second Key
+	secondKey := make([]byte, verkle.KeySize)
+	copy(secondKey, key)
+	secondKey[verkle.StemSize] = firstKey[verkle.StemSize] + byte(1)
+	outputIndexUint256, err := h.GetUInt256(secondKey)
+	if err != nil {
+		return "", err
+	}
+	outputIndex := outputIndexUint256.Dec()
+
+	return transactionID + "i" + outputIndex, nil
+}
+
+func (h *LightHeader) InsertUInt256(key []byte, value *uint256.Int) error {
+	var dest [verkle.LeafValueSize]byte
+	value.WriteToArray32(&dest)
+	return h.insert(key, dest[:], nil)
+}
+
+func (h *LightHeader) GetUInt256(key []byte) (*uint256.Int, error) {
+	res := uint256.NewInt(0)
+	value, err := h.get(key, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(value) == 0 {
+		return res, nil
+	}
+	return res.SetBytes(value), nil
+}
+
+func (h *LightHeader) InsertBytes(key []byte, value []byte) error {
+	if err := protocol.CheckBytes(key, value); err != nil {
+		return err
+	}
+	// The first slot is the number of required slots to store the byte.
+	newKey := make([]byte, verkle.KeySize)
+	copy(newKey, key)
+
+	len := len(value)
+	requiredSlots := (len + verkle.LeafValueSize - 1) / verkle.LeafValueSize
+	if err := h.InsertUInt256(newKey, uint256.NewInt(uint64(len))); err != nil {
+		return err
+	}
+
+	totalLen := requiredSlots * verkle.LeafValueSize
+	padded := make([]byte, totalLen)
+	copy(padded, value)
+
+	for i := range requiredSlots {
+		newKey[verkle.StemSize] = key[verkle.StemSize] + byte(i+1)
+		if err := h.insert(newKey, padded[i*verkle.LeafValueSize:(i+1)*verkle.LeafValueSize], nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h *LightHeader) GetBytes(key []byte) ([]byte, error) {
+	if err := protocol.CheckKey(key); err != nil {
+		return nil, err
+	}
+	newKey := make([]byte, verkle.KeySize)
+	copy(newKey, key)
+
+	length, err := h.GetUInt256(newKey)
+	if err != nil {
+		return nil, err
+	}
+	len := length.Uint64()
+	if len == 0 {
+		return make([]byte, 0), nil
+	}
+	requiredSlots := (len + verkle.LeafValueSize - 1) / verkle.LeafValueSize
+
+	padded := make([]byte, 0)
+	for i := range requiredSlots {
+		newKey[verkle.StemSize] = key[verkle.StemSize] + byte(i+1)
+		value, err := h.get(newKey, nil)
+		if err != nil {
+			return nil, err
+		}
+		padded = append(padded, value...)
+	}
+	res := padded[:len]
+	return res, nil
+}
+
+// Delete isn't supported, since the keys can't be removed from the stateless tree.
+func (h *LightHeader) Delete(key []byte) error {
+	return fmt.Errorf("%w: the stateless view can't delete the key %x", protocol.ErrUnsupported, key)
+}
+
+// Range isn't supported, since the stateless tree only holds the keys accessed by the block.
+func (h *LightHeader) Range(prefix []byte, fn func(key []byte, value []byte) bool) error {
+	return fmt.Errorf("%w: the stateless view only holds the keys accessed by the block", protocol.ErrUnsupported)
+}
+
+func (h *LightHeader) GetHeight() uint {
+	return h.Height
+}
@@ -0,0 +1,41 @@
+package reexec
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord"
+	"github.com/ethereum/go-verkle"
+)
+
+type WitnessEntry struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Exists bool   `json:"exists"`
+}
+
+// Witness is a self-contained record of the execution of a block:
+// the transfers, every state read with the proof of its value against the pre-state root, and every state write.
+type Witness struct {
+	Height         uint              `json:"height"`
+	PreCommitment  string            `json:"preCommitment"`
+	PostCommitment string            `json:"postCommitment"`
+	OrdTransfers   []ord.OrdTransfer `json:"ordTransfers"`
+	Reads          []WitnessEntry    `json:"reads"`
+	Writes         []WitnessEntry    `json:"writes"`
+	// The multiproof of all read keys, nil if no key is accessed.
+	Proof     *verkle.VerkleProof `json:"proof"`
+	StateDiff verkle.StateDiff    `json:"stateDiff"`
+}
+
+// Hash is the hex of the SHA-256 of the JSON encoding of the witness, i.e. of the exported witness file,
+// which is published with the checkpoints.
+func (w *Witness) Hash() (string, error) {
+	bytes, err := json.Marshal(w)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(bytes)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,138 @@
+// Package reexec re-executes a block from its execution witness and recomputes the post-state root.
+// It performs no I/O and depends only on deterministic computation,
+// so it can be compiled into zkVM guests to prove the correct execution of the committee.
+package reexec
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord/protocol"
+	"github.com/ethereum/go-verkle"
+)
+
+func parseCommitment(commitment string) (*verkle.Point, error) {
+	bytes, err := base64.StdEncoding.DecodeString(commitment)
+	if err != nil {
+		return nil, err
+	}
+	var p verkle.Point
+	err = p.SetBytes(bytes)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// PreState rebuilds the stateless tree of the accessed keys and verifies it against the pre-state root.
+func PreState(rootC *verkle.Point, vProof *verkle.VerkleProof, stateDiff verkle.StateDiff) (verkle.VerkleNode, error) {
+	if vProof == nil {
+		return verkle.NewStatelessInternal(0, rootC), nil
+	}
+	proof, err := verkle.DeserializeProof(vProof, stateDiff)
+	if err != nil {
+		return nil, err
+	}
+	preRoot, err := verkle.PreStateTreeFromProof(proof, rootC)
+	if err != nil {
+		return nil, err
+	}
+	err = verkle.VerifyVerkleProofWithPreState(proof, preRoot)
+	if err != nil {
+		return nil, err
+	}
+	return preRoot, nil
+}
+
+// Execute runs the block of the witness on its proven pre-state and returns the post-state commitment.
+func Execute(w *Witness) (string, error) {
+	header, err := execute(w)
+	if err != nil {
+		return "", err
+	}
+	postBytes := header.Root.Commit().Bytes()
+	return base64.StdEncoding.EncodeToString(postBytes[:]), nil
+}
+
+func execute(w *Witness) (*LightHeader, error) {
+	rootC, err := parseCommitment(w.PreCommitment)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pre-state commitment at height %d: %v", w.Height, err)
+	}
+	preRoot, err := PreState(rootC, w.Proof, w.StateDiff)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pre-state proof at height %d: %v", w.Height, err)
+	}
+	// The call of Commit is necessary to refresh the root commit.
+	preRoot.Commit()
+
+	header := &LightHeader{
+		Root:   preRoot,
+		Height: w.Height - 1,
+	}
+	protocol.Exec(header, w.OrdTransfers, w.Height)
+	return header, nil
+}
+
+// PostState runs the block of the witness like Execute and returns the stateless post-state,
+// which only holds the keys accessed by the block.
+func PostState(w *Witness) (*LightHeader, error) {
+	return execute(w)
+}
+
+// KeyChange is a key whose value is changed by a block.
+type KeyChange struct {
+	Key            string `json:"key"`
+	OldValue       string `json:"oldValue"`
+	NewValue       string `json:"newValue"`
+	OldValueExists bool   `json:"oldValueExists"`
+}
+
+// Replay runs the block of the witness like Execute, and also returns the keys whose values the block changed,
+// in the order of the reads of the witness. A key first written with zeros isn't reported as changed.
+func Replay(w *Witness) (string, []KeyChange, error) {
+	header, err := execute(w)
+	if err != nil {
+		return "", nil, err
+	}
+	changes := make([]KeyChange, 0)
+	for _, read := range w.Reads {
+		key, err := hex.DecodeString(read.Key)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid key %s of the witness at height %d", read.Key, w.Height)
+		}
+		value, err := header.get(key, nil)
+		if err != nil {
+			return "", nil, err
+		}
+		// The keys absent from the stateless view read as zeros, as the witness records them.
+		if value == nil {
+			value = make([]byte, verkle.LeafValueSize)
+		}
+		newValue := hex.EncodeToString(value)
+		if newValue == read.Value {
+			continue
+		}
+		changes = append(changes, KeyChange{
+			Key:            read.Key,
+			OldValue:       read.Value,
+			NewValue:       newValue,
+			OldValueExists: read.Exists,
+		})
+	}
+	postBytes := header.Root.Commit().Bytes()
+	return base64.StdEncoding.EncodeToString(postBytes[:]), changes, nil
+}
+
+// Verify re-executes the witness and checks the recomputed post-state root against the recorded one.
+func Verify(w *Witness) error {
+	post, err := Execute(w)
+	if err != nil {
+		return err
+	}
+	if post != w.PostCommitment {
+		return fmt.Errorf("mismatched post-state commitment at height %d: %s and %s", w.Height, post, w.PostCommitment)
+	}
+	return nil
+}
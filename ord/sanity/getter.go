@@ -0,0 +1,115 @@
+package sanity
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/RiemaLabs/modular-indexer-committee/archive"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/getter"
+)
+
+// ErrQuarantined is returned for a block waiting for the review of the operator.
+var ErrQuarantined = errors.New("the block is quarantined")
+
+// Record is stored as <QuarantineDir>/<height>.json. The operator releases the block by setting Approved to true.
+type Record struct {
+	Height        uint      `json:"height"`
+	Reasons       []string  `json:"reasons"`
+	Transfers     int       `json:"transfers"`
+	QuarantinedAt time.Time `json:"quarantinedAt"`
+	Approved      bool      `json:"approved"`
+}
+
+// The archive tier of the old quarantine records, nil if they're kept on the disk.
+var Archive *archive.Archiver
+
+func recordPath(dir string, blockHeight uint) string {
+	return filepath.Join(dir, fmt.Sprintf("%d.json", blockHeight))
+}
+
+// LoadRecord returns the quarantine record of the block, nil if the block has never been quarantined.
+func LoadRecord(dir string, blockHeight uint) (*Record, error) {
+	bytes, err := os.ReadFile(recordPath(dir, blockHeight))
+	if errors.Is(err, os.ErrNotExist) && Archive != nil {
+		bytes, err = Archive.Fetch(context.Background(), archive.ClassQuarantine, filepath.Base(recordPath(dir, blockHeight)))
+		if errors.Is(err, archive.ErrNotFound) {
+			return nil, nil
+		}
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var r Record
+	if err := json.Unmarshal(bytes, &r); err != nil {
+		return nil, fmt.Errorf("invalid quarantine record of the block %d: %v", blockHeight, err)
+	}
+	return &r, nil
+}
+
+func storeRecord(dir string, r *Record) error {
+	bytes, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(recordPath(dir, r.Height), bytes, 0644)
+}
+
+// Getter validates the ord transfers of the wrapped getter before returning them.
+type Getter struct {
+	getter.OrdGetter
+	validator *Validator
+	dir       string
+	sync.Mutex
+}
+
+func NewGetter(ordGetter getter.OrdGetter, cfg Config) *Getter {
+	return &Getter{
+		OrdGetter: ordGetter,
+		validator: NewValidator(cfg),
+		dir:       cfg.QuarantineDir,
+	}
+}
+
+func (g *Getter) GetOrdTransfers(blockHeight uint) ([]getter.OrdTransfer, error) {
+	ots, err := g.OrdGetter.GetOrdTransfers(blockHeight)
+	if err != nil {
+		return nil, err
+	}
+	g.Lock()
+	defer g.Unlock()
+	g.validator.Forget(blockHeight)
+	reasons := g.validator.Check(ots, blockHeight)
+	if len(reasons) != 0 {
+		record, err := LoadRecord(g.dir, blockHeight)
+		if err != nil {
+			return nil, err
+		}
+		if record == nil || !record.Approved {
+			record = &Record{
+				Height:        blockHeight,
+				Reasons:       reasons,
+				Transfers:     len(ots),
+				QuarantinedAt: time.Now().UTC(),
+			}
+			if err := storeRecord(g.dir, record); err != nil {
+				return nil, fmt.Errorf("failed to quarantine the block %d: %v", blockHeight, err)
+			}
+			return nil, fmt.Errorf("%w: block %d: %s", ErrQuarantined, blockHeight, strings.Join(reasons, "; "))
+		}
+	}
+	g.validator.Accept(ots, blockHeight)
+	return ots, nil
+}
@@ -0,0 +1,149 @@
+// Package sanity validates the ord transfers returned by a getter before their execution.
+// A block breaking an invariant or showing a statistical anomaly is quarantined for the review of the operator,
+// since executing a corrupted block silently diverges the state root from the other committee members.
+package sanity
+
+import (
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord"
+)
+
+type Config struct {
+	Enabled bool `json:"enabled"`
+	// A block is anomalous if its number of transfers is lower than the ratio of the average of the previous window.
+	// Zero disables the detection of the collapses.
+	CollapseRatio float64 `json:"collapseRatio"`
+	// The number of the previous blocks averaged.
+	Window int `json:"window"`
+	// The detection of the collapses only starts once the average reaches the baseline.
+	MinBaseline float64 `json:"minBaseline"`
+	// The directory to store the quarantined blocks.
+	QuarantineDir string `json:"quarantineDir"`
+}
+
+func (cfg Config) Validate() error {
+	if cfg.CollapseRatio < 0 || cfg.CollapseRatio >= 1 {
+		return fmt.Errorf("the collapse ratio %v must be in [0, 1)", cfg.CollapseRatio)
+	}
+	if cfg.CollapseRatio > 0 && cfg.Window <= 0 {
+		return fmt.Errorf("the window of the collapse detection must be positive")
+	}
+	if cfg.QuarantineDir == "" {
+		return fmt.Errorf("the quarantine directory is required")
+	}
+	return nil
+}
+
+// The type and the subtype of a MIME type, optionally followed by parameters.
+var mimeTypePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9!#$&^_.+-]*/[A-Za-z0-9][A-Za-z0-9!#$&^_.+-]*$`)
+
+// plausibleContentType checks the content type, which is hex encoded by OPI.
+func plausibleContentType(contentType string) bool {
+	if decoded, err := hex.DecodeString(contentType); err == nil {
+		contentType = string(decoded)
+	}
+	return mimeTypePattern.MatchString(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+}
+
+type blockCount struct {
+	height uint
+	count  int
+}
+
+// Validator checks the invariants of every block and the anomalies against the previously accepted blocks.
+type Validator struct {
+	cfg Config
+
+	// The last accepted block and the ID of its last transfer.
+	lastHeight uint
+	lastID     uint
+	hasLast    bool
+
+	// The transfer counts of the last accepted blocks.
+	counts []blockCount
+}
+
+func NewValidator(cfg Config) *Validator {
+	return &Validator{cfg: cfg}
+}
+
+// Check returns the reasons to quarantine the block, empty if the block looks sane.
+func (v *Validator) Check(ots []ord.OrdTransfer, blockHeight uint) []string {
+	reasons := make([]string, 0)
+	lastID, hasLast := v.lastID, v.hasLast && v.lastHeight+1 == blockHeight
+	seen := make(map[string]bool)
+	for _, ot := range ots {
+		if ot.BlockHeight != blockHeight {
+			reasons = append(reasons, fmt.Sprintf("transfer %d: block height %d mismatches", ot.ID, ot.BlockHeight))
+		}
+		if hasLast && ot.ID <= lastID {
+			// Duplicate IDs are caught as well.
+			reasons = append(reasons, fmt.Sprintf("transfer %d: ID is not increasing after %d", ot.ID, lastID))
+		}
+		lastID, hasLast = ot.ID, true
+		if !ord.IsInscriptionID(ot.InscriptionID) {
+			reasons = append(reasons, fmt.Sprintf("transfer %d: invalid inscription ID %q", ot.ID, ot.InscriptionID))
+		}
+		if _, err := ord.ParseSatPoint(ot.NewSatpoint); err != nil {
+			reasons = append(reasons, fmt.Sprintf("transfer %d: invalid new satpoint %q", ot.ID, ot.NewSatpoint))
+		}
+		if ot.OldSatpoint != "" {
+			if _, err := ord.ParseSatPoint(ot.OldSatpoint); err != nil {
+				reasons = append(reasons, fmt.Sprintf("transfer %d: invalid old satpoint %q", ot.ID, ot.OldSatpoint))
+			}
+		}
+		if !plausibleContentType(ot.ContentType) {
+			reasons = append(reasons, fmt.Sprintf("transfer %d: implausible content type %q", ot.ID, ot.ContentType))
+		}
+		move := ot.InscriptionID + " " + ot.OldSatpoint + " " + ot.NewSatpoint
+		if seen[move] {
+			reasons = append(reasons, fmt.Sprintf("transfer %d: duplicate move of %s", ot.ID, ot.InscriptionID))
+		}
+		seen[move] = true
+	}
+
+	if v.cfg.CollapseRatio > 0 && len(v.counts) > 0 {
+		sum := 0
+		for _, c := range v.counts {
+			sum += c.count
+		}
+		average := float64(sum) / float64(len(v.counts))
+		if average >= v.cfg.MinBaseline && float64(len(ots)) < average*v.cfg.CollapseRatio {
+			reasons = append(reasons, fmt.Sprintf("the transfer count %d collapses from the average %.1f of the previous %d blocks", len(ots), average, len(v.counts)))
+		}
+	}
+	return reasons
+}
+
+// Forget drops the accepted blocks from the height on, e.g. before they are fetched again after a reorg.
+func (v *Validator) Forget(blockHeight uint) {
+	kept := v.counts[:0]
+	for _, c := range v.counts {
+		if c.height < blockHeight {
+			kept = append(kept, c)
+		}
+	}
+	v.counts = kept
+	if v.hasLast && v.lastHeight >= blockHeight {
+		v.hasLast = false
+	}
+}
+
+// Accept records the block as the baseline of the following blocks.
+func (v *Validator) Accept(ots []ord.OrdTransfer, blockHeight uint) {
+	if len(ots) != 0 {
+		v.lastID = ots[len(ots)-1].ID
+		v.lastHeight, v.hasLast = blockHeight, true
+	} else if v.hasLast && v.lastHeight+1 == blockHeight {
+		// An empty block keeps the last ID as the baseline of the next block.
+		v.lastHeight = blockHeight
+	}
+	v.counts = append(v.counts, blockCount{height: blockHeight, count: len(ots)})
+	if v.cfg.Window > 0 && len(v.counts) > v.cfg.Window {
+		v.counts = v.counts[len(v.counts)-v.cfg.Window:]
+	}
+}
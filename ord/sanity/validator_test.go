@@ -0,0 +1,46 @@
+package sanity
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord"
+)
+
+func block(height uint, firstID uint, n int) []ord.OrdTransfer {
+	ots := make([]ord.OrdTransfer, n)
+	for i := range ots {
+		ots[i] = ord.OrdTransfer{
+			ID:            firstID + uint(i),
+			BlockHeight:   height,
+			InscriptionID: fmt.Sprintf("%064di%d", height, i),
+			NewSatpoint:   fmt.Sprintf("%064d:0:0", i),
+			ContentType:   "746578742f706c61696e3b636861727365743d7574662d38",
+		}
+	}
+	return ots
+}
+
+func TestValidator_Collapse(t *testing.T) {
+	v := NewValidator(Config{CollapseRatio: 0.1, Window: 3, MinBaseline: 50})
+	id := uint(1)
+	for h := uint(100); h < 105; h++ {
+		ots := block(h, id, 100)
+		if reasons := v.Check(ots, h); len(reasons) != 0 {
+			t.Fatalf("Unexpected reasons %v", reasons)
+		}
+		v.Accept(ots, h)
+		id += 100
+	}
+	if reasons := v.Check(block(105, id, 5), 105); len(reasons) != 1 {
+		t.Fatalf("Expected the collapse to be detected, got %v", reasons)
+	}
+	if reasons := v.Check(block(105, id-1, 20), 105); len(reasons) != 1 {
+		t.Fatalf("Expected the repeated ID to be detected, got %v", reasons)
+	}
+	// After a reorg, the block is compared against the blocks before it only.
+	v.Forget(104)
+	if reasons := v.Check(block(104, id-100, 20), 104); len(reasons) != 0 {
+		t.Fatalf("Unexpected reasons after the reorg %v", reasons)
+	}
+}
@@ -0,0 +1,29 @@
+package satpoint
+
+import (
+	"github.com/RiemaLabs/modular-indexer-committee/ord/getter"
+)
+
+// Getter validates the ord transfers of the wrapped getter with the tracker before returning them.
+type Getter struct {
+	getter.OrdGetter
+	tracker *Tracker
+}
+
+func NewGetter(ordGetter getter.OrdGetter, tracker *Tracker) *Getter {
+	return &Getter{
+		OrdGetter: ordGetter,
+		tracker:   tracker,
+	}
+}
+
+func (g *Getter) GetOrdTransfers(blockHeight uint) ([]getter.OrdTransfer, error) {
+	ots, err := g.OrdGetter.GetOrdTransfers(blockHeight)
+	if err != nil {
+		return nil, err
+	}
+	if err := g.tracker.Validate(ots, blockHeight); err != nil {
+		return nil, err
+	}
+	return ots, nil
+}
@@ -0,0 +1,153 @@
+// Package satpoint independently tracks the satpoints of the pending transfer inscriptions from the raw transactions,
+// to catch the satpoint-tracking bugs of the getter before they corrupt the balances.
+package satpoint
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/btcsuite/btcd/wire"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord"
+)
+
+type pendingTransfer struct {
+	satpoint string
+	height   uint
+}
+
+// Tracker validates the moves of the transfer inscriptions, which trigger the transfers of balances.
+type Tracker struct {
+	sync.Mutex
+	source TxSource
+	// The latest satpoints of the transfer inscriptions that are not moved yet.
+	// A transfer inscription inscribed before the start of the tracker is untracked,
+	// then only its move is validated.
+	pending map[string]pendingTransfer
+}
+
+func NewTracker(source TxSource) *Tracker {
+	return &Tracker{
+		source:  source,
+		pending: make(map[string]pendingTransfer),
+	}
+}
+
+func isTransferInscription(content []byte) bool {
+	var js map[string]interface{}
+	if err := json.Unmarshal(content, &js); err != nil {
+		return false
+	}
+	return js["p"] == "brc-20" && js["op"] == "transfer"
+}
+
+// Validate checks the moves of the transfer inscriptions of the block and records the new pending ones.
+func (t *Tracker) Validate(ots []ord.OrdTransfer, blockHeight uint) error {
+	t.Lock()
+	defer t.Unlock()
+
+	// The block is fetched again after a reorg, forget the inscriptions of the orphaned blocks.
+	for id, p := range t.pending {
+		if p.height >= blockHeight {
+			delete(t.pending, id)
+		}
+	}
+
+	txs := make(map[string]*wire.MsgTx)
+	for _, ot := range ots {
+		if !isTransferInscription(ot.Content) {
+			continue
+		}
+		if ot.OldSatpoint == "" {
+			t.pending[ot.InscriptionID] = pendingTransfer{satpoint: ot.NewSatpoint, height: blockHeight}
+			continue
+		}
+		if p, tracked := t.pending[ot.InscriptionID]; tracked && p.satpoint != ot.OldSatpoint {
+			return fmt.Errorf("the transfer inscription %s at block %d moves from %s, but it is located at %s",
+				ot.InscriptionID, blockHeight, ot.OldSatpoint, p.satpoint)
+		}
+		if err := t.validateMove(&ot, txs); err != nil {
+			return fmt.Errorf("invalid move of the transfer inscription %s at block %d: %v", ot.InscriptionID, blockHeight, err)
+		}
+		delete(t.pending, ot.InscriptionID)
+	}
+	return nil
+}
+
+func (t *Tracker) transaction(txID string, txs map[string]*wire.MsgTx) (*wire.MsgTx, error) {
+	if tx, found := txs[txID]; found {
+		return tx, nil
+	}
+	raw, err := t.source.GetRawTransaction(txID)
+	if err != nil {
+		return nil, err
+	}
+	var tx wire.MsgTx
+	if err := tx.Deserialize(bytes.NewReader(raw)); err != nil {
+		return nil, fmt.Errorf("failed to decode the transaction %s: %v", txID, err)
+	}
+	if tx.TxHash().String() != txID {
+		return nil, fmt.Errorf("the source returns the transaction %s instead of %s", tx.TxHash().String(), txID)
+	}
+	txs[txID] = &tx
+	return &tx, nil
+}
+
+// validateMove follows the sat of the old satpoint through the spending transaction in the first-in-first-out order.
+func (t *Tracker) validateMove(ot *ord.OrdTransfer, txs map[string]*wire.MsgTx) error {
+	oldPoint, err := ord.ParseSatPoint(ot.OldSatpoint)
+	if err != nil {
+		return err
+	}
+	if ot.SentAsFee {
+		// The new satpoint locates in the coinbase, which can't be followed from the spending transaction.
+		return nil
+	}
+	newPoint, err := ord.ParseSatPoint(ot.NewSatpoint)
+	if err != nil {
+		return err
+	}
+	tx, err := t.transaction(string(newPoint.OutPoint().TxID()), txs)
+	if err != nil {
+		return err
+	}
+
+	var position uint64
+	spent := false
+	for _, in := range tx.TxIn {
+		prevOut := in.PreviousOutPoint
+		if prevOut.Hash.String() == string(oldPoint.OutPoint().TxID()) && uint64(prevOut.Index) == oldPoint.OutPoint().OutputIndex() {
+			position += oldPoint.Offset()
+			spent = true
+			break
+		}
+		prev, err := t.transaction(prevOut.Hash.String(), txs)
+		if err != nil {
+			return err
+		}
+		if int(prevOut.Index) >= len(prev.TxOut) {
+			return fmt.Errorf("the transaction %s spends the missing output %s", tx.TxHash().String(), prevOut.String())
+		}
+		position += uint64(prev.TxOut[prevOut.Index].Value)
+	}
+	if !spent {
+		return fmt.Errorf("the transaction %s doesn't spend the old satpoint %s", tx.TxHash().String(), ot.OldSatpoint)
+	}
+
+	for index, out := range tx.TxOut {
+		if position < uint64(out.Value) {
+			if uint64(index) != newPoint.OutPoint().OutputIndex() || position != newPoint.Offset() {
+				return fmt.Errorf("the sat moves to %s:%d:%d instead of %s", tx.TxHash().String(), index, position, ot.NewSatpoint)
+			}
+			if pkscript := hex.EncodeToString(out.PkScript); pkscript != string(ot.NewPkscript) {
+				return fmt.Errorf("the sat moves to the pkscript %s instead of %s", pkscript, ot.NewPkscript)
+			}
+			return nil
+		}
+		position -= uint64(out.Value)
+	}
+	return fmt.Errorf("the sat is spent as fee, but the transfer isn't marked as sent as fee")
+}
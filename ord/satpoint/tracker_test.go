@@ -0,0 +1,103 @@
+package satpoint
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord"
+)
+
+type memorySource map[string][]byte
+
+func (s memorySource) GetRawTransaction(txID string) ([]byte, error) {
+	raw, found := s[txID]
+	if !found {
+		return nil, fmt.Errorf("the transaction %s is not found", txID)
+	}
+	return raw, nil
+}
+
+func (s memorySource) add(t *testing.T, tx *wire.MsgTx) string {
+	var buf bytes.Buffer
+	if err := tx.Serialize(&buf); err != nil {
+		t.Fatal(err)
+	}
+	txID := tx.TxHash().String()
+	s[txID] = buf.Bytes()
+	return txID
+}
+
+func newTx(prevOuts []wire.OutPoint, values []int64, pkscripts [][]byte) *wire.MsgTx {
+	tx := wire.NewMsgTx(2)
+	for i := range prevOuts {
+		tx.AddTxIn(wire.NewTxIn(&prevOuts[i], nil, nil))
+	}
+	for i := range values {
+		tx.AddTxOut(wire.NewTxOut(values[i], pkscripts[i]))
+	}
+	return tx
+}
+
+func Test_Tracker(t *testing.T) {
+	source := make(memorySource)
+	pk1, pk2 := []byte{0x51, 0x20, 0x01}, []byte{0x51, 0x20, 0x02}
+	a := newTx([]wire.OutPoint{{Hash: chainhash.Hash{1}}}, []int64{1000, 5000}, [][]byte{pk1, pk1})
+	aID := source.add(t, a)
+	b := newTx([]wire.OutPoint{{Hash: chainhash.Hash{2}}}, []int64{3000}, [][]byte{pk2})
+	source.add(t, b)
+	// The sats of b:0 come first, then the sats of a:1. The fee is 1000.
+	spend := newTx([]wire.OutPoint{{Hash: b.TxHash(), Index: 0}, {Hash: a.TxHash(), Index: 1}}, []int64{2000, 5000}, [][]byte{pk1, pk2})
+	spendID := source.add(t, spend)
+
+	content := []byte(`{"p":"brc-20","op":"transfer","tick":"ordi","amt":"1"}`)
+	inscribe := ord.OrdTransfer{InscriptionID: "x", NewSatpoint: aID + ":1:100", Content: content}
+	// 3000 + 100 sats precede the inscribed sat, which lands at the offset 1100 of the second output.
+	move := ord.OrdTransfer{InscriptionID: "x", OldSatpoint: aID + ":1:100", NewSatpoint: spendID + ":1:1100", NewPkscript: "512002", Content: content}
+
+	tracker := NewTracker(source)
+	if err := tracker.Validate([]ord.OrdTransfer{inscribe}, 100); err != nil {
+		t.Fatal(err)
+	}
+	if err := tracker.Validate([]ord.OrdTransfer{move}, 101); err != nil {
+		t.Fatal(err)
+	}
+
+	invalid := map[string]func(ot *ord.OrdTransfer){
+		"wrong offset":         func(ot *ord.OrdTransfer) { ot.NewSatpoint = spendID + ":1:1000" },
+		"wrong output":         func(ot *ord.OrdTransfer) { ot.NewSatpoint = spendID + ":0:1100" },
+		"wrong pkscript":       func(ot *ord.OrdTransfer) { ot.NewPkscript = "512001" },
+		"unspent old satpoint": func(ot *ord.OrdTransfer) { ot.OldSatpoint = aID + ":0:100" },
+		"unmarked fee": func(ot *ord.OrdTransfer) {
+			ot.OldSatpoint = aID + ":1:4500"
+			ot.NewSatpoint = spendID + ":1:4500"
+		},
+	}
+	for name, tamper := range invalid {
+		tracker := NewTracker(source)
+		ot := move
+		tamper(&ot)
+		if err := tracker.Validate([]ord.OrdTransfer{ot}, 101); err == nil {
+			t.Fatalf("Expected the move with %s to be rejected", name)
+		}
+	}
+
+	// The getter claims a move from another satpoint than the tracked one.
+	tracker = NewTracker(source)
+	if err := tracker.Validate([]ord.OrdTransfer{inscribe}, 100); err != nil {
+		t.Fatal(err)
+	}
+	ot := move
+	ot.OldSatpoint = aID + ":1:200"
+	ot.NewSatpoint = spendID + ":1:1200"
+	if err := tracker.Validate([]ord.OrdTransfer{ot}, 101); err == nil {
+		t.Fatal("Expected the move from an untracked satpoint to be rejected")
+	}
+	// After a reorg at the inscribed block, the inscription is untracked and only the move is validated.
+	if err := tracker.Validate([]ord.OrdTransfer{ot}, 100); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,162 @@
+// Package smt implements a compact sparse Merkle tree over 32-byte keys and values using SHA-256,
+// committing the same key-values as the verkle tree for the verifiers not supporting verkle proofs yet.
+//
+// The tree is a binary trie indexed by the bits of the key, from the most significant one:
+//   - the hash of an empty subtree is 32 zero bytes;
+//   - the hash of a subtree holding a single key-value is sha256(0x00 || key || value), whatever its depth;
+//   - the hash of any other subtree is sha256(0x01 || left || right).
+//
+// The root only depends on the set of the key-values, not on the order of the insertions and deletions.
+package smt
+
+import (
+	"crypto/sha256"
+)
+
+const (
+	KeySize   = 32
+	ValueSize = 32
+)
+
+var (
+	leafPrefix     = []byte{0}
+	internalPrefix = []byte{1}
+)
+
+type node struct {
+	left, right *node
+
+	leaf  bool
+	key   [KeySize]byte
+	value [ValueSize]byte
+
+	hash  [32]byte
+	dirty bool
+}
+
+type Tree struct {
+	root *node
+	size int
+}
+
+func New() *Tree {
+	return &Tree{}
+}
+
+func bit(key [KeySize]byte, depth int) byte {
+	return (key[depth/8] >> (7 - depth%8)) & 1
+}
+
+func newLeaf(key [KeySize]byte, value [ValueSize]byte) *node {
+	return &node{leaf: true, key: key, value: value, dirty: true}
+}
+
+func (n *node) child(b byte) **node {
+	if b == 0 {
+		return &n.left
+	}
+	return &n.right
+}
+
+// Len returns the number of the key-values in the tree.
+func (t *Tree) Len() int {
+	return t.size
+}
+
+// Insert inserts or updates the value of the key.
+func (t *Tree) Insert(key [KeySize]byte, value [ValueSize]byte) {
+	t.root = t.insert(t.root, key, value, 0)
+}
+
+func (t *Tree) insert(n *node, key [KeySize]byte, value [ValueSize]byte, depth int) *node {
+	if n == nil {
+		t.size++
+		return newLeaf(key, value)
+	}
+	if n.leaf {
+		if n.key == key {
+			n.value = value
+			n.dirty = true
+			return n
+		}
+		// Split the leaf until the bits of the keys differ.
+		t.size++
+		top := &node{dirty: true}
+		cur := top
+		for d := depth; ; d++ {
+			b, other := bit(key, d), bit(n.key, d)
+			if b != other {
+				*cur.child(b) = newLeaf(key, value)
+				*cur.child(other) = n
+				return top
+			}
+			next := &node{dirty: true}
+			*cur.child(b) = next
+			cur = next
+		}
+	}
+	b := bit(key, depth)
+	*n.child(b) = t.insert(*n.child(b), key, value, depth+1)
+	n.dirty = true
+	return n
+}
+
+// Delete removes the key, which is a no-op if the key is absent.
+func (t *Tree) Delete(key [KeySize]byte) {
+	t.root = t.delete(t.root, key, 0)
+}
+
+func (t *Tree) delete(n *node, key [KeySize]byte, depth int) *node {
+	if n == nil {
+		return nil
+	}
+	if n.leaf {
+		if n.key == key {
+			t.size--
+			return nil
+		}
+		return n
+	}
+	b := bit(key, depth)
+	*n.child(b) = t.delete(*n.child(b), key, depth+1)
+	n.dirty = true
+	// Hoist the single remaining leaf, so that the shape of the tree only depends on its key-values.
+	if n.left == nil && n.right == nil {
+		return nil
+	}
+	if n.left == nil && n.right.leaf {
+		return n.right
+	}
+	if n.right == nil && n.left.leaf {
+		return n.left
+	}
+	return n
+}
+
+func (n *node) commit() [32]byte {
+	if n == nil {
+		return [32]byte{}
+	}
+	if !n.dirty {
+		return n.hash
+	}
+	hasher := sha256.New()
+	if n.leaf {
+		hasher.Write(leafPrefix)
+		hasher.Write(n.key[:])
+		hasher.Write(n.value[:])
+	} else {
+		left, right := n.left.commit(), n.right.commit()
+		hasher.Write(internalPrefix)
+		hasher.Write(left[:])
+		hasher.Write(right[:])
+	}
+	copy(n.hash[:], hasher.Sum(nil))
+	n.dirty = false
+	return n.hash
+}
+
+// Commit returns the root of the tree, only rehashing the subtrees changed since the last call.
+func (t *Tree) Commit() [32]byte {
+	return t.root.commit()
+}
@@ -0,0 +1,92 @@
+package smt
+
+import (
+	"crypto/sha256"
+	"math/rand"
+	"testing"
+)
+
+func randomKV(r *rand.Rand, n int) ([][KeySize]byte, [][ValueSize]byte) {
+	keys := make([][KeySize]byte, n)
+	values := make([][ValueSize]byte, n)
+	for i := range keys {
+		r.Read(keys[i][:])
+		r.Read(values[i][:])
+	}
+	// Keys sharing long prefixes.
+	keys[1] = keys[0]
+	keys[1][KeySize-1] ^= 1
+	return keys, values
+}
+
+func TestTree_SingleLeaf(t *testing.T) {
+	var key [KeySize]byte
+	var value [ValueSize]byte
+	key[0], value[0] = 0xff, 1
+	tree := New()
+	if tree.Commit() != [32]byte{} {
+		t.Fatal("Expected the root of the empty tree to be zero")
+	}
+	tree.Insert(key, value)
+	expected := sha256.Sum256(append(append([]byte{0}, key[:]...), value[:]...))
+	if tree.Commit() != expected {
+		t.Fatal("Expected the root of a single key-value to be its leaf hash")
+	}
+}
+
+func TestTree_OrderIndependent(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	keys, values := randomKV(r, 200)
+
+	forward := New()
+	for i := range keys {
+		forward.Insert(keys[i], values[i])
+	}
+	backward := New()
+	for i := len(keys) - 1; i >= 0; i-- {
+		backward.Insert(keys[i], values[i])
+	}
+	if forward.Commit() != backward.Commit() || forward.Len() != len(keys) {
+		t.Fatal("Expected the root not to depend on the insertion order")
+	}
+
+	// Committing in the middle doesn't change the result.
+	partial := New()
+	for i := range keys {
+		partial.Insert(keys[i], values[i])
+		if i%17 == 0 {
+			partial.Commit()
+		}
+	}
+	if partial.Commit() != forward.Commit() {
+		t.Fatal("Expected the incremental commits to match")
+	}
+}
+
+func TestTree_Delete(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	keys, values := randomKV(r, 100)
+
+	half := New()
+	for i := 0; i < 50; i++ {
+		half.Insert(keys[i], values[i])
+	}
+	tree := New()
+	for i := range keys {
+		tree.Insert(keys[i], values[i])
+	}
+	tree.Commit()
+	for i := 50; i < 100; i++ {
+		tree.Delete(keys[i])
+	}
+	tree.Delete(keys[99])
+	if tree.Commit() != half.Commit() || tree.Len() != 50 {
+		t.Fatal("Expected the deletions to restore the previous root")
+	}
+	for i := 0; i < 50; i++ {
+		tree.Delete(keys[i])
+	}
+	if tree.Commit() != [32]byte{} || tree.Len() != 0 {
+		t.Fatal("Expected the tree to be empty")
+	}
+}
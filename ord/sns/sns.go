@@ -0,0 +1,285 @@
+// Package sns indexes the sats names: the first inscription claiming a name owns it, first-is-first, and the name
+// follows the inscription as it's transferred. The names are kept in the namespace of the protocol in the state,
+// so they are committed by the checkpoints along with BRC-20.
+package sns
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	uint256 "github.com/holiman/uint256"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/protocol"
+)
+
+// The name of the protocol, which namespaces its keys and is claimed by the "p" field of the JSON registrations.
+const Name = "sns"
+
+// The max lengths of a name and of an owner in bytes, so that they fit the slots of their keys.
+const (
+	MaxNameLength  = 1024
+	MaxOwnerLength = 4096
+)
+
+type Config struct {
+	Enabled bool `json:"enabled"`
+	// The height from which the names are claimed, the inscriptions before it are ignored.
+	ActivationHeight uint `json:"activationHeight"`
+	// The namespaces of the names, i.e. what follows their dot, "sats" by default.
+	Namespaces []string `json:"namespaces"`
+}
+
+// The rules of the execution, set by Configure. Re-executions of the committee must use the same rules.
+var (
+	ActivationHeight uint
+	Namespaces       = []string{"sats"}
+)
+
+// Configure sets the rules of the execution from the config.
+func Configure(cfg Config) {
+	ActivationHeight = cfg.ActivationHeight
+	if len(cfg.Namespaces) != 0 {
+		Namespaces = make([]string, len(cfg.Namespaces))
+		for i, namespace := range cfg.Namespaces {
+			Namespaces[i] = strings.ToLower(namespace)
+		}
+	}
+}
+
+// handler claims the plain texts which are names, so that the other protocols of the plain texts receive the rest.
+type handler struct{}
+
+func (handler) Exec(state protocol.KVStorage, ots []ord.OrdTransfer, blockHeight uint) {
+	Exec(state, ots, blockHeight)
+}
+
+func (handler) ActivationHeight() uint {
+	return ActivationHeight
+}
+
+func (handler) Claims(ot ord.OrdTransfer) bool {
+	_, ok := ParseName(ot.Content)
+	return ok
+}
+
+// Register registers the protocol next to BRC-20. It receives the JSON registrations naming it and the plain texts
+// which are names, which BRC-20 ignores.
+func Register() {
+	protocol.Register(Name, handler{}, "text/plain")
+}
+
+// Name State
+// Key: Keccak256(name + "GetNameHash")[:StemSize] + LocationID
+var NameExists byte = 0x00
+var NameInscriptionID byte = 0x01 // inscription should take 2 slots, next should start with 03
+var NameIndex byte = 0x03         // The index of the name among the names of its owner.
+var NameOwner byte = 0x04         // bytes, taking the rest of the slots
+
+// Owner State
+// Key: Keccak256(owner + "GetOwnerHash")[:StemSize] + LocationID
+var OwnerNameCount byte = 0x00
+
+// Owner Name State, the name at an index of the names of the owner
+// Key: Keccak256(owner + ":" + index + "GetOwnerNameHash")[:StemSize] + LocationID
+var OwnerName byte = 0x00 // bytes, taking the rest of the slots
+
+func hashStem(keySpace string, locationID byte, parts ...string) []byte {
+	return protocol.StemKey(Name, keySpace, locationID, parts...)
+}
+
+func GetNameHash(name string, locationID byte) []byte {
+	return hashStem("GetNameHash", locationID, name)
+}
+
+func GetOwnerHash(owner string, locationID byte) []byte {
+	return hashStem("GetOwnerHash", locationID, owner)
+}
+
+func GetOwnerNameHash(owner string, index uint64, locationID byte) []byte {
+	return hashStem("GetOwnerNameHash", locationID, owner, ":", strconv.FormatUint(index, 10))
+}
+
+// ParseName returns the name claimed by the content, false if none. The content is either the name as a plain text,
+// of which only the first word counts, or {"p":"sns","op":"reg","name":"<name>"}. The names are lowercased and made
+// of a label and one of the namespaces, separated by a dot.
+func ParseName(content []byte) (string, bool) {
+	text := strings.TrimSpace(string(content))
+	if strings.HasPrefix(text, "{") {
+		var js struct {
+			P    string `json:"p"`
+			Op   string `json:"op"`
+			Name string `json:"name"`
+		}
+		if err := json.Unmarshal(content, &js); err != nil || strings.ToLower(js.P) != Name || js.Op != "reg" {
+			return "", false
+		}
+		text = js.Name
+	}
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return "", false
+	}
+	name := strings.ToLower(words[0])
+	if len(name) > MaxNameLength || !utf8.ValidString(name) {
+		return "", false
+	}
+	label, namespace, found := strings.Cut(name, ".")
+	if !found || label == "" || !slices.Contains(Namespaces, namespace) {
+		return "", false
+	}
+	return name, true
+}
+
+// must panics on an error of the state, since the values are checked before they are written.
+func must(err error) {
+	if err != nil {
+		panic(fmt.Errorf("failed to update the names: %w", err))
+	}
+}
+
+func getUInt256(state protocol.KVStorage, key []byte) *uint256.Int {
+	value, err := state.GetUInt256(key)
+	must(err)
+	return value
+}
+
+func getBytes(state protocol.KVStorage, key []byte) string {
+	value, err := state.GetBytes(key)
+	must(err)
+	return string(value)
+}
+
+// Exec claims the names of the inscriptions of the block, and moves the names of the transferred ones to their new owners.
+func Exec(state protocol.KVStorage, ots []ord.OrdTransfer, blockHeight uint) {
+	if blockHeight < ActivationHeight {
+		return
+	}
+	for _, ot := range ots {
+		// The inscriptions sent as fee don't claim or move the names.
+		if ot.SentAsFee {
+			continue
+		}
+		name, ok := ParseName(ot.Content)
+		if !ok {
+			continue
+		}
+		owner := protocol.Owner(ot.NewWallet, ot.NewPkscript)
+		if owner == "" || len(owner) > MaxOwnerLength {
+			continue
+		}
+		exists := !getUInt256(state, GetNameHash(name, NameExists)).IsZero()
+		if ot.OldSatpoint == "" {
+			if exists {
+				continue // claimed already
+			}
+			if _, _, err := protocol.SplitInscriptionID(ot.InscriptionID); err != nil {
+				continue
+			}
+			must(state.InsertUInt256(GetNameHash(name, NameExists), uint256.NewInt(1)))
+			must(state.InsertInscriptionID(GetNameHash(name, NameInscriptionID), ot.InscriptionID))
+			addName(state, owner, name)
+			continue
+		}
+		if !exists {
+			continue
+		}
+		inscriptionID, err := state.GetInscriptionID(GetNameHash(name, NameInscriptionID))
+		must(err)
+		if inscriptionID != ot.InscriptionID {
+			continue // another inscription of the claimed name
+		}
+		if previous := getBytes(state, GetNameHash(name, NameOwner)); previous != owner {
+			removeName(state, previous, name)
+			addName(state, owner, name)
+		}
+	}
+}
+
+// addName appends the name to the names of the owner, and makes it the owner of the name.
+func addName(state protocol.KVStorage, owner string, name string) {
+	count := getUInt256(state, GetOwnerHash(owner, OwnerNameCount)).Uint64()
+	must(state.InsertBytes(GetOwnerNameHash(owner, count, OwnerName), []byte(name)))
+	must(state.InsertUInt256(GetOwnerHash(owner, OwnerNameCount), uint256.NewInt(count+1)))
+	must(state.InsertUInt256(GetNameHash(name, NameIndex), uint256.NewInt(count)))
+	must(state.InsertBytes(GetNameHash(name, NameOwner), []byte(owner)))
+}
+
+// removeName removes the name from the names of the owner, moving the last name of the owner to its index.
+func removeName(state protocol.KVStorage, owner string, name string) {
+	count := getUInt256(state, GetOwnerHash(owner, OwnerNameCount)).Uint64()
+	index := getUInt256(state, GetNameHash(name, NameIndex)).Uint64()
+	if count == 0 || index >= count {
+		panic(fmt.Errorf("the name %s isn't among the %d names of its owner %s", name, count, owner))
+	}
+	last := count - 1
+	if index != last {
+		moved := getBytes(state, GetOwnerNameHash(owner, last, OwnerName))
+		must(state.InsertBytes(GetOwnerNameHash(owner, index, OwnerName), []byte(moved)))
+		must(state.InsertUInt256(GetNameHash(moved, NameIndex), uint256.NewInt(index)))
+	}
+	must(state.InsertBytes(GetOwnerNameHash(owner, last, OwnerName), nil))
+	must(state.InsertUInt256(GetOwnerHash(owner, OwnerNameCount), uint256.NewInt(last)))
+}
+
+// Record is a claimed name.
+type Record struct {
+	Name          string `json:"name"`
+	InscriptionID string `json:"inscriptionID"`
+	Owner         string `json:"owner"`
+}
+
+// Resolve returns the record of the name from the state of the protocol, false if it isn't claimed.
+func Resolve(state protocol.KVStorage, name string) (Record, bool, error) {
+	name = strings.ToLower(name)
+	exists, err := state.GetUInt256(GetNameHash(name, NameExists))
+	if err != nil || exists.IsZero() {
+		return Record{}, false, err
+	}
+	inscriptionID, err := state.GetInscriptionID(GetNameHash(name, NameInscriptionID))
+	if err != nil {
+		return Record{}, false, err
+	}
+	owner, err := state.GetBytes(GetNameHash(name, NameOwner))
+	if err != nil {
+		return Record{}, false, err
+	}
+	return Record{Name: name, InscriptionID: inscriptionID, Owner: string(owner)}, true, nil
+}
+
+// RecordKeys returns the keys of the record of the name in the state of the protocol, in their order.
+func RecordKeys(r Record) [][]byte {
+	keys := [][]byte{
+		GetNameHash(r.Name, NameExists),
+		GetNameHash(r.Name, NameInscriptionID),
+		GetNameHash(r.Name, NameInscriptionID+1),
+		GetNameHash(r.Name, NameIndex),
+	}
+	for i := 0; i <= (len(r.Owner)+protocol.SlotSize-1)/protocol.SlotSize; i++ {
+		keys = append(keys, GetNameHash(r.Name, NameOwner+byte(i)))
+	}
+	return keys
+}
+
+// Names returns the names of the owner from the offset, at most limit of them, along with their total number.
+// The order of the names changes as the names leave the owner.
+func Names(state protocol.KVStorage, owner string, offset int, limit int) ([]string, int, error) {
+	count, err := state.GetUInt256(GetOwnerHash(owner, OwnerNameCount))
+	if err != nil {
+		return nil, 0, err
+	}
+	total := int(count.Uint64())
+	names := make([]string, 0, max(min(limit, total-offset), 0))
+	for i := offset; i < total && len(names) < limit; i++ {
+		name, err := state.GetBytes(GetOwnerNameHash(owner, uint64(i), OwnerName))
+		if err != nil {
+			return nil, 0, err
+		}
+		names = append(names, string(name))
+	}
+	return names, total, nil
+}
@@ -0,0 +1,102 @@
+package sns
+
+import (
+	"strings"
+	"testing"
+
+	uint256 "github.com/holiman/uint256"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord"
+)
+
+type memoryState map[string][]byte
+
+func (m memoryState) InsertInscriptionID(key []byte, value string) error {
+	m[string(key)] = []byte(value)
+	return nil
+}
+func (m memoryState) GetInscriptionID(key []byte) (string, error) { return string(m[string(key)]), nil }
+func (m memoryState) InsertUInt256(key []byte, value *uint256.Int) error {
+	m[string(key)] = value.Bytes()
+	return nil
+}
+func (m memoryState) GetUInt256(key []byte) (*uint256.Int, error) {
+	return uint256.NewInt(0).SetBytes(m[string(key)]), nil
+}
+func (m memoryState) InsertBytes(key []byte, value []byte) error {
+	m[string(key)] = value
+	return nil
+}
+func (m memoryState) GetBytes(key []byte) ([]byte, error) { return m[string(key)], nil }
+func (m memoryState) GetHeight() uint                     { return 0 }
+func (m memoryState) Delete(key []byte) error {
+	delete(m, string(key))
+	return nil
+}
+func (m memoryState) Range(prefix []byte, fn func(key []byte, value []byte) bool) error {
+	return nil
+}
+
+func TestParseName(t *testing.T) {
+	for content, expected := range map[string]string{
+		"Satoshi.sats":   "satoshi.sats",
+		" hal.sats\nbio": "hal.sats",
+		"a.b.sats":       "",
+		".sats":          "",
+		"satoshi.unisat": "",
+		"satoshi":        "",
+		`{"p":"sns","op":"reg","name":"Nakamoto.sats"}`: "nakamoto.sats",
+		`{"p":"sns","op":"update","name":"x.sats"}`:     "",
+		`{"p":"brc-20","op":"reg","name":"x.sats"}`:     "",
+	} {
+		name, ok := ParseName([]byte(content))
+		if name != expected || ok != (expected != "") {
+			t.Fatalf("Expected %q from %q, got %q", expected, content, name)
+		}
+	}
+}
+
+func TestExec(t *testing.T) {
+	state := memoryState{}
+	inscribe := func(id string, wallet string, content string) ord.OrdTransfer {
+		return ord.OrdTransfer{InscriptionID: strings.Repeat(id, 64) + "i0", NewWallet: ord.Wallet(wallet), Content: []byte(content)}
+	}
+	transfer := func(id string, wallet string, content string) ord.OrdTransfer {
+		ot := inscribe(id, wallet, content)
+		ot.OldSatpoint = strings.Repeat(id, 64) + ":0:0"
+		return ot
+	}
+	Exec(state, []ord.OrdTransfer{
+		inscribe("a", "alice", "satoshi.sats"),
+		inscribe("b", "alice", "hal.sats"),
+		// The later claims of a name are ignored.
+		inscribe("c", "bob", "Satoshi.sats"),
+		inscribe("d", "bob", "finney.sats"),
+	}, 800000)
+	Exec(state, []ord.OrdTransfer{
+		// Only the first inscription of the name moves it.
+		transfer("c", "carol", "satoshi.sats"),
+		transfer("a", "bob", "satoshi.sats"),
+	}, 800001)
+
+	record, found, err := Resolve(state, "SATOSHI.sats")
+	if err != nil || !found || record.Owner != "bob" || record.InscriptionID != strings.Repeat("a", 64)+"i0" {
+		t.Fatalf("Unexpected record %+v, %v", record, err)
+	}
+	if _, found, _ := Resolve(state, "nobody.sats"); found {
+		t.Fatal("Expected the name to be unclaimed")
+	}
+	for owner, expected := range map[string][]string{
+		"alice": {"hal.sats"},
+		"bob":   {"finney.sats", "satoshi.sats"},
+		"carol": {},
+	} {
+		names, total, err := Names(state, owner, 0, 10)
+		if err != nil || total != len(expected) || strings.Join(names, ",") != strings.Join(expected, ",") {
+			t.Fatalf("Unexpected names of %s: %v, %d, %v", owner, names, total, err)
+		}
+	}
+	if names, total, _ := Names(state, "bob", 1, 10); total != 2 || len(names) != 1 || names[0] != "satoshi.sats" {
+		t.Fatalf("Unexpected page %v of %d", names, total)
+	}
+}
@@ -0,0 +1,236 @@
+package stateless
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/brc20"
+)
+
+// The number of the blocks of a day in the census.
+const CensusDayBlocks = 144
+
+// A tick not fully minted is abandoned if it hasn't been deployed or minted for about a month.
+const CensusAbandonedBlocks = 4320
+
+const censusSuffix = ".census"
+
+// TickCensus is the deploy and the mint progress of a tick, as executed.
+type TickCensus struct {
+	Tick string `json:"tick"`
+	// The height of the deploy, 0 if deployed before the census started.
+	DeployHeight uint `json:"deployHeight"`
+	// The height of the latest mint, 0 if never minted.
+	LastMintHeight uint `json:"lastMintHeight"`
+	SelfMint       bool `json:"selfMint"`
+	// The owner of the deploy inscription, empty if deployed before the census started or by the genesis.
+	DeployerPkscript string `json:"deployerPkscript,omitempty"`
+	Deployer         string `json:"deployer,omitempty"`
+	// Whether the remaining supply is 0.
+	Completed bool `json:"completed"`
+}
+
+func (t *TickCensus) abandoned(height uint) bool {
+	return !t.Completed && max(t.DeployHeight, t.LastMintHeight)+CensusAbandonedBlocks <= height
+}
+
+// tickDeployer is the owner of a deploy inscription, observed by the execution.
+type tickDeployer struct {
+	pkscript ord.Pkscript
+	wallet   ord.Wallet
+}
+
+// censusChange is the record of a tick before a block, which undoes the block if it's executed again after a reorg.
+type censusChange struct {
+	Height   uint        `json:"height"`
+	Tick     string      `json:"tick"`
+	Previous *TickCensus `json:"previous"`
+}
+
+type censusState struct {
+	// The first block observed by the census, whose earlier ticks are missing.
+	FromHeight uint                   `json:"fromHeight"`
+	Height     uint                   `json:"height"`
+	Ticks      map[string]*TickCensus `json:"ticks"`
+	Journal    []censusChange         `json:"journal"`
+}
+
+var census struct {
+	sync.Mutex
+	censusState
+}
+
+// recordCensus records the ticks deployed or minted by the block at the height of the header, which has been flushed.
+// A block executed again after a reorg replaces the old one.
+func recordCensus(h *Header, ticks map[string]bool, deployers map[string]tickDeployer) {
+	census.Lock()
+	defer census.Unlock()
+	if census.Ticks == nil {
+		census.Ticks = make(map[string]*TickCensus)
+		census.FromHeight = h.Height
+	}
+	rewindCensus(h.Height - 1)
+
+	names := make([]string, 0, len(ticks))
+	for tick := range ticks {
+		names = append(names, tick)
+	}
+	sort.Strings(names)
+	for _, tick := range names {
+		previous := census.Ticks[tick]
+		census.Journal = append(census.Journal, censusChange{Height: h.Height, Tick: tick, Previous: previous})
+		var record TickCensus
+		switch {
+		case ticks[tick]:
+			record = TickCensus{Tick: tick, DeployHeight: h.Height}
+			if deployer, found := deployers[tick]; found {
+				record.DeployerPkscript, record.Deployer = string(deployer.pkscript), string(deployer.wallet)
+			}
+		case previous == nil:
+			record = TickCensus{Tick: tick, LastMintHeight: h.Height}
+		default:
+			record = *previous
+			record.LastMintHeight = h.Height
+		}
+		record.SelfMint = !h.peekUInt256(brc20.GetTickHash(tick, brc20.IsSelfMint)).IsZero()
+		record.Completed = h.peekUInt256(brc20.GetTickHash(tick, brc20.RemainingSupply)).IsZero()
+		census.Ticks[tick] = &record
+	}
+
+	// Only the blocks which may be reorganized are kept in the journal.
+	keep := 0
+	for keep < len(census.Journal) && census.Journal[keep].Height+ReorgDepth < h.Height {
+		keep++
+	}
+	census.Journal = census.Journal[keep:]
+	census.Height = h.Height
+}
+
+func resetCensus() {
+	census.Lock()
+	defer census.Unlock()
+	census.censusState = censusState{}
+}
+
+// rewindCensus reverts the records of the blocks after the height by the journal, which must be locked.
+func rewindCensus(height uint) {
+	for len(census.Journal) > 0 && census.Journal[len(census.Journal)-1].Height > height {
+		change := census.Journal[len(census.Journal)-1]
+		if change.Previous == nil {
+			delete(census.Ticks, change.Tick)
+		} else {
+			census.Ticks[change.Tick] = change.Previous
+		}
+		census.Journal = census.Journal[:len(census.Journal)-1]
+	}
+}
+
+func storeCensus(height uint) error {
+	census.Lock()
+	data, err := json.Marshal(census.censusState)
+	census.Unlock()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(cachePath, censusFile(height)), data, 0666)
+}
+
+// loadCensus loads the census stored along with the state cache at the height.
+// Without it, the census starts from the height and misses the earlier ticks.
+func loadCensus(height uint) {
+	census.Lock()
+	defer census.Unlock()
+	census.censusState = censusState{}
+	data, err := os.ReadFile(filepath.Join(cachePath, censusFile(height)))
+	if err == nil {
+		err = json.Unmarshal(data, &census.censusState)
+	}
+	if err != nil || census.Height != height {
+		log.Printf("The census of the ticks starts from the height %d, since it isn't cached: %v", height, err)
+		census.censusState = censusState{FromHeight: height, Height: height, Ticks: make(map[string]*TickCensus)}
+	}
+}
+
+func censusFile(height uint) string {
+	return fmt.Sprintf("%d%s", height, censusSuffix)
+}
+
+type CensusDay struct {
+	// The blocks of the day, from the FromHeight to the ToHeight inclusive.
+	FromHeight uint `json:"fromHeight"`
+	ToHeight   uint `json:"toHeight"`
+	Deploys    int  `json:"deploys"`
+}
+
+// Census is the statistics of the deployed ticks at the height.
+type Census struct {
+	Height     uint `json:"height"`
+	FromHeight uint `json:"fromHeight"`
+	TotalTicks int  `json:"totalTicks"`
+	SelfMint   int  `json:"selfMint"`
+	// The ticks fully minted, still being minted and abandoned, which sum up to the total.
+	Completed int `json:"completed"`
+	Minting   int `json:"minting"`
+	Abandoned int `json:"abandoned"`
+	// The deploys of the latest days, the latest day first.
+	Days []CensusDay `json:"days"`
+}
+
+// CurrentCensus returns the statistics of the ticks along with the deploys of the latest days.
+func CurrentCensus(days int) Census {
+	census.Lock()
+	defer census.Unlock()
+	c := Census{Height: census.Height, FromHeight: census.FromHeight, TotalTicks: len(census.Ticks)}
+	for i := 0; i < days && uint(i)*CensusDayBlocks <= census.Height; i++ {
+		to := census.Height - uint(i)*CensusDayBlocks
+		c.Days = append(c.Days, CensusDay{FromHeight: to - min(to, CensusDayBlocks-1), ToHeight: to})
+	}
+	for _, t := range census.Ticks {
+		if t.SelfMint {
+			c.SelfMint++
+		}
+		switch {
+		case t.Completed:
+			c.Completed++
+		case t.abandoned(census.Height):
+			c.Abandoned++
+		default:
+			c.Minting++
+		}
+		if day := (census.Height - t.DeployHeight) / CensusDayBlocks; t.DeployHeight != 0 && day < uint(len(c.Days)) {
+			c.Days[day].Deploys++
+		}
+	}
+	return c
+}
+
+// sortedCensusTicks returns the ticks ordered by their deploys. The census shall be locked.
+func sortedCensusTicks() []TickCensus {
+	ticks := make([]TickCensus, 0, len(census.Ticks))
+	for _, t := range census.Ticks {
+		ticks = append(ticks, *t)
+	}
+	sort.Slice(ticks, func(i, j int) bool {
+		if ticks[i].DeployHeight != ticks[j].DeployHeight {
+			return ticks[i].DeployHeight < ticks[j].DeployHeight
+		}
+		return ticks[i].Tick < ticks[j].Tick
+	})
+	return ticks
+}
+
+// CensusTicks returns the ticks ordered by their deploys, along with the total number of the ticks.
+func CensusTicks(offset int, limit int) ([]TickCensus, int) {
+	census.Lock()
+	ticks := sortedCensusTicks()
+	census.Unlock()
+	total := len(ticks)
+	offset = min(offset, total)
+	return ticks[offset:min(offset+limit, total)], total
+}
@@ -7,6 +7,7 @@ import (
 	"strconv"
 
 	"github.com/RiemaLabs/modular-indexer-committee/ord"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/brc20"
 )
 
 type Record struct {
@@ -80,7 +81,10 @@ func (h *Header) VerifyState(records *OPIRecords) {
 			opiAvailableBalance := ele.AvailableBalance
 
 			var ordPkscript ord.Pkscript = ord.Pkscript(opiPkScript)
-			_, _, availableBalance, overallBalance := GetBalances(h, opiTick, ordPkscript)
+			_, _, availableBalance, overallBalance, err := brc20.GetBalances(h, opiTick, ordPkscript)
+			if err != nil {
+				log.Fatalf("at block height %d, failed to read the balances of Pkscript %s: %v", height, ordPkscript, err)
+			}
 			availableBalanceStr := availableBalance.String()
 			overallBalanceStr := overallBalance.String()
 
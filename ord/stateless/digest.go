@@ -0,0 +1,48 @@
+package stateless
+
+import (
+	"crypto/sha256"
+
+	verkle "github.com/ethereum/go-verkle"
+	"github.com/holiman/uint256"
+)
+
+// The digest of the state is the sum modulo 2^256 of sha256(key || value) over all key-values.
+// It doesn't depend on the order of the key-values, so it is maintained incrementally by every write,
+// and two members can compare their full states by the digests without exchanging or rebuilding the trees.
+func entryDigest(key [verkle.KeySize]byte, value [ValueSize]byte) *uint256.Int {
+	hasher := sha256.New()
+	hasher.Write(key[:])
+	hasher.Write(value[:])
+	return new(uint256.Int).SetBytes(hasher.Sum(nil))
+}
+
+func computeDigest(kv KVStore) *uint256.Int {
+	digest := uint256.NewInt(0)
+	kv.Range(func(key [verkle.KeySize]byte, value [ValueSize]byte) bool {
+		digest.Add(digest, entryDigest(key, value))
+		return true
+	})
+	return digest
+}
+
+// updateDigest replaces the entry of the key in the digest before the write of the key-value.
+func (h *Header) updateDigest(key [verkle.KeySize]byte, value [ValueSize]byte, exists bool) {
+	if h.digest == nil {
+		h.digest = computeDigest(h.KV)
+	}
+	if old, found := h.KV.Get(key); found {
+		h.digest.Sub(h.digest, entryDigest(key, old))
+	}
+	if exists {
+		h.digest.Add(h.digest, entryDigest(key, value))
+	}
+}
+
+// Digest returns the order-independent digest of the flushed key-values.
+func (h *Header) Digest() [32]byte {
+	if h.digest == nil {
+		return computeDigest(h.KV).Bytes32()
+	}
+	return h.digest.Bytes32()
+}
@@ -0,0 +1,231 @@
+package stateless
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord/brc20"
+)
+
+// The directory of the LevelDB database keeping the events of every executed block. Empty disables it.
+var EventsPath = ""
+
+// ErrEventsUnavailable is returned for the blocks whose events aren't kept.
+var ErrEventsUnavailable = errors.New("the events of the block aren't kept")
+
+// Key layout of the events database, where the heights are big-endian uint64
+// Block: "e" + height, Value: the events of the block encoded by brc20.MarshalEvents
+// Inscription: "i" + inscription ID + "/" + height, Value: empty, for every block with an event of the inscription
+// Indexed: "m/inscriptions", set once the blocks recorded before the inscriptions were indexed are indexed
+var (
+	eventsPrefix       = []byte("e")
+	inscriptionsPrefix = []byte("i")
+	inscriptionsMarker = []byte("m/inscriptions")
+)
+
+// InscriptionEvent is an event of an inscription along with the height of its block.
+type InscriptionEvent struct {
+	Height uint
+	Event  brc20.Event
+}
+
+var (
+	eventsMu sync.Mutex
+	eventsDB *leveldb.DB
+)
+
+func openEvents() (*leveldb.DB, error) {
+	if eventsDB != nil {
+		return eventsDB, nil
+	}
+	db, err := leveldb.OpenFile(EventsPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := indexInscriptions(db); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	eventsDB = db
+	return db, nil
+}
+
+// indexInscriptions indexes the inscriptions of the blocks recorded before the index was added.
+func indexInscriptions(db *leveldb.DB) error {
+	if found, err := db.Has(inscriptionsMarker, nil); err != nil || found {
+		return err
+	}
+	batch := new(leveldb.Batch)
+	iter := db.NewIterator(util.BytesPrefix(eventsPrefix), nil)
+	for iter.Next() {
+		if err := indexBlock(batch, iter.Key(), iter.Value(), false); err != nil {
+			iter.Release()
+			return err
+		}
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		return err
+	}
+	batch.Put(inscriptionsMarker, nil)
+	return db.Write(batch, nil)
+}
+
+func inscriptionKey(inscriptionID string, height uint) []byte {
+	return append(prefixed(inscriptionsPrefix, []byte(inscriptionID+"/")), heightBytes(height)...)
+}
+
+// eventInscription returns the inscription of the event.
+func eventInscription(event brc20.Event) string {
+	switch e := event.(type) {
+	case brc20.DeployEvent:
+		return e.InscriptionID
+	case brc20.MintEvent:
+		return e.InscriptionID
+	case brc20.TransferInscribeEvent:
+		return e.InscriptionID
+	case brc20.TransferTransferEvent:
+		return e.InscriptionID
+	}
+	return ""
+}
+
+// indexBlock puts the inscriptions of the block recorded at the key into the batch, or deletes them.
+func indexBlock(batch *leveldb.Batch, key []byte, data []byte, remove bool) error {
+	events, err := brc20.UnmarshalEvents(data)
+	if err != nil {
+		return fmt.Errorf("failed to decode the events of the block %x: %v", key, err)
+	}
+	height := uint(binary.BigEndian.Uint64(key[len(eventsPrefix):]))
+	for _, event := range events {
+		if remove {
+			batch.Delete(inscriptionKey(eventInscription(event), height))
+		} else {
+			batch.Put(inscriptionKey(eventInscription(event), height), nil)
+		}
+	}
+	return nil
+}
+
+// CloseEvents closes the events database.
+func CloseEvents() error {
+	eventsMu.Lock()
+	defer eventsMu.Unlock()
+	if eventsDB == nil {
+		return nil
+	}
+	err := eventsDB.Close()
+	eventsDB = nil
+	return err
+}
+
+// recordEvents keeps the events of the block at the height, which has been paged. A block executed again, e.g. after
+// a reorg, replaces the events of itself and drops the ones of the blocks following it.
+func recordEvents(height uint, events []brc20.Event) {
+	if EventsPath == "" {
+		return
+	}
+	eventsMu.Lock()
+	defer eventsMu.Unlock()
+	db, err := openEvents()
+	if err != nil {
+		panic(fmt.Errorf("failed to open the events database %s: %v", EventsPath, err))
+	}
+	data, err := brc20.MarshalEvents(events)
+	if err != nil {
+		panic(err)
+	}
+	batch := new(leveldb.Batch)
+	iter := db.NewIterator(&util.Range{Start: prefixed(eventsPrefix, heightBytes(height)), Limit: util.BytesPrefix(eventsPrefix).Limit}, nil)
+	for iter.Next() {
+		if err := indexBlock(batch, iter.Key(), iter.Value(), true); err != nil {
+			iter.Release()
+			panic(err)
+		}
+		batch.Delete(append([]byte(nil), iter.Key()...))
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		panic(fmt.Errorf("failed to read the events database: %v", err))
+	}
+	key := prefixed(eventsPrefix, heightBytes(height))
+	batch.Put(key, data)
+	if err := indexBlock(batch, key, data, false); err != nil {
+		panic(err)
+	}
+	if err := db.Write(batch, nil); err != nil {
+		panic(fmt.Errorf("failed to write the events database: %v", err))
+	}
+}
+
+// BlockEvents returns the events of the block at the height in the order of its transfers.
+func BlockEvents(height uint) ([]brc20.Event, error) {
+	if EventsPath == "" {
+		return nil, fmt.Errorf("%w: %d, the events database is disabled", ErrEventsUnavailable, height)
+	}
+	eventsMu.Lock()
+	defer eventsMu.Unlock()
+	db, err := openEvents()
+	if err != nil {
+		return nil, err
+	}
+	data, err := db.Get(prefixed(eventsPrefix, heightBytes(height)), nil)
+	if errors.Is(err, leveldb.ErrNotFound) {
+		return nil, fmt.Errorf("%w: %d", ErrEventsUnavailable, height)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return brc20.UnmarshalEvents(data)
+}
+
+// InscriptionEvents returns the events of the inscription kept by the events database, in the order of the blocks,
+// along with the first height kept.
+func InscriptionEvents(inscriptionID string) ([]InscriptionEvent, uint, error) {
+	if EventsPath == "" {
+		return nil, 0, fmt.Errorf("%w: the events database is disabled", ErrEventsUnavailable)
+	}
+	eventsMu.Lock()
+	defer eventsMu.Unlock()
+	db, err := openEvents()
+	if err != nil {
+		return nil, 0, err
+	}
+	var from uint
+	first := db.NewIterator(util.BytesPrefix(eventsPrefix), nil)
+	if first.Next() {
+		from = uint(binary.BigEndian.Uint64(first.Key()[len(eventsPrefix):]))
+	}
+	first.Release()
+	if err := first.Error(); err != nil {
+		return nil, 0, err
+	}
+
+	res := make([]InscriptionEvent, 0)
+	prefix := prefixed(inscriptionsPrefix, []byte(inscriptionID+"/"))
+	iter := db.NewIterator(util.BytesPrefix(prefix), nil)
+	defer iter.Release()
+	for iter.Next() {
+		height := bytes.TrimPrefix(iter.Key(), prefix)
+		data, err := db.Get(prefixed(eventsPrefix, height), nil)
+		if err != nil {
+			return nil, 0, err
+		}
+		events, err := brc20.UnmarshalEvents(data)
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, event := range events {
+			if eventInscription(event) == inscriptionID {
+				res = append(res, InscriptionEvent{Height: uint(binary.BigEndian.Uint64(height)), Event: event})
+			}
+		}
+	}
+	return res, from, iter.Error()
+}
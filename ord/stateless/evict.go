@@ -0,0 +1,150 @@
+package stateless
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/ethereum/go-verkle"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+
+	"github.com/RiemaLabs/modular-indexer-committee/internal/metrics"
+)
+
+// EvictInterval is the number of the blocks between the evictions of the verkle tree kept in the state database,
+// which write the subtrees below EvictDepth into the database and drop them from the memory, to be resolved again
+// on demand. Zero keeps the whole tree in the memory once the catch-up ends.
+var EvictInterval uint = 0
+
+// EvictDepth is the depth of the deepest nodes always kept in the memory, below which the subtrees are evicted.
+// The root is at the depth 0, so the depth 2 keeps 65793 internal nodes in the memory at most.
+var EvictDepth uint8 = 2
+
+// Key layout of the evicted nodes in the state database
+// Live node: "l" + path, Value: the serialized node evicted since the latest commit
+//
+// The live nodes are the nodes of the tree ahead of the committed one, which take precedence over the committed
+// nodes when resolved. They're moved to the committed nodes by the next commit, and dropped by a restart, which
+// resumes from the committed tree.
+var livePrefix = []byte("l")
+
+// treeLock serializes the accesses to the tree which may resolve the evicted nodes, since resolving a node
+// modifies the tree.
+var treeLock sync.Mutex
+
+// Evicting reports whether the nodes of the tree are evicted and resolved on demand while serving.
+func Evicting() bool {
+	return EvictInterval != 0 && stateDB != nil
+}
+
+// LockTree serializes the accesses to the tree which may resolve the evicted nodes, e.g. the proofs, and returns
+// the unlock. Without the eviction, the tree is hydrated before being served, and LockTree does nothing.
+func LockTree() func() {
+	if !Evicting() {
+		return func() {}
+	}
+	treeLock.Lock()
+	return treeLock.Unlock
+}
+
+// resolveNode resolves the node at the path, evicted since the latest commit or committed.
+func resolveNode(path []byte) ([]byte, error) {
+	serialized, err := stateDB.Get(prefixed(livePrefix, path), nil)
+	if err == nil {
+		return serialized, nil
+	}
+	if !errors.Is(err, leveldb.ErrNotFound) {
+		return nil, err
+	}
+	return stateDB.Get(prefixed(nodePrefix, path), nil)
+}
+
+// dropLiveNodes drops the nodes evicted ahead of the committed tree, e.g. by the run before a restart.
+func dropLiveNodes(db *leveldb.DB) error {
+	batch := new(leveldb.Batch)
+	iter := db.NewIterator(util.BytesPrefix(livePrefix), nil)
+	for iter.Next() {
+		batch.Delete(append([]byte(nil), iter.Key()...))
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		return err
+	}
+	return db.Write(batch, nil)
+}
+
+// commitLiveNodes moves the evicted nodes to the committed ones in the batch of a commit, before the nodes in the
+// memory are flushed into the batch as well.
+func commitLiveNodes(db *leveldb.DB, batch *leveldb.Batch) error {
+	iter := db.NewIterator(util.BytesPrefix(livePrefix), nil)
+	defer iter.Release()
+	for iter.Next() {
+		path := iter.Key()[len(livePrefix):]
+		batch.Put(prefixed(nodePrefix, path), append([]byte(nil), iter.Value()...))
+		batch.Delete(append([]byte(nil), iter.Key()...))
+	}
+	return iter.Error()
+}
+
+// Evict writes the subtrees of the tree below EvictDepth into the state database and drops them from the memory.
+// The nodes resolved since the previous eviction are written, whether they changed or not, so the writes follow
+// the keys accessed by the blocks and the proofs in between. Unlike a commit, the eviction isn't synced, since a
+// restart resumes from the committed tree anyway.
+func (h *Header) Evict() error {
+	if !Evicting() {
+		return nil
+	}
+	h.Settle()
+	root, isInternal := h.Root.(*verkle.InternalNode)
+	if !isInternal {
+		return fmt.Errorf("unexpected root node %T", h.Root)
+	}
+	defer LockTree()()
+	// The pending commitments are computed before their children are dropped.
+	root.Commit()
+	batch := new(leveldb.Batch)
+	var flushErr error
+	flushNodes(root, nil, int(EvictDepth)+1, func(path []byte, node verkle.VerkleNode) {
+		serialized, err := node.Serialize()
+		if err != nil && flushErr == nil {
+			flushErr = fmt.Errorf("failed to serialize the node at %x: %v", path, err)
+		}
+		batch.Put(prefixed(livePrefix, path), serialized)
+	})
+	if flushErr != nil {
+		return flushErr
+	}
+	if err := stateDB.Write(batch, nil); err != nil {
+		return err
+	}
+	metrics.EvictedNodes.Add(float64(batch.Len()))
+	log.Printf("Evicted %d nodes of the tree at height %d", batch.Len(), h.Height)
+	return nil
+}
+
+// flushNodes flushes the resident nodes of the subtree at the path from the depth, children first, and drops them
+// from the memory. Unlike the Flush of go-verkle, the paths are followed from the root rather than captured from the
+// leaves, so an internal node whose children are all dropped already is flushed at its path as well.
+func flushNodes(n *verkle.InternalNode, path []byte, depth int, flush func(path []byte, node verkle.VerkleNode)) {
+	for i, child := range n.Children() {
+		childPath := append(path[:len(path):len(path)], byte(i))
+		switch c := child.(type) {
+		case *verkle.InternalNode:
+			flushNodes(c, childPath, depth, flush)
+		case *verkle.LeafNode:
+			if len(childPath) >= depth {
+				flush(childPath, c)
+			}
+		default:
+			continue
+		}
+		if len(childPath) >= depth {
+			_ = n.SetChild(i, verkle.HashedNode{})
+		}
+	}
+	if len(path) >= depth {
+		flush(path, n)
+	}
+}
@@ -0,0 +1,96 @@
+package stateless
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/RiemaLabs/modular-indexer-committee/internal/metrics"
+	"github.com/RiemaLabs/modular-indexer-committee/internal/tracing"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/brc20"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/getter"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/protocol"
+)
+
+// eventRecorder collects the events of the execution on a state which doesn't follow them.
+type eventRecorder struct {
+	brc20.KVStorage
+	events []brc20.Event
+}
+
+func (r *eventRecorder) ObserveEvent(event brc20.Event) {
+	r.events = append(r.events, event)
+}
+
+// Exec executes a block on the state and returns the events of the BRC-20 transfers in the order of the block.
+// The rules live in the packages of the protocols, which are free of I/O, while the header additionally supports
+// the sharded execution, the progress report, the witness export and the watchlist.
+func Exec(state brc20.KVStorage, ots []getter.OrdTransfer, blockHeight uint) []brc20.Event {
+	return ExecContext(context.Background(), state, ots, blockHeight)
+}
+
+// ExecContext is Exec traced under the context.
+func ExecContext(ctx context.Context, state brc20.KVStorage, ots []getter.OrdTransfer, blockHeight uint) []brc20.Event {
+	header, isHeader := state.(*Header)
+	if !isHeader {
+		recorder := &eventRecorder{KVStorage: state}
+		protocol.Exec(recorder, ots, blockHeight)
+		return recorder.events
+	}
+	// Without a deadline the execution never fails.
+	_ = execBlock(ctx, header, ots, blockHeight, time.Time{})
+	return header.events
+}
+
+// execBlock executes a block on the header before the deadline, if any.
+// An exceeded block is rolled back so that the header is left as it was before the block.
+func execBlock(ctx context.Context, header *Header, ots []getter.OrdTransfer, blockHeight uint, deadline time.Time) (err error) {
+	ctx, span := tracing.Start(ctx, "exec", tracing.Height(blockHeight), attribute.Int("transfers", len(ots)))
+	defer func() {
+		// The stages of the handlers are traced by a span each, as the children of the execution.
+		header.stages.Emit(ctx)
+		header.stages = nil
+		tracing.End(span, err)
+	}()
+	startProgress(blockHeight, len(ots))
+	started := time.Now()
+	if ExecShards > 1 {
+		err = execSharded(header, ots, blockHeight, deadline)
+	} else {
+		err = execSerial(header, ots, blockHeight, deadline)
+	}
+	finishProgress(err != nil)
+	if err != nil {
+		rollbackBlock(header)
+		return err
+	}
+	metrics.ExecDuration.Observe(time.Since(started).Seconds())
+
+	if WitnessPath != "" || KeepWitnesses != 0 {
+		recordWitness(header, ots, blockHeight)
+	}
+	if Watchlist != nil {
+		header.watched = ots
+		header.watching = true
+	}
+	return nil
+}
+
+// execSerial executes the transfers one by one to report the progress and to check the deadline in between,
+// which is the same as executing the block at once since the state is only flushed by Paging.
+func execSerial(header *Header, ots []getter.OrdTransfer, blockHeight uint, deadline time.Time) error {
+	if len(ots) == 0 {
+		protocol.Exec(header, ots, blockHeight)
+		return nil
+	}
+	for i := range ots {
+		if exceeded(deadline) {
+			return fmt.Errorf("%w: block %d at transfer %d / %d", ErrDeadlineExceeded, blockHeight, i, len(ots))
+		}
+		protocol.Exec(header, ots[i:i+1], blockHeight)
+		advanceProgress(1)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package stateless
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sort"
+
+	"github.com/ethereum/go-verkle"
+	"github.com/holiman/uint256"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/brc20"
+)
+
+// ExportedBalance is the balance of a pkscript of a tick in the state, extended to 18 decimals.
+type ExportedBalance struct {
+	Tick             string `json:"tick"`
+	Pkscript         string `json:"pkscript"`
+	AvailableBalance string `json:"availableBalance"`
+	OverallBalance   string `json:"overallBalance"`
+}
+
+// StateExport is the full BRC-20 state after a block: the metadata and the supplies of the ticks, and the balances.
+type StateExport struct {
+	Height     uint              `json:"height"`
+	Commitment string            `json:"commitment"`
+	Ticks      []TickInfo        `json:"ticks"`
+	Balances   []ExportedBalance `json:"balances"`
+	// The number of the key-values of the state, and of those not decoded as a tick or a balance, such as the wallets,
+	// the events, the other protocols, and the balances of the pkscripts missed by the holders index.
+	Keys          int `json:"keys"`
+	UndecodedKeys int `json:"undecodedKeys"`
+	// The first blocks observed by the census and the holders index, whose earlier ticks and holders are missing
+	// from the export unless they changed afterwards.
+	CensusFromHeight  uint `json:"censusFromHeight"`
+	HoldersFromHeight uint `json:"holdersFromHeight"`
+}
+
+// exportStem is the preimage of a stem in the state: a tick, or the balances of a pkscript of a tick.
+type exportStem struct {
+	tick     string
+	pkscript ord.Pkscript
+}
+
+// Export iterates the flushed state, decoding the keys of the ticks known to the census and of the balances of their
+// holders. The keys are hashes, so the census and the holders index shall be at the height of the state.
+func (h *Header) Export() (*StateExport, error) {
+	census.Lock()
+	defer census.Unlock()
+	holders.Lock()
+	defer holders.Unlock()
+	if census.Height != h.Height || holders.height != h.Height {
+		return nil, fmt.Errorf("the census and the holders are at the heights %d and %d instead of %d", census.Height, holders.height, h.Height)
+	}
+
+	stems := make(map[[verkle.StemSize]byte]exportStem)
+	for tick := range census.Ticks {
+		stems[[verkle.StemSize]byte(brc20.GetTickHash(tick, 0))] = exportStem{tick: tick}
+		for pkscript := range holders.ticks[tick] {
+			stems[[verkle.StemSize]byte(brc20.GetTickPkscriptHash(tick, pkscript, 0))] = exportStem{tick: tick, pkscript: pkscript}
+		}
+	}
+
+	commitment := h.Root.Commit().Bytes()
+	export := &StateExport{
+		Height:            h.Height,
+		Commitment:        base64.StdEncoding.EncodeToString(commitment[:]),
+		Ticks:             make([]TickInfo, 0),
+		Balances:          make([]ExportedBalance, 0),
+		CensusFromHeight:  census.FromHeight,
+		HoldersFromHeight: holders.fromHeight,
+	}
+	deployed := make(map[string]bool)
+	balances := make(map[exportStem]*ExportedBalance)
+	err := h.Range(nil, func(key []byte, value []byte) bool {
+		export.Keys++
+		stem, found := stems[[verkle.StemSize]byte(key[:verkle.StemSize])]
+		if !found {
+			export.UndecodedKeys++
+			return true
+		}
+		if stem.pkscript == "" {
+			deployed[stem.tick] = true
+			return true
+		}
+		b, found := balances[stem]
+		if !found {
+			b = &ExportedBalance{Tick: stem.tick, Pkscript: string(stem.pkscript), AvailableBalance: "0", OverallBalance: "0"}
+			balances[stem] = b
+		}
+		switch key[verkle.StemSize] {
+		case brc20.AvailableBalancePkscript:
+			b.AvailableBalance = new(uint256.Int).SetBytes(value).Dec()
+		case brc20.OverallBalancePkscript:
+			b.OverallBalance = new(uint256.Int).SetBytes(value).Dec()
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, t := range sortedCensusTicks() {
+		if !deployed[t.Tick] {
+			continue
+		}
+		if info, found := h.tickInfo(t.Tick); found {
+			export.Ticks = append(export.Ticks, info)
+		}
+	}
+	for _, b := range balances {
+		if b.OverallBalance != "0" || b.AvailableBalance != "0" {
+			export.Balances = append(export.Balances, *b)
+		}
+	}
+	sort.Slice(export.Balances, func(i, j int) bool {
+		if export.Balances[i].Tick != export.Balances[j].Tick {
+			return export.Balances[i].Tick < export.Balances[j].Tick
+		}
+		return export.Balances[i].Pkscript < export.Balances[j].Pkscript
+	})
+	return export, nil
+}
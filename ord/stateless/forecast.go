@@ -0,0 +1,147 @@
+package stateless
+
+import (
+	"runtime"
+	"sync"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord/brc20"
+)
+
+// The number of the latest blocks whose growth is projected forward, about a week.
+const ForecastWindow = 1008
+
+// The number of the blocks of the peak growth, about a day, which catches the inscription frenzies.
+const ForecastPeakWindow = 144
+
+// The horizons of the projections in blocks, about a day, a week and a month.
+var ForecastHorizons = []uint{144, 1008, 4320}
+
+// The size of a key-value in the state cache, a 32-byte key and a 32-byte value gob-encoded with their lengths.
+const StorageBytesPerKey = 66
+
+type blockGrowth struct {
+	height uint
+	keys   [brc20.NumCategories]int
+}
+
+var growth struct {
+	sync.Mutex
+	blocks []blockGrowth
+	height uint
+	size   int
+}
+
+// recordGrowth records the new keys of the executed block. A block executed again after a reorg replaces the old one.
+func recordGrowth(height uint, keys [brc20.NumCategories]int, size int) {
+	growth.Lock()
+	defer growth.Unlock()
+	for len(growth.blocks) > 0 && growth.blocks[len(growth.blocks)-1].height >= height {
+		growth.blocks = growth.blocks[:len(growth.blocks)-1]
+	}
+	growth.blocks = append(growth.blocks, blockGrowth{height: height, keys: keys})
+	if len(growth.blocks) > ForecastWindow {
+		growth.blocks = growth.blocks[len(growth.blocks)-ForecastWindow:]
+	}
+	growth.height = height
+	growth.size = size
+}
+
+type CategoryForecast struct {
+	Category string `json:"category"`
+	// The new keys over the observed blocks.
+	Keys int `json:"keys"`
+	// The average new keys per block, and the highest average over ForecastPeakWindow blocks.
+	Rate     float64 `json:"rate"`
+	PeakRate float64 `json:"peakRate"`
+}
+
+type Projection struct {
+	Blocks uint `json:"blocks"`
+	// The projected state at the average rate, and at the peak rate.
+	Keys            int    `json:"keys"`
+	StorageBytes    uint64 `json:"storageBytes"`
+	MemoryBytes     uint64 `json:"memoryBytes"`
+	PeakKeys        int    `json:"peakKeys"`
+	PeakMemoryBytes uint64 `json:"peakMemoryBytes"`
+}
+
+// Forecast projects the state size forward from the growth of the latest blocks.
+type Forecast struct {
+	Height uint `json:"height"`
+	Keys   int  `json:"keys"`
+	// The number of the observed blocks, at most ForecastWindow.
+	Blocks int `json:"blocks"`
+	// The heap in use per key, which includes the verkle tree along with the key-values.
+	MemoryBytesPerKey float64            `json:"memoryBytesPerKey"`
+	Categories        []CategoryForecast `json:"categories"`
+	Projections       []Projection       `json:"projections"`
+}
+
+// peakRate returns the highest average of the consecutive windows of the counts.
+func peakRate(counts []int, window int) float64 {
+	if len(counts) < window {
+		window = len(counts)
+	}
+	if window == 0 {
+		return 0
+	}
+	sum, peak := 0, 0
+	for i, count := range counts {
+		sum += count
+		if i >= window {
+			sum -= counts[i-window]
+		}
+		if i >= window-1 && sum > peak {
+			peak = sum
+		}
+	}
+	return float64(peak) / float64(window)
+}
+
+func CurrentForecast() Forecast {
+	growth.Lock()
+	blocks := append([]blockGrowth{}, growth.blocks...)
+	f := Forecast{Height: growth.height, Keys: growth.size, Blocks: len(blocks)}
+	growth.Unlock()
+
+	if f.Keys != 0 {
+		var stats runtime.MemStats
+		runtime.ReadMemStats(&stats)
+		f.MemoryBytesPerKey = float64(stats.HeapInuse) / float64(f.Keys)
+	}
+
+	var rate float64
+	totals := make([]int, len(blocks))
+	for c := brc20.Category(0); c < brc20.NumCategories; c++ {
+		counts := make([]int, len(blocks))
+		cf := CategoryForecast{Category: c.String()}
+		for i, b := range blocks {
+			counts[i] = b.keys[c]
+			totals[i] += b.keys[c]
+			cf.Keys += b.keys[c]
+		}
+		if len(blocks) != 0 {
+			cf.Rate = float64(cf.Keys) / float64(len(blocks))
+		}
+		// The spikes at both ends of the blocks may average higher than any single window.
+		cf.PeakRate = max(peakRate(counts, ForecastPeakWindow), cf.Rate)
+		rate += cf.Rate
+		f.Categories = append(f.Categories, cf)
+	}
+	// The peak of the total isn't the sum of the peaks of the categories, which may happen at different blocks.
+	peak := max(peakRate(totals, ForecastPeakWindow), rate)
+
+	for _, blocks := range ForecastHorizons {
+		keys := f.Keys + int(rate*float64(blocks))
+		peakKeys := f.Keys + int(peak*float64(blocks))
+		f.Projections = append(f.Projections, Projection{
+			Blocks:          blocks,
+			Keys:            keys,
+			StorageBytes:    uint64(keys) * StorageBytesPerKey,
+			MemoryBytes:     uint64(float64(keys) * f.MemoryBytesPerKey),
+			PeakKeys:        peakKeys,
+			PeakMemoryBytes: uint64(float64(peakKeys) * f.MemoryBytesPerKey),
+		})
+	}
+	return f
+}
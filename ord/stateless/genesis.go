@@ -0,0 +1,44 @@
+package stateless
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord/brc20"
+)
+
+// The bootstrap state injected into a new header at the genesis height. Nil starts from the empty state.
+var Genesis *brc20.Genesis = nil
+
+func LoadGenesis(path string) (*brc20.Genesis, error) {
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var g brc20.Genesis
+	if err := json.Unmarshal(bytes, &g); err != nil {
+		return nil, fmt.Errorf("failed to parse the bootstrap state %s: %v", path, err)
+	}
+	if err := brc20.ValidateGenesis(&g); err != nil {
+		return nil, fmt.Errorf("invalid bootstrap state %s: %v", path, err)
+	}
+	return &g, nil
+}
+
+// Bootstrap writes the bootstrap state into the tree without advancing the height.
+func (h *Header) Bootstrap(g *brc20.Genesis) error {
+	if err := brc20.ApplyGenesis(h, g); err != nil {
+		return err
+	}
+	ticks, deployers, observed := h.ticks, h.deployers, h.holders
+	h.recordPreImages()
+	if _, err := h.flush(NodeResolveFn); err != nil {
+		return err
+	}
+	recordCensus(h, ticks, deployers)
+	recordHolders(h, observed)
+	// The call of Commit is necessary to refresh the root commit.
+	h.Root.Commit()
+	return nil
+}
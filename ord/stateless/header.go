@@ -1,41 +1,45 @@
 package stateless
 
 import (
+	"bufio"
 	"bytes"
+	"context"
+	"encoding/binary"
 	"encoding/gob"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
+	"log"
 	"sort"
+	"time"
 
 	"github.com/ethereum/go-verkle"
 	"github.com/holiman/uint256"
+	"github.com/klauspost/compress/zstd"
+	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/RiemaLabs/modular-indexer-committee/internal/metrics"
+	"github.com/RiemaLabs/modular-indexer-committee/internal/tracing"
+	"github.com/RiemaLabs/modular-indexer-committee/ord"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/brc20"
 	"github.com/RiemaLabs/modular-indexer-committee/ord/getter"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/protocol"
 )
 
-func (h *Header) insert(key []byte, value []byte, nodeResolverFn verkle.NodeResolverFn) {
-	if len(key) != verkle.KeySize {
-		panic(fmt.Errorf("the length the key to insert bytes must be %d, current is: %d", verkle.KeySize, len(key)))
+func (h *Header) insert(key []byte, value []byte, nodeResolverFn verkle.NodeResolverFn) error {
+	if err := protocol.CheckKey(key); err != nil {
+		return err
 	}
 	if len(value) != ValueSize {
-		panic(fmt.Errorf("the length the value must be %d, current is: %d", ValueSize, len(key)))
+		return fmt.Errorf("the length of the value must be %d, current is: %d", ValueSize, len(value))
 	}
 
-	// Get the old value from the verkle tree root.
-	oldValue, err := h.Root.Get(key, nodeResolverFn)
-	if err != nil {
-		panic(err)
-	}
-	oldValueExists := len(oldValue) > 0
-
 	var keyArray [verkle.KeySize]byte
 	copy(keyArray[:], key)
 
-	var oldValueArray [ValueSize]byte
-	if oldValueExists {
-		copy(oldValueArray[:], oldValue)
-	}
+	// Get the old value from the flushed key-values, which are consistent with the tree once it's settled.
+	oldValueArray, oldValueExists := h.KV.Get(keyArray)
 
 	var newValueArray [ValueSize]byte
 	copy(newValueArray[:], value)
@@ -45,6 +49,8 @@ func (h *Header) insert(key []byte, value []byte, nodeResolverFn verkle.NodeReso
 	for i, ele := range h.Access.Elements {
 		if bytes.Equal(keyArray[:], ele.Key[:]) {
 			h.Access.Elements[i].NewValue = newValueArray
+			h.Access.Elements[i].Deleted = false
+			h.Access.Elements[i].Written = true
 			exists = true
 			break
 		}
@@ -55,33 +61,37 @@ func (h *Header) insert(key []byte, value []byte, nodeResolverFn verkle.NodeReso
 			OldValue:       oldValueArray,
 			NewValue:       newValueArray,
 			OldValueExists: oldValueExists,
+			Written:        true,
 		})
 	}
 
 	h.IntermediateKV[[verkle.KeySize]byte(key)] = [ValueSize]byte(value)
+	delete(h.deleted, keyArray)
+	if h.lastWrite != nil {
+		h.lastWrite[keyArray] = h.cursor
+	}
+	return nil
 }
 
-func (h *Header) get(key []byte, nodeResolverFn verkle.NodeResolverFn) []byte {
-	if len(key) != verkle.KeySize {
-		panic(fmt.Errorf("the length the key to insert bytes must be %d, current is: %d", verkle.KeySize, len(key)))
+func (h *Header) get(key []byte, nodeResolverFn verkle.NodeResolverFn) ([]byte, error) {
+	if err := protocol.CheckKey(key); err != nil {
+		return nil, err
 	}
 
 	key32 := [verkle.KeySize]byte(key)
 
-	oldValue, err := h.Root.Get(key, nodeResolverFn)
-	if err != nil {
-		panic(err)
-	}
-	oldValueExists := len(oldValue) > 0
+	oldValue, oldValueExists := h.KV.Get(key32)
 
 	var res [ValueSize]byte
 	var found bool
 
 	if res, found = h.IntermediateKV[key32]; found {
 		// The value has been updated during the execution.
+	} else if h.deleted[key32] {
+		res = defaultValue()
 	} else {
 		if oldValueExists {
-			res = [ValueSize]byte(oldValue)
+			res = oldValue
 		} else {
 			res = defaultValue()
 		}
@@ -103,67 +113,74 @@ func (h *Header) get(key []byte, nodeResolverFn verkle.NodeResolverFn) []byte {
 			OldValueExists: oldValueExists,
 		})
 	}
-	return res[:]
+	return res[:], nil
 }
 
-func (h *Header) InsertInscriptionID(key []byte, value string) {
+func (h *Header) InsertInscriptionID(key []byte, value string) error {
+	transactionID, outputIndex, err := protocol.SplitInscriptionID(value)
+	if err == nil {
+		err = protocol.CheckKey(key)
+	}
+	if err != nil {
+		return err
+	}
 	// The first slot contains the first 32 bytes of the InscriptionID
 	firstKey := make([]byte, verkle.KeySize)
 	copy(firstKey, key)
-
-	valueLen := verkle.LeafValueSize * 2 // 64
-	transactionID, err := hex.DecodeString(value[:valueLen])
-	if err != nil {
-		panic(err)
+	if err := h.insert(firstKey, transactionID, NodeResolveFn); err != nil {
+		return err
 	}
-	h.insert(firstKey, transactionID, NodeResolveFn)
 
 	// The second slot contains the output index of the InscriptionID
 	secondKey := make([]byte, verkle.KeySize)
 	copy(secondKey, key)
 	secondKey[verkle.StemSize] = firstKey[verkle.StemSize] + byte(1)
-
-	outputIndex := value[valueLen+1:]
-	outputIndexUint256, err := uint256.FromDecimal(outputIndex)
-	if err != nil {
-		panic(err)
-	}
-	h.InsertUInt256(secondKey, outputIndexUint256)
+	return h.InsertUInt256(secondKey, outputIndex)
 }
 
-func (h *Header) GetInscriptionID(key []byte) string {
+func (h *Header) GetInscriptionID(key []byte) (string, error) {
+	if err := protocol.CheckKey(key); err != nil {
+		return "", err
+	}
 	// The first Key
 	firstKey := make([]byte, verkle.KeySize)
 	copy(firstKey, key)
-	transactionIDBytes := h.get(firstKey, NodeResolveFn)
+	transactionIDBytes, err := h.get(firstKey, NodeResolveFn)
+	if err != nil {
+		return "", err
+	}
 	transactionID := hex.EncodeToString(transactionIDBytes)
 
 	// The second Key
 	secondKey := make([]byte, verkle.KeySize)
 	copy(secondKey, key)
 	secondKey[verkle.StemSize] = firstKey[verkle.StemSize] + byte(1)
-	outputIndexUint256 := h.GetUInt256(secondKey)
+	outputIndexUint256, err := h.GetUInt256(secondKey)
+	if err != nil {
+		return "", err
+	}
 	outputIndex := outputIndexUint256.Dec()
 
-	return transactionID + "i" + outputIndex
+	return transactionID + "i" + outputIndex, nil
 }
 
-func (h *Header) InsertUInt256(key []byte, value *uint256.Int) {
+func (h *Header) InsertUInt256(key []byte, value *uint256.Int) error {
 	var dest [ValueSize]byte
 	value.WriteToArray32(&dest)
-	h.insert(key, dest[:], NodeResolveFn)
+	return h.insert(key, dest[:], NodeResolveFn)
 }
 
-func (h *Header) GetUInt256(key []byte) *uint256.Int {
-	res := uint256.NewInt(0)
-	value := h.get(key, NodeResolveFn)
-	return res.SetBytes(value)
+func (h *Header) GetUInt256(key []byte) (*uint256.Int, error) {
+	value, err := h.get(key, NodeResolveFn)
+	if err != nil {
+		return nil, err
+	}
+	return uint256.NewInt(0).SetBytes(value), nil
 }
 
-func (h *Header) InsertBytes(key []byte, value []byte) {
-	expectedSize := (verkle.NodeWidth - int(key[verkle.StemSize])) * ValueSize
-	if len(value) > expectedSize {
-		panic(fmt.Errorf("the max length of the byte is: %d at key %s, current is: %d", expectedSize, key, len(value)))
+func (h *Header) InsertBytes(key []byte, value []byte) error {
+	if err := protocol.CheckBytes(key, value); err != nil {
+		return err
 	}
 	// The first slot is the number of required slots to store the byte.
 	newKey := make([]byte, verkle.KeySize)
@@ -171,7 +188,9 @@ func (h *Header) InsertBytes(key []byte, value []byte) {
 
 	len := len(value)
 	requiredSlots := (len + ValueSize - 1) / ValueSize
-	h.InsertUInt256(newKey, uint256.NewInt(uint64(len)))
+	if err := h.InsertUInt256(newKey, uint256.NewInt(uint64(len))); err != nil {
+		return err
+	}
 
 	totalLen := requiredSlots * ValueSize
 	padded := make([]byte, totalLen)
@@ -179,47 +198,284 @@ func (h *Header) InsertBytes(key []byte, value []byte) {
 
 	for i := range requiredSlots {
 		newKey[verkle.StemSize] = key[verkle.StemSize] + byte(i+1)
-		h.insert(newKey, padded[i*ValueSize:(i+1)*ValueSize], NodeResolveFn)
+		if err := h.insert(newKey, padded[i*ValueSize:(i+1)*ValueSize], NodeResolveFn); err != nil {
+			return err
+		}
 	}
+	return nil
 }
 
-func (h *Header) GetBytes(key []byte) []byte {
+func (h *Header) GetBytes(key []byte) ([]byte, error) {
+	if err := protocol.CheckKey(key); err != nil {
+		return nil, err
+	}
 	newKey := make([]byte, verkle.KeySize)
 	copy(newKey, key)
 
-	len := h.GetUInt256(newKey).Uint64()
+	length, err := h.GetUInt256(newKey)
+	if err != nil {
+		return nil, err
+	}
+	len := length.Uint64()
 	if len == 0 {
-		return make([]byte, 0)
+		return make([]byte, 0), nil
 	}
 	requiredSlots := (len + ValueSize - 1) / ValueSize
 
 	padded := make([]byte, 0)
 	for i := range requiredSlots {
 		newKey[verkle.StemSize] = key[verkle.StemSize] + byte(i+1)
-		padded = append(padded, h.get(newKey, NodeResolveFn)...)
+		value, err := h.get(newKey, NodeResolveFn)
+		if err != nil {
+			return nil, err
+		}
+		padded = append(padded, value...)
 	}
 	res := padded[:len]
-	return res
+	return res, nil
 }
 
-func (h *Header) Paging(ordGetter getter.OrdGetter, queryHash bool, nodeResolverFn verkle.NodeResolverFn) error {
+// Delete removes the key from the state once the block is paged. Unlike a write of zeros, the key is then absent
+// from the flushed key-values and the commitments. Inside a shard, the deletion is a write merged as the others.
+func (h *Header) Delete(key []byte) error {
+	if err := protocol.CheckKey(key); err != nil {
+		return err
+	}
+	keyArray := [verkle.KeySize]byte(key)
+	oldValueArray, oldValueExists := h.KV.Get(keyArray)
+
+	exists := false
+	for i, ele := range h.Access.Elements {
+		if ele.Key == keyArray {
+			h.Access.Elements[i].NewValue = [ValueSize]byte{}
+			h.Access.Elements[i].Deleted = true
+			h.Access.Elements[i].Written = false
+			exists = true
+			break
+		}
+	}
+	if !exists {
+		h.Access.Elements = append(h.Access.Elements, TripleElement{
+			Key:            keyArray,
+			OldValue:       oldValueArray,
+			OldValueExists: oldValueExists,
+			Deleted:        true,
+		})
+	}
+
+	delete(h.IntermediateKV, keyArray)
+	if h.deleted == nil {
+		h.deleted = make(map[[verkle.KeySize]byte]bool)
+	}
+	h.deleted[keyArray] = true
+	if h.lastWrite != nil {
+		h.lastWrite[keyArray] = h.cursor
+	}
+	return nil
+}
+
+// Range ranges the flushed key-values along with the writes and the deletions of the block being executed.
+func (h *Header) Range(prefix []byte, fn func(key []byte, value []byte) bool) error {
+	stopped := false
+	h.KV.RangePrefix(prefix, func(key [verkle.KeySize]byte, value [ValueSize]byte) bool {
+		if h.deleted[key] {
+			return true
+		}
+		if written, found := h.IntermediateKV[key]; found {
+			value = written
+		}
+		stopped = !fn(key[:], value[:])
+		return !stopped
+	})
+	if stopped {
+		return nil
+	}
 	for key, value := range h.IntermediateKV {
-		h.KV[key] = value
-		_ = h.Root.Insert(key[:], value[:], nodeResolverFn)
+		if !bytes.HasPrefix(key[:], prefix) {
+			continue
+		}
+		if _, found := h.KV.Get(key); found {
+			continue
+		}
+		if !fn(key[:], value[:]) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// flush writes the key-values of the executed block into the tree and the commitments maintained along with it.
+// flush writes the key-values of the executed block and returns the number of the new keys of each category.
+func (h *Header) flush(nodeResolverFn verkle.NodeResolverFn) ([brc20.NumCategories]int, error) {
+	var growth [brc20.NumCategories]int
+	secondary := h.secondaryTree()
+	for key, value := range h.IntermediateKV {
+		if _, found := h.KV.Get(key); !found {
+			growth[h.categories[[verkle.StemSize]byte(key[:verkle.StemSize])]]++
+		}
+		h.updateDigest(key, value, true)
+		h.KV.Put(key, value)
+		if secondary != nil {
+			secondary.Insert(key, value)
+		}
+	}
+	removed := false
+	for key := range h.deleted {
+		if _, found := h.KV.Get(key); !found {
+			continue
+		}
+		h.updateDigest(key, [ValueSize]byte{}, false)
+		h.KV.Delete(key)
+		if secondary != nil {
+			secondary.Delete(key)
+		}
+		removed = true
+	}
+	var err error
+	if removed {
+		err = h.rebuildTree(nodeResolverFn)
+	} else {
+		err = h.insertTree(h.IntermediateKV, nodeResolverFn)
 	}
 
 	h.Access = AccessList{}
 	h.IntermediateKV = KeyValueMap{}
-	// Update height and hash
-	h.Height++
-	metrics.CurrentHeight.Set(float64(h.Height))
+	h.deleted = nil
+	h.categories = nil
+	h.ticks = nil
+	h.deployers = nil
+	h.holders = nil
+	h.events, h.eventSeqs = nil, nil
+	return growth, err
+}
+
+// Fork returns a disposable header sharing the committed tree of the header, which is only read by the fork.
+// The writes of the fork stay in its IntermediateKV and Access, so the fork must never be paged.
+func (h *Header) Fork() *Header {
+	return &Header{
+		Root:           h.Root,
+		KV:             h.KV,
+		Height:         h.Height,
+		Hash:           h.Hash,
+		Access:         AccessList{},
+		IntermediateKV: KeyValueMap{},
+	}
+}
+
+func (h *Header) ObserveTick(tick string, deployed bool) {
+	if h.ticks == nil {
+		h.ticks = make(map[string]bool)
+	}
+	h.ticks[tick] = h.ticks[tick] || deployed
+}
+
+func (h *Header) ObserveDeploy(tick string, pkscript ord.Pkscript, wallet ord.Wallet) {
+	if h.deployers == nil {
+		h.deployers = make(map[string]tickDeployer)
+	}
+	h.deployers[tick] = tickDeployer{pkscript: pkscript, wallet: wallet}
+}
+
+func (h *Header) ObserveHolder(tick string, pkscript ord.Pkscript) {
+	if h.holders == nil {
+		h.holders = make(map[string]map[ord.Pkscript]bool)
+	}
+	if h.holders[tick] == nil {
+		h.holders[tick] = make(map[ord.Pkscript]bool)
+	}
+	h.holders[tick][pkscript] = true
+}
+
+func (h *Header) ObserveEvent(event brc20.Event) {
+	h.events = append(h.events, event)
+	h.eventSeqs = append(h.eventSeqs, h.cursor)
+}
+
+// ObserveFailure logs the diagnostic of a transfer skipped by a failure, along with the stack of a panic.
+func (h *Header) ObserveFailure(ot getter.OrdTransfer, blockHeight uint, err error) {
+	kind := "error"
+	var stack []byte
+	var p *brc20.PanicError
+	if errors.As(err, &p) {
+		kind, stack = "panic", p.Stack
+	}
+	metrics.FailedTransfers.WithLabelValues(kind).Inc()
+	log.Printf("Skipped the transfer of a failure: height=%d id=%d inscription=%s old_satpoint=%q new_satpoint=%q kind=%s error=%q",
+		blockHeight, ot.ID, ot.InscriptionID, ot.OldSatpoint, ot.NewSatpoint, kind, err)
+	if stack != nil {
+		log.Printf("The stack of the panic of the inscription %s:\n%s", ot.InscriptionID, stack)
+	}
+}
+
+func (h *Header) ObserveStage(name string, started time.Time, elapsed time.Duration) {
+	if tracing.Enabled() {
+		h.stages.Observe(name, started, elapsed)
+	}
+}
+
+func (h *Header) ObserveCategory(key []byte, category brc20.Category) {
+	if h.categories == nil {
+		h.categories = make(map[[verkle.StemSize]byte]brc20.Category)
+	}
+	h.categories[[verkle.StemSize]byte(key[:verkle.StemSize])] = category
+}
+
+func (h *Header) Paging(ordGetter getter.OrdGetter, queryHash bool, nodeResolverFn verkle.NodeResolverFn) error {
+	return h.PagingContext(context.Background(), ordGetter, queryHash, nodeResolverFn)
+}
+
+// PagingContext is Paging traced under the context.
+// The hash of the block is queried before the block is applied, so that a failure of the getter leaves the block
+// pending rather than applied without its hash and without the notifications of its events.
+func (h *Header) PagingContext(ctx context.Context, ordGetter getter.OrdGetter, queryHash bool, nodeResolverFn verkle.NodeResolverFn) error {
+	hash := h.Hash
 	if queryHash {
-		hash, err := ordGetter.GetBlockHash(h.Height)
-		if err != nil {
-			return err
+		var err error
+		if hash, err = ordGetter.GetBlockHash(h.Height + 1); err != nil {
+			return fmt.Errorf("failed to get the hash of the block %d: %w", h.Height+1, err)
+		}
+	}
+	return h.page(ctx, hash, nodeResolverFn)
+}
+
+// page applies the executed block of the hash to the state.
+func (h *Header) page(ctx context.Context, hash string, nodeResolverFn verkle.NodeResolverFn) (err error) {
+	_, span := tracing.Start(ctx, "paging", tracing.Height(h.Height+1), attribute.Int("writes", len(h.IntermediateKV)))
+	defer func() {
+		tracing.End(span, err)
+	}()
+	ticks, deployers, observed, events := h.ticks, h.deployers, h.holders, h.events
+	h.recordPreImages()
+	if h.diffs != nil || HistoryPath != "" {
+		writes := h.writes()
+		if h.diffs != nil && WriteAheadLog {
+			// The block is logged before it's applied, the state cache being recovered by the log is only slower.
+			if err := h.appendWAL(writes); err != nil {
+				log.Printf("Failed to append the block %d to the write-ahead log: %v", h.Height+1, err)
+			}
 		}
-		h.Hash = hash
+		h.recordDiff(writes)
+		h.recordHistory(writes)
+	}
+	growth, err := h.flush(nodeResolverFn)
+	if err != nil {
+		return fmt.Errorf("failed to page the block %d: %w", h.Height+1, err)
 	}
+	h.pagedEvents = len(events)
+	exportWitness(h)
+	// Update height and hash
+	h.Height++
+	h.Hash = hash
+	recordGrowth(h.Height, growth, h.KV.Len())
+	metrics.KVSize.Set(float64(h.KV.Len()))
+	recordCensus(h, ticks, deployers)
+	recordHolders(h, observed)
+	recordEvents(h.Height, events)
+	observeOPICheck(h, events)
+	observeWatchlist(h)
+	metrics.CurrentHeight.Set(float64(h.Height))
+	publishBlock(h, events)
+	notifyWebhooks(h, events)
 	return nil
 }
 
@@ -227,32 +483,104 @@ func (h *Header) GetHeight() uint {
 	return h.Height
 }
 
-func (h *Header) Serialize() (*bytes.Buffer, error) {
-	// TODO: Medium. Use a native database instead of a key-value store for the state management.
-	var buffer bytes.Buffer
-	encoder := gob.NewEncoder(&buffer)
-	err := encoder.Encode(h.KV)
+// PagedEvents returns the number of the events emitted by the latest paged block.
+func (h *Header) PagedEvents() int {
+	return h.pagedEvents
+}
+
+// The state caches are zstd streams of chunks, each made of the little-endian uint32 number of its key-values followed
+// by the key-values, and ended by an empty chunk. Neither side holds more than a chunk besides the key-values.
+const snapshotChunkSize = 4096
+
+var zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+
+// Serialize streams the key-values of the state to the writer.
+func (h *Header) Serialize(w io.Writer) error {
+	encoder, err := zstd.NewWriter(w)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	const entrySize = verkle.KeySize + ValueSize
+	chunk := make([]byte, 4, 4+snapshotChunkSize*entrySize)
+	writeChunk := func() error {
+		binary.LittleEndian.PutUint32(chunk[:4], uint32((len(chunk)-4)/entrySize))
+		_, err := encoder.Write(chunk)
+		chunk = chunk[:4]
+		return err
 	}
-	return &buffer, nil
+	h.KV.Range(func(key [verkle.KeySize]byte, value [ValueSize]byte) bool {
+		chunk = append(append(chunk, key[:]...), value[:]...)
+		if len(chunk) == cap(chunk) {
+			err = writeChunk()
+		}
+		return err == nil
+	})
+	if err == nil && len(chunk) > 4 {
+		err = writeChunk()
+	}
+	if err == nil {
+		err = writeChunk()
+	}
+	if err != nil {
+		encoder.Close()
+		return err
+	}
+	return encoder.Close()
 }
 
 func (h *Header) OrderedKeys() [][verkle.KeySize]byte {
-	keys := make([][verkle.KeySize]byte, 0, len(h.KV))
-	for key := range h.KV {
+	keys := make([][verkle.KeySize]byte, 0, h.KV.Len())
+	h.KV.Range(func(key [verkle.KeySize]byte, _ [ValueSize]byte) bool {
 		keys = append(keys, key)
-	}
+		return true
+	})
 	sort.Slice(keys, func(i, j int) bool {
 		return string(keys[i][:]) < string(keys[j][:])
 	})
 	return keys
 }
 
-func Deserialize(buffer *bytes.Buffer, height uint, nodeResolverFn verkle.NodeResolverFn) (*Header, error) {
-	var kv KeyValueMap
-	decoder := gob.NewDecoder(buffer)
-	err := decoder.Decode(&kv)
+// decodeKV reads the key-values streamed by Serialize, or the legacy gob state caches.
+func decodeKV(r io.Reader) (MemoryKV, error) {
+	reader := bufio.NewReader(r)
+	if magic, err := reader.Peek(len(zstdMagic)); err != nil || !bytes.Equal(magic, zstdMagic) {
+		var kv MemoryKV
+		err := gob.NewDecoder(reader).Decode(&kv)
+		return kv, err
+	}
+	decoder, err := zstd.NewReader(reader)
+	if err != nil {
+		return nil, err
+	}
+	defer decoder.Close()
+	const entrySize = verkle.KeySize + ValueSize
+	kv := make(MemoryKV)
+	var count [4]byte
+	chunk := make([]byte, snapshotChunkSize*entrySize)
+	for {
+		if _, err := io.ReadFull(decoder, count[:]); err != nil {
+			return nil, fmt.Errorf("truncated state cache: %v", err)
+		}
+		n := int(binary.LittleEndian.Uint32(count[:]))
+		if n == 0 {
+			return kv, nil
+		}
+		if n > snapshotChunkSize {
+			return nil, fmt.Errorf("invalid chunk of %d key-values in the state cache", n)
+		}
+		if _, err := io.ReadFull(decoder, chunk[:n*entrySize]); err != nil {
+			return nil, fmt.Errorf("truncated state cache: %v", err)
+		}
+		for i := 0; i < n; i++ {
+			entry := chunk[i*entrySize : (i+1)*entrySize]
+			kv[[verkle.KeySize]byte(entry[:verkle.KeySize])] = [ValueSize]byte(entry[verkle.KeySize:])
+		}
+	}
+}
+
+// Deserialize reads the state streamed by Serialize and rebuilds its tree.
+func Deserialize(r io.Reader, height uint, nodeResolverFn verkle.NodeResolverFn) (*Header, error) {
+	kv, err := decodeKV(r)
 	if err != nil {
 		return nil, err
 	}
@@ -260,7 +588,7 @@ func Deserialize(buffer *bytes.Buffer, height uint, nodeResolverFn verkle.NodeRe
 	for k, v := range kv {
 		err := root.Insert(k[:], v[:], nodeResolverFn)
 		if err != nil {
-			return nil, nil
+			return nil, err
 		}
 	}
 	// The call of Commit is necessary to refresh the root commit.
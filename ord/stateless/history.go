@@ -0,0 +1,234 @@
+package stateless
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-verkle"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// The directory of the LevelDB database indexing the writes of every block by the key, which serves the values of
+// the keys at any height since the database was created. Empty keeps only the latest ReorgDepth blocks queryable.
+var HistoryPath = ""
+
+// ErrHistoryUnavailable is returned for the heights whose later blocks aren't kept.
+var ErrHistoryUnavailable = errors.New("the blocks following the height aren't kept")
+
+// ErrHeightNotReached is returned for the heights beyond the latest block.
+var ErrHeightNotReached = errors.New("the height isn't reached yet")
+
+// Key layout of the history database, where the heights are big-endian uint64, so the writes of a key are ordered
+// Write: "w" + key + height, Value: 1 if the key existed before the block at the height, followed by its value
+// Block: "b" + height, Value: the keys written by the block at the height
+// Meta: "m", Value: the JSON of historyRange
+var (
+	writePrefix = []byte("w")
+	blockPrefix = []byte("b")
+)
+
+// historyRange is the first and the latest block whose writes are kept, both 0 if none is.
+type historyRange struct {
+	Start  uint `json:"start"`
+	Latest uint `json:"latest"`
+}
+
+var (
+	historyMu   sync.Mutex
+	historyDB   *leveldb.DB
+	historyKept historyRange
+)
+
+func openHistory() (*leveldb.DB, error) {
+	if historyDB != nil {
+		return historyDB, nil
+	}
+	db, err := leveldb.OpenFile(HistoryPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	kept := historyRange{}
+	metaBytes, err := db.Get(metaKey, nil)
+	if err == nil {
+		err = json.Unmarshal(metaBytes, &kept)
+	} else if errors.Is(err, leveldb.ErrNotFound) {
+		err = nil
+	}
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("invalid history database meta: %v", err)
+	}
+	historyDB, historyKept = db, kept
+	return db, nil
+}
+
+// CloseHistory closes the history database.
+func CloseHistory() error {
+	historyMu.Lock()
+	defer historyMu.Unlock()
+	if historyDB == nil {
+		return nil
+	}
+	err := historyDB.Close()
+	historyDB, historyKept = nil, historyRange{}
+	return err
+}
+
+func heightBytes(height uint) []byte {
+	return binary.BigEndian.AppendUint64(nil, uint64(height))
+}
+
+func writeKey(key [verkle.KeySize]byte, height uint) []byte {
+	return append(prefixed(writePrefix, key[:]), heightBytes(height)...)
+}
+
+// deleteHistory deletes the writes of the blocks from the height to the height to, both included.
+func deleteHistory(db *leveldb.DB, batch *leveldb.Batch, from, to uint) error {
+	for height := from; height <= to; height++ {
+		keys, err := db.Get(prefixed(blockPrefix, heightBytes(height)), nil)
+		if errors.Is(err, leveldb.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		for i := 0; i+verkle.KeySize <= len(keys); i += verkle.KeySize {
+			batch.Delete(writeKey([verkle.KeySize]byte(keys[i:i+verkle.KeySize]), height))
+		}
+		batch.Delete(prefixed(blockPrefix, heightBytes(height)))
+	}
+	return nil
+}
+
+// truncateHistory deletes the writes of the blocks from the height on.
+func truncateHistory(db *leveldb.DB, batch *leveldb.Batch, from uint) error {
+	return deleteHistory(db, batch, from, historyKept.Latest)
+}
+
+// writeHistoryMeta writes the batch along with the kept range.
+func writeHistoryMeta(db *leveldb.DB, batch *leveldb.Batch, kept historyRange) error {
+	metaBytes, err := json.Marshal(kept)
+	if err != nil {
+		return err
+	}
+	batch.Put(metaKey, metaBytes)
+	return db.Write(batch, nil)
+}
+
+// recordHistory indexes the writes of the block being paged. A block executed again, e.g. after a reorg, replaces
+// the writes of itself and of the blocks following it, while a block not following the kept ones restarts the history.
+func (h *Header) recordHistory(writes []TripleElement) {
+	if HistoryPath == "" {
+		return
+	}
+	historyMu.Lock()
+	defer historyMu.Unlock()
+	db, err := openHistory()
+	if err != nil {
+		panic(fmt.Errorf("failed to open the history database %s: %v", HistoryPath, err))
+	}
+	height := h.Height + 1
+	batch := new(leveldb.Batch)
+	kept := historyKept
+	switch {
+	case kept.Latest == 0:
+		kept.Start = height
+	case height <= kept.Start || height > kept.Latest+1:
+		err = truncateHistory(db, batch, kept.Start)
+		kept.Start = height
+	case height <= kept.Latest:
+		err = truncateHistory(db, batch, height)
+	}
+	if err != nil {
+		panic(fmt.Errorf("failed to truncate the history database: %v", err))
+	}
+	kept.Latest = height
+
+	keys := make([]byte, 0, len(writes)*verkle.KeySize)
+	for _, elem := range writes {
+		value := make([]byte, 1, 1+ValueSize)
+		if elem.OldValueExists {
+			value[0] = 1
+		}
+		batch.Put(writeKey(elem.Key, height), append(value, elem.OldValue[:]...))
+		keys = append(keys, elem.Key[:]...)
+	}
+	batch.Put(prefixed(blockPrefix, heightBytes(height)), keys)
+	if err := writeHistoryMeta(db, batch, kept); err != nil {
+		panic(fmt.Errorf("failed to write the history database: %v", err))
+	}
+	historyKept = kept
+}
+
+// historyValueAt returns the value of the key before its first write in the blocks (height, latest],
+// and false if the key isn't written by them.
+func historyValueAt(key [verkle.KeySize]byte, height uint, latest uint) ([ValueSize]byte, bool, error) {
+	historyMu.Lock()
+	defer historyMu.Unlock()
+	db, err := openHistory()
+	if err != nil {
+		return [ValueSize]byte{}, false, err
+	}
+	if historyKept.Latest < latest || height+1 < historyKept.Start {
+		return [ValueSize]byte{}, false, fmt.Errorf("%w: %d, the writes are kept from the block %d to %d", ErrHistoryUnavailable, height, historyKept.Start, historyKept.Latest)
+	}
+	iter := db.NewIterator(&util.Range{Start: writeKey(key, height+1), Limit: writeKey(key, latest+1)}, nil)
+	defer iter.Release()
+	if !iter.Next() {
+		return [ValueSize]byte{}, false, iter.Error()
+	}
+	value := iter.Value()
+	if value[0] == 0 {
+		return defaultValue(), true, nil
+	}
+	return [ValueSize]byte(value[1:]), true, nil
+}
+
+// ValueAt returns the value of the key once the block at the height was executed. The latest ReorgDepth blocks are
+// reverted from the diffs kept by the queue, the older ones from the history database if enabled.
+// The queue shall be locked for reading.
+func (queue *Queue) ValueAt(key [verkle.KeySize]byte, height uint) ([ValueSize]byte, error) {
+	latest := queue.Header.Height
+	if height > latest {
+		return [ValueSize]byte{}, fmt.Errorf("%w: %d, the latest height is %d", ErrHeightNotReached, height, latest)
+	}
+	current := func() [ValueSize]byte {
+		if value, found := queue.Header.KV.Get(key); found {
+			return value
+		}
+		return defaultValue()
+	}
+	// The diff of the state at the height H keeps the accesses of the block H + 1, with the values before it.
+	if len(queue.History) != 0 && height >= queue.StartHeight() {
+		for _, state := range queue.History {
+			if state.Height < height {
+				continue
+			}
+			for _, elem := range state.Access.Elements {
+				if elem.Key != key {
+					continue
+				}
+				if !elem.OldValueExists {
+					return defaultValue(), nil
+				}
+				return elem.OldValue, nil
+			}
+		}
+		return current(), nil
+	}
+	if HistoryPath == "" {
+		return [ValueSize]byte{}, fmt.Errorf("%w: %d, the latest %d blocks are kept", ErrHistoryUnavailable, height, len(queue.History))
+	}
+	value, written, err := historyValueAt(key, height, latest)
+	if err != nil {
+		return [ValueSize]byte{}, err
+	}
+	if !written {
+		return current(), nil
+	}
+	return value, nil
+}
@@ -0,0 +1,253 @@
+package stateless
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/holiman/uint256"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/brc20"
+)
+
+const holdersSuffix = ".holders"
+
+// Holder is a pkscript with a positive overall balance of a tick.
+type Holder struct {
+	Pkscript       string `json:"pkscript"`
+	OverallBalance string `json:"overallBalance"`
+}
+
+// holderChange is the overall balance of a holder before a block, which undoes the block if it's executed again
+// after a reorg. An empty Previous didn't hold the tick.
+type holderChange struct {
+	Height   uint   `json:"height"`
+	Tick     string `json:"tick"`
+	Pkscript string `json:"pkscript"`
+	Previous string `json:"previous"`
+}
+
+// holdersState is the stored index of the holders, whose balances are decimal.
+type holdersState struct {
+	// The first block observed by the index, whose earlier holders are missing.
+	FromHeight uint                         `json:"fromHeight"`
+	Height     uint                         `json:"height"`
+	Ticks      map[string]map[string]string `json:"ticks"`
+	Journal    []holderChange               `json:"journal"`
+}
+
+var holders struct {
+	sync.Mutex
+	fromHeight uint
+	height     uint
+	ticks      map[string]map[ord.Pkscript]*uint256.Int
+	journal    []holderChange
+	// The holders of each tick sorted by their balances, dropped once the tick changes.
+	sorted map[string][]Holder
+	// The ticks held by each pkscript, the reverse of ticks, since the state keys the balances by the tick first.
+	pkscripts map[ord.Pkscript]map[string]bool
+}
+
+// setHolder updates the overall balance of the pkscript, where nil or zero removes it from the holders.
+func setHolder(tick string, pkscript ord.Pkscript, balance *uint256.Int) {
+	delete(holders.sorted, tick)
+	if balance == nil || balance.IsZero() {
+		delete(holders.ticks[tick], pkscript)
+		if len(holders.ticks[tick]) == 0 {
+			delete(holders.ticks, tick)
+		}
+		delete(holders.pkscripts[pkscript], tick)
+		if len(holders.pkscripts[pkscript]) == 0 {
+			delete(holders.pkscripts, pkscript)
+		}
+		return
+	}
+	if holders.ticks[tick] == nil {
+		holders.ticks[tick] = make(map[ord.Pkscript]*uint256.Int)
+	}
+	holders.ticks[tick][pkscript] = balance
+	if holders.pkscripts[pkscript] == nil {
+		holders.pkscripts[pkscript] = make(map[string]bool)
+	}
+	holders.pkscripts[pkscript][tick] = true
+}
+
+// recordHolders records the overall balances of the pkscripts observed by the block at the height of the header,
+// which has been flushed. A block executed again after a reorg replaces the old one.
+func recordHolders(h *Header, observed map[string]map[ord.Pkscript]bool) {
+	holders.Lock()
+	defer holders.Unlock()
+	if holders.ticks == nil {
+		holders.ticks = make(map[string]map[ord.Pkscript]*uint256.Int)
+		holders.pkscripts = make(map[ord.Pkscript]map[string]bool)
+		holders.fromHeight = h.Height
+	}
+	if holders.sorted == nil {
+		holders.sorted = make(map[string][]Holder)
+	}
+	rewindHolders(h.Height - 1)
+
+	ticks := make([]string, 0, len(observed))
+	for tick := range observed {
+		ticks = append(ticks, tick)
+	}
+	sort.Strings(ticks)
+	for _, tick := range ticks {
+		pkscripts := make([]string, 0, len(observed[tick]))
+		for pkscript := range observed[tick] {
+			pkscripts = append(pkscripts, string(pkscript))
+		}
+		sort.Strings(pkscripts)
+		for _, pkscript := range pkscripts {
+			balance := h.peekUInt256(brc20.GetTickPkscriptHash(tick, ord.Pkscript(pkscript), brc20.OverallBalancePkscript))
+			previous := holders.ticks[tick][ord.Pkscript(pkscript)]
+			if (previous == nil && balance.IsZero()) || (previous != nil && previous.Eq(balance)) {
+				continue
+			}
+			change := holderChange{Height: h.Height, Tick: tick, Pkscript: pkscript}
+			if previous != nil {
+				change.Previous = previous.Dec()
+			}
+			holders.journal = append(holders.journal, change)
+			setHolder(tick, ord.Pkscript(pkscript), balance)
+		}
+	}
+
+	// Only the blocks which may be reorganized are kept in the journal.
+	keep := 0
+	for keep < len(holders.journal) && holders.journal[keep].Height+ReorgDepth < h.Height {
+		keep++
+	}
+	holders.journal = holders.journal[keep:]
+	holders.height = h.Height
+}
+
+// rewindHolders reverts the balances of the blocks after the height by the journal, which must be locked.
+func rewindHolders(height uint) {
+	for len(holders.journal) > 0 && holders.journal[len(holders.journal)-1].Height > height {
+		change := holders.journal[len(holders.journal)-1]
+		var previous *uint256.Int
+		if change.Previous != "" {
+			previous = uint256.MustFromDecimal(change.Previous)
+		}
+		setHolder(change.Tick, ord.Pkscript(change.Pkscript), previous)
+		holders.journal = holders.journal[:len(holders.journal)-1]
+	}
+}
+
+func resetHolders() {
+	holders.Lock()
+	defer holders.Unlock()
+	holders.fromHeight, holders.height = 0, 0
+	holders.ticks, holders.journal, holders.sorted, holders.pkscripts = nil, nil, nil, nil
+}
+
+func storeHolders(height uint) error {
+	holders.Lock()
+	state := holdersState{
+		FromHeight: holders.fromHeight,
+		Height:     holders.height,
+		Ticks:      make(map[string]map[string]string, len(holders.ticks)),
+		Journal:    holders.journal,
+	}
+	for tick, balances := range holders.ticks {
+		state.Ticks[tick] = make(map[string]string, len(balances))
+		for pkscript, balance := range balances {
+			state.Ticks[tick][string(pkscript)] = balance.Dec()
+		}
+	}
+	data, err := json.Marshal(state)
+	holders.Unlock()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(cachePath, holdersFile(height)), data, 0666)
+}
+
+// loadHolders loads the holders stored along with the state cache at the height.
+// Without them, the index starts from the height and misses the holders not changed since.
+func loadHolders(height uint) {
+	holders.Lock()
+	defer holders.Unlock()
+	var state holdersState
+	data, err := os.ReadFile(filepath.Join(cachePath, holdersFile(height)))
+	if err == nil {
+		err = json.Unmarshal(data, &state)
+	}
+	if err == nil && state.Height != height {
+		err = fmt.Errorf("the holders are at the height %d", state.Height)
+	}
+	holders.ticks = make(map[string]map[ord.Pkscript]*uint256.Int)
+	holders.sorted = make(map[string][]Holder)
+	holders.pkscripts = make(map[ord.Pkscript]map[string]bool)
+	if err != nil {
+		log.Printf("The holders of the ticks start from the height %d, since they aren't cached: %v", height, err)
+		holders.fromHeight, holders.height, holders.journal = height, height, nil
+		return
+	}
+	for tick, balances := range state.Ticks {
+		for pkscript, balance := range balances {
+			value, err := uint256.FromDecimal(balance)
+			if err == nil {
+				setHolder(tick, ord.Pkscript(pkscript), value)
+			}
+		}
+	}
+	holders.fromHeight, holders.height, holders.journal = state.FromHeight, state.Height, state.Journal
+}
+
+func holdersFile(height uint) string {
+	return fmt.Sprintf("%d%s", height, holdersSuffix)
+}
+
+// TickHolders returns the holders of the tick sorted by their overall balances descending, along with the total
+// number of the holders, the height of the index and the first height it observed.
+func TickHolders(tick string, offset int, limit int) ([]Holder, int, uint, uint) {
+	holders.Lock()
+	defer holders.Unlock()
+	sorted, found := holders.sorted[tick]
+	if !found {
+		balances := holders.ticks[tick]
+		pkscripts := make([]ord.Pkscript, 0, len(balances))
+		for pkscript := range balances {
+			pkscripts = append(pkscripts, pkscript)
+		}
+		sort.Slice(pkscripts, func(i, j int) bool {
+			if c := balances[pkscripts[i]].Cmp(balances[pkscripts[j]]); c != 0 {
+				return c > 0
+			}
+			return strings.Compare(string(pkscripts[i]), string(pkscripts[j])) < 0
+		})
+		sorted = make([]Holder, len(pkscripts))
+		for i, pkscript := range pkscripts {
+			sorted[i] = Holder{Pkscript: string(pkscript), OverallBalance: balances[pkscript].Dec()}
+		}
+		if holders.sorted != nil {
+			holders.sorted[tick] = sorted
+		}
+	}
+	total := len(sorted)
+	offset = min(offset, total)
+	return sorted[offset:min(offset+limit, total)], total, holders.height, holders.fromHeight
+}
+
+// PkscriptTicks returns the ticks held by the pkscript in the alphabetical order, along with the total number of
+// the ticks, the height of the index and the first height it observed.
+func PkscriptTicks(pkscript string, offset int, limit int) ([]string, int, uint, uint) {
+	holders.Lock()
+	defer holders.Unlock()
+	ticks := make([]string, 0, len(holders.pkscripts[ord.Pkscript(pkscript)]))
+	for tick := range holders.pkscripts[ord.Pkscript(pkscript)] {
+		ticks = append(ticks, tick)
+	}
+	sort.Strings(ticks)
+	total := len(ticks)
+	offset = min(offset, total)
+	return ticks[offset:min(offset+limit, total)], total, holders.height, holders.fromHeight
+}
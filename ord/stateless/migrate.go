@@ -0,0 +1,302 @@
+package stateless
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"github.com/ethereum/go-verkle"
+	"github.com/holiman/uint256"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord/brc20"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/getter"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/protocol"
+)
+
+// The key layout of the state is the schema version of its keys, see brc20.SchemaVersion. It's recorded along with
+// the state caches, and a state stored before the layout was recorded is of the layout 1.
+const layoutFile = "state.layout"
+
+// Migration rewrites the key-values of the state from the layout From to the next one, e.g. for new location IDs,
+// a new hash or new tick lengths. Rewrite walks the old keys by Range, writes the new ones and deletes the old ones,
+// which are flushed into the tree once it returns.
+type Migration struct {
+	Name    string
+	From    int
+	Rewrite func(state protocol.KVStorage) error
+}
+
+// Migrations holds the registered migrations by the layouts they apply to.
+var Migrations = make(map[int]Migration)
+
+// MigrationRecord is a migration applied to the state, which the checkpoints after it publish.
+type MigrationRecord struct {
+	Name   string `json:"name"`
+	From   int    `json:"from"`
+	To     int    `json:"to"`
+	Height uint   `json:"height"`
+	// Base64 of the commitment of the migrated state.
+	Commitment string `json:"commitment"`
+	// The key scheme the migration rewrote the state to, only set by the migrations of the key scheme.
+	KeyScheme string `json:"keyScheme,omitempty"`
+}
+
+// StateLayout is the key layout of the state, along with the migrations producing it from the older layouts.
+type StateLayout struct {
+	Version int `json:"version"`
+	// The key scheme of the state, see protocol.KeySchemeFlag, empty for the legacy one.
+	KeyScheme  string            `json:"keyScheme,omitempty"`
+	Migrations []MigrationRecord `json:"migrations"`
+	// The protocols other than BRC-20 whose namespaces the state holds, which executed every block of the state
+	// since their activations, see Header.CheckModules. None for a layout stored before the protocols were recorded,
+	// whose state is taken to have executed none of them.
+	Modules []string `json:"modules,omitempty"`
+}
+
+var layout struct {
+	sync.Mutex
+	StateLayout
+}
+
+// resetLayout starts a fresh state in the layout of the rules.
+func resetLayout() {
+	layout.Lock()
+	defer layout.Unlock()
+	layout.StateLayout = StateLayout{Version: brc20.SchemaVersion, KeyScheme: protocol.KeySchemeFlag(protocol.Keys), Migrations: make([]MigrationRecord, 0)}
+	for name := range protocol.Modules() {
+		layout.Modules = append(layout.Modules, name)
+	}
+	slices.Sort(layout.Modules)
+}
+
+// loadLayout loads the layout stored along with the state caches, the layout 1 if none is stored.
+func loadLayout() {
+	layout.Lock()
+	defer layout.Unlock()
+	layout.StateLayout = StateLayout{Version: 1, Migrations: make([]MigrationRecord, 0)}
+	data, err := os.ReadFile(filepath.Join(cachePath, layoutFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return
+	}
+	if err == nil {
+		err = json.Unmarshal(data, &layout.StateLayout)
+	}
+	if err != nil {
+		panic(fmt.Errorf("failed to read the layout of the state: %v", err))
+	}
+}
+
+func storeLayout() error {
+	layout.Lock()
+	data, err := json.Marshal(layout.StateLayout)
+	layout.Unlock()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(cachePath, layoutFile), data, 0666)
+}
+
+// CurrentLayout returns the key layout of the loaded state.
+func CurrentLayout() StateLayout {
+	layout.Lock()
+	defer layout.Unlock()
+	current := layout.StateLayout
+	current.Migrations = append(make([]MigrationRecord, 0, len(current.Migrations)), current.Migrations...)
+	return current
+}
+
+// CheckModules records the registered protocols newly enabled on the state, unless the state executed a block since
+// the activation of a protocol without it: the namespace of the protocol would miss what the block claimed. Such a
+// protocol is to be activated after the height of the state, backfilled, see Header.Backfill, or the state is to be
+// reindexed. The protocols no longer enabled are dropped, as they miss the next blocks. A layout stored before the
+// protocols were recorded holds none of them, as nothing tells which ones the state was indexed along with.
+func (h *Header) CheckModules() error {
+	layout.Lock()
+	defer layout.Unlock()
+	modules := protocol.Modules()
+	enabled := make([]string, 0, len(modules))
+	for name, activation := range modules {
+		if !slices.Contains(layout.Modules, name) && activation <= h.Height {
+			return fmt.Errorf("the protocol %s is enabled on the state at height %d without its blocks since its activation height %d, activate it after the height, backfill it or reindex the state",
+				name, h.Height, activation)
+		}
+		enabled = append(enabled, name)
+	}
+	slices.Sort(enabled)
+	layout.Modules = enabled
+	return nil
+}
+
+// Backfill executes the blocks from the height from up to the height of the state through the protocol name alone,
+// into its namespace, see protocol.ExecModule, and records it among the protocols of the state, so that a protocol
+// enabled on a state past its activation takes no reindex. The keys of the other protocols, BRC-20 included, are left
+// as they are, so the protocol shall have executed no block of the state before. The header shall have no block
+// pending, and the backfilled state is stored as by Migrate.
+func (h *Header) Backfill(ordGetter getter.OrdGetter, name string, from uint) error {
+	if len(h.IntermediateKV) != 0 || len(h.deleted) != 0 {
+		return fmt.Errorf("the state can't be backfilled with the block %d pending", h.Height+1)
+	}
+	activation, found := protocol.Modules()[name]
+	if !found {
+		return fmt.Errorf("the protocol %s isn't enabled", name)
+	}
+	if from > activation {
+		return fmt.Errorf("the protocol %s is activated at height %d, its blocks from %d would be missed", name, activation, from)
+	}
+	layout.Lock()
+	defer layout.Unlock()
+	if slices.Contains(layout.Modules, name) {
+		return fmt.Errorf("the protocol %s is recorded on the state already", name)
+	}
+	log.Printf("Backfilling the protocol %s on the state at height %d from the block %d", name, h.Height, from)
+	for height := from; height <= h.Height; height++ {
+		ots, err := ordGetter.GetOrdTransfers(height)
+		if err != nil {
+			return fmt.Errorf("failed to get the transfers of the block %d: %w", height, err)
+		}
+		if err := protocol.ExecModule(h, name, ots, height); err != nil {
+			return err
+		}
+		h.recordPreImages()
+		if _, err := h.flush(NodeResolveFn); err != nil {
+			return fmt.Errorf("failed to backfill the block %d: %w", height, err)
+		}
+	}
+	if err := h.settle(); err != nil {
+		return err
+	}
+	h.migrated = true
+	layout.Modules = append(layout.Modules, name)
+	slices.Sort(layout.Modules)
+	commitment := h.Root.Commit().Bytes()
+	log.Printf("Backfilled the protocol %s, the commitment is %s", name, base64.StdEncoding.EncodeToString(commitment[:]))
+	return nil
+}
+
+// Migrate rewrites the state up to the layout target by the migrations, one layout after another, and returns those
+// applied. The header shall have no block pending. The migrated state isn't stored, but the next StoreHeader stores
+// a full state cache, since the migrations aren't recorded by the incremental ones.
+func (h *Header) Migrate(target int) ([]MigrationRecord, error) {
+	if len(h.IntermediateKV) != 0 || len(h.deleted) != 0 {
+		return nil, fmt.Errorf("the state can't be migrated with the block %d pending", h.Height+1)
+	}
+	layout.Lock()
+	defer layout.Unlock()
+	if layout.Version > target {
+		return nil, fmt.Errorf("the state is of the layout %d, newer than %d", layout.Version, target)
+	}
+	applied := make([]MigrationRecord, 0)
+	for layout.Version < target {
+		m, found := Migrations[layout.Version]
+		if !found {
+			return applied, fmt.Errorf("no migration from the layout %d", layout.Version)
+		}
+		log.Printf("Migrating the state at height %d from the layout %d by %s", h.Height, m.From, m.Name)
+		if err := m.Rewrite(h); err != nil {
+			h.Access = AccessList{}
+			h.IntermediateKV = KeyValueMap{}
+			h.deleted = nil
+			return applied, fmt.Errorf("failed to migrate the layout %d by %s: %v", m.From, m.Name, err)
+		}
+		if _, err := h.flush(NodeResolveFn); err != nil {
+			return applied, err
+		}
+		if err := h.settle(); err != nil {
+			return applied, err
+		}
+		h.migrated = true
+		commitment := h.Root.Commit().Bytes()
+		record := MigrationRecord{
+			Name:       m.Name,
+			From:       m.From,
+			To:         m.From + 1,
+			Height:     h.Height,
+			Commitment: base64.StdEncoding.EncodeToString(commitment[:]),
+		}
+		layout.Version = record.To
+		layout.Migrations = append(layout.Migrations, record)
+		applied = append(applied, record)
+		log.Printf("Migrated the state to the layout %d, the commitment is %s", record.To, record.Commitment)
+	}
+	return applied, nil
+}
+
+// MigrateKeyScheme rewrites every key of the state from the key scheme of its layout to the scheme to, and returns the
+// migration applied. The keys are hashes, so the stems are told by the preimages, and a key whose preimage isn't among
+// them fails the migration, leaving the state as it was. The header shall have no block pending, and the migrated state
+// is stored as by Migrate.
+func (h *Header) MigrateKeyScheme(to protocol.KeyScheme, preImages []protocol.PreImage) (MigrationRecord, error) {
+	if len(h.IntermediateKV) != 0 || len(h.deleted) != 0 {
+		return MigrationRecord{}, fmt.Errorf("the state can't be migrated with the block %d pending", h.Height+1)
+	}
+	layout.Lock()
+	defer layout.Unlock()
+	from, err := protocol.KeySchemeOf(layout.KeyScheme)
+	if err != nil {
+		return MigrationRecord{}, err
+	}
+	if from.Name() == to.Name() {
+		return MigrationRecord{}, fmt.Errorf("the state is of the key scheme %s already", to.Name())
+	}
+	stems := make(map[[verkle.StemSize]byte][verkle.StemSize]byte, len(preImages))
+	for _, p := range preImages {
+		stems[p.StemOf(from)] = p.StemOf(to)
+	}
+	moved := make(map[[verkle.KeySize]byte][]byte)
+	unknown := make(map[[verkle.StemSize]byte]bool)
+	if err := h.Range(nil, func(key []byte, value []byte) bool {
+		stem := [verkle.StemSize]byte(key[:verkle.StemSize])
+		if _, found := stems[stem]; !found {
+			unknown[stem] = true
+		}
+		moved[[verkle.KeySize]byte(key)] = bytes.Clone(value)
+		return true
+	}); err != nil {
+		return MigrationRecord{}, err
+	}
+	if len(unknown) != 0 {
+		return MigrationRecord{}, fmt.Errorf("the preimages of %d stems of the state are unknown", len(unknown))
+	}
+
+	log.Printf("Migrating the state at height %d from the key scheme %s to %s", h.Height, from.Name(), to.Name())
+	for key, value := range moved {
+		stem := stems[[verkle.StemSize]byte(key[:verkle.StemSize])]
+		newKey := append(stem[:], key[verkle.StemSize])
+		if err := h.Delete(key[:]); err == nil {
+			err = h.InsertUInt256(newKey, new(uint256.Int).SetBytes(value))
+		}
+		if err != nil {
+			h.Access = AccessList{}
+			h.IntermediateKV = KeyValueMap{}
+			h.deleted = nil
+			return MigrationRecord{}, fmt.Errorf("failed to migrate the key %x: %v", key, err)
+		}
+	}
+	if _, err := h.flush(NodeResolveFn); err != nil {
+		return MigrationRecord{}, err
+	}
+	if err := h.settle(); err != nil {
+		return MigrationRecord{}, err
+	}
+	h.migrated = true
+	commitment := h.Root.Commit().Bytes()
+	record := MigrationRecord{
+		Name:       "key-scheme-" + to.Name(),
+		From:       layout.Version,
+		To:         layout.Version,
+		Height:     h.Height,
+		Commitment: base64.StdEncoding.EncodeToString(commitment[:]),
+		KeyScheme:  to.Name(),
+	}
+	layout.KeyScheme = protocol.KeySchemeFlag(to)
+	layout.Migrations = append(layout.Migrations, record)
+	log.Printf("Migrated the state to the key scheme %s, the commitment is %s", to.Name(), record.Commitment)
+	return record, nil
+}
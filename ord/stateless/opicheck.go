@@ -0,0 +1,20 @@
+package stateless
+
+import (
+	"github.com/RiemaLabs/modular-indexer-committee/crosscheck"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/brc20"
+)
+
+// The cross-check of the event hashes of every executed block against OPI. Nil disables it.
+var OPICheck *crosscheck.OPIChecker = nil
+
+// observeOPICheck hashes the events of the paged block as OPI does, the decimals being read from the flushed state.
+func observeOPICheck(header *Header, events []brc20.Event) {
+	if OPICheck == nil {
+		return
+	}
+	hash := brc20.OPIBlockEventHash(events, func(tick string) uint64 {
+		return header.peekUInt256(brc20.GetTickHash(tick, brc20.Decimals)).Uint64()
+	})
+	OPICheck.Observe(header.Height, hash)
+}
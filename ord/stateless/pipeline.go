@@ -0,0 +1,129 @@
+package stateless
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-verkle"
+
+	"github.com/RiemaLabs/modular-indexer-committee/internal/metrics"
+)
+
+// Pipeline toggles the pipelined paging of the header. While enabled, the writes of a paged block are inserted into
+// the tree in the background, overlapping the fetch and the execution of the next block, which read the flushed
+// key-values instead of the tree. Every reader of the tree shall Settle the header first, so the pipeline is only
+// meant for the catch-up, where nothing else reads the tree. Disabling the pipeline settles the header.
+func (h *Header) Pipeline(enabled bool) {
+	if !enabled {
+		h.Settle()
+	}
+	h.pipelined = enabled
+}
+
+// Settle waits until the writes of the paged blocks are inserted into the tree.
+func (h *Header) Settle() {
+	if h.inserting != nil {
+		<-h.inserting
+		h.inserting = nil
+	}
+}
+
+// settle is Settle returning the failure of the insertion, if any.
+func (h *Header) settle() error {
+	h.Settle()
+	return h.treeErr
+}
+
+// ErrTreeInsertion is returned by the paging of a block whose writes fail to be inserted into the tree. The tree then
+// misses them, so every later paging fails as well and the state must be reloaded from the latest store.
+var ErrTreeInsertion = errors.New("failed to insert the writes into the tree")
+
+// insertTree inserts the writes of a block into the tree, in the background if the header is pipelined.
+// Unless pipelined, the tree is committed right away, since the block is read after it anyway. The failure of a
+// background insertion is returned by the insertion of the next block.
+func (h *Header) insertTree(writes KeyValueMap, nodeResolverFn verkle.NodeResolverFn) error {
+	h.Settle()
+	if h.treeErr != nil {
+		return h.treeErr
+	}
+	if !h.pipelined {
+		unlock := LockTree()
+		defer unlock()
+		if err := insertWrites(h.Root, writes, nodeResolverFn); err != nil {
+			h.treeErr = err
+			return err
+		}
+		started := time.Now()
+		h.Root.Commit()
+		metrics.CommitDuration.Observe(time.Since(started).Seconds())
+		return nil
+	}
+	inserting := make(chan struct{})
+	h.inserting = inserting
+	go func() {
+		defer close(inserting)
+		defer LockTree()()
+		// Settle waits for the close, so the error is set before it's read.
+		h.treeErr = insertWrites(h.Root, writes, nodeResolverFn)
+	}()
+	return nil
+}
+
+// rebuildTree rebuilds the tree from the flushed key-values, since the keys can't be deleted from the verkle tree,
+// like the recovery from a reorg does.
+func (h *Header) rebuildTree(nodeResolverFn verkle.NodeResolverFn) error {
+	h.Settle()
+	if h.treeErr != nil {
+		return h.treeErr
+	}
+	root := verkle.New()
+	var err error
+	h.KV.Range(func(key [verkle.KeySize]byte, value [ValueSize]byte) bool {
+		if err = root.Insert(key[:], value[:], nodeResolverFn); err != nil {
+			err = fmt.Errorf("%w: key %x: %v", ErrTreeInsertion, key, err)
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		h.treeErr = err
+		return err
+	}
+	started := time.Now()
+	// The call of Commit is necessary to refresh the root commit.
+	root.Commit()
+	metrics.CommitDuration.Observe(time.Since(started).Seconds())
+	h.Root = root
+	return nil
+}
+
+// insertWrites inserts the writes grouped by their stems, so the commitment of each leaf is updated once
+// for all of its written values rather than once per value.
+func insertWrites(root verkle.VerkleNode, writes KeyValueMap, nodeResolverFn verkle.NodeResolverFn) error {
+	internal, isInternal := root.(*verkle.InternalNode)
+	if !isInternal {
+		for key, value := range writes {
+			if err := root.Insert(key[:], value[:], nodeResolverFn); err != nil {
+				return fmt.Errorf("%w: key %x: %v", ErrTreeInsertion, key, err)
+			}
+		}
+		return nil
+	}
+	stems := make(map[[verkle.StemSize]byte][][]byte)
+	for key, value := range writes {
+		stem := [verkle.StemSize]byte(key[:verkle.StemSize])
+		values, found := stems[stem]
+		if !found {
+			values = make([][]byte, verkle.NodeWidth)
+			stems[stem] = values
+		}
+		values[key[verkle.StemSize]] = value[:]
+	}
+	for stem, values := range stems {
+		if err := internal.InsertValuesAtStem(stem[:], values, nodeResolverFn); err != nil {
+			return fmt.Errorf("%w: stem %x: %v", ErrTreeInsertion, stem, err)
+		}
+	}
+	return nil
+}
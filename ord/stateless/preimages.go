@@ -0,0 +1,205 @@
+package stateless
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-verkle"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord/brc20"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/protocol"
+)
+
+// The directory of the LevelDB database keeping the preimages of the stems written to the state. Empty disables it.
+var PreImagesPath = ""
+
+// ErrPreImagesUnavailable is returned while the preimages aren't kept.
+var ErrPreImagesUnavailable = errors.New("the preimages of the keys aren't kept")
+
+// Key layout of the preimages database
+// Stem: "p" + stem, Value: the preimage of the stem encoded as JSON, see protocol.KeyPreImage
+var preImagesPrefix = []byte("p")
+
+var (
+	preImagesMu sync.Mutex
+	preImagesDB *leveldb.DB
+)
+
+func openPreImages() (*leveldb.DB, error) {
+	if preImagesDB != nil {
+		return preImagesDB, nil
+	}
+	db, err := leveldb.OpenFile(PreImagesPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	preImagesDB = db
+	return db, nil
+}
+
+// ClosePreImages closes the preimages database.
+func ClosePreImages() error {
+	preImagesMu.Lock()
+	defer preImagesMu.Unlock()
+	if preImagesDB == nil {
+		return nil
+	}
+	err := preImagesDB.Close()
+	preImagesDB = nil
+	return err
+}
+
+// recordPreImages keeps the preimages of the stems written by the block being paged, which were recorded while the
+// stems were derived. The preimages are never removed: a stem always has the same preimage, even after a reorg.
+func (h *Header) recordPreImages() {
+	if PreImagesPath == "" {
+		return
+	}
+	recorded := protocol.TakePreImages()
+	batch := new(leveldb.Batch)
+	written := make(map[[verkle.StemSize]byte]bool)
+	for key := range h.IntermediateKV {
+		stem := [verkle.StemSize]byte(key[:verkle.StemSize])
+		if written[stem] {
+			continue
+		}
+		written[stem] = true
+		p, found := recorded[stem]
+		if !found {
+			continue
+		}
+		data, err := json.Marshal(p)
+		if err != nil {
+			panic(err)
+		}
+		batch.Put(prefixed(preImagesPrefix, stem[:]), data)
+	}
+	if batch.Len() == 0 {
+		return
+	}
+	preImagesMu.Lock()
+	defer preImagesMu.Unlock()
+	db, err := openPreImages()
+	if err != nil {
+		panic(fmt.Errorf("failed to open the preimages database %s: %v", PreImagesPath, err))
+	}
+	if err := db.Write(batch, nil); err != nil {
+		panic(fmt.Errorf("failed to write the preimages database: %v", err))
+	}
+}
+
+// DecodedKey is a key of the state interpreted by the preimage of its stem.
+type DecodedKey struct {
+	Key string `json:"key"`
+	// The preimage of the stem, nil if it isn't kept.
+	PreImage   *protocol.KeyPreImage `json:"preImage"`
+	LocationID byte                  `json:"locationID"`
+	// The name of the value at the location, and the slot of the value if it takes several, for the keys of BRC-20.
+	Location string `json:"location,omitempty"`
+	Slot     int    `json:"slot,omitempty"`
+}
+
+// DecodeKey returns the preimage of the stem of the key, which has a nil preimage if it isn't kept.
+func DecodeKey(key []byte) (DecodedKey, error) {
+	if len(key) != verkle.KeySize {
+		return DecodedKey{}, fmt.Errorf("the key %x isn't of %d bytes", key, verkle.KeySize)
+	}
+	if PreImagesPath == "" {
+		return DecodedKey{}, ErrPreImagesUnavailable
+	}
+	decoded := DecodedKey{Key: hex.EncodeToString(key), LocationID: key[verkle.StemSize]}
+	preImagesMu.Lock()
+	defer preImagesMu.Unlock()
+	db, err := openPreImages()
+	if err != nil {
+		return DecodedKey{}, err
+	}
+	data, err := db.Get(prefixed(preImagesPrefix, key[:verkle.StemSize]), nil)
+	if errors.Is(err, leveldb.ErrNotFound) {
+		return decoded, nil
+	}
+	if err != nil {
+		return DecodedKey{}, err
+	}
+	var p protocol.KeyPreImage
+	if err := json.Unmarshal(data, &p); err != nil {
+		return DecodedKey{}, fmt.Errorf("invalid preimage of the key %x: %v", key, err)
+	}
+	decoded.PreImage = &p
+	if p.Domain == brc20.Name {
+		decoded.Location, decoded.Slot, _ = brc20.LocationOf(p.KeySpace, key[verkle.StemSize])
+	}
+	return decoded, nil
+}
+
+// RangePreImages iterates the kept preimages by their stems, until fn returns false.
+func RangePreImages(fn func(stem [verkle.StemSize]byte, p protocol.KeyPreImage) bool) error {
+	if PreImagesPath == "" {
+		return ErrPreImagesUnavailable
+	}
+	preImagesMu.Lock()
+	defer preImagesMu.Unlock()
+	db, err := openPreImages()
+	if err != nil {
+		return err
+	}
+	iter := db.NewIterator(util.BytesPrefix(preImagesPrefix), nil)
+	defer iter.Release()
+	for iter.Next() {
+		var p protocol.KeyPreImage
+		if err := json.Unmarshal(iter.Value(), &p); err != nil {
+			return fmt.Errorf("invalid preimage of the stem %x: %v", iter.Key()[len(preImagesPrefix):], err)
+		}
+		if !fn([verkle.StemSize]byte(iter.Key()[len(preImagesPrefix):]), p) {
+			break
+		}
+	}
+	return iter.Error()
+}
+
+// MigratePreImages migrates the state to the key scheme by the kept preimages, see Header.MigrateKeyScheme, and keys
+// the preimages by the stems of the scheme.
+func (h *Header) MigratePreImages(to protocol.KeyScheme) (MigrationRecord, error) {
+	var preImages []protocol.PreImage
+	kept := make(map[[verkle.StemSize]byte]protocol.KeyPreImage)
+	if err := RangePreImages(func(_ [verkle.StemSize]byte, p protocol.KeyPreImage) bool {
+		kept[p.PreImage().StemOf(to)] = p
+		preImages = append(preImages, p.PreImage())
+		return true
+	}); err != nil {
+		return MigrationRecord{}, err
+	}
+	record, err := h.MigrateKeyScheme(to, preImages)
+	if err != nil {
+		return MigrationRecord{}, err
+	}
+
+	preImagesMu.Lock()
+	defer preImagesMu.Unlock()
+	db, err := openPreImages()
+	if err != nil {
+		return record, err
+	}
+	batch := new(leveldb.Batch)
+	iter := db.NewIterator(util.BytesPrefix(preImagesPrefix), nil)
+	for iter.Next() {
+		batch.Delete(append([]byte(nil), iter.Key()...))
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		return record, err
+	}
+	for stem, p := range kept {
+		data, err := json.Marshal(p)
+		if err != nil {
+			return record, err
+		}
+		batch.Put(prefixed(preImagesPrefix, stem[:]), data)
+	}
+	return record, db.Write(batch, nil)
+}
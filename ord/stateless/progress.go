@@ -0,0 +1,90 @@
+package stateless
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/RiemaLabs/modular-indexer-committee/internal/metrics"
+)
+
+// The deadline of executing a new block while serving. Zero disables it.
+// The catch-up and the reorg recovery are never interrupted.
+var BlockDeadline time.Duration = 0
+
+// ErrDeadlineExceeded is returned if a block isn't executed before the deadline, after rolling back the partial block.
+var ErrDeadlineExceeded = errors.New("the execution of the block exceeds the deadline")
+
+// Progress is the progress of the block being executed, or of the last executed block.
+type Progress struct {
+	Executing bool
+	Height    uint
+	Processed int
+	Total     int
+	Started   time.Time
+	Elapsed   time.Duration
+}
+
+var progress struct {
+	sync.Mutex
+	Progress
+}
+
+// CurrentProgress returns a snapshot of the execution progress.
+func CurrentProgress() Progress {
+	progress.Lock()
+	defer progress.Unlock()
+	p := progress.Progress
+	if p.Executing {
+		p.Elapsed = time.Since(p.Started)
+	}
+	return p
+}
+
+func startProgress(blockHeight uint, total int) {
+	progress.Lock()
+	defer progress.Unlock()
+	progress.Progress = Progress{Executing: true, Height: blockHeight, Total: total, Started: time.Now()}
+	metrics.BlockTransfers.Set(float64(total))
+	metrics.BlockTransfersProcessed.Set(0)
+}
+
+func advanceProgress(n int) {
+	progress.Lock()
+	defer progress.Unlock()
+	progress.Processed += n
+	metrics.BlockTransfersProcessed.Set(float64(progress.Processed))
+}
+
+func finishProgress(exceeded bool) {
+	progress.Lock()
+	defer progress.Unlock()
+	progress.Executing = false
+	progress.Elapsed = time.Since(progress.Started)
+	if exceeded {
+		metrics.BlockDeadlineExceeded.Inc()
+	}
+}
+
+// blockDeadline returns the deadline of a block starting now, zero if there is none.
+func blockDeadline() time.Time {
+	if BlockDeadline <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(BlockDeadline)
+}
+
+func exceeded(deadline time.Time) bool {
+	return !deadline.IsZero() && time.Now().After(deadline)
+}
+
+// rollbackBlock discards the partial execution of the block, which is only flushed by Paging.
+func rollbackBlock(header *Header) {
+	header.Access = AccessList{}
+	header.IntermediateKV = KeyValueMap{}
+	header.categories = nil
+	header.ticks = nil
+	header.deployers = nil
+	header.holders = nil
+	header.events, header.eventSeqs = nil, nil
+}
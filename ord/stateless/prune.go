@@ -0,0 +1,262 @@
+package stateless
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+
+	"github.com/RiemaLabs/modular-indexer-committee/archive"
+)
+
+// The defaults of the retention.
+const (
+	DefaultSnapshotBlocks = 2000
+	DefaultPruneInterval  = 600
+)
+
+// RetentionConfig bounds the history kept on the disk, by the number of the latest blocks whose history is kept.
+type RetentionConfig struct {
+	// The state caches older than the latest blocks are evicted, except the latest baseline and the diffs
+	// following it (default 2000).
+	SnapshotBlocks uint `json:"snapshotBlocks"`
+	// The witnesses of --witness older than the latest blocks are deleted, or moved to the archive if enabled.
+	// 0 keeps them all.
+	WitnessBlocks uint `json:"witnessBlocks"`
+	// The writes of the history database older than the latest blocks are deleted, so the values at the heights
+	// before are no longer served. 0 keeps them all.
+	HistoryBlocks uint `json:"historyBlocks"`
+	// The events of the events database older than the latest blocks are deleted. 0 keeps them all.
+	EventsBlocks uint `json:"eventsBlocks"`
+	// The interval in seconds to prune the history (default 600).
+	Interval int `json:"interval"`
+}
+
+func (cfg RetentionConfig) Validate() error {
+	if cfg.Interval < 0 {
+		return errors.New("the interval of the pruning must not be negative")
+	}
+	if cfg.HistoryBlocks != 0 && cfg.HistoryBlocks < ReorgDepth {
+		return fmt.Errorf("the history database must keep at least the %d blocks of the reorgs", ReorgDepth)
+	}
+	return nil
+}
+
+// Retention is the retention of the history on the disk.
+var Retention RetentionConfig
+
+var pruneMu sync.Mutex
+
+// PruneResult is the history deleted by a pruning.
+type PruneResult struct {
+	// The latest height the retention counts back from.
+	Height    uint `json:"height"`
+	Snapshots int  `json:"snapshots"`
+	Witnesses int  `json:"witnesses"`
+	// The numbers of the blocks deleted from the history and the events databases.
+	HistoryBlocks int `json:"historyBlocks"`
+	EventsBlocks  int `json:"eventsBlocks"`
+}
+
+// retainedFrom returns the first height of the latest blocks kept, 0 if all are kept.
+func retainedFrom(height, blocks uint) uint {
+	if blocks == 0 || height < blocks {
+		return 0
+	}
+	return height - blocks
+}
+
+// SnapshotEvictHeight returns the height before which the state caches are evicted.
+func SnapshotEvictHeight(height uint) uint {
+	blocks := Retention.SnapshotBlocks
+	if blocks == 0 {
+		blocks = DefaultSnapshotBlocks
+	}
+	return retainedFrom(height, blocks)
+}
+
+// Prune deletes the history on the disk older than the retention, counting back from the height of the latest block.
+func Prune(height uint) (PruneResult, error) {
+	pruneMu.Lock()
+	defer pruneMu.Unlock()
+	result := PruneResult{Height: height}
+	var err error
+	if result.Snapshots, err = pruneSnapshots(SnapshotEvictHeight(height)); err != nil {
+		return result, fmt.Errorf("failed to prune the state caches: %v", err)
+	}
+	if WitnessPath != "" {
+		if result.Witnesses, err = pruneWitnesses(retainedFrom(height, Retention.WitnessBlocks)); err != nil {
+			return result, fmt.Errorf("failed to prune the witnesses: %v", err)
+		}
+	}
+	if HistoryPath != "" {
+		if result.HistoryBlocks, err = pruneHistory(retainedFrom(height, Retention.HistoryBlocks)); err != nil {
+			return result, fmt.Errorf("failed to prune the history database: %v", err)
+		}
+	}
+	if EventsPath != "" {
+		if result.EventsBlocks, err = pruneEvents(retainedFrom(height, Retention.EventsBlocks)); err != nil {
+			return result, fmt.Errorf("failed to prune the events database: %v", err)
+		}
+	}
+	return result, nil
+}
+
+// RunPruning prunes the history at every interval until the context is done.
+func RunPruning(ctx context.Context, latestHeight func() uint) {
+	interval := time.Duration(Retention.Interval) * time.Second
+	if interval <= 0 {
+		interval = DefaultPruneInterval * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			result, err := Prune(latestHeight())
+			if err != nil {
+				log.Printf("Failed to prune the history: %v", err)
+			}
+			if result.Snapshots+result.Witnesses+result.HistoryBlocks+result.EventsBlocks != 0 {
+				log.Printf("Pruned %d state caches, %d witnesses, %d blocks of the history and %d blocks of the events before height %d",
+					result.Snapshots, result.Witnesses, result.HistoryBlocks, result.EventsBlocks, result.Height)
+			}
+		}
+	}
+}
+
+// removeOld deletes the file of the class, or moves it to the archive if enabled.
+func removeOld(class, path string) error {
+	if Archive != nil {
+		return Archive.Move(context.Background(), class, path)
+	}
+	return os.Remove(path)
+}
+
+// pruneSnapshots evicts the state caches before the height, except the latest baseline and the diffs following it.
+func pruneSnapshots(evictHeight uint) (int, error) {
+	baselines, _ := snapshots()
+	files, err := os.ReadDir(cachePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	pruned := 0
+	for _, file := range files {
+		if ext := filepath.Ext(file.Name()); ext == fileSuffix || ext == diffSuffix || ext == censusSuffix || ext == holdersSuffix {
+			heightString := strings.TrimSuffix(file.Name(), ext)
+			height, err := strconv.Atoi(heightString)
+			if err == nil && height < int(evictHeight) && evictable(file.Name(), uint(height), baselines) {
+				if err := removeOld(archive.ClassSnapshots, filepath.Join(cachePath, file.Name())); err != nil {
+					log.Printf("Failed to remove old file: %s, err: %v", file.Name(), err)
+					continue
+				}
+				pruned++
+			}
+		}
+	}
+	return pruned, nil
+}
+
+// pruneWitnesses deletes the witnesses of the blocks before the height.
+func pruneWitnesses(before uint) (int, error) {
+	if before == 0 {
+		return 0, nil
+	}
+	files, err := os.ReadDir(WitnessPath)
+	if err != nil {
+		return 0, err
+	}
+	pruned := 0
+	for _, file := range files {
+		height, err := strconv.ParseUint(strings.TrimSuffix(file.Name(), witnessSuffix), 10, 64)
+		if err != nil || filepath.Ext(file.Name()) != witnessSuffix || uint(height) >= before {
+			continue
+		}
+		if err := removeOld(archive.ClassWitnesses, filepath.Join(WitnessPath, file.Name())); err != nil {
+			return pruned, err
+		}
+		pruned++
+	}
+	return pruned, nil
+}
+
+// pruneHistory deletes the writes of the blocks before the height, which becomes the start of the kept history.
+func pruneHistory(before uint) (int, error) {
+	historyMu.Lock()
+	defer historyMu.Unlock()
+	db, err := openHistory()
+	if err != nil {
+		return 0, err
+	}
+	kept := historyKept
+	// The latest block is always kept, so the history keeps following the blocks.
+	if kept.Latest == 0 || before <= kept.Start {
+		return 0, nil
+	}
+	if before > kept.Latest {
+		before = kept.Latest
+	}
+	batch := new(leveldb.Batch)
+	if err := deleteHistory(db, batch, kept.Start, before-1); err != nil {
+		return 0, err
+	}
+	pruned := int(before - kept.Start)
+	kept.Start = before
+	if err := writeHistoryMeta(db, batch, kept); err != nil {
+		return 0, err
+	}
+	historyKept = kept
+	// The deleted writes are spread over the keys, so the whole database is compacted to free the disk.
+	return pruned, db.CompactRange(util.Range{})
+}
+
+// pruneEvents deletes the events of the blocks before the height.
+func pruneEvents(before uint) (int, error) {
+	if before == 0 {
+		return 0, nil
+	}
+	eventsMu.Lock()
+	defer eventsMu.Unlock()
+	db, err := openEvents()
+	if err != nil {
+		return 0, err
+	}
+	limit := prefixed(eventsPrefix, heightBytes(before))
+	batch := new(leveldb.Batch)
+	pruned := 0
+	iter := db.NewIterator(&util.Range{Start: eventsPrefix, Limit: limit}, nil)
+	for iter.Next() {
+		if err := indexBlock(batch, iter.Key(), iter.Value(), true); err != nil {
+			iter.Release()
+			return 0, err
+		}
+		batch.Delete(append([]byte(nil), iter.Key()...))
+		pruned++
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		return 0, err
+	}
+	if pruned == 0 {
+		return 0, nil
+	}
+	if err := db.Write(batch, nil); err != nil {
+		return 0, err
+	}
+	// The inscriptions of the pruned blocks are spread over the index, so it's compacted as a whole.
+	return pruned, db.CompactRange(util.Range{})
+}
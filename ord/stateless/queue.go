@@ -2,11 +2,14 @@ package stateless
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"log"
 	"sort"
 
+	"github.com/RiemaLabs/modular-indexer-committee/internal/tracing"
 	"github.com/RiemaLabs/modular-indexer-committee/ord"
 	"github.com/RiemaLabs/modular-indexer-committee/ord/getter"
 	goipa "github.com/crate-crypto/go-ipa"
@@ -15,6 +18,14 @@ import (
 	verkle "github.com/ethereum/go-verkle"
 )
 
+// The number of the latest blocks whose diffs are kept by the queue, which is the deepest reorg recovered automatically.
+// It's never less than ord.BitcoinConfirmations.
+var ReorgDepth uint = ord.BitcoinConfirmations
+
+// ErrReorgTooDeep is returned if the last common ancestor of a reorg is older than the blocks kept by the queue,
+// in which case the state has to be resynced from a snapshot.
+var ErrReorgTooDeep = errors.New("the reorg is deeper than the blocks kept by the queue")
+
 func (state DiffState) Copy() DiffState {
 	newElements := make([]TripleElement, len(state.Access.Elements))
 
@@ -28,10 +39,13 @@ func (state DiffState) Copy() DiffState {
 
 	newDiff := AccessList{Elements: newElements}
 	return DiffState{
-		Height:       state.Height,
-		Hash:         state.Hash,
-		Access:       newDiff,
-		VerkleCommit: state.VerkleCommit,
+		Height:          state.Height,
+		Hash:            state.Hash,
+		Access:          newDiff,
+		VerkleCommit:    state.VerkleCommit,
+		SecondaryCommit: state.SecondaryCommit,
+		EventCount:      state.EventCount,
+		TickCount:       state.TickCount,
 	}
 }
 
@@ -51,39 +65,81 @@ func (queue *Queue) Println() {
 }
 
 func (queue *Queue) Update(getter getter.OrdGetter, latestHeight uint) error {
+	return queue.UpdateContext(context.Background(), getter, latestHeight)
+}
+
+// UpdateContext executes the blocks up to the latest height. Once the context is done, the block being executed
+// is finished and the error of the context is returned.
+func (queue *Queue) UpdateContext(ctx context.Context, getter getter.OrdGetter, latestHeight uint) error {
 	queue.Lock()
 	defer queue.Unlock()
+	defer queue.advance()
 	curHeight := queue.Header.Height
 	for i := curHeight + 1; i <= latestHeight; i++ {
-		ordTransfer, err := getter.GetOrdTransfers(i)
-		if err != nil {
+		if err := ctx.Err(); err != nil {
 			return err
 		}
-		// Write to Diff
-		Exec(queue.Header, ordTransfer, i)
-		hash, err := getter.GetBlockHash(i - 1)
-		if err != nil {
+		if err := queue.updateBlock(getter, i); err != nil {
 			return err
 		}
-		newDiffState := DiffState{
-			Height:       i - 1,
-			Hash:         hash,
-			Access:       queue.Header.Access,
-			VerkleCommit: queue.Header.Root.Commit().Bytes(),
-		}
-		copy(queue.History[:], queue.History[1:])
-		queue.History[len(queue.History)-1] = newDiffState
+	}
+	return nil
+}
 
-		proof, err := generateProofFromUpdate(queue.Header, &newDiffState)
-		if err != nil {
-			return err
-		}
-		if proof != nil {
-			queue.LastStateProof = proof
-		}
+// updateBlock executes and pages the block at the height, traced by a span of the block.
+func (queue *Queue) updateBlock(getter getter.OrdGetter, i uint) (err error) {
+	ctx, span := tracing.Start(context.Background(), "block", tracing.Height(i))
+	defer func() {
+		tracing.End(span, err)
+	}()
+	_, fetchSpan := tracing.Start(ctx, "fetch", tracing.Height(i))
+	var hash, blockHash string
+	ordTransfer, err := getter.GetOrdTransfers(i)
+	if err == nil {
+		// The block is fetched entirely before it's executed, so a failure of the getter leaves the state untouched.
+		hash, err = getter.GetBlockHash(i - 1)
+	}
+	if err == nil {
+		blockHash, err = getter.GetBlockHash(i)
+	}
+	tracing.End(fetchSpan, err)
+	if err != nil {
+		return err
+	}
+	// Write to Diff
+	if err := execBlock(ctx, queue.Header, ordTransfer, i, blockDeadline()); err != nil {
+		return err
+	}
+	newDiffState := DiffState{
+		Height:          i - 1,
+		Hash:            hash,
+		Access:          queue.Header.Access,
+		VerkleCommit:    queue.Header.Root.Commit().Bytes(),
+		SecondaryCommit: queue.Header.SecondaryRoot(),
+		EventCount:      queue.Header.pagedEvents,
+		TickCount:       CurrentCensus(0).TotalTicks,
+	}
+	queue.History = append(queue.History, newDiffState)
+	if uint(len(queue.History)) > ReorgDepth {
+		queue.History = queue.History[uint(len(queue.History))-ReorgDepth:]
+	}
 
-		queue.Header.OrdTrans = ordTransfer
-		_ = queue.Header.Paging(getter, true, NodeResolveFn)
+	proof, err := generateProofFromUpdate(queue.Header, &newDiffState)
+	if err != nil {
+		return err
+	}
+	if proof != nil {
+		queue.LastStateProof = proof
+	}
+
+	queue.Header.OrdTrans = ordTransfer
+	if err := queue.Header.page(ctx, blockHash, NodeResolveFn); err != nil {
+		return err
+	}
+	if EvictInterval != 0 && i%EvictInterval == 0 {
+		if err := queue.Header.Evict(); err != nil {
+			log.Printf("Failed to evict the tree at height %d: %v", i, err)
+		}
 	}
 	return nil
 }
@@ -91,9 +147,10 @@ func (queue *Queue) Update(getter getter.OrdGetter, latestHeight uint) error {
 func Rollingback(header *Header, stateDiff *DiffState) (verkle.VerkleNode, [][]byte) {
 	var keys [][]byte
 	kvMap := make(KeyValueMap)
-	for k, v := range header.KV {
+	header.KV.Range(func(k [verkle.KeySize]byte, v [ValueSize]byte) bool {
 		kvMap[k] = v
-	}
+		return true
+	})
 
 	for _, elem := range stateDiff.Access.Elements {
 		keys = append(keys, elem.Key[:])
@@ -114,16 +171,37 @@ func Rollingback(header *Header, stateDiff *DiffState) (verkle.VerkleNode, [][]b
 	return rollback, keys
 }
 
-func (queue *Queue) Recovery(getter getter.OrdGetter, reorgHeight uint) error {
-	queue.Lock()
-	defer queue.Unlock()
+// invalidate drops the results of the reorganized blocks kept by the getter, if any.
+func invalidate(g getter.OrdGetter, reorgHeight uint) {
+	if invalidator, ok := g.(getter.Invalidator); ok {
+		invalidator.Invalidate(reorgHeight)
+	}
+}
+
+// checkReorg returns ErrReorgTooDeep unless the blocks from the reorgHeight on are kept in the history.
+func (queue *Queue) checkReorg(reorgHeight uint) error {
+	curHeight := queue.Header.Height
+	startHeight := queue.StartHeight()
+	// The diff of the block reorgHeight is kept by the state of the height reorgHeight - 1.
+	if reorgHeight <= startHeight || reorgHeight > curHeight {
+		return fmt.Errorf("%w: can't recover from the block %d with the blocks from %d to %d", ErrReorgTooDeep, reorgHeight, startHeight+1, curHeight)
+	}
+	return nil
+}
+
+// rollback reverts the state of the queue, which must be locked, to the block before the reorgHeight by the diffs kept
+// in the history, and returns the state of that block. The history from that block is left to the caller.
+func (queue *Queue) rollback(reorgHeight uint) (DiffState, error) {
 	curHeight := queue.Header.Height
 	startHeight := queue.StartHeight()
+	if err := queue.checkReorg(reorgHeight); err != nil {
+		return DiffState{}, err
+	}
+	log.Printf("Roll back %d blocks to the common ancestor %d", curHeight-reorgHeight+1, reorgHeight-1)
 
 	// Rollback to the reorgHeight - 1.
 	for i := curHeight - 1; i >= reorgHeight-1; i-- {
-		index := i - startHeight
-		pastState := queue.History[index]
+		pastState := queue.History[i-startHeight]
 
 		// Inner bug in go-verkle, doesn't work.
 		// for _, elem := range pastState.Diff.Elements {
@@ -135,90 +213,149 @@ func (queue *Queue) Recovery(getter getter.OrdGetter, reorgHeight uint) error {
 		// 		queue.Header.Root.Delete(elem.Key[:], NodeResolveFn)
 		// 	}
 		// }
-		// newRoot := queue.Header.Root
-		// newBytes := queue.Header.Root.Commit().Bytes()
-		// n := base64.StdEncoding.EncodeToString(newBytes[:])
 
+		queue.Header.rollbackSecondary(pastState.Access)
 		for _, elem := range pastState.Access.Elements {
+			queue.Header.updateDigest(elem.Key, elem.OldValue, elem.OldValueExists)
 			if elem.OldValueExists {
-				queue.Header.KV[elem.Key] = elem.OldValue
+				queue.Header.KV.Put(elem.Key, elem.OldValue)
 			} else {
-				delete(queue.Header.KV, elem.Key)
+				queue.Header.KV.Delete(elem.Key)
 			}
 		}
-		newRoot := verkle.New()
-		for k, v := range queue.Header.KV {
-			_ = newRoot.Insert(k[:], v[:], NodeResolveFn)
-		}
-		newBytes := newRoot.Commit().Bytes()
-		n := base64.StdEncoding.EncodeToString(newBytes[:])
-		o := base64.StdEncoding.EncodeToString(pastState.VerkleCommit[:])
-		if n != o {
-			panic(fmt.Sprintf("Recovery the header failed! The commitment is different: %s and %s", n, o))
-		}
-		newHeader := Header{
-			Root:           newRoot,
-			KV:             queue.Header.KV,
-			Height:         i,
-			Hash:           pastState.Hash,
-			Access:         AccessList{},
-			IntermediateKV: KeyValueMap{},
-			OrdTrans:       queue.Header.OrdTrans,
-		}
-		queue.Header = &newHeader
 	}
 
-	// Compute to the curHeight from the reorgHeight.
+	// The tree is rebuilt once at the common ancestor, however deep the reorg is.
+	ancestor := queue.History[reorgHeight-1-startHeight]
+	newRoot := verkle.New()
+	queue.Header.KV.Range(func(k [verkle.KeySize]byte, v [ValueSize]byte) bool {
+		_ = newRoot.Insert(k[:], v[:], NodeResolveFn)
+		return true
+	})
+	newBytes := newRoot.Commit().Bytes()
+	n := base64.StdEncoding.EncodeToString(newBytes[:])
+	o := base64.StdEncoding.EncodeToString(ancestor.VerkleCommit[:])
+	if n != o {
+		panic(fmt.Sprintf("Recovery the header failed! The commitment is different: %s and %s", n, o))
+	}
+	if secondaryRoot := queue.Header.SecondaryRoot(); secondaryRoot != ancestor.SecondaryCommit {
+		panic(fmt.Sprintf("Recovery the header failed! The secondary commitment is different: %x and %x", secondaryRoot, ancestor.SecondaryCommit))
+	}
+	queue.Header = &Header{
+		Root:           newRoot,
+		KV:             queue.Header.KV,
+		Height:         reorgHeight - 1,
+		Hash:           ancestor.Hash,
+		Access:         AccessList{},
+		IntermediateKV: KeyValueMap{},
+		OrdTrans:       queue.Header.OrdTrans,
+		secondary:      queue.Header.secondary,
+		digest:         queue.Header.digest,
+		pagedEvents:    ancestor.EventCount,
+	}
+
+	return ancestor, nil
+}
+
+// Recovery reverts the state to the block before the reorgHeight, whose hash changed, and re-executes the blocks
+// of the new chain up to the current height.
+func (queue *Queue) Recovery(getter getter.OrdGetter, reorgHeight uint) error {
+	queue.Lock()
+	defer queue.Unlock()
+	defer queue.advance()
+	curHeight := queue.Header.Height
+	startHeight := queue.StartHeight()
+	if err := queue.checkReorg(reorgHeight); err != nil {
+		return err
+	}
+	invalidate(getter, reorgHeight)
+
+	// The blocks of the new chain are fetched before the rollback, so a failure of the getter leaves the state
+	// untouched and the reorg is recovered again later.
+	transfers := make([][]ord.OrdTransfer, 0, curHeight-reorgHeight+1)
+	hashes := make([]string, 0, curHeight-reorgHeight+2)
 	for i := reorgHeight; i <= curHeight; i++ {
-		index := i - startHeight - 1
 		ordTransfer, err := getter.GetOrdTransfers(i)
 		if err != nil {
 			return err
 		}
-		Exec(queue.Header, ordTransfer, i)
-		var hash string
-		hash, err = getter.GetBlockHash(i - 1)
+		hash, err := getter.GetBlockHash(i - 1)
 		if err != nil {
 			return err
 		}
+		transfers, hashes = append(transfers, ordTransfer), append(hashes, hash)
+	}
+	// Each block is paged with the hash fetched before the next block, or with the hash of the latest block.
+	latestHash, err := getter.GetBlockHash(curHeight)
+	if err != nil {
+		return err
+	}
+	hashes = append(hashes, latestHash)
+	ancestor, err := queue.rollback(reorgHeight)
+	if err != nil {
+		return err
+	}
+
+	// Compute to the curHeight from the reorgHeight.
+	for i := reorgHeight; i <= curHeight; i++ {
+		index := i - startHeight - 1
+		ordTransfer, hash := transfers[i-reorgHeight], hashes[i-reorgHeight]
+		Exec(queue.Header, ordTransfer, i)
+		// The census is only rewound by the paging of the first block of the reorg.
+		tickCount := CurrentCensus(0).TotalTicks
+		if i == reorgHeight {
+			tickCount = ancestor.TickCount
+		}
 		queue.History[index] = DiffState{
-			Height:       i - 1,
-			Hash:         hash,
-			Access:       queue.Header.Access,
-			VerkleCommit: queue.Header.Root.Commit().Bytes(),
+			Height:          i - 1,
+			Hash:            hash,
+			Access:          queue.Header.Access,
+			VerkleCommit:    queue.Header.Root.Commit().Bytes(),
+			SecondaryCommit: queue.Header.SecondaryRoot(),
+			EventCount:      queue.Header.pagedEvents,
+			TickCount:       tickCount,
 		}
 		queue.Header.OrdTrans = ordTransfer
-		_ = queue.Header.Paging(getter, true, NodeResolveFn)
+		if err := queue.Header.page(context.Background(), hashes[i-reorgHeight+1], NodeResolveFn); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
+// CheckForReorg returns the first block of the reorg, following the last common ancestor among the kept blocks,
+// or 0 if the hash of the latest block is unchanged. ErrReorgTooDeep is returned if no kept block is unchanged.
 func (queue *Queue) CheckForReorg(getter getter.OrdGetter) (uint, error) {
 	queue.Lock()
 	defer queue.Unlock()
-	// return the height that needs to start reorg
-	for i := 0; i <= len(queue.History)-1; i++ {
+	// The hashes are chained, so the older blocks are unchanged as long as the latest one is.
+	latestHash, err := getter.GetBlockHash(queue.Header.Height)
+	if err != nil {
+		return 0, err
+	}
+	if latestHash == queue.Header.Hash {
+		return 0, nil
+	}
+	for i := len(queue.History) - 1; i >= 0; i-- {
 		state := queue.History[i]
-		height := state.Height
-		hash := state.Hash
-		newHash, err := getter.GetBlockHash(height)
+		newHash, err := getter.GetBlockHash(state.Height)
 		if err != nil {
 			return 0, err
 		}
-		if hash == newHash {
-			continue
-		} else {
-			return height, nil
+		if state.Hash == newHash {
+			return state.Height + 1, nil
 		}
 	}
-	return 0, nil
+	return 0, fmt.Errorf("%w: the blocks from %d to %d are all reorganized", ErrReorgTooDeep, queue.StartHeight(), queue.Header.Height)
 }
 
 func NewQueues(getter getter.OrdGetter, header *Header, queryHash bool, startHeight uint) (*Queue, error) {
-	var stateList [ord.BitcoinConfirmations]DiffState
+	// The queue reads the tree after every block.
+	header.Pipeline(false)
+	stateList := make([]DiffState, ReorgDepth)
 	var proof *verkle.Proof
-	for i := startHeight; i <= startHeight+ord.BitcoinConfirmations-1; i++ {
+	for i := startHeight; i <= startHeight+ReorgDepth-1; i++ {
 		ordTransfer, err := getter.GetOrdTransfers(i)
 		if err != nil {
 			return nil, err
@@ -232,26 +369,117 @@ func NewQueues(getter getter.OrdGetter, header *Header, queryHash bool, startHei
 			}
 		}
 		stateList[i-startHeight] = DiffState{
-			Height:       i - 1,
-			Hash:         hash,
-			Access:       header.Access,
-			VerkleCommit: header.Root.Commit().Bytes(),
+			Height:          i - 1,
+			Hash:            hash,
+			Access:          header.Access,
+			VerkleCommit:    header.Root.Commit().Bytes(),
+			SecondaryCommit: header.SecondaryRoot(),
+			EventCount:      header.pagedEvents,
+			TickCount:       CurrentCensus(0).TotalTicks,
 		}
-		if i == startHeight+ord.BitcoinConfirmations-1 {
+		if i == startHeight+ReorgDepth-1 {
 			proof, _ = generateProofFromUpdate(header, &stateList[i-startHeight])
+			// The latest state proof is served along with the ord transfers of the same block.
+			header.OrdTrans = ordTransfer
+		}
+		if err := header.Paging(getter, true, NodeResolveFn); err != nil {
+			return nil, err
 		}
-		_ = header.Paging(getter, true, NodeResolveFn)
 	}
 	// The call of Commit is necessary to refresh the root commit.
 	header.Root.Commit()
+	// The queue never stores the state cache.
+	header.diffs = nil
 	queue := Queue{
 		Header:         header,
 		History:        stateList,
 		LastStateProof: proof,
 	}
+	queue.advance()
 	return &queue, nil
 }
 
+// StoreFinalized stores the state cache of the oldest block kept by the queue, which no recoverable reorg reverts,
+// so that a restart catches up from it rather than from the state cache of the last catch-up. The state is rolled
+// back to the block in place, so the queue shall no longer be used.
+func (queue *Queue) StoreFinalized() error {
+	queue.Lock()
+	defer queue.Unlock()
+	if StateDBPath != "" {
+		return errors.New("the state database is only committed by the catch-up")
+	}
+	height := queue.StartHeight()
+	if latest := latestSnapshot(snapshots()); latest >= height {
+		log.Printf("The state cache at the height %d is already stored, skip the finalized height %d", latest, height)
+		return nil
+	}
+	for i := len(queue.History) - 1; i >= 0; i-- {
+		for _, elem := range queue.History[i].Access.Elements {
+			if elem.OldValueExists {
+				queue.Header.KV.Put(elem.Key, elem.OldValue)
+			} else {
+				queue.Header.KV.Delete(elem.Key)
+			}
+		}
+	}
+	census.Lock()
+	rewindCensus(height)
+	census.Height = height
+	census.Unlock()
+	holders.Lock()
+	rewindHolders(height)
+	holders.height = height
+	holders.Unlock()
+
+	// The state cache is serialized from the key-values, so the tree isn't rebuilt.
+	finalized := &Header{
+		KV:     queue.Header.KV,
+		Height: height,
+		Hash:   queue.History[0].Hash,
+	}
+	return StoreHeader(finalized, SnapshotEvictHeight(height))
+}
+
+// advance commits the block boundary reached by the header, which must be locked, and wakes up the waiters.
+// The root is committed here, so that the readers never compute the commitment concurrently.
+func (queue *Queue) advance() {
+	queue.Header.Root.Commit()
+	queue.committed.Store(uint64(queue.Header.Height))
+	queue.advancedMu.Lock()
+	defer queue.advancedMu.Unlock()
+	if queue.advanced != nil {
+		close(queue.advanced)
+		queue.advanced = nil
+	}
+}
+
+// CommittedHeight returns the height of the latest committed block boundary without waiting for the lock.
+func (queue *Queue) CommittedHeight() uint {
+	return uint(queue.committed.Load())
+}
+
+// WaitHeight blocks until the queue commits the block at the height, or the context is done.
+// A reorg may roll the queue back below the height again once the lock is released, so the readers shall check
+// the height of the header under the lock.
+func (queue *Queue) WaitHeight(ctx context.Context, height uint) error {
+	for {
+		queue.advancedMu.Lock()
+		if queue.advanced == nil {
+			queue.advanced = make(chan struct{})
+		}
+		advanced := queue.advanced
+		queue.advancedMu.Unlock()
+		if queue.CommittedHeight() >= height {
+			return nil
+		}
+		select {
+		case <-advanced:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
 func generateProofFromUpdate(header *Header, stateDiff *DiffState) (*verkle.Proof, error) {
 	if len(stateDiff.Access.Elements) == 0 {
 		return nil, nil
@@ -264,7 +492,9 @@ func generateProofFromUpdate(header *Header, stateDiff *DiffState) (*verkle.Proo
 	}
 
 	preroot := header.Root
+	unlock := LockTree()
 	pe, es, poas, err := verkle.GetCommitmentsForMultiproof(preroot, keys, NodeResolveFn)
+	unlock()
 	if err != nil {
 		return nil, fmt.Errorf("error getting pre-state proof data: %w", err)
 	}
@@ -0,0 +1,86 @@
+package stateless
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"github.com/ethereum/go-verkle"
+
+	"github.com/RiemaLabs/modular-indexer-committee/internal/metrics"
+)
+
+// NewReplica returns the queue serving the state of the header without executing the blocks, whose history is
+// filled by the blocks replicated from another member, see Replicate.
+func NewReplica(header *Header) *Queue {
+	// The queue reads the tree after every block.
+	header.Pipeline(false)
+	// The queue never stores the state cache.
+	header.diffs = nil
+	queue := Queue{Header: header}
+	queue.advance()
+	return &queue
+}
+
+// Replicate applies the writes of a block executed by another member instead of executing the block. The block is
+// given as kept by the history, i.e. by the height, the hash and the commitment of its parent along with its access
+// list, followed by its own hash and the commitment after it. If the block replaces a block already replicated,
+// the state is first rolled back to its parent by the history.
+//
+// The state is only consistent with the member if the commitment after the block matches, otherwise the queue shall
+// no longer be served.
+func (queue *Queue) Replicate(block DiffState, hash string, postCommit [32]byte) error {
+	queue.Lock()
+	defer queue.Unlock()
+	defer queue.advance()
+	if block.Height < queue.Header.Height {
+		if len(queue.History) == 0 {
+			return fmt.Errorf("%w: can't replace the block %d replicated before the state at height %d", ErrReorgTooDeep, block.Height+1, queue.Header.Height)
+		}
+		startHeight := queue.StartHeight()
+		if _, err := queue.rollback(block.Height + 1); err != nil {
+			return err
+		}
+		queue.History = queue.History[:block.Height-startHeight]
+	}
+	h := queue.Header
+	if block.Height != h.Height {
+		return fmt.Errorf("the block %d doesn't follow the state at height %d", block.Height+1, h.Height)
+	}
+	if h.Hash != "" && block.Hash != h.Hash {
+		return fmt.Errorf("the parent %s of the block %d differs from the hash %s of the state", block.Hash, block.Height+1, h.Hash)
+	}
+	if pre := h.Root.Commit().Bytes(); pre != block.VerkleCommit {
+		return fmt.Errorf("the commitment %s before the block %d differs from %s of the state", base64.StdEncoding.EncodeToString(block.VerkleCommit[:]), block.Height+1, base64.StdEncoding.EncodeToString(pre[:]))
+	}
+
+	for _, elem := range block.Access.Elements {
+		if elem.Deleted {
+			if h.deleted == nil {
+				h.deleted = make(map[[verkle.KeySize]byte]bool)
+			}
+			h.deleted[elem.Key] = true
+		} else if elem.Written {
+			h.IntermediateKV[elem.Key] = elem.NewValue
+		}
+	}
+	block.SecondaryCommit = h.SecondaryRoot()
+	block.EventCount = h.pagedEvents
+	block.TickCount = CurrentCensus(0).TotalTicks
+	if _, err := h.flush(NodeResolveFn); err != nil {
+		return err
+	}
+	h.Height++
+	h.Hash = hash
+	h.OrdTrans = nil
+	metrics.CurrentHeight.Set(float64(h.Height))
+	metrics.KVSize.Set(float64(h.KV.Len()))
+	if post := h.Root.Commit().Bytes(); post != postCommit {
+		return fmt.Errorf("the commitment %s after the block %d differs from %s of the replicated block", base64.StdEncoding.EncodeToString(post[:]), h.Height, base64.StdEncoding.EncodeToString(postCommit[:]))
+	}
+
+	queue.History = append(queue.History, block)
+	if uint(len(queue.History)) > ReorgDepth {
+		queue.History = queue.History[uint(len(queue.History))-ReorgDepth:]
+	}
+	return nil
+}
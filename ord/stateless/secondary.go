@@ -0,0 +1,48 @@
+package stateless
+
+import (
+	"github.com/RiemaLabs/modular-indexer-committee/ord/smt"
+)
+
+// Whether the header additionally maintains a sparse Merkle tree over the same key-values as the verkle tree,
+// whose root is published along with the verkle commitment for the verifiers not supporting verkle proofs yet.
+var SecondaryCommitment bool = false
+
+// secondaryTree returns the sparse Merkle tree of the flushed key-values, building it on the first use.
+func (h *Header) secondaryTree() *smt.Tree {
+	if !SecondaryCommitment {
+		return nil
+	}
+	if h.secondary == nil {
+		h.secondary = smt.New()
+		h.KV.Range(func(key [32]byte, value [32]byte) bool {
+			h.secondary.Insert(key, value)
+			return true
+		})
+	}
+	return h.secondary
+}
+
+// SecondaryRoot returns the root of the sparse Merkle tree over the flushed key-values, or zero if it is disabled.
+func (h *Header) SecondaryRoot() [32]byte {
+	tree := h.secondaryTree()
+	if tree == nil {
+		return [32]byte{}
+	}
+	return tree.Commit()
+}
+
+// rollbackSecondary applies the old values of the access list, which is cheaper than rebuilding the tree.
+func (h *Header) rollbackSecondary(access AccessList) {
+	tree := h.secondaryTree()
+	if tree == nil {
+		return
+	}
+	for _, elem := range access.Elements {
+		if elem.OldValueExists {
+			tree.Insert(elem.Key, elem.OldValue)
+		} else {
+			tree.Delete(elem.Key)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package stateless
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord/brc20"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/getter"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/protocol"
+	"github.com/ethereum/go-verkle"
+)
+
+// The number of workers executing the ticks of a block concurrently. Values lower than 2 disable the sharded execution.
+var ExecShards uint = 1
+
+type shardResult struct {
+	header *Header
+	// The index of the transfer that first accessed each element of header.Access.
+	seqs []int
+}
+
+type shardElement struct {
+	seq   int
+	shard int
+	pos   int
+}
+
+// Different ticks never share balance, tick or event keys, so the transfers of a block can be executed per tick.
+// The only keys shared by the shards are the latest pkscripts of wallets, which are written but never read by Exec.
+// The keys of the other protocols are namespaced, so each of them is executed in a shard of its own.
+func transferTick(ot getter.OrdTransfer, blockHeight uint) string {
+	if name := protocol.Of(ot, blockHeight); name != "" {
+		return "\x00" + name
+	}
+	if brc20.Limits.SkipContent(ot.Content) != "" {
+		// The content is skipped by Exec, which doesn't need to be parsed here.
+		return ""
+	}
+	return brc20.NormalizeTick(brc20.ParseContent(ot.Content, blockHeight)["tick"])
+}
+
+func execSharded(header *Header, ots []getter.OrdTransfer, blockHeight uint, deadline time.Time) error {
+	if len(header.Access.Elements) != 0 || len(header.IntermediateKV) != 0 {
+		// The block has been partially executed, the merge below assumes a clean header.
+		return execSerial(header, ots, blockHeight, deadline)
+	}
+
+	// Group the transfers by tick, keeping the order of the block inside each group.
+	groups := make(map[string][]int)
+	ticks := make([]string, 0)
+	for i, ot := range ots {
+		tick := transferTick(ot, blockHeight)
+		if _, found := groups[tick]; !found {
+			ticks = append(ticks, tick)
+		}
+		groups[tick] = append(groups[tick], i)
+	}
+	if len(ticks) < 2 {
+		return execSerial(header, ots, blockHeight, deadline)
+	}
+
+	results := make([]shardResult, len(ticks))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	var aborted atomic.Bool
+	for range min(ExecShards, uint(len(ticks))) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for s := range jobs {
+				results[s] = execShard(header, ots, groups[ticks[s]], blockHeight, deadline, &aborted)
+			}
+		}()
+	}
+	for s := range ticks {
+		jobs <- s
+	}
+	close(jobs)
+	wg.Wait()
+	if aborted.Load() {
+		// The shards never write the header, which is left as it was.
+		return fmt.Errorf("%w: block %d", ErrDeadlineExceeded, blockHeight)
+	}
+
+	mergeShards(header, results)
+	return nil
+}
+
+func execShard(header *Header, ots []getter.OrdTransfer, indexes []int, blockHeight uint, deadline time.Time, aborted *atomic.Bool) shardResult {
+	shard := header.Fork()
+	shard.lastWrite = make(map[[verkle.KeySize]byte]int)
+	seqs := make([]int, 0)
+	for _, i := range indexes {
+		if aborted.Load() || exceeded(deadline) {
+			aborted.Store(true)
+			break
+		}
+		shard.cursor = i
+		protocol.Exec(shard, ots[i:i+1], blockHeight)
+		advanceProgress(1)
+		for len(seqs) < len(shard.Access.Elements) {
+			seqs = append(seqs, i)
+		}
+	}
+	return shardResult{header: shard, seqs: seqs}
+}
+
+// mergeShards rebuilds the access list and the intermediate key-values of a serial execution.
+func mergeShards(header *Header, results []shardResult) {
+	elements := make([]shardElement, 0)
+	for s, res := range results {
+		for pos, seq := range res.seqs {
+			elements = append(elements, shardElement{seq: seq, shard: s, pos: pos})
+		}
+	}
+	// Elements are appended at their first access, in the order of the transfers of the block.
+	sort.Slice(elements, func(i, j int) bool {
+		if elements[i].seq != elements[j].seq {
+			return elements[i].seq < elements[j].seq
+		}
+		return elements[i].pos < elements[j].pos
+	})
+
+	// For a key written or deleted by several shards, the latest transfer wins.
+	winners := make(map[[verkle.KeySize]byte]int)
+	for s, res := range results {
+		for key, seq := range res.header.lastWrite {
+			if w, found := winners[key]; !found || results[w].header.lastWrite[key] < seq {
+				winners[key] = s
+			}
+		}
+	}
+
+	accessed := make(map[[verkle.KeySize]byte]bool)
+	for _, e := range elements {
+		elem := results[e.shard].header.Access.Elements[e.pos]
+		if accessed[elem.Key] {
+			continue
+		}
+		accessed[elem.Key] = true
+		if w, found := winners[elem.Key]; found && results[w].header.deleted[elem.Key] {
+			elem.NewValue = [ValueSize]byte{}
+			elem.Deleted, elem.Written = true, false
+			if header.deleted == nil {
+				header.deleted = make(map[[verkle.KeySize]byte]bool)
+			}
+			header.deleted[elem.Key] = true
+		} else if found {
+			elem.NewValue = results[w].header.IntermediateKV[elem.Key]
+			elem.Deleted, elem.Written = false, true
+			header.IntermediateKV[elem.Key] = elem.NewValue
+		}
+		header.Access.Elements = append(header.Access.Elements, elem)
+	}
+	// The events are emitted in the order of the transfers of the block, as the elements.
+	events := make([]shardElement, 0)
+	for s, res := range results {
+		for pos, seq := range res.header.eventSeqs {
+			events = append(events, shardElement{seq: seq, shard: s, pos: pos})
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].seq != events[j].seq {
+			return events[i].seq < events[j].seq
+		}
+		return events[i].pos < events[j].pos
+	})
+	for _, e := range events {
+		header.ObserveEvent(results[e.shard].header.events[e.pos])
+	}
+
+	for _, res := range results {
+		for stem, category := range res.header.categories {
+			header.ObserveCategory(stem[:], category)
+		}
+		for tick, deployed := range res.header.ticks {
+			header.ObserveTick(tick, deployed)
+		}
+		for tick, deployer := range res.header.deployers {
+			header.ObserveDeploy(tick, deployer.pkscript, deployer.wallet)
+		}
+		for tick, pkscripts := range res.header.holders {
+			for pkscript := range pkscripts {
+				header.ObserveHolder(tick, pkscript)
+			}
+		}
+		header.stages.Merge(res.header.stages)
+	}
+}
@@ -0,0 +1,282 @@
+package stateless
+
+import (
+	"bufio"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-verkle"
+)
+
+const diffSuffix = ".diff"
+
+// The number of blocks between the full baselines of the state cache. In between, every store of the state only
+// writes the key-values written by the blocks since the previous store, which are replayed onto the baseline
+// at the restart. 0 writes a full baseline at every store.
+var SnapshotBaselineInterval uint = 10000
+
+// snapshotDiff is the incremental state cache following the state cache at the height From.
+// Each block keeps the key-values it wrote, as TripleElements with the written NewValue.
+type snapshotDiff struct {
+	From   uint
+	Blocks []DiffState
+}
+
+// writes returns the accesses of the block being paged which wrote the keys, with the written NewValue,
+// along with those which deleted the keys.
+func (h *Header) writes() []TripleElement {
+	elements := make([]TripleElement, 0, len(h.IntermediateKV)+len(h.deleted))
+	for _, elem := range h.Access.Elements {
+		if value, written := h.IntermediateKV[elem.Key]; written {
+			elem.NewValue = value
+			elements = append(elements, elem)
+		} else if h.deleted[elem.Key] {
+			elements = append(elements, elem)
+		}
+	}
+	return elements
+}
+
+// recordDiff keeps the writes of the block being paged for the next store of the state cache.
+func (h *Header) recordDiff(writes []TripleElement) {
+	if h.diffs == nil {
+		return
+	}
+	h.diffs = append(h.diffs, DiffState{Height: h.Height + 1, Access: AccessList{Elements: writes}})
+}
+
+// snapshots returns the heights of the baselines and of the diffs on the disk, both in the ascending order.
+func snapshots() ([]uint, []uint) {
+	files, err := os.ReadDir(cachePath)
+	if err != nil {
+		return nil, nil
+	}
+	var baselines, diffs []uint
+	for _, file := range files {
+		ext := filepath.Ext(file.Name())
+		height, err := strconv.ParseUint(strings.TrimSuffix(file.Name(), ext), 10, 64)
+		if err != nil {
+			continue
+		}
+		switch ext {
+		case fileSuffix:
+			baselines = append(baselines, uint(height))
+		case diffSuffix:
+			diffs = append(diffs, uint(height))
+		}
+	}
+	sort.Slice(baselines, func(i, j int) bool { return baselines[i] < baselines[j] })
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i] < diffs[j] })
+	return baselines, diffs
+}
+
+// diffChain returns the heights of the diffs following the baseline one after another.
+func diffChain(baseline uint, diffs []uint) []uint {
+	chain := make([]uint, 0)
+	for _, height := range diffs {
+		if height > baseline {
+			chain = append(chain, height)
+		}
+	}
+	return chain
+}
+
+func readDiff(height uint) (*snapshotDiff, error) {
+	file, err := os.Open(filepath.Join(cachePath, fmt.Sprintf("%d%s", height, diffSuffix)))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var diff snapshotDiff
+	if err := gob.NewDecoder(bufio.NewReader(file)).Decode(&diff); err != nil {
+		return nil, err
+	}
+	return &diff, nil
+}
+
+// writeCache streams a state cache into a temporary file, which replaces the file of the name once complete,
+// so an interrupted store never leaves a truncated state cache behind.
+func writeCache(name string, write func(w io.Writer) error) error {
+	path := filepath.Join(cachePath, name)
+	file, err := os.Create(path + ".tmp")
+	if err != nil {
+		return err
+	}
+	buffered := bufio.NewWriter(file)
+	err = write(buffered)
+	if err == nil {
+		err = buffered.Flush()
+	}
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(path + ".tmp")
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// latestSnapshot returns the height of the state cache loaded by the next start, 0 if none.
+func latestSnapshot(baselines, diffs []uint) uint {
+	latest := uint(0)
+	if len(baselines) != 0 {
+		latest = baselines[len(baselines)-1]
+	}
+	if chain := diffChain(latest, diffs); len(chain) != 0 {
+		latest = chain[len(chain)-1]
+	}
+	return latest
+}
+
+// storeSnapshot writes the diff of the blocks since the latest state cache, or a full baseline if the diffs
+// don't follow it or the baseline is due.
+func storeSnapshot(header *Header) error {
+	baselines, diffs := snapshots()
+	from := header.Height - uint(len(header.diffs))
+	latest := latestSnapshot(baselines, diffs)
+	incremental := header.diffs != nil && !header.migrated && len(baselines) != 0 && latest == from &&
+		SnapshotBaselineInterval != 0 && header.Height-baselines[len(baselines)-1] < SnapshotBaselineInterval
+
+	switch {
+	case incremental && len(header.diffs) == 0:
+		// The state is unchanged since the latest state cache.
+		return nil
+	case incremental:
+		err := writeCache(fmt.Sprintf("%d%s", header.Height, diffSuffix), func(w io.Writer) error {
+			return gob.NewEncoder(w).Encode(snapshotDiff{From: from, Blocks: header.diffs})
+		})
+		if err != nil {
+			return err
+		}
+	default:
+		if err := writeCache(fmt.Sprintf("%d%s", header.Height, fileSuffix), header.Serialize); err != nil {
+			return err
+		}
+	}
+	if header.diffs != nil {
+		header.diffs = make([]DiffState, 0)
+	}
+	header.migrated = false
+	return nil
+}
+
+// loadSnapshot rebuilds the state from the baseline at the height and the diffs following it.
+// A diff which doesn't follow the state loaded so far ends the chain.
+func loadSnapshot(baseline uint, diffs []uint) (*Header, error) {
+	file, err := os.Open(filepath.Join(cachePath, fmt.Sprintf("%d%s", baseline, fileSuffix)))
+	if err != nil {
+		return nil, err
+	}
+	kv, err := decodeKV(file)
+	file.Close()
+	if err != nil {
+		return nil, err
+	}
+	height := baseline
+	for _, diffHeight := range diffChain(baseline, diffs) {
+		diff, err := readDiff(diffHeight)
+		if err != nil || diff.From != height {
+			break
+		}
+		for _, block := range diff.Blocks {
+			for _, elem := range block.Access.Elements {
+				if elem.Deleted {
+					delete(kv, elem.Key)
+				} else {
+					kv[elem.Key] = elem.NewValue
+				}
+			}
+		}
+		height = diffHeight
+	}
+
+	root := verkle.New()
+	for k, v := range kv {
+		if err := root.Insert(k[:], v[:], nil); err != nil {
+			return nil, err
+		}
+	}
+	// The call of Commit is necessary to refresh the root commit.
+	root.Commit()
+	return &Header{
+		Root:           root,
+		KV:             kv,
+		Height:         height,
+		Access:         AccessList{},
+		IntermediateKV: KeyValueMap{},
+		diffs:          make([]DiffState, 0),
+	}, nil
+}
+
+// evictable returns whether the state cache at the height may be evicted, which is never the case
+// for the latest baseline and the diffs following it.
+func evictable(name string, height uint, baselines []uint) bool {
+	if len(baselines) == 0 {
+		return true
+	}
+	latest := baselines[len(baselines)-1]
+	switch filepath.Ext(name) {
+	case fileSuffix:
+		return height != latest
+	case diffSuffix:
+		return height < latest
+	}
+	return true
+}
+
+// HasState reports whether a state is stored on the disk, either a state cache or the state database.
+func HasState() (bool, error) {
+	if StateDBPath != "" {
+		_, found, err := loadStateDB(0)
+		return found, err
+	}
+	baselines, _ := snapshots()
+	return len(baselines) != 0, nil
+}
+
+// ImportSnapshot rebuilds the state of the block at the height from the key-values of a snapshot, e.g. served by
+// a trusted member. The census and the holders start from the height, as they aren't part of the state.
+// The state is only stored by StoreHeader, once the caller trusts its commitment.
+func ImportSnapshot(height uint, hash string, kv MemoryKV) (*Header, error) {
+	header := &Header{
+		Root:           verkle.New(),
+		KV:             kv,
+		Height:         height,
+		Hash:           hash,
+		Access:         AccessList{},
+		IntermediateKV: KeyValueMap{},
+	}
+	if StateDBPath != "" {
+		stored, found, err := loadStateDB(height)
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			return nil, fmt.Errorf("the state database already holds the state at height %d", stored.Height)
+		}
+		for k, v := range kv {
+			stored.KV.Put(k, v)
+		}
+		header.KV = stored.KV
+	}
+	for k, v := range kv {
+		if err := header.Root.Insert(k[:], v[:], nil); err != nil {
+			return nil, err
+		}
+	}
+	// The call of Commit is necessary to refresh the root commit.
+	header.Root.Commit()
+	resetCensus()
+	resetHolders()
+	return header, nil
+}
@@ -0,0 +1,264 @@
+package stateless
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-verkle"
+	"github.com/holiman/uint256"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord/protocol"
+)
+
+// The directory of the LevelDB database keeping the key-values and the verkle nodes on the disk instead of
+// the gob snapshots of the cache. Empty keeps the whole state in the memory.
+var StateDBPath = ""
+
+// Key layout of the state database
+// Key-value: "k" + key, Value: value
+// Verkle node: "n" + path, Value: the serialized node
+// Meta: "m", Value: the JSON of stateMeta
+var (
+	kvPrefix   = []byte("k")
+	nodePrefix = []byte("n")
+	metaKey    = []byte("m")
+)
+
+type stateMeta struct {
+	Height uint   `json:"height"`
+	Size   int    `json:"size"`
+	Digest string `json:"digest,omitempty"`
+}
+
+var stateDB *leveldb.DB
+
+// openStateDB opens the database once, resolving the flushed verkle nodes from it.
+func openStateDB() (*leveldb.DB, error) {
+	if stateDB != nil {
+		return stateDB, nil
+	}
+	db, err := leveldb.OpenFile(StateDBPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := dropLiveNodes(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	stateDB = db
+	NodeResolveFn = resolveNode
+	return db, nil
+}
+
+// CloseStateDB closes the database, after which the nodes can't be resolved.
+func CloseStateDB() error {
+	if stateDB == nil {
+		return nil
+	}
+	err := stateDB.Close()
+	stateDB = nil
+	NodeResolveFn = nil
+	return err
+}
+
+func prefixed(prefix []byte, key []byte) []byte {
+	return append(append(make([]byte, 0, len(prefix)+len(key)), prefix...), key...)
+}
+
+// diskKV keeps the key-values in the database, along with the writes since the latest commit in the memory.
+type diskKV struct {
+	db *leveldb.DB
+	// The writes since the latest commit, where nil deletes the key.
+	dirty map[[verkle.KeySize]byte]*[ValueSize]byte
+	size  int
+}
+
+func (d *diskKV) Get(key [verkle.KeySize]byte) ([ValueSize]byte, bool) {
+	if value, found := d.dirty[key]; found {
+		if value == nil {
+			return [ValueSize]byte{}, false
+		}
+		return *value, true
+	}
+	value, err := d.db.Get(prefixed(kvPrefix, key[:]), nil)
+	if errors.Is(err, leveldb.ErrNotFound) {
+		return [ValueSize]byte{}, false
+	}
+	if err != nil {
+		panic(&protocol.StorageError{Err: fmt.Errorf("failed to read the state database: %v", err)})
+	}
+	return [ValueSize]byte(value), true
+}
+
+func (d *diskKV) Put(key [verkle.KeySize]byte, value [ValueSize]byte) {
+	if _, found := d.Get(key); !found {
+		d.size++
+	}
+	d.dirty[key] = &value
+}
+
+func (d *diskKV) Delete(key [verkle.KeySize]byte) {
+	if _, found := d.Get(key); found {
+		d.size--
+	}
+	d.dirty[key] = nil
+}
+
+func (d *diskKV) Len() int {
+	return d.size
+}
+
+func (d *diskKV) Range(f func(key [verkle.KeySize]byte, value [ValueSize]byte) bool) {
+	d.RangePrefix(nil, f)
+}
+
+// RangePrefix seeks the prefix by the iterator of the database, merging the writes since the latest commit.
+func (d *diskKV) RangePrefix(prefix []byte, f func(key [verkle.KeySize]byte, value [ValueSize]byte) bool) {
+	iter := d.db.NewIterator(util.BytesPrefix(prefixed(kvPrefix, prefix)), nil)
+	defer iter.Release()
+	stored := make(map[[verkle.KeySize]byte]bool)
+	for iter.Next() {
+		key := [verkle.KeySize]byte(iter.Key()[len(kvPrefix):])
+		value := [ValueSize]byte(iter.Value())
+		if dirty, found := d.dirty[key]; found {
+			stored[key] = true
+			if dirty == nil {
+				continue
+			}
+			value = *dirty
+		}
+		if !f(key, value) {
+			return
+		}
+	}
+	for key, value := range d.dirty {
+		if value != nil && !stored[key] && bytes.HasPrefix(key[:], prefix) && !f(key, *value) {
+			return
+		}
+	}
+}
+
+// loadStateDB returns the header of the state in the database, or an empty header if there is none.
+// The verkle tree starts from the root node, whose children are resolved from the database on demand.
+func loadStateDB(initHeight uint) (*Header, bool, error) {
+	db, err := openStateDB()
+	if err != nil {
+		return nil, false, err
+	}
+	header := Header{
+		Root:           verkle.New(),
+		KV:             &diskKV{db: db, dirty: make(map[[verkle.KeySize]byte]*[ValueSize]byte)},
+		Height:         initHeight,
+		Access:         AccessList{},
+		IntermediateKV: KeyValueMap{},
+	}
+	metaBytes, err := db.Get(metaKey, nil)
+	if errors.Is(err, leveldb.ErrNotFound) {
+		return &header, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	var meta stateMeta
+	if err := json.Unmarshal(metaBytes, &meta); err != nil {
+		return nil, false, fmt.Errorf("invalid state database meta: %v", err)
+	}
+	rootBytes, err := resolveNode(nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read the root node: %v", err)
+	}
+	header.Root, err = verkle.ParseNode(rootBytes, 0)
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid root node: %v", err)
+	}
+	header.Height = meta.Height
+	header.KV.(*diskKV).size = meta.Size
+	if meta.Digest != "" {
+		header.digest, err = uint256.FromHex(meta.Digest)
+		if err != nil {
+			return nil, false, fmt.Errorf("invalid state digest: %v", err)
+		}
+	}
+	return &header, true, nil
+}
+
+// commitStateDB writes the key-values and the verkle nodes changed since the latest commit atomically.
+// The nodes are flushed out of the memory, to be resolved from the database again when accessed.
+func commitStateDB(header *Header) error {
+	kv, onDisk := header.KV.(*diskKV)
+	if !onDisk {
+		return fmt.Errorf("the state of the height %d isn't kept in the state database", header.Height)
+	}
+	batch := new(leveldb.Batch)
+	for key, value := range kv.dirty {
+		if value == nil {
+			batch.Delete(prefixed(kvPrefix, key[:]))
+		} else {
+			batch.Put(prefixed(kvPrefix, key[:]), value[:])
+		}
+	}
+
+	root, isInternal := header.Root.(*verkle.InternalNode)
+	if !isInternal {
+		return fmt.Errorf("unexpected root node %T", header.Root)
+	}
+	defer LockTree()()
+	if err := commitLiveNodes(kv.db, batch); err != nil {
+		return err
+	}
+	// The pending commitments are computed before the nodes are flushed.
+	root.Commit()
+	var flushErr error
+	flushNodes(root, nil, 0, func(path []byte, node verkle.VerkleNode) {
+		serialized, err := node.Serialize()
+		if err != nil && flushErr == nil {
+			flushErr = fmt.Errorf("failed to serialize the node at %x: %v", path, err)
+		}
+		batch.Put(prefixed(nodePrefix, path), serialized)
+	})
+	if flushErr != nil {
+		return flushErr
+	}
+
+	meta := stateMeta{Height: header.Height, Size: kv.size}
+	if header.digest != nil {
+		meta.Digest = header.digest.Hex()
+	}
+	metaBytes, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	batch.Put(metaKey, metaBytes)
+	if err := kv.db.Write(batch, &opt.WriteOptions{Sync: true}); err != nil {
+		return err
+	}
+	kv.dirty = make(map[[verkle.KeySize]byte]*[ValueSize]byte)
+	return nil
+}
+
+// Hydrate resolves every flushed node of the verkle tree into the memory. The tree shall be hydrated before
+// being read concurrently, since resolving a node modifies the tree, unless the nodes are evicted while serving,
+// whose readers lock the tree instead, see LockTree.
+func (h *Header) Hydrate() {
+	if _, onDisk := h.KV.(*diskKV); !onDisk || Evicting() {
+		return
+	}
+	h.Settle()
+	var lastStem [verkle.StemSize]byte
+	first := true
+	h.KV.Range(func(key [verkle.KeySize]byte, _ [ValueSize]byte) bool {
+		stem := [verkle.StemSize]byte(key[:verkle.StemSize])
+		if first || stem != lastStem {
+			if _, err := h.Root.Get(key[:], NodeResolveFn); err != nil {
+				panic(fmt.Errorf("failed to resolve the key %x: %v", key, err))
+			}
+		}
+		lastStem, first = stem, false
+		return true
+	})
+}
@@ -1,105 +1,155 @@
 package stateless
 
 import (
-	"bytes"
+	"context"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
-	"strconv"
-	"strings"
 
 	"github.com/ethereum/go-verkle"
 
+	"github.com/RiemaLabs/modular-indexer-committee/archive"
 	"github.com/RiemaLabs/modular-indexer-committee/internal/metrics"
 )
 
 const cachePath = ".cache"
 const fileSuffix = ".dat"
 
+// The archive tier of the evicted state caches, which restores the latest one onto an empty disk. Nil discards them.
+var Archive *archive.Archiver
+
+// restoreCache retrieves the latest archived state cache, along with its census and holders, onto the disk.
+func restoreCache() (int, string) {
+	ctx := context.Background()
+	heights, err := Archive.Heights(ctx, archive.ClassSnapshots, fileSuffix)
+	if err != nil || len(heights) == 0 {
+		log.Printf("No state cache is restored from the archive: %v", err)
+		return 0, ""
+	}
+	height := heights[len(heights)-1]
+	if err := os.MkdirAll(cachePath, 0755); err != nil {
+		log.Printf("Failed to restore the state cache at height %d: %v", height, err)
+		return 0, ""
+	}
+	for _, name := range []string{fmt.Sprintf("%d%s", height, fileSuffix), censusFile(height), holdersFile(height)} {
+		data, err := Archive.Fetch(ctx, archive.ClassSnapshots, name)
+		if err == nil {
+			err = os.WriteFile(filepath.Join(cachePath, name), data, 0666)
+		}
+		// Without its census and holders, they start from the height of the state cache.
+		if err != nil && name == fmt.Sprintf("%d%s", height, fileSuffix) {
+			log.Printf("Failed to restore the state cache at height %d: %v", height, err)
+			return 0, ""
+		}
+	}
+	log.Printf("Restored the state cache at height %d from the archive", height)
+	return int(height), fmt.Sprintf("%d%s", height, fileSuffix)
+}
+
 func LoadHeader(enableStateRootCache bool, initHeight uint) *Header {
 	curHeight := initHeight
 	myHeader := Header{
 		Root:           verkle.New(),
 		Height:         curHeight,
-		KV:             make(KeyValueMap),
+		KV:             make(MemoryKV),
 		Access:         AccessList{},
 		IntermediateKV: KeyValueMap{},
 	}
 	metrics.CurrentHeight.Set(float64(myHeader.Height))
-	if enableStateRootCache {
-		files, err := os.ReadDir(cachePath)
+	// Without a usable cache, the indexing starts from the bootstrap state.
+	fresh := func() *Header {
+		resetCensus()
+		resetHolders()
+		resetLayout()
+		if Genesis != nil {
+			if err := myHeader.Bootstrap(Genesis); err != nil {
+				panic(fmt.Errorf("failed to inject the bootstrap state at height %d: %v", curHeight, err))
+			}
+			log.Printf("Injected the bootstrap state of %d ticks at height %d", len(Genesis.Ticks), curHeight)
+		}
+		recoverBlocks(&myHeader)
+		return &myHeader
+	}
+	// The state database replaces the state caches, committed at every store.
+	if enableStateRootCache && StateDBPath != "" {
+		stored, found, err := loadStateDB(initHeight)
 		if err != nil {
-			return &myHeader
+			panic(fmt.Errorf("failed to open the state database %s: %v", StateDBPath, err))
 		}
-		// Variables to keep track of the file with the maximum state.height
-		var maxHeight int
-		var maxFile string
-
-		// Iterate through all files
-		for _, file := range files {
-			// Check if the file has the suffix
-			if filepath.Ext(file.Name()) == fileSuffix {
-				heightString := strings.TrimSuffix(file.Name(), fileSuffix)
-				height, err := strconv.Atoi(heightString)
-				if err == nil && height > maxHeight {
-					// Update the maximum state.height and corresponding file name
-					maxHeight = height
-					maxFile = file.Name()
-				}
+		if !found {
+			myHeader.KV = stored.KV
+			return fresh()
+		}
+		log.Printf("Loaded the state at height %d from the state database", stored.Height)
+		metrics.CurrentHeight.Set(float64(stored.Height))
+		loadCensus(stored.Height)
+		loadHolders(stored.Height)
+		loadLayout()
+		return stored
+	}
+	if enableStateRootCache {
+		// The writes of every block are recorded for the incremental state caches.
+		myHeader.diffs = make([]DiffState, 0)
+		baselines, diffs := snapshots()
+		if len(baselines) == 0 && Archive != nil {
+			if height, _ := restoreCache(); height != 0 {
+				baselines = []uint{uint(height)}
 			}
 		}
 
-		if maxFile != "" {
-			data, err := os.ReadFile(filepath.Join(cachePath, maxFile))
-			if err != nil {
-				return &myHeader
-			}
-			var buffer = bytes.NewBuffer(data)
+		if len(baselines) != 0 {
 			log.Println("Start to rebuild verkle tree.")
-			storedState, err := Deserialize(buffer, uint(maxHeight), nil)
+			storedState, err := loadSnapshot(baselines[len(baselines)-1], diffs)
 			if err != nil {
-				return &myHeader
+				return fresh()
 			}
-			log.Println("End to rebuild verkle tree.")
+			log.Printf("End to rebuild verkle tree at height %d.", storedState.Height)
+			loadCensus(storedState.Height)
+			loadHolders(storedState.Height)
+			loadLayout()
+			recoverBlocks(storedState)
 			return storedState
 		}
+	}
+	return fresh()
+}
 
+// recoverBlocks pages the blocks logged after the state cache, if the state cache is recorded by the write-ahead log.
+func recoverBlocks(header *Header) {
+	if header.diffs == nil || !WriteAheadLog {
+		return
+	}
+	if err := header.recoverWAL(); err != nil {
+		log.Printf("Failed to recover the blocks by the write-ahead log: %v", err)
 	}
-	return &myHeader
+	metrics.CurrentHeight.Set(float64(header.Height))
 }
 
 func StoreHeader(header *Header, evictHeight uint) error {
-	buffer, err := header.Serialize()
-	bytes := buffer.Bytes()
-	if err != nil {
+	header.Settle()
+	if StateDBPath != "" {
+		if err := commitStateDB(header); err != nil {
+			return err
+		}
+	} else if err := storeSnapshot(header); err != nil {
 		return err
 	}
-
-	fileName := fmt.Sprintf("%d%s", header.Height, fileSuffix)
-	filePath := filepath.Join(cachePath, fileName)
-	err = os.WriteFile(filePath, bytes, 0666)
-	if err != nil {
+	if err := storeCensus(header.Height); err != nil {
 		return err
 	}
-
-	// Delete old files
-	files, err := os.ReadDir(cachePath)
-	if err != nil {
+	if err := storeHolders(header.Height); err != nil {
 		return err
 	}
-	for _, file := range files {
-		// Check if the file has the suffix
-		if filepath.Ext(file.Name()) == fileSuffix {
-			heightString := strings.TrimSuffix(file.Name(), fileSuffix)
-			height, err := strconv.Atoi(heightString)
-			if err == nil && height < int(evictHeight) {
-				err := os.Remove(filepath.Join(cachePath, file.Name()))
-				if err != nil {
-					log.Printf("Failed to remove old file: %s, err: %v", file.Name(), err)
-				}
-			}
-		}
+	if err := storeLayout(); err != nil {
+		return err
 	}
-	return nil
+	// The blocks logged so far are recovered by the state cache along with its census and holders.
+	if err := truncateWAL(header.Height); err != nil {
+		return err
+	}
+
+	// Delete old files, except the latest baseline and the diffs following it.
+	_, err := pruneSnapshots(evictHeight)
+	return err
 }
@@ -0,0 +1,75 @@
+package stateless
+
+import (
+	"encoding/base64"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/brc20"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/subscription"
+)
+
+// The hub pushing the events and the balance changes of every executed block to the subscribers. Nil disables it.
+var Subscriptions *subscription.Hub = nil
+
+// publishBlock pushes the events of the block, which has been paged, and the balances of the pkscripts touched by them.
+func publishBlock(h *Header, events []brc20.Event) {
+	if Subscriptions == nil {
+		return
+	}
+	watchesEvents := Subscriptions.WatchesEvents()
+	var changes []subscription.BalanceChange
+	if len(events) != 0 && Subscriptions.WatchesBalances() {
+		changes = balanceChanges(h, events)
+	}
+	if !watchesEvents && len(changes) == 0 {
+		return
+	}
+	h.Settle()
+	commitBytes := h.Root.Commit().Bytes()
+	commitment := base64.StdEncoding.EncodeToString(commitBytes[:])
+	if watchesEvents {
+		data, err := brc20.MarshalEvents(events)
+		if err != nil {
+			panic(err)
+		}
+		Subscriptions.PublishEvents(h.Height, h.Hash, commitment, data)
+	}
+	if len(changes) != 0 {
+		Subscriptions.PublishBalances(h.Height, h.Hash, commitment, changes)
+	}
+}
+
+// balanceChanges reads the balances of the pkscripts touched by the events.
+func balanceChanges(h *Header, events []brc20.Event) []subscription.BalanceChange {
+	type touched struct {
+		tick     string
+		pkscript ord.Pkscript
+	}
+	seen := make(map[touched]bool)
+	changes := make([]subscription.BalanceChange, 0)
+	touch := func(tick string, pkscript ord.Pkscript) {
+		t := touched{tick: tick, pkscript: pkscript}
+		if pkscript == "" || seen[t] {
+			return
+		}
+		seen[t] = true
+		changes = append(changes, subscription.BalanceChange{
+			Tick:             tick,
+			Pkscript:         string(pkscript),
+			AvailableBalance: h.peekUInt256(brc20.GetTickPkscriptHash(tick, pkscript, brc20.AvailableBalancePkscript)).Dec(),
+			OverallBalance:   h.peekUInt256(brc20.GetTickPkscriptHash(tick, pkscript, brc20.OverallBalancePkscript)).Dec(),
+		})
+	}
+	for _, event := range events {
+		switch e := event.(type) {
+		case brc20.MintEvent:
+			touch(e.Tick, e.Pkscript)
+		case brc20.TransferInscribeEvent:
+			touch(e.Tick, e.Pkscript)
+		case brc20.TransferTransferEvent:
+			touch(e.Tick, e.SourcePkscript)
+			touch(e.Tick, e.SpentPkscript)
+		}
+	}
+	return changes
+}
@@ -0,0 +1,108 @@
+package stateless
+
+import (
+	"encoding/hex"
+
+	"github.com/ethereum/go-verkle"
+	"github.com/holiman/uint256"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord/brc20"
+)
+
+// TickInfo is the metadata of a deployed tick, read from the state along with the census.
+// The amounts are extended to 18 decimals, as the balances.
+type TickInfo struct {
+	Tick          string `json:"tick"`
+	InscriptionID string `json:"inscriptionID"`
+	// The owner of the deploy inscription and the height of the deploy, empty if unknown to the census.
+	DeployerPkscript string `json:"deployerPkscript"`
+	Deployer         string `json:"deployer"`
+	DeployHeight     uint   `json:"deployHeight"`
+	MaxSupply        string `json:"maxSupply"`
+	LimitPerMint     string `json:"limitPerMint"`
+	Decimals         uint64 `json:"decimals"`
+	SelfMint         bool   `json:"selfMint"`
+	Minted           string `json:"minted"`
+	RemainingSupply  string `json:"remainingSupply"`
+	// The amount burned by the transfers to the unspendable outputs, and the minted amount left after the burns.
+	BurnedSupply      string `json:"burnedSupply"`
+	CirculatingSupply string `json:"circulatingSupply"`
+	// The counters of the state, 0 unless they are active: the holders, the mints and the minted amount since
+	// their activation.
+	HolderCount  uint64 `json:"holderCount"`
+	MintCount    uint64 `json:"mintCount"`
+	MintedAmount string `json:"mintedAmount"`
+	// The height of the latest mint, 0 if unknown to the census.
+	LastMintHeight uint `json:"lastMintHeight"`
+	Completed      bool `json:"completed"`
+}
+
+// peekInscriptionID reads the inscription ID stored by Header.InsertInscriptionID without recording the access.
+func (h *Header) peekInscriptionID(key []byte) string {
+	var transactionID [ValueSize]byte
+	if value, found := h.KV.Get([verkle.KeySize]byte(key)); found {
+		transactionID = value
+	}
+	secondKey := make([]byte, verkle.KeySize)
+	copy(secondKey, key)
+	secondKey[verkle.StemSize] = key[verkle.StemSize] + byte(1)
+	return hex.EncodeToString(transactionID[:]) + "i" + h.peekUInt256(secondKey).Dec()
+}
+
+// tickInfo reads the tick from the flushed state, false if it isn't deployed. The census shall be locked.
+func (h *Header) tickInfo(tick string) (TickInfo, bool) {
+	if h.peekUInt256(brc20.GetTickHash(tick, brc20.Exists)).IsZero() {
+		return TickInfo{}, false
+	}
+	maxSupply := h.peekUInt256(brc20.GetTickHash(tick, brc20.MaxSupply))
+	remaining := h.peekUInt256(brc20.GetTickHash(tick, brc20.RemainingSupply))
+	burned := h.peekUInt256(brc20.GetTickHash(tick, brc20.BurnedSupply))
+	minted := new(uint256.Int).Sub(maxSupply, remaining)
+	info := TickInfo{
+		Tick:              tick,
+		InscriptionID:     h.peekInscriptionID(brc20.GetTickHash(tick, brc20.InscriptionID)),
+		MaxSupply:         maxSupply.Dec(),
+		LimitPerMint:      h.peekUInt256(brc20.GetTickHash(tick, brc20.LimitPerMint)).Dec(),
+		Decimals:          h.peekUInt256(brc20.GetTickHash(tick, brc20.Decimals)).Uint64(),
+		SelfMint:          !h.peekUInt256(brc20.GetTickHash(tick, brc20.IsSelfMint)).IsZero(),
+		Minted:            minted.Dec(),
+		RemainingSupply:   remaining.Dec(),
+		BurnedSupply:      burned.Dec(),
+		CirculatingSupply: new(uint256.Int).Sub(minted, burned).Dec(),
+		HolderCount:       h.peekUInt256(brc20.GetTickHash(tick, brc20.HolderCount)).Uint64(),
+		MintCount:         h.peekUInt256(brc20.GetTickHash(tick, brc20.MintCount)).Uint64(),
+		MintedAmount:      h.peekUInt256(brc20.GetTickHash(tick, brc20.MintedAmount)).Dec(),
+		Completed:         remaining.IsZero(),
+	}
+	if record, found := census.Ticks[tick]; found {
+		info.DeployerPkscript, info.Deployer = record.DeployerPkscript, record.Deployer
+		info.DeployHeight, info.LastMintHeight = record.DeployHeight, record.LastMintHeight
+	}
+	return info, true
+}
+
+// TickInfo returns the metadata of the tick, false if it isn't deployed, along with the height of the census.
+// The queue shall be locked for reading.
+func (h *Header) TickInfo(tick string) (TickInfo, bool, uint) {
+	census.Lock()
+	defer census.Unlock()
+	info, found := h.tickInfo(tick)
+	return info, found, census.Height
+}
+
+// TickInfos returns the metadata of the ticks known to the census ordered by their deploys, along with the total
+// number of the ticks and the height of the census. The queue shall be locked for reading.
+func (h *Header) TickInfos(offset int, limit int) ([]TickInfo, int, uint) {
+	census.Lock()
+	defer census.Unlock()
+	ticks := sortedCensusTicks()
+	total := len(ticks)
+	offset = min(offset, total)
+	infos := make([]TickInfo, 0, min(limit, total-offset))
+	for _, t := range ticks[offset:min(offset+limit, total)] {
+		if info, found := h.tickInfo(t.Tick); found {
+			infos = append(infos, info)
+		}
+	}
+	return infos, total, census.Height
+}
@@ -1,12 +1,18 @@
 package stateless
 
 import (
+	"bytes"
 	"sync"
+	"sync/atomic"
 
+	"github.com/RiemaLabs/modular-indexer-committee/internal/tracing"
 	"github.com/RiemaLabs/modular-indexer-committee/ord"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/brc20"
 	"github.com/RiemaLabs/modular-indexer-committee/ord/getter"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/reexec"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/smt"
 	verkle "github.com/ethereum/go-verkle"
-	uint256 "github.com/holiman/uint256"
+	"github.com/holiman/uint256"
 )
 
 const ValueSize = 32
@@ -16,6 +22,10 @@ type TripleElement struct {
 	OldValue       [ValueSize]byte
 	NewValue       [ValueSize]byte
 	OldValueExists bool
+	// Whether the key is deleted by the block, whose NewValue is then zeros.
+	Deleted bool
+	// Whether the key is written by the block, unlike the keys only read, whose NewValue is the value read.
+	Written bool
 }
 
 type AccessList struct {
@@ -28,18 +38,72 @@ type DiffState struct {
 	Hash   string
 	// ipa.CompressedSize
 	VerkleCommit [32]byte
+	// The root of the sparse Merkle tree over the same key-values, zero if SecondaryCommitment is disabled.
+	SecondaryCommit [32]byte
+	// The number of the events emitted by the block and of the ticks in the census after it, published by the checkpoints.
+	EventCount int
+	TickCount  int
 
 	Access AccessList
 }
 
 type KeyValueMap = map[[verkle.KeySize]byte][ValueSize]byte
 
+// KVStore is the flushed key-values of the state, kept either in the memory or on the disk.
+type KVStore interface {
+	Get(key [verkle.KeySize]byte) ([ValueSize]byte, bool)
+	Put(key [verkle.KeySize]byte, value [ValueSize]byte)
+	Delete(key [verkle.KeySize]byte)
+	Len() int
+	// Range calls f on every key-value until f returns false.
+	Range(f func(key [verkle.KeySize]byte, value [ValueSize]byte) bool)
+	// RangePrefix calls f on every key-value whose key starts with the prefix until f returns false.
+	RangePrefix(prefix []byte, f func(key [verkle.KeySize]byte, value [ValueSize]byte) bool)
+}
+
+// MemoryKV keeps the key-values in the memory.
+type MemoryKV KeyValueMap
+
+func (m MemoryKV) Get(key [verkle.KeySize]byte) ([ValueSize]byte, bool) {
+	value, found := m[key]
+	return value, found
+}
+
+func (m MemoryKV) Put(key [verkle.KeySize]byte, value [ValueSize]byte) {
+	m[key] = value
+}
+
+func (m MemoryKV) Delete(key [verkle.KeySize]byte) {
+	delete(m, key)
+}
+
+func (m MemoryKV) Len() int {
+	return len(m)
+}
+
+func (m MemoryKV) Range(f func(key [verkle.KeySize]byte, value [ValueSize]byte) bool) {
+	for key, value := range m {
+		if !f(key, value) {
+			return
+		}
+	}
+}
+
+// RangePrefix scans the whole map, which has no order to seek the prefix by.
+func (m MemoryKV) RangePrefix(prefix []byte, f func(key [verkle.KeySize]byte, value [ValueSize]byte) bool) {
+	for key, value := range m {
+		if bytes.HasPrefix(key[:], prefix) && !f(key, value) {
+			return
+		}
+	}
+}
+
 type Header struct {
 	// Verkle Tree Root
 	Root verkle.VerkleNode
 
 	// All Key Values on the Verkle Tree. It shall be consistent with the Root.
-	KV KeyValueMap
+	KV KVStore
 
 	// The state is after the execution of Block Height.
 	Height uint
@@ -52,42 +116,67 @@ type Header struct {
 	Access AccessList
 	// The key-value map during the execution of the block.
 	IntermediateKV KeyValueMap
+	// The keys deleted during the execution of the block, which are absent from IntermediateKV.
+	deleted map[[verkle.KeySize]byte]bool
+
+	// Only used by the shards of a sharded execution.
+	// The index of the transfer being executed and the index of the last transfer writing each key.
+	cursor    int
+	lastWrite map[[verkle.KeySize]byte]int
+
+	// The witness of the executed block waiting for the post-state root, only used when WitnessPath or KeepWitnesses is set.
+	witness *reexec.Witness
+
+	// The ord transfers of the executed block waiting for the watchlist, only used when Watchlist is set.
+	watched  []getter.OrdTransfer
+	watching bool
+
+	// The sparse Merkle tree of the flushed key-values, only used when SecondaryCommitment is set.
+	secondary *smt.Tree
+	// The order-independent digest of the flushed key-values, computed on the first write.
+	digest *uint256.Int
+
+	// The category of each stem written by the block being executed, following the growth of the state.
+	categories map[[verkle.StemSize]byte]brc20.Category
+	// The ticks deployed (true) or minted (false) by the block being executed, following the census.
+	ticks map[string]bool
+	// The deployers of the ticks deployed by the block being executed, following the census.
+	deployers map[string]tickDeployer
+	// The pkscripts whose overall balances of each tick may be changed by the block being executed, following the holders.
+	holders map[string]map[ord.Pkscript]bool
+	// The events emitted by the block being executed, and in a shard the index of the transfer emitting each one.
+	events    []brc20.Event
+	eventSeqs []int
+	// The number of the events emitted by the latest paged block.
+	pagedEvents int
+	// The stages of the block being executed, only timed when the tracing is enabled.
+	stages tracing.Stages
+
+	// The writes of the blocks since the latest store of the state cache, nil if they aren't recorded.
+	diffs []DiffState
+	// Whether the state has been migrated since the latest store of the state cache, which the diffs miss.
+	migrated bool
+
+	// Whether the writes of the paged blocks are inserted into the tree in the background, see Pipeline.
+	pipelined bool
+	// Closed once the background insertion of the latest paged block completes, nil if none is pending.
+	inserting chan struct{}
+	// The failure to insert the writes of a paged block into the tree, which then misses them for good.
+	treeErr error
 
 	sync.RWMutex
 }
 
-type LightHeader struct {
-	// Verkle Tree Root
-	Root verkle.VerkleNode
-	// The state is after the execution of Block Height.
-	Height uint
-	// Block Hash.
-	Hash string
-}
-
 type Queue struct {
-	Header         *Header
-	History        [ord.BitcoinConfirmations]DiffState
+	Header *Header
+	// The diffs of the latest ReorgDepth blocks, from the oldest to the latest.
+	History        []DiffState
 	LastStateProof *verkle.Proof
 	sync.RWMutex
-}
-
-type KVStorage interface {
-	insert(key []byte, value []byte, nodeResolverFn verkle.NodeResolverFn)
-
-	get(key []byte, nodeResolverFn verkle.NodeResolverFn) []byte
-
-	InsertInscriptionID(key []byte, value string)
-
-	GetInscriptionID(key []byte) string
-
-	InsertUInt256(key []byte, value *uint256.Int)
-
-	GetUInt256(key []byte) *uint256.Int
-
-	InsertBytes(key []byte, value []byte)
-
-	GetBytes(key []byte) []byte
 
-	GetHeight() uint
+	// The height of the latest block boundary committed by the queue, readable without the lock.
+	committed atomic.Uint64
+	// Closed and replaced whenever a block boundary is committed, waking up the waiters of WaitHeight.
+	advancedMu sync.Mutex
+	advanced   chan struct{}
 }
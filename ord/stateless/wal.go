@@ -0,0 +1,242 @@
+package stateless
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/ethereum/go-verkle"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord"
+)
+
+// Whether the writes of every block recorded for the state cache are appended to the write-ahead log before they're
+// applied, so that a restart after a crash recovers the state of the latest block paged rather than of the latest
+// state cache. The log is truncated by every store of the state cache.
+var WriteAheadLog = false
+
+const walFile = "state.wal"
+
+// walRecord is the block appended to the write-ahead log, with everything paging the block records besides the state.
+// Each record is framed by the little-endian uint32 length and the CRC-32 of its gob, so a torn tail is detected.
+type walRecord struct {
+	Height    uint
+	Writes    []TripleElement
+	Ticks     map[string]bool
+	Deployers map[string][2]string
+	Holders   map[string][]string
+}
+
+// encodeWAL frames the record by its length and checksum.
+func encodeWAL(record walRecord) ([]byte, error) {
+	var payload bytes.Buffer
+	if err := gob.NewEncoder(&payload).Encode(record); err != nil {
+		return nil, err
+	}
+	frame := make([]byte, 8, 8+payload.Len())
+	binary.LittleEndian.PutUint32(frame[0:4], uint32(payload.Len()))
+	binary.LittleEndian.PutUint32(frame[4:8], crc32.ChecksumIEEE(payload.Bytes()))
+	return append(frame, payload.Bytes()...), nil
+}
+
+func walPath() string {
+	return filepath.Join(cachePath, walFile)
+}
+
+// appendWAL syncs the record of the block being paged to the write-ahead log.
+func (h *Header) appendWAL(writes []TripleElement) error {
+	record := walRecord{
+		Height:    h.Height + 1,
+		Writes:    writes,
+		Ticks:     h.ticks,
+		Deployers: make(map[string][2]string, len(h.deployers)),
+		Holders:   make(map[string][]string, len(h.holders)),
+	}
+	for tick, deployer := range h.deployers {
+		record.Deployers[tick] = [2]string{string(deployer.pkscript), string(deployer.wallet)}
+	}
+	for tick, pkscripts := range h.holders {
+		for pkscript := range pkscripts {
+			record.Holders[tick] = append(record.Holders[tick], string(pkscript))
+		}
+	}
+	frame, err := encodeWAL(record)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(cachePath, 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(walPath(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(frame)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// readWAL returns the complete records of the write-ahead log, along with the offset where each one ends.
+// A torn or corrupted record ends the log.
+func readWAL() ([]walRecord, []int64, error) {
+	file, err := os.Open(walPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+	reader := bufio.NewReader(file)
+	var records []walRecord
+	var ends []int64
+	var offset int64
+	var frame [8]byte
+	for {
+		if _, err := io.ReadFull(reader, frame[:]); err != nil {
+			return records, ends, nil
+		}
+		length := int64(binary.LittleEndian.Uint32(frame[0:4]))
+		if offset+int64(len(frame))+length > info.Size() {
+			return records, ends, nil
+		}
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(reader, payload); err != nil {
+			return records, ends, nil
+		}
+		if crc32.ChecksumIEEE(payload) != binary.LittleEndian.Uint32(frame[4:8]) {
+			return records, ends, nil
+		}
+		var record walRecord
+		if err := gob.NewDecoder(bytes.NewReader(payload)).Decode(&record); err != nil {
+			return records, ends, nil
+		}
+		offset += int64(len(frame) + len(payload))
+		records, ends = append(records, record), append(ends, offset)
+	}
+}
+
+// truncateWAL drops the records of the blocks up to the height, which has been stored.
+func truncateWAL(height uint) error {
+	records, _, err := readWAL()
+	if err != nil {
+		return err
+	}
+	kept := 0
+	for kept < len(records) && records[kept].Height <= height {
+		kept++
+	}
+	if kept == len(records) {
+		if err := os.Remove(walPath()); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	return writeCache(walFile, func(w io.Writer) error {
+		for _, record := range records[kept:] {
+			frame, err := encodeWAL(record)
+			if err == nil {
+				_, err = w.Write(frame)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// recoverWAL pages the blocks of the write-ahead log following the height of the header, whose census and holders
+// have been loaded. The records which don't follow the recovered state are dropped, along with a torn tail.
+func (h *Header) recoverWAL() error {
+	info, err := os.Stat(walPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	records, ends, err := readWAL()
+	if err != nil {
+		return err
+	}
+	from := h.Height
+	end := int64(0)
+	// The tree is committed once after all the blocks.
+	pipelined := h.pipelined
+	h.pipelined = true
+	for i, record := range records {
+		if record.Height > h.Height+1 {
+			// The rest was logged on top of another state.
+			break
+		}
+		end = ends[i]
+		if record.Height <= h.Height {
+			continue
+		}
+		h.IntermediateKV = make(KeyValueMap, len(record.Writes))
+		for _, elem := range record.Writes {
+			if elem.Deleted {
+				if h.deleted == nil {
+					h.deleted = make(map[[verkle.KeySize]byte]bool)
+				}
+				h.deleted[elem.Key] = true
+			} else {
+				h.IntermediateKV[elem.Key] = elem.NewValue
+			}
+		}
+		deployers := make(map[string]tickDeployer, len(record.Deployers))
+		for tick, deployer := range record.Deployers {
+			deployers[tick] = tickDeployer{pkscript: ord.Pkscript(deployer[0]), wallet: ord.Wallet(deployer[1])}
+		}
+		observed := make(map[string]map[ord.Pkscript]bool, len(record.Holders))
+		for tick, pkscripts := range record.Holders {
+			observed[tick] = make(map[ord.Pkscript]bool, len(pkscripts))
+			for _, pkscript := range pkscripts {
+				observed[tick][ord.Pkscript(pkscript)] = true
+			}
+		}
+		h.recordDiff(record.Writes)
+		if _, err := h.flush(NodeResolveFn); err != nil {
+			h.Settle()
+			h.pipelined = pipelined
+			return err
+		}
+		h.Height++
+		recordCensus(h, record.Ticks, deployers)
+		recordHolders(h, observed)
+	}
+	h.pipelined = pipelined
+	if err := h.settle(); err != nil {
+		return err
+	}
+	// The call of Commit is necessary to refresh the root commit.
+	h.Root.Commit()
+	if h.Height != from {
+		log.Printf("Recovered the blocks from %d to %d by the write-ahead log", from+1, h.Height)
+	}
+	if info.Size() != end {
+		log.Printf("Dropped the write-ahead log after the offset %d of %d bytes", end, info.Size())
+		if err := os.Truncate(walPath(), end); err != nil {
+			return fmt.Errorf("failed to truncate the write-ahead log: %v", err)
+		}
+	}
+	return nil
+}
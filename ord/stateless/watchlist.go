@@ -0,0 +1,40 @@
+package stateless
+
+import (
+	"encoding/base64"
+
+	"github.com/ethereum/go-verkle"
+	"github.com/holiman/uint256"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/brc20"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/watchlist"
+)
+
+// The watchlist observing every executed block. Nil disables it.
+var Watchlist *watchlist.Watchlist = nil
+
+// peekUInt256 reads the flushed state without recording the access.
+func (h *Header) peekUInt256(key []byte) *uint256.Int {
+	res := uint256.NewInt(0)
+	if value, found := h.KV.Get([verkle.KeySize]byte(key)); found {
+		res.SetBytes(value[:])
+	}
+	return res
+}
+
+func observeWatchlist(header *Header) {
+	if !header.watching {
+		return
+	}
+	header.Settle()
+	commitBytes := header.Root.Commit().Bytes()
+	commitment := base64.StdEncoding.EncodeToString(commitBytes[:])
+	Watchlist.Observe(header.watched, header.Height, commitment, func(tick string, pkscript ord.Pkscript) (string, string) {
+		available := header.peekUInt256(brc20.GetTickPkscriptHash(tick, pkscript, brc20.AvailableBalancePkscript))
+		overall := header.peekUInt256(brc20.GetTickPkscriptHash(tick, pkscript, brc20.OverallBalancePkscript))
+		return available.Dec(), overall.Dec()
+	})
+	header.watched = nil
+	header.watching = false
+}
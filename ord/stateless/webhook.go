@@ -0,0 +1,25 @@
+package stateless
+
+import (
+	"encoding/base64"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord/brc20"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/subscription"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/webhook"
+)
+
+// The webhooks notified of the events of every executed block. Nil disables them.
+var Webhooks *webhook.Notifier = nil
+
+// notifyWebhooks queues the events of the block, which has been paged, to the webhooks matching them.
+func notifyWebhooks(h *Header, events []brc20.Event) {
+	if Webhooks == nil || len(events) == 0 {
+		return
+	}
+	h.Settle()
+	commitBytes := h.Root.Commit().Bytes()
+	commitment := base64.StdEncoding.EncodeToString(commitBytes[:])
+	Webhooks.Notify(h.Height, h.Hash, commitment, events, func(matched []brc20.Event) []subscription.BalanceChange {
+		return balanceChanges(h, matched)
+	})
+}
@@ -0,0 +1,180 @@
+package stateless
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/RiemaLabs/modular-indexer-committee/archive"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/getter"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/reexec"
+	"github.com/ethereum/go-verkle"
+)
+
+// The directory to export the execution witness of every block to. Empty disables the export.
+var WitnessPath = ""
+
+// The number of the witnesses of the latest blocks kept in memory for RecentWitness, even if WitnessPath is empty.
+var KeepWitnesses uint = 0
+
+var (
+	recentMu        sync.Mutex
+	recentWitnesses = make(map[uint]*reexec.Witness)
+)
+
+const witnessSuffix = ".json"
+
+// NewWitness shall be called after the execution of the block and before the Paging of the header.
+func NewWitness(header *Header, ots []getter.OrdTransfer, blockHeight uint) (*reexec.Witness, error) {
+	header.Settle()
+	preBytes := header.Root.Commit().Bytes()
+	witness := reexec.Witness{
+		Height:        blockHeight,
+		PreCommitment: base64.StdEncoding.EncodeToString(preBytes[:]),
+		OrdTransfers:  ots,
+		Reads:         make([]reexec.WitnessEntry, 0, len(header.Access.Elements)),
+		Writes:        make([]reexec.WitnessEntry, 0, len(header.IntermediateKV)),
+	}
+	for _, elem := range header.Access.Elements {
+		witness.Reads = append(witness.Reads, reexec.WitnessEntry{
+			Key:    hex.EncodeToString(elem.Key[:]),
+			Value:  hex.EncodeToString(elem.OldValue[:]),
+			Exists: elem.OldValueExists,
+		})
+		if value, found := header.IntermediateKV[elem.Key]; found {
+			witness.Writes = append(witness.Writes, reexec.WitnessEntry{
+				Key:    hex.EncodeToString(elem.Key[:]),
+				Value:  hex.EncodeToString(value[:]),
+				Exists: true,
+			})
+		}
+	}
+
+	proof, err := generateProofFromUpdate(header, &DiffState{Access: header.Access})
+	if err != nil {
+		return nil, err
+	}
+	if proof != nil {
+		vProof, stateDiff, err := verkle.SerializeProof(proof)
+		if err != nil {
+			return nil, err
+		}
+		witness.Proof = vProof
+		witness.StateDiff = stateDiff
+	}
+	return &witness, nil
+}
+
+// SealWitness shall be called after the Paging of the header to record the post-state root.
+func SealWitness(w *reexec.Witness, header *Header) {
+	header.Settle()
+	postBytes := header.Root.Commit().Bytes()
+	w.PostCommitment = base64.StdEncoding.EncodeToString(postBytes[:])
+}
+
+func StoreWitness(dir string, w *reexec.Witness) error {
+	bytes, err := json.Marshal(w)
+	if err != nil {
+		return err
+	}
+	fileName := fmt.Sprintf("%d%s", w.Height, witnessSuffix)
+	return os.WriteFile(filepath.Join(dir, fileName), bytes, 0666)
+}
+
+func LoadWitness(dir string, height uint) (*reexec.Witness, error) {
+	fileName := fmt.Sprintf("%d%s", height, witnessSuffix)
+	bytes, err := os.ReadFile(filepath.Join(dir, fileName))
+	// The witnesses of the old blocks may have been moved to the archive.
+	if errors.Is(err, os.ErrNotExist) && Archive != nil {
+		bytes, err = Archive.Fetch(context.Background(), archive.ClassWitnesses, fileName)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var w reexec.Witness
+	err = json.Unmarshal(bytes, &w)
+	if err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// WitnessHash returns the hash of the witness of the block at the height leading to the commitment,
+// empty if the witness isn't kept or is of a block replaced by a reorg.
+func WitnessHash(height uint, commitment [32]byte) string {
+	w, err := RecentWitness(height)
+	if err != nil || w.PostCommitment != base64.StdEncoding.EncodeToString(commitment[:]) {
+		return ""
+	}
+	hash, err := w.Hash()
+	if err != nil {
+		return ""
+	}
+	return hash
+}
+
+// KeepRecentWitnesses keeps the witnesses of at least the latest n blocks in memory.
+func KeepRecentWitnesses(n uint) {
+	if KeepWitnesses < n {
+		KeepWitnesses = n
+	}
+}
+
+func recordWitness(header *Header, ots []getter.OrdTransfer, blockHeight uint) {
+	witness, err := NewWitness(header, ots, blockHeight)
+	if err != nil {
+		log.Printf("Failed to record the witness at height %d: %v", blockHeight, err)
+		return
+	}
+	header.witness = witness
+}
+
+func exportWitness(header *Header) {
+	if header.witness == nil {
+		return
+	}
+	SealWitness(header.witness, header)
+	if WitnessPath != "" {
+		err := StoreWitness(WitnessPath, header.witness)
+		if err != nil {
+			log.Printf("Failed to store the witness at height %d: %v", header.witness.Height, err)
+		}
+	}
+	if KeepWitnesses != 0 {
+		keepWitness(header.witness)
+	}
+	header.witness = nil
+}
+
+func keepWitness(w *reexec.Witness) {
+	recentMu.Lock()
+	defer recentMu.Unlock()
+	recentWitnesses[w.Height] = w
+	for height := range recentWitnesses {
+		if height+KeepWitnesses <= w.Height || height > w.Height {
+			// The witnesses above the height are of the blocks rolled back by a reorg.
+			delete(recentWitnesses, height)
+		}
+	}
+}
+
+// RecentWitness returns the witness of the block at the height, kept in memory or exported to WitnessPath.
+func RecentWitness(height uint) (*reexec.Witness, error) {
+	recentMu.Lock()
+	w, found := recentWitnesses[height]
+	recentMu.Unlock()
+	if found {
+		return w, nil
+	}
+	if WitnessPath == "" {
+		return nil, fmt.Errorf("the witness at height %d is no longer kept", height)
+	}
+	return LoadWitness(WitnessPath, height)
+}
@@ -0,0 +1,224 @@
+// Package subscription pushes the published checkpoints, and the events and the balance changes of every executed block
+// to the subscribers, so that they don't poll the APIs per block. It only observes the execution and never changes the state root.
+package subscription
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord/brc20"
+)
+
+const (
+	TypeCheckpoint = "checkpoint"
+	TypeBalances   = "balances"
+	TypeEvents     = "events"
+)
+
+// The number of the messages buffered for a subscriber, which is dropped once it falls further behind.
+const SubscriberBuffer = 256
+
+// BalanceChange is the balances of a pkscript on a tick after a block which may have changed them.
+type BalanceChange struct {
+	Tick             string `json:"tick"`
+	Pkscript         string `json:"pkscript"`
+	AvailableBalance string `json:"availableBalance"`
+	OverallBalance   string `json:"overallBalance"`
+}
+
+// Message is pushed to the subscribers. A block executed again after a reorg is pushed again with its new balances.
+type Message struct {
+	Type   string `json:"type"`
+	Height uint   `json:"height"`
+	Hash   string `json:"hash,omitempty"`
+	// The checkpoint as published, only set for TypeCheckpoint.
+	Checkpoint json.RawMessage `json:"checkpoint,omitempty"`
+	// The state root committing the balances, so that they can be proven against the checkpoint of the block.
+	Commitment string          `json:"commitment,omitempty"`
+	Changes    []BalanceChange `json:"changes,omitempty"`
+	// The events of the block tagged by their types, as encoded by brc20.MarshalEvents, only set for TypeEvents.
+	Events json.RawMessage `json:"events,omitempty"`
+}
+
+// Filter selects the messages of a subscriber. A balance change matches if either its tick or its pkscript is subscribed.
+// The events of every block are pushed to the subscribers of the events, even if there is none.
+type Filter struct {
+	Checkpoints bool     `json:"checkpoints"`
+	Events      bool     `json:"events"`
+	Ticks       []string `json:"ticks"`
+	Pkscripts   []string `json:"pkscripts"`
+}
+
+type Subscriber struct {
+	ch     chan Message
+	filter struct {
+		checkpoints bool
+		events      bool
+		ticks       map[string]bool
+		pkscripts   map[string]bool
+	}
+}
+
+// Messages returns the channel of the pushed messages, which is closed once the subscriber is dropped.
+func (s *Subscriber) Messages() <-chan Message {
+	return s.ch
+}
+
+func (s *Subscriber) matches(change BalanceChange) bool {
+	return s.filter.ticks[change.Tick] || s.filter.pkscripts[change.Pkscript]
+}
+
+type Hub struct {
+	sync.Mutex
+	maxSubscribers int
+	subscribers    map[*Subscriber]struct{}
+}
+
+// NewHub accepts at most maxSubscribers subscribers at once.
+func NewHub(maxSubscribers int) *Hub {
+	return &Hub{
+		maxSubscribers: maxSubscribers,
+		subscribers:    make(map[*Subscriber]struct{}),
+	}
+}
+
+// Subscribe adds a subscriber without any filter, false if there are too many subscribers already.
+func (h *Hub) Subscribe() (*Subscriber, bool) {
+	h.Lock()
+	defer h.Unlock()
+	if len(h.subscribers) >= h.maxSubscribers {
+		return nil, false
+	}
+	s := &Subscriber{ch: make(chan Message, SubscriberBuffer)}
+	s.filter.ticks = make(map[string]bool)
+	s.filter.pkscripts = make(map[string]bool)
+	h.subscribers[s] = struct{}{}
+	return s, true
+}
+
+// Unsubscribe drops the subscriber and closes its channel.
+func (h *Hub) Unsubscribe(s *Subscriber) {
+	h.Lock()
+	defer h.Unlock()
+	h.drop(s)
+}
+
+func (h *Hub) drop(s *Subscriber) {
+	if _, found := h.subscribers[s]; found {
+		delete(h.subscribers, s)
+		close(s.ch)
+	}
+}
+
+// Update adds the filter to the subscriber, or removes it if remove is set, and returns the resulting filter.
+func (h *Hub) Update(s *Subscriber, filter Filter, remove bool) Filter {
+	h.Lock()
+	defer h.Unlock()
+	if filter.Checkpoints {
+		s.filter.checkpoints = !remove
+	}
+	if filter.Events {
+		s.filter.events = !remove
+	}
+	for _, tick := range filter.Ticks {
+		if remove {
+			delete(s.filter.ticks, brc20.NormalizeTick(tick))
+		} else {
+			s.filter.ticks[brc20.NormalizeTick(tick)] = true
+		}
+	}
+	for _, pkscript := range filter.Pkscripts {
+		if remove {
+			delete(s.filter.pkscripts, strings.ToLower(pkscript))
+		} else {
+			s.filter.pkscripts[strings.ToLower(pkscript)] = true
+		}
+	}
+	res := Filter{Checkpoints: s.filter.checkpoints, Events: s.filter.events, Ticks: make([]string, 0), Pkscripts: make([]string, 0)}
+	for tick := range s.filter.ticks {
+		res.Ticks = append(res.Ticks, tick)
+	}
+	for pkscript := range s.filter.pkscripts {
+		res.Pkscripts = append(res.Pkscripts, pkscript)
+	}
+	return res
+}
+
+// WatchesBalances tells whether any subscriber follows the balance changes, which are only read if so.
+func (h *Hub) WatchesBalances() bool {
+	h.Lock()
+	defer h.Unlock()
+	for s := range h.subscribers {
+		if len(s.filter.ticks) != 0 || len(s.filter.pkscripts) != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// WatchesEvents tells whether any subscriber follows the events of the blocks.
+func (h *Hub) WatchesEvents() bool {
+	h.Lock()
+	defer h.Unlock()
+	for s := range h.subscribers {
+		if s.filter.events {
+			return true
+		}
+	}
+	return false
+}
+
+// push sends the message to the subscriber, dropping it if its buffer is full.
+// The dropped subscriber reconnects and catches up by the APIs.
+func (h *Hub) push(s *Subscriber, m Message) {
+	select {
+	case s.ch <- m:
+	default:
+		h.drop(s)
+	}
+}
+
+// PublishCheckpoint pushes the published checkpoint of the block to the subscribers of the checkpoints.
+func (h *Hub) PublishCheckpoint(height uint, hash string, checkpoint any) error {
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+	h.Lock()
+	defer h.Unlock()
+	for s := range h.subscribers {
+		if s.filter.checkpoints {
+			h.push(s, Message{Type: TypeCheckpoint, Height: height, Hash: hash, Checkpoint: data})
+		}
+	}
+	return nil
+}
+
+// PublishBalances pushes the balance changes of the executed block to the subscribers of their ticks or pkscripts.
+func (h *Hub) PublishBalances(height uint, hash string, commitment string, changes []BalanceChange) {
+	h.Lock()
+	defer h.Unlock()
+	for s := range h.subscribers {
+		matched := make([]BalanceChange, 0)
+		for _, change := range changes {
+			if s.matches(change) {
+				matched = append(matched, change)
+			}
+		}
+		if len(matched) != 0 {
+			h.push(s, Message{Type: TypeBalances, Height: height, Hash: hash, Commitment: commitment, Changes: matched})
+		}
+	}
+}
+
+// PublishEvents pushes the encoded events of the executed block to the subscribers of the events.
+func (h *Hub) PublishEvents(height uint, hash string, commitment string, events json.RawMessage) {
+	h.Lock()
+	defer h.Unlock()
+	for s := range h.subscribers {
+		if s.filter.events {
+			h.push(s, Message{Type: TypeEvents, Height: height, Hash: hash, Commitment: commitment, Events: events})
+		}
+	}
+}
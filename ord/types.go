@@ -1,5 +1,12 @@
 package ord
 
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
 type TXID string
 
 type Wallet string
@@ -10,6 +17,19 @@ type Pkscript string
 // The number after the i defines the index (starting at 0) of new inscriptions being inscribed in the reveal transaction.
 type InscriptionID string
 
+// IsInscriptionID reports whether the inscription ID is in the format <txid>i<index>.
+func IsInscriptionID(inscriptionID string) bool {
+	txID, index, found := strings.Cut(inscriptionID, "i")
+	if !found || len(txID) != 64 {
+		return false
+	}
+	if _, err := hex.DecodeString(txID); err != nil {
+		return false
+	}
+	_, err := strconv.ParseUint(index, 10, 64)
+	return err == nil
+}
+
 // Example: 680df1e4d43016571e504b0b142ee43c5c0b83398a97bdcfd94ea6f287322d22:0
 // An outpoint consists of a transaction ID and output index.
 type OutPoint struct {
@@ -26,3 +46,60 @@ type SatPoint struct {
 	outPoint OutPoint
 	offset   uint64
 }
+
+// TODO: High. Record Old satpoint- Current satpoint to get OrdTransfer from the Bitcoin block directly.
+type OrdTransfer struct {
+	ID            uint     `json:"ID"`
+	InscriptionID string   `json:"inscriptionID"`
+	BlockHeight   uint     `json:"blockHeight"`
+	OldSatpoint   string   `json:"oldSatpoint"`
+	NewSatpoint   string   `json:"newSatpoint"`
+	NewPkscript   Pkscript `json:"newPkscript"`
+	NewWallet     Wallet   `json:"newWallet"`
+	SentAsFee     bool     `json:"sentAsFee"`
+	Content       []byte   `json:"content"`
+	ContentType   string   `json:"contentType"`
+	ParentID      string   `json:"parentID"`
+}
+
+func (p OutPoint) TxID() TXID {
+	return p.txID
+}
+
+// OutputIndex returns the index of the output within the transaction.
+func (p OutPoint) OutputIndex() uint64 {
+	return p.offset
+}
+
+func (s SatPoint) OutPoint() OutPoint {
+	return s.outPoint
+}
+
+// Offset returns the offset of the sat within the output.
+func (s SatPoint) Offset() uint64 {
+	return s.offset
+}
+
+func (s SatPoint) String() string {
+	return fmt.Sprintf("%s:%d:%d", s.outPoint.txID, s.outPoint.offset, s.offset)
+}
+
+// ParseSatPoint parses a satpoint in the format <txid>:<output index>:<offset>.
+func ParseSatPoint(satpoint string) (SatPoint, error) {
+	parts := strings.Split(satpoint, ":")
+	if len(parts) != 3 || len(parts[0]) != 64 {
+		return SatPoint{}, fmt.Errorf("invalid satpoint %s", satpoint)
+	}
+	if _, err := hex.DecodeString(parts[0]); err != nil {
+		return SatPoint{}, fmt.Errorf("invalid txid of the satpoint %s: %v", satpoint, err)
+	}
+	index, err := strconv.ParseUint(parts[1], 10, 32)
+	if err != nil {
+		return SatPoint{}, fmt.Errorf("invalid output index of the satpoint %s: %v", satpoint, err)
+	}
+	offset, err := strconv.ParseUint(parts[2], 10, 64)
+	if err != nil {
+		return SatPoint{}, fmt.Errorf("invalid offset of the satpoint %s: %v", satpoint, err)
+	}
+	return SatPoint{outPoint: OutPoint{txID: TXID(parts[0]), offset: index}, offset: offset}, nil
+}
@@ -0,0 +1,298 @@
+// Package watchlist keeps the detailed activity of a configured set of wallets and pkscripts,
+// so that their owners get deep visibility without archiving the events of every address.
+// It only observes the execution and never changes the state root.
+package watchlist
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/brc20"
+)
+
+type Config struct {
+	Wallets   []string `json:"wallets"`
+	Pkscripts []string `json:"pkscripts"`
+	// The max number of kept events, the oldest ones are evicted first.
+	MaxEvents int `json:"maxEvents"`
+}
+
+const (
+	DirectionIn  = "in"
+	DirectionOut = "out"
+)
+
+const (
+	TypeApply = "apply"
+	// A revert compensates an event of a block replaced by a reorg, in the reverse order of the events.
+	TypeRevert = "revert"
+)
+
+// Event is an ord transfer touching a watched address, along with the balances of the address after the block.
+type Event struct {
+	Seq  uint64 `json:"seq"`
+	Type string `json:"type"`
+	// The sequence number of the reverted event, only set for TypeRevert.
+	Reverts       uint64 `json:"reverts,omitempty"`
+	Height        uint   `json:"height"`
+	Address       string `json:"address"`
+	Pkscript      string `json:"pkscript"`
+	InscriptionID string `json:"inscriptionID"`
+	Op            string `json:"op"`
+	Tick          string `json:"tick"`
+	Amount        string `json:"amount"`
+	// DirectionIn if the inscription is inscribed to or received by the address, DirectionOut if it leaves the address.
+	Direction   string `json:"direction"`
+	OldSatpoint string `json:"oldSatpoint"`
+	NewSatpoint string `json:"newSatpoint"`
+	SentAsFee   bool   `json:"sentAsFee"`
+	// The balances of the pkscript on the tick after the block.
+	AvailableBalance string `json:"availableBalance"`
+	OverallBalance   string `json:"overallBalance"`
+	// The state root committing the balances, so that they can be proven against the checkpoint of the block.
+	Commitment string `json:"commitment"`
+}
+
+type held struct {
+	address  string
+	pkscript string
+}
+
+// ArchiveSegment is the min number of the events trimmed at once into the archive.
+const ArchiveSegment = 1000
+
+// Archive keeps the events trimmed from the memory, so that the older events can still be queried.
+type Archive interface {
+	StoreEvents(events []Event) error
+	// LoadEvents returns at most limit matching events after the sequence number since and before the sequence number before.
+	LoadEvents(since, before uint64, match func(Event) bool, limit int) ([]Event, error)
+}
+
+// BalanceFn reads the available and overall balances of the pkscript on the tick after the block.
+type BalanceFn func(tick string, pkscript ord.Pkscript) (string, string)
+
+type Watchlist struct {
+	sync.RWMutex
+	wallets   map[string]bool
+	pkscripts map[string]bool
+	maxEvents int
+
+	// The inscriptions held by the watched addresses, to detect them leaving.
+	held        map[string]held
+	events      []Event
+	seq         uint64
+	subscribers map[chan Event]struct{}
+	archive     Archive
+}
+
+func New(cfg Config) *Watchlist {
+	w := &Watchlist{
+		wallets:     make(map[string]bool),
+		pkscripts:   make(map[string]bool),
+		maxEvents:   cfg.MaxEvents,
+		held:        make(map[string]held),
+		subscribers: make(map[chan Event]struct{}),
+	}
+	if w.maxEvents <= 0 {
+		w.maxEvents = 100000
+	}
+	for _, wallet := range cfg.Wallets {
+		w.wallets[wallet] = true
+	}
+	for _, pkscript := range cfg.Pkscripts {
+		w.pkscripts[strings.ToLower(pkscript)] = true
+	}
+	return w
+}
+
+// SetArchive archives the trimmed events instead of discarding them.
+func (w *Watchlist) SetArchive(a Archive) {
+	w.Lock()
+	defer w.Unlock()
+	w.archive = a
+}
+
+// watched returns the watched address matching the wallet or the pkscript.
+func (w *Watchlist) watched(wallet ord.Wallet, pkscript ord.Pkscript) (string, bool) {
+	if w.wallets[string(wallet)] {
+		return string(wallet), true
+	}
+	if w.pkscripts[strings.ToLower(string(pkscript))] {
+		return string(pkscript), true
+	}
+	return "", false
+}
+
+func parseContent(content []byte) (string, string, string) {
+	var js map[string]interface{}
+	if err := json.Unmarshal(content, &js); err != nil {
+		return "", "", ""
+	}
+	field := func(name string) string {
+		if v, ok := js[name]; ok {
+			return fmt.Sprint(v)
+		}
+		return ""
+	}
+	return field("op"), brc20.NormalizeTick(field("tick")), field("amt")
+}
+
+// emit assigns the next sequence number to the event and notifies the subscribers.
+func (w *Watchlist) emit(e Event) {
+	w.seq++
+	e.Seq = w.seq
+	w.events = append(w.events, e)
+	for ch := range w.subscribers {
+		select {
+		case ch <- e:
+		default:
+			// Drop the notification for the slow subscriber, which can catch up by Events.
+		}
+	}
+}
+
+// revert compensates the applied events from the height on, from the latest one, and restores the held inscriptions.
+func (w *Watchlist) revert(blockHeight uint) {
+	// The events still applied are in the order of the heights, and a revert always follows its event.
+	reverted := make(map[uint64]bool)
+	for i := len(w.events) - 1; i >= 0; i-- {
+		e := w.events[i]
+		if e.Type == TypeRevert {
+			reverted[e.Reverts] = true
+			continue
+		}
+		if reverted[e.Seq] {
+			continue
+		}
+		if e.Height < blockHeight {
+			break
+		}
+		if e.Direction == DirectionIn {
+			delete(w.held, e.InscriptionID)
+		} else {
+			w.held[e.InscriptionID] = held{address: e.Address, pkscript: e.Pkscript}
+		}
+		r := e
+		r.Type, r.Reverts = TypeRevert, e.Seq
+		w.emit(r)
+	}
+}
+
+// Observe records the events of the block after its execution.
+// Observing a height again, e.g. after a reorg, reverts the events from the height on before recording the new ones.
+func (w *Watchlist) Observe(ots []ord.OrdTransfer, blockHeight uint, commitment string, balance BalanceFn) {
+	w.Lock()
+	defer w.Unlock()
+
+	w.revert(blockHeight)
+
+	newEvents := make([]Event, 0)
+	for _, ot := range ots {
+		op, tick, amount := parseContent(ot.Content)
+		event := Event{
+			Type:          TypeApply,
+			Height:        blockHeight,
+			InscriptionID: ot.InscriptionID,
+			Op:            op,
+			Tick:          tick,
+			Amount:        amount,
+			OldSatpoint:   ot.OldSatpoint,
+			NewSatpoint:   ot.NewSatpoint,
+			SentAsFee:     ot.SentAsFee,
+			Commitment:    commitment,
+		}
+		if h, found := w.held[ot.InscriptionID]; found && ot.OldSatpoint != "" {
+			out := event
+			out.Address, out.Pkscript, out.Direction = h.address, h.pkscript, DirectionOut
+			newEvents = append(newEvents, out)
+			delete(w.held, ot.InscriptionID)
+		}
+		if address, found := w.watched(ot.NewWallet, ot.NewPkscript); found && !ot.SentAsFee {
+			in := event
+			in.Address, in.Pkscript, in.Direction = address, string(ot.NewPkscript), DirectionIn
+			newEvents = append(newEvents, in)
+			w.held[ot.InscriptionID] = held{address: address, pkscript: string(ot.NewPkscript)}
+		}
+	}
+
+	for _, e := range newEvents {
+		if e.Tick != "" {
+			e.AvailableBalance, e.OverallBalance = balance(e.Tick, ord.Pkscript(e.Pkscript))
+		}
+		w.emit(e)
+	}
+	if w.archive != nil {
+		// The trimmed events are archived in segments rather than one by one.
+		if len(w.events) >= w.maxEvents+ArchiveSegment {
+			trimmed := w.events[:len(w.events)-w.maxEvents]
+			if err := w.archive.StoreEvents(trimmed); err != nil {
+				// Keep them until the next block.
+				log.Printf("Failed to archive %d watchlist events: %v", len(trimmed), err)
+				return
+			}
+			w.events = append([]Event{}, w.events[len(trimmed):]...)
+		}
+	} else if len(w.events) > w.maxEvents {
+		w.events = append([]Event{}, w.events[len(w.events)-w.maxEvents:]...)
+	}
+}
+
+// Events returns at most limit events of the address after the sequence number since. An empty address matches all.
+// The events trimmed from the memory are retrieved from the archive, if any. If the archive fails,
+// only the events before the failure are returned, so that none is skipped.
+func (w *Watchlist) Events(address string, since uint64, limit int) []Event {
+	match := func(e Event) bool { return address == "" || e.Address == address }
+	res := make([]Event, 0)
+	for {
+		w.RLock()
+		first, archive := w.seq+1, w.archive
+		if len(w.events) != 0 {
+			first = w.events[0].Seq
+		}
+		if archive == nil || since+1 >= first {
+			break
+		}
+		w.RUnlock()
+		archived, err := archive.LoadEvents(since, first, match, limit-len(res))
+		if err != nil {
+			log.Printf("Failed to load the archived watchlist events after %d: %v", since, err)
+			return res
+		}
+		res = append(res, archived...)
+		if len(res) == limit {
+			return res
+		}
+		since = first - 1
+	}
+	defer w.RUnlock()
+	for _, e := range w.events {
+		if e.Seq <= since || !match(e) {
+			continue
+		}
+		res = append(res, e)
+		if len(res) == limit {
+			break
+		}
+	}
+	return res
+}
+
+// Subscribe notifies the new events instantly until the returned cancel function is called.
+func (w *Watchlist) Subscribe() (<-chan Event, func()) {
+	w.Lock()
+	defer w.Unlock()
+	ch := make(chan Event, 256)
+	w.subscribers[ch] = struct{}{}
+	return ch, func() {
+		w.Lock()
+		defer w.Unlock()
+		if _, found := w.subscribers[ch]; found {
+			delete(w.subscribers, ch)
+			close(ch)
+		}
+	}
+}
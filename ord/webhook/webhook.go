@@ -0,0 +1,318 @@
+// Package webhook POSTs the events of every executed block matching the filters of the configured webhooks, so that
+// the downstream services notice the deposits without polling the APIs. It only observes the execution and never
+// changes the state root.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/RiemaLabs/modular-indexer-committee/internal/metrics"
+	"github.com/RiemaLabs/modular-indexer-committee/internal/retry"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/brc20"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/subscription"
+)
+
+const (
+	// The header carrying the hex HMAC-SHA256 of the body by the secret of the webhook, prefixed by "sha256=".
+	SignatureHeader = "X-Signature-256"
+	// The header carrying the ID of the delivery, i.e. the height and the hash of the block, which is the same for
+	// every retry so that the receivers drop the duplicates.
+	DeliveryHeader = "X-Delivery"
+)
+
+const (
+	DefaultTimeout    = 10 * time.Second
+	DefaultMaxPending = 1000
+)
+
+type Hook struct {
+	URL string `json:"url"`
+	// The key signing the bodies, which may refer to a secret. Empty sends the bodies unsigned.
+	Secret string `json:"secret"`
+	// The ticks of the matched events. Empty matches every tick.
+	Ticks []string `json:"ticks"`
+	// The wallets or the pkscripts involved in the matched events, on either side of a transfer. Empty matches every
+	// address.
+	Addresses []string `json:"addresses"`
+	// The types of the matched events, e.g. "mint-inscribe" or "transfer-transfer". Empty matches every type.
+	Events []string `json:"events"`
+	// Whether to send the balances after the block of the pkscripts involved in the matched events.
+	Balances bool `json:"balances"`
+}
+
+type Config struct {
+	Hooks []Hook `json:"hooks"`
+	// The retries of a failed delivery, along with the circuit of every webhook, see retry.Config.
+	Retry retry.Config `json:"retry"`
+	// The milliseconds of a single POST, 10000 by default.
+	Timeout int `json:"timeout"`
+	// The max number of the blocks waiting to be delivered to a webhook, 1000 by default, the oldest ones being dropped.
+	MaxPending int `json:"maxPending"`
+}
+
+// Payload is POSTed to a webhook once a block has matched events. A block executed again after a reorg is POSTed
+// again with its new hash and events.
+type Payload struct {
+	Height uint   `json:"height"`
+	Hash   string `json:"hash"`
+	// The state root committing the balances, so that they can be proven against the checkpoint of the block.
+	Commitment string `json:"commitment"`
+	// The matched events tagged by their types, as encoded by brc20.MarshalEvents.
+	Events json.RawMessage `json:"events"`
+	// The balances of the pkscripts involved in the matched events, only set if the webhook asks for them.
+	Balances []subscription.BalanceChange `json:"balances,omitempty"`
+}
+
+// Sign returns the signature of the body by the secret, as sent in the SignatureHeader.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+type delivery struct {
+	id   string
+	body []byte
+}
+
+type hook struct {
+	url       string
+	secret    string
+	ticks     map[string]bool
+	addresses map[string]bool
+	events    map[brc20.EventType]bool
+	balances  bool
+
+	breaker *retry.Breaker
+	mu      sync.Mutex
+	pending []delivery
+	wake    chan struct{}
+}
+
+// matches tells whether the event is of a matched type and tick, and involves a matched address.
+func (h *hook) matches(event brc20.Event) bool {
+	if len(h.events) != 0 && !h.events[event.Type()] {
+		return false
+	}
+	var tick string
+	var addresses []string
+	switch e := event.(type) {
+	case brc20.DeployEvent:
+		tick, addresses = e.Tick, []string{string(e.Wallet), string(e.Pkscript)}
+	case brc20.MintEvent:
+		tick, addresses = e.Tick, []string{string(e.Wallet), string(e.Pkscript)}
+	case brc20.TransferInscribeEvent:
+		tick, addresses = e.Tick, []string{string(e.Wallet), string(e.Pkscript)}
+	case brc20.TransferTransferEvent:
+		tick = e.Tick
+		addresses = []string{string(e.SourceWallet), string(e.SourcePkscript), string(e.SpentWallet), string(e.SpentPkscript)}
+	default:
+		return false
+	}
+	if len(h.ticks) != 0 && !h.ticks[tick] {
+		return false
+	}
+	if len(h.addresses) == 0 {
+		return true
+	}
+	for _, address := range addresses {
+		if address != "" && (h.addresses[address] || h.addresses[strings.ToLower(address)]) {
+			return true
+		}
+	}
+	return false
+}
+
+// BalanceFn reads the balances after the block of the pkscripts involved in the events.
+type BalanceFn func(events []brc20.Event) []subscription.BalanceChange
+
+type Notifier struct {
+	hooks      []*hook
+	client     *http.Client
+	maxPending int
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// New starts delivering to the webhooks of the config, whose secrets are resolved by secret, until Stop is called.
+func New(cfg Config, secret func(string) string) (*Notifier, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	n := &Notifier{
+		client:     &http.Client{Timeout: DefaultTimeout},
+		maxPending: DefaultMaxPending,
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+	if cfg.Timeout > 0 {
+		n.client.Timeout = time.Duration(cfg.Timeout) * time.Millisecond
+	}
+	if cfg.MaxPending > 0 {
+		n.maxPending = cfg.MaxPending
+	}
+	for i, c := range cfg.Hooks {
+		if !strings.HasPrefix(c.URL, "http://") && !strings.HasPrefix(c.URL, "https://") {
+			cancel()
+			return nil, fmt.Errorf("invalid URL of the webhook %d: %q", i, c.URL)
+		}
+		h := &hook{
+			url:       c.URL,
+			secret:    secret(c.Secret),
+			ticks:     make(map[string]bool),
+			addresses: make(map[string]bool),
+			events:    make(map[brc20.EventType]bool),
+			balances:  c.Balances,
+			breaker:   retry.NewBreaker(fmt.Sprintf("webhook %d", i), cfg.Retry),
+			wake:      make(chan struct{}, 1),
+		}
+		for _, tick := range c.Ticks {
+			h.ticks[brc20.NormalizeTick(tick)] = true
+		}
+		for _, address := range c.Addresses {
+			h.addresses[address] = true
+			h.addresses[strings.ToLower(address)] = true
+		}
+		for _, event := range c.Events {
+			switch t := brc20.EventType(event); t {
+			case brc20.EventDeployInscribe, brc20.EventMintInscribe, brc20.EventTransferInscribe, brc20.EventTransferTransfer:
+				h.events[t] = true
+			default:
+				cancel()
+				return nil, fmt.Errorf("unknown event type of the webhook %d: %q", i, event)
+			}
+		}
+		n.hooks = append(n.hooks, h)
+	}
+	for _, h := range n.hooks {
+		n.wg.Add(1)
+		go n.deliver(h)
+	}
+	return n, nil
+}
+
+// Stop stops the deliveries, dropping the pending ones, and waits for the ongoing ones.
+func (n *Notifier) Stop() {
+	n.cancel()
+	n.wg.Wait()
+}
+
+// Notify queues the matched events of the executed block to every webhook, without waiting for the deliveries.
+func (n *Notifier) Notify(height uint, hash string, commitment string, events []brc20.Event, balances BalanceFn) {
+	id := fmt.Sprintf("%d-%s", height, hash)
+	for _, h := range n.hooks {
+		matched := make([]brc20.Event, 0)
+		for _, event := range events {
+			if h.matches(event) {
+				matched = append(matched, event)
+			}
+		}
+		if len(matched) == 0 {
+			continue
+		}
+		data, err := brc20.MarshalEvents(matched)
+		if err != nil {
+			panic(err)
+		}
+		payload := Payload{Height: height, Hash: hash, Commitment: commitment, Events: data}
+		if h.balances {
+			payload.Balances = balances(matched)
+		}
+		body, err := json.Marshal(payload)
+		if err != nil {
+			panic(err)
+		}
+		h.mu.Lock()
+		h.pending = append(h.pending, delivery{id: id, body: body})
+		if dropped := len(h.pending) - n.maxPending; dropped > 0 {
+			log.Printf("Drop %d blocks pending for the webhook %s", dropped, h.url)
+			metrics.WebhookDeliveries.WithLabelValues("dropped").Add(float64(dropped))
+			h.pending = append([]delivery{}, h.pending[dropped:]...)
+		}
+		h.mu.Unlock()
+		select {
+		case h.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// deliver POSTs the pending blocks to the webhook in the order of the blocks. A block failing every retry is retried
+// after the pause of the circuit, so that an unreachable webhook misses no block unless too many are pending.
+func (n *Notifier) deliver(h *hook) {
+	defer n.wg.Done()
+	for {
+		h.mu.Lock()
+		var d delivery
+		found := len(h.pending) != 0
+		if found {
+			d = h.pending[0]
+		}
+		h.mu.Unlock()
+		if !found {
+			select {
+			case <-h.wake:
+				continue
+			case <-n.ctx.Done():
+				return
+			}
+		}
+		err := h.breaker.Do(n.ctx, func() error {
+			return n.post(h, d)
+		})
+		if err != nil {
+			if n.ctx.Err() != nil {
+				return
+			}
+			metrics.WebhookDeliveries.WithLabelValues("failure").Inc()
+			log.Printf("Failed to deliver the block %s to the webhook %s: %v", d.id, h.url, err)
+			select {
+			case <-time.After(h.breaker.Pause()):
+			case <-n.ctx.Done():
+				return
+			}
+			continue
+		}
+		metrics.WebhookDeliveries.WithLabelValues("success").Inc()
+		h.mu.Lock()
+		// The delivered block may have been dropped meanwhile.
+		if len(h.pending) != 0 && h.pending[0].id == d.id && bytes.Equal(h.pending[0].body, d.body) {
+			h.pending = h.pending[1:]
+		}
+		h.mu.Unlock()
+	}
+}
+
+func (n *Notifier) post(h *hook, d delivery) error {
+	req, err := http.NewRequestWithContext(n.ctx, http.MethodPost, h.url, bytes.NewReader(d.body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(DeliveryHeader, d.id)
+	if h.secret != "" {
+		req.Header.Set(SignatureHeader, Sign(h.secret, d.body))
+	}
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+package peer
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// The number of the state keys sampled per block by default.
+const DefaultAuditSamples = 16
+
+// AuditConfig sets up the sampling audits, where the members compare a few state keys of every block
+// instead of waiting for the divergence of the state roots to be noticed.
+type AuditConfig struct {
+	// The members audited after each block, empty disables the audits.
+	Members []Member `json:"members"`
+	// The number of the state keys sampled per block, DefaultAuditSamples if 0.
+	Samples int `json:"samples"`
+}
+
+type Member struct {
+	Name string `json:"name"`
+	// The base URL of the committee indexer of the member.
+	URL string `json:"url"`
+	// The bearer token presented to the member, which may refer to a secret.
+	Token string `json:"token"`
+	// The hex of the ed25519 public key of the member, served at /v1/peer/key.
+	PublicKey string `json:"publicKey"`
+}
+
+func (cfg AuditConfig) Validate() error {
+	if cfg.Samples < 0 {
+		return errors.New("the number of the samples must not be negative")
+	}
+	for _, m := range cfg.Members {
+		if m.Name == "" || m.URL == "" {
+			return errors.New("the name and the URL of the audited member are required")
+		}
+		if key, err := hex.DecodeString(m.PublicKey); err != nil || len(key) != ed25519.PublicKeySize {
+			return fmt.Errorf("invalid public key of the member %s", m.Name)
+		}
+	}
+	return nil
+}
+
+// Sample is the value of a sampled key after the block, as the execution reads it.
+type Sample struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	// Whether the key is in the state, which the proof needs to tell apart the default values.
+	Exists bool `json:"exists"`
+}
+
+// Audit is the sample of the state keys of a block.
+type Audit struct {
+	Height uint   `json:"height"`
+	Hash   string `json:"hash"`
+	// The base64 of the verkle commitment after the block.
+	Commitment string   `json:"commitment"`
+	Samples    []Sample `json:"samples"`
+	// The base64 of the multiproof of the samples against the commitment, only if the block is the latest one.
+	Proof     string `json:"proof,omitempty"`
+	Signature string `json:"signature"`
+}
+
+func (a *Audit) message() []byte {
+	m := []byte("audit")
+	m = binary.BigEndian.AppendUint64(m, uint64(a.Height))
+	m = writeString(m, a.Hash)
+	m = writeString(m, a.Commitment)
+	m = binary.BigEndian.AppendUint32(m, uint32(len(a.Samples)))
+	for _, s := range a.Samples {
+		m = writeString(m, s.Key)
+		m = writeString(m, s.Value)
+		if s.Exists {
+			m = append(m, 1)
+		} else {
+			m = append(m, 0)
+		}
+	}
+	m = writeString(m, a.Proof)
+	digest := sha256.Sum256(m)
+	return digest[:]
+}
+
+func (s *Signer) SignAudit(a *Audit) {
+	a.Signature = hex.EncodeToString(ed25519.Sign(s.key, a.message()))
+}
+
+func VerifyAudit(a *Audit, publicKey string) error {
+	if err := verify(publicKey, a.Signature, a.message()); err != nil {
+		return fmt.Errorf("the audit of the block %d: %v", a.Height, err)
+	}
+	return nil
+}
+
+// SampleKeys picks n of the keys by the block hash. The members with the same keys pick the same ones,
+// while nobody can tell in advance which keys of a block are going to be compared.
+func SampleKeys(hash string, keys [][]byte, n int) [][]byte {
+	sorted := make([][]byte, len(keys))
+	copy(sorted, keys)
+	sort.Slice(sorted, func(i, j int) bool { return bytes.Compare(sorted[i], sorted[j]) < 0 })
+	if len(sorted) <= n {
+		return sorted
+	}
+	picked := make([]bool, len(sorted))
+	for i, count := uint32(0), 0; count < n; i++ {
+		seed := sha256.Sum256(binary.BigEndian.AppendUint32([]byte(hash), i))
+		index := binary.BigEndian.Uint64(seed[:8]) % uint64(len(sorted))
+		if !picked[index] {
+			picked[index] = true
+			count++
+		}
+	}
+	samples := make([][]byte, 0, n)
+	for i, key := range sorted {
+		if picked[i] {
+			samples = append(samples, key)
+		}
+	}
+	return samples
+}
+
+// ErrNotComparable is returned if the audits are of different blocks, e.g. a member lags behind or follows a fork.
+var ErrNotComparable = errors.New("the audits are of different blocks")
+
+// Compare returns the mismatches between the local audit and the audit of a member.
+func Compare(local, remote *Audit) ([]string, error) {
+	if local.Height != remote.Height || local.Hash != remote.Hash {
+		return nil, ErrNotComparable
+	}
+	values := make(map[string]string, len(remote.Samples))
+	for _, s := range remote.Samples {
+		values[s.Key] = s.Value
+	}
+	var mismatches []string
+	for _, s := range local.Samples {
+		value, found := values[s.Key]
+		switch {
+		case !found:
+			mismatches = append(mismatches, fmt.Sprintf("the key %s isn't sampled by the member", s.Key))
+		case value != s.Value:
+			mismatches = append(mismatches, fmt.Sprintf("the key %s is %s instead of %s", s.Key, value, s.Value))
+		}
+		delete(values, s.Key)
+	}
+	for key := range values {
+		mismatches = append(mismatches, fmt.Sprintf("the key %s is only sampled by the member", key))
+	}
+	sort.Strings(mismatches)
+	return mismatches, nil
+}
+
+// FetchAudit requests the signed audit of the block from the member.
+func FetchAudit(ctx context.Context, client *http.Client, m Member, token string, height uint) (*Audit, error) {
+	url := fmt.Sprintf("%s/v1/peer/audit?height=%d", strings.TrimRight(m.URL, "/"), height)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	var response struct {
+		Error  *string `json:"error"`
+		Result *Audit  `json:"result"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("invalid response of the member %s with the status %d: %v", m.Name, resp.StatusCode, err)
+	}
+	if response.Error != nil {
+		return nil, fmt.Errorf("the member %s: %s", m.Name, *response.Error)
+	}
+	if response.Result == nil {
+		return nil, fmt.Errorf("the member %s returned no audit", m.Name)
+	}
+	if err := VerifyAudit(response.Result, m.PublicKey); err != nil {
+		return nil, fmt.Errorf("the member %s: %v", m.Name, err)
+	}
+	return response.Result, nil
+}
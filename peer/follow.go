@@ -0,0 +1,64 @@
+package peer
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ErrDiffsPruned is returned if the member no longer keeps the diffs from the height followed, in which case the
+// state has to be resynced from a snapshot.
+var ErrDiffsPruned = errors.New("the diffs are pruned by the member")
+
+// FollowDiffs follows the diffs of the blocks from the height streamed by a member at /v1/peer/diffs, and calls
+// apply with every diff once its signature is verified. It returns once the stream ends, the context is done or
+// apply fails, after which the caller resumes from the block following the diffs applied.
+func FollowDiffs(ctx context.Context, client *http.Client, baseURL, token, publicKey string, from uint, apply func(*Diff) error) error {
+	url := fmt.Sprintf("%s/v1/peer/diffs?from=%d", strings.TrimRight(baseURL, "/"), from)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusGone {
+		return fmt.Errorf("%w: the block %d", ErrDiffsPruned, from)
+	}
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("the diffs are replied with %s", resp.Status)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	// A diff carries every key accessed by its block.
+	scanner.Buffer(nil, 1<<26)
+	for scanner.Scan() {
+		data, found := strings.CutPrefix(scanner.Text(), "data:")
+		if !found {
+			continue
+		}
+		var d Diff
+		if err := json.Unmarshal([]byte(data), &d); err != nil {
+			return fmt.Errorf("invalid diff: %v", err)
+		}
+		if err := VerifyDiff(&d, publicKey); err != nil {
+			return err
+		}
+		if err := apply(&d); err != nil {
+			return err
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,193 @@
+// Package peer authenticates the state updates served to other committee members and standbys,
+// so that they can follow the state of a trusted member instead of executing the raw chain data.
+//
+// A Diff carries the key-values written by a block along with the commitments before and after the block,
+// and a Manifest describes a full snapshot by its order-independent digest. Both are signed with ed25519.
+package peer
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+type Config struct {
+	Enabled bool `json:"enabled"`
+	// The bearer tokens of the peers, which may refer to secrets.
+	Tokens []string `json:"tokens"`
+	// The hex of the ed25519 seed signing the diffs and the snapshots, which may refer to a secret.
+	SigningKey string `json:"signingKey"`
+	// The sampling audits of the other members, which are served the same way.
+	Audit AuditConfig `json:"audit"`
+}
+
+func (cfg Config) Validate() error {
+	if !cfg.Enabled {
+		if len(cfg.Audit.Members) != 0 {
+			return errors.New("the audits require the peer service")
+		}
+		return nil
+	}
+	if len(cfg.Tokens) == 0 {
+		return errors.New("at least one peer token is required")
+	}
+	if cfg.SigningKey == "" {
+		return errors.New("the signing key is required")
+	}
+	return cfg.Audit.Validate()
+}
+
+type Element struct {
+	Key            string `json:"key"`
+	OldValue       string `json:"oldValue"`
+	NewValue       string `json:"newValue"`
+	OldValueExists bool   `json:"oldValueExists"`
+	// Whether the block writes or deletes the key, unlike the keys only read by the block.
+	Written bool `json:"written,omitempty"`
+	Deleted bool `json:"deleted,omitempty"`
+}
+
+// Diff is the state update of a block.
+type Diff struct {
+	Height     uint   `json:"height"`
+	Hash       string `json:"hash"`
+	ParentHash string `json:"parentHash"`
+	// The base64 of the verkle commitments before and after the block.
+	PreCommitment  string    `json:"preCommitment"`
+	PostCommitment string    `json:"postCommitment"`
+	Elements       []Element `json:"elements"`
+	Signature      string    `json:"signature"`
+}
+
+// Manifest describes a snapshot, which is followed by its key-values.
+type Manifest struct {
+	Height     uint   `json:"height"`
+	Hash       string `json:"hash"`
+	Commitment string `json:"commitment"`
+	// The hex of the order-independent digest of the key-values, see stateless.Digest.
+	Digest    string `json:"digest"`
+	Size      int    `json:"size"`
+	Signature string `json:"signature"`
+}
+
+type KeyValue struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+func writeString(h []byte, s string) []byte {
+	h = binary.BigEndian.AppendUint32(h, uint32(len(s)))
+	return append(h, s...)
+}
+
+// message returns the signed bytes of the diff, every field but the signature.
+func (d *Diff) message() []byte {
+	m := []byte("diff")
+	m = binary.BigEndian.AppendUint64(m, uint64(d.Height))
+	m = writeString(m, d.Hash)
+	m = writeString(m, d.ParentHash)
+	m = writeString(m, d.PreCommitment)
+	m = writeString(m, d.PostCommitment)
+	m = binary.BigEndian.AppendUint32(m, uint32(len(d.Elements)))
+	for _, e := range d.Elements {
+		m = writeString(m, e.Key)
+		m = writeString(m, e.OldValue)
+		m = writeString(m, e.NewValue)
+		var flags byte
+		if e.OldValueExists {
+			flags |= 1
+		}
+		if e.Written {
+			flags |= 2
+		}
+		if e.Deleted {
+			flags |= 4
+		}
+		m = append(m, flags)
+	}
+	digest := sha256.Sum256(m)
+	return digest[:]
+}
+
+func (mf *Manifest) message() []byte {
+	m := []byte("snapshot")
+	m = binary.BigEndian.AppendUint64(m, uint64(mf.Height))
+	m = writeString(m, mf.Hash)
+	m = writeString(m, mf.Commitment)
+	m = writeString(m, mf.Digest)
+	m = binary.BigEndian.AppendUint64(m, uint64(mf.Size))
+	digest := sha256.Sum256(m)
+	return digest[:]
+}
+
+type Signer struct {
+	key ed25519.PrivateKey
+}
+
+func NewSigner(seedHex string) (*Signer, error) {
+	seed, err := hex.DecodeString(seedHex)
+	if err != nil {
+		return nil, fmt.Errorf("the signing key isn't hex: %v", err)
+	}
+	if len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("the signing key must be a %d-byte ed25519 seed", ed25519.SeedSize)
+	}
+	return &Signer{key: ed25519.NewKeyFromSeed(seed)}, nil
+}
+
+// PublicKey returns the hex of the public key verifying the signatures.
+func (s *Signer) PublicKey() string {
+	return hex.EncodeToString(s.key.Public().(ed25519.PublicKey))
+}
+
+func (s *Signer) SignDiff(d *Diff) {
+	d.Signature = hex.EncodeToString(ed25519.Sign(s.key, d.message()))
+}
+
+func (s *Signer) SignManifest(m *Manifest) {
+	m.Signature = hex.EncodeToString(ed25519.Sign(s.key, m.message()))
+}
+
+func verify(publicKey, signature string, message []byte) error {
+	key, err := hex.DecodeString(publicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return errors.New("invalid public key")
+	}
+	sig, err := hex.DecodeString(signature)
+	if err != nil || !ed25519.Verify(key, message, sig) {
+		return errors.New("invalid signature")
+	}
+	return nil
+}
+
+func VerifyDiff(d *Diff, publicKey string) error {
+	if err := verify(publicKey, d.Signature, d.message()); err != nil {
+		return fmt.Errorf("the diff of the block %d: %v", d.Height, err)
+	}
+	return nil
+}
+
+func VerifyManifest(m *Manifest, publicKey string) error {
+	if err := verify(publicKey, m.Signature, m.message()); err != nil {
+		return fmt.Errorf("the snapshot at the height %d: %v", m.Height, err)
+	}
+	return nil
+}
+
+// Authorized reports whether the token is one of the tokens, in constant time per token.
+func Authorized(token string, tokens []string) bool {
+	if token == "" {
+		return false
+	}
+	authorized := false
+	for _, t := range tokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(t)) == 1 {
+			authorized = true
+		}
+	}
+	return authorized
+}
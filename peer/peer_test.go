@@ -0,0 +1,83 @@
+package peer
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSignDiff(t *testing.T) {
+	signer, err := NewSigner(strings.Repeat("ab", 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := Diff{
+		Height:         780001,
+		ParentHash:     "00000000000000000002",
+		PreCommitment:  "pre",
+		PostCommitment: "post",
+		Elements:       []Element{{Key: "01", OldValue: "02", NewValue: "03"}},
+	}
+	signer.SignDiff(&d)
+	if err := VerifyDiff(&d, signer.PublicKey()); err != nil {
+		t.Fatal(err)
+	}
+
+	tampered := d
+	tampered.Elements = []Element{{Key: "01", OldValue: "02", NewValue: "03", OldValueExists: true}}
+	if VerifyDiff(&tampered, signer.PublicKey()) == nil {
+		t.Fatal("Expected the tampered diff to be rejected")
+	}
+	tampered.Elements = []Element{{Key: "01", OldValue: "02", NewValue: "03", Written: true}}
+	if VerifyDiff(&tampered, signer.PublicKey()) == nil {
+		t.Fatal("Expected the diff writing a read key to be rejected")
+	}
+	other, _ := NewSigner(strings.Repeat("cd", 32))
+	if VerifyDiff(&d, other.PublicKey()) == nil {
+		t.Fatal("Expected the signature of another key to be rejected")
+	}
+
+	if _, err := NewSigner("ab"); err == nil {
+		t.Fatal("Expected the short seed to be rejected")
+	}
+	if Authorized("", []string{""}) || !Authorized("b", []string{"a", "b"}) {
+		t.Fatal("Unexpected authorization")
+	}
+}
+
+func TestSampleKeys(t *testing.T) {
+	var keys [][]byte
+	for i := 0; i < 100; i++ {
+		keys = append(keys, []byte{byte(i)})
+	}
+	samples := SampleKeys("hash", keys, 10)
+	if len(samples) != 10 {
+		t.Fatalf("Expected 10 samples, got %d", len(samples))
+	}
+	reversed := make([][]byte, len(keys))
+	for i, key := range keys {
+		reversed[len(keys)-1-i] = key
+	}
+	if fmt.Sprint(SampleKeys("hash", reversed, 10)) != fmt.Sprint(samples) {
+		t.Fatal("Expected the samples not to depend on the order of the keys")
+	}
+	if fmt.Sprint(SampleKeys("other", keys, 10)) == fmt.Sprint(samples) {
+		t.Fatal("Expected another block hash to sample other keys")
+	}
+	if len(SampleKeys("hash", keys[:5], 10)) != 5 {
+		t.Fatal("Expected every key to be sampled if there are fewer keys than samples")
+	}
+
+	local := Audit{Height: 1, Hash: "hash", Samples: []Sample{{Key: "01", Value: "02"}, {Key: "03", Value: "04"}}}
+	remote := Audit{Height: 1, Hash: "hash", Samples: []Sample{{Key: "01", Value: "02"}, {Key: "03", Value: "05"}}}
+	if mismatches, err := Compare(&local, &local); err != nil || len(mismatches) != 0 {
+		t.Fatalf("Unexpected mismatches %v: %v", mismatches, err)
+	}
+	if mismatches, err := Compare(&local, &remote); err != nil || len(mismatches) != 1 {
+		t.Fatalf("Expected one mismatch, got %v: %v", mismatches, err)
+	}
+	remote.Hash = "fork"
+	if _, err := Compare(&local, &remote); err != ErrNotComparable {
+		t.Fatal("Expected the audits of different blocks not to be compared")
+	}
+}
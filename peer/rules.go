@@ -0,0 +1,61 @@
+package peer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// FetchRulesVersion requests the version of the rules of the member from its latest checkpoint.
+// The members predating the rules versions report an empty version.
+func FetchRulesVersion(ctx context.Context, client *http.Client, m Member) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(m.URL, "/")+"/v1/checkpoint", nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	var response struct {
+		Error  *string `json:"error"`
+		Result *struct {
+			RulesVersion string `json:"rulesVersion"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return "", fmt.Errorf("invalid response of the member %s with the status %d: %v", m.Name, resp.StatusCode, err)
+	}
+	if response.Error != nil {
+		return "", fmt.Errorf("the member %s: %s", m.Name, *response.Error)
+	}
+	if response.Result == nil {
+		return "", fmt.Errorf("the member %s returned no checkpoint", m.Name)
+	}
+	return response.Result.RulesVersion, nil
+}
+
+// MajorityRules returns the rules version reported by more than half of the members, if any.
+// The members not reporting a version count against every version, so a few reachable members never make a majority.
+func MajorityRules(versions map[string]string, members int) (string, bool) {
+	counts := make(map[string]int)
+	for _, version := range versions {
+		if version != "" {
+			counts[version]++
+		}
+	}
+	for version, count := range counts {
+		if count*2 > members {
+			return version, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,66 @@
+package peer
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// FetchSnapshot downloads the snapshot at the URL, either served by a member at /v1/peer/snapshot or saved elsewhere,
+// and calls put with every key-value. The manifest is returned once its signature and the number of the key-values
+// are verified, while the caller verifies the digest and the commitment of the key-values.
+func FetchSnapshot(ctx context.Context, client *http.Client, url, token, publicKey string, put func(key, value []byte) error) (*Manifest, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("the snapshot is replied with %s", resp.Status)
+	}
+
+	decoder := json.NewDecoder(resp.Body)
+	var manifest Manifest
+	if err := decoder.Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("invalid manifest of the snapshot: %v", err)
+	}
+	if err := VerifyManifest(&manifest, publicKey); err != nil {
+		return nil, err
+	}
+	size := 0
+	for decoder.More() {
+		var kv KeyValue
+		if err := decoder.Decode(&kv); err != nil {
+			return nil, fmt.Errorf("invalid key-value %d of the snapshot: %v", size, err)
+		}
+		key, err := hex.DecodeString(kv.Key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key %s of the snapshot", kv.Key)
+		}
+		value, err := hex.DecodeString(kv.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value of the key %s of the snapshot", kv.Key)
+		}
+		if err := put(key, value); err != nil {
+			return nil, err
+		}
+		size++
+		if size > manifest.Size {
+			return nil, errors.New("the snapshot holds more key-values than its manifest")
+		}
+	}
+	if size != manifest.Size {
+		return nil, fmt.Errorf("the snapshot holds %d key-values rather than %d", size, manifest.Size)
+	}
+	return &manifest, nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/RiemaLabs/modular-indexer-committee/apis"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+	"github.com/RiemaLabs/modular-indexer-committee/peer"
+)
+
+func Test_PeerAudit(t *testing.T) {
+	ordGetterTest, arguments := loadMain(782000)
+	queue, err := CatchupStage(ordGetterTest, &arguments, stateless.BRC20StartHeight-1, 780000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signer, err := peer.NewSigner(strings.Repeat("01", 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	apis.Peers = &apis.PeerService{
+		Signer:      signer,
+		Tokens:      func() []string { return []string{"member"} },
+		MemberToken: func(peer.Member) string { return "member" },
+	}
+	defer func() { apis.Peers = nil }()
+	gin.SetMode(gin.TestMode)
+	ts := httptest.NewServer(apis.NewRouter(queue, "brc-20", false, false))
+	defer ts.Close()
+	member := peer.Member{Name: "self", URL: ts.URL, PublicKey: signer.PublicKey()}
+
+	ctx := context.Background()
+	latest := queue.LatestHeight()
+	local, err := apis.LocalAudit(queue, latest, peer.DefaultAuditSamples)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(local.Samples) == 0 || local.Proof == "" {
+		t.Fatalf("Expected the audit of the latest block to sample keys with their proof")
+	}
+	remote, err := peer.FetchAudit(ctx, http.DefaultClient, member, "member", latest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := apis.VerifyAuditProof(remote); err != nil {
+		t.Fatal(err)
+	}
+	if mismatches, err := peer.Compare(local, remote); err != nil || len(mismatches) != 0 {
+		t.Fatalf("Unexpected mismatches %v: %v", mismatches, err)
+	}
+
+	// A diverged value is caught by the comparison, and can't be covered by the proof.
+	remote.Samples[0].Value = strings.Repeat("ff", 32)
+	remote.Samples[0].Exists = true
+	if mismatches, _ := peer.Compare(local, remote); len(mismatches) != 1 {
+		t.Fatalf("Expected one mismatch, got %v", mismatches)
+	}
+	if apis.VerifyAuditProof(remote) == nil {
+		t.Fatal("Expected the diverged value not to match the proof")
+	}
+
+	// The earlier blocks are audited without proofs, and the later ones aren't executed yet.
+	previous, err := peer.FetchAudit(ctx, http.DefaultClient, member, "member", latest-1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if previous.Proof != "" || previous.Hash != queue.History[len(queue.History)-1].Hash {
+		t.Fatalf("Unexpected audit of the previous block %+v", previous)
+	}
+	if _, err := peer.FetchAudit(ctx, http.DefaultClient, member, "member", latest+1); err == nil {
+		t.Fatal("Expected the block not executed yet to be rejected")
+	}
+	if _, err := peer.FetchAudit(ctx, http.DefaultClient, member, "intruder", latest); err == nil {
+		t.Fatal("Expected the unknown token to be rejected")
+	}
+}
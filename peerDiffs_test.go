@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/holiman/uint256"
+
+	"github.com/RiemaLabs/modular-indexer-committee/apis"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+	"github.com/RiemaLabs/modular-indexer-committee/peer"
+)
+
+func Test_PeerDiffs(t *testing.T) {
+	ordGetterTest, arguments := loadMain(782000)
+	queue, err := CatchupStage(ordGetterTest, &arguments, stateless.BRC20StartHeight-1, 780000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signer, err := peer.NewSigner(strings.Repeat("01", 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	apis.Peers = &apis.PeerService{Signer: signer, Tokens: func() []string { return []string{"standby"} }}
+	defer func() { apis.Peers = nil }()
+	gin.SetMode(gin.TestMode)
+	ts := httptest.NewServer(apis.NewRouter(queue, "brc-20", false, false))
+	defer ts.Close()
+
+	get := func(ctx context.Context, path, token string) *http.Response {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	ctx := context.Background()
+	if resp := get(ctx, "/v1/peer/diffs", "member"); resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("Expected the unknown token to be rejected, got %d", resp.StatusCode)
+	}
+	oldest := queue.History[0].Height + 1
+	if resp := get(ctx, fmt.Sprintf("/v1/peer/diffs?from=%d", oldest-1), "standby"); resp.StatusCode != http.StatusGone {
+		t.Fatalf("Expected the pruned diffs to be gone, got %d", resp.StatusCode)
+	}
+
+	// Resume from the second oldest block, the stream follows the chain of commitments up to the latest state.
+	streamCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	resp := get(streamCtx, fmt.Sprintf("/v1/peer/diffs?from=%d", oldest+1), "standby")
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(nil, 1<<24)
+	var diffs []peer.Diff
+	for len(diffs) < len(queue.History)-1 && scanner.Scan() {
+		data, found := strings.CutPrefix(scanner.Text(), "data:")
+		if !found {
+			continue
+		}
+		var d peer.Diff
+		if err := json.Unmarshal([]byte(data), &d); err != nil {
+			t.Fatal(err)
+		}
+		if err := peer.VerifyDiff(&d, signer.PublicKey()); err != nil {
+			t.Fatal(err)
+		}
+		diffs = append(diffs, d)
+	}
+	cancel()
+	if len(diffs) != len(queue.History)-1 || diffs[0].Height != oldest+1 {
+		t.Fatalf("Unexpected diffs from the block %d", oldest+1)
+	}
+	for i := 1; i < len(diffs); i++ {
+		if diffs[i].PreCommitment != diffs[i-1].PostCommitment {
+			t.Fatalf("Broken chain of commitments at the block %d", diffs[i].Height)
+		}
+	}
+	latest := queue.Header.Root.Commit().Bytes()
+	if diffs[len(diffs)-1].PostCommitment != base64.StdEncoding.EncodeToString(latest[:]) {
+		t.Fatal("Expected the last diff to end at the latest state")
+	}
+	diffs[0].Elements = append(diffs[0].Elements, peer.Element{Key: "00"})
+	if peer.VerifyDiff(&diffs[0], signer.PublicKey()) == nil {
+		t.Fatal("Expected the tampered diff to be rejected")
+	}
+
+	// The snapshot is verified by its digest.
+	resp = get(ctx, "/v1/peer/snapshot", "standby")
+	defer resp.Body.Close()
+	decoder := json.NewDecoder(resp.Body)
+	var manifest peer.Manifest
+	if err := decoder.Decode(&manifest); err != nil {
+		t.Fatal(err)
+	}
+	if err := peer.VerifyManifest(&manifest, signer.PublicKey()); err != nil {
+		t.Fatal(err)
+	}
+	digest, size := uint256.NewInt(0), 0
+	for decoder.More() {
+		var kv peer.KeyValue
+		if err := decoder.Decode(&kv); err != nil {
+			t.Fatal(err)
+		}
+		key, _ := hex.DecodeString(kv.Key)
+		value, _ := hex.DecodeString(kv.Value)
+		h := sha256.Sum256(append(key, value...))
+		digest.Add(digest, new(uint256.Int).SetBytes(h[:]))
+		size++
+	}
+	b := digest.Bytes32()
+	if manifest.Height != queue.Header.Height || size != manifest.Size || hex.EncodeToString(b[:]) != manifest.Digest {
+		t.Fatalf("The snapshot doesn't match its manifest %+v", manifest)
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-verkle"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord/getter"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+func Test_PipelinedPaging(t *testing.T) {
+	var catchupHeight uint = 780000
+	ordGetterTest, _ := loadMain(782000)
+
+	catchup := func(pipelined bool) *stateless.Header {
+		header := stateless.LoadHeader(false, stateless.BRC20StartHeight-1)
+		header.Pipeline(pipelined)
+		for i := header.Height + 1; i <= catchupHeight; i++ {
+			ots, err := ordGetterTest.GetOrdTransfers(i)
+			if err != nil {
+				t.Fatal(err)
+			}
+			stateless.Exec(header, ots, i)
+			if err := header.Paging(ordGetterTest, false, stateless.NodeResolveFn); err != nil {
+				t.Fatal(err)
+			}
+		}
+		header.Pipeline(false)
+		return header
+	}
+	sequential := catchup(false)
+	pipelined := catchup(true)
+	if pipelined.Height != sequential.Height || pipelined.Digest() != sequential.Digest() {
+		t.Fatalf("The pipelined state differs at height %d", catchupHeight)
+	}
+	commitment := sequential.Root.Commit().Bytes()
+	if pipelined.Root.Commit().Bytes() != commitment {
+		t.Fatalf("The pipelined commitment differs at height %d", catchupHeight)
+	}
+
+	// The writes inserted by stems build the same tree as inserted one by one.
+	root := verkle.New()
+	sequential.KV.Range(func(key [verkle.KeySize]byte, value [stateless.ValueSize]byte) bool {
+		if err := root.Insert(key[:], value[:], nil); err != nil {
+			t.Fatal(err)
+		}
+		return true
+	})
+	if root.Commit().Bytes() != commitment {
+		t.Fatalf("The commitment of the tree inserted by stems differs at height %d", catchupHeight)
+	}
+}
+
+func Test_TreeInsertionFailure(t *testing.T) {
+	deploy := inscribe(strings.Repeat("1", 64)+"i0", deployerPkscript, "", `{"p":"brc-20","op":"deploy","tick":"tree","max":"100","lim":"10"}`)
+	mint := func(i int) []getter.OrdTransfer {
+		id := strings.Repeat(string(rune('2'+i)), 64) + "i0"
+		return []getter.OrdTransfer{inscribe(id, deployerPkscript, "", `{"p":"brc-20","op":"mint","tick":"tree","amt":"1"}`)}
+	}
+	unresolvable := func([]byte) ([]byte, error) { return nil, errors.New("unresolvable node") }
+
+	for _, pipelined := range []bool{false, true} {
+		header := stateless.LoadHeader(false, 800000)
+		stateless.Exec(header, []getter.OrdTransfer{deploy}, 800001)
+		if err := header.Paging(nil, false, stateless.NodeResolveFn); err != nil {
+			t.Fatal(err)
+		}
+		// The children of the root are left as their hashes, which fail to be resolved.
+		serialized, err := header.Root.Serialize()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if header.Root, err = verkle.ParseNode(serialized, 0); err != nil {
+			t.Fatal(err)
+		}
+		header.Pipeline(pipelined)
+
+		// The failure of a background insertion is returned by the paging of the next block.
+		stateless.Exec(header, mint(0), 800002)
+		err = header.Paging(nil, false, unresolvable)
+		if pipelined {
+			if err != nil {
+				t.Fatal(err)
+			}
+			stateless.Exec(header, mint(1), 800003)
+			err = header.Paging(nil, false, unresolvable)
+		}
+		if !errors.Is(err, stateless.ErrTreeInsertion) {
+			t.Fatalf("Expected the paging to fail to insert the writes when pipelined %v, got %v", pipelined, err)
+		}
+		// The tree misses the writes for good, so the next blocks fail as well.
+		stateless.Exec(header, mint(2), header.Height+1)
+		if err := header.Paging(nil, false, stateless.NodeResolveFn); !errors.Is(err, stateless.ErrTreeInsertion) {
+			t.Fatalf("Expected the paging of the next block to fail, got %v", err)
+		}
+		header.Pipeline(false)
+	}
+}
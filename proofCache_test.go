@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/RiemaLabs/modular-indexer-committee/apis"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+func Test_ProofCache(t *testing.T) {
+	tick := "ordi"
+	pkScript := "5120409943cab2dee3c71940969a612c6ee65c57cad1f064ca8db4508dab49260ca3"
+	ordGetterTest, arguments := loadMain(782000)
+	queue, _ := CatchupStage(ordGetterTest, &arguments, stateless.BRC20StartHeight-1, uint(779960))
+
+	apis.Proofs = apis.NewProofCache(16)
+	defer func() { apis.Proofs = nil }()
+
+	gin.SetMode(gin.TestMode)
+	r := gin.Default()
+	r.GET("/v1/brc20_verifiable/current_balance_of_pkscript", func(c *gin.Context) {
+		apis.GetCurrentBalanceOfPkscript(c, queue)
+	})
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	query := func() apis.Brc20VerifiableCurrentBalanceOfPkscriptResponse {
+		resp, err := http.Get(ts.URL + "/v1/brc20_verifiable/current_balance_of_pkscript?tick=" + tick + "&pkscript=" + pkScript)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var res apis.Brc20VerifiableCurrentBalanceOfPkscriptResponse
+		if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+			t.Fatal(err)
+		}
+		if res.Error != nil {
+			t.Fatal(*res.Error)
+		}
+		return res
+	}
+
+	first, second := query(), query()
+	if hits, misses := apis.Proofs.Stats(); hits != 1 || misses != 1 {
+		t.Fatalf("Expected 1 hit and 1 miss, got %d hits and %d misses", hits, misses)
+	}
+	if *first.Proof != *second.Proof {
+		t.Fatal("The cached proof differs from the generated one")
+	}
+
+	// The popular key set is proven again against the new root right after the update.
+	if err := queue.Update(ordGetterTest, uint(779980)); err != nil {
+		t.Fatal(err)
+	}
+	apis.Proofs.Precompute(queue.Header, apis.PrecomputedProofs)
+	res := query()
+	if hits, misses := apis.Proofs.Stats(); hits != 2 || misses != 1 {
+		t.Fatalf("Expected 2 hits and 1 miss, got %d hits and %d misses", hits, misses)
+	}
+	if _, err := apis.VerifyCurrentBalanceOfPkscript(queue.Header.Root.Commit(), tick, pkScript, &res); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/RiemaLabs/modular-indexer-committee/apis"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+func Test_ReadAfterWrite(t *testing.T) {
+	ordGetterTest, arguments := loadMain(782000)
+	queue, err := CatchupStage(ordGetterTest, &arguments, stateless.BRC20StartHeight-1, 780000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := apis.NewRouter(queue, "brc-20", false, false)
+	get := func(url string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+		return w
+	}
+	height := queue.Header.Height
+
+	// The served state is stamped with its block boundary.
+	w := get("/v1/brc20/current_balance_of_pkscript?tick=ordi&pkscript=5120")
+	commitment := queue.Header.Root.Commit().Bytes()
+	if w.Code != http.StatusOK || w.Header().Get(apis.HeaderBlockHeight) != strconv.Itoa(int(height)) ||
+		w.Header().Get(apis.HeaderBlockHash) != queue.Header.Hash ||
+		w.Header().Get(apis.HeaderStateRoot) != base64.StdEncoding.EncodeToString(commitment[:]) {
+		t.Fatalf("Unexpected response %d with headers %v", w.Code, w.Header())
+	}
+
+	if w := get("/v1/brc20/block_height?min_height=abc"); w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected the invalid min_height to be rejected, got %d", w.Code)
+	}
+	if w := get(fmt.Sprintf("/v1/brc20/block_height?min_height=%d&timeout=10ms", height+1)); w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected the request to time out before the height %d, got %d", height+1, w.Code)
+	}
+
+	// The request waits until the next block is committed.
+	served := make(chan *httptest.ResponseRecorder)
+	go func() {
+		served <- get(fmt.Sprintf("/v1/brc20/block_height?min_height=%d&timeout=1m", height+1))
+	}()
+	if err := queue.Update(ordGetterTest, height+1); err != nil {
+		t.Fatal(err)
+	}
+	w = <-served
+	commitment = queue.Header.Root.Commit().Bytes()
+	if w.Code != http.StatusOK || w.Body.String() != strconv.Itoa(int(height+1)) ||
+		w.Header().Get(apis.HeaderBlockHeight) != strconv.Itoa(int(height+1)) ||
+		w.Header().Get(apis.HeaderStateRoot) != base64.StdEncoding.EncodeToString(commitment[:]) {
+		t.Fatalf("Unexpected response %d: %s with headers %v", w.Code, w.Body.String(), w.Header())
+	}
+}
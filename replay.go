@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/RiemaLabs/modular-indexer-committee/checkpoint"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/brc20"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/reexec"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+// ReplayArguments is what the replay command re-executes: the stored witnesses of the blocks from From to To.
+type ReplayArguments struct {
+	WitnessPath string
+	From        uint
+	To          uint
+	// The checkpoint history of a member to compare the replayed commitments with, optional, see OpenHistory.
+	Checkpoints  string
+	MetaProtocol string
+	Report       string
+}
+
+// KeyDivergence is a key whose replayed value differs from the value written by the recorded execution.
+type KeyDivergence struct {
+	Key string `json:"key"`
+	// The decoded key, nil if its preimage isn't among the transfers of the block.
+	Meaning       *brc20.KeyMeaning `json:"meaning,omitempty"`
+	PreValue      string            `json:"preValue"`
+	RecordedValue string            `json:"recordedValue"`
+	ReplayedValue string            `json:"replayedValue"`
+}
+
+// BlockReplay is the re-execution of a block compared with its recorded execution.
+type BlockReplay struct {
+	Height             uint   `json:"height"`
+	PreCommitment      string `json:"preCommitment"`
+	RecordedCommitment string `json:"recordedCommitment"`
+	ReplayedCommitment string `json:"replayedCommitment"`
+	// Whether the pre-state is the recorded post-state of the previous replayed block, true for the first one.
+	FollowsPrevious bool `json:"followsPrevious"`
+	// The commitments of the checkpoints published at the height, if a checkpoint history is given.
+	Checkpoints []string        `json:"checkpoints,omitempty"`
+	Diverged    bool            `json:"diverged"`
+	Keys        []KeyDivergence `json:"keys"`
+	// The error of re-executing the witness, e.g. of an invalid pre-state proof.
+	Error string `json:"error,omitempty"`
+}
+
+// ReplayReport is the replay of the blocks, along with the heights of those diverging from their recorded executions.
+type ReplayReport struct {
+	From     uint          `json:"from"`
+	To       uint          `json:"to"`
+	Diverged []uint        `json:"diverged"`
+	Blocks   []BlockReplay `json:"blocks"`
+}
+
+// ReplayBlock re-executes the block of the witness on its proven pre-state, and compares the values written and the
+// post-state commitment with those recorded by the witness. The divergent keys are decoded by the transfers of the block.
+func ReplayBlock(w *reexec.Witness) BlockReplay {
+	block := BlockReplay{
+		Height:             w.Height,
+		PreCommitment:      w.PreCommitment,
+		RecordedCommitment: w.PostCommitment,
+		Keys:               make([]KeyDivergence, 0),
+	}
+	replayed, changes, err := reexec.Replay(w)
+	if err != nil {
+		block.Diverged, block.Error = true, err.Error()
+		return block
+	}
+	block.ReplayedCommitment = replayed
+
+	decoder := brc20.NewKeyDecoder()
+	post, err := reexec.PostState(w)
+	if err != nil {
+		block.Diverged, block.Error = true, err.Error()
+		return block
+	}
+	decoder.AddTransfers(w.OrdTransfers, post)
+
+	recorded := make(map[string]string, len(w.Writes))
+	for _, write := range w.Writes {
+		recorded[write.Key] = write.Value
+	}
+	replayedValues := make(map[string]string, len(changes))
+	for _, change := range changes {
+		replayedValues[change.Key] = change.NewValue
+	}
+	for _, read := range w.Reads {
+		recordedValue, found := recorded[read.Key]
+		if !found {
+			recordedValue = read.Value
+		}
+		replayedValue, found := replayedValues[read.Key]
+		if !found {
+			replayedValue = read.Value
+		}
+		if recordedValue == replayedValue {
+			continue
+		}
+		divergence := KeyDivergence{Key: read.Key, PreValue: read.Value, RecordedValue: recordedValue, ReplayedValue: replayedValue}
+		if key, err := hex.DecodeString(read.Key); err == nil {
+			if meaning, found := decoder.Decode(key); found {
+				divergence.Meaning = &meaning
+			}
+		}
+		block.Keys = append(block.Keys, divergence)
+	}
+	block.Diverged = block.ReplayedCommitment != block.RecordedCommitment || len(block.Keys) != 0
+	return block
+}
+
+// ReplayStage replays the stored witnesses of the blocks in turn, comparing the replayed commitments with the
+// checkpoints of the history as well, if not nil.
+func ReplayStage(ctx context.Context, arguments *ReplayArguments, history checkpoint.History) (*ReplayReport, error) {
+	if arguments.From == 0 || arguments.From > arguments.To {
+		return nil, fmt.Errorf("invalid range of blocks from %d to %d", arguments.From, arguments.To)
+	}
+	report := ReplayReport{From: arguments.From, To: arguments.To, Diverged: make([]uint, 0), Blocks: make([]BlockReplay, 0)}
+	previous := ""
+	for height := arguments.From; height <= arguments.To; height++ {
+		w, err := stateless.LoadWitness(arguments.WitnessPath, height)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the witness at height %d: %v", height, err)
+		}
+		block := ReplayBlock(w)
+		block.FollowsPrevious = previous == "" || previous == w.PreCommitment
+		if !block.FollowsPrevious {
+			log.Printf("The pre-state of block %d isn't the recorded post-state of block %d", height, height-1)
+		}
+		previous = w.PostCommitment
+		if history != nil {
+			checkpoints, err := history.Checkpoints(ctx, height)
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch the checkpoints at height %d: %v", height, err)
+			}
+			for _, c := range checkpoints {
+				block.Checkpoints = append(block.Checkpoints, c.Commitment)
+				if c.Commitment != block.ReplayedCommitment {
+					block.Diverged = true
+				}
+			}
+		}
+		if block.Diverged {
+			report.Diverged = append(report.Diverged, height)
+			logBlockReplay(&block)
+		}
+		report.Blocks = append(report.Blocks, block)
+	}
+	return &report, nil
+}
+
+func logBlockReplay(block *BlockReplay) {
+	if block.Error != "" {
+		log.Printf("Block %d can't be replayed: %s", block.Height, block.Error)
+		return
+	}
+	log.Printf("Block %d diverges: recorded %s, replayed %s, checkpoints %v", block.Height, block.RecordedCommitment, block.ReplayedCommitment, block.Checkpoints)
+	for _, k := range block.Keys {
+		meaning := "unknown"
+		if m := k.Meaning; m != nil {
+			meaning = fmt.Sprintf("%s %s (location %d, slot %d)", m.KeySpace, m.Location, m.LocationID, m.Slot)
+			for _, input := range [][2]string{{"tick", m.Tick}, {"pkscript", m.Pkscript}, {"wallet", m.Wallet}, {"inscription", m.InscriptionID}} {
+				if input[1] != "" {
+					meaning += fmt.Sprintf(" %s=%s", input[0], input[1])
+				}
+			}
+		}
+		log.Printf("  %s %s: pre %s, recorded %s, replayed %s", k.Key, meaning, k.PreValue, k.RecordedValue, k.ReplayedValue)
+	}
+}
+
+// Replay runs the replay command, writing the report if required, and fails if any block diverges.
+func Replay(arguments *ReplayArguments) error {
+	ctx := context.Background()
+	var history checkpoint.History
+	if arguments.Checkpoints != "" {
+		var err error
+		history, err = OpenHistory(ctx, arguments.Checkpoints, arguments.MetaProtocol)
+		if err != nil {
+			return err
+		}
+	}
+	report, err := ReplayStage(ctx, arguments, history)
+	if err != nil {
+		return err
+	}
+	if arguments.Report != "" {
+		bytes, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(arguments.Report, bytes, 0644); err != nil {
+			return err
+		}
+		log.Printf("The replay report is written to %s", arguments.Report)
+	}
+	if len(report.Diverged) != 0 {
+		return fmt.Errorf("%d of %d blocks diverge, the first at height %d", len(report.Diverged), len(report.Blocks), report.Diverged[0])
+	}
+	log.Printf("The replays of the blocks from %d to %d match their recorded executions", arguments.From, arguments.To)
+	return nil
+}
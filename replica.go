@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/ethereum/go-verkle"
+
+	"github.com/RiemaLabs/modular-indexer-committee/apis"
+	"github.com/RiemaLabs/modular-indexer-committee/internal/metrics"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+	"github.com/RiemaLabs/modular-indexer-committee/peer"
+)
+
+// The interval of reconnecting to the member followed by the replica.
+const replicaRetryInterval = 10 * time.Second
+
+// ReplicaStage serves the APIs from the stored state, or from the snapshot of the replica member onto an empty disk,
+// and follows the diffs of the member if configured. Neither the getter nor the checkpoints are involved, so the
+// replicas scale the queries without more connections to the OPI database or more DA keys.
+func ReplicaStage(arguments *RuntimeArguments, initHeight uint) {
+	metrics.Stage.Set(metrics.StageCatchup)
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	m := GlobalConfig.Replica.Member
+	header, err := loadReplica(ctx, m, initHeight)
+	if err != nil {
+		log.Fatalf("Failed to load the state of the replica: %v", err)
+	}
+	header.Hydrate()
+	queue := stateless.NewReplica(header)
+	log.Printf("Serving the state at height %d as a read-only replica", queue.LatestHeight())
+
+	metrics.Stage.Set(metrics.StageServing)
+	if arguments.EnableService {
+		metaProtocol := GlobalConfig.Service.MetaProtocol
+		if arguments.ProtocolName != "" {
+			metaProtocol = arguments.ProtocolName
+		}
+		if arguments.ProofCacheSize > 0 {
+			apis.Proofs = apis.NewProofCache(arguments.ProofCacheSize)
+		}
+		go apis.StartService(queue, metaProtocol, false, arguments.EnableTest, arguments.EnablePprof)
+		if arguments.GRPCAddr != "" {
+			go apis.StartGRPCService(queue, metaProtocol, arguments.GRPCAddr)
+		}
+	}
+
+	if m.URL != "" {
+		log.Printf("Following the diffs of the member %s at %s", m.Name, m.URL)
+		for ctx.Err() == nil {
+			err := peer.FollowDiffs(ctx, &http.Client{}, m.URL, Secrets.Get(m.Token), m.PublicKey, queue.LatestHeight()+1, func(d *peer.Diff) error {
+				if err := replicateDiff(queue, d); err != nil {
+					log.Fatalf("Failed to replicate the block %d of the member %s, resync the replica from a snapshot: %v", d.Height, m.Name, err)
+				}
+				metrics.LatestHeight.Set(float64(queue.LatestHeight()))
+				apis.RecordUpstream(apis.UpstreamMember, nil)
+				return nil
+			})
+			if ctx.Err() != nil {
+				break
+			}
+			apis.RecordUpstream(apis.UpstreamMember, err)
+			if errors.Is(err, peer.ErrDiffsPruned) {
+				log.Fatalf("The replica lags behind the diffs kept by the member %s, resync it from a snapshot: %v", m.Name, err)
+			}
+			log.Printf("The diffs of the member %s are interrupted at height %d, retry in %v: %v", m.Name, queue.LatestHeight(), replicaRetryInterval, err)
+			waitRound(ctx, replicaRetryInterval)
+		}
+	}
+	<-ctx.Done()
+
+	log.Printf("Shutting down at height %d. Please don't force exit.", queue.LatestHeight())
+	if arguments.EnableStateRootCache && len(queue.History) != 0 {
+		if err := queue.StoreFinalized(); err != nil {
+			log.Printf("Unable to store the finalized state: %v", err)
+		}
+	}
+	flushTraces()
+	os.Exit(0)
+}
+
+// loadReplica loads the stored state, or imports the snapshot of the member onto an empty disk.
+func loadReplica(ctx context.Context, m peer.Member, initHeight uint) (*stateless.Header, error) {
+	stored, err := stateless.HasState()
+	if err != nil {
+		return nil, err
+	}
+	if stored {
+		return stateless.LoadHeader(true, initHeight), nil
+	}
+	if m.URL == "" {
+		return nil, errors.New("no state is stored, configure the replica member to import its snapshot")
+	}
+	header, manifest, err := downloadSnapshot(ctx, strings.TrimRight(m.URL, "/")+"/v1/peer/snapshot", m)
+	if err != nil {
+		return nil, err
+	}
+	if err := stateless.StoreHeader(header, 0); err != nil {
+		return nil, err
+	}
+	log.Printf("Imported the snapshot of %d key-values at height %d from the member %s", manifest.Size, manifest.Height, m.Name)
+	return header, nil
+}
+
+// replicateDiff applies the diff of a block verified by the signature of the member.
+func replicateDiff(queue *stateless.Queue, d *peer.Diff) error {
+	pre, err := base64.StdEncoding.DecodeString(d.PreCommitment)
+	if err != nil || len(pre) != 32 {
+		return fmt.Errorf("invalid commitment %s before the block", d.PreCommitment)
+	}
+	post, err := base64.StdEncoding.DecodeString(d.PostCommitment)
+	if err != nil || len(post) != 32 {
+		return fmt.Errorf("invalid commitment %s after the block", d.PostCommitment)
+	}
+	elements := make([]stateless.TripleElement, len(d.Elements))
+	for i, e := range d.Elements {
+		key, err := hex.DecodeString(e.Key)
+		if err != nil || len(key) != verkle.KeySize {
+			return fmt.Errorf("invalid key %s", e.Key)
+		}
+		oldValue, err := hex.DecodeString(e.OldValue)
+		if err != nil || len(oldValue) != stateless.ValueSize {
+			return fmt.Errorf("invalid old value of the key %s", e.Key)
+		}
+		newValue, err := hex.DecodeString(e.NewValue)
+		if err != nil || len(newValue) != stateless.ValueSize {
+			return fmt.Errorf("invalid new value of the key %s", e.Key)
+		}
+		elements[i] = stateless.TripleElement{
+			Key:            [verkle.KeySize]byte(key),
+			OldValue:       [stateless.ValueSize]byte(oldValue),
+			NewValue:       [stateless.ValueSize]byte(newValue),
+			OldValueExists: e.OldValueExists,
+			Written:        e.Written,
+			Deleted:        e.Deleted,
+		}
+	}
+	block := stateless.DiffState{
+		Height:       d.Height - 1,
+		Hash:         d.ParentHash,
+		VerkleCommit: [32]byte(pre),
+		Access:       stateless.AccessList{Elements: elements},
+	}
+	return queue.Replicate(block, d.Hash, [32]byte(post))
+}
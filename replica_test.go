@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/RiemaLabs/modular-indexer-committee/apis"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+	"github.com/RiemaLabs/modular-indexer-committee/peer"
+)
+
+func Test_Replica(t *testing.T) {
+	ordGetterTest, arguments := loadMain(782000)
+	queue, err := CatchupStage(ordGetterTest, &arguments, stateless.BRC20StartHeight-1, 780000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signer, err := peer.NewSigner(strings.Repeat("01", 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	apis.Peers = &apis.PeerService{Signer: signer, Tokens: func() []string { return []string{"replica"} }}
+	defer func() { apis.Peers = nil }()
+	gin.SetMode(gin.TestMode)
+	ts := httptest.NewServer(apis.NewRouter(queue, "brc-20", false, false))
+	defer ts.Close()
+
+	// The replica starts from the state of the oldest block kept by the member, which its diffs follow.
+	kv := make(stateless.MemoryKV)
+	queue.Header.KV.Range(func(key [32]byte, value [stateless.ValueSize]byte) bool {
+		kv[key] = value
+		return true
+	})
+	for i := len(queue.History) - 1; i >= 0; i-- {
+		for _, elem := range queue.History[i].Access.Elements {
+			if elem.OldValueExists {
+				kv[elem.Key] = elem.OldValue
+			} else {
+				delete(kv, elem.Key)
+			}
+		}
+	}
+	oldest := queue.History[0]
+	header, err := stateless.ImportSnapshot(oldest.Height, oldest.Hash, kv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replica := stateless.NewReplica(header)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var diffs []*peer.Diff
+	err = peer.FollowDiffs(ctx, http.DefaultClient, ts.URL, "replica", signer.PublicKey(), replica.LatestHeight()+1, func(d *peer.Diff) error {
+		diffs = append(diffs, d)
+		if err := replicateDiff(replica, d); err != nil {
+			return err
+		}
+		if replica.LatestHeight() == queue.LatestHeight() {
+			cancel()
+		}
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected the diffs to be followed up to the latest block, got %v", err)
+	}
+	same := func() bool {
+		return replica.LatestHeight() == queue.LatestHeight() && replica.Header.Hash == queue.Header.Hash &&
+			replica.Header.Root.Commit().Bytes() == queue.Header.Root.Commit().Bytes() && replica.Header.Digest() == queue.Header.Digest()
+	}
+	if !same() || len(replica.History) != len(queue.History) {
+		t.Fatalf("The replica at height %d differs from the member at height %d", replica.LatestHeight(), queue.LatestHeight())
+	}
+
+	// A diff replacing a replicated block rolls the replica back to its parent first.
+	if err := replicateDiff(replica, diffs[0]); err != nil {
+		t.Fatal(err)
+	}
+	if replica.LatestHeight() != diffs[0].Height || len(replica.History) != 1 {
+		t.Fatalf("Expected the replica to be rolled back to the block %d, got %d", diffs[0].Height, replica.LatestHeight())
+	}
+	for _, d := range diffs[1:] {
+		if err := replicateDiff(replica, d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !same() {
+		t.Fatal("The replica differs from the member after the rollback")
+	}
+
+	// The diffs not following the state are rejected.
+	if err := replicateDiff(replica, &peer.Diff{Height: replica.LatestHeight() + 2, PreCommitment: diffs[0].PreCommitment, PostCommitment: diffs[0].PostCommitment}); err == nil {
+		t.Fatal("Expected the diff skipping a block to be rejected")
+	}
+	err = peer.FollowDiffs(context.Background(), http.DefaultClient, ts.URL, "replica", signer.PublicKey(), oldest.Height, func(*peer.Diff) error { return nil })
+	if !errors.Is(err, peer.ErrDiffsPruned) {
+		t.Fatalf("Expected the pruned diffs to be gone, got %v", err)
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/RiemaLabs/modular-indexer-committee/apis"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/brc20"
+	"github.com/RiemaLabs/modular-indexer-committee/peer"
+)
+
+func Test_RulesDisagreement(t *testing.T) {
+	local := brc20.RulesVersion()
+	brc20.DeployPolicies = []brc20.DeployPolicy{brc20.DeployRules{{ActivationHeight: 1, ReservedTicks: []string{"ordi"}}}}
+	other := brc20.RulesVersion()
+	brc20.DeployPolicies = nil
+	if local == other || brc20.RulesVersion() != local {
+		t.Fatalf("Expected the rules versions to follow the rules: %s and %s", local, other)
+	}
+
+	versions := map[string]string{"a": other, "b": other, "c": local}
+	member := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/v1/checkpoint" {
+				http.NotFound(w, r)
+				return
+			}
+			version, found := versions[name]
+			if !found {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(apis.CheckpointResponse{Result: &apis.CheckpointResult{RulesVersion: version}})
+		}))
+	}
+	var members []peer.Member
+	for _, name := range []string{"a", "b", "c"} {
+		ts := member(name)
+		defer ts.Close()
+		members = append(members, peer.Member{Name: name, URL: ts.URL})
+	}
+	apis.Peers = &apis.PeerService{Audit: peer.AuditConfig{Members: members}}
+	defer func() { apis.Peers = nil }()
+
+	ctx := context.Background()
+	apis.CheckRules(ctx)
+	if !apis.SafeMode() {
+		t.Fatal("Expected the safe mode, since the majority runs other rules")
+	}
+
+	// A single reachable member isn't a majority, so the mode is kept.
+	delete(versions, "a")
+	delete(versions, "b")
+	apis.CheckRules(ctx)
+	if !apis.SafeMode() {
+		t.Fatal("Expected the safe mode to be kept without a majority")
+	}
+
+	versions["a"], versions["b"] = local, other
+	apis.CheckRules(ctx)
+	if apis.SafeMode() {
+		t.Fatal("Expected to leave the safe mode, since the majority runs the local rules")
+	}
+}
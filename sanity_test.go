@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord/sanity"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+func Test_SanityQuarantine(t *testing.T) {
+	dir := t.TempDir()
+	ordGetterTest, arguments := loadMain(782000)
+	g := sanity.NewGetter(ordGetterTest, sanity.Config{Enabled: true, QuarantineDir: dir})
+
+	// The real blocks are sane.
+	queue, err := CatchupStage(g, &arguments, stateless.BRC20StartHeight-1, 780000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Corrupt a transfer of the next non-empty block.
+	next := queue.Header.Height + 1
+	for ; next < 780100; next++ {
+		if ots, _ := ordGetterTest.GetOrdTransfers(next); len(ots) != 0 {
+			break
+		}
+	}
+	for i := range ordGetterTest.OrdTransfers {
+		if ordGetterTest.OrdTransfers[i].BlockHeight == next {
+			ordGetterTest.OrdTransfers[i].NewSatpoint = "corrupted"
+			break
+		}
+	}
+
+	err = queue.Update(g, next)
+	if !errors.Is(err, sanity.ErrQuarantined) {
+		t.Fatalf("Expected the block %d to be quarantined, got %v", next, err)
+	}
+	if queue.Header.Height != next-1 {
+		t.Fatalf("Expected the queue to stop before the quarantined block, got height %d", queue.Header.Height)
+	}
+	record, err := sanity.LoadRecord(dir, next)
+	if err != nil || record == nil || len(record.Reasons) != 1 || record.Approved {
+		t.Fatalf("Unexpected quarantine record %+v: %v", record, err)
+	}
+
+	// The block is executed once the operator approves it.
+	record.Approved = true
+	bytes, _ := json.Marshal(record)
+	if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("%d.json", next)), bytes, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := queue.Update(g, next); err != nil {
+		t.Fatal(err)
+	}
+	if queue.Header.Height != next {
+		t.Fatalf("Expected the approved block to be executed, got height %d", queue.Header.Height)
+	}
+}
@@ -0,0 +1,325 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/RiemaLabs/modular-indexer-committee/apis"
+	"github.com/RiemaLabs/modular-indexer-committee/committee"
+	"github.com/RiemaLabs/modular-indexer-committee/ord"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/bitmap"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/getter"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/sns"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+// The protocols can't be unregistered, so the sats names stay registered for the rest of the tests,
+// which don't inscribe any plain text.
+var registerSNS sync.Once
+
+func Test_SatsNames(t *testing.T) {
+	registerSNS.Do(sns.Register)
+	apis.Modules = append(apis.Modules, apis.SNSModule)
+	defer func() {
+		apis.Modules = apis.Modules[:len(apis.Modules)-1]
+	}()
+
+	claim := inscribe(strings.Repeat("7", 64)+"i0", deployerPkscript, "", "Satoshi.sats")
+	claim.NewWallet = "bc1qalice"
+	transfer := claim
+	transfer.OldSatpoint = strings.Repeat("7", 64) + ":0:0"
+	transfer.NewPkscript, transfer.NewWallet = ord.Pkscript(successorPkscript), "bc1qbob"
+	g := &blocksGetter{
+		blocks: map[uint][]getter.OrdTransfer{
+			800001: {
+				claim,
+				inscribe(strings.Repeat("8", 64)+"i0", successorPkscript, "", `{"p":"sns","op":"reg","name":"satoshi.sats"}`),
+				inscribe(strings.Repeat("9", 64)+"i0", deployerPkscript, "", `{"p":"brc-20","op":"deploy","tick":"name","max":"100","lim":"10"}`),
+			},
+			800002: {transfer},
+		},
+		hashes: make(map[uint]string),
+	}
+	header := stateless.LoadHeader(false, 800000)
+	queue, err := stateless.NewQueues(g, header, true, 800001)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info, found, _ := queue.Header.TickInfo("name"); !found || info.MaxSupply != "100000000000000000000" {
+		t.Fatalf("Expected BRC-20 to be executed along with the names, got %+v", info)
+	}
+	r := apis.NewRouter(queue, "brc-20", false, false)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/sns/name/SATOSHI.sats", nil))
+	var resp apis.SNSNameResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || w.Code != http.StatusOK || resp.Proof == nil {
+		t.Fatalf("Unexpected response %d: %s", w.Code, w.Body.String())
+	}
+	if resp.Result.Owner != "bc1qbob" || resp.Result.InscriptionID != claim.InscriptionID {
+		t.Fatalf("Unexpected record %+v", resp.Result)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/sns/name/nobody.sats", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("Expected the unclaimed name to be not found, got %d", w.Code)
+	}
+
+	for address, expected := range map[string]int{"bc1qalice": 0, "bc1qbob": 1} {
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/sns/names?address="+address, nil))
+		var names apis.SNSNamesResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &names); err != nil || w.Code != http.StatusOK || names.Result.Total != expected {
+			t.Fatalf("Unexpected names of %s %d: %s", address, w.Code, w.Body.String())
+		}
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/checkpoint", nil))
+	var checkpoint apis.CheckpointResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &checkpoint); err != nil || len(checkpoint.Result.Modules) != 2 || checkpoint.Result.Modules[1].MetaProtocol != sns.Name {
+		t.Fatalf("Unexpected checkpoint %s", w.Body.String())
+	}
+}
+
+func Test_SatsNamesRouting(t *testing.T) {
+	registerSNS.Do(sns.Register)
+	defer func() { sns.ActivationHeight = 0 }()
+	// Naming the names in another content type or another case, the transfers go to BRC-20 as before the module.
+	asJSON := inscribe(strings.Repeat("7", 64)+"i0", deployerPkscript, "", `{"p":"sns","op":"reg","name":"satoshi.sats"}`)
+	asJSON.ContentType = "application/json"
+	ots := []getter.OrdTransfer{
+		asJSON,
+		inscribe(strings.Repeat("8", 64)+"i0", deployerPkscript, "", `{"p":"SNS","op":"reg","name":"nakamoto.sats"}`),
+		inscribe(strings.Repeat("9", 64)+"i0", deployerPkscript, "", `{"p":"brc-20","op":"deploy","tick":"name","max":"100","lim":"10"}`),
+	}
+	commitments := make([][32]byte, 0, 2)
+	for _, activation := range []uint{800002, 800001} {
+		sns.ActivationHeight = activation
+		header := stateless.LoadHeader(false, 800000)
+		stateless.Exec(header, ots, 800001)
+		if err := header.Paging(nil, false, stateless.NodeResolveFn); err != nil {
+			t.Fatal(err)
+		}
+		commitments = append(commitments, header.Root.Commit().Bytes())
+	}
+	if commitments[0] != commitments[1] {
+		t.Fatal("Expected the transfers not claimed by the names to leave the same state as before their activation")
+	}
+}
+
+// useStateDB keeps the state of the test in a state database, which is dropped by the cleanup along with the layout.
+func useStateDB(t *testing.T) {
+	registerSNS.Do(sns.Register)
+	stateless.StateDBPath = t.TempDir()
+	t.Cleanup(func() {
+		_ = stateless.CloseStateDB()
+		stateless.StateDBPath = ""
+		sns.ActivationHeight = 0
+		for _, pattern := range []string{"*.census", "*.holders", "state.layout"} {
+			files, _ := filepath.Glob(filepath.Join(".cache", pattern))
+			for _, file := range files {
+				_ = os.Remove(file)
+			}
+		}
+	})
+}
+
+// storeNamesState stores a state of the names at the height 800001 into a state database dropped by the cleanup.
+func storeNamesState(t *testing.T) {
+	useStateDB(t)
+
+	// A fresh state executes the names from its genesis.
+	header := stateless.LoadHeader(true, 800000)
+	if err := committee.MigrateHeader(header, false); err != nil {
+		t.Fatal(err)
+	}
+	stateless.Exec(header, []getter.OrdTransfer{inscribe(strings.Repeat("7", 64)+"i0", deployerPkscript, "", "satoshi.sats")}, 800001)
+	if err := header.Paging(nil, false, stateless.NodeResolveFn); err != nil {
+		t.Fatal(err)
+	}
+	if err := stateless.StoreHeader(header, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := stateless.CloseStateDB(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// dropNames drops the names from the layout of the stored state, as if they weren't enabled along with it.
+func dropNames(t *testing.T) {
+	path := filepath.Join(".cache", "state.layout")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var layout stateless.StateLayout
+	// The other modules registered by the tests stay recorded.
+	if err := json.Unmarshal(data, &layout); err != nil || !slices.Contains(layout.Modules, sns.Name) {
+		t.Fatalf("Expected the names to be recorded in the layout, got %s: %v", data, err)
+	}
+	layout.Modules = slices.DeleteFunc(layout.Modules, func(module string) bool { return module == sns.Name })
+	if data, err = json.Marshal(layout); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0666); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_SatsNamesEnabling(t *testing.T) {
+	storeNamesState(t)
+
+	// The same state stored before the names were enabled misses the claims of its blocks since the activation.
+	dropNames(t)
+	header := stateless.LoadHeader(true, 800000)
+	if err := committee.MigrateHeader(header, false); err == nil {
+		t.Fatal("Expected the names enabled after their activation to be rejected")
+	}
+	// Activated after the height of the state, the names miss no block.
+	sns.ActivationHeight = header.Height + 1
+	if err := committee.MigrateHeader(header, false); err != nil {
+		t.Fatal(err)
+	}
+	if modules := stateless.CurrentLayout().Modules; !slices.Contains(modules, sns.Name) {
+		t.Fatalf("Expected the names to be recorded, got %v", modules)
+	}
+}
+
+func Test_SatsNamesLegacyLayout(t *testing.T) {
+	storeNamesState(t)
+	// The districts, which the other tests may have registered, are activated after the state, so that only the names
+	// are to be backfilled.
+	registerBitmap.Do(bitmap.Register)
+	bitmap.ActivationHeight = 800002
+	defer func() { bitmap.ActivationHeight = 0 }()
+
+	// The layout stored before the protocols were recorded lacks the modules.
+	path := filepath.Join(".cache", "state.layout")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stored map[string]any
+	if err := json.Unmarshal(data, &stored); err != nil {
+		t.Fatal(err)
+	}
+	delete(stored, "modules")
+	if data, err = json.Marshal(stored); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	// Nothing tells the names were executed along with the state, which is past their activation.
+	header := stateless.LoadHeader(true, 800000)
+	if modules := stateless.CurrentLayout().Modules; len(modules) != 0 {
+		t.Fatalf("Expected the legacy layout to record no modules, got %v", modules)
+	}
+	if err := committee.MigrateHeader(header, false); err == nil {
+		t.Fatal("Expected the names enabled on a legacy layout after their activation to be rejected")
+	}
+	// Backfilled from their activation, the names are recorded.
+	sns.ActivationHeight = 800001
+	g := &blocksGetter{
+		blocks: map[uint][]getter.OrdTransfer{
+			800001: {inscribe(strings.Repeat("7", 64)+"i0", deployerPkscript, "", "satoshi.sats")},
+		},
+		hashes: make(map[uint]string),
+	}
+	if err := committee.Backfill(g, header, sns.Name, 800001, false); err != nil {
+		t.Fatal(err)
+	}
+	if modules := stateless.CurrentLayout().Modules; !slices.Contains(modules, sns.Name) {
+		t.Fatalf("Expected the names to be recorded, got %v", modules)
+	}
+}
+
+func Test_SatsNamesBackfill(t *testing.T) {
+	useStateDB(t)
+	g := &blocksGetter{
+		blocks: map[uint][]getter.OrdTransfer{
+			800001: {
+				inscribe(strings.Repeat("7", 64)+"i0", deployerPkscript, "", "Satoshi.sats"),
+				inscribe(strings.Repeat("9", 64)+"i0", deployerPkscript, "", `{"p":"brc-20","op":"deploy","tick":"name","max":"100","lim":"10"}`),
+			},
+			800002: {
+				inscribe(strings.Repeat("8", 64)+"i0", successorPkscript, "", `{"p":"sns","op":"reg","name":"nakamoto.sats"}`),
+				inscribe(strings.Repeat("6", 64)+"i0", successorPkscript, "", `{"p":"brc-20","op":"mint","tick":"name","amt":"10"}`),
+			},
+		},
+		hashes: make(map[uint]string),
+	}
+	execBlocks := func(header *stateless.Header) {
+		for height := uint(800001); height <= 800002; height++ {
+			stateless.Exec(header, g.blocks[height], height)
+			if err := header.Paging(nil, false, stateless.NodeResolveFn); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// A full reindex executes the names along with BRC-20.
+	sns.ActivationHeight = 800001
+	reindexed := stateless.LoadHeader(false, 800000)
+	execBlocks(reindexed)
+	expected := reindexed.Root.Commit().Bytes()
+
+	// The stored state executed BRC-20 alone, as the names were activated after its blocks.
+	sns.ActivationHeight = 800003
+	header := stateless.LoadHeader(true, 800000)
+	if err := committee.MigrateHeader(header, false); err != nil {
+		t.Fatal(err)
+	}
+	execBlocks(header)
+	if commitment := header.Root.Commit().Bytes(); commitment == expected {
+		t.Fatal("Expected the state without the names to differ from the reindexed one")
+	}
+	if err := stateless.StoreHeader(header, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := stateless.CloseStateDB(); err != nil {
+		t.Fatal(err)
+	}
+	dropNames(t)
+
+	// Activated at the first block, the names are backfilled into the stored state rather than reindexed.
+	sns.ActivationHeight = 800001
+	header = stateless.LoadHeader(true, 800000)
+	if err := committee.MigrateHeader(header, false); err == nil {
+		t.Fatal("Expected the names enabled after their activation to be rejected")
+	}
+	if err := committee.Backfill(g, header, sns.Name, 800002, true); err == nil {
+		t.Fatal("Expected the backfill from after the activation to be rejected")
+	}
+	if err := committee.Backfill(g, header, sns.Name, 800001, true); err != nil {
+		t.Fatal(err)
+	}
+	if commitment := header.Root.Commit().Bytes(); commitment != expected {
+		t.Fatalf("Expected the backfilled commitment %x to be the reindexed %x", commitment, expected)
+	}
+	if err := committee.Backfill(g, header, sns.Name, 800001, true); err == nil {
+		t.Fatal("Expected the names to be backfilled once")
+	}
+	if err := stateless.CloseStateDB(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The stored state starts with the names enabled.
+	header = stateless.LoadHeader(true, 800000)
+	if err := committee.MigrateHeader(header, false); err != nil {
+		t.Fatal(err)
+	}
+	if header.Height != 800002 || header.Root.Commit().Bytes() != expected {
+		t.Fatalf("Unexpected stored state at height %d", header.Height)
+	}
+}
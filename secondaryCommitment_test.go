@@ -0,0 +1,52 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord/smt"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+func rebuildSecondaryRoot(kv stateless.KVStore) [32]byte {
+	tree := smt.New()
+	kv.Range(func(key [32]byte, value [32]byte) bool {
+		tree.Insert(key, value)
+		return true
+	})
+	return tree.Commit()
+}
+
+func Test_SecondaryCommitment(t *testing.T) {
+	stateless.SecondaryCommitment = true
+	defer func() { stateless.SecondaryCommitment = false }()
+
+	ordGetterTest, arguments := loadMain(782000)
+	queue, err := CatchupStage(ordGetterTest, &arguments, stateless.BRC20StartHeight-1, 780000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if queue.Header.SecondaryRoot() != rebuildSecondaryRoot(queue.Header.KV) {
+		t.Fatal("The incremental secondary root differs from the rebuilt one")
+	}
+
+	secondaries := make([][32]byte, 0, len(queue.History))
+	for _, h := range queue.History {
+		if h.SecondaryCommit == ([32]byte{}) {
+			t.Fatalf("Expected the secondary commitment at height %d", h.Height)
+		}
+		secondaries = append(secondaries, h.SecondaryCommit)
+	}
+
+	// Recovery checks the rolled back secondary roots against the history and recomputes them.
+	if err := queue.Recovery(ordGetterTest, queue.Header.Height-3); err != nil {
+		t.Fatal(err)
+	}
+	for i, h := range queue.History {
+		if h.SecondaryCommit != secondaries[i] {
+			t.Fatalf("The secondary commitment at height %d changed after the reorganization", h.Height)
+		}
+	}
+	if queue.Header.SecondaryRoot() != rebuildSecondaryRoot(queue.Header.KV) {
+		t.Fatal("The secondary root differs from the rebuilt one after the reorganization")
+	}
+}
@@ -0,0 +1,193 @@
+// Package secrets resolves the credentials of the config from the environment, files or external secret stores,
+// and refreshes them periodically, so that long-running committee indexers follow the rotations without restarts.
+//
+// A config value is a reference if it starts with a scheme:
+//   - env:<name>, the environment variable;
+//   - file:<path>, the trimmed content of the file, e.g. mounted by Kubernetes;
+//   - vault:<path>#<field>, the field of a HashiCorp Vault secret, either of the KV version 1 or 2;
+//   - awssm:<secret ID>[#<field>], the AWS Secrets Manager secret, or a field of the JSON secret.
+//
+// Any other value is a literal.
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Config struct {
+	// The interval of the refreshes in seconds, zero disables the rotation.
+	RefreshInterval int `json:"refreshInterval"`
+	Vault           struct {
+		Address string `json:"address"`
+		// The token, or the file holding the token, which is read again at every fetch.
+		Token     string `json:"token"`
+		TokenFile string `json:"tokenFile"`
+	} `json:"vault"`
+	AWS struct {
+		Region string `json:"region"`
+	} `json:"aws"`
+}
+
+// Store fetches the secret of the reference without the scheme.
+type Store interface {
+	Fetch(ctx context.Context, ref string) (string, error)
+}
+
+func splitRef(value string) (string, string, bool) {
+	scheme, ref, found := strings.Cut(value, ":")
+	if !found {
+		return "", "", false
+	}
+	switch scheme {
+	case "env", "file", "vault", "awssm":
+		return scheme, ref, true
+	}
+	return "", "", false
+}
+
+// IsRef reports whether the config value is a reference to a secret.
+func IsRef(value string) bool {
+	_, _, isRef := splitRef(value)
+	return isRef
+}
+
+type watcher struct {
+	refs     []string
+	onChange func()
+}
+
+type Manager struct {
+	stores   map[string]Store
+	interval time.Duration
+
+	sync.RWMutex
+	values   map[string]string
+	watchers []watcher
+}
+
+func New(cfg Config) *Manager {
+	return &Manager{
+		stores: map[string]Store{
+			"env":   envStore{},
+			"file":  fileStore{},
+			"vault": newVaultStore(cfg),
+			"awssm": newAWSStore(cfg),
+		},
+		interval: time.Duration(cfg.RefreshInterval) * time.Second,
+		values:   make(map[string]string),
+	}
+}
+
+func (m *Manager) fetch(ctx context.Context, value string) (string, error) {
+	scheme, ref, _ := splitRef(value)
+	secret, err := m.stores[scheme].Fetch(ctx, ref)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch the secret %s: %v", value, err)
+	}
+	return secret, nil
+}
+
+// Load resolves the references, which are refreshed from then on. Literals are kept as they are.
+func (m *Manager) Load(ctx context.Context, values ...string) error {
+	for _, value := range values {
+		if !IsRef(value) {
+			continue
+		}
+		secret, err := m.fetch(ctx, value)
+		if err != nil {
+			return err
+		}
+		m.Lock()
+		m.values[value] = secret
+		m.Unlock()
+	}
+	return nil
+}
+
+// Get returns the latest secret of the loaded reference, or the value itself if it is a literal.
+func (m *Manager) Get(value string) string {
+	if m == nil || !IsRef(value) {
+		return value
+	}
+	m.RLock()
+	defer m.RUnlock()
+	return m.values[value]
+}
+
+// Watch calls onChange after a refresh changing any of the references.
+func (m *Manager) Watch(onChange func(), values ...string) {
+	refs := make([]string, 0, len(values))
+	for _, value := range values {
+		if IsRef(value) {
+			refs = append(refs, value)
+		}
+	}
+	if len(refs) == 0 {
+		return
+	}
+	m.Lock()
+	defer m.Unlock()
+	m.watchers = append(m.watchers, watcher{refs: refs, onChange: onChange})
+}
+
+// Refresh fetches every loaded reference again. A failing fetch keeps the previous secret.
+func (m *Manager) Refresh(ctx context.Context) {
+	m.RLock()
+	refs := make([]string, 0, len(m.values))
+	for ref := range m.values {
+		refs = append(refs, ref)
+	}
+	m.RUnlock()
+
+	changed := make(map[string]bool)
+	for _, ref := range refs {
+		secret, err := m.fetch(ctx, ref)
+		if err != nil {
+			log.Printf("Keep the previous secret: %v", err)
+			continue
+		}
+		m.Lock()
+		if m.values[ref] != secret {
+			m.values[ref] = secret
+			changed[ref] = true
+		}
+		m.Unlock()
+	}
+	if len(changed) == 0 {
+		return
+	}
+
+	m.RLock()
+	watchers := append([]watcher{}, m.watchers...)
+	m.RUnlock()
+	for _, w := range watchers {
+		for _, ref := range w.refs {
+			if changed[ref] {
+				w.onChange()
+				break
+			}
+		}
+	}
+}
+
+// Run refreshes the secrets every interval until the context is done.
+func (m *Manager) Run(ctx context.Context) {
+	if m.interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.Refresh(ctx)
+		}
+	}
+}
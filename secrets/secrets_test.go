@@ -0,0 +1,89 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestManager_Rotation(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "password")
+	if err := os.WriteFile(path, []byte("old\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("COMMITTEE_DB_USER", "indexer")
+
+	m := New(Config{})
+	if err := m.Load(ctx, "env:COMMITTEE_DB_USER", "file:"+path, "localhost"); err != nil {
+		t.Fatal(err)
+	}
+	if m.Get("env:COMMITTEE_DB_USER") != "indexer" || m.Get("file:"+path) != "old" || m.Get("localhost") != "localhost" {
+		t.Fatal("Unexpected secrets")
+	}
+
+	rotations := 0
+	m.Watch(func() { rotations++ }, "file:"+path, "localhost")
+	m.Refresh(ctx)
+	if rotations != 0 {
+		t.Fatal("Expected no rotation")
+	}
+
+	if err := os.WriteFile(path, []byte("new\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	m.Refresh(ctx)
+	if rotations != 1 || m.Get("file:"+path) != "new" {
+		t.Fatalf("Expected the rotation to be applied, got %d rotations", rotations)
+	}
+
+	// A failing fetch keeps the previous secret.
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	m.Refresh(ctx)
+	if rotations != 1 || m.Get("file:"+path) != "new" {
+		t.Fatal("Expected the previous secret to be kept")
+	}
+
+	if err := m.Load(ctx, "env:COMMITTEE_UNSET_SECRET"); err == nil {
+		t.Fatal("Expected the missing secret to fail")
+	}
+}
+
+func TestVaultStore(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/committee":
+			fmt.Fprint(w, `{"data":{"data":{"privateKey":"v2"},"metadata":{"version":3}}}`)
+		case "/v1/kv/committee":
+			fmt.Fprint(w, `{"data":{"privateKey":"v1"}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	var cfg Config
+	cfg.Vault.Address = server.URL
+	cfg.Vault.Token = "token"
+	m := New(cfg)
+	ctx := context.Background()
+	if err := m.Load(ctx, "vault:secret/data/committee#privateKey", "vault:kv/committee#privateKey"); err != nil {
+		t.Fatal(err)
+	}
+	if m.Get("vault:secret/data/committee#privateKey") != "v2" || m.Get("vault:kv/committee#privateKey") != "v1" {
+		t.Fatal("Unexpected vault secrets")
+	}
+	if err := m.Load(ctx, "vault:secret/data/committee#missing"); err == nil {
+		t.Fatal("Expected the missing field to fail")
+	}
+}
@@ -0,0 +1,172 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+)
+
+type envStore struct{}
+
+func (envStore) Fetch(_ context.Context, name string) (string, error) {
+	secret, found := os.LookupEnv(name)
+	if !found {
+		return "", fmt.Errorf("the environment variable %s is not set", name)
+	}
+	return secret, nil
+}
+
+type fileStore struct{}
+
+func (fileStore) Fetch(_ context.Context, path string) (string, error) {
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(bytes)), nil
+}
+
+// field returns the field of the JSON object.
+func field(object map[string]interface{}, name string) (string, error) {
+	value, found := object[name]
+	if !found {
+		return "", fmt.Errorf("the field %s is not found", name)
+	}
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	return fmt.Sprint(value), nil
+}
+
+type vaultStore struct {
+	address   string
+	token     string
+	tokenFile string
+	client    *http.Client
+}
+
+func newVaultStore(cfg Config) *vaultStore {
+	address := cfg.Vault.Address
+	if address == "" {
+		address = os.Getenv("VAULT_ADDR")
+	}
+	token := cfg.Vault.Token
+	if token == "" {
+		token = os.Getenv("VAULT_TOKEN")
+	}
+	return &vaultStore{
+		address:   strings.TrimSuffix(address, "/"),
+		token:     token,
+		tokenFile: cfg.Vault.TokenFile,
+		client:    &http.Client{},
+	}
+}
+
+func (s *vaultStore) Fetch(ctx context.Context, ref string) (string, error) {
+	path, name, found := strings.Cut(ref, "#")
+	if !found {
+		return "", fmt.Errorf("the field of the vault secret %s is missing", ref)
+	}
+	if s.address == "" {
+		return "", fmt.Errorf("the vault address is not set")
+	}
+	token := s.token
+	if s.tokenFile != "" {
+		bytes, err := os.ReadFile(s.tokenFile)
+		if err != nil {
+			return "", err
+		}
+		token = strings.TrimSpace(string(bytes))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.address+"/v1/"+strings.TrimPrefix(path, "/"), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("vault responded %d: %s", resp.StatusCode, body)
+	}
+
+	var secret struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &secret); err != nil {
+		return "", err
+	}
+	// The KV version 2 nests the secret in data.data.
+	if nested, ok := secret.Data["data"].(map[string]interface{}); ok {
+		if _, isV2 := secret.Data["metadata"]; isV2 {
+			return field(nested, name)
+		}
+	}
+	return field(secret.Data, name)
+}
+
+type awsStore struct {
+	region string
+
+	sync.Mutex
+	client *secretsmanager.Client
+}
+
+func newAWSStore(cfg Config) *awsStore {
+	return &awsStore{region: cfg.AWS.Region}
+}
+
+// The client is created on the first use, since most deployments don't use AWS Secrets Manager.
+func (s *awsStore) getClient(ctx context.Context) (*secretsmanager.Client, error) {
+	s.Lock()
+	defer s.Unlock()
+	if s.client == nil {
+		options := make([]func(*config.LoadOptions) error, 0)
+		if s.region != "" {
+			options = append(options, config.WithRegion(s.region))
+		}
+		awsCfg, err := config.LoadDefaultConfig(ctx, options...)
+		if err != nil {
+			return nil, err
+		}
+		s.client = secretsmanager.NewFromConfig(awsCfg)
+	}
+	return s.client, nil
+}
+
+func (s *awsStore) Fetch(ctx context.Context, ref string) (string, error) {
+	secretID, name, hasField := strings.Cut(ref, "#")
+	client, err := s.getClient(ctx)
+	if err != nil {
+		return "", err
+	}
+	out, err := client.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{SecretId: aws.String(secretID)})
+	if err != nil {
+		return "", err
+	}
+	secret := aws.ToString(out.SecretString)
+	if !hasField {
+		return secret, nil
+	}
+	var object map[string]interface{}
+	if err := json.Unmarshal([]byte(secret), &object); err != nil {
+		return "", fmt.Errorf("the secret %s is not a JSON object: %v", secretID, err)
+	}
+	return field(object, name)
+}
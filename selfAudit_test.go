@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/RiemaLabs/modular-indexer-committee/apis"
+	"github.com/RiemaLabs/modular-indexer-committee/checkpoint"
+	"github.com/RiemaLabs/modular-indexer-committee/committee"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/getter"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+	"github.com/RiemaLabs/modular-indexer-committee/selfaudit"
+)
+
+func Test_SelfAudit(t *testing.T) {
+	g := &blocksGetter{blocks: map[uint][]getter.OrdTransfer{}, hashes: make(map[uint]string)}
+	header := stateless.LoadHeader(false, 800000)
+	queue, err := stateless.NewQueues(g, header, true, 800001)
+	if err != nil {
+		t.Fatal(err)
+	}
+	latestHeight := queue.LatestHeight()
+	states := make(map[uint]stateless.DiffState)
+	for _, state := range queue.History {
+		states[state.Height] = state
+	}
+	states[latestHeight] = committee.LatestState(queue)
+	checkpointAt := func(height uint) checkpoint.Checkpoint {
+		state, found := states[height]
+		if !found {
+			t.Fatalf("The state at height %d isn't kept", height)
+		}
+		return newCheckpoint(&RuntimeArguments{CommitteeIndexerName: "auditee", ProtocolName: "brc-20"}, &state)
+	}
+
+	dir := t.TempDir()
+	ledger, err := checkpoint.OpenLedger(filepath.Join(t.TempDir(), "publications"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ledger.Close()
+	publisher := &checkpoint.LocalPublisher{Dir: dir}
+	publishedAt := time.Now().Add(-time.Hour)
+	publish := func(c checkpoint.Checkpoint) {
+		if err := publisher.Publish(context.Background(), &c); err != nil {
+			t.Fatal(err)
+		}
+		if err := ledger.Record(&c, publisher.Method(), publisher.Receipt(&c), publishedAt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The checkpoint at the latest height is intact, the one before it is overwritten after its publication,
+	// and the one before that commits a state other than the executed one.
+	publish(checkpointAt(latestHeight))
+	tampered := checkpointAt(latestHeight - 1)
+	publish(tampered)
+	tampered.Commitment = "forged"
+	if err := publisher.Publish(context.Background(), &tampered); err != nil {
+		t.Fatal(err)
+	}
+	diverged := checkpointAt(latestHeight - 2)
+	diverged.Commitment = "diverged"
+	publish(diverged)
+
+	apis.SelfAudit, err = selfaudit.New(selfaudit.Config{Enabled: true, Method: "Local"}, ledger, &checkpoint.DirSource{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	apis.SelfAudit.Name, apis.SelfAudit.MetaProtocol = "auditee", "brc-20"
+	apis.SelfAudit.Local = func(height uint, hash string) (string, bool) {
+		return localCommitment(queue, height, hash)
+	}
+	apis.Admin = &apis.AdminService{Tokens: func() []string { return []string{"operator"} }}
+	defer func() {
+		apis.SelfAudit = nil
+		apis.Admin = nil
+	}()
+	ts := httptest.NewServer(apis.NewRouter(queue, "brc-20", false, false))
+	defer ts.Close()
+	call := func(method, path string) (int, apis.SelfAuditResponse) {
+		req, err := http.NewRequest(method, ts.URL+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "Bearer operator")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var res apis.SelfAuditResponse
+		if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode, res
+	}
+
+	if status, _ := call(http.MethodGet, "/v1/selfaudit"); status != http.StatusNotFound {
+		t.Fatalf("Expected no audit yet, got %d", status)
+	}
+	status, res := call(http.MethodPost, "/v1/admin/selfaudit")
+	if status != http.StatusOK || res.Result.Checked != 3 || res.Result.Recomputed != 3 || len(res.Result.Findings) != 2 {
+		t.Fatalf("Unexpected audit %d %+v", status, res.Result)
+	}
+	if f := res.Result.Findings[0]; f.Height != latestHeight-2 || f.Kind != selfaudit.KindDiverged || f.Local == "" {
+		t.Fatalf("Unexpected finding %+v", f)
+	}
+	if f := res.Result.Findings[1]; f.Height != latestHeight-1 || f.Kind != selfaudit.KindTampered || f.Published != "forged" {
+		t.Fatalf("Unexpected finding %+v", f)
+	}
+	if status, res := call(http.MethodGet, "/v1/selfaudit"); status != http.StatusOK || len(res.Result.Findings) != 2 {
+		t.Fatalf("Unexpected latest audit %d %+v", status, res.Result)
+	}
+
+	// The audit of the given heights.
+	if status, res := call(http.MethodPost, fmt.Sprintf("/v1/admin/selfaudit?from=%d&to=%d", latestHeight, latestHeight)); status != http.StatusOK || res.Result.Checked != 1 || len(res.Result.Findings) != 0 {
+		t.Fatalf("Unexpected audit %d %+v", status, res.Result)
+	}
+	if status, _ := call(http.MethodPost, "/v1/admin/selfaudit?from=2&to=1"); status != http.StatusBadRequest {
+		t.Fatalf("Expected the heights to be rejected, got %d", status)
+	}
+}
@@ -0,0 +1,305 @@
+// Package selfaudit reads the checkpoints published by the member back from where they are published, e.g. the DA
+// layer, and compares them with the publication record and with the locally recomputed commitments, so that a
+// tampered, lost or doubly published checkpoint is noticed by the member itself.
+package selfaudit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/RiemaLabs/modular-indexer-committee/checkpoint"
+	"github.com/RiemaLabs/modular-indexer-committee/internal/metrics"
+)
+
+// The defaults of the config.
+const (
+	DefaultMethod   = "DA"
+	DefaultInterval = 3600
+	DefaultDelay    = 600
+	DefaultHeights  = 1000
+)
+
+type Config struct {
+	Enabled bool `json:"enabled"`
+	// Where the checkpoints are read back: a directory, s3://<bucket>, an http(s) URL or da://<namespaceID>,
+	// by default the namespace of the DA report config.
+	Source string `json:"source"`
+	// The publication method whose publications are audited, DA by default.
+	Method string `json:"method"`
+	// The number of seconds between the audits (default 3600), 0 only audits on demand.
+	Interval int `json:"interval"`
+	// The number of seconds a publication is given to be readable before it's audited (default 600).
+	Delay int `json:"delay"`
+	// The number of the latest published heights audited each time (default 1000).
+	Heights uint `json:"heights"`
+}
+
+func (cfg Config) Validate() error {
+	if cfg.Interval < 0 || cfg.Delay < 0 {
+		return errors.New("the interval and the delay of the self-audit must not be negative")
+	}
+	return nil
+}
+
+// The kinds of the findings.
+const (
+	// The published checkpoint differs from the recorded publication, or its signature is invalid.
+	KindTampered = "tampered"
+	// The recorded publication can't be read back.
+	KindMissing = "missing"
+	// The checkpoint of the block is published more than once.
+	KindDuplicate = "duplicate"
+	// The published commitment differs from the locally recomputed one.
+	KindDiverged = "diverged"
+)
+
+var Kinds = []string{KindTampered, KindMissing, KindDuplicate, KindDiverged}
+
+// Finding is a published checkpoint failing the audit.
+type Finding struct {
+	Height uint   `json:"height"`
+	Hash   string `json:"hash"`
+	Kind   string `json:"kind"`
+	// The commitments recorded at the publication, read back, and recomputed locally, as far as known.
+	Recorded  string `json:"recorded"`
+	Published string `json:"published,omitempty"`
+	Local     string `json:"local,omitempty"`
+	// The number of the publications of the checkpoint and their transactions of the DA layer.
+	Copies int      `json:"copies"`
+	TxIDs  []string `json:"txIDs,omitempty"`
+	Detail string   `json:"detail,omitempty"`
+}
+
+// Report is an audit of the publications of the heights from From to To.
+type Report struct {
+	From uint `json:"from"`
+	To   uint `json:"to"`
+	// The number of the audited checkpoints, and of those compared with a locally recomputed commitment.
+	Checked    int       `json:"checked"`
+	Recomputed int       `json:"recomputed"`
+	Findings   []Finding `json:"findings"`
+	StartedAt  time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt"`
+}
+
+// copier is a source telling how many times a checkpoint is published, e.g. the DASource scanning a namespace.
+type copier interface {
+	Copies(key string) int
+}
+
+// Auditor audits the publications recorded by the ledger.
+type Auditor struct {
+	cfg    Config
+	ledger *checkpoint.Ledger
+	source checkpoint.Source
+	// The name and the meta protocol of the checkpoints of the member, which make their keys.
+	Name         string
+	MetaProtocol string
+
+	// Local returns the locally recomputed commitment of the block at the height, false if it's unknown.
+	// Only the latest published block of each height is recomputed, since the blocks reorganized out aren't kept.
+	Local func(height uint, hash string) (string, bool)
+
+	// Audits are serialized, and the latest report is kept.
+	running sync.Mutex
+	mu      sync.RWMutex
+	latest  *Report
+	now     func() time.Time
+}
+
+// New audits the publications of the ledger by the method of the config, read back from the source.
+func New(cfg Config, ledger *checkpoint.Ledger, source checkpoint.Source) (*Auditor, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if cfg.Method == "" {
+		cfg.Method = DefaultMethod
+	}
+	if cfg.Delay == 0 {
+		cfg.Delay = DefaultDelay
+	}
+	if cfg.Heights == 0 {
+		cfg.Heights = DefaultHeights
+	}
+	return &Auditor{cfg: cfg, ledger: ledger, source: source, now: time.Now}, nil
+}
+
+// Run audits the latest published heights every interval until the context is done.
+func (a *Auditor) Run(ctx context.Context) {
+	if a.cfg.Interval == 0 {
+		return
+	}
+	ticker := time.NewTicker(time.Duration(a.cfg.Interval) * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if _, err := a.AuditLatest(ctx); err != nil {
+			log.Printf("Failed to audit the published checkpoints: %v", err)
+		}
+	}
+}
+
+// AuditLatest audits the configured number of the latest published heights.
+func (a *Auditor) AuditLatest(ctx context.Context) (*Report, error) {
+	to, found, err := a.ledger.Latest()
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return a.Audit(ctx, 0, 0)
+	}
+	from := uint(0)
+	if to >= a.cfg.Heights {
+		from = to - a.cfg.Heights + 1
+	}
+	return a.Audit(ctx, from, to)
+}
+
+// group is the publications of the same block.
+type group struct {
+	height       uint
+	hash         string
+	publications []checkpoint.Publication
+}
+
+// Audit checks the publications of the heights from from to to, older than the delay, and keeps the report.
+func (a *Auditor) Audit(ctx context.Context, from, to uint) (*Report, error) {
+	a.running.Lock()
+	defer a.running.Unlock()
+	report := Report{From: from, To: to, Findings: make([]Finding, 0), StartedAt: a.now()}
+	publications, err := a.ledger.Publications(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	// The publications are in the order of the heights and of the times, so the last block of a height is the latest
+	// published one.
+	settled := report.StartedAt.Add(-time.Duration(a.cfg.Delay) * time.Second)
+	var groups []*group
+	for _, p := range publications {
+		if p.Method != a.cfg.Method || p.PublishedAt.After(settled) {
+			continue
+		}
+		var g *group
+		for i := len(groups) - 1; i >= 0 && groups[i].height == p.Height; i-- {
+			if groups[i].hash == p.Hash {
+				g = groups[i]
+				break
+			}
+		}
+		if g == nil {
+			g = &group{height: p.Height, hash: p.Hash}
+			groups = append(groups, g)
+		}
+		g.publications = append(g.publications, p)
+	}
+
+	for i, g := range groups {
+		latest := i+1 == len(groups) || groups[i+1].height != g.height
+		findings, recomputed, err := a.check(ctx, g, latest)
+		if err != nil {
+			return nil, fmt.Errorf("failed to audit the checkpoint at height %d: %v", g.height, err)
+		}
+		report.Checked++
+		if recomputed {
+			report.Recomputed++
+		}
+		report.Findings = append(report.Findings, findings...)
+	}
+	report.FinishedAt = a.now()
+
+	counts := make(map[string]int)
+	for _, f := range report.Findings {
+		counts[f.Kind]++
+		log.Printf("The published checkpoint at height %d of block %s is %s: %s", f.Height, f.Hash, f.Kind, f.Detail)
+	}
+	for _, kind := range Kinds {
+		metrics.SelfAuditFindings.WithLabelValues(kind).Set(float64(counts[kind]))
+	}
+	log.Printf("Audited %d published checkpoints from height %d to %d with %d findings", report.Checked, from, to, len(report.Findings))
+
+	a.mu.Lock()
+	a.latest = &report
+	a.mu.Unlock()
+	return &report, nil
+}
+
+// check reads back the checkpoint of the block, and compares it with the latest publication and, if the block is the
+// latest published one of its height, with the locally recomputed commitment.
+func (a *Auditor) check(ctx context.Context, g *group, latest bool) ([]Finding, bool, error) {
+	recorded := g.publications[len(g.publications)-1]
+	finding := func(kind, detail string) Finding {
+		f := Finding{Height: g.height, Hash: g.hash, Kind: kind, Recorded: recorded.Commitment, Copies: len(g.publications), Detail: detail}
+		for _, p := range g.publications {
+			if p.TxID != "" {
+				f.TxIDs = append(f.TxIDs, p.TxID)
+			}
+		}
+		return f
+	}
+	var findings []Finding
+
+	key := checkpoint.ObjectKey(&checkpoint.Checkpoint{
+		Name:         a.Name,
+		MetaProtocol: a.MetaProtocol,
+		Height:       strconv.FormatUint(uint64(g.height), 10),
+		Hash:         g.hash,
+	})
+	published, err := a.source.Get(ctx, key)
+	switch {
+	case errors.Is(err, checkpoint.ErrNotPublished):
+		findings = append(findings, finding(KindMissing, fmt.Sprintf("%s isn't found", key)))
+	case err != nil:
+		return nil, false, err
+	case published.Commitment != recorded.Commitment:
+		f := finding(KindTampered, "the commitment differs from the published one")
+		f.Published = published.Commitment
+		findings = append(findings, f)
+	case published.Signature != "":
+		if err := published.VerifySignature(); err != nil {
+			f := finding(KindTampered, err.Error())
+			f.Published = published.Commitment
+			findings = append(findings, f)
+		}
+	}
+
+	copies := len(g.publications)
+	if c, ok := a.source.(copier); ok && err == nil {
+		copies = max(copies, c.Copies(key))
+	}
+	if copies > 1 {
+		f := finding(KindDuplicate, fmt.Sprintf("published %d times", copies))
+		f.Copies = copies
+		findings = append(findings, f)
+	}
+
+	if !latest || a.Local == nil {
+		return findings, false, nil
+	}
+	local, found := a.Local(g.height, g.hash)
+	if !found {
+		return findings, false, nil
+	}
+	if local != recorded.Commitment {
+		f := finding(KindDiverged, "the published commitment isn't the locally recomputed one")
+		f.Local = local
+		findings = append(findings, f)
+	}
+	return findings, true, nil
+}
+
+// Latest returns the report of the latest audit, nil if none is done yet.
+func (a *Auditor) Latest() *Report {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.latest
+}
@@ -0,0 +1,110 @@
+package selfaudit
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/RiemaLabs/modular-indexer-committee/checkpoint"
+)
+
+func TestAudit(t *testing.T) {
+	dir := t.TempDir()
+	ledger, err := checkpoint.OpenLedger(filepath.Join(t.TempDir(), "ledger"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ledger.Close()
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	indexerID := checkpoint.IndexerIdentification{URL: "https://committee.example", Name: "committee", Version: "v1", MetaProtocol: "brc-20"}
+	publish := func(height uint, hash, commitment string, at time.Time, upload bool) {
+		c := checkpoint.NewCheckpoint(&indexerID, height, hash, commitment)
+		if upload {
+			if err := (&checkpoint.LocalPublisher{Dir: dir}).Publish(context.Background(), &c); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := ledger.Record(&c, "Local", checkpoint.Receipt{Location: filepath.Join(dir, checkpoint.ObjectKey(&c))}, at); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old := now.Add(-time.Hour)
+	publish(780000, "00000000000000000001", "root0", old, true)
+	// The checkpoint at 780001 is published twice, and the one at 780002 is lost.
+	publish(780001, "00000000000000000002", "root1", old, true)
+	publish(780001, "00000000000000000002", "root1", old.Add(time.Minute), true)
+	publish(780002, "00000000000000000003", "root2", old, false)
+	// The checkpoint at 780003 is overwritten after its publication.
+	publish(780003, "00000000000000000004", "root3", old, true)
+	forged := checkpoint.NewCheckpoint(&indexerID, 780003, "00000000000000000004", "forged")
+	if err := (&checkpoint.LocalPublisher{Dir: dir}).Publish(context.Background(), &forged); err != nil {
+		t.Fatal(err)
+	}
+	// The block at 780004 is reorganized out, and the published commitment of its replacement isn't recomputed locally.
+	publish(780004, "00000000000000000005", "stale", old, true)
+	publish(780004, "00000000000000000006", "root4", old.Add(time.Minute), true)
+	// The checkpoint at 780005 isn't due for the audit yet.
+	publish(780005, "00000000000000000007", "root5", now.Add(-time.Minute), false)
+	// Publications by other methods aren't audited.
+	other := checkpoint.NewCheckpoint(&indexerID, 780006, "00000000000000000008", "root6")
+	if err := ledger.Record(&other, "S3", checkpoint.Receipt{}, old); err != nil {
+		t.Fatal(err)
+	}
+
+	auditor, err := New(Config{Enabled: true, Method: "Local", Heights: 10}, ledger, &checkpoint.DirSource{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	auditor.Name, auditor.MetaProtocol = "committee", "brc-20"
+	auditor.now = func() time.Time { return now }
+	locals := map[uint]string{780000: "root0", 780001: "root1", 780003: "root3", 780004: "diverged"}
+	auditor.Local = func(height uint, hash string) (string, bool) {
+		if height == 780004 && hash != "00000000000000000006" {
+			t.Fatalf("Expected only the latest block to be recomputed, got %s", hash)
+		}
+		local, found := locals[height]
+		return local, found
+	}
+	if auditor.Latest() != nil {
+		t.Fatal("Expected no audit yet")
+	}
+
+	report, err := auditor.AuditLatest(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.From != 779997 || report.To != 780006 || report.Checked != 6 || report.Recomputed != 4 {
+		t.Fatalf("Unexpected report %+v", report)
+	}
+	expected := []struct {
+		height uint
+		kind   string
+	}{
+		{780001, KindDuplicate},
+		{780002, KindMissing},
+		{780003, KindTampered},
+		{780004, KindDiverged},
+	}
+	if len(report.Findings) != len(expected) {
+		t.Fatalf("Unexpected findings %+v", report.Findings)
+	}
+	for i, e := range expected {
+		if f := report.Findings[i]; f.Height != e.height || f.Kind != e.kind {
+			t.Fatalf("Expected %s at height %d, got %+v", e.kind, e.height, f)
+		}
+	}
+	if f := report.Findings[0]; f.Copies != 2 {
+		t.Fatalf("Unexpected copies %+v", f)
+	}
+	if f := report.Findings[2]; f.Recorded != "root3" || f.Published != "forged" {
+		t.Fatalf("Unexpected tampered checkpoint %+v", f)
+	}
+	if f := report.Findings[3]; f.Hash != "00000000000000000006" || f.Local != "diverged" {
+		t.Fatalf("Unexpected diverged checkpoint %+v", f)
+	}
+	if auditor.Latest() != report {
+		t.Fatal("Expected the latest report to be kept")
+	}
+}
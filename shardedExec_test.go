@@ -0,0 +1,138 @@
+package main
+
+import (
+	"log"
+	"math"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-verkle"
+	"github.com/holiman/uint256"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/getter"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/protocol"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+func Test_ShardedExec(t *testing.T) {
+	var latestHeight uint = 780000
+	ordGetterTest, _ := loadMain(782000)
+	serial := stateless.LoadHeader(false, stateless.BRC20StartHeight-1)
+	sharded := stateless.LoadHeader(false, stateless.BRC20StartHeight-1)
+	defer func() { stateless.ExecShards = 1 }()
+
+	for i := stateless.BRC20StartHeight; i <= latestHeight; i++ {
+		ordTransfer, err := ordGetterTest.GetOrdTransfers(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stateless.ExecShards = 1
+		stateless.Exec(serial, ordTransfer, i)
+		stateless.ExecShards = 4
+		stateless.Exec(sharded, ordTransfer, i)
+
+		if !reflect.DeepEqual(serial.Access, sharded.Access) {
+			t.Fatalf("Mismatched access list at block height %d", i)
+		}
+		if !reflect.DeepEqual(serial.IntermediateKV, sharded.IntermediateKV) {
+			t.Fatalf("Mismatched key-values at block height %d", i)
+		}
+		_ = serial.Paging(ordGetterTest, false, stateless.NodeResolveFn)
+		_ = sharded.Paging(ordGetterTest, false, stateless.NodeResolveFn)
+	}
+
+	if serial.Root.Commit().Bytes() != sharded.Root.Commit().Bytes() {
+		t.Fatal("Mismatched commitment between the serial and the sharded execution")
+	}
+	log.Printf("Sharded execution matches the serial one from %d to %d", stateless.BRC20StartHeight, latestHeight)
+}
+
+// The protocol stays registered for the rest of the tests, which execute no block after its activation once the
+// test is done.
+var registerShardDeleter sync.Once
+
+// shardDeleter keeps a key per inscribed name, and deletes it once the name is inscribed again.
+type shardDeleter struct{}
+
+var shardDeleterActivation uint = math.MaxUint
+
+func (shardDeleter) ActivationHeight() uint {
+	return shardDeleterActivation
+}
+
+func (shardDeleter) Exec(state protocol.KVStorage, ots []ord.OrdTransfer, blockHeight uint) {
+	for _, ot := range ots {
+		key := make([]byte, verkle.KeySize)
+		copy(key, ot.Content)
+		value, _ := state.GetUInt256(key)
+		if value.IsZero() {
+			_ = state.InsertUInt256(key, uint256.NewInt(uint64(blockHeight)))
+		} else {
+			_ = state.Delete(key)
+		}
+	}
+}
+
+func Test_ShardedExecDelete(t *testing.T) {
+	registerShardDeleter.Do(func() { protocol.Register("shard-deleter", shardDeleter{}, "text/x-shard-deleter") })
+	shardDeleterActivation = 800001
+	defer func() {
+		shardDeleterActivation = math.MaxUint
+		stateless.ExecShards = 1
+	}()
+	name := func(inscriptionID string, content string) getter.OrdTransfer {
+		ot := inscribe(inscriptionID, deployerPkscript, "", content)
+		ot.ContentType = "text/x-shard-deleter"
+		return ot
+	}
+	blocks := [][]getter.OrdTransfer{
+		{
+			name(strings.Repeat("1", 64)+"i0", "kept"),
+			name(strings.Repeat("2", 64)+"i0", "deleted"),
+			inscribe(strings.Repeat("3", 64)+"i0", deployerPkscript, "", `{"p":"brc-20","op":"deploy","tick":"dele","max":"100","lim":"10"}`),
+		},
+		{
+			inscribe(strings.Repeat("4", 64)+"i0", deployerPkscript, "", `{"p":"brc-20","op":"mint","tick":"dele","amt":"10"}`),
+			name(strings.Repeat("5", 64)+"i0", "deleted"),
+			inscribe(strings.Repeat("6", 64)+"i0", deployerPkscript, "", `{"p":"brc-20","op":"mint","tick":"dele","amt":"10"}`),
+		},
+	}
+
+	serial := stateless.LoadHeader(false, 800000)
+	sharded := stateless.LoadHeader(false, 800000)
+	deleted := 0
+	for i, ots := range blocks {
+		height := uint(800001 + i)
+		stateless.ExecShards = 1
+		stateless.Exec(serial, ots, height)
+		stateless.ExecShards = 4
+		stateless.Exec(sharded, ots, height)
+
+		if !reflect.DeepEqual(serial.Access, sharded.Access) {
+			t.Fatalf("Mismatched access list at block height %d", height)
+		}
+		if !reflect.DeepEqual(serial.IntermediateKV, sharded.IntermediateKV) {
+			t.Fatalf("Mismatched key-values at block height %d", height)
+		}
+		for _, elem := range sharded.Access.Elements {
+			if elem.Deleted {
+				deleted++
+			}
+		}
+		if err := serial.Paging(nil, false, stateless.NodeResolveFn); err != nil {
+			t.Fatal(err)
+		}
+		if err := sharded.Paging(nil, false, stateless.NodeResolveFn); err != nil {
+			t.Fatal(err)
+		}
+		if serial.Root.Commit().Bytes() != sharded.Root.Commit().Bytes() {
+			t.Fatalf("Mismatched commitment between the serial and the sharded execution at block height %d", height)
+		}
+	}
+	if deleted != 1 {
+		t.Fatalf("Expected a key to be deleted, got %d deletions", deleted)
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/RiemaLabs/modular-indexer-committee/apis"
+	"github.com/RiemaLabs/modular-indexer-committee/checkpoint"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/getter"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+	"github.com/RiemaLabs/modular-indexer-committee/peer"
+)
+
+func Test_SnapshotBootstrap(t *testing.T) {
+	cleanup := func() {
+		for _, pattern := range []string{"*.dat", "*.diff", "*.census", "*.holders", "state.layout"} {
+			files, _ := filepath.Glob(filepath.Join(".cache", pattern))
+			for _, file := range files {
+				_ = os.Remove(file)
+			}
+		}
+	}
+	cleanup()
+	saved := GlobalConfig
+	t.Cleanup(func() {
+		GlobalConfig = saved
+		apis.Peers = nil
+		cleanup()
+	})
+
+	// The trusted member serves the state after a deploy.
+	source := stateless.LoadHeader(false, 800000)
+	pkscript := "0014" + strings.Repeat("5b", 20)
+	stateless.Exec(source, []getter.OrdTransfer{inscribe(strings.Repeat("5", 64)+"i0", pkscript, "", `{"p":"brc-20","op":"deploy","tick":"boot","max":"100","lim":"10"}`)}, 800001)
+	if err := source.Paging(nil, false, stateless.NodeResolveFn); err != nil {
+		t.Fatal(err)
+	}
+	source.Hash = "hash800001"
+	signer, err := peer.NewSigner(strings.Repeat("5b", 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	apis.Peers = &apis.PeerService{Signer: signer, Tokens: func() []string { return []string{"newcomer"} }}
+	gin.SetMode(gin.TestMode)
+	ts := httptest.NewServer(apis.NewRouter(&stateless.Queue{Header: source}, "brc-20", false, false))
+	defer ts.Close()
+
+	dir := t.TempDir()
+	publish := func(commitment string) {
+		c := checkpoint.Checkpoint{Name: "alice", MetaProtocol: "brc-20", Height: "800001", Hash: "hash800001", Commitment: commitment}
+		data, _ := json.Marshal(c)
+		if err := os.WriteFile(filepath.Join(dir, checkpoint.ObjectKey(&c)), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	GlobalConfig.SnapshotBootstrap.Member = peer.Member{Name: "alice", URL: ts.URL, Token: "newcomer", PublicKey: signer.PublicKey()}
+	GlobalConfig.SnapshotBootstrap.Checkpoints.Name = "alice"
+	GlobalConfig.SnapshotBootstrap.Checkpoints.Source = dir
+	g := &blocksGetter{hashes: make(map[uint]string)}
+	ctx := context.Background()
+	stored := func() bool {
+		found, err := stateless.HasState()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return found
+	}
+
+	// The snapshot is rejected without a checkpoint of its commitment, or off the chain.
+	if err := BootstrapSnapshot(ctx, g, "brc-20"); err == nil || stored() {
+		t.Fatalf("Expected the snapshot without a checkpoint to be rejected: %v", err)
+	}
+	publish(base64.StdEncoding.EncodeToString(make([]byte, 32)))
+	if err := BootstrapSnapshot(ctx, g, "brc-20"); err == nil || !strings.Contains(err.Error(), "differs") || stored() {
+		t.Fatalf("Expected the snapshot of another commitment to be rejected: %v", err)
+	}
+	commitment := source.Root.Commit().Bytes()
+	publish(base64.StdEncoding.EncodeToString(commitment[:]))
+	g.hashes[800001] = "reorganized"
+	if err := BootstrapSnapshot(ctx, g, "brc-20"); err == nil || stored() {
+		t.Fatalf("Expected the snapshot of a reorganized block to be rejected: %v", err)
+	}
+	delete(g.hashes, 800001)
+
+	// Once verified, the snapshot is the state cache the indexing starts from.
+	if err := BootstrapSnapshot(ctx, g, "brc-20"); err != nil {
+		t.Fatal(err)
+	}
+	header := stateless.LoadHeader(true, 780000)
+	if header.Height != 800001 || header.KV.Len() != source.KV.Len() || header.Root.Commit().Bytes() != commitment {
+		t.Fatalf("Unexpected state of %d keys at height %d", header.KV.Len(), header.Height)
+	}
+	GlobalConfig.SnapshotBootstrap.Member.Token = ""
+	if err := BootstrapSnapshot(ctx, g, "brc-20"); err != nil {
+		t.Fatalf("Expected the stored state to be kept: %v", err)
+	}
+}
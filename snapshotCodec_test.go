@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/gob"
+	"testing"
+
+	"github.com/ethereum/go-verkle"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+func Test_SnapshotCodec(t *testing.T) {
+	// More key-values than a chunk holds.
+	kv := make(stateless.MemoryKV)
+	for i := 0; i < 10000; i++ {
+		var seed [8]byte
+		binary.LittleEndian.PutUint64(seed[:], uint64(i))
+		key := sha256.Sum256(seed[:])
+		// The values are mostly padded amounts, as the balances are.
+		var value [stateless.ValueSize]byte
+		binary.BigEndian.PutUint64(value[stateless.ValueSize-8:], uint64(i))
+		kv[key] = value
+	}
+	header := &stateless.Header{Root: verkle.New(), KV: kv}
+
+	var buffer bytes.Buffer
+	if err := header.Serialize(&buffer); err != nil {
+		t.Fatal(err)
+	}
+	if buffer.Len() >= len(kv)*(verkle.KeySize+stateless.ValueSize) {
+		t.Fatalf("Expected the state cache to be compressed, got %d bytes", buffer.Len())
+	}
+	check := func(loaded *stateless.Header) {
+		if loaded.Height != 800000 || loaded.KV.Len() != len(kv) {
+			t.Fatalf("Loaded %d keys at height %d", loaded.KV.Len(), loaded.Height)
+		}
+		for key, value := range kv {
+			if got, found := loaded.KV.Get(key); !found || got != value {
+				t.Fatalf("Unexpected value of the key %x", key)
+			}
+		}
+	}
+	loaded, err := stateless.Deserialize(&buffer, 800000, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(loaded)
+
+	// The legacy gob state caches are still read.
+	buffer.Reset()
+	if err := gob.NewEncoder(&buffer).Encode(kv); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err = stateless.Deserialize(&buffer, 800000, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(loaded)
+
+	// A truncated state cache is rejected rather than loaded partially.
+	buffer.Reset()
+	if err := header.Serialize(&buffer); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stateless.Deserialize(bytes.NewReader(buffer.Bytes()[:buffer.Len()/2]), 800000, nil); err == nil {
+		t.Fatal("Expected the truncated state cache to be rejected")
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+func Test_StateDB(t *testing.T) {
+	var latestHeight uint = 780000
+	ordGetterTest, arguments := loadMain(782000)
+	arguments.EnableStateRootCache = true
+	stateless.StateDBPath = t.TempDir()
+	t.Cleanup(func() {
+		_ = stateless.CloseStateDB()
+		stateless.StateDBPath = ""
+		for _, pattern := range []string{"*.census", "*.holders", "state.layout"} {
+			files, _ := filepath.Glob(filepath.Join(".cache", pattern))
+			for _, file := range files {
+				_ = os.Remove(file)
+			}
+		}
+	})
+
+	queue, err := CatchupStage(ordGetterTest, &arguments, stateless.BRC20StartHeight-1, latestHeight)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored := queue.History[0]
+	latestCommitment := queue.Header.Root.Commit().Bytes()
+	latestLen := queue.Header.KV.Len()
+	if err := stateless.CloseStateDB(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The restart resumes from the committed state without rebuilding the tree.
+	header := stateless.LoadHeader(true, stateless.BRC20StartHeight-1)
+	if header.Height != stored.Height {
+		t.Fatalf("the state is loaded at height %d, expected %d", header.Height, stored.Height)
+	}
+	if header.Root.Commit().Bytes() != stored.VerkleCommit {
+		t.Fatalf("the loaded commitment differs from the commitment at height %d", stored.Height)
+	}
+
+	// The nodes are resolved from the database while the remaining blocks are executed.
+	for i := header.Height + 1; i <= latestHeight; i++ {
+		ots, err := ordGetterTest.GetOrdTransfers(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stateless.Exec(header, ots, i)
+		if err := header.Paging(ordGetterTest, false, stateless.NodeResolveFn); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if header.Root.Commit().Bytes() != latestCommitment {
+		t.Fatalf("the commitment at height %d differs after the restart", latestHeight)
+	}
+	if header.KV.Len() != latestLen {
+		t.Fatalf("the state holds %d keys after the restart, expected %d", header.KV.Len(), latestLen)
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/holiman/uint256"
+
+	"github.com/RiemaLabs/modular-indexer-committee/apis"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+func rebuildDigest(kv stateless.KVStore) string {
+	digest := uint256.NewInt(0)
+	kv.Range(func(key [32]byte, value [32]byte) bool {
+		h := sha256.Sum256(append(key[:], value[:]...))
+		digest.Add(digest, new(uint256.Int).SetBytes(h[:]))
+		return true
+	})
+	b := digest.Bytes32()
+	return hex.EncodeToString(b[:])
+}
+
+func Test_StateDigest(t *testing.T) {
+	ordGetterTest, arguments := loadMain(782000)
+	queue, err := CatchupStage(ordGetterTest, &arguments, stateless.BRC20StartHeight-1, 780000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.Default()
+	r.GET("/v1/state/digest", func(c *gin.Context) {
+		apis.GetStateDigest(c, queue)
+	})
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	check := func() {
+		resp, err := http.Get(ts.URL + "/v1/state/digest")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var res apis.StateDigestResponse
+		if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+			t.Fatal(err)
+		}
+		if res.Result.Height != queue.Header.Height || res.Result.Size != queue.Header.KV.Len() {
+			t.Fatalf("Unexpected state digest %+v", res.Result)
+		}
+		if res.Result.Digest != rebuildDigest(queue.Header.KV) {
+			t.Fatalf("The incremental digest differs from the rebuilt one at height %d", queue.Header.Height)
+		}
+	}
+
+	check()
+	mockService(ordGetterTest, queue, 5)
+	check()
+	if err := queue.Recovery(ordGetterTest, queue.Header.Height-3); err != nil {
+		t.Fatal(err)
+	}
+	check()
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord/getter"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+func Test_StateExport(t *testing.T) {
+	cleanup := func() {
+		for _, pattern := range []string{"*.dat", "*.diff", "*.census", "*.holders", "state.layout"} {
+			files, _ := filepath.Glob(filepath.Join(".cache", pattern))
+			for _, file := range files {
+				_ = os.Remove(file)
+			}
+		}
+	}
+	cleanup()
+	t.Cleanup(cleanup)
+
+	pkscriptA := "0014" + strings.Repeat("e1", 20)
+	pkscriptB := "0014" + strings.Repeat("e2", 20)
+	header := stateless.LoadHeader(false, 800000)
+	blocks := [][]getter.OrdTransfer{
+		{inscribe(strings.Repeat("e", 64)+"i0", pkscriptA, "", `{"p":"brc-20","op":"deploy","tick":"expo","max":"100","lim":"10"}`)},
+		{
+			inscribe(strings.Repeat("e", 64)+"i1", pkscriptA, "", `{"p":"brc-20","op":"mint","tick":"expo","amt":"10"}`),
+			inscribe(strings.Repeat("e", 64)+"i2", pkscriptB, "", `{"p":"brc-20","op":"mint","tick":"expo","amt":"4"}`),
+		},
+		{inscribe(strings.Repeat("e", 64)+"i3", pkscriptA, "", `{"p":"brc-20","op":"transfer","tick":"expo","amt":"3"}`)},
+	}
+	for i, ots := range blocks {
+		stateless.Exec(header, ots, 800001+uint(i))
+		if err := header.Paging(nil, false, stateless.NodeResolveFn); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	export, err := header.Export()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(export.Ticks) != 1 || export.Ticks[0].Tick != "expo" || export.Ticks[0].Minted != "14000000000000000000" {
+		t.Fatalf("Unexpected ticks %+v", export.Ticks)
+	}
+	expected := []stateless.ExportedBalance{
+		{Tick: "expo", Pkscript: pkscriptA, AvailableBalance: "7000000000000000000", OverallBalance: "10000000000000000000"},
+		{Tick: "expo", Pkscript: pkscriptB, AvailableBalance: "4000000000000000000", OverallBalance: "4000000000000000000"},
+	}
+	if len(export.Balances) != len(expected) || export.Balances[0] != expected[0] || export.Balances[1] != expected[1] {
+		t.Fatalf("Unexpected balances %+v", export.Balances)
+	}
+	// The transfer inscription is an event, not a tick or a balance.
+	if export.Keys != header.KV.Len() || export.UndecodedKeys == 0 {
+		t.Fatalf("Unexpected %d undecoded keys of %d", export.UndecodedKeys, export.Keys)
+	}
+
+	var buffer bytes.Buffer
+	if err := WriteExport(&buffer, export, "csv"); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&buffer).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 4 || records[1][1] != "tick" || records[2][1] != "balance" || records[2][3] != pkscriptA || records[3][5] != expected[1].OverallBalance {
+		t.Fatalf("Unexpected CSV %v", records)
+	}
+
+	// The command exports the stored state only at its height.
+	if err := stateless.StoreHeader(header, 0); err != nil {
+		t.Fatal(err)
+	}
+	output := filepath.Join(t.TempDir(), "export.json")
+	if err := Export(&ExportArguments{Height: 800002, Format: "json", Output: output}); err == nil {
+		t.Fatal("Expected the height other than the stored one to be rejected")
+	}
+	if err := Export(&ExportArguments{Height: 800003, Format: "json", Output: output}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stored stateless.StateExport
+	if err := json.Unmarshal(data, &stored); err != nil {
+		t.Fatal(err)
+	}
+	if stored.Height != 800003 || stored.Commitment != export.Commitment || len(stored.Balances) != 2 {
+		t.Fatalf("Unexpected stored export %+v", stored)
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+func Test_StateForecast(t *testing.T) {
+	ordGetterTest, arguments := loadMain(782000)
+	queue, err := CatchupStage(ordGetterTest, &arguments, stateless.BRC20StartHeight-1, 780000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mockService(ordGetterTest, queue, 10)
+
+	f := stateless.CurrentForecast()
+	if f.Height != queue.Header.Height || f.Keys != queue.Header.KV.Len() {
+		t.Fatalf("Unexpected forecast at height %d of %d keys", f.Height, f.Keys)
+	}
+	if f.Blocks != int(queue.Header.Height-stateless.BRC20StartHeight+1) {
+		t.Fatalf("Expected every block since the start to be observed, got %d", f.Blocks)
+	}
+
+	// Every key of the state grows from an empty state, in a known category.
+	total := 0
+	for _, c := range f.Categories {
+		total += c.Keys
+		if c.Category == "other" && c.Keys != 0 {
+			t.Fatalf("Expected every key to be categorized, got %d other keys", c.Keys)
+		}
+		if (c.Category == "balances" || c.Category == "ticks") && c.Keys == 0 {
+			t.Fatalf("Expected the %s to grow", c.Category)
+		}
+		if c.PeakRate < c.Rate {
+			t.Fatalf("The peak rate of the %s is lower than the average", c.Category)
+		}
+	}
+	if total != f.Keys {
+		t.Fatalf("Expected the growth %d to add up to the state size %d", total, f.Keys)
+	}
+
+	for i, p := range f.Projections {
+		if p.Keys < f.Keys || p.PeakKeys < p.Keys || p.StorageBytes != uint64(p.Keys)*stateless.StorageBytesPerKey {
+			t.Fatalf("Unexpected projection %+v", p)
+		}
+		if i > 0 && p.Keys < f.Projections[i-1].Keys {
+			t.Fatal("Expected the projections to grow with the horizon")
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-verkle"
+	"github.com/holiman/uint256"
+	"golang.org/x/crypto/sha3"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord/brc20"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/getter"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/protocol"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+// rehash derives the stem of the layout 2 of the test from the stem of the layout 1.
+func rehash(key []byte) []byte {
+	hasher := sha3.NewLegacyKeccak256()
+	hasher.Write([]byte("layout2"))
+	hasher.Write(key[:verkle.StemSize])
+	return append(hasher.Sum(nil)[:verkle.StemSize], key[verkle.StemSize])
+}
+
+func Test_StateMigration(t *testing.T) {
+	pkscript := "0014" + strings.Repeat("6d", 20)
+	header := stateless.LoadHeader(false, 800000)
+	stateless.Exec(header, []getter.OrdTransfer{
+		inscribe(strings.Repeat("6", 64)+"i0", pkscript, "", `{"p":"brc-20","op":"deploy","tick":"mgrt","max":"100","lim":"10"}`),
+	}, 800001)
+	if err := header.Paging(nil, false, stateless.NodeResolveFn); err != nil {
+		t.Fatal(err)
+	}
+	size := header.KV.Len()
+	stem := brc20.GetTickHash("mgrt", 0)[:verkle.StemSize]
+	old := make(map[string][]byte)
+	_ = header.Range(stem, func(key []byte, value []byte) bool {
+		old[string(key)] = bytes.Clone(value)
+		return true
+	})
+
+	// The migration moves the keys of the tick to the stem rehashed by the layout 2.
+	stateless.Migrations[brc20.SchemaVersion] = stateless.Migration{
+		Name: "rehash-ticks",
+		From: brc20.SchemaVersion,
+		Rewrite: func(state protocol.KVStorage) error {
+			moved := make(map[string][]byte)
+			if err := state.Range(stem, func(key []byte, value []byte) bool {
+				moved[string(key)] = bytes.Clone(value)
+				return true
+			}); err != nil {
+				return err
+			}
+			for key, value := range moved {
+				if err := state.Delete([]byte(key)); err != nil {
+					return err
+				}
+				if err := state.InsertUInt256(rehash([]byte(key)), new(uint256.Int).SetBytes(value)); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+	defer delete(stateless.Migrations, brc20.SchemaVersion)
+	migrated, err := header.Migrate(brc20.SchemaVersion + 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrated) != 1 || migrated[0].Height != 800001 || migrated[0].To != brc20.SchemaVersion+1 {
+		t.Fatalf("Unexpected migrations %+v", migrated)
+	}
+	if header.KV.Len() != size {
+		t.Fatalf("The migrated state holds %d keys, expected %d", header.KV.Len(), size)
+	}
+	_ = header.Range(stem, func(key []byte, value []byte) bool {
+		t.Fatalf("The old key %x is left by the migration", key)
+		return false
+	})
+	for key, value := range old {
+		moved, err := header.GetUInt256(rehash([]byte(key)))
+		if err != nil || !bytes.Equal(moved.PaddedBytes(len(value)), value) {
+			t.Fatalf("The key %x is moved with %v, expected %x", key, moved, value)
+		}
+	}
+	rebuilt := verkle.New()
+	header.KV.Range(func(key [verkle.KeySize]byte, value [stateless.ValueSize]byte) bool {
+		_ = rebuilt.Insert(key[:], value[:], nil)
+		return true
+	})
+	if rebuilt.Commit().Bytes() != header.Root.Commit().Bytes() {
+		t.Fatal("The migrated tree differs from the tree of the migrated key-values")
+	}
+
+	// The checkpoints after the migration publish the layout and the migration.
+	c := newCheckpoint(NewRuntimeArguments(), &stateless.DiffState{Height: 800001, VerkleCommit: header.Root.Commit().Bytes()})
+	if c.SchemaVersion != brc20.SchemaVersion+1 || len(c.Migrations) != 1 || c.Migrations[0].Name != "rehash-ticks" || c.Migrations[0].Commitment != c.Commitment {
+		t.Fatalf("Unexpected migrations %+v of the checkpoint", c.Migrations)
+	}
+
+	if _, err := header.Migrate(brc20.SchemaVersion + 2); err == nil {
+		t.Fatal("Expected the missing migration to fail")
+	}
+	if stateless.CurrentLayout().Version != brc20.SchemaVersion+1 {
+		t.Fatalf("Unexpected layout %+v", stateless.CurrentLayout())
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/ethereum/go-verkle"
+
+	"github.com/RiemaLabs/modular-indexer-committee/apis"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+func Test_StatePruning(t *testing.T) {
+	var latestHeight uint = 779960
+	stateless.WitnessPath = t.TempDir()
+	stateless.HistoryPath = t.TempDir()
+	stateless.EventsPath = t.TempDir()
+	// The state caches shared with the other tests are kept.
+	stateless.Retention = stateless.RetentionConfig{SnapshotBlocks: 1 << 30, WitnessBlocks: 10, HistoryBlocks: 20, EventsBlocks: 10}
+	defer func() {
+		_ = stateless.CloseHistory()
+		_ = stateless.CloseEvents()
+		stateless.WitnessPath, stateless.HistoryPath, stateless.EventsPath = "", "", ""
+		stateless.Retention = stateless.RetentionConfig{}
+	}()
+
+	ordGetterTest, arguments := loadMain(782000)
+	queue, err := CatchupStage(ordGetterTest, &arguments, stateless.BRC20StartHeight-1, latestHeight)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := stateless.Prune(queue.LatestHeight())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Witnesses == 0 || result.HistoryBlocks == 0 || result.EventsBlocks == 0 {
+		t.Fatalf("Expected the old history to be pruned, got %+v", result)
+	}
+	files, err := os.ReadDir(stateless.WitnessPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		if file.Name() < "779950.json" {
+			t.Fatalf("Unexpected kept witness %s", file.Name())
+		}
+	}
+	if _, err := stateless.LoadWitness(stateless.WitnessPath, 779950); err != nil {
+		t.Fatal(err)
+	}
+
+	// The values are served as long as the writes of the later blocks are kept.
+	if _, err := queue.ValueAt([verkle.KeySize]byte{}, 779940); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := queue.ValueAt([verkle.KeySize]byte{}, 779930); !errors.Is(err, stateless.ErrHistoryUnavailable) {
+		t.Fatalf("Expected the pruned height to be unavailable, got %v", err)
+	}
+	if _, err := stateless.BlockEvents(779949); !errors.Is(err, stateless.ErrEventsUnavailable) {
+		t.Fatalf("Expected the pruned events to be unavailable, got %v", err)
+	}
+	if _, err := stateless.BlockEvents(779950); err != nil {
+		t.Fatal(err)
+	}
+
+	// The operators force a pruning by the admin API, which finds nothing more to prune.
+	apis.Admin = &apis.AdminService{Tokens: func() []string { return []string{"operator"} }}
+	defer func() { apis.Admin = nil }()
+	ts := httptest.NewServer(apis.NewRouter(queue, "brc-20", false, false))
+	defer ts.Close()
+	prune := func(token string) (int, apis.PruneResponse) {
+		req, err := http.NewRequest(http.MethodPost, ts.URL+"/v1/admin/prune", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var res apis.PruneResponse
+		if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode, res
+	}
+	if status, _ := prune("wallet"); status != http.StatusUnauthorized {
+		t.Fatalf("Unexpected status %d of an unauthorized pruning", status)
+	}
+	status, res := prune("operator")
+	if status != http.StatusOK || res.Result.Height != latestHeight || res.Result.Witnesses != 0 || res.Result.HistoryBlocks != 0 {
+		t.Fatalf("Unexpected pruning %d %+v", status, res.Result)
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-verkle"
+	"github.com/holiman/uint256"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord/brc20"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/getter"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+func Test_StateRangeDelete(t *testing.T) {
+	pkscript := "0014" + strings.Repeat("7c", 20)
+	header := stateless.LoadHeader(false, 800000)
+	stateless.Exec(header, []getter.OrdTransfer{
+		inscribe(strings.Repeat("7", 64)+"i0", pkscript, "", `{"p":"brc-20","op":"deploy","tick":"rnge","max":"100","lim":"10"}`),
+	}, 800001)
+	if err := header.Paging(nil, false, stateless.NodeResolveFn); err != nil {
+		t.Fatal(err)
+	}
+	commitment := header.Root.Commit().Bytes()
+	digest := header.Digest()
+	size := header.KV.Len()
+
+	stem := brc20.GetTickHash("rnge", 0)[:verkle.StemSize]
+	ranged := 0
+	if err := header.Range(stem, func(key []byte, value []byte) bool {
+		if !bytes.HasPrefix(key, stem) {
+			t.Fatalf("The key %x is out of the prefix %x", key, stem)
+		}
+		ranged++
+		return true
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if ranged == 0 {
+		t.Fatal("Expected the deploy to be ranged by the stem of its tick")
+	}
+
+	// A key written by a block and deleted by the next leaves the state as it was.
+	key := bytes.Repeat([]byte{0xfe}, verkle.KeySize)
+	if err := header.InsertUInt256(key, uint256.NewInt(7)); err != nil {
+		t.Fatal(err)
+	}
+	if err := header.Paging(nil, false, stateless.NodeResolveFn); err != nil {
+		t.Fatal(err)
+	}
+	if header.KV.Len() != size+1 {
+		t.Fatalf("The state holds %d keys, expected %d", header.KV.Len(), size+1)
+	}
+	if err := header.Delete(key); err != nil {
+		t.Fatal(err)
+	}
+	if value, err := header.GetUInt256(key); err != nil || !value.IsZero() {
+		t.Fatalf("The deleted key reads %v, %v", value, err)
+	}
+	if err := header.Range(key[:verkle.StemSize], func(key []byte, value []byte) bool {
+		t.Fatalf("The deleted key %x is ranged", key)
+		return false
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := header.Paging(nil, false, stateless.NodeResolveFn); err != nil {
+		t.Fatal(err)
+	}
+	if header.KV.Len() != size || header.Digest() != digest {
+		t.Fatalf("The state holds %d keys after the deletion, expected %d", header.KV.Len(), size)
+	}
+	if header.Root.Commit().Bytes() != commitment {
+		t.Fatal("The commitment differs after the deletion of the written key")
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/RiemaLabs/modular-indexer-committee/apis"
+	"github.com/RiemaLabs/modular-indexer-committee/checkpoint"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/getter"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/subscription"
+)
+
+func Test_Subscription(t *testing.T) {
+	pkscriptA := "0014" + strings.Repeat("a2", 20)
+	pkscriptB := "0014" + strings.Repeat("b2", 20)
+	g := &blocksGetter{
+		blocks: map[uint][]getter.OrdTransfer{
+			800001: {inscribe(strings.Repeat("3", 64)+"i0", pkscriptA, "", `{"p":"brc-20","op":"deploy","tick":"WSST","max":"100"}`)},
+			800002: {inscribe(strings.Repeat("3", 64)+"i1", pkscriptA, "", `{"p":"brc-20","op":"mint","tick":"wsst","amt":"10"}`)},
+		},
+		hashes: make(map[uint]string),
+	}
+	hub := subscription.NewHub(1)
+	stateless.Subscriptions = hub
+	defer func() {
+		stateless.Subscriptions = nil
+	}()
+	header := stateless.LoadHeader(false, 800000)
+	queue, err := stateless.NewQueues(g, header, true, 800001)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(apis.NewRouter(queue, "brc-20", false, false))
+	defer srv.Close()
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws"
+
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	read := func(v any) {
+		_ = conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+		if err := conn.ReadJSON(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := conn.WriteJSON(apis.SubscriptionRequest{Type: apis.SubscriptionSubscribe, Filter: subscription.Filter{Checkpoints: true, Pkscripts: []string{pkscriptB}}}); err != nil {
+		t.Fatal(err)
+	}
+	var reply apis.SubscriptionReply
+	read(&reply)
+	if reply.Error != nil || !reply.Result.Checkpoints || len(reply.Result.Pkscripts) != 1 || reply.Result.Pkscripts[0] != pkscriptB {
+		t.Fatalf("Unexpected reply %+v", reply)
+	}
+	if err := conn.WriteJSON(apis.SubscriptionRequest{Type: "follow"}); err != nil {
+		t.Fatal(err)
+	}
+	read(&reply)
+	if reply.Error == nil {
+		t.Fatal("Expected an unknown request to be rejected")
+	}
+
+	// The subscribers are limited.
+	_, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	if err == nil || resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("Expected the second subscriber to be rejected, got %v", err)
+	}
+
+	// Only the balances of the subscribed pkscript are pushed, after each block.
+	g.blocks[800007] = []getter.OrdTransfer{
+		inscribe(strings.Repeat("4", 64)+"i0", pkscriptB, "", `{"p":"brc-20","op":"mint","tick":"wsst","amt":"5"}`),
+		inscribe(strings.Repeat("4", 64)+"i1", pkscriptA, "", `{"p":"brc-20","op":"mint","tick":"wsst","amt":"3"}`),
+	}
+	g.blocks[800008] = []getter.OrdTransfer{inscribe(strings.Repeat("5", 64)+"i0", pkscriptA, "", `{"p":"brc-20","op":"transfer","tick":"wsst","amt":"1"}`)}
+	if err := queue.Update(g, 800008); err != nil {
+		t.Fatal(err)
+	}
+	var m subscription.Message
+	read(&m)
+	expected := subscription.BalanceChange{Tick: "wsst", Pkscript: pkscriptB, AvailableBalance: "5000000000000000000", OverallBalance: "5000000000000000000"}
+	if m.Type != subscription.TypeBalances || m.Height != 800007 || m.Hash != "hash800007" || m.Commitment == "" || len(m.Changes) != 1 || m.Changes[0] != expected {
+		t.Fatalf("Unexpected message %+v", m)
+	}
+
+	if err := hub.PublishCheckpoint(800008, "hash800008", checkpoint.Checkpoint{Height: "800008", Hash: "hash800008"}); err != nil {
+		t.Fatal(err)
+	}
+	read(&m)
+	var c checkpoint.Checkpoint
+	if m.Type != subscription.TypeCheckpoint || m.Height != 800008 || json.Unmarshal(m.Checkpoint, &c) != nil || c.Height != "800008" {
+		t.Fatalf("Unexpected message %+v", m)
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/RiemaLabs/modular-indexer-committee/apis"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/getter"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+func Test_TickCensus(t *testing.T) {
+	header := stateless.LoadHeader(false, 800000)
+	pkscript := "0014" + strings.Repeat("33", 20)
+	blocks := [][]getter.OrdTransfer{
+		{
+			inscribe(strings.Repeat("d", 64)+"i0", pkscript, "", `{"p":"brc-20","op":"deploy","tick":"fast","max":"10","lim":"10"}`),
+			inscribe(strings.Repeat("d", 64)+"i1", pkscript, "", `{"p":"brc-20","op":"deploy","tick":"slow","max":"100","lim":"10"}`),
+		},
+		{inscribe(strings.Repeat("e", 64)+"i0", pkscript, "", `{"p":"brc-20","op":"mint","tick":"fast","amt":"10"}`)},
+		{inscribe(strings.Repeat("e", 64)+"i1", pkscript, "", `{"p":"brc-20","op":"mint","tick":"slow","amt":"10"}`)},
+	}
+	for _, ots := range blocks {
+		stateless.Exec(header, ots, header.Height+1)
+		if err := header.Paging(nil, false, stateless.NodeResolveFn); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	census := stateless.CurrentCensus(2)
+	if census.Height != 800003 || census.TotalTicks != 2 || census.Completed != 1 || census.Minting != 1 || census.Abandoned != 0 {
+		t.Fatalf("Unexpected census %+v", census)
+	}
+	if len(census.Days) != 2 || census.Days[0].Deploys != 2 || census.Days[0].ToHeight != 800003 || census.Days[1].Deploys != 0 {
+		t.Fatalf("Unexpected deploys per day %+v", census.Days)
+	}
+
+	queue := &stateless.Queue{Header: header}
+	r := apis.NewRouter(queue, "brc-20", false, false)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/brc20/census/ticks?limit=1&offset=1", nil))
+	var resp apis.CensusTicksResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("Unexpected response %d: %s", w.Code, w.Body.String())
+	}
+	ticks := resp.Result.Ticks
+	if resp.Result.Total != 2 || len(ticks) != 1 || ticks[0].Tick != "slow" || ticks[0].DeployHeight != 800001 || ticks[0].LastMintHeight != 800003 {
+		t.Fatalf("Unexpected ticks %+v", resp.Result)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/brc20/census?days=1000", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected too many days to be rejected, got %d", w.Code)
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/RiemaLabs/modular-indexer-committee/apis"
+	"github.com/RiemaLabs/modular-indexer-committee/lightclient"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/brc20"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/getter"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+func Test_TickCounters(t *testing.T) {
+	brc20.CountersHeight = 800001
+	defer func() {
+		brc20.CountersHeight = 0
+	}()
+
+	transferID := strings.Repeat("6", 64) + "i0"
+	transferContent := `{"p":"brc-20","op":"transfer","tick":"cntr","amt":"10"}`
+	spent := inscribe(transferID, successorPkscript, "", transferContent)
+	spent.OldSatpoint = strings.Repeat("6", 64) + ":0:0"
+	g := &blocksGetter{
+		blocks: map[uint][]getter.OrdTransfer{
+			800001: {inscribe(strings.Repeat("4", 64)+"i0", deployerPkscript, "", `{"p":"brc-20","op":"deploy","tick":"cntr","max":"100","lim":"10"}`)},
+			800002: {
+				inscribe(strings.Repeat("5", 64)+"i0", deployerPkscript, "", `{"p":"brc-20","op":"mint","tick":"cntr","amt":"10"}`),
+				inscribe(strings.Repeat("5", 64)+"i1", successorPkscript, "", `{"p":"brc-20","op":"mint","tick":"cntr","amt":"10"}`),
+			},
+			// The deployer sends all its balance to the successor, which leaves the holders.
+			800003: {inscribe(transferID, deployerPkscript, "", transferContent)},
+			800004: {spent},
+		},
+		hashes: make(map[uint]string),
+	}
+	header := stateless.LoadHeader(false, 800000)
+	queue, err := stateless.NewQueues(g, header, true, 800001)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := apis.NewRouter(queue, "brc-20", false, false)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/brc20_tick/CNTR", nil))
+	var tick apis.Brc20TickResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &tick); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("Unexpected response %d: %s", w.Code, w.Body.String())
+	}
+	if info := tick.Result.TickInfo; info.HolderCount != 1 || info.MintCount != 2 || info.MintedAmount != "20000000000000000000" {
+		t.Fatalf("Unexpected counters %+v", info)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/brc20_verifiable/tick_counters?tick=CNTR", nil))
+	var resp apis.Brc20VerifiableTickCountersResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || w.Code != http.StatusOK || resp.Proof == nil {
+		t.Fatalf("Unexpected response %d: %s", w.Code, w.Body.String())
+	}
+	claimed := lightclient.TickCounters{
+		Tick:         resp.Result.Tick,
+		HolderCount:  resp.Result.HolderCount,
+		MintCount:    resp.Result.MintCount,
+		MintedAmount: resp.Result.MintedAmount,
+	}
+	if claimed.Tick != "cntr" || claimed.HolderCount != "1" || claimed.MintCount != "2" {
+		t.Fatalf("Unexpected counters %+v", resp.Result)
+	}
+	rootC, err := lightclient.ParseCommitment(resp.Result.StateRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := lightclient.VerifyTickCounters(rootC, claimed, *resp.Proof); err != nil {
+		t.Fatal(err)
+	}
+	claimed.HolderCount = "2"
+	if lightclient.VerifyTickCounters(rootC, claimed, *resp.Proof) == nil {
+		t.Fatal("Expected the inflated holder count to fail the verification")
+	}
+}
+
+func Test_TickCountersBeforeActivation(t *testing.T) {
+	brc20.CountersHeight = 800002
+	defer func() {
+		brc20.CountersHeight = 0
+	}()
+
+	// The deployer holds the tick before the activation, then leaves the holders after it.
+	transferID := strings.Repeat("9", 64) + "i0"
+	transferContent := `{"p":"brc-20","op":"transfer","tick":"prea","amt":"10"}`
+	spent := inscribe(transferID, successorPkscript, "", transferContent)
+	spent.OldSatpoint = strings.Repeat("9", 64) + ":0:0"
+	g := &blocksGetter{
+		blocks: map[uint][]getter.OrdTransfer{
+			800001: {
+				inscribe(strings.Repeat("7", 64)+"i0", deployerPkscript, "", `{"p":"brc-20","op":"deploy","tick":"prea","max":"100","lim":"10"}`),
+				inscribe(strings.Repeat("7", 64)+"i1", deployerPkscript, "", `{"p":"brc-20","op":"mint","tick":"prea","amt":"10"}`),
+			},
+			800002: {
+				inscribe(strings.Repeat("8", 64)+"i0", successorPkscript, "", `{"p":"brc-20","op":"mint","tick":"prea","amt":"10"}`),
+				inscribe(strings.Repeat("8", 64)+"i1", deployerPkscript, "", `{"p":"brc-20","op":"deploy","tick":"post","max":"100","lim":"10"}`),
+				inscribe(strings.Repeat("8", 64)+"i2", deployerPkscript, "", `{"p":"brc-20","op":"mint","tick":"post","amt":"10"}`),
+			},
+			800003: {inscribe(transferID, deployerPkscript, "", transferContent)},
+			800004: {spent},
+		},
+		hashes: make(map[uint]string),
+	}
+	header := stateless.LoadHeader(false, 800000)
+	queue, err := stateless.NewQueues(g, header, true, 800001)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The holders of the tick deployed before the activation aren't counted, rather than miscounted by the holder
+	// leaving after the activation, while the ones of the tick deployed after it are.
+	for tick, holders := range map[string]uint64{"prea": 0, "post": 1} {
+		_, holderCount, _, _, err := brc20.GetTickCounters(queue.Header, tick)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if holderCount.Uint64() != holders {
+			t.Fatalf("Expected %d holders of %s, got %s", holders, tick, holderCount)
+		}
+	}
+	counted, err := queue.Header.GetUInt256(brc20.GetTickHash("prea", brc20.HoldersCounted))
+	if err != nil || !counted.IsZero() {
+		t.Fatalf("Expected the holders of prea not to be counted, got %v: %v", counted, err)
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/RiemaLabs/modular-indexer-committee/apis"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/getter"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+// blocksGetter serves the synthetic blocks by their heights.
+type blocksGetter struct {
+	blocks map[uint][]getter.OrdTransfer
+	hashes map[uint]string
+}
+
+func (g *blocksGetter) GetLatestBlockHeight() (uint, error) { return 0, nil }
+
+func (g *blocksGetter) GetBlockHash(blockHeight uint) (string, error) {
+	if hash, found := g.hashes[blockHeight]; found {
+		return hash, nil
+	}
+	return fmt.Sprintf("hash%d", blockHeight), nil
+}
+
+func (g *blocksGetter) GetOrdTransfers(blockHeight uint) ([]getter.OrdTransfer, error) {
+	return g.blocks[blockHeight], nil
+}
+
+func Test_TickHolders(t *testing.T) {
+	pkscriptA := "0014" + strings.Repeat("aa", 20)
+	pkscriptB := "0014" + strings.Repeat("bb", 20)
+	pkscriptC := "0014" + strings.Repeat("cc", 20)
+	pkscriptD := "0014" + strings.Repeat("dd", 20)
+	transferA := strings.Repeat("a", 64) + "i0"
+	transferB := strings.Repeat("b", 64) + "i0"
+	move := func(inscriptionID string, pkscript string) getter.OrdTransfer {
+		ot := inscribe(inscriptionID, pkscript, "", `{"p":"brc-20","op":"transfer","tick":"hold","amt":"20"}`)
+		ot.OldSatpoint = inscriptionID + ":0:0"
+		return ot
+	}
+	g := &blocksGetter{
+		blocks: map[uint][]getter.OrdTransfer{
+			800001: {inscribe(strings.Repeat("d", 64)+"i0", pkscriptA, "", `{"p":"brc-20","op":"deploy","tick":"HOLD","max":"100","lim":"50"}`)},
+			800002: {
+				inscribe(strings.Repeat("e", 64)+"i0", pkscriptA, "", `{"p":"brc-20","op":"mint","tick":"hold","amt":"50"}`),
+				inscribe(strings.Repeat("e", 64)+"i1", pkscriptB, "", `{"p":"brc-20","op":"mint","tick":"hold","amt":"20"}`),
+			},
+			800003: {inscribe(transferA, pkscriptA, "", `{"p":"brc-20","op":"transfer","tick":"hold","amt":"20"}`)},
+			800004: {inscribe(transferB, pkscriptB, "", `{"p":"brc-20","op":"transfer","tick":"hold","amt":"20"}`)},
+			800005: {move(transferB, pkscriptA)},
+			800006: {move(transferA, pkscriptC)},
+		},
+		hashes: make(map[uint]string),
+	}
+	header := stateless.LoadHeader(false, 800000)
+	queue, err := stateless.NewQueues(g, header, true, 800001)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := func(holders ...string) {
+		page, total, height, fromHeight := stateless.TickHolders("hold", 0, 10)
+		if height != queue.Header.Height || fromHeight != 800001 || total != len(holders) || len(page) != len(holders) {
+			t.Fatalf("Unexpected holders at height %d from %d: %+v", height, fromHeight, page)
+		}
+		for i, holder := range page {
+			if got := holder.Pkscript + "=" + holder.OverallBalance; got != holders[i] {
+				t.Fatalf("Expected the holder %s at %d, got %s", holders[i], i, got)
+			}
+		}
+	}
+	// The holder moving all of its balance away is removed.
+	expect(pkscriptA+"=50000000000000000000", pkscriptC+"=20000000000000000000")
+
+	// The reorganized block moves the transfer to another pkscript instead.
+	g.blocks[800006] = []getter.OrdTransfer{move(transferA, pkscriptD)}
+	g.hashes[800006] = "fork800006"
+	if err := queue.Recovery(g, 800006); err != nil {
+		t.Fatal(err)
+	}
+	expect(pkscriptA+"=50000000000000000000", pkscriptD+"=20000000000000000000")
+
+	r := apis.NewRouter(queue, "brc-20", false, false)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/brc20/holders?tick=HOLD&offset=1&limit=1", nil))
+	var resp apis.HoldersResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("Unexpected response %d: %s", w.Code, w.Body.String())
+	}
+	if resp.Result.Total != 2 || len(resp.Result.Holders) != 1 || resp.Result.Holders[0].Pkscript != pkscriptD || resp.Result.Height != 800006 {
+		t.Fatalf("Unexpected holders %+v", resp.Result)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/brc20/holders?tick=hold&limit=10000", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected too many holders to be rejected, got %d", w.Code)
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/RiemaLabs/modular-indexer-committee/apis"
+	"github.com/RiemaLabs/modular-indexer-committee/ord"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/getter"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+func Test_TickInfo(t *testing.T) {
+	pkscript := "0014" + strings.Repeat("ee", 20)
+	deployID := strings.Repeat("f", 64) + "i0"
+	deploy := inscribe(deployID, pkscript, "", `{"p":"brc-20","op":"deploy","tick":"META","max":"100","lim":"30","dec":"2"}`)
+	deploy.NewWallet = ord.Wallet("bc1qdeployer")
+	g := &blocksGetter{
+		blocks: map[uint][]getter.OrdTransfer{
+			800001: {inscribe(strings.Repeat("1", 64)+"i0", pkscript, "", `{"p":"brc-20","op":"deploy","tick":"frst","max":"10"}`)},
+			800002: {deploy},
+			800003: {inscribe(strings.Repeat("2", 64)+"i0", pkscript, "", `{"p":"brc-20","op":"mint","tick":"meta","amt":"30"}`)},
+			800004: {inscribe(strings.Repeat("3", 64)+"i0", pkscript, "", `{"p":"brc-20","op":"mint","tick":"meta","amt":"25.5"}`)},
+		},
+		hashes: make(map[uint]string),
+	}
+	header := stateless.LoadHeader(false, 800000)
+	queue, err := stateless.NewQueues(g, header, true, 800001)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := apis.NewRouter(queue, "brc-20", false, false)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/brc20_tick/META", nil))
+	var resp apis.Brc20TickResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("Unexpected response %d: %s", w.Code, w.Body.String())
+	}
+	expected := stateless.TickInfo{
+		Tick:              "meta",
+		InscriptionID:     deployID,
+		DeployerPkscript:  pkscript,
+		Deployer:          "bc1qdeployer",
+		DeployHeight:      800002,
+		MaxSupply:         "100000000000000000000",
+		LimitPerMint:      "30000000000000000000",
+		Decimals:          2,
+		Minted:            "55500000000000000000",
+		RemainingSupply:   "44500000000000000000",
+		BurnedSupply:      "0",
+		CirculatingSupply: "55500000000000000000",
+		MintedAmount:      "0",
+		LastMintHeight:    800004,
+	}
+	if resp.Result.TickInfo != expected || resp.Result.Height != queue.Header.Height {
+		t.Fatalf("Unexpected tick %+v", resp.Result)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/brc20_ticks?offset=1&limit=5", nil))
+	var list apis.Brc20TicksResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("Unexpected response %d: %s", w.Code, w.Body.String())
+	}
+	if list.Result.Total != 2 || len(list.Result.Ticks) != 1 || list.Result.Ticks[0] != expected {
+		t.Fatalf("Unexpected ticks %+v", list.Result)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/brc20_tick/none", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("Expected an undeployed tick to be not found, got %d", w.Code)
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-verkle"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+func Test_TreeEviction(t *testing.T) {
+	var latestHeight uint = 780000
+	ordGetterTest, arguments := loadMain(782000)
+	arguments.EnableStateRootCache = true
+	stateless.StateDBPath = t.TempDir()
+	stateless.EvictInterval = 1
+	t.Cleanup(func() {
+		_ = stateless.CloseStateDB()
+		stateless.StateDBPath = ""
+		stateless.EvictInterval = 0
+		for _, pattern := range []string{"*.census", "*.holders", "state.layout"} {
+			files, _ := filepath.Glob(filepath.Join(".cache", pattern))
+			for _, file := range files {
+				_ = os.Remove(file)
+			}
+		}
+	})
+
+	queue, err := CatchupStage(ordGetterTest, &arguments, stateless.BRC20StartHeight-1, latestHeight)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := queue.Header
+	keys := make([][verkle.KeySize]byte, 0)
+	header.KV.Range(func(key [verkle.KeySize]byte, _ [stateless.ValueSize]byte) bool {
+		keys = append(keys, key)
+		return len(keys) < 200
+	})
+
+	// The blocks are executed on the tree evicted after every block, whose nodes are resolved from the database.
+	for i := header.Height + 1; i <= latestHeight+10; i++ {
+		ots, err := ordGetterTest.GetOrdTransfers(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stateless.Exec(header, ots, i)
+		if err := header.Paging(ordGetterTest, false, stateless.NodeResolveFn); err != nil {
+			t.Fatal(err)
+		}
+		if err := header.Evict(); err != nil {
+			t.Fatal(err)
+		}
+		for _, key := range keys {
+			expected, _ := header.KV.Get(key)
+			value, err := header.Root.Get(key[:], stateless.NodeResolveFn)
+			if err != nil || !bytes.Equal(value, expected[:]) {
+				t.Fatalf("The key %x is resolved as %x with %v at height %d, expected %x", key, value, err, i, expected)
+			}
+		}
+	}
+	rebuilt := verkle.New()
+	header.KV.Range(func(key [verkle.KeySize]byte, value [stateless.ValueSize]byte) bool {
+		_ = rebuilt.Insert(key[:], value[:], nil)
+		return true
+	})
+	commitment := header.Root.Commit().Bytes()
+	if rebuilt.Commit().Bytes() != commitment {
+		t.Fatal("The evicted tree differs from the tree of the key-values")
+	}
+
+	// The commit moves the evicted nodes to the committed tree, which a restart resumes from.
+	if err := stateless.StoreHeader(header, 0); err != nil {
+		t.Fatal(err)
+	}
+	size := header.KV.Len()
+	if err := stateless.CloseStateDB(); err != nil {
+		t.Fatal(err)
+	}
+	restarted := stateless.LoadHeader(true, stateless.BRC20StartHeight-1)
+	if restarted.Height != latestHeight+10 || restarted.Root.Commit().Bytes() != commitment || restarted.KV.Len() != size {
+		t.Fatalf("The state is loaded at height %d with %d keys, expected the committed height %d with %d keys", restarted.Height, restarted.KV.Len(), latestHeight+10, size)
+	}
+	restarted.Hydrate()
+	if err := restarted.Evict(); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range keys {
+		expected, _ := restarted.KV.Get(key)
+		if value, err := restarted.Root.Get(key[:], stateless.NodeResolveFn); err != nil || !bytes.Equal(value, expected[:]) {
+			t.Fatalf("The key %x is resolved as %x with %v after the restart, expected %x", key, value, err, expected)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RiemaLabs/modular-indexer-committee/internal/retry"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/getter"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+// outageGetter fails every call while the upstream is down, counting the calls.
+type outageGetter struct {
+	*blocksGetter
+	down  bool
+	calls int
+}
+
+var errOutage = errors.New("connection refused")
+
+func (g *outageGetter) GetBlockHash(blockHeight uint) (string, error) {
+	g.calls++
+	if g.down {
+		return "", errOutage
+	}
+	return g.blocksGetter.GetBlockHash(blockHeight)
+}
+
+func (g *outageGetter) GetOrdTransfers(blockHeight uint) ([]getter.OrdTransfer, error) {
+	g.calls++
+	if g.down {
+		return nil, errOutage
+	}
+	return g.blocksGetter.GetOrdTransfers(blockHeight)
+}
+
+func Test_UpstreamOutage(t *testing.T) {
+	pkscript := "0014" + strings.Repeat("aa", 20)
+	blocks := &blocksGetter{blocks: map[uint][]getter.OrdTransfer{}, hashes: make(map[uint]string)}
+	header := stateless.LoadHeader(false, 800000)
+	queue, err := stateless.NewQueues(blocks, header, true, 800001)
+	if err != nil {
+		t.Fatal(err)
+	}
+	latestHeight := queue.LatestHeight()
+	blocks.blocks[latestHeight] = []getter.OrdTransfer{inscribe(strings.Repeat("d", 64)+"i0", pkscript, "", `{"p":"brc-20","op":"deploy","tick":"down","max":"1000"}`)}
+	blocks.blocks[latestHeight+1] = []getter.OrdTransfer{inscribe(strings.Repeat("e", 64)+"i0", pkscript, "", `{"p":"brc-20","op":"deploy","tick":"back","max":"1000"}`)}
+
+	g := &outageGetter{blocksGetter: blocks, down: true}
+	breaker := retry.NewBreaker("getter", retry.Config{Attempts: 2, Backoff: 1, MaxBackoff: 1, Threshold: 2, Cooldown: 1})
+	r := getter.NewRetrying(g, breaker)
+	commitment := queue.Header.Root.Commit().Bytes()
+	history := len(queue.History)
+	untouched := func() bool {
+		return queue.LatestHeight() == latestHeight && queue.Header.Root.Commit().Bytes() == commitment && len(queue.History) == history
+	}
+
+	// The failed blocks and reorgs leave the state untouched, until the circuit opens.
+	if err := queue.Update(r, latestHeight+1); !errors.Is(err, retry.ErrUnavailable) || !untouched() {
+		t.Fatalf("Expected the block to be left unexecuted, got %v at height %d", err, queue.LatestHeight())
+	}
+	if err := queue.Recovery(r, latestHeight); !errors.Is(err, retry.ErrUnavailable) || !untouched() {
+		t.Fatalf("Expected the reorg to be left unrecovered, got %v at height %d", err, queue.LatestHeight())
+	}
+	if g.calls != 4 || breaker.State() != retry.Open {
+		t.Fatalf("Expected the circuit to open after 4 calls, got %s after %d", breaker.State(), g.calls)
+	}
+	if err := queue.Update(r, latestHeight+1); !errors.Is(err, retry.ErrUnavailable) || g.calls != 4 {
+		t.Fatalf("Expected the open circuit to refuse the block, got %v after %d calls", err, g.calls)
+	}
+
+	// The indexing resumes once the upstream is back after the cooldown.
+	g.down = false
+	time.Sleep(breaker.Pause())
+	if err := queue.Recovery(r, latestHeight); err != nil || breaker.State() != retry.Closed {
+		t.Fatalf("Expected the reorg to be recovered, got %v in %s", err, breaker.State())
+	}
+	if err := queue.Update(r, latestHeight+1); err != nil || queue.LatestHeight() != latestHeight+1 {
+		t.Fatalf("Expected the block to be executed, got %v at height %d", err, queue.LatestHeight())
+	}
+	if census := stateless.CurrentCensus(0); census.TotalTicks < 2 {
+		t.Fatalf("Expected both ticks to be deployed, got %d", census.TotalTicks)
+	}
+}
+
+// hashOutageGetter fails the hash of a block while its transfers are served.
+type hashOutageGetter struct {
+	*blocksGetter
+	failing uint
+}
+
+func (g *hashOutageGetter) GetBlockHash(blockHeight uint) (string, error) {
+	if blockHeight == g.failing {
+		return "", errOutage
+	}
+	return g.blocksGetter.GetBlockHash(blockHeight)
+}
+
+func Test_BlockHashOutage(t *testing.T) {
+	pkscript := "0014" + strings.Repeat("aa", 20)
+	blocks := &blocksGetter{blocks: map[uint][]getter.OrdTransfer{}, hashes: make(map[uint]string)}
+	header := stateless.LoadHeader(false, 800000)
+	queue, err := stateless.NewQueues(blocks, header, true, 800001)
+	if err != nil {
+		t.Fatal(err)
+	}
+	latestHeight := queue.LatestHeight()
+	blocks.blocks[latestHeight+1] = []getter.OrdTransfer{inscribe(strings.Repeat("f", 64)+"i0", pkscript, "", `{"p":"brc-20","op":"deploy","tick":"hash","max":"1000"}`)}
+	commitment := queue.Header.Root.Commit().Bytes()
+	history := len(queue.History)
+
+	// The block whose hash fails is left unexecuted rather than applied along with the hash of its parent.
+	g := &hashOutageGetter{blocksGetter: blocks, failing: latestHeight + 1}
+	if err := queue.Update(g, latestHeight+1); !errors.Is(err, errOutage) {
+		t.Fatalf("Expected the failure of the hash, got %v", err)
+	}
+	if queue.LatestHeight() != latestHeight || queue.Header.Root.Commit().Bytes() != commitment || len(queue.History) != history {
+		t.Fatalf("Expected the block to be left unexecuted, got height %d", queue.LatestHeight())
+	}
+
+	// A failure of the hash of the latest block leaves the reorg unrecovered as well.
+	g.failing = latestHeight
+	if err := queue.Recovery(g, latestHeight); !errors.Is(err, errOutage) || queue.Header.Root.Commit().Bytes() != commitment {
+		t.Fatalf("Expected the reorg to be left unrecovered, got %v", err)
+	}
+
+	g.failing = 0
+	if err := queue.Update(g, latestHeight+1); err != nil || queue.LatestHeight() != latestHeight+1 {
+		t.Fatalf("Expected the block to be executed, got %v at height %d", err, queue.LatestHeight())
+	}
+	if expected, _ := blocks.GetBlockHash(latestHeight + 1); queue.Header.Hash != expected {
+		t.Fatalf("Expected the hash %s of the block, got %s", expected, queue.Header.Hash)
+	}
+	if info, found, _ := queue.Header.TickInfo("hash"); !found || info.MaxSupply != "1000000000000000000000" {
+		t.Fatalf("Expected the tick to be deployed once, got %+v", info)
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/RiemaLabs/modular-indexer-committee/checkpoint"
+	"github.com/RiemaLabs/modular-indexer-committee/client"
+	"github.com/RiemaLabs/modular-indexer-committee/lightclient"
+	"github.com/RiemaLabs/modular-indexer-committee/ord"
+)
+
+// VerifyArguments is what the verify command audits: the balance of a pkscript served by the committee indexers,
+// against the checkpoint published by a member.
+type VerifyArguments struct {
+	Committees []string
+	// Where the member publishes its checkpoints, see OpenSource.
+	Source    string
+	Publisher string
+	// The hex of the public key signing the checkpoints of the member, optional.
+	PublicKey    string
+	MetaProtocol string
+	Network      string
+	DANetwork    string
+	// The height of the balance, 0 for the latest block of the committee indexers.
+	Height   uint
+	Tick     string
+	Pkscript string
+}
+
+// VerifiedBalance is the balance proven against the commitment of the checkpoint.
+type VerifiedBalance struct {
+	Tick             string
+	Pkscript         string
+	Height           uint
+	Hash             string
+	AvailableBalance string
+	OverallBalance   string
+	Publisher        string
+	Commitment       string
+}
+
+// VerifyBalance fetches the checkpoint of the latest block of the committee indexers published by the member,
+// and returns the balance once its proof served by the committee indexers is valid against the checkpoint.
+// The committee indexers only prove the balances of their latest block, so a past height can't be verified.
+func VerifyBalance(ctx context.Context, c *client.Client, source checkpoint.Source, arguments *VerifyArguments) (*VerifiedBalance, error) {
+	latest, err := c.Checkpoint(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if arguments.Height != 0 && arguments.Height != latest.Height {
+		return nil, fmt.Errorf("the committee indexers are at height %d, and only prove the balances of their latest block", latest.Height)
+	}
+	key := checkpoint.ObjectKey(&checkpoint.Checkpoint{
+		Name:         arguments.Publisher,
+		MetaProtocol: arguments.MetaProtocol,
+		Height:       strconv.FormatUint(uint64(latest.Height), 10),
+		Hash:         latest.Hash,
+	})
+	published, err := source.Get(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch the checkpoint %s: %v", key, err)
+	}
+	if err := published.CheckNetwork(ord.IndexedNetwork.Tag()); err != nil {
+		return nil, err
+	}
+	if arguments.PublicKey != "" {
+		if _, err := lightclient.VerifyCheckpoint(published, arguments.PublicKey); err != nil {
+			return nil, err
+		}
+	}
+	balance, err := c.VerifiedBalanceOfPkscript(ctx, published.Commitment, arguments.Tick, arguments.Pkscript)
+	if err != nil {
+		return nil, err
+	}
+	return &VerifiedBalance{
+		Tick:             arguments.Tick,
+		Pkscript:         arguments.Pkscript,
+		Height:           latest.Height,
+		Hash:             latest.Hash,
+		AvailableBalance: balance.AvailableBalance,
+		OverallBalance:   balance.OverallBalance,
+		Publisher:        arguments.Publisher,
+		Commitment:       published.Commitment,
+	}, nil
+}
+
+// Verify runs the verify command, printing the verified balance.
+func Verify(arguments *VerifyArguments) error {
+	if arguments.Tick == "" || arguments.Pkscript == "" {
+		return fmt.Errorf("both --tick and --pkscript are required")
+	}
+	if arguments.Source == "" || arguments.Publisher == "" {
+		return fmt.Errorf("both --checkpoints and --publisher are required")
+	}
+	network, err := ord.ParseNetwork(arguments.Network)
+	if err != nil {
+		return err
+	}
+	ord.IndexedNetwork = network
+	c, err := client.New(arguments.Committees...)
+	if err != nil {
+		return err
+	}
+	GlobalConfig.Report.Da.Network = arguments.DANetwork
+	source, err := OpenSource(arguments.Source)
+	if err != nil {
+		return err
+	}
+	b, err := VerifyBalance(context.Background(), c, source, arguments)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Verified balance of the pkscript %s of the tick %s at height %d (%s)\n", b.Pkscript, b.Tick, b.Height, b.Hash)
+	fmt.Printf("  available: %s\n  overall:   %s\n", b.AvailableBalance, b.OverallBalance)
+	fmt.Printf("Proven against the commitment %s of the checkpoint published by %s\n", b.Commitment, b.Publisher)
+	return nil
+}
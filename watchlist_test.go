@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/brc20"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/watchlist"
+)
+
+func Test_Watchlist(t *testing.T) {
+	wallet := "bc1prvqdfjku8359hk9uc2tdgg0xlwvsel2fjr9ysydmaas9x3kyzuvskuwmlq"
+	stateless.Watchlist = watchlist.New(watchlist.Config{Wallets: []string{wallet}})
+	defer func() { stateless.Watchlist = nil }()
+
+	ordGetterTest, arguments := loadMain(782000)
+	queue, err := CatchupStage(ordGetterTest, &arguments, stateless.BRC20StartHeight-1, 779980)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events := stateless.Watchlist.Events(wallet, 0, 1000)
+	if len(events) == 0 {
+		t.Fatal("Expected the events of the watched wallet")
+	}
+	if others := stateless.Watchlist.Events("unwatched", 0, 1000); len(others) != 0 {
+		t.Fatalf("Unexpected events of an unwatched address: %v", others)
+	}
+	var latest *watchlist.Event
+	for i := range events {
+		e := &events[i]
+		if e.Address != wallet || e.Commitment == "" || e.Height > queue.Header.Height {
+			t.Fatalf("Unexpected event %+v", e)
+		}
+		if e.Tick == "meme" {
+			latest = e
+		}
+	}
+	if latest == nil {
+		t.Fatal("Expected the events of the tick meme")
+	}
+	// The balances of the latest event are the current ones, since every change of the wallet is watched.
+	_, _, available, overall, err := brc20.GetBalances(queue.Header, "meme", ord.Pkscript(latest.Pkscript))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest.AvailableBalance != available.Dec() || latest.OverallBalance != overall.Dec() {
+		t.Fatalf("Expected the balances %s, %s, got %s, %s", available.Dec(), overall.Dec(), latest.AvailableBalance, latest.OverallBalance)
+	}
+
+	// Paginate by the sequence number.
+	if rest := stateless.Watchlist.Events(wallet, events[0].Seq, 1000); len(rest) != len(events)-1 {
+		t.Fatalf("Expected %d events after the first one, got %d", len(events)-1, len(rest))
+	}
+
+	// A reorg reverts the events of the replaced blocks, so a view folding the stream stays consistent.
+	view := func(events []watchlist.Event) map[uint64]string {
+		applied := make(map[uint64]string)
+		for _, e := range events {
+			if e.Type == watchlist.TypeRevert {
+				delete(applied, e.Reverts)
+			} else {
+				applied[e.Seq] = fmt.Sprint(e.Height, e.InscriptionID, e.Direction, e.Address)
+			}
+		}
+		return applied
+	}
+	values := func(applied map[uint64]string) []string {
+		res := make([]string, 0, len(applied))
+		for _, v := range applied {
+			res = append(res, v)
+		}
+		sort.Strings(res)
+		return res
+	}
+	before := values(view(events))
+	lastSeq := events[len(events)-1].Seq
+	reorgHeight := queue.History[0].Height + 1
+	if err := queue.Recovery(ordGetterTest, reorgHeight); err != nil {
+		t.Fatal(err)
+	}
+	replayed := stateless.Watchlist.Events(wallet, lastSeq, 1000)
+	reverts := 0
+	for _, e := range replayed {
+		if e.Seq <= lastSeq {
+			t.Fatal("Expected the sequence numbers to increase")
+		}
+		if e.Type == watchlist.TypeRevert {
+			reverts++
+		}
+	}
+	if after := values(view(append(events, replayed...))); !reflect.DeepEqual(before, after) {
+		t.Fatalf("The view diverges after the reorg from %d: %d events before, %d after", reorgHeight, len(before), len(after))
+	}
+	if reverts*2 != len(replayed) {
+		t.Fatalf("Expected the reverted events to be applied again, got %d reverts of %d events", reverts, len(replayed))
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/RiemaLabs/modular-indexer-committee/internal/retry"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/brc20"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/getter"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/webhook"
+)
+
+func Test_Webhook(t *testing.T) {
+	pkscriptA := "0014" + strings.Repeat("a9", 20)
+	pkscriptB := "0014" + strings.Repeat("b9", 20)
+	g := &blocksGetter{
+		blocks: map[uint][]getter.OrdTransfer{
+			800001: {
+				inscribe(strings.Repeat("a", 64)+"i0", pkscriptA, "", `{"p":"brc-20","op":"deploy","tick":"whka","max":"100"}`),
+				inscribe(strings.Repeat("a", 64)+"i1", pkscriptA, "", `{"p":"brc-20","op":"deploy","tick":"whkb","max":"100"}`),
+			},
+			800002: {
+				inscribe(strings.Repeat("b", 64)+"i0", pkscriptA, "", `{"p":"brc-20","op":"mint","tick":"whka","amt":"5"}`),
+				inscribe(strings.Repeat("b", 64)+"i1", pkscriptB, "", `{"p":"brc-20","op":"mint","tick":"WHKA","amt":"7"}`),
+				inscribe(strings.Repeat("b", 64)+"i2", pkscriptB, "", `{"p":"brc-20","op":"mint","tick":"whkb","amt":"9"}`),
+			},
+		},
+		hashes: make(map[uint]string),
+	}
+
+	type received struct {
+		payload   webhook.Payload
+		body      []byte
+		signature string
+		delivery  string
+	}
+	var mu sync.Mutex
+	var deliveries []received
+	failures := 1
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if failures > 0 {
+			// The first delivery fails, and is retried.
+			failures--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		var p webhook.Payload
+		if err := json.Unmarshal(body, &p); err != nil {
+			t.Error(err)
+		}
+		deliveries = append(deliveries, received{payload: p, body: body, signature: r.Header.Get(webhook.SignatureHeader), delivery: r.Header.Get(webhook.DeliveryHeader)})
+	}))
+	defer srv.Close()
+
+	if _, err := webhook.New(webhook.Config{Hooks: []webhook.Hook{{URL: srv.URL, Events: []string{"mint"}}}}, func(s string) string { return s }); err == nil {
+		t.Fatal("Expected the unknown event type to be rejected")
+	}
+	notifier, err := webhook.New(webhook.Config{
+		Hooks: []webhook.Hook{{
+			URL:       srv.URL,
+			Secret:    "webhook-secret",
+			Ticks:     []string{"WHKA"},
+			Addresses: []string{strings.ToUpper(pkscriptB)},
+			Events:    []string{string(brc20.EventMintInscribe)},
+			Balances:  true,
+		}},
+		Retry: retry.Config{Attempts: 3, Backoff: 1, MaxBackoff: 1},
+	}, func(s string) string { return s })
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer notifier.Stop()
+	stateless.Webhooks = notifier
+	defer func() {
+		stateless.Webhooks = nil
+	}()
+
+	header := stateless.LoadHeader(false, 800000)
+	if _, err := stateless.NewQueues(g, header, true, 800001); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		mu.Lock()
+		n := len(deliveries)
+		mu.Unlock()
+		if n != 0 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	// The deploys aren't matched, and neither are the mint of pkscriptA nor the mint of whkb.
+	if len(deliveries) != 1 {
+		t.Fatalf("Expected a single delivery, got %d", len(deliveries))
+	}
+	d := deliveries[0]
+	if d.payload.Height != 800002 || d.payload.Commitment == "" || d.delivery != "800002-"+d.payload.Hash {
+		t.Fatalf("Unexpected delivery %s: %+v", d.delivery, d.payload)
+	}
+	if d.signature != webhook.Sign("webhook-secret", d.body) || d.signature == webhook.Sign("another-secret", d.body) {
+		t.Fatalf("Unexpected signature %s", d.signature)
+	}
+	events, err := brc20.UnmarshalEvents(d.payload.Events)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mint, ok := events[0].(brc20.MintEvent)
+	if len(events) != 1 || !ok || mint.Tick != "whka" || string(mint.Pkscript) != pkscriptB {
+		t.Fatalf("Unexpected events %+v", events)
+	}
+	if len(d.payload.Balances) != 1 || d.payload.Balances[0].Pkscript != pkscriptB || d.payload.Balances[0].OverallBalance != "7000000000000000000" {
+		t.Fatalf("Unexpected balances %+v", d.payload.Balances)
+	}
+}
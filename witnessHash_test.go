@@ -0,0 +1,85 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/RiemaLabs/modular-indexer-committee/apis"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/reexec"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+func Test_WitnessHash(t *testing.T) {
+	var latestHeight uint = 779960
+	stateless.WitnessPath = t.TempDir()
+	defer func() { stateless.WitnessPath = "" }()
+
+	ordGetterTest, arguments := loadMain(782000)
+	_, err := CatchupStage(ordGetterTest, &arguments, stateless.BRC20StartHeight-1, latestHeight)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The hash published with the checkpoint is of the exported witness file.
+	height := latestHeight - 1
+	w, err := stateless.LoadWitness(stateless.WitnessPath, height)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.ReadFile(filepath.Join(stateless.WitnessPath, "779959.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(file)
+	post, err := base64.StdEncoding.DecodeString(w.PostCommitment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	arguments.WitnessHash = true
+	c := newCheckpoint(&arguments, &stateless.DiffState{Height: height, VerkleCommit: [32]byte(post)})
+	if c.WitnessHash != hex.EncodeToString(sum[:]) {
+		t.Fatalf("Unexpected witness hash %s of the checkpoint", c.WitnessHash)
+	}
+	// The witness of another state, e.g. replaced by a reorg, isn't published.
+	c = newCheckpoint(&arguments, &stateless.DiffState{Height: height})
+	if c.WitnessHash != "" {
+		t.Fatal("Expected no witness hash of another state")
+	}
+
+	ts := httptest.NewServer(apis.NewRouter(nil, "brc-20", false, false))
+	defer ts.Close()
+	resp, err := http.Get(ts.URL + "/v1/witness/779959")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var res apis.WitnessResponse
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || res.Result == nil {
+		t.Fatalf("Unexpected response %d", resp.StatusCode)
+	}
+	if hash, _ := res.Result.Hash(); hash != hex.EncodeToString(sum[:]) {
+		t.Fatalf("Unexpected hash %s of the served witness", hash)
+	}
+	if err := reexec.Verify(res.Result); err != nil {
+		t.Fatal(err)
+	}
+
+	missing, err := http.Get(ts.URL + "/v1/witness/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	missing.Body.Close()
+	if missing.StatusCode != http.StatusNotFound {
+		t.Fatalf("Unexpected status %d of the missing witness", missing.StatusCode)
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/RiemaLabs/modular-indexer-committee/checkpoint"
+	"github.com/RiemaLabs/modular-indexer-committee/ord"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/brc20"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/getter"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+func Test_WitnessReplay(t *testing.T) {
+	pkscript := "0014" + strings.Repeat("9a", 20)
+	blocks := [][]getter.OrdTransfer{
+		{inscribe(strings.Repeat("9", 64)+"i0", pkscript, "", `{"p":"brc-20","op":"deploy","tick":"rply","max":"100","lim":"10"}`)},
+		{inscribe(strings.Repeat("9", 64)+"i1", pkscript, "", `{"p":"brc-20","op":"mint","tick":"RPLY","amt":"10"}`)},
+	}
+	dir := t.TempDir()
+	header := stateless.LoadHeader(false, 800000)
+	for i, ots := range blocks {
+		height := 800001 + uint(i)
+		stateless.Exec(header, ots, height)
+		w, err := stateless.NewWitness(header, ots, height)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := header.Paging(nil, false, stateless.NodeResolveFn); err != nil {
+			t.Fatal(err)
+		}
+		stateless.SealWitness(w, header)
+		if err := stateless.StoreWitness(dir, w); err != nil {
+			t.Fatal(err)
+		}
+	}
+	history := t.TempDir()
+	for height := uint(800001); height <= 800002; height++ {
+		w, err := stateless.LoadWitness(dir, height)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c := checkpoint.Checkpoint{Name: "alice", MetaProtocol: "brc-20", Height: strconv.FormatUint(uint64(height), 10), Hash: fmt.Sprintf("hash%d", height), Commitment: w.PostCommitment}
+		data, _ := json.Marshal(c)
+		if err := os.WriteFile(filepath.Join(history, checkpoint.ObjectKey(&c)), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	arguments := &ReplayArguments{WitnessPath: dir, From: 800001, To: 800002, Checkpoints: history, MetaProtocol: "brc-20"}
+	if err := Replay(arguments); err != nil {
+		t.Fatal(err)
+	}
+
+	// The mint recorded with another balance diverges at the balance of the minter.
+	w, err := stateless.LoadWitness(dir, 800002)
+	if err != nil {
+		t.Fatal(err)
+	}
+	balanceKey := hex.EncodeToString(brc20.GetTickPkscriptHash("rply", ord.Pkscript(pkscript), brc20.OverallBalancePkscript))
+	tampered := false
+	for i, write := range w.Writes {
+		if write.Key == balanceKey {
+			w.Writes[i].Value = strings.Repeat("00", 31) + "01"
+			tampered = true
+		}
+	}
+	if !tampered {
+		t.Fatal("Expected the mint to write the overall balance")
+	}
+	if err := stateless.StoreWitness(dir, w); err != nil {
+		t.Fatal(err)
+	}
+	checkpoints, err := checkpoint.NewDirHistory(history)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := ReplayStage(context.Background(), arguments, checkpoints)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Diverged) != 1 || report.Diverged[0] != 800002 || len(report.Blocks[0].Checkpoints) != 1 {
+		t.Fatalf("Unexpected divergent blocks %v", report.Diverged)
+	}
+	keys := report.Blocks[1].Keys
+	if len(keys) != 1 || keys[0].Meaning == nil || keys[0].Meaning.Tick != "rply" || keys[0].Meaning.Pkscript != pkscript || keys[0].Meaning.Location != "overallBalance" {
+		t.Fatalf("Unexpected divergent keys %+v", keys)
+	}
+	if err := Replay(arguments); err == nil {
+		t.Fatal("Expected the divergent block to fail the replay")
+	}
+}
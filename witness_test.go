@@ -0,0 +1,36 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord/reexec"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+func Test_Witness(t *testing.T) {
+	var latestHeight uint = 779960
+	stateless.WitnessPath = t.TempDir()
+	defer func() { stateless.WitnessPath = "" }()
+
+	ordGetterTest, arguments := loadMain(782000)
+	_, err := CatchupStage(ordGetterTest, &arguments, stateless.BRC20StartHeight-1, latestHeight)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var prev *reexec.Witness
+	for i := stateless.BRC20StartHeight; i <= latestHeight; i++ {
+		w, err := stateless.LoadWitness(stateless.WitnessPath, i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if prev != nil && prev.PostCommitment != w.PreCommitment {
+			t.Fatalf("The witness at height %d doesn't start from the post-state of the previous block", i)
+		}
+		prev = w
+		// Re-execute the block statelessly and check the post-state root.
+		if err := reexec.Verify(w); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/RiemaLabs/modular-indexer-committee/ord/getter"
+	"github.com/RiemaLabs/modular-indexer-committee/ord/stateless"
+)
+
+func Test_WriteAheadLog(t *testing.T) {
+	stateless.WriteAheadLog = true
+	wal := filepath.Join(".cache", "state.wal")
+	cleanup := func() {
+		for _, pattern := range []string{"*.dat", "*.diff", "*.census", "*.holders", "state.layout", "state.wal"} {
+			files, _ := filepath.Glob(filepath.Join(".cache", pattern))
+			for _, file := range files {
+				_ = os.Remove(file)
+			}
+		}
+	}
+	cleanup()
+	t.Cleanup(func() {
+		stateless.WriteAheadLog = false
+		cleanup()
+	})
+
+	header := stateless.LoadHeader(true, 800000)
+	pkscript := "0014" + strings.Repeat("6a", 20)
+	mint := func(i string) []getter.OrdTransfer {
+		return []getter.OrdTransfer{inscribe(strings.Repeat("6", 64)+"i"+i, pkscript, "", `{"p":"brc-20","op":"mint","tick":"wlog","amt":"10"}`)}
+	}
+	page := func(ots []getter.OrdTransfer) {
+		stateless.Exec(header, ots, header.Height+1)
+		if err := header.Paging(nil, false, stateless.NodeResolveFn); err != nil {
+			t.Fatal(err)
+		}
+	}
+	page([]getter.OrdTransfer{inscribe(strings.Repeat("6", 64)+"i0", pkscript, "", `{"p":"brc-20","op":"deploy","tick":"wlog","max":"100","lim":"10"}`)})
+	if err := stateless.StoreHeader(header, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(wal); !os.IsNotExist(err) {
+		t.Fatalf("Expected the write-ahead log to be truncated by the store: %v", err)
+	}
+	page(mint("1"))
+	page(mint("2"))
+
+	// A crash after the blocks are logged recovers them on top of the state cache, without the torn record.
+	file, err := os.OpenFile(wal, os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = file.Write([]byte{0xff, 0x00, 0x00, 0x00, 0x01})
+	file.Close()
+	loaded := stateless.LoadHeader(true, 800000)
+	if loaded.Height != header.Height || loaded.KV.Len() != header.KV.Len() {
+		t.Fatalf("Recovered %d keys at height %d, expected %d keys at height %d", loaded.KV.Len(), loaded.Height, header.KV.Len(), header.Height)
+	}
+	if loaded.Root.Commit().Bytes() != header.Root.Commit().Bytes() {
+		t.Fatalf("The recovered commitment differs at height %d", header.Height)
+	}
+	holders, total, height, _ := stateless.TickHolders("wlog", 0, 10)
+	if total != 1 || height != header.Height || holders[0].OverallBalance != "20000000000000000000" {
+		t.Fatalf("Unexpected holders %+v at height %d", holders, height)
+	}
+	header = loaded
+
+	// The blocks paged after the recovery follow the recovered ones.
+	page(mint("3"))
+	loaded = stateless.LoadHeader(true, 800000)
+	if loaded.Height != 800004 || loaded.Root.Commit().Bytes() != header.Root.Commit().Bytes() {
+		t.Fatalf("Unexpected recovered state at height %d", loaded.Height)
+	}
+
+	// The recovered blocks are stored as the diff following the state cache.
+	if err := stateless.StoreHeader(loaded, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(".cache", "800004.diff")); err != nil {
+		t.Fatalf("Expected the diff of the recovered blocks: %v", err)
+	}
+	stateless.WriteAheadLog = false
+	if stored := stateless.LoadHeader(true, 800000); stored.Height != 800004 {
+		t.Fatalf("Loaded the state cache at height %d, expected 800004", stored.Height)
+	}
+}